@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"flag"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 
@@ -23,6 +25,7 @@ func main() {
 	// Parse command-line flags
 	configPath := flag.String("config", "config/config.dev.yaml", "Path to configuration file")
 	runOnce := flag.String("run-once", "", "Run a specific job once and exit (e.g., 'mark-overdue-rentals', 'all-nightly', 'all-monthly')")
+	force := flag.Bool("force", false, "With -run-once perform-bill-splitting, rerun orgs already settled for the month")
 	flag.Parse()
 
 	// Load configuration
@@ -42,6 +45,7 @@ func main() {
 		logger.Error("Failed to connect to database", "error", err)
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
+	cfg.Database.ApplyPool(db)
 	defer db.Close()
 
 	// Test database connection
@@ -52,19 +56,39 @@ func main() {
 	logger.Info("Database connection established")
 
 	// Initialize Repositories
-	store := postgres.NewStore(db)
-
-	// Initialize Notification service (no FCM in cronjob — push is disabled)
-	noteSvc := service.NewNotificationService(store.NotificationRepository, store.FcmTokenRepository)
+	store := postgres.NewStoreWithOptions(db, postgres.StoreOptions{
+		TxMaxAttempts:  cfg.Database.TxMaxAttempts,
+		TxRetryBackoff: time.Duration(cfg.Database.TxRetryBackoffMs) * time.Millisecond,
+	})
+
+	// Initialize Notification service (no FCM in cronjob — push is disabled, SMS is not)
+	smsProvider, err := service.NewSMSProvider(cfg.SMS.Provider, cfg.SMS.AccountSID, cfg.SMS.AuthToken, cfg.SMS.FromNumber,
+		service.TwilioOptions{Timeout: time.Duration(cfg.SMS.TimeoutSeconds) * time.Second})
+	if err != nil {
+		log.Fatalf("Failed to initialize SMS provider: %v", err)
+	}
+	smsSvc := service.NewAsyncSMSService(smsProvider)
+	defer drainSMS(smsSvc)
+	noteSvc := service.NewNotificationServiceWithOptions(store.NotificationRepository, store.FcmTokenRepository,
+		service.NotificationOptions{DeadLetters: store.NotificationDeadLetterRepository, SMS: smsSvc, Users: store.UserRepository})
 
 	// Initialize Services
-	emailService := service.NewEmailService(
+	smtpTransport := service.NewEmailServiceWithOptions(
 		cfg.SMTP.Host,
 		fmt.Sprintf("%d", cfg.SMTP.Port),
 		cfg.SMTP.User,
 		cfg.SMTP.Password,
 		cfg.SMTP.From,
-	)
+		service.EmailOptions{
+			TLSMode:      service.TLSMode(cfg.SMTP.TLSMode),
+			Timeout:      time.Duration(cfg.SMTP.TimeoutSeconds) * time.Second,
+			MaxAttempts:  cfg.SMTP.MaxAttempts,
+			RetryBackoff: time.Duration(cfg.SMTP.RetryBackoffMs) * time.Millisecond,
+		},
+	).(service.EmailTransport)
+	emailOutbox := service.NewEmailOutbox(store.EmailOutboxRepository, smtpTransport, int32(cfg.SMTP.OutboxMaxAttempts))
+	defer drainEmailOutbox(emailOutbox)
+	emailService := service.NewOutboxEmailService(emailOutbox)
 
 	rentalService := service.NewRentalService(
 		store.RentalRepository,
@@ -73,47 +97,63 @@ func main() {
 		store.UserRepository,
 		emailService,
 		noteSvc,
+		store.RentalEventRepository,
 	)
 
 	ledgerService := service.NewLedgerService(
 		store.LedgerRepository,
+		store.UserRepository,
 	)
 
 	orgService := service.NewOrganizationService(
 		store.OrganizationRepository,
 		store.UserRepository,
 		store.InvitationRepository,
+		store.ToolRepository,
 		noteSvc,
 		nil, // cronjob does not handle threshold-update broadcasts
 		nil,
+		service.NewAdminAudit(store.AdminAuditRepository),
 	)
 
 	userService := service.NewUserService(
 		store.UserRepository,
 		store.OrganizationRepository,
+		store.RentalRepository,
+		store.BillRepository,
+		store.LedgerRepository,
+		store.NotificationRepository,
 	)
 
 	jobServices := &jobs.Services{
-		Email:  emailService,
-		Rental: rentalService,
-		Ledger: ledgerService,
-		Org:    orgService,
-		User:   userService,
+		Email:        emailService,
+		Rental:       rentalService,
+		Ledger:       ledgerService,
+		Org:          orgService,
+		User:         userService,
+		Notification: noteSvc,
+		EmailOutbox:  emailOutbox,
 	}
 
 	// Initialize Job Runner
 	jobRunner := jobs.NewJobRunner(db, store, jobServices, cfg)
+	// Replicas share Postgres advisory locks so each job run executes on one instance only
+	jobRunner.SetLocker(jobs.NewAdvisoryLocker(db))
 
 	// Check if running a single job
 	if *runOnce != "" {
 		logger.Info("Running job once", "job", *runOnce)
-		runJobOnce(jobRunner, *runOnce)
+		runJobOnce(jobRunner, *runOnce, *force)
 		logger.Info("Job execution completed", "job", *runOnce)
 		return
 	}
 
 	// Initialize Scheduler
-	cronScheduler := scheduler.NewScheduler(jobRunner)
+	cronScheduler, err := scheduler.NewScheduler(jobRunner)
+	if err != nil {
+		logger.Error("Failed to initialize scheduler", "error", err)
+		log.Fatalf("Failed to initialize scheduler: %v", err)
+	}
 
 	// Start scheduler
 	cronScheduler.Start()
@@ -131,22 +171,38 @@ func main() {
 }
 
 // runJobOnce runs a specific job once and exits
-func runJobOnce(jobRunner *jobs.JobRunner, jobName string) {
+func runJobOnce(jobRunner *jobs.JobRunner, jobName string, force bool) {
 	switch jobName {
+	case "auto-activate-rentals":
+		jobRunner.AutoActivateScheduledRentals()
 	case "mark-overdue-rentals":
 		jobRunner.MarkOverdueRentals()
+	case "expire-stale-rentals":
+		jobRunner.ExpireStalePendingRentals()
 	case "send-overdue-reminders":
 		jobRunner.SendOverdueReminders()
 	case "send-bill-reminders":
 		jobRunner.SendBillReminders()
 	case "check-overdue-bills":
 		jobRunner.CheckOverdueBills()
+	case "send-low-balance-alerts":
+		jobRunner.SendLowBalanceAlerts()
 	case "resolve-disputed-bills":
 		jobRunner.ResolveDisputedBills()
 	case "take-balance-snapshots":
 		jobRunner.TakeBalanceSnapshots()
 	case "perform-bill-splitting":
-		jobRunner.PerformBillSplitting()
+		if force {
+			jobRunner.ForcePerformBillSplitting()
+		} else {
+			jobRunner.PerformBillSplitting()
+		}
+	case "reconcile-balances":
+		jobRunner.ReconcileBalances()
+	case "retry-failed-emails":
+		jobRunner.RetryFailedEmails()
+	case "retry-failed-notifications":
+		jobRunner.RetryFailedNotifications()
 	case "all-nightly":
 		jobRunner.RunAllNightlyJobs()
 	case "all-monthly":
@@ -154,15 +210,39 @@ func runJobOnce(jobRunner *jobs.JobRunner, jobName string) {
 	default:
 		logger.Error("Unknown job name", "job", jobName)
 		fmt.Printf("Available jobs:\n")
+		fmt.Printf("  - auto-activate-rentals\n")
 		fmt.Printf("  - mark-overdue-rentals\n")
+		fmt.Printf("  - expire-stale-rentals\n")
 		fmt.Printf("  - send-overdue-reminders\n")
 		fmt.Printf("  - send-bill-reminders\n")
 		fmt.Printf("  - check-overdue-bills\n")
+		fmt.Printf("  - send-low-balance-alerts\n")
 		fmt.Printf("  - resolve-disputed-bills\n")
 		fmt.Printf("  - take-balance-snapshots\n")
 		fmt.Printf("  - perform-bill-splitting\n")
+		fmt.Printf("  - reconcile-balances\n")
+		fmt.Printf("  - retry-failed-emails\n")
+		fmt.Printf("  - retry-failed-notifications\n")
 		fmt.Printf("  - all-nightly\n")
 		fmt.Printf("  - all-monthly\n")
 		os.Exit(1)
 	}
 }
+
+// drainEmailOutbox waits for queued emails to be sent before the process exits
+func drainEmailOutbox(outbox *service.EmailOutbox) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := outbox.Shutdown(ctx); err != nil {
+		logger.Warn("Email drain timed out; undelivered emails remain in the outbox", "error", err)
+	}
+}
+
+// drainSMS waits for texts sent by the jobs before the process exits
+func drainSMS(sms *service.AsyncSMSService) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := sms.Shutdown(ctx); err != nil {
+		logger.Warn("SMS drain timed out; some texts may not have been sent", "error", err)
+	}
+}
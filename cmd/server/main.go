@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"database/sql"
 	"flag"
 	"fmt"
@@ -10,10 +9,13 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
@@ -21,11 +23,14 @@ import (
 	"ubertool-backend-trusted/internal/api/grpc/interceptor"
 	httpapi "ubertool-backend-trusted/internal/api/http"
 	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository/postgres"
 	"ubertool-backend-trusted/internal/security"
+	"ubertool-backend-trusted/internal/server"
 	"ubertool-backend-trusted/internal/service"
 	"ubertool-backend-trusted/internal/storage"
+	"ubertool-backend-trusted/internal/utils"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
@@ -56,7 +61,7 @@ func main() {
 		logger.Error("Failed to connect to database", "error", err)
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
+	cfg.Database.ApplyPool(db)
 
 	// Test database connection
 	if err := db.Ping(); err != nil {
@@ -66,7 +71,10 @@ func main() {
 	logger.Info("Database connection established")
 
 	// Initialize Repositories
-	store := postgres.NewStore(db)
+	store := postgres.NewStoreWithOptions(db, postgres.StoreOptions{
+		TxMaxAttempts:  cfg.Database.TxMaxAttempts,
+		TxRetryBackoff: time.Duration(cfg.Database.TxRetryBackoffMs) * time.Millisecond,
+	})
 
 	// Initialize Notification + Push services (created first so other services can depend on noteSvc)
 	fcmClient, fcmErr := config.InitFirebase()
@@ -74,19 +82,26 @@ func main() {
 		logger.Warn("FCM client unavailable — push notifications disabled", "error", fcmErr)
 		fcmClient = nil
 	}
-	noteSvc := service.NewNotificationService(store.NotificationRepository, store.FcmTokenRepository)
+	smsProvider, err := service.NewSMSProvider(cfg.SMS.Provider, cfg.SMS.AccountSID, cfg.SMS.AuthToken, cfg.SMS.FromNumber,
+		service.TwilioOptions{Timeout: time.Duration(cfg.SMS.TimeoutSeconds) * time.Second})
+	if err != nil {
+		log.Fatalf("Failed to initialize SMS provider: %v", err)
+	}
+	smsSvc := service.NewAsyncSMSService(smsProvider)
+	noteSvc := service.NewNotificationServiceWithOptions(store.NotificationRepository, store.FcmTokenRepository,
+		service.NotificationOptions{DeadLetters: store.NotificationDeadLetterRepository, SMS: smsSvc, Users: store.UserRepository})
 	pushSvc := service.NewPushNotificationService(fcmClient, store.FcmTokenRepository)
 	noteSvc.SetPushService(pushSvc)
 
 	// Initialize Security
-	tokenManager := security.NewTokenManager(cfg.JWT.Secret)
+	tokenManager := security.NewTokenManagerWithConfig(tokenConfig(cfg.JWT))
 	authInterceptor := interceptor.NewAuthInterceptor(tokenManager)
 
 	// Initialize Storage Service
 	var storageService storage.StorageInterface
 	if cfg.Storage.Type == "" || cfg.Storage.Type == "mock" {
 		logger.Info("Using mock storage (local filesystem)", "upload_dir", cfg.Storage.UploadDir)
-		mockStorage, err := storage.NewMockStorageService(cfg.Storage.BaseURL, cfg.Storage.UploadDir)
+		mockStorage, err := storage.NewMockStorageService(cfg.Storage.BaseURL, cfg.Storage.UploadDir, cfg.Storage.SigningSecret)
 		if err != nil {
 			logger.Error("Failed to initialize mock storage", "error", err)
 			log.Fatalf("Failed to initialize mock storage: %v", err)
@@ -103,18 +118,34 @@ func main() {
 		store.UserRepository,
 		store.OrganizationRepository,
 		storageService,
+		service.ImageStorageOptions{
+			DownloadURLExpiry: time.Duration(cfg.Storage.DownloadURLExpiry) * time.Minute,
+			ThumbnailMaxDim:   cfg.Storage.ThumbnailMaxDim,
+			AllowedTypes:      cfg.Storage.AllowedTypes,
+			MaxImageBytes:     cfg.Storage.MaxImageBytes(),
+			MaxImagesPerTool:  cfg.Storage.MaxImagesPerTool,
+		},
 	)
 
-	// Initialize Email Service (wrapped in async worker pool so SMTP never blocks gRPC handlers)
-	emailSvc := service.NewAsyncEmailService(service.NewEmailService(
+	// Initialize Email Service (persisted to the outbox and sent by workers so SMTP never blocks gRPC handlers)
+	smtpTransport := service.NewEmailServiceWithOptions(
 		cfg.SMTP.Host,
 		fmt.Sprintf("%d", cfg.SMTP.Port),
 		cfg.SMTP.User,
 		cfg.SMTP.Password,
 		cfg.SMTP.From,
-	))
+		service.EmailOptions{
+			TLSMode:      service.TLSMode(cfg.SMTP.TLSMode),
+			Timeout:      time.Duration(cfg.SMTP.TimeoutSeconds) * time.Second,
+			MaxAttempts:  cfg.SMTP.MaxAttempts,
+			RetryBackoff: time.Duration(cfg.SMTP.RetryBackoffMs) * time.Millisecond,
+		},
+	).(service.EmailTransport)
+	emailOutbox := service.NewEmailOutbox(store.EmailOutboxRepository, smtpTransport, int32(cfg.SMTP.OutboxMaxAttempts))
+	emailSvc := service.NewOutboxEmailService(emailOutbox)
 
 	// Initialize Services
+	adminAudit := service.NewAdminAudit(store.AdminAuditRepository)
 	authSvc := service.NewAuthService(
 		store.UserRepository,
 		store.InvitationRepository,
@@ -122,41 +153,59 @@ func main() {
 		store.OrganizationRepository,
 		noteSvc,
 		emailSvc,
-		cfg.JWT.Secret,
+		tokenManager,
 		store.FcmTokenRepository,
 		store.PendingCredentialsRepository,
+		store.RevokedTokenRepository,
+		authPolicy(cfg.Auth),
 	)
-	userSvc := service.NewUserService(store.UserRepository, store.OrganizationRepository)
-	orgSvc := service.NewOrganizationService(store.OrganizationRepository, store.UserRepository, store.InvitationRepository, noteSvc, emailSvc, pushSvc)
+	userSvc := service.NewUserService(store.UserRepository, store.OrganizationRepository, store.RentalRepository, store.BillRepository, store.LedgerRepository, store.NotificationRepository)
+	orgSvc := service.NewOrganizationService(store.OrganizationRepository, store.UserRepository, store.InvitationRepository, store.ToolRepository, noteSvc, emailSvc, pushSvc, adminAudit)
 	toolSvc := service.NewToolService(store.ToolRepository, store.UserRepository, store.OrganizationRepository)
-	ledgerSvc := service.NewLedgerService(store.LedgerRepository)
-	rentalSvc := service.NewRentalService(
+	ledgerSvc := service.NewLedgerService(store.LedgerRepository, store.UserRepository)
+	rentalSvc := service.NewRentalServiceWithOptions(
 		store.RentalRepository,
 		store.ToolRepository,
 		store.LedgerRepository,
 		store.UserRepository,
 		emailSvc,
 		noteSvc,
+		store.RentalEventRepository,
+		service.RentalOptions{
+			StartDateGrace:  time.Duration(cfg.Rental.StartDateGraceHours) * time.Hour,
+			Transactor:      store.Transactor(),
+			PricingRounding: utils.RoundingPolicy(cfg.Rental.PricingRounding),
+		},
 	)
-	adminSvc := service.NewAdminService(
+	adminSvc := service.NewAdminServiceWithOptions(
 		store.JoinRequestRepository,
 		store.UserRepository,
 		store.LedgerRepository,
 		store.OrganizationRepository,
 		store.InvitationRepository,
+		store.BillRepository,
 		emailSvc,
+		adminAudit,
+		service.AdminOptions{
+			MaxInvitationBatch: cfg.Admin.MaxInvitationBatch,
+			JobRuns:            store.JobRunRepository,
+			SuperAdminEmails:   cfg.Admin.SuperAdminEmails,
+		},
 	)
-	billSplitSvc := service.NewBillSplitService(
+	billSplitSvc := service.NewBillSplitServiceWithOptions(
 		store.BillRepository,
 		store.UserRepository,
+		store.LedgerRepository,
 		store.OrganizationRepository,
 		noteSvc,
 		emailSvc,
+		adminAudit,
+		service.BillSplitOptions{DefaultSettlementThresholdCents: cfg.Billing.DefaultSettlementThresholdCents},
 	)
 
 	// Initialize gRPC handlers
 	authHandler := api.NewAuthHandler(authSvc)
-	userHandler := api.NewUserHandler(userSvc)
+	userHandler := api.NewUserHandler(userSvc, rentalSvc, noteSvc, billSplitSvc)
 	orgHandler := api.NewOrganizationHandler(orgSvc)
 	toolHandler := api.NewToolHandler(toolSvc)
 	rentalHandler := api.NewRentalHandler(rentalSvc, userSvc, toolSvc, orgSvc)
@@ -173,8 +222,25 @@ func main() {
 		log.Fatalf("Failed to listen: %v", err)
 	}
 
+	rateLimitInterceptor := interceptor.NewRateLimitInterceptor(
+		interceptor.NewInMemoryRateLimiter(cfg.Security.RateLimit.RequestsPerMinute, cfg.Security.RateLimit.Burst),
+		interceptor.RateLimitedMethods,
+	)
+
+	errorInterceptor := interceptor.NewErrorInterceptor()
+
 	s := grpc.NewServer(
-		grpc.UnaryInterceptor(authInterceptor.Unary()),
+		grpc.ChainUnaryInterceptor(
+			interceptor.NewRequestIDInterceptor().Unary(),
+			rateLimitInterceptor.Unary(),
+			authInterceptor.Unary(),
+			interceptor.NewUserOrgCacheInterceptor().Unary(),
+			errorInterceptor.Unary(),
+		),
+		grpc.ChainStreamInterceptor(
+			authInterceptor.Stream(),
+			errorInterceptor.Stream(),
+		),
 	)
 
 	// Register services
@@ -189,26 +255,33 @@ func main() {
 	pb.RegisterImageStorageServiceServer(s, imageHandler)
 	pb.RegisterBillSplitServiceServer(s, billSplitHandler)
 
+	// Register the standard gRPC health service; "" reports the server as a whole
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthSrv)
+	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+
 	// Register reflection service for grpcurl
 	reflection.Register(s)
 
-	// Set up HTTP server for mock storage endpoints (if using mock storage)
+	// Set up HTTP server for health probes, plus mock storage endpoints (if using mock storage)
+	router := mux.NewRouter()
+	httpapi.RegisterHealthRoutes(router, db)
 	if cfg.Storage.Type == "" || cfg.Storage.Type == "mock" {
 		mockStorage := storageService.(*storage.MockStorageService)
-		router := mux.NewRouter()
-		httpapi.RegisterMockStorageRoutes(router, mockStorage)
-
-		// Start HTTP server in a goroutine
-		httpPort := cfg.Server.Port + 1 // Use next port for HTTP
-		httpAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, httpPort)
-		go func() {
-			logger.Info("HTTP server for mock storage listening", "address", httpAddr)
-			if err := http.ListenAndServe(httpAddr, router); err != nil {
-				logger.Error("HTTP server error", "error", err)
-			}
-		}()
+		httpapi.RegisterMockStorageRoutes(router, mockStorage, cfg.Storage.AllowedTypes)
 	}
 
+	// Start HTTP server in a goroutine
+	httpPort := cfg.Server.Port + 1 // Use next port for HTTP
+	httpAddr := fmt.Sprintf("%s:%d", cfg.Server.Host, httpPort)
+	httpSrv := &http.Server{Addr: httpAddr, Handler: router}
+	go func() {
+		logger.Info("HTTP server listening", "address", httpAddr)
+		if err := httpSrv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Error("HTTP server error", "error", err)
+		}
+	}()
+
 	logger.Info("gRPC server listening", "address", cfg.GetServerAddress())
 
 	// Run gRPC server in background; block until OS signal.
@@ -230,24 +303,46 @@ func main() {
 		logger.Info("Shutdown signal received", "signal", sig)
 	}
 
-	// Stop accepting new RPCs; wait for in-flight handlers to complete.
-	s.GracefulStop()
-	logger.Info("gRPC server stopped")
+	// Stop health checks, HTTP and gRPC, then drain background sends. FCM retries and
+	// outbox emails left undelivered at the deadline are retried later; texts are not.
+	err = server.Shutdown(server.Components{
+		GRPC:   s,
+		Health: healthSrv,
+		HTTP:   httpSrv,
+		Drainers: []server.NamedDrainer{
+			{Name: "fcm", Drainer: pushSvc},
+			{Name: "sms", Drainer: smsSvc},
+			{Name: "email", Drainer: emailOutbox},
+		},
+		DB: db,
+	}, time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
+	if err != nil {
+		logger.Warn("Shutdown finished with errors", "error", err)
+	}
+	logger.Info("Server stopped")
+}
 
-	// Drain any in-flight FCM send goroutines (including sleeping retries).
-	// Allow up to 15 seconds before giving up so critical pushes are delivered.
-	drainCtx, drainCancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer drainCancel()
-	if err := pushSvc.Shutdown(drainCtx); err != nil {
-		logger.Warn("FCM drain timed out; some in-flight pushes may be lost", "error", err)
-	} else {
-		logger.Info("FCM goroutines drained")
+// tokenConfig converts the JWT config into token signing keys and lifetimes
+func tokenConfig(cfg config.JWTConfig) security.TokenConfig {
+	return security.TokenConfig{
+		AccessSecret:  cfg.Secret,
+		RefreshSecret: cfg.RefreshSecret,
+		AccessTTL:     time.Duration(cfg.AccessTokenExpiry) * time.Minute,
+		RefreshTTL:    time.Duration(cfg.RefreshTokenExpiry) * time.Minute,
+		TwoFATTL:      time.Duration(cfg.TempTokenExpiry) * time.Minute,
+		OrgClaimsTTL:  time.Duration(cfg.OrgClaimsTTL) * time.Minute,
 	}
+}
 
-	// Drain in-flight async email sends.
-	if err := emailSvc.Shutdown(drainCtx); err != nil {
-		logger.Warn("Email drain timed out; some in-flight emails may be lost", "error", err)
-	} else {
-		logger.Info("Email goroutines drained")
+// authPolicy converts the auth config into the service's login policy
+func authPolicy(cfg config.AuthConfig) service.AuthPolicy {
+	policy := service.AuthPolicy{
+		TwoFAExempt:              service.TwoFAExemptions{Emails: cfg.TwoFAExemptEmails},
+		RequireEmailVerification: cfg.RequireEmailVerification,
+		EmailVerificationURL:     cfg.EmailVerificationURL,
+	}
+	for _, role := range cfg.TwoFAExemptRoles {
+		policy.TwoFAExempt.Roles = append(policy.TwoFAExempt.Roles, domain.UserOrgRole(strings.ToUpper(strings.TrimSpace(role))))
 	}
+	return policy
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/service"
 )
 
@@ -43,6 +44,17 @@ func (h *AdminHandler) AdminBlockUserAccount(ctx context.Context, req *pb.AdminB
 	return &pb.AdminBlockUserAccountResponse{Success: true}, nil
 }
 
+func (h *AdminHandler) UnblockMember(ctx context.Context, req *pb.UnblockMemberRequest) (*pb.VanilaResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.adminSvc.UnblockMember(ctx, adminID, req.OrganizationId, req.UserId); err != nil {
+		return nil, err
+	}
+	return &pb.VanilaResponse{Success: true}, nil
+}
+
 func (h *AdminHandler) ListMembers(ctx context.Context, req *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
 	users, uos, err := h.adminSvc.ListMembers(ctx, req.OrganizationId)
 	if err != nil {
@@ -106,6 +118,56 @@ func (h *AdminHandler) SendInvitation(ctx context.Context, req *pb.SendInvitatio
 	}, nil
 }
 
+func (h *AdminHandler) ResendInvitation(ctx context.Context, req *pb.ResendInvitationRequest) (*pb.ResendInvitationResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := h.adminSvc.ResendInvitation(ctx, adminID, req.OrganizationId, req.InvitationCode, req.Email, req.ExtendExpiry)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ResendInvitationResponse{
+		Success:   true,
+		ExpiresOn: inv.ExpiresOn,
+	}, nil
+}
+
+func (h *AdminHandler) RevokeInvitation(ctx context.Context, req *pb.RevokeInvitationRequest) (*pb.VanilaResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.adminSvc.RevokeInvitation(ctx, adminID, req.OrganizationId, req.InvitationCode, req.Email); err != nil {
+		return nil, err
+	}
+	return &pb.VanilaResponse{Success: true}, nil
+}
+
+func (h *AdminHandler) BulkCreateInvitations(ctx context.Context, req *pb.BulkCreateInvitationsRequest) (*pb.BulkCreateInvitationsResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results, err := h.adminSvc.BulkCreateInvitations(ctx, adminID, req.OrganizationId, req.Emails)
+	if err != nil {
+		return nil, err
+	}
+	res := &pb.BulkCreateInvitationsResponse{Results: make([]*pb.BulkInvitationResult, len(results))}
+	for i, r := range results {
+		res.Results[i] = MapDomainBulkInvitationResultToProto(r)
+		switch r.Status {
+		case domain.BulkInvitationStatusCreated:
+			res.CreatedCount++
+		case domain.BulkInvitationStatusSkipped:
+			res.SkippedCount++
+		default:
+			res.FailedCount++
+		}
+	}
+	return res, nil
+}
+
 func (h *AdminHandler) GetMemberProfile(ctx context.Context, req *pb.GetMemberProfileRequest) (*pb.GetMemberProfileResponse, error) {
 	user, uo, err := h.adminSvc.GetMemberProfile(ctx, req.OrganizationId, req.UserId)
 	if err != nil {
@@ -115,3 +177,114 @@ func (h *AdminHandler) GetMemberProfile(ctx context.Context, req *pb.GetMemberPr
 		Profile: MapDomainMemberProfileToProto(*user, *uo),
 	}, nil
 }
+
+func (h *AdminHandler) GetDisputeStatistics(ctx context.Context, req *pb.GetDisputeStatisticsRequest) (*pb.GetDisputeStatisticsResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := h.adminSvc.GetDisputeStatistics(ctx, adminID, req.OrganizationId, req.FromMonth, req.ToMonth)
+	if err != nil {
+		return nil, err
+	}
+	return MapDomainDisputeStatisticsToProto(stats), nil
+}
+
+func (h *AdminHandler) GetOrgBalanceSheet(ctx context.Context, req *pb.GetOrgBalanceSheetRequest) (*pb.GetOrgBalanceSheetResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sheet, err := h.adminSvc.GetOrgBalanceSheet(ctx, adminID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	return MapDomainOrgBalanceSheetToProto(sheet), nil
+}
+
+func (h *AdminHandler) ListAuditLog(ctx context.Context, req *pb.ListAuditLogRequest) (*pb.ListAuditLogResponse, error) {
+	superAdminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := domain.AdminAuditFilter{
+		AdminID:    req.AdminId,
+		Action:     domain.AdminAuditAction(req.Action),
+		TargetType: domain.AdminAuditTargetType(req.TargetType),
+		TargetID:   req.TargetId,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	entries, total, err := h.adminSvc.ListAuditLog(ctx, superAdminID, req.OrganizationId, filter)
+	if err != nil {
+		return nil, err
+	}
+	protoEntries := make([]*pb.AdminAuditEntry, len(entries))
+	for i := range entries {
+		protoEntries[i] = MapDomainAdminAuditEntryToProto(&entries[i])
+	}
+	return &pb.ListAuditLogResponse{Entries: protoEntries, TotalCount: total}, nil
+}
+
+func (h *AdminHandler) GetJobRuns(ctx context.Context, req *pb.GetJobRunsRequest) (*pb.GetJobRunsResponse, error) {
+	superAdminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := domain.JobRunFilter{
+		JobName: req.JobName,
+		Status:  domain.JobRunStatus(req.Status),
+		Limit:   req.Limit,
+	}
+	runs, err := h.adminSvc.GetJobRuns(ctx, superAdminID, req.OrganizationId, filter)
+	if err != nil {
+		return nil, err
+	}
+	protoRuns := make([]*pb.JobRun, len(runs))
+	for i := range runs {
+		protoRuns[i] = MapDomainJobRunToProto(&runs[i])
+	}
+	return &pb.GetJobRunsResponse{Runs: protoRuns}, nil
+}
+
+func (h *AdminHandler) ListAllOrganizations(ctx context.Context, req *pb.ListAllOrganizationsRequest) (*pb.ListAllOrganizationsResponse, error) {
+	superAdminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := h.adminSvc.ListAllOrganizations(ctx, superAdminID)
+	if err != nil {
+		return nil, err
+	}
+	protoOrgs := make([]*pb.Organization, len(orgs))
+	for i := range orgs {
+		protoOrgs[i] = MapDomainOrgToProto(&orgs[i], "")
+	}
+	return &pb.ListAllOrganizationsResponse{Organizations: protoOrgs}, nil
+}
+
+func (h *AdminHandler) GetAnyMemberProfile(ctx context.Context, req *pb.GetAnyMemberProfileRequest) (*pb.GetMemberProfileResponse, error) {
+	superAdminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, uo, err := h.adminSvc.GetAnyMemberProfile(ctx, superAdminID, req.OrganizationId, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetMemberProfileResponse{
+		Profile: MapDomainMemberProfileToProto(*user, *uo),
+	}, nil
+}
+
+func (h *AdminHandler) AdjustAnyBalance(ctx context.Context, req *pb.AdjustAnyBalanceRequest) (*pb.AdjustAnyBalanceResponse, error) {
+	superAdminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := h.adminSvc.AdjustAnyBalance(ctx, superAdminID, req.OrganizationId, req.UserId, req.AmountCents, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AdjustAnyBalanceResponse{BalanceCents: balance}, nil
+}
@@ -47,21 +47,34 @@ func (h *AuthHandler) ValidateInvite(ctx context.Context, req *pb.ValidateInvite
 	}, nil
 }
 
+func (h *AuthHandler) GetInvitationInfo(ctx context.Context, req *pb.GetInvitationInfoRequest) (*pb.GetInvitationInfoResponse, error) {
+	info, err := h.authSvc.GetInvitationInfo(ctx, req.InvitationCode, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetInvitationInfoResponse{
+		OrganizationName:        info.OrgName,
+		OrganizationDescription: info.OrgDescription,
+		Metro:                   info.Metro,
+		ExpiresOn:               info.ExpiresOn,
+	}, nil
+}
+
 func (h *AuthHandler) RequestToJoinOrganization(ctx context.Context, req *pb.RequestToJoinRequest) (*pb.VanilaResponse, error) {
 	logger.Info("=== API RequestToJoinOrganization called ===",
 		"organizationID", req.OrganizationId,
 		"name", req.Name,
 		"email", req.Email)
-	logger.EnterMethod("AuthHandler.RequestToJoinOrganization", "organizationID", req.OrganizationId, "email", req.Email)
+	logger.EnterMethodContext(ctx, "AuthHandler.RequestToJoinOrganization", "organizationID", req.OrganizationId, "email", req.Email)
 
 	err := h.authSvc.RequestToJoin(ctx, req.OrganizationId, req.Name, req.Email, req.Message, req.AdminEmail)
 	if err != nil {
-		logger.ExitMethodWithError("AuthHandler.RequestToJoinOrganization", err, "organizationID", req.OrganizationId, "email", req.Email)
+		logger.ExitMethodWithErrorContext(ctx, "AuthHandler.RequestToJoinOrganization", err, "organizationID", req.OrganizationId, "email", req.Email)
 		logger.Error("=== API RequestToJoinOrganization FAILED ===", "error", err)
 		return nil, err
 	}
 
-	logger.ExitMethod("AuthHandler.RequestToJoinOrganization", "organizationID", req.OrganizationId)
+	logger.ExitMethodContext(ctx, "AuthHandler.RequestToJoinOrganization", "organizationID", req.OrganizationId)
 	logger.Info("=== API RequestToJoinOrganization completed successfully ===", "organizationID", req.OrganizationId)
 	return &pb.VanilaResponse{Success: true}, nil
 }
@@ -73,31 +86,51 @@ func (h *AuthHandler) UserSignup(ctx context.Context, req *pb.SignupRequest) (*p
 	}
 	return &pb.VanilaResponse{
 		Success: true,
-		Message: "Your account has been created. Please log in.",
+		Message: "Your account has been created. Please check your email to verify your address, then log in.",
+	}, nil
+}
+
+func (h *AuthHandler) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VanilaResponse, error) {
+	if err := h.authSvc.VerifyEmail(ctx, req.Token); err != nil {
+		return nil, err
+	}
+	return &pb.VanilaResponse{
+		Success: true,
+		Message: "Your email address has been verified.",
 	}, nil
 }
 
 func (h *AuthHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
-	session, _, _, err := h.authSvc.Login(ctx, req.Email, req.Password)
+	session, access, refresh, requires2FA, _, err := h.authSvc.Login(ctx, req.Email, req.Password)
 	if err != nil {
 		return nil, err
 	}
 
+	if !requires2FA {
+		return &pb.LoginResponse{
+			Success:      true,
+			AccessToken:  access,
+			RefreshToken: refresh,
+			Message:      "Login successful",
+		}, nil
+	}
+
 	return &pb.LoginResponse{
-		Success:    true,
-		TwoFaToken: session,
-		Message:    "2FA Required",
+		Success:       true,
+		TwoFaToken:    session,
+		RequiresTwoFa: true,
+		Message:       "2FA Required",
 	}, nil
 }
 
 func (h *AuthHandler) Verify2FA(ctx context.Context, req *pb.Verify2FARequest) (*pb.Verify2FAResponse, error) {
 	logger.Info("=== API Verify2FA called ===", "codeProvided", req.TwoFaCode)
-	logger.EnterMethod("AuthHandler.Verify2FA", "codeLength", len(req.TwoFaCode))
+	logger.EnterMethodContext(ctx, "AuthHandler.Verify2FA", "codeLength", len(req.TwoFaCode))
 
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
 		logger.Error("CRITICAL: Failed to get userID from context - 2FA token missing or invalid?", "error", err)
-		logger.ExitMethodWithError("AuthHandler.Verify2FA", err, "reason", "no userID in context")
+		logger.ExitMethodWithErrorContext(ctx, "AuthHandler.Verify2FA", err, "reason", "no userID in context")
 		return nil, err
 	}
 	logger.Info("UserID extracted from 2FA token", "userID", userID)
@@ -109,12 +142,12 @@ func (h *AuthHandler) Verify2FA(ctx context.Context, req *pb.Verify2FARequest) (
 	access, refresh, user, resetPassword, err := h.authSvc.Verify2FA(ctx, int32(userID), req.TwoFaCode, tempPwd)
 	if err != nil {
 		logger.Error("=== API Verify2FA FAILED ===", "userID", userID, "error", err)
-		logger.ExitMethodWithError("AuthHandler.Verify2FA", err, "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "AuthHandler.Verify2FA", err, "userID", userID)
 		return nil, err
 	}
 
 	logger.Info("=== API Verify2FA completed successfully ===", "userID", userID, "resetPassword", resetPassword)
-	logger.ExitMethod("AuthHandler.Verify2FA", "userID", userID)
+	logger.ExitMethodContext(ctx, "AuthHandler.Verify2FA", "userID", userID)
 	return &pb.Verify2FAResponse{
 		Success:       true,
 		AccessToken:   access,
@@ -158,14 +191,14 @@ func (h *AuthHandler) ChangePassword(ctx context.Context, req *pb.ChangePassword
 }
 
 func (h *AuthHandler) ResetPassword(ctx context.Context, req *pb.ResetPasswordRequest) (*pb.VanilaResponse, error) {
-	logger.EnterMethod("AuthHandler.ResetPassword", "userEmail", req.UserEmail)
+	logger.EnterMethodContext(ctx, "AuthHandler.ResetPassword", "userEmail", req.UserEmail)
 
 	if err := h.authSvc.ResetPassword(ctx, req.UserEmail); err != nil {
-		logger.ExitMethodWithError("AuthHandler.ResetPassword", err, "userEmail", req.UserEmail)
+		logger.ExitMethodWithErrorContext(ctx, "AuthHandler.ResetPassword", err, "userEmail", req.UserEmail)
 		return nil, err
 	}
 
-	logger.ExitMethod("AuthHandler.ResetPassword", "userEmail", req.UserEmail)
+	logger.ExitMethodContext(ctx, "AuthHandler.ResetPassword", "userEmail", req.UserEmail)
 	return &pb.VanilaResponse{
 		Success: true,
 		Message: "If an account with that email exists, a temporary password has been sent.",
@@ -191,3 +224,14 @@ func (h *AuthHandler) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.Va
 	return &pb.VanilaResponse{Success: true}, nil
 }
 
+func (h *AuthHandler) RevokeAllSessions(ctx context.Context, req *pb.RevokeAllSessionsRequest) (*pb.VanilaResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "missing or invalid access token")
+	}
+
+	if err := h.authSvc.RevokeAllSessions(ctx, int32(userID)); err != nil {
+		return nil, err
+	}
+	return &pb.VanilaResponse{Success: true, Message: "All sessions have been signed out."}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/service"
 )
 
@@ -77,21 +78,39 @@ func (h *BillSplitHandler) ListPayments(ctx context.Context, req *pb.ListPayment
 		return nil, err
 	}
 
-	bills, err := h.billSplitSvc.ListPayments(ctx, userID, req.OrganizationId, req.ShowHistory)
+	page := req.GetPagination().GetPage()
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.GetPagination().GetPageSize()
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	bills, total, err := h.billSplitSvc.ListPayments(ctx, userID, req.OrganizationId, req.ShowHistory, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
 
+	names, err := h.billUserNames(ctx, bills, nil)
+	if err != nil {
+		return nil, err
+	}
 	payments := make([]*pb.PaymentItem, len(bills))
-	for i, bill := range bills {
-		payments[i], err = MapDomainBillToPaymentItem(ctx, &bill, userID, h.userSvc)
-		if err != nil {
-			return nil, err
-		}
+	for i := range bills {
+		payments[i] = MapDomainBillToPaymentItem(&bills[i], userID, names)
 	}
 
 	return &pb.ListPaymentsResponse{
 		Payments: payments,
+		Pagination: &pb.PaginationResponse{
+			TotalCount: total,
+			Page:       page,
+			PageSize:   pageSize,
+		},
 	}, nil
 }
 
@@ -101,28 +120,47 @@ func (h *BillSplitHandler) GetPaymentDetail(ctx context.Context, req *pb.GetPaym
 		return nil, err
 	}
 
-	bill, actions, canAcknowledge, err := h.billSplitSvc.GetPaymentDetail(ctx, userID, req.PaymentId)
+	page := req.GetPagination().GetPage()
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.GetPagination().GetPageSize()
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	filter := domain.BillActionFilter{Page: page, PageSize: pageSize}
+	for _, t := range req.ActionTypes {
+		filter.ActionTypes = append(filter.ActionTypes, domain.BillActionType(t))
+	}
+
+	bill, actions, totalActions, canAcknowledge, err := h.billSplitSvc.GetPaymentDetail(ctx, userID, req.PaymentId, filter)
 	if err != nil {
 		return nil, err
 	}
 
-	payment, err := MapDomainBillToPaymentItem(ctx, bill, userID, h.userSvc)
+	names, err := h.billUserNames(ctx, []domain.Bill{*bill}, actions)
 	if err != nil {
 		return nil, err
 	}
+	payment := MapDomainBillToPaymentItem(bill, userID, names)
 
 	history := make([]*pb.PaymentAction, len(actions))
-	for i, action := range actions {
-		history[i], err = MapDomainBillActionToProto(ctx, &action, h.userSvc)
-		if err != nil {
-			return nil, err
-		}
+	for i := range actions {
+		history[i] = MapDomainBillActionToProto(&actions[i], names)
 	}
 
 	return &pb.GetPaymentDetailResponse{
 		Payment:        payment,
 		History:        history,
 		CanAcknowledge: canAcknowledge,
+		HistoryPagination: &pb.PaginationResponse{
+			TotalCount: totalActions,
+			Page:       page,
+			PageSize:   pageSize,
+		},
 	}, nil
 }
 
@@ -146,6 +184,26 @@ func (h *BillSplitHandler) AcknowledgePayment(ctx context.Context, req *pb.Ackno
 	}, nil
 }
 
+func (h *BillSplitHandler) VoidBill(ctx context.Context, req *pb.VoidBillRequest) (*pb.VanilaResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = h.billSplitSvc.VoidBill(ctx, userID, req.PaymentId, req.Notes)
+	if err != nil {
+		return &pb.VanilaResponse{
+			Success: false,
+			Message: err.Error(),
+		}, nil
+	}
+
+	return &pb.VanilaResponse{
+		Success: true,
+		Message: "Void request recorded",
+	}, nil
+}
+
 func (h *BillSplitHandler) ListDisputedPayments(ctx context.Context, req *pb.ListDisputedPaymentsRequest) (*pb.ListDisputedPaymentsResponse, error) {
 	adminID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -157,12 +215,13 @@ func (h *BillSplitHandler) ListDisputedPayments(ctx context.Context, req *pb.Lis
 		return nil, err
 	}
 
+	names, err := h.billUserNames(ctx, bills, nil)
+	if err != nil {
+		return nil, err
+	}
 	disputes := make([]*pb.DisputedPaymentItem, len(bills))
-	for i, bill := range bills {
-		disputes[i], err = MapDomainBillToDisputedPaymentItem(ctx, &bill, h.userSvc)
-		if err != nil {
-			return nil, err
-		}
+	for i := range bills {
+		disputes[i] = MapDomainBillToDisputedPaymentItem(&bills[i], names)
 	}
 
 	return &pb.ListDisputedPaymentsResponse{
@@ -170,6 +229,34 @@ func (h *BillSplitHandler) ListDisputedPayments(ctx context.Context, req *pb.Lis
 	}, nil
 }
 
+func (h *BillSplitHandler) GetDisputeDetail(ctx context.Context, req *pb.GetDisputeDetailRequest) (*pb.GetDisputeDetailResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := h.billSplitSvc.GetDisputeDetail(ctx, adminID, req.PaymentId)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := h.billUserNames(ctx, []domain.Bill{detail.Bill}, detail.Actions)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*pb.PaymentAction, len(detail.Actions))
+	for i := range detail.Actions {
+		history[i] = MapDomainBillActionToProto(&detail.Actions[i], names)
+	}
+
+	return &pb.GetDisputeDetailResponse{
+		Payment:  MapDomainBillToPaymentItem(&detail.Bill, adminID, names),
+		History:  history,
+		Debtor:   MapDomainMemberProfileToProto(detail.Debtor.User, detail.Debtor.Membership),
+		Creditor: MapDomainMemberProfileToProto(detail.Creditor.User, detail.Creditor.Membership),
+	}, nil
+}
+
 func (h *BillSplitHandler) ListResolvedDisputes(ctx context.Context, req *pb.ListResolvedDisputesRequest) (*pb.ListResolvedDisputesResponse, error) {
 	adminID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -181,12 +268,13 @@ func (h *BillSplitHandler) ListResolvedDisputes(ctx context.Context, req *pb.Lis
 		return nil, err
 	}
 
+	names, err := h.billUserNames(ctx, bills, nil)
+	if err != nil {
+		return nil, err
+	}
 	disputes := make([]*pb.DisputedPaymentItem, len(bills))
-	for i, bill := range bills {
-		disputes[i], err = MapDomainBillToDisputedPaymentItem(ctx, &bill, h.userSvc)
-		if err != nil {
-			return nil, err
-		}
+	for i := range bills {
+		disputes[i] = MapDomainBillToDisputedPaymentItem(&bills[i], names)
 	}
 
 	return &pb.ListResolvedDisputesResponse{
@@ -214,3 +302,125 @@ func (h *BillSplitHandler) ResolveDispute(ctx context.Context, req *pb.ResolveDi
 		Message: "Dispute resolved successfully",
 	}, nil
 }
+
+func (h *BillSplitHandler) PreviewSettlement(ctx context.Context, req *pb.PreviewSettlementRequest) (*pb.PreviewSettlementResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := h.billSplitSvc.PreviewSettlement(ctx, adminID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int32
+	transfers := make([]*pb.ProposedTransfer, len(preview.Transfers))
+	for i, t := range preview.Transfers {
+		transfers[i] = &pb.ProposedTransfer{
+			DebtorId:     t.DebtorUserID,
+			DebtorName:   t.DebtorName,
+			CreditorId:   t.CreditorUserID,
+			CreditorName: t.CreditorName,
+			AmountCents:  t.AmountCents,
+		}
+		total += t.AmountCents
+	}
+
+	return &pb.PreviewSettlementResponse{
+		Transfers:        transfers,
+		SettlementMonth:  preview.SettlementMonth,
+		ThresholdCents:   preview.ThresholdCents,
+		TotalAmountCents: total,
+	}, nil
+}
+
+func (h *BillSplitHandler) RunSettlement(ctx context.Context, req *pb.RunSettlementRequest) (*pb.RunSettlementResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bills, err := h.billSplitSvc.RunSettlement(ctx, adminID, req.OrganizationId, req.SettlementMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := h.billUserNames(ctx, bills, nil)
+	if err != nil {
+		return nil, err
+	}
+	var total int32
+	payments := make([]*pb.PaymentItem, len(bills))
+	for i := range bills {
+		payments[i] = MapDomainBillToPaymentItem(&bills[i], adminID, names)
+		total += bills[i].AmountCents
+	}
+
+	return &pb.RunSettlementResponse{
+		Payments:         payments,
+		SettlementMonth:  req.SettlementMonth,
+		TotalAmountCents: total,
+	}, nil
+}
+
+func (h *BillSplitHandler) GetSettlementThreshold(ctx context.Context, req *pb.GetSettlementThresholdRequest) (*pb.SettlementThresholdResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := h.billSplitSvc.GetSettlementThreshold(ctx, adminID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	return mapSettlementThreshold(threshold), nil
+}
+
+func (h *BillSplitHandler) SetSettlementThreshold(ctx context.Context, req *pb.SetSettlementThresholdRequest) (*pb.SettlementThresholdResponse, error) {
+	adminID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	threshold, err := h.billSplitSvc.SetSettlementThreshold(ctx, adminID, req.OrganizationId, req.ThresholdCents)
+	if err != nil {
+		return nil, err
+	}
+	return mapSettlementThreshold(threshold), nil
+}
+
+func mapSettlementThreshold(t *domain.SettlementThreshold) *pb.SettlementThresholdResponse {
+	return &pb.SettlementThresholdResponse{
+		OrganizationId:        t.OrgID,
+		ThresholdCents:        t.ThresholdCents,
+		UsesDefault:           t.UsesDefault,
+		DefaultThresholdCents: t.DefaultCents,
+	}
+}
+
+// billUserNames resolves the names of every debtor, creditor and actor involved in bills
+// and actions with a single user lookup, keyed by user ID
+func (h *BillSplitHandler) billUserNames(ctx context.Context, bills []domain.Bill, actions []domain.BillAction) (map[int32]string, error) {
+	ids := make([]int32, 0, 2*len(bills)+len(actions))
+	for _, b := range bills {
+		ids = append(ids, b.DebtorUserID, b.CreditorUserID)
+	}
+	for _, a := range actions {
+		if a.ActorUserID != nil {
+			ids = append(ids, *a.ActorUserID)
+		}
+	}
+	if len(ids) == 0 {
+		return map[int32]string{}, nil
+	}
+	users, err := h.userSvc.GetUsersByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int32]string, len(users))
+	for id, u := range users {
+		names[id] = u.Name
+	}
+	return names, nil
+}
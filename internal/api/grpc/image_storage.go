@@ -130,3 +130,22 @@ func (h *ImageStorageHandler) SetPrimaryImage(ctx context.Context, req *pb.SetPr
 		Message: "Primary image updated successfully",
 	}, nil
 }
+
+// ReorderToolImages sets the display order of a tool's images
+func (h *ImageStorageHandler) ReorderToolImages(ctx context.Context, req *pb.ReorderToolImagesRequest) (*pb.ReorderToolImagesResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := h.storeSvc.ReorderToolImages(ctx, userID, req.ToolId, req.ImageIds)
+	if err != nil {
+		return nil, err
+	}
+
+	protoImages := make([]*pb.ToolImage, len(images))
+	for i := range images {
+		protoImages[i] = MapDomainToolImageToProto(&images[i])
+	}
+	return &pb.ReorderToolImagesResponse{Images: protoImages}, nil
+}
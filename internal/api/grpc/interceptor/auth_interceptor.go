@@ -26,66 +26,99 @@ func NewAuthInterceptor(tm security.TokenManager) *AuthInterceptor {
 // Unary returns a server interceptor function to authenticate and authorize unary RPCs
 func (i *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		level := config.GetSecurityLevel(info.FullMethod)
-
-		logger.Debug("Auth interceptor processing request", "method", info.FullMethod, "securityLevel", level)
-
-		// Public endpoint - skip auth
-		if level == config.SecurityPublic {
-			logger.Debug("Public endpoint - skipping authentication", "method", info.FullMethod)
-			return handler(ctx, req)
-		}
-
-		// Extract token from metadata
-		logger.Debug("Extracting token from metadata", "method", info.FullMethod)
-		token, err := i.extractToken(ctx)
+		newCtx, err := i.authenticate(ctx, info.FullMethod)
 		if err != nil {
-			logger.Warn("Token extraction failed", "method", info.FullMethod, "error", err)
 			return nil, err
 		}
-		logger.Debug("Token extracted", "method", info.FullMethod, "tokenPrefix", token[:min(20, len(token))])
+		return handler(newCtx, req)
+	}
+}
 
-		// Validate token
-		logger.Debug("Validating token", "method", info.FullMethod)
-		claims, err := i.tokenManager.ValidateToken(token)
+// Stream returns a server interceptor function to authenticate and authorize streaming RPCs.
+// Without it, a stream handler would trust whatever "user-id" header the client sent.
+func (i *AuthInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		newCtx, err := i.authenticate(ss.Context(), info.FullMethod)
 		if err != nil {
-			logger.Error("Token validation failed", "method", info.FullMethod, "error", err)
-			return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+			return err
 		}
-		logger.Info("Token validated successfully", "method", info.FullMethod, "userID", claims.UserID, "tokenType", claims.Type)
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: newCtx})
+	}
+}
 
-		// Check token type based on security level
-		logger.Debug("Checking security level requirements", "method", info.FullMethod, "requiredLevel", level, "tokenType", claims.Type)
-		if err := i.checkSecurityLevel(level, claims); err != nil {
-			logger.Error("Security level check failed", "method", info.FullMethod, "requiredLevel", level, "tokenType", claims.Type, "error", err)
-			return nil, err
-		}
-		logger.Debug("Security level check passed", "method", info.FullMethod)
-
-		// Inject user ID into context. We use a Copy to avoid side effects
-		// and Set to overwrite any existing "user-id" header from the client for security.
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			md = metadata.New(nil)
-		} else {
-			md = md.Copy()
-		}
+// authenticatedStream overrides the stream context with the one carrying the verified user ID
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-		md.Set("user-id", strconv.Itoa(int(claims.UserID)))
-		// For 2FA tokens, propagate the temp_pwd flag so the Verify2FA handler can
-		// determine whether the user authenticated via a temporary password.
-		if claims.Type == security.TokenType2FAPending {
-			tempPwdVal := "false"
-			if claims.TempPwd {
-				tempPwdVal = "true"
-			}
-			md.Set("temp-pwd", tempPwdVal)
-		}
-		newCtx := metadata.NewIncomingContext(ctx, md)
-		logger.Debug("User ID injected into context", "method", info.FullMethod, "userID", claims.UserID)
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
 
-		return handler(newCtx, req)
+// authenticate validates the token required by the method's security level and returns a
+// context whose metadata carries the verified user ID.
+func (i *AuthInterceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	level := config.GetSecurityLevel(method)
+
+	logger.DebugContext(ctx, "Auth interceptor processing request", "method", method, "securityLevel", level)
+
+	// Public endpoint - skip auth
+	if level == config.SecurityPublic {
+		logger.DebugContext(ctx, "Public endpoint - skipping authentication", "method", method)
+		return ctx, nil
+	}
+
+	// Extract token from metadata
+	logger.DebugContext(ctx, "Extracting token from metadata", "method", method)
+	token, err := i.extractToken(ctx)
+	if err != nil {
+		logger.WarnContext(ctx, "Token extraction failed", "method", method, "error", err)
+		return nil, err
 	}
+	logger.DebugContext(ctx, "Token extracted", "method", method, "tokenPrefix", token[:min(20, len(token))])
+
+	// Validate token
+	logger.DebugContext(ctx, "Validating token", "method", method)
+	claims, err := i.tokenManager.ValidateToken(token)
+	if err != nil {
+		logger.ErrorContext(ctx, "Token validation failed", "method", method, "error", err)
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	logger.InfoContext(ctx, "Token validated successfully", "method", method, "userID", claims.UserID, "tokenType", claims.Type)
+
+	// Check token type based on security level
+	logger.DebugContext(ctx, "Checking security level requirements", "method", method, "requiredLevel", level, "tokenType", claims.Type)
+	if err := i.checkSecurityLevel(level, claims); err != nil {
+		logger.ErrorContext(ctx, "Security level check failed", "method", method, "requiredLevel", level, "tokenType", claims.Type, "error", err)
+		return nil, err
+	}
+	logger.DebugContext(ctx, "Security level check passed", "method", method)
+
+	// Inject user ID into context. We use a Copy to avoid side effects
+	// and Set to overwrite any existing "user-id" header from the client for security.
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		md = metadata.New(nil)
+	} else {
+		md = md.Copy()
+	}
+
+	md.Set("user-id", strconv.Itoa(int(claims.UserID)))
+	// For 2FA tokens, propagate the temp_pwd flag so the Verify2FA handler can
+	// determine whether the user authenticated via a temporary password.
+	if claims.Type == security.TokenType2FAPending {
+		tempPwdVal := "false"
+		if claims.TempPwd {
+			tempPwdVal = "true"
+		}
+		md.Set("temp-pwd", tempPwdVal)
+	}
+	// Services read the token's membership snapshot from the claims to skip membership lookups
+	newCtx := security.ContextWithClaims(metadata.NewIncomingContext(ctx, md), claims)
+	logger.DebugContext(ctx, "User ID injected into context", "method", method, "userID", claims.UserID)
+
+	return newCtx, nil
 }
 
 func min(a, b int) int {
@@ -98,26 +131,26 @@ func min(a, b int) int {
 func (i *AuthInterceptor) extractToken(ctx context.Context) (string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		logger.Warn("No metadata in request")
+		logger.WarnContext(ctx, "No metadata in request")
 		return "", status.Error(codes.Unauthenticated, "metadata is not provided")
 	}
 
 	// Log all available metadata keys for debugging
-	logger.Debug("Request metadata", "keys", getAllMetadataKeys(md))
+	logger.DebugContext(ctx, "Request metadata", "keys", getAllMetadataKeys(md))
 
 	authHeader := md["authorization"]
 	if len(authHeader) == 0 {
-		logger.Warn("No authorization header found in metadata", "availableHeaders", getAllMetadataKeys(md))
+		logger.WarnContext(ctx, "No authorization header found in metadata", "availableHeaders", getAllMetadataKeys(md))
 		return "", status.Error(codes.Unauthenticated, "authorization token is not provided")
 	}
 
-	logger.Debug("Authorization header found", "headerValue", authHeader[0][:min(50, len(authHeader[0]))])
+	logger.DebugContext(ctx, "Authorization header found", "headerValue", authHeader[0][:min(50, len(authHeader[0]))])
 
 	token := authHeader[0]
 	// Remove Bearer prefix if present
 	if len(token) > 7 && strings.ToUpper(token[0:7]) == "BEARER " {
 		token = token[7:]
-		logger.Debug("Bearer prefix removed from token")
+		logger.DebugContext(ctx, "Bearer prefix removed from token")
 	}
 
 	return token, nil
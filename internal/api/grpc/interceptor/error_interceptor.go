@@ -0,0 +1,65 @@
+package interceptor
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ubertool-backend-trusted/internal/domain"
+)
+
+// errorCodes maps the domain error kinds to the status codes clients see
+var errorCodes = []struct {
+	kind error
+	code codes.Code
+}{
+	{domain.ErrUnauthorized, codes.PermissionDenied},
+	{domain.ErrNotFound, codes.NotFound},
+	{sql.ErrNoRows, codes.NotFound},
+	{domain.ErrConflict, codes.FailedPrecondition},
+	{domain.ErrValidation, codes.InvalidArgument},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+}
+
+type ErrorInterceptor struct{}
+
+func NewErrorInterceptor() *ErrorInterceptor {
+	return &ErrorInterceptor{}
+}
+
+// Unary returns a server interceptor that converts errors returned by handlers into status errors
+func (i *ErrorInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		return resp, StatusFromError(err)
+	}
+}
+
+// Stream returns the streaming counterpart of Unary
+func (i *ErrorInterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return StatusFromError(handler(srv, ss))
+	}
+}
+
+// StatusFromError returns err as a status error carrying the code of its domain kind and its
+// original message. Status errors pass through unchanged; errors of no known kind stay Unknown.
+func StatusFromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	for _, m := range errorCodes {
+		if errors.Is(err, m.kind) {
+			return status.Error(m.code, err.Error())
+		}
+	}
+	return err
+}
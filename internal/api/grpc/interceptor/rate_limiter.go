@@ -0,0 +1,91 @@
+package interceptor
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter decides whether a request identified by key may proceed. Implementations must be
+// safe for concurrent use; the in-memory limiter below can be swapped for a shared store (e.g.
+// Redis) when the server runs with more than one replica.
+type RateLimiter interface {
+	Allow(key string) bool
+}
+
+// bucket is a single token bucket; tokens refill continuously up to the burst size
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// InMemoryRateLimiter is a per-key token bucket limiter held in process memory
+type InMemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	rate      float64 // tokens per second
+	burst     float64
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewInMemoryRateLimiter allows burst requests per key at once and refills at requestsPerMinute
+func NewInMemoryRateLimiter(requestsPerMinute float64, burst int) *InMemoryRateLimiter {
+	return NewInMemoryRateLimiterWithClock(requestsPerMinute, burst, time.Now)
+}
+
+// NewInMemoryRateLimiterWithClock is NewInMemoryRateLimiter with an injectable clock for tests
+func NewInMemoryRateLimiterWithClock(requestsPerMinute float64, burst int, now func() time.Time) *InMemoryRateLimiter {
+	return &InMemoryRateLimiter{
+		buckets:   make(map[string]*bucket),
+		rate:      requestsPerMinute / 60,
+		burst:     float64(burst),
+		lastSweep: now(),
+		now:       now,
+	}
+}
+
+// Allow takes one token from the key's bucket, returning false when the bucket is empty
+func (l *InMemoryRateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = l.refill(b, now)
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *InMemoryRateLimiter) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + now.Sub(b.last).Seconds()*l.rate
+	if tokens > l.burst {
+		return l.burst
+	}
+	return tokens
+}
+
+// sweep drops buckets that have refilled completely so idle keys do not accumulate.
+// Caller must hold l.mu.
+func (l *InMemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package interceptor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// RequestIDHeader is the metadata key used to pass a correlation ID in and out of the server
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds client-supplied IDs so they cannot bloat every log line
+const maxRequestIDLength = 64
+
+type RequestIDInterceptor struct{}
+
+func NewRequestIDInterceptor() *RequestIDInterceptor {
+	return &RequestIDInterceptor{}
+}
+
+// Unary returns a server interceptor that reuses the caller's x-request-id or generates one,
+// stores it in the context for logger, and echoes it back in the response header.
+func (i *RequestIDInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		requestID := incomingRequestID(ctx)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		ctx = logger.ContextWithRequestID(ctx, requestID)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+		logger.DebugContext(ctx, "Request started", "method", info.FullMethod)
+		return handler(ctx, req)
+	}
+}
+
+func incomingRequestID(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(RequestIDHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	id := values[0]
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return ""
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return ""
+		}
+	}
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
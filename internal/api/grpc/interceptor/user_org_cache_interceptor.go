@@ -0,0 +1,24 @@
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type UserOrgCacheInterceptor struct{}
+
+func NewUserOrgCacheInterceptor() *UserOrgCacheInterceptor {
+	return &UserOrgCacheInterceptor{}
+}
+
+// Unary returns a server interceptor that gives each unary RPC its own membership cache, so
+// the repeated authorization checks of one request hit the database once per membership.
+// Streams are left uncached; they live long enough for a cached block flag to go stale.
+func (i *UserOrgCacheInterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(repository.WithUserOrgCache(ctx), req)
+	}
+}
@@ -1,12 +1,16 @@
 package grpc
 
 import (
+	"bufio"
 	"context"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
 	"ubertool-backend-trusted/internal/service"
 )
 
+// ledgerExportChunkSize is the buffered size of each ExportLedger chunk
+const ledgerExportChunkSize = 32 * 1024
+
 type LedgerHandler struct {
 	pb.UnimplementedLedgerServiceServer
 	ledgerSvc service.LedgerService
@@ -58,3 +62,47 @@ func (h *LedgerHandler) GetLedgerSummary(ctx context.Context, req *pb.GetLedgerS
 	}
 	return MapDomainLedgerSummaryToProto(summary), nil
 }
+
+func (h *LedgerHandler) GetBalanceHistory(ctx context.Context, req *pb.GetBalanceHistoryRequest) (*pb.GetBalanceHistoryResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := h.ledgerSvc.GetBalanceHistory(ctx, userID, req.OrganizationId, req.FromDate, req.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]*pb.BalancePoint, len(snapshots))
+	for i, s := range snapshots {
+		points[i] = MapDomainBalanceSnapshotToProto(&s)
+	}
+	return &pb.GetBalanceHistoryResponse{Points: points}, nil
+}
+
+func (h *LedgerHandler) ExportLedger(req *pb.ExportLedgerRequest, stream pb.LedgerService_ExportLedgerServer) error {
+	ctx := stream.Context()
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriterSize(ledgerChunkWriter{stream: stream}, ledgerExportChunkSize)
+	if err := h.ledgerSvc.ExportLedger(ctx, userID, req.UserId, req.OrganizationId, req.FromDate, req.ToDate, w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// ledgerChunkWriter sends every write as one ExportLedgerChunk
+type ledgerChunkWriter struct {
+	stream pb.LedgerService_ExportLedgerServer
+}
+
+func (c ledgerChunkWriter) Write(p []byte) (int, error) {
+	// Send may retain the message until it is serialized, so hand it a copy of the buffer
+	data := append([]byte(nil), p...)
+	if err := c.stream.Send(&pb.ExportLedgerChunk{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -1,12 +1,10 @@
 package grpc
 
 import (
-	"context"
 	"time"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
 	"ubertool-backend-trusted/internal/domain"
-	"ubertool-backend-trusted/internal/service"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -25,16 +23,30 @@ func MapDomainUserToProto(u *domain.User) *pb.User {
 	}
 
 	return &pb.User{
-		Id:        u.ID,
-		Email:     u.Email,
-		Phone:     u.PhoneNumber,
-		Name:      u.Name,
-		AvatarUrl: u.AvatarURL,
-		Orgs:      protoOrgs,
-		CreatedOn: u.CreatedOn,
+		Id:                     u.ID,
+		Email:                  u.Email,
+		Phone:                  u.PhoneNumber,
+		PhoneDisplay:           domain.FormatPhoneNumber(u.PhoneNumber),
+		Name:                   u.Name,
+		AvatarUrl:              u.AvatarURL,
+		Orgs:                   protoOrgs,
+		CreatedOn:              u.CreatedOn,
+		NotificationPreference: string(u.NotificationPreference),
 	}
 }
 
+// MapDomainUserToPublicProto is MapDomainUserToProto without contact details
+func MapDomainUserToPublicProto(u *domain.User) *pb.User {
+	p := MapDomainUserToProto(u)
+	if p != nil {
+		p.Email = ""
+		p.Phone = ""
+		p.PhoneDisplay = ""
+		p.NotificationPreference = ""
+	}
+	return p
+}
+
 // MapDomainOrgToProto converts domain Organization to protobuf Organization
 // userRole is optional - pass empty string to leave user_role field empty
 func MapDomainOrgToProto(o *domain.Organization, userRole string) *pb.Organization {
@@ -58,13 +70,18 @@ func MapDomainOrgToProto(o *domain.Organization, userRole string) *pb.Organizati
 		Address:                         o.Address,
 		Metro:                           o.Metro,
 		MemberCount:                     o.MemberCount,
+		ToolCount:                       o.ToolCount,
 		AdminEmail:                      o.AdminEmail,
 		AdminPhone:                      o.AdminPhoneNumber,
+		AdminPhoneDisplay:               domain.FormatPhoneNumber(o.AdminPhoneNumber),
 		CreatedOn:                       o.CreatedOn,
 		UserRole:                        userRole,
 		Admins:                          protoAdmins,
 		MaxBillsplitRentalCostCents:     o.MaxBillsplitRentalCostCents,
 		BillsplitSettlementThresholdCents: o.SettlementThresholdCents,
+		AutoActivateRentals:             o.AutoActivateRentals,
+		AdjacentMetros:                  o.AdjacentMetros,
+		CurrencyCode:                    o.Currency(),
 	}
 }
 
@@ -87,7 +104,7 @@ func MapDomainToolToProto(t *domain.Tool) *pb.Tool {
 		Metro:                t.Metro,
 		Status:               MapDomainToolStatusToProto(t.Status),
 		CreatedOn:            t.CreatedOn,
-		UpdatedOn:            t.CreatedOn,
+		UpdatedOn:            t.UpdatedOn,
 	}
 }
 
@@ -173,6 +190,12 @@ func MapDomainRentalToProtoWithNames(r *domain.Rental, renterName, ownerName, to
 		RejectionReason:        r.RejectionReason,
 		ChargeBillsplit:        r.ChargeBillsplit,
 	}
+	if r.LastAgreedEndDate != nil {
+		proto.LastAgreedEndDate = *r.LastAgreedEndDate
+	}
+	if r.RequestedEndDate != nil {
+		proto.RequestedEndDate = *r.RequestedEndDate
+	}
 	return proto
 }
 
@@ -198,11 +221,75 @@ func MapDomainRentalStatusToProto(s domain.RentalStatus) pb.RentalStatus {
 		return pb.RentalStatus_RENTAL_STATUS_RETURN_DATE_CHANGED
 	case domain.RentalStatusReturnDateChangeRejected:
 		return pb.RentalStatus_RENTAL_STATUS_RETURN_DATE_CHANGE_REJECTED
+	case domain.RentalStatusEarlyReturnRequested:
+		return pb.RentalStatus_RENTAL_STATUS_EARLY_RETURN_REQUESTED
 	default:
 		return pb.RentalStatus_RENTAL_STATUS_UNSPECIFIED
 	}
 }
 
+func MapDomainRentalEventToProto(e domain.RentalEvent, actorName string) *pb.RentalEvent {
+	proto := &pb.RentalEvent{
+		Id:         e.ID,
+		ActorName:  actorName,
+		FromStatus: MapDomainRentalStatusToProto(e.FromStatus),
+		ToStatus:   MapDomainRentalStatusToProto(e.ToStatus),
+		Note:       e.Note,
+		CreatedAt:  timestamppb.New(e.CreatedAt),
+	}
+	if e.ActorUserID != nil {
+		proto.ActorUserId = *e.ActorUserID
+	}
+	return proto
+}
+
+func MapDomainRentalReceiptToProto(r *domain.RentalReceipt) *pb.RentalReceipt {
+	lines := make([]*pb.ReceiptLine, len(r.Lines))
+	for i, l := range r.Lines {
+		lines[i] = &pb.ReceiptLine{
+			Unit:           string(l.Unit),
+			Quantity:       l.Quantity,
+			UnitPriceCents: l.UnitPriceCents,
+			AmountCents:    l.AmountCents,
+		}
+	}
+	return &pb.RentalReceipt{
+		RentalId:               r.RentalID,
+		OrganizationId:         r.OrgID,
+		ToolId:                 r.ToolID,
+		ToolName:               r.ToolName,
+		OwnerId:                r.OwnerID,
+		OwnerName:              r.OwnerName,
+		RenterId:               r.RenterID,
+		RenterName:             r.RenterName,
+		StartDate:              r.StartDate,
+		EndDate:                r.EndDate,
+		CompletedOn:            r.CompletedOn,
+		DurationUnit:           r.DurationUnit,
+		Lines:                  lines,
+		AdjustmentCents:        r.AdjustmentCents,
+		RentalCostCents:        r.RentalCostCents,
+		SurchargeOrCreditCents: r.SurchargeOrCreditCents,
+		TotalCents:             r.TotalCents,
+		ChargeBillsplit:        r.ChargeBillsplit,
+		ReturnCondition:        r.ReturnCondition,
+		CurrencyCode:           r.CurrencyCode,
+	}
+}
+
+func MapDomainToolAvailabilityToProto(toolID int32, ranges []domain.ToolBusyRange) *pb.ToolAvailability {
+	busy := make([]*pb.BusyRange, len(ranges))
+	for i, br := range ranges {
+		busy[i] = &pb.BusyRange{
+			RentalId:  br.RentalID,
+			StartDate: br.StartDate,
+			EndDate:   br.EndDate,
+			Status:    MapDomainRentalStatusToProto(br.Status),
+		}
+	}
+	return &pb.ToolAvailability{ToolId: toolID, BusyRanges: busy}
+}
+
 func MapProtoRentalStatusToDomain(s pb.RentalStatus) string {
 	switch s {
 	case pb.RentalStatus_RENTAL_STATUS_PENDING:
@@ -225,6 +312,8 @@ func MapProtoRentalStatusToDomain(s pb.RentalStatus) string {
 		return string(domain.RentalStatusReturnDateChanged)
 	case pb.RentalStatus_RENTAL_STATUS_RETURN_DATE_CHANGE_REJECTED:
 		return string(domain.RentalStatusReturnDateChangeRejected)
+	case pb.RentalStatus_RENTAL_STATUS_EARLY_RETURN_REQUESTED:
+		return string(domain.RentalStatusEarlyReturnRequested)
 	default:
 		return ""
 	}
@@ -257,7 +346,7 @@ func MapDomainTransactionToProto(t *domain.LedgerTransaction) *pb.Transaction {
 		ChargedOn:      t.ChargedOn,
 	}
 	if t.RelatedRentalID != nil {
-		// proto.RelatedRentalId = *t.RelatedRentalID // Error: proto expects Object, domain has ID
+		proto.RelatedRentalId = *t.RelatedRentalID
 	}
 	return proto
 }
@@ -274,6 +363,14 @@ func MapDomainTransactionTypeToProto(t domain.TransactionType) pb.TransactionTyp
 		return pb.TransactionType_TRANSACTION_TYPE_REFUND
 	case domain.TransactionTypeAdjustment:
 		return pb.TransactionType_TRANSACTION_TYPE_ADJUSTMENT
+	case domain.TransactionTypeBillSettlement:
+		return pb.TransactionType_TRANSACTION_TYPE_BILL_SETTLEMENT
+	case domain.TransactionTypeBillPenalty:
+		return pb.TransactionType_TRANSACTION_TYPE_BILL_PENALTY
+	case domain.TransactionTypeHold:
+		return pb.TransactionType_TRANSACTION_TYPE_HOLD
+	case domain.TransactionTypeHoldRelease:
+		return pb.TransactionType_TRANSACTION_TYPE_HOLD_RELEASE
 	default:
 		return pb.TransactionType_TRANSACTION_TYPE_UNSPECIFIED
 	}
@@ -315,11 +412,13 @@ func MapDomainMemberProfileToProto(u domain.User, uo domain.UserOrg) *pb.MemberP
 		IsBlocked:      uo.RentingBlocked || uo.LendingBlocked,
 		BlockReason:    uo.BlockedReason,
 		Phone:          u.PhoneNumber,
+		PhoneDisplay:   domain.FormatPhoneNumber(u.PhoneNumber),
 		Role:           string(uo.Role),
 		AvatarUrl:      u.AvatarURL,
 		RentingBlocked: uo.RentingBlocked,
 		LendingBlocked: uo.LendingBlocked,
 		Status:         string(uo.Status),
+		CurrencyCode:   uo.CurrencyCode,
 	}
 	if uo.BlockedOn != nil {
 		proto.BlockedOn = *uo.BlockedOn
@@ -355,8 +454,22 @@ func MapDomainLedgerSummaryToProto(s *domain.LedgerSummary) *pb.GetLedgerSummary
 		return &pb.GetLedgerSummaryResponse{}
 	}
 	return &pb.GetLedgerSummaryResponse{
-		Balance:     s.Balance,
-		StatusCount: s.StatusCount,
+		Balance:          s.Balance,
+		StatusCount:      s.StatusCount,
+		StoredBalance:    s.StoredBalance,
+		BalanceMismatch:  s.BalanceMismatch,
+		HeldBalance:      s.HeldBalance,
+		AvailableBalance: s.AvailableBalance,
+	}
+}
+
+func MapDomainBalanceSnapshotToProto(s *domain.BalanceSnapshot) *pb.BalancePoint {
+	if s == nil {
+		return nil
+	}
+	return &pb.BalancePoint{
+		SnapshotDate: s.SnapshotDate,
+		BalanceCents: s.BalanceCents,
 	}
 }
 
@@ -387,28 +500,9 @@ func MapDomainToolImageToProto(t *domain.ToolImage) *pb.ToolImage {
 
 // Bill Split Mappers
 
-func MapDomainBillToPaymentItem(ctx context.Context, bill *domain.Bill, userID int32, userSvc service.UserService) (*pb.PaymentItem, error) {
+func MapDomainBillToPaymentItem(bill *domain.Bill, userID int32, names map[int32]string) *pb.PaymentItem {
 	if bill == nil {
-		return nil, nil
-	}
-
-	// Get debtor and creditor names
-	debtor, _, _, err := userSvc.GetUserProfile(ctx, bill.DebtorUserID)
-	if err != nil {
-		return nil, err
-	}
-	creditor, _, _, err := userSvc.GetUserProfile(ctx, bill.CreditorUserID)
-	if err != nil {
-		return nil, err
-	}
-
-	debtorName := ""
-	creditorName := ""
-	if debtor != nil {
-		debtorName = debtor.Name
-	}
-	if creditor != nil {
-		creditorName = creditor.Name
+		return nil
 	}
 
 	category := MapDomainPaymentCategoryToProto(bill.GetPaymentCategory(userID))
@@ -416,9 +510,9 @@ func MapDomainBillToPaymentItem(ctx context.Context, bill *domain.Bill, userID i
 	payment := &pb.PaymentItem{
 		PaymentId:         bill.ID,
 		DebtorId:          bill.DebtorUserID,
-		DebtorName:        debtorName,
+		DebtorName:        names[bill.DebtorUserID],
 		CreditorId:        bill.CreditorUserID,
-		CreditorName:      creditorName,
+		CreditorName:      names[bill.CreditorUserID],
 		AmountCents:       bill.AmountCents,
 		SettlementMonth:   bill.SettlementMonth,
 		Status:            string(bill.Status),
@@ -444,20 +538,25 @@ func MapDomainBillToPaymentItem(ctx context.Context, bill *domain.Bill, userID i
 	if bill.ResolvedAt != nil {
 		payment.ResolvedAt = timestamppb.New(*bill.ResolvedAt)
 	}
+	if bill.VoidRequestedBy != nil {
+		payment.VoidRequestedBy = *bill.VoidRequestedBy
+	}
+	if bill.VoidRequestedAt != nil {
+		payment.VoidRequestedAt = timestamppb.New(*bill.VoidRequestedAt)
+	}
 
-	return payment, nil
+	return payment
 }
 
-func MapDomainBillActionToProto(ctx context.Context, action *domain.BillAction, userSvc service.UserService) (*pb.PaymentAction, error) {
+func MapDomainBillActionToProto(action *domain.BillAction, names map[int32]string) *pb.PaymentAction {
 	if action == nil {
-		return nil, nil
+		return nil
 	}
 
 	actorName := "System"
 	if action.ActorUserID != nil {
-		actor, _, _, err := userSvc.GetUserProfile(ctx, *action.ActorUserID)
-		if err == nil && actor != nil {
-			actorName = actor.Name
+		if name, ok := names[*action.ActorUserID]; ok {
+			actorName = name
 		}
 	}
 
@@ -473,39 +572,20 @@ func MapDomainBillActionToProto(ctx context.Context, action *domain.BillAction,
 		Notes:             action.Notes,
 		ActionDetailsJson: action.ActionDetails,
 		CreatedAt:         timestamppb.New(action.CreatedAt),
-	}, nil
+	}
 }
 
-func MapDomainBillToDisputedPaymentItem(ctx context.Context, bill *domain.Bill, userSvc service.UserService) (*pb.DisputedPaymentItem, error) {
+func MapDomainBillToDisputedPaymentItem(bill *domain.Bill, names map[int32]string) *pb.DisputedPaymentItem {
 	if bill == nil {
-		return nil, nil
-	}
-
-	// Get debtor and creditor names
-	debtor, _, _, err := userSvc.GetUserProfile(ctx, bill.DebtorUserID)
-	if err != nil {
-		return nil, err
-	}
-	creditor, _, _, err := userSvc.GetUserProfile(ctx, bill.CreditorUserID)
-	if err != nil {
-		return nil, err
-	}
-
-	debtorName := ""
-	creditorName := ""
-	if debtor != nil {
-		debtorName = debtor.Name
-	}
-	if creditor != nil {
-		creditorName = creditor.Name
+		return nil
 	}
 
 	item := &pb.DisputedPaymentItem{
 		PaymentId:    bill.ID,
 		DebtorId:     bill.DebtorUserID,
-		DebtorName:   debtorName,
+		DebtorName:   names[bill.DebtorUserID],
 		CreditorId:   bill.CreditorUserID,
-		CreditorName: creditorName,
+		CreditorName: names[bill.CreditorUserID],
 		AmountCents:  bill.AmountCents,
 		Reason:       bill.DisputeReason,
 		Resolution:   bill.ResolutionOutcome,
@@ -518,20 +598,20 @@ func MapDomainBillToDisputedPaymentItem(ctx context.Context, bill *domain.Bill,
 		item.ResolvedAt = timestamppb.New(*bill.ResolvedAt)
 	}
 
-	return item, nil
+	return item
 }
 
-func MapDomainPaymentCategoryToProto(category string) pb.PaymentCategory {
+func MapDomainPaymentCategoryToProto(category domain.PaymentCategory) pb.PaymentCategory {
 	switch category {
-	case "PAYMENT_TO_MAKE":
+	case domain.PaymentCategoryPaymentToMake:
 		return pb.PaymentCategory_PAYMENT_TO_MAKE
-	case "RECEIPT_TO_VERIFY":
+	case domain.PaymentCategoryReceiptToVerify:
 		return pb.PaymentCategory_RECEIPT_TO_VERIFY
-	case "PAYMENT_IN_DISPUTE":
+	case domain.PaymentCategoryPaymentInDispute:
 		return pb.PaymentCategory_PAYMENT_IN_DISPUTE
-	case "RECEIPT_IN_DISPUTE":
+	case domain.PaymentCategoryReceiptInDispute:
 		return pb.PaymentCategory_RECEIPT_IN_DISPUTE
-	case "COMPLETED":
+	case domain.PaymentCategoryCompleted:
 		return pb.PaymentCategory_COMPLETED
 	default:
 		return pb.PaymentCategory_PAYMENT_CATEGORY_UNSPECIFIED
@@ -557,3 +637,82 @@ func timeToEpochMillis(t time.Time) int64 {
 	}
 	return t.UnixNano() / 1000000
 }
+
+func MapDomainDisputeStatisticsToProto(s *domain.DisputeStatistics) *pb.GetDisputeStatisticsResponse {
+	if s == nil {
+		return &pb.GetDisputeStatisticsResponse{}
+	}
+	return &pb.GetDisputeStatisticsResponse{
+		TotalDisputes:        s.TotalDisputes,
+		CountByStatus:        s.CountByStatus,
+		CountByOutcome:       s.CountByOutcome,
+		AvgResolutionSeconds: s.AvgResolutionSeconds,
+		TotalPenalizedCents:  s.TotalPenalizedCents,
+	}
+}
+
+func MapDomainOrgBalanceSheetToProto(s *domain.OrgBalanceSheet) *pb.GetOrgBalanceSheetResponse {
+	if s == nil {
+		return &pb.GetOrgBalanceSheetResponse{}
+	}
+	byStatus := make(map[string]*pb.BillStatusTotal, len(s.BillsByStatus))
+	for status, total := range s.BillsByStatus {
+		byStatus[status] = &pb.BillStatusTotal{Count: total.Count, AmountCents: total.AmountCents}
+	}
+	pending := s.BillsByStatus[string(domain.BillStatusPending)]
+	disputed := s.BillsByStatus[string(domain.BillStatusDisputed)]
+	return &pb.GetOrgBalanceSheetResponse{
+		MemberCount:               s.MemberCount,
+		MembersInCredit:           s.MembersInCredit,
+		MembersInDebt:             s.MembersInDebt,
+		TotalPositiveBalanceCents: s.TotalPositiveBalanceCents,
+		TotalNegativeBalanceCents: s.TotalNegativeBalanceCents,
+		NetBalanceCents:           s.TotalPositiveBalanceCents + s.TotalNegativeBalanceCents,
+		OutstandingBillCount:      pending.Count,
+		OutstandingAmountCents:    pending.AmountCents,
+		DisputedBillCount:         disputed.Count,
+		DisputedAmountCents:       disputed.AmountCents,
+		BillsByStatus:             byStatus,
+	}
+}
+
+func MapDomainAdminAuditEntryToProto(e *domain.AdminAuditEntry) *pb.AdminAuditEntry {
+	if e == nil {
+		return nil
+	}
+	return &pb.AdminAuditEntry{
+		Id:             e.ID,
+		OrganizationId: e.OrgID,
+		AdminId:        e.AdminID,
+		Action:         string(e.Action),
+		TargetType:     string(e.TargetType),
+		TargetId:       e.TargetID,
+		Details:        e.Details,
+		CreatedAt:      timeToProto(&e.CreatedAt),
+		Before:         e.Before,
+		After:          e.After,
+	}
+}
+
+func MapDomainJobRunToProto(r *domain.JobRun) *pb.JobRun {
+	if r == nil {
+		return nil
+	}
+	return &pb.JobRun{
+		Id:         r.ID,
+		JobName:    r.JobName,
+		Status:     string(r.Status),
+		Error:      r.Error,
+		StartedAt:  timeToProto(&r.StartedAt),
+		FinishedAt: timeToProto(r.FinishedAt),
+	}
+}
+
+func MapDomainBulkInvitationResultToProto(r domain.BulkInvitationResult) *pb.BulkInvitationResult {
+	return &pb.BulkInvitationResult{
+		Email:          r.Email,
+		Status:         string(r.Status),
+		InvitationCode: r.InvitationCode,
+		Message:        r.Message,
+	}
+}
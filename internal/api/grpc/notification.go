@@ -32,7 +32,7 @@ func (h *NotificationHandler) GetNotifications(ctx context.Context, req *pb.GetN
 	page := (req.Offset / limit) + 1
 	pageSize := limit
 
-	notes, count, err := h.noteSvc.GetNotifications(ctx, userID, page, pageSize)
+	notes, count, err := h.noteSvc.GetNotifications(ctx, userID, req.Type, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +46,18 @@ func (h *NotificationHandler) GetNotifications(ctx context.Context, req *pb.GetN
 	}, nil
 }
 
+func (h *NotificationHandler) GetUnreadCount(ctx context.Context, req *pb.GetUnreadCountRequest) (*pb.GetUnreadCountResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count, err := h.noteSvc.UnreadCount(ctx, userID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetUnreadCountResponse{UnreadCount: count}, nil
+}
+
 func (h *NotificationHandler) MarkNotificationRead(ctx context.Context, req *pb.MarkNotificationReadRequest) (*pb.VanilaResponse, error) {
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -58,6 +70,47 @@ func (h *NotificationHandler) MarkNotificationRead(ctx context.Context, req *pb.
 	return &pb.VanilaResponse{Success: true}, nil
 }
 
+func (h *NotificationHandler) MarkAllNotificationsRead(ctx context.Context, req *pb.MarkAllNotificationsReadRequest) (*pb.MarkAllNotificationsReadResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count, err := h.noteSvc.MarkAllRead(ctx, userID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MarkAllNotificationsReadResponse{UpdatedCount: count}, nil
+}
+
+// StreamNotifications pushes the caller's new notifications until the client disconnects
+func (h *NotificationHandler) StreamNotifications(req *pb.StreamNotificationsRequest, stream pb.NotificationService_StreamNotificationsServer) error {
+	ctx := stream.Context()
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	updates, unsubscribe := h.noteSvc.Subscribe(userID)
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if req.OrganizationId > 0 && n.OrgID != req.OrganizationId {
+				continue
+			}
+			if err := stream.Send(MapDomainNotificationToProto(&n)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (h *NotificationHandler) SyncDeviceToken(ctx context.Context, req *pb.SyncTokenRequest) (*emptypb.Empty, error) {
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
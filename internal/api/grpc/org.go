@@ -75,6 +75,27 @@ func (h *OrganizationHandler) SearchOrganizations(ctx context.Context, req *pb.S
 	}
 	return &pb.ListOrganizationsResponse{Organizations: protoOrgs}, nil
 }
+
+func (h *OrganizationHandler) ListMetros(ctx context.Context, req *pb.ListMetrosRequest) (*pb.ListMetrosResponse, error) {
+	metros, err := h.orgSvc.ListMetros(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ListMetrosResponse{Metros: metros}, nil
+}
+
+func (h *OrganizationHandler) SetAdjacentMetros(ctx context.Context, req *pb.SetAdjacentMetrosRequest) (*pb.SetAdjacentMetrosResponse, error) {
+	callerID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	org, err := h.orgSvc.SetAdjacentMetros(ctx, callerID, req.OrganizationId, req.Metros)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.SetAdjacentMetrosResponse{Organization: MapDomainOrgToProto(org, "")}, nil
+}
+
 func (h *OrganizationHandler) UpdateOrganization(ctx context.Context, req *pb.UpdateOrganizationRequest) (*pb.UpdateOrganizationResponse, error) {
 	callerID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -90,6 +111,17 @@ func (h *OrganizationHandler) UpdateOrganization(ctx context.Context, req *pb.Up
 		AdminPhoneNumber:            req.AdminPhone,
 		SettlementThresholdCents:    req.BillsplitSettlementThresholdCents,
 		MaxBillsplitRentalCostCents: req.MaxBillsplitRentalCostCents,
+		CurrencyCode:                req.CurrencyCode,
+	}
+	if req.AutoActivateRentals != nil {
+		org.AutoActivateRentals = *req.AutoActivateRentals
+	} else {
+		// Unlike the thresholds, false is a meaningful value, so keep the stored policy when unset
+		current, _, err := h.orgSvc.GetOrganization(ctx, req.OrganizationId, callerID)
+		if err != nil {
+			return nil, err
+		}
+		org.AutoActivateRentals = current.AutoActivateRentals
 	}
 	err = h.orgSvc.UpdateOrganization(ctx, callerID, org)
 	if err != nil {
@@ -105,6 +137,7 @@ func (h *OrganizationHandler) CreateOrganization(ctx context.Context, req *pb.Cr
 		Metro:            req.Metro,
 		AdminEmail:       req.AdminEmail,
 		AdminPhoneNumber: req.AdminPhone,
+		CurrencyCode:     req.CurrencyCode,
 	}
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -135,3 +168,26 @@ func (h *OrganizationHandler) JoinOrganizationWithInvite(ctx context.Context, re
 		Message:      message,
 	}, nil
 }
+
+func (h *OrganizationHandler) GetOrganizationMembers(ctx context.Context, req *pb.GetOrganizationMembersRequest) (*pb.GetOrganizationMembersResponse, error) {
+	callerID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := domain.MemberFilter{
+		BlockedOnly:         req.BlockedOnly,
+		NegativeBalanceOnly: req.NegativeBalanceOnly,
+		Role:                domain.UserOrgRole(req.Role),
+		Page:                req.Page,
+		PageSize:            req.PageSize,
+	}
+	users, uos, total, err := h.orgSvc.ListMembers(ctx, callerID, req.OrganizationId, filter)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*pb.MemberProfile, len(users))
+	for i := range users {
+		members[i] = MapDomainMemberProfileToProto(users[i], uos[i])
+	}
+	return &pb.GetOrganizationMembersResponse{Members: members, TotalCount: total}, nil
+}
@@ -162,6 +162,25 @@ func (h *RentalHandler) ListMyLendings(ctx context.Context, req *pb.ListMyLendin
 	}, nil
 }
 
+func (h *RentalHandler) ListActionableRentals(ctx context.Context, req *pb.ListActionableRentalsRequest) (*pb.ListRentalsResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rentals, err := h.rentalSvc.ListActionableRentals(ctx, userID, req.OrganizationId)
+	if err != nil {
+		return nil, err
+	}
+	protoRentals := make([]*pb.RentalRequest, len(rentals))
+	for i, r := range rentals {
+		protoRentals[i] = h.populateRentalNames(ctx, &r)
+	}
+	return &pb.ListRentalsResponse{
+		Rentals:    protoRentals,
+		TotalCount: int32(len(rentals)),
+	}, nil
+}
+
 func (h *RentalHandler) GetRental(ctx context.Context, req *pb.GetRentalRequest) (*pb.GetRentalResponse, error) {
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -174,6 +193,49 @@ func (h *RentalHandler) GetRental(ctx context.Context, req *pb.GetRentalRequest)
 	}
 	return &pb.GetRentalResponse{RentalRequest: h.populateRentalNames(ctx, rt)}, nil
 }
+func (h *RentalHandler) GetRentalHistory(ctx context.Context, req *pb.GetRentalHistoryRequest) (*pb.GetRentalHistoryResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := h.rentalSvc.GetRentalHistory(ctx, userID, req.RequestId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve each actor once; job-driven transitions have no actor
+	names := make(map[int32]string)
+	protoEvents := make([]*pb.RentalEvent, len(events))
+	for i, e := range events {
+		var actorName string
+		if e.ActorUserID != nil {
+			name, ok := names[*e.ActorUserID]
+			if !ok {
+				if actor, _, _, err := h.userSvc.GetUserProfile(ctx, *e.ActorUserID); err == nil && actor != nil {
+					name = actor.Name
+				}
+				names[*e.ActorUserID] = name
+			}
+			actorName = name
+		}
+		protoEvents[i] = MapDomainRentalEventToProto(e, actorName)
+	}
+	return &pb.GetRentalHistoryResponse{Events: protoEvents}, nil
+}
+
+func (h *RentalHandler) GetRentalReceipt(ctx context.Context, req *pb.GetRentalReceiptRequest) (*pb.GetRentalReceiptResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := h.rentalSvc.GetRentalReceipt(ctx, userID, req.RequestId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetRentalReceiptResponse{Receipt: MapDomainRentalReceiptToProto(receipt)}, nil
+}
+
 func (h *RentalHandler) CancelRental(ctx context.Context, req *pb.CancelRentalRequest) (*pb.CancelRentalResponse, error) {
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -291,6 +353,42 @@ func (h *RentalHandler) CancelReturnDateChange(ctx context.Context, req *pb.Canc
 	return &pb.CancelReturnDateChangeResponse{RentalRequest: h.populateRentalNames(ctx, rt)}, nil
 }
 
+func (h *RentalHandler) AcceptEarlyReturn(ctx context.Context, req *pb.AcceptEarlyReturnRequest) (*pb.AcceptEarlyReturnResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := h.rentalSvc.AcceptEarlyReturn(ctx, userID, req.RequestId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AcceptEarlyReturnResponse{RentalRequest: h.populateRentalNames(ctx, rt)}, nil
+}
+
+func (h *RentalHandler) DeclineEarlyReturn(ctx context.Context, req *pb.DeclineEarlyReturnRequest) (*pb.DeclineEarlyReturnResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := h.rentalSvc.DeclineEarlyReturn(ctx, userID, req.RequestId, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeclineEarlyReturnResponse{RentalRequest: h.populateRentalNames(ctx, rt)}, nil
+}
+
+func (h *RentalHandler) CancelEarlyReturn(ctx context.Context, req *pb.CancelEarlyReturnRequest) (*pb.CancelEarlyReturnResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := h.rentalSvc.CancelEarlyReturn(ctx, userID, req.RequestId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CancelEarlyReturnResponse{RentalRequest: h.populateRentalNames(ctx, rt)}, nil
+}
+
 func (h *RentalHandler) ListToolRentals(ctx context.Context, req *pb.ListToolRentalsRequest) (*pb.ListRentalsResponse, error) {
 	userID, err := GetUserIDFromContext(ctx)
 	if err != nil {
@@ -306,7 +404,7 @@ func (h *RentalHandler) ListToolRentals(ctx context.Context, req *pb.ListToolRen
 		pageSize = 10
 	}
 
-	rentals, count, err := h.rentalSvc.ListToolRentals(ctx, userID, req.ToolId, req.OrganizationId, statuses, page, pageSize)
+	rentals, count, err := h.rentalSvc.ListToolRentals(ctx, userID, req.ToolId, req.OrganizationId, statuses, req.FromDate, req.ToDate, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -317,3 +415,27 @@ func (h *RentalHandler) ListToolRentals(ctx context.Context, req *pb.ListToolRen
 	}
 	return &pb.ListRentalsResponse{Rentals: protoRentals, TotalCount: count}, nil
 }
+
+func (h *RentalHandler) GetBatchAvailability(ctx context.Context, req *pb.GetBatchAvailabilityRequest) (*pb.GetBatchAvailabilityResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	availability, err := h.rentalSvc.GetBatchAvailability(ctx, userID, req.ToolIds, req.FromDate, req.ToDate)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]*pb.ToolAvailability, 0, len(req.ToolIds))
+	seen := make(map[int32]bool, len(req.ToolIds))
+	for _, toolID := range req.ToolIds {
+		ranges, ok := availability[toolID]
+		if !ok || seen[toolID] {
+			continue // tools outside the caller's orgs are left out
+		}
+		seen[toolID] = true
+		tools = append(tools, MapDomainToolAvailabilityToProto(toolID, ranges))
+	}
+	return &pb.GetBatchAvailabilityResponse{Tools: tools}, nil
+}
@@ -57,6 +57,29 @@ func (h *ToolHandler) GetTool(ctx context.Context, req *pb.GetToolRequest) (*pb.
 	}, nil
 }
 
+func (h *ToolHandler) GetToolDetail(ctx context.Context, req *pb.GetToolDetailRequest) (*pb.GetToolDetailResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tool, images, err := h.toolSvc.GetTool(ctx, req.ToolId, userID)
+	if err != nil {
+		return nil, err
+	}
+	protoImages := make([]*pb.ToolImage, len(images))
+	for i := range images {
+		protoImages[i] = MapDomainToolImageToProto(&images[i])
+	}
+	owner := MapDomainUserToPublicProto(tool.Owner)
+	protoTool := MapDomainToolToProto(tool)
+	protoTool.Owner = owner
+	return &pb.GetToolDetailResponse{
+		Tool:   protoTool,
+		Images: protoImages,
+		Owner:  owner,
+	}, nil
+}
+
 func (h *ToolHandler) UpdateTool(ctx context.Context, req *pb.UpdateToolRequest) (*pb.UpdateToolResponse, error) {
 	tool := &domain.Tool{
 		ID:                   req.ToolId,
@@ -137,7 +160,7 @@ func (h *ToolHandler) SearchTools(ctx context.Context, req *pb.SearchToolsReques
 	if req.Condition != pb.ToolCondition_TOOL_CONDITION_UNSPECIFIED {
 		conditionFilter = string(MapProtoToolConditionToDomain(req.Condition))
 	}
-	tools, count, err := h.toolSvc.SearchTools(ctx, userID, req.OrganizationId, req.Metro, req.Query, req.Categories, req.MaxPrice, conditionFilter, page, pageSize)
+	tools, count, err := h.toolSvc.SearchTools(ctx, userID, req.OrganizationId, req.Metro, req.IncludeAdjacentMetros, req.Query, req.Categories, req.MaxPrice, conditionFilter, page, pageSize)
 	if err != nil {
 		return nil, err
 	}
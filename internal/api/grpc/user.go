@@ -4,16 +4,25 @@ import (
 	"context"
 
 	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/service"
 )
 
 type UserHandler struct {
 	pb.UnimplementedUserServiceServer
-	userSvc service.UserService
+	userSvc      service.UserService
+	rentalSvc    service.RentalService
+	noteSvc      service.NotificationService
+	billSplitSvc service.BillSplitService
 }
 
-func NewUserHandler(userSvc service.UserService) *UserHandler {
-	return &UserHandler{userSvc: userSvc}
+func NewUserHandler(userSvc service.UserService, rentalSvc service.RentalService, noteSvc service.NotificationService, billSplitSvc service.BillSplitService) *UserHandler {
+	return &UserHandler{
+		userSvc:      userSvc,
+		rentalSvc:    rentalSvc,
+		noteSvc:      noteSvc,
+		billSplitSvc: billSplitSvc,
+	}
 }
 
 func (h *UserHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
@@ -66,3 +75,64 @@ func (h *UserHandler) UpdateProfile(ctx context.Context, req *pb.UpdateProfileRe
 	}
 	return &pb.UpdateProfileResponse{User: MapDomainUserToProto(user)}, nil
 }
+
+func (h *UserHandler) UpdateNotificationPreference(ctx context.Context, req *pb.UpdateNotificationPreferenceRequest) (*pb.UpdateNotificationPreferenceResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.userSvc.UpdateNotificationPreference(ctx, userID, domain.NotificationPreference(req.NotificationPreference)); err != nil {
+		return nil, err
+	}
+	user, _, _, err := h.userSvc.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateNotificationPreferenceResponse{User: MapDomainUserToProto(user)}, nil
+}
+
+func (h *UserHandler) ExportMyData(ctx context.Context, req *pb.ExportMyDataRequest) (*pb.ExportMyDataResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := h.userSvc.ExportMyData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ExportMyDataResponse{Data: data, ContentType: "application/json"}, nil
+}
+
+// GetDashboard assembles the home screen counts from the rental, notification and bill split services
+func (h *UserHandler) GetDashboard(ctx context.Context, req *pb.GetDashboardRequest) (*pb.GetDashboardResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, err := h.rentalSvc.GetActivityCounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := h.noteSvc.UnreadCount(ctx, userID, 0)
+	if err != nil {
+		return nil, err
+	}
+	paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute, err := h.billSplitSvc.GetGlobalBillSplitSummary(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetDashboardResponse{
+		ActiveRentalsCount:        activity.ActiveRentals,
+		ActiveLendingsCount:       activity.ActiveLendings,
+		PendingRentalActionsCount: activity.PendingActions,
+		UnreadNotificationsCount:  unread,
+		BillSplitSummary: &pb.BillSplitSummary{
+			PaymentsToMake:    paymentsToMake,
+			ReceiptsToVerify:  receiptsToVerify,
+			PaymentsInDispute: paymentsInDispute,
+			ReceiptsInDispute: receiptsInDispute,
+		},
+	}, nil
+}
@@ -0,0 +1,59 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"ubertool-backend-trusted/internal/logger"
+
+	"github.com/gorilla/mux"
+)
+
+// readinessTimeout bounds the database ping made by /readyz
+const readinessTimeout = 2 * time.Second
+
+// Pinger checks that a dependency is reachable; *sql.DB satisfies it
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthHandler serves liveness and readiness probes
+type HealthHandler struct {
+	db Pinger
+}
+
+// NewHealthHandler creates a new health handler
+func NewHealthHandler(db Pinger) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+// HandleHealth reports that the process is up; it never touches dependencies
+func (h *HealthHandler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// HandleReady reports whether the server can take traffic, i.e. the database answers a ping
+func (h *HealthHandler) HandleReady(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := h.db.PingContext(ctx); err != nil {
+		logger.Warn("Readiness check failed", "error", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("database unavailable\n"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok\n"))
+}
+
+// RegisterHealthRoutes registers /healthz and /readyz
+func RegisterHealthRoutes(router *mux.Router, db Pinger) {
+	handler := NewHealthHandler(db)
+	router.HandleFunc("/healthz", handler.HandleHealth).Methods("GET", "HEAD")
+	router.HandleFunc("/readyz", handler.HandleReady).Methods("GET", "HEAD")
+}
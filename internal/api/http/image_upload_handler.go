@@ -1,9 +1,15 @@
 package http
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"ubertool-backend-trusted/internal/storage"
 
@@ -12,16 +18,29 @@ import (
 
 // ImageUploadHandler handles HTTP uploads for mock storage
 type ImageUploadHandler struct {
-	mockStorage *storage.MockStorageService
+	mockStorage  *storage.MockStorageService
+	allowedTypes []string // storage.allowed_types; uploads and downloads are limited to these
 }
 
-// NewImageUploadHandler creates a new upload handler
-func NewImageUploadHandler(mockStorage *storage.MockStorageService) *ImageUploadHandler {
+// NewImageUploadHandler creates a new upload handler accepting the given MIME types
+func NewImageUploadHandler(mockStorage *storage.MockStorageService, allowedTypes []string) *ImageUploadHandler {
 	return &ImageUploadHandler{
-		mockStorage: mockStorage,
+		mockStorage:  mockStorage,
+		allowedTypes: allowedTypes,
 	}
 }
 
+// isAllowedType reports whether contentType is in the configured allowlist
+func (h *ImageUploadHandler) isAllowedType(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	for _, allowed := range h.allowedTypes {
+		if ct == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
 // HandleMockUpload handles HTTP PUT requests to mock presigned URLs
 func (h *ImageUploadHandler) HandleMockUpload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
@@ -37,8 +56,7 @@ func (h *ImageUploadHandler) HandleMockUpload(w http.ResponseWriter, r *http.Req
 	}
 
 	// Validate content type
-	contentType := r.Header.Get("Content-Type")
-	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
+	if !h.isAllowedType(r.Header.Get("Content-Type")) {
 		http.Error(w, "Invalid content type", http.StatusBadRequest)
 		return
 	}
@@ -69,6 +87,17 @@ func (h *ImageUploadHandler) HandleMockDownload(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// Validate signature and expiry
+	query := r.URL.Query()
+	if err := h.mockStorage.VerifyDownloadSignature(key, query.Get("expires"), query.Get("signature")); err != nil {
+		if errors.Is(err, storage.ErrURLExpired) {
+			http.Error(w, "Download URL expired", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+
 	// Read file
 	file, err := h.mockStorage.ReadFile(key)
 	if err != nil {
@@ -77,29 +106,72 @@ func (h *ImageUploadHandler) HandleMockDownload(w http.ResponseWriter, r *http.R
 	}
 	defer file.Close()
 
-	// Determine content type from file extension
-	ext := filepath.Ext(key)
-	contentType := "application/octet-stream"
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".png":
-		contentType = "image/png"
-	case ".gif":
-		contentType = "image/gif"
+	// Determine content type from file extension; anything outside the allowlist is served as opaque bytes
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(key)))
+	if !h.isAllowedType(contentType) {
+		contentType = "application/octet-stream"
 	}
 
 	// Set headers
 	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", cacheMaxAge(query.Get("expires"))))
 
 	// Stream file
 	io.Copy(w, file)
 }
 
-// RegisterMockStorageRoutes registers the mock storage HTTP endpoints
-func RegisterMockStorageRoutes(router *mux.Router, mockStorage *storage.MockStorageService) {
-	handler := NewImageUploadHandler(mockStorage)
+// HandleMockDelete handles HTTP DELETE requests to signed delete URLs
+func (h *ImageUploadHandler) HandleMockDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Get storage key from query parameter
+	key := r.URL.Query().Get("key")
+	if key == "" {
+		http.Error(w, "Missing key parameter", http.StatusBadRequest)
+		return
+	}
+
+	// Validate signature and expiry
+	query := r.URL.Query()
+	if err := h.mockStorage.VerifyDeleteSignature(key, query.Get("expires"), query.Get("signature")); err != nil {
+		if errors.Is(err, storage.ErrURLExpired) {
+			http.Error(w, "Delete URL expired", http.StatusForbidden)
+			return
+		}
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+
+	// Delete file; a missing file is treated as already deleted
+	if err := h.mockStorage.DeleteFile(r.Context(), key); err != nil {
+		if errors.Is(err, storage.ErrInvalidKey) {
+			http.Error(w, "Invalid key", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to delete file", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// cacheMaxAge limits client caching to the remaining lifetime of the signed URL
+func cacheMaxAge(expires string) int64 {
+	expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+	remaining := expiresAt - time.Now().Unix()
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RegisterMockStorageRoutes registers the mock storage HTTP endpoints for the allowed MIME types
+func RegisterMockStorageRoutes(router *mux.Router, mockStorage *storage.MockStorageService, allowedTypes []string) {
+	handler := NewImageUploadHandler(mockStorage, allowedTypes)
 	router.HandleFunc("/api/v1/upload/{token}", handler.HandleMockUpload).Methods("PUT")
 	router.HandleFunc("/api/v1/download/{key}", handler.HandleMockDownload).Methods("GET")
+	router.HandleFunc("/api/v1/files/{key}", handler.HandleMockDelete).Methods("DELETE")
 }
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -12,16 +17,23 @@ type Config struct {
 	Server    ServerConfig    `yaml:"server"`
 	Database  DatabaseConfig  `yaml:"database"`
 	SMTP      SMTPConfig      `yaml:"smtp"`
+	SMS       SMSConfig       `yaml:"sms"`
 	JWT       JWTConfig       `yaml:"jwt"`
+	Auth      AuthConfig      `yaml:"auth"`
 	Storage   StorageConfig   `yaml:"storage"`
 	Log       LogConfig       `yaml:"log"`
 	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Billing   BillingConfig   `yaml:"billing"`
+	Security  SecurityConfig  `yaml:"security"`
+	Admin     AdminConfig     `yaml:"admin"`
+	Rental    RentalConfig    `yaml:"rental"`
 }
 
 // ServerConfig contains gRPC server settings
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host                   string `yaml:"host"`
+	Port                   int    `yaml:"port"`
+	ShutdownTimeoutSeconds int    `yaml:"shutdown_timeout_seconds"` // drain deadline after SIGTERM
 }
 
 // DatabaseConfig contains PostgreSQL connection settings
@@ -32,6 +44,22 @@ type DatabaseConfig struct {
 	Password string `yaml:"password"`
 	Database string `yaml:"database"`
 	SSLMode  string `yaml:"ssl_mode"`
+
+	// Connection pool, applied with ApplyPool
+	MaxOpenConns           int `yaml:"max_open_conns"`
+	MaxIdleConns           int `yaml:"max_idle_conns"`
+	ConnMaxLifetimeMinutes int `yaml:"conn_max_lifetime_minutes"`
+
+	// Retries of transactions aborted by a serialization failure or deadlock
+	TxMaxAttempts    int `yaml:"tx_max_attempts"`
+	TxRetryBackoffMs int `yaml:"tx_retry_backoff_ms"`
+}
+
+// ApplyPool sets the connection pool limits on db
+func (c DatabaseConfig) ApplyPool(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(c.ConnMaxLifetimeMinutes) * time.Minute)
 }
 
 // SMTPConfig contains email service settings
@@ -41,14 +69,42 @@ type SMTPConfig struct {
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
 	From     string `yaml:"from"`
+
+	TLSMode        string `yaml:"tls_mode"`         // starttls, implicit or none; empty infers from port
+	TimeoutSeconds int    `yaml:"timeout_seconds"`  // Dial timeout and per-send I/O deadline
+	MaxAttempts    int    `yaml:"max_attempts"`     // Tries per message, including the first
+	RetryBackoffMs int    `yaml:"retry_backoff_ms"` // Delay before the first retry, doubled each time
+
+	OutboxMaxAttempts int `yaml:"outbox_max_attempts"` // Delivery attempts per outbox entry before it stays FAILED
+}
+
+// SMSConfig contains text message settings. Only users who opt in are texted, and only for
+// high-priority notifications.
+type SMSConfig struct {
+	Provider       string `yaml:"provider"`        // "noop" (log only) or "twilio"; empty is noop
+	AccountSID     string `yaml:"account_sid"`     // Twilio account
+	AuthToken      string `yaml:"auth_token"`      // Twilio auth token
+	FromNumber     string `yaml:"from_number"`     // Sending number in E.164 form
+	TimeoutSeconds int    `yaml:"timeout_seconds"` // Per-request deadline
 }
 
 // JWTConfig contains JWT token settings
 type JWTConfig struct {
 	Secret             string `yaml:"secret"`
+	RefreshSecret      string `yaml:"refresh_secret"` // Signs refresh tokens only (defaults to secret)
 	AccessTokenExpiry  int    `yaml:"access_token_expiry_minutes"`
 	RefreshTokenExpiry int    `yaml:"refresh_token_expiry_minutes"`
-	TempTokenExpiry    int    `yaml:"temp_token_expiry_minutes"`
+	TempTokenExpiry    int    `yaml:"temp_token_expiry_minutes"` // Lifetime of the 2FA pending token
+	OrgClaimsTTL       int    `yaml:"org_claims_ttl_minutes"`    // How long an access token's membership snapshot is trusted
+}
+
+// AuthConfig contains login policy settings
+type AuthConfig struct {
+	TwoFAExemptRoles  []string `yaml:"two_fa_exempt_roles"`  // Org roles (e.g. SUPER_ADMIN) that log in without 2FA
+	TwoFAExemptEmails []string `yaml:"two_fa_exempt_emails"` // Service accounts that log in without 2FA
+	// Reject logins until the user opens the emailed verification link
+	RequireEmailVerification bool   `yaml:"require_email_verification"`
+	EmailVerificationURL     string `yaml:"email_verification_url"` // Link base; ?token=... is appended
 }
 
 // StorageConfig contains file storage settings
@@ -58,6 +114,60 @@ type StorageConfig struct {
 	BaseURL      string   `yaml:"base_url"`   // Server base URL for mock URLs
 	MaxFileSize  int64    `yaml:"max_file_size_mb"`
 	AllowedTypes []string `yaml:"allowed_types"`
+
+	MaxImagesPerTool int `yaml:"max_images_per_tool"` // Confirmed images a tool may hold
+
+	SigningSecret     string `yaml:"signing_secret"`              // HMAC key for signed download URLs; required outside mock storage
+	DownloadURLExpiry int    `yaml:"download_url_expiry_minutes"` // Lifetime of signed download URLs
+	ThumbnailMaxDim   int    `yaml:"thumbnail_max_dimension"`     // Longest side of generated thumbnails, in pixels
+}
+
+// MaxImageBytes is the largest accepted image upload, from max_file_size_mb
+func (c StorageConfig) MaxImageBytes() int64 {
+	return c.MaxFileSize << 20
+}
+
+// BillingConfig contains ledger and bill settlement settings
+type BillingConfig struct {
+	AutoReconcile                   bool  `yaml:"auto_reconcile"`                     // Overwrite drifted balances with the ledger sum
+	DriftAlertThresholdCents        int32 `yaml:"drift_alert_threshold_cents"`        // Notify org admins when drift exceeds this amount
+	DefaultSettlementThresholdCents int32 `yaml:"default_settlement_threshold_cents"` // Settlement threshold for orgs without their own
+	DebtorAckGraceDays              int   `yaml:"debtor_ack_grace_days"`              // Days after the notice before an unpaid bill is disputed
+	CreditorAckGraceDays            int   `yaml:"creditor_ack_grace_days"`            // Days after the debtor's ack before an unconfirmed bill is disputed
+	EscalateOverdueBillsToAdmins    bool  `yaml:"escalate_overdue_bills_to_admins"`   // Notify org admins of automatically opened disputes
+	LowBalanceAlertCents            int32 `yaml:"low_balance_alert_cents"`            // Alert members once they owe this much
+}
+
+// AdminConfig contains org administration limits
+type AdminConfig struct {
+	MaxInvitationBatch int      `yaml:"max_invitation_batch"` // Emails accepted by one BulkCreateInvitations call
+	SuperAdminEmails   []string `yaml:"super_admin_emails"`   // Platform operators allowed to call the cross-org admin RPCs
+}
+
+// RentalConfig contains rental lifecycle settings
+type RentalConfig struct {
+	RequestExpiryHours  int `yaml:"request_expiry_hours"`   // PENDING requests older than this are expired by the cronjob
+	StartDateGraceHours int `yaml:"start_date_grace_hours"` // Hours past UTC midnight a start date of "yesterday" is still accepted
+	// PricingRounding is how a partial week or month is charged for week- and month-unit tools:
+	// round_up, nearest or prorate. Captured on each rental when it is requested.
+	PricingRounding string `yaml:"pricing_rounding"`
+
+	// Days past end_date at which each overdue reminder level is sent
+	OverdueReminderDays     int `yaml:"overdue_reminder_days"`
+	OverdueSecondNoticeDays int `yaml:"overdue_second_notice_days"`
+	OverdueFinalNoticeDays  int `yaml:"overdue_final_notice_days"` // also flags the rental to org admins
+}
+
+// SecurityConfig contains transport-level protections
+type SecurityConfig struct {
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+}
+
+// RateLimitConfig configures the token buckets guarding Login, Verify2FA and RequestToJoinOrganization.
+// Buckets are kept per client IP and per email.
+type RateLimitConfig struct {
+	RequestsPerMinute float64 `yaml:"requests_per_minute"` // Refill rate of each bucket
+	Burst             int     `yaml:"burst"`               // Attempts allowed back-to-back before throttling
 }
 
 // LogConfig contains logging settings
@@ -129,11 +239,31 @@ func (c *Config) overrideWithEnv() {
 	if val := os.Getenv("SMTP_FROM"); val != "" {
 		c.SMTP.From = val
 	}
+	if val := os.Getenv("SMTP_TLS_MODE"); val != "" {
+		c.SMTP.TLSMode = val
+	}
+
+	// SMS
+	if val := os.Getenv("SMS_PROVIDER"); val != "" {
+		c.SMS.Provider = val
+	}
+	if val := os.Getenv("SMS_ACCOUNT_SID"); val != "" {
+		c.SMS.AccountSID = val
+	}
+	if val := os.Getenv("SMS_AUTH_TOKEN"); val != "" {
+		c.SMS.AuthToken = val
+	}
+	if val := os.Getenv("SMS_FROM_NUMBER"); val != "" {
+		c.SMS.FromNumber = val
+	}
 
 	// JWT
 	if val := os.Getenv("JWT_SECRET"); val != "" {
 		c.JWT.Secret = val
 	}
+	if val := os.Getenv("JWT_REFRESH_SECRET"); val != "" {
+		c.JWT.RefreshSecret = val
+	}
 
 	// Server
 	if val := os.Getenv("SERVER_HOST"); val != "" {
@@ -147,6 +277,9 @@ func (c *Config) overrideWithEnv() {
 	if val := os.Getenv("UPLOAD_DIR"); val != "" {
 		c.Storage.UploadDir = val
 	}
+	if val := os.Getenv("STORAGE_SIGNING_SECRET"); val != "" {
+		c.Storage.SigningSecret = val
+	}
 
 	// Log
 	if val := os.Getenv("LOG_LEVEL"); val != "" {
@@ -171,6 +304,9 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if c.Server.ShutdownTimeoutSeconds <= 0 {
+		c.Server.ShutdownTimeoutSeconds = 30
+	}
 
 	// Database validation
 	if c.Database.Host == "" {
@@ -182,6 +318,24 @@ func (c *Config) Validate() error {
 	if c.Database.Database == "" {
 		return fmt.Errorf("database name is required")
 	}
+	if c.Database.MaxOpenConns <= 0 {
+		c.Database.MaxOpenConns = 25
+	}
+	if c.Database.MaxIdleConns <= 0 {
+		c.Database.MaxIdleConns = 10
+	}
+	if c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		c.Database.MaxIdleConns = c.Database.MaxOpenConns
+	}
+	if c.Database.ConnMaxLifetimeMinutes <= 0 {
+		c.Database.ConnMaxLifetimeMinutes = 30
+	}
+	if c.Database.TxMaxAttempts <= 0 {
+		c.Database.TxMaxAttempts = 3
+	}
+	if c.Database.TxRetryBackoffMs <= 0 {
+		c.Database.TxRetryBackoffMs = 50
+	}
 
 	// SMTP validation
 	if c.SMTP.Host == "" {
@@ -190,6 +344,48 @@ func (c *Config) Validate() error {
 	if c.SMTP.Port <= 0 || c.SMTP.Port > 65535 {
 		return fmt.Errorf("invalid SMTP port: %d", c.SMTP.Port)
 	}
+	switch c.SMTP.TLSMode {
+	case "":
+		if c.SMTP.Port == 465 {
+			c.SMTP.TLSMode = "implicit"
+		} else {
+			c.SMTP.TLSMode = "starttls"
+		}
+	case "starttls", "implicit", "none":
+	default:
+		return fmt.Errorf("invalid SMTP tls_mode: %q", c.SMTP.TLSMode)
+	}
+	if c.SMTP.TimeoutSeconds <= 0 {
+		c.SMTP.TimeoutSeconds = 10
+	}
+	if c.SMTP.MaxAttempts <= 0 {
+		c.SMTP.MaxAttempts = 3
+	}
+	if c.SMTP.RetryBackoffMs <= 0 {
+		c.SMTP.RetryBackoffMs = 500
+	}
+	if c.SMTP.OutboxMaxAttempts <= 0 {
+		c.SMTP.OutboxMaxAttempts = 6
+	}
+
+	// SMS validation
+	switch c.SMS.Provider {
+	case "":
+		c.SMS.Provider = "noop"
+	case "noop":
+	case "twilio":
+		if c.SMS.AccountSID == "" || c.SMS.AuthToken == "" {
+			return fmt.Errorf("SMS account_sid and auth_token are required for twilio")
+		}
+		if !strings.HasPrefix(c.SMS.FromNumber, "+") {
+			return fmt.Errorf("SMS from_number must be in E.164 form, e.g. +14155550123")
+		}
+	default:
+		return fmt.Errorf("invalid SMS provider: %q", c.SMS.Provider)
+	}
+	if c.SMS.TimeoutSeconds <= 0 {
+		c.SMS.TimeoutSeconds = 10
+	}
 
 	// JWT validation
 	if c.JWT.Secret == "" {
@@ -198,11 +394,109 @@ func (c *Config) Validate() error {
 	if len(c.JWT.Secret) < 32 {
 		return fmt.Errorf("JWT secret must be at least 32 characters")
 	}
+	if c.JWT.RefreshSecret != "" && len(c.JWT.RefreshSecret) < 32 {
+		return fmt.Errorf("JWT refresh secret must be at least 32 characters")
+	}
+	if c.JWT.AccessTokenExpiry <= 0 {
+		c.JWT.AccessTokenExpiry = 15
+	}
+	if c.JWT.RefreshTokenExpiry <= 0 {
+		c.JWT.RefreshTokenExpiry = 10080
+	}
+	if c.JWT.TempTokenExpiry <= 0 {
+		c.JWT.TempTokenExpiry = 5
+	}
+	if c.JWT.OrgClaimsTTL <= 0 {
+		c.JWT.OrgClaimsTTL = 5
+	}
 
 	// Storage validation
 	if c.Storage.UploadDir == "" {
 		return fmt.Errorf("upload directory is required")
 	}
+	if c.Storage.Type == "" || c.Storage.Type == "mock" {
+		// Local development: an unset key gets a random one, so download URLs expire with the process
+		if c.Storage.SigningSecret == "" {
+			key := make([]byte, 32)
+			if _, err := rand.Read(key); err != nil {
+				return fmt.Errorf("failed to generate storage signing secret: %w", err)
+			}
+			c.Storage.SigningSecret = hex.EncodeToString(key)
+		}
+	} else {
+		if c.Storage.SigningSecret == "" || strings.HasPrefix(c.Storage.SigningSecret, "CHANGE_ME") {
+			return fmt.Errorf("storage signing secret is required")
+		}
+		if len(c.Storage.SigningSecret) < 32 {
+			return fmt.Errorf("storage signing secret must be at least 32 characters")
+		}
+		if c.Storage.SigningSecret == c.JWT.Secret || c.Storage.SigningSecret == c.JWT.RefreshSecret {
+			return fmt.Errorf("storage signing secret must differ from the JWT secrets")
+		}
+	}
+	if c.Storage.DownloadURLExpiry <= 0 {
+		c.Storage.DownloadURLExpiry = 60
+	}
+	if c.Storage.ThumbnailMaxDim <= 0 {
+		c.Storage.ThumbnailMaxDim = 300
+	}
+	if c.Storage.MaxFileSize <= 0 {
+		c.Storage.MaxFileSize = 10
+	}
+	if len(c.Storage.AllowedTypes) == 0 {
+		c.Storage.AllowedTypes = []string{"image/jpeg", "image/png", "image/webp"}
+	}
+	if c.Storage.MaxImagesPerTool <= 0 {
+		c.Storage.MaxImagesPerTool = 10
+	}
+
+	// Billing defaults
+	if c.Billing.DefaultSettlementThresholdCents <= 0 {
+		c.Billing.DefaultSettlementThresholdCents = 500
+	}
+	if c.Billing.DebtorAckGraceDays <= 0 {
+		c.Billing.DebtorAckGraceDays = 10
+	}
+	if c.Billing.CreditorAckGraceDays <= 0 {
+		c.Billing.CreditorAckGraceDays = 5
+	}
+	if c.Billing.LowBalanceAlertCents <= 0 {
+		c.Billing.LowBalanceAlertCents = 5000
+	}
+
+	// Admin defaults
+	if c.Admin.MaxInvitationBatch <= 0 {
+		c.Admin.MaxInvitationBatch = 100
+	}
+
+	// Rental defaults
+	if c.Rental.RequestExpiryHours <= 0 {
+		c.Rental.RequestExpiryHours = 72
+	}
+	if c.Rental.StartDateGraceHours <= 0 {
+		c.Rental.StartDateGraceHours = 12
+	}
+	switch c.Rental.PricingRounding {
+	case "":
+		c.Rental.PricingRounding = "round_up"
+	case "round_up", "nearest", "prorate":
+	default:
+		return fmt.Errorf("invalid rental pricing_rounding: %q", c.Rental.PricingRounding)
+	}
+	if c.Rental.OverdueReminderDays <= 0 {
+		c.Rental.OverdueReminderDays = 1
+	}
+	if c.Rental.OverdueSecondNoticeDays <= 0 {
+		c.Rental.OverdueSecondNoticeDays = 3
+	}
+	if c.Rental.OverdueFinalNoticeDays <= 0 {
+		c.Rental.OverdueFinalNoticeDays = 7
+	}
+	if c.Rental.OverdueReminderDays >= c.Rental.OverdueSecondNoticeDays ||
+		c.Rental.OverdueSecondNoticeDays >= c.Rental.OverdueFinalNoticeDays {
+		return fmt.Errorf("overdue reminder days must increase: %d, %d, %d",
+			c.Rental.OverdueReminderDays, c.Rental.OverdueSecondNoticeDays, c.Rental.OverdueFinalNoticeDays)
+	}
 
 	// Scheduler defaults
 	if c.Scheduler.MarkOverdueRentals == "" {
@@ -215,7 +509,10 @@ func (c *Config) Validate() error {
 		c.Scheduler.SendBillReminders = "0 0 4 * * *" // 4 AM UTC
 	}
 	if c.Scheduler.CheckOverdueBills == "" {
-		c.Scheduler.CheckOverdueBills = "0 0 5 10 * *" // 10th of month at 5 AM UTC
+		c.Scheduler.CheckOverdueBills = "0 0 5 * * *" // Daily at 5 AM UTC
+	}
+	if c.Scheduler.SendLowBalanceAlerts == "" {
+		c.Scheduler.SendLowBalanceAlerts = "0 30 4 * * *" // Daily at 4:30 AM UTC
 	}
 	if c.Scheduler.ResolveDisputedBills == "" {
 		c.Scheduler.ResolveDisputedBills = "0 0 23 L * *" // Last day of month at 11 PM UTC
@@ -229,6 +526,35 @@ func (c *Config) Validate() error {
 	if c.Scheduler.SendBillNotices == "" {
 		c.Scheduler.SendBillNotices = "0 0 9 * * *" // Daily at 9 AM UTC
 	}
+	if c.Scheduler.ReconcileBalances == "" {
+		c.Scheduler.ReconcileBalances = "0 0 1 * * *" // Daily at 1 AM UTC
+	}
+	if c.Scheduler.AutoActivateRentals == "" {
+		c.Scheduler.AutoActivateRentals = "0 5 0 * * *" // Daily at 12:05 AM UTC
+	}
+	if c.Scheduler.RetryFailedEmails == "" {
+		c.Scheduler.RetryFailedEmails = "0 */15 * * * *" // Every 15 minutes
+	}
+	if c.Scheduler.RetryFailedNotifications == "" {
+		c.Scheduler.RetryFailedNotifications = "0 5-59/15 * * * *" // Every 15 minutes, offset from the email retry
+	}
+	if c.Scheduler.ExpireStalePendingRentals == "" {
+		c.Scheduler.ExpireStalePendingRentals = "0 10 * * * *" // Hourly at :10
+	}
+	if c.Scheduler.Timezone == "" {
+		c.Scheduler.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(c.Scheduler.Timezone); err != nil {
+		return fmt.Errorf("invalid scheduler timezone %q: %w", c.Scheduler.Timezone, err)
+	}
+
+	// Rate limit defaults
+	if c.Security.RateLimit.RequestsPerMinute <= 0 {
+		c.Security.RateLimit.RequestsPerMinute = 5
+	}
+	if c.Security.RateLimit.Burst <= 0 {
+		c.Security.RateLimit.Burst = 10
+	}
 
 	return nil
 }
@@ -257,8 +583,17 @@ type SchedulerConfig struct {
 	SendOverdueReminders string `yaml:"send_overdue_reminders"`
 	SendBillReminders    string `yaml:"send_bill_reminders"`
 	CheckOverdueBills    string `yaml:"check_overdue_bills"`
+	SendLowBalanceAlerts string `yaml:"send_low_balance_alerts"`
 	ResolveDisputedBills string `yaml:"resolve_disputed_bills"`
 	TakeBalanceSnapshots string `yaml:"take_balance_snapshots"`
 	PerformBillSplitting string `yaml:"perform_bill_splitting"`
 	SendBillNotices      string `yaml:"send_bill_notices"`
+	ReconcileBalances    string `yaml:"reconcile_balances"`
+	AutoActivateRentals  string `yaml:"auto_activate_rentals"`
+	RetryFailedEmails    string `yaml:"retry_failed_emails"`
+
+	ExpireStalePendingRentals string `yaml:"expire_stale_pending_rentals"`
+	RetryFailedNotifications  string `yaml:"retry_failed_notifications"`
+
+	Timezone string `yaml:"timezone"` // IANA name the expressions are evaluated in, e.g. "America/New_York"
 }
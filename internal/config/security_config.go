@@ -15,7 +15,9 @@ var EndpointSecurityConfig = map[string]SecurityLevel{
 	// AuthService - Public
 	"/ubertool.trusted.api.v1.AuthService/UserSignup":                SecurityPublic,
 	"/ubertool.trusted.api.v1.AuthService/ValidateInvite":            SecurityPublic,
+	"/ubertool.trusted.api.v1.AuthService/GetInvitationInfo":         SecurityPublic,
 	"/ubertool.trusted.api.v1.AuthService/RequestToJoinOrganization": SecurityPublic,
+	"/ubertool.trusted.api.v1.AuthService/VerifyEmail":               SecurityPublic,
 	// TODO: add health check to auth service
 	// "/ubertool.trusted.api.v1.AuthService/HealthCheck":           SecurityPublic,
 	"/ubertool.trusted.api.v1.AuthService/Login": SecurityPublic,
@@ -29,43 +31,68 @@ var EndpointSecurityConfig = map[string]SecurityLevel{
 	"/ubertool.trusted.api.v1.AuthService/RefreshToken": SecurityRefresh,
 
 	// AuthService - Access Protected
-	"/ubertool.trusted.api.v1.AuthService/Logout":         SecurityAccess,
-	"/ubertool.trusted.api.v1.AuthService/ChangePassword": SecurityAccess,
+	"/ubertool.trusted.api.v1.AuthService/Logout":            SecurityAccess,
+	"/ubertool.trusted.api.v1.AuthService/ChangePassword":    SecurityAccess,
+	"/ubertool.trusted.api.v1.AuthService/RevokeAllSessions": SecurityAccess,
 
 	// AuthService - Public (self-service password reset; no auth token required)
 	"/ubertool.trusted.api.v1.AuthService/ResetPassword": SecurityPublic,
 
+	// gRPC health checking protocol - Public, polled by load balancers
+	"/grpc.health.v1.Health/Check": SecurityPublic,
+	"/grpc.health.v1.Health/Watch": SecurityPublic,
+
 	// OrganizationService - Public
 	"/ubertool.trusted.api.v1.OrganizationService/SearchOrganizations": SecurityPublic,
+	"/ubertool.trusted.api.v1.OrganizationService/ListMetros":          SecurityPublic,
 
 	// OrganizationService - Access Protected
-	"/ubertool.trusted.api.v1.OrganizationService/GetOrganization":     SecurityAccess,
-	"/ubertool.trusted.api.v1.OrganizationService/CreateOrganization":  SecurityAccess,
-	"/ubertool.trusted.api.v1.OrganizationService/UpdateOrganization":  SecurityAccess,
-	"/ubertool.trusted.api.v1.OrganizationService/ListMyOrganizations": SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/GetOrganization":        SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/CreateOrganization":     SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/UpdateOrganization":     SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/ListMyOrganizations":    SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/GetOrganizationMembers": SecurityAccess,
+	"/ubertool.trusted.api.v1.OrganizationService/SetAdjacentMetros":      SecurityAccess,
 
 	// UserService - All Access Protected
-	"/ubertool.trusted.api.v1.UserService/GetUser":       SecurityAccess,
-	"/ubertool.trusted.api.v1.UserService/UpdateProfile": SecurityAccess,
+	"/ubertool.trusted.api.v1.UserService/GetUser":                      SecurityAccess,
+	"/ubertool.trusted.api.v1.UserService/UpdateProfile":                SecurityAccess,
+	"/ubertool.trusted.api.v1.UserService/UpdateNotificationPreference": SecurityAccess,
+	"/ubertool.trusted.api.v1.UserService/GetDashboard":                 SecurityAccess,
 
 	// AdminService - All Access Protected
 	"/ubertool.trusted.api.v1.AdminService/ApproveRequestToJoin":  SecurityAccess,
 	"/ubertool.trusted.api.v1.AdminService/AdminBlockUserAccount": SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/UnblockMember":         SecurityAccess,
 	"/ubertool.trusted.api.v1.AdminService/ListMembers":           SecurityAccess,
 	"/ubertool.trusted.api.v1.AdminService/SearchUsers":           SecurityAccess,
 	"/ubertool.trusted.api.v1.AdminService/ListJoinRequests":      SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/ListAuditLog":          SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/GetOrgBalanceSheet":    SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/GetJobRuns":            SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/ListAllOrganizations":  SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/GetAnyMemberProfile":   SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/AdjustAnyBalance":      SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/ResendInvitation":      SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/RevokeInvitation":      SecurityAccess,
+	"/ubertool.trusted.api.v1.AdminService/BulkCreateInvitations": SecurityAccess,
 
 	// ImageStorageService - Access Protected
-	"/ubertool.trusted.api.v1.ImageStorageService/GetUploadUrl": SecurityAccess,
+	"/ubertool.trusted.api.v1.ImageStorageService/GetUploadUrl":      SecurityAccess,
+	"/ubertool.trusted.api.v1.ImageStorageService/ReorderToolImages": SecurityAccess,
 
 	// LedgerService - Access Protected
 	"/ubertool.trusted.api.v1.LedgerService/GetBalance":       SecurityAccess,
 	"/ubertool.trusted.api.v1.LedgerService/GetTransactions":  SecurityAccess,
 	"/ubertool.trusted.api.v1.LedgerService/GetLedgerSummary": SecurityAccess,
+	"/ubertool.trusted.api.v1.LedgerService/ExportLedger":     SecurityAccess,
 
 	// NotificationService - Access Protected
-	"/ubertool.trusted.api.v1.NotificationService/GetNotifications":     SecurityAccess,
-	"/ubertool.trusted.api.v1.NotificationService/MarkNotificationRead": SecurityAccess,
+	"/ubertool.trusted.api.v1.NotificationService/GetNotifications":         SecurityAccess,
+	"/ubertool.trusted.api.v1.NotificationService/GetUnreadCount":           SecurityAccess,
+	"/ubertool.trusted.api.v1.NotificationService/StreamNotifications":      SecurityAccess,
+	"/ubertool.trusted.api.v1.NotificationService/MarkNotificationRead":     SecurityAccess,
+	"/ubertool.trusted.api.v1.NotificationService/MarkAllNotificationsRead": SecurityAccess,
 
 	// RentalService - Access Protected
 	"/ubertool.trusted.api.v1.RentalService/ApproveRentalRequest":  SecurityAccess,
@@ -73,6 +100,8 @@ var EndpointSecurityConfig = map[string]SecurityLevel{
 	"/ubertool.trusted.api.v1.RentalService/ListMyLendings":        SecurityAccess,
 	"/ubertool.trusted.api.v1.RentalService/CompleteRental":        SecurityAccess,
 	"/ubertool.trusted.api.v1.RentalService/GetRental":             SecurityAccess,
+	"/ubertool.trusted.api.v1.RentalService/GetRentalHistory":      SecurityAccess,
+	"/ubertool.trusted.api.v1.RentalService/GetRentalReceipt":      SecurityAccess,
 	"/ubertool.trusted.api.v1.RentalService/CreateRentalRequest":   SecurityAccess,
 	"/ubertool.trusted.api.v1.RentalService/FinalizeRentalRequest": SecurityAccess,
 	"/ubertool.trusted.api.v1.RentalService/ListMyRentals":         SecurityAccess,
@@ -85,6 +114,7 @@ var EndpointSecurityConfig = map[string]SecurityLevel{
 	"/ubertool.trusted.api.v1.ToolService/DeleteTool":         SecurityAccess,
 	"/ubertool.trusted.api.v1.ToolService/SearchTools":        SecurityAccess,
 	"/ubertool.trusted.api.v1.ToolService/ListToolCategories": SecurityAccess,
+	"/ubertool.trusted.api.v1.ToolService/GetToolDetail":      SecurityAccess,
 }
 
 // GetSecurityLevel returns the security level for a given method
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+type AdminAuditAction string
+
+const (
+	AdminAuditActionResolveDispute     AdminAuditAction = "RESOLVE_DISPUTE"
+	AdminAuditActionBlockMember        AdminAuditAction = "BLOCK_MEMBER"
+	AdminAuditActionUnblockMember      AdminAuditAction = "UNBLOCK_MEMBER"
+	AdminAuditActionApproveJoinRequest AdminAuditAction = "APPROVE_JOIN_REQUEST"
+	AdminAuditActionRejectJoinRequest  AdminAuditAction = "REJECT_JOIN_REQUEST"
+	AdminAuditActionSendInvitation     AdminAuditAction = "SEND_INVITATION"
+	AdminAuditActionResendInvitation   AdminAuditAction = "RESEND_INVITATION"
+	AdminAuditActionRevokeInvitation   AdminAuditAction = "REVOKE_INVITATION"
+	AdminAuditActionUpdateOrganization AdminAuditAction = "UPDATE_ORGANIZATION"
+	AdminAuditActionSetThreshold       AdminAuditAction = "SET_SETTLEMENT_THRESHOLD"
+	AdminAuditActionRunSettlement      AdminAuditAction = "RUN_SETTLEMENT"
+	AdminAuditActionSetAdjacentMetros  AdminAuditAction = "SET_ADJACENT_METROS"
+	AdminAuditActionAdjustBalance      AdminAuditAction = "ADJUST_BALANCE"
+)
+
+type AdminAuditTargetType string
+
+const (
+	AdminAuditTargetBill         AdminAuditTargetType = "BILL"
+	AdminAuditTargetUser         AdminAuditTargetType = "USER"
+	AdminAuditTargetJoinRequest  AdminAuditTargetType = "JOIN_REQUEST"
+	AdminAuditTargetInvitation   AdminAuditTargetType = "INVITATION"
+	AdminAuditTargetOrganization AdminAuditTargetType = "ORGANIZATION"
+)
+
+// AdminAuditEntry records one privileged mutation performed by an org admin. Before and
+// After snapshot the fields the mutation changed; they are empty for actions that only
+// carry Details.
+type AdminAuditEntry struct {
+	ID         int32                `json:"id"`
+	OrgID      int32                `json:"org_id"`
+	AdminID    int32                `json:"admin_id"`
+	Action     AdminAuditAction     `json:"action"`
+	TargetType AdminAuditTargetType `json:"target_type"`
+	TargetID   int32                `json:"target_id"`
+	Details    map[string]string    `json:"details"`
+	Before     map[string]string    `json:"before,omitempty"`
+	After      map[string]string    `json:"after,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+}
+
+// AdminAuditFilter narrows an audit log listing; zero values match everything
+type AdminAuditFilter struct {
+	AdminID    int32
+	Action     AdminAuditAction
+	TargetType AdminAuditTargetType
+	TargetID   int32
+	Page       int32
+	PageSize   int32
+}
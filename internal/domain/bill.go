@@ -1,6 +1,11 @@
 package domain
 
-import "time"
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 type BillStatus string
 
@@ -10,6 +15,7 @@ const (
 	BillStatusDisputed            BillStatus = "DISPUTED"
 	BillStatusAdminResolved       BillStatus = "ADMIN_RESOLVED"
 	BillStatusSystemDefaultAction BillStatus = "SYSTEM_DEFAULT_ACTION"
+	BillStatusVoided              BillStatus = "VOIDED" // Cancelled by both parties; no balance moved
 )
 
 type DisputeReason string
@@ -44,34 +50,91 @@ type Bill struct {
 	DisputeReason          string     `json:"dispute_reason"`
 	ResolutionOutcome      string     `json:"resolution_outcome"`
 	ResolutionNotes        string     `json:"resolution_notes"`
+	VoidRequestedBy        *int32     `json:"void_requested_by"` // Party waiting on the other to agree to void the bill
+	VoidRequestedAt        *time.Time `json:"void_requested_at"`
+	Version                int32      `json:"version"` // Optimistic lock; must match the stored row on Update
 	CreatedAt              time.Time  `json:"created_at"`
 	UpdatedAt              time.Time  `json:"updated_at"`
 }
 
-// Helper to determine payment category for UI
-func (b *Bill) GetPaymentCategory(userID int32) string {
+// SettlementTransfer is a proposed debtor to creditor payment that has not been billed yet
+type SettlementTransfer struct {
+	DebtorUserID   int32  `json:"debtor_user_id"`
+	DebtorName     string `json:"debtor_name"`
+	CreditorUserID int32  `json:"creditor_user_id"`
+	CreditorName   string `json:"creditor_name"`
+	AmountCents    int32  `json:"amount_cents"`
+}
+
+// SettlementPreview is the outcome bill splitting would produce for an org right now
+type SettlementPreview struct {
+	OrgID           int32                `json:"org_id"`
+	SettlementMonth string               `json:"settlement_month"` // Format: 'YYYY-MM'
+	ThresholdCents  int32                `json:"threshold_cents"`
+	Transfers       []SettlementTransfer `json:"transfers"`
+}
+
+// PaymentCategory is where a bill stands for one of its parties. The bill split summaries,
+// the payment listing and the API mapper all take it from Bill.GetPaymentCategory.
+type PaymentCategory string
+
+const (
+	// PaymentCategoryNone is an open bill that waits on someone other than the user, e.g. a
+	// paid bill the creditor has yet to confirm, seen by the debtor
+	PaymentCategoryNone             PaymentCategory = ""
+	PaymentCategoryPaymentToMake    PaymentCategory = "PAYMENT_TO_MAKE"
+	PaymentCategoryReceiptToVerify  PaymentCategory = "RECEIPT_TO_VERIFY"
+	PaymentCategoryPaymentInDispute PaymentCategory = "PAYMENT_IN_DISPUTE"
+	PaymentCategoryReceiptInDispute PaymentCategory = "RECEIPT_IN_DISPUTE"
+	PaymentCategoryCompleted        PaymentCategory = "COMPLETED"
+)
+
+// OpenBillStatuses are the statuses of bills still in progress, listed as active payments
+func OpenBillStatuses() []BillStatus {
+	return []BillStatus{BillStatusPending, BillStatusDisputed}
+}
+
+// ClosedBillStatuses are the final statuses, listed as payment history. A bill in one of
+// them is COMPLETED for both parties.
+func ClosedBillStatuses() []BillStatus {
+	return []BillStatus{BillStatusPaid, BillStatusAdminResolved, BillStatusSystemDefaultAction, BillStatusVoided}
+}
+
+// IsClosed reports whether s is one of ClosedBillStatuses
+func (s BillStatus) IsClosed() bool {
+	switch s {
+	case BillStatusPaid, BillStatusAdminResolved, BillStatusSystemDefaultAction, BillStatusVoided:
+		return true
+	}
+	return false
+}
+
+// GetPaymentCategory returns the bill's category for userID. A closed bill is COMPLETED; an
+// open bill is PaymentCategoryNone for a user who is not the one it waits on.
+func (b *Bill) GetPaymentCategory(userID int32) PaymentCategory {
+	if b.Status.IsClosed() {
+		return PaymentCategoryCompleted
+	}
 	isDebtor := b.DebtorUserID == userID
 	isCreditor := b.CreditorUserID == userID
 
 	switch b.Status {
 	case BillStatusPending:
 		if isDebtor && b.DebtorAcknowledgedAt == nil {
-			return "PAYMENT_TO_MAKE"
+			return PaymentCategoryPaymentToMake
 		}
 		if isCreditor && b.DebtorAcknowledgedAt != nil {
-			return "RECEIPT_TO_VERIFY"
+			return PaymentCategoryReceiptToVerify
 		}
 	case BillStatusDisputed:
 		if isDebtor {
-			return "PAYMENT_IN_DISPUTE"
+			return PaymentCategoryPaymentInDispute
 		}
 		if isCreditor {
-			return "RECEIPT_IN_DISPUTE"
+			return PaymentCategoryReceiptInDispute
 		}
-	case BillStatusPaid, BillStatusAdminResolved, BillStatusSystemDefaultAction:
-		return "COMPLETED"
 	}
-	return "COMPLETED"
+	return PaymentCategoryNone
 }
 
 type BillActionType string
@@ -84,6 +147,8 @@ const (
 	BillActionTypeAdminComment         BillActionType = "ADMIN_COMMENT"
 	BillActionTypeAdminResolution      BillActionType = "ADMIN_RESOLUTION"
 	BillActionTypeSystemAutoResolve    BillActionType = "SYSTEM_AUTO_RESOLVE"
+	BillActionTypeVoidRequested        BillActionType = "VOID_REQUESTED"
+	BillActionTypeVoidConfirmed        BillActionType = "VOID_CONFIRMED"
 )
 
 type BillAction struct {
@@ -91,7 +156,72 @@ type BillAction struct {
 	BillID        int32          `json:"bill_id"`
 	ActorUserID   *int32         `json:"actor_user_id"` // NULL for system actions
 	ActionType    BillActionType `json:"action_type"`
-	ActionDetails string         `json:"action_details"` // JSONB stored as string
+	ActionDetails string         `json:"action_details"` // JSONB stored as string; "{}" when the action has none
 	Notes         string         `json:"notes"`
 	CreatedAt     time.Time      `json:"created_at"`
 }
+
+// NormalizeActionDetails checks that details is a JSON document and returns it compacted.
+// Blank details normalize to "", which is stored as NULL.
+func NormalizeActionDetails(details string) (string, error) {
+	if strings.TrimSpace(details) == "" {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(details)); err != nil {
+		return "", Invalidf("action details are not valid JSON: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// DisputeDetail is the full context an admin needs to resolve a disputed bill
+type DisputeDetail struct {
+	Bill     Bill
+	Actions  []BillAction // Every action on the bill, oldest first
+	Debtor   DisputeParty
+	Creditor DisputeParty
+}
+
+// DisputeParty is one side of a dispute with their membership in the bill's org
+type DisputeParty struct {
+	User       User
+	Membership UserOrg
+}
+
+// BillActionFilter pages through a bill's actions, oldest first. An empty ActionTypes matches
+// every action type.
+type BillActionFilter struct {
+	ActionTypes []BillActionType
+	Page        int32
+	PageSize    int32
+}
+
+// DisputeStatistics aggregates disputed bills for an organization over a range of settlement months
+type DisputeStatistics struct {
+	OrgID                int32            `json:"org_id"`
+	FromMonth            string           `json:"from_month"` // Format: 'YYYY-MM', empty for unbounded
+	ToMonth              string           `json:"to_month"`   // Format: 'YYYY-MM', empty for unbounded
+	TotalDisputes        int32            `json:"total_disputes"`
+	CountByStatus        map[string]int32 `json:"count_by_status"`
+	CountByOutcome       map[string]int32 `json:"count_by_outcome"`
+	AvgResolutionSeconds int64            `json:"avg_resolution_seconds"` // disputed_at -> resolved_at
+	TotalPenalizedCents  int64            `json:"total_penalized_cents"`  // Balance penalties applied by admin resolutions
+}
+
+// BillStatusTotal counts an organization's bills in one status
+type BillStatusTotal struct {
+	Count       int32 `json:"count"`
+	AmountCents int64 `json:"amount_cents"`
+}
+
+// OrgBalanceSheet is an organization's financial position: member balances from users_orgs
+// and every bill grouped by status
+type OrgBalanceSheet struct {
+	OrgID                     int32                      `json:"org_id"`
+	MemberCount               int32                      `json:"member_count"`
+	MembersInCredit           int32                      `json:"members_in_credit"`
+	MembersInDebt             int32                      `json:"members_in_debt"`
+	TotalPositiveBalanceCents int64                      `json:"total_positive_balance_cents"`
+	TotalNegativeBalanceCents int64                      `json:"total_negative_balance_cents"` // Zero or below
+	BillsByStatus             map[string]BillStatusTotal `json:"bills_by_status"`
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultCurrencyCode labels amounts for orgs that have not chosen a currency
+const DefaultCurrencyCode = "USD"
+
+// currencySymbols are the symbols used when formatting amounts. Codes without an entry are
+// rendered with the code itself, e.g. "CHF 12.50".
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"CAD": "CA$",
+	"AUD": "A$",
+	"NZD": "NZ$",
+	"HKD": "HK$",
+	"SGD": "S$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "CN¥",
+	"KRW": "₩",
+	"INR": "₹",
+	"PHP": "₱",
+	"NGN": "₦",
+	"BRL": "R$",
+	"MXN": "MX$",
+}
+
+// NormalizeCurrencyCode upper-cases and validates an ISO 4217 style code. An empty code
+// normalizes to DefaultCurrencyCode.
+func NormalizeCurrencyCode(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return DefaultCurrencyCode, nil
+	}
+	if len(code) != 3 {
+		return "", Invalidf("currency code must be 3 letters, got %q", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", Invalidf("currency code must be 3 letters, got %q", code)
+		}
+	}
+	return code, nil
+}
+
+// FormatCents renders an amount in cents with the currency's symbol, e.g. "$12.50",
+// "€12.50" or "CHF 12.50". An empty code formats as DefaultCurrencyCode.
+func FormatCents(cents int32, currencyCode string) string {
+	if currencyCode == "" {
+		currencyCode = DefaultCurrencyCode
+	}
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	amount := fmt.Sprintf("%d.%02d", cents/100, cents%100)
+	if symbol, ok := currencySymbols[currencyCode]; ok {
+		return sign + symbol + amount
+	}
+	return sign + currencyCode + " " + amount
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type EmailOutboxStatus string
+
+const (
+	EmailOutboxStatusPending EmailOutboxStatus = "PENDING" // Persisted, not yet picked up by a worker
+	EmailOutboxStatusSending EmailOutboxStatus = "SENDING" // Claimed by a worker
+	EmailOutboxStatusSent    EmailOutboxStatus = "SENT"
+	EmailOutboxStatusFailed  EmailOutboxStatus = "FAILED" // Last attempt failed; retried until attempts run out
+)
+
+// EmailOutboxEntry is a composed email persisted before delivery
+type EmailOutboxEntry struct {
+	ID            int64             `json:"id"`
+	Recipients    []string          `json:"recipients"`
+	Cc            []string          `json:"cc"`
+	Subject       string            `json:"subject"`
+	Body          string            `json:"body"`
+	IsHTML        bool              `json:"is_html"`
+	HTMLBody      string            `json:"html_body"` // HTML alternative to Body, sent as multipart
+	Status        EmailOutboxStatus `json:"status"`
+	Attempts      int32             `json:"attempts"`
+	LastError     string            `json:"last_error"`
+	NextAttemptAt time.Time         `json:"next_attempt_at"`
+	CreatedAt     time.Time         `json:"created_at"`
+	SentAt        *time.Time        `json:"sent_at"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds shared by the services. The gRPC error interceptor maps each kind to a status
+// code, so clients can tell a permission failure from a missing record or a bad request.
+var (
+	ErrUnauthorized = errors.New("unauthorized")      // PermissionDenied
+	ErrNotFound     = errors.New("not found")         // NotFound
+	ErrConflict     = errors.New("conflict")          // FailedPrecondition: wrong state for the operation
+	ErrValidation   = errors.New("validation failed") // InvalidArgument
+)
+
+// kindError keeps its own message while matching its kind with errors.Is
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// Unauthorizedf formats an error of kind ErrUnauthorized. Like fmt.Errorf, %w wraps its operand.
+func Unauthorizedf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrUnauthorized, err: fmt.Errorf(format, args...)}
+}
+
+// NotFoundf formats an error of kind ErrNotFound
+func NotFoundf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrNotFound, err: fmt.Errorf(format, args...)}
+}
+
+// Conflictf formats an error of kind ErrConflict
+func Conflictf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrConflict, err: fmt.Errorf(format, args...)}
+}
+
+// Invalidf formats an error of kind ErrValidation
+func Invalidf(format string, args ...interface{}) error {
+	return &kindError{kind: ErrValidation, err: fmt.Errorf(format, args...)}
+}
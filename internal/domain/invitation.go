@@ -10,5 +10,31 @@ type Invitation struct {
 	ExpiresOn      string  `json:"expires_on"`
 	UsedOn         *string `json:"used_on,omitempty"`
 	UsedByUserID   *int32  `json:"used_by_user_id,omitempty"`
+	RevokedOn      *string `json:"revoked_on,omitempty"`
 	CreatedOn      string  `json:"created_on"`
 }
+
+type BulkInvitationStatus string
+
+const (
+	BulkInvitationStatusCreated BulkInvitationStatus = "CREATED"
+	BulkInvitationStatusSkipped BulkInvitationStatus = "SKIPPED"
+	BulkInvitationStatusFailed  BulkInvitationStatus = "FAILED"
+)
+
+// BulkInvitationResult is the outcome for one email of a bulk invitation request
+type BulkInvitationResult struct {
+	Email          string               `json:"email"`
+	Status         BulkInvitationStatus `json:"status"`
+	InvitationCode string               `json:"invitation_code,omitempty"`
+	Message        string               `json:"message,omitempty"` // Why the email was skipped or failed
+}
+
+// InvitationInfo is what a prospective member may see about an invitation before signing up:
+// the inviting org and when the invitation lapses, nothing about who sent it or the org's admins
+type InvitationInfo struct {
+	OrgName        string `json:"org_name"`
+	OrgDescription string `json:"org_description"`
+	Metro          string `json:"metro"`
+	ExpiresOn      string `json:"expires_on"`
+}
@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+type JobRunStatus string
+
+const (
+	JobRunStatusRunning   JobRunStatus = "RUNNING" // Started; still RUNNING after the job's usual duration means the process died
+	JobRunStatusSucceeded JobRunStatus = "SUCCEEDED"
+	JobRunStatusFailed    JobRunStatus = "FAILED" // Returned an error or panicked; see Error
+)
+
+// JobRun records one execution of a scheduled cronjob job
+type JobRun struct {
+	ID         int64        `json:"id"`
+	JobName    string       `json:"job_name"`
+	Status     JobRunStatus `json:"status"`
+	Error      string       `json:"error"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt *time.Time   `json:"finished_at"`
+}
+
+// JobRunFilter narrows a job run listing; zero values match everything
+type JobRunFilter struct {
+	JobName string
+	Status  JobRunStatus
+	Limit   int32
+}
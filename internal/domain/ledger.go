@@ -8,6 +8,14 @@ const (
 	TransactionTypeLendingDebit  TransactionType = "LENDING_DEBIT"
 	TransactionTypeRefund        TransactionType = "REFUND"
 	TransactionTypeAdjustment    TransactionType = "ADJUSTMENT"
+	// Bill settlements move a settled bill's amount between debtor and creditor; penalties
+	// charge a party found at fault in a dispute
+	TransactionTypeBillSettlement TransactionType = "BILL_SETTLEMENT"
+	TransactionTypeBillPenalty    TransactionType = "BILL_PENALTY"
+	// Holds reserve a renter's funds from finalize until the rental is settled or cancelled.
+	// They are recorded in the ledger but do not change users_orgs.balance_cents.
+	TransactionTypeHold        TransactionType = "HOLD"
+	TransactionTypeHoldRelease TransactionType = "HOLD_RELEASE"
 )
 
 type LedgerTransaction struct {
@@ -23,9 +31,22 @@ type LedgerTransaction struct {
 }
 
 type LedgerSummary struct {
-	Balance              int32            `json:"balance"`
+	Balance              int32            `json:"balance"`           // Sum of the member's ledger_transactions, excluding holds
+	StoredBalance        int32            `json:"stored_balance"`    // Cached users_orgs.balance_cents
+	BalanceMismatch      bool             `json:"balance_mismatch"`  // StoredBalance has drifted from Balance
+	HeldBalance          int32            `json:"held_balance"`      // Funds reserved by outstanding rental holds
+	AvailableBalance     int32            `json:"available_balance"` // Balance less HeldBalance
 	ActiveRentalsCount   int32            `json:"active_rentals_count"`
 	ActiveLendingsCount  int32            `json:"active_lendings_count"`
 	PendingRequestsCount int32            `json:"pending_requests_count"`
 	StatusCount          map[string]int32 `json:"status_count"`
 }
+
+// BalanceSnapshot is a point-in-time copy of a member's balance, taken by the snapshot job
+type BalanceSnapshot struct {
+	UserID          int32  `json:"user_id"`
+	OrgID           int32  `json:"org_id"`
+	BalanceCents    int32  `json:"balance_cents"`
+	SettlementMonth string `json:"settlement_month"` // Format: 'YYYY-MM'
+	SnapshotDate    string `json:"snapshot_date"`    // Format: 'YYYY-MM-DD'
+}
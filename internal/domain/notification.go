@@ -14,3 +14,39 @@ type Notification struct {
 	Attributes  map[string]string `json:"attributes"`
 	CreatedAt   *time.Time        `json:"created_at"`
 }
+
+// smsNotificationTypes are the "type" attributes of events urgent enough to text users
+// who opted in: the tool is ready, in hand, late, or a payment is contested.
+var smsNotificationTypes = map[string]bool{
+	"RENTAL_APPROVED":       true,
+	"RENTAL_PICKUP":         true,
+	"RENTAL_AUTO_ACTIVATED": true,
+	"RENTAL_OVERDUE":        true,
+	"BILL_DISPUTE_OPENED":   true,
+}
+
+// IsHighPriority reports whether the notification should also go out by SMS
+func (n *Notification) IsHighPriority() bool {
+	return smsNotificationTypes[n.Attributes["type"]]
+}
+
+type NotificationDeadLetterStatus string
+
+const (
+	NotificationDeadLetterStatusFailed    NotificationDeadLetterStatus = "FAILED"    // Insert failed; retried until attempts run out
+	NotificationDeadLetterStatusRetrying  NotificationDeadLetterStatus = "RETRYING"  // Claimed by the retry job
+	NotificationDeadLetterStatusDelivered NotificationDeadLetterStatus = "DELIVERED" // Re-created as NotificationID
+)
+
+// NotificationDeadLetter is a notification whose insert failed, kept so the retry job can re-create it
+type NotificationDeadLetter struct {
+	ID             int64                        `json:"id"`
+	Notification   Notification                 `json:"notification"`
+	Silent         bool                         `json:"silent"` // Dispatched without a push; the retry sends none either
+	Status         NotificationDeadLetterStatus `json:"status"`
+	Attempts       int32                        `json:"attempts"` // Includes the original dispatch
+	LastError      string                       `json:"last_error"`
+	NextAttemptAt  time.Time                    `json:"next_attempt_at"`
+	CreatedAt      time.Time                    `json:"created_at"`
+	NotificationID *int64                       `json:"notification_id"`
+}
@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"strings"
+	"unicode"
+)
+
 type Organization struct {
 	ID                              int32  `json:"id"`
 	Name                            string `json:"name"`
@@ -10,7 +15,58 @@ type Organization struct {
 	AdminEmail                      string `json:"admin_email"`
 	CreatedOn                       string `json:"created_on"`
 	MemberCount                     int32  `json:"member_count"`                        // Count of non-blocked members
+	ToolCount                       int32  `json:"tool_count"`                          // Count of searchable tools in the org's metro
 	Admins                          []User `json:"admins,omitempty"`                    // List of SUPER_ADMIN and ADMIN users, populated in SearchOrganizations
-	SettlementThresholdCents        int32  `json:"settlement_threshold_cents"`         // Max amount allowed to carry over after bill splitting
+	SettlementThresholdCents        int32  `json:"settlement_threshold_cents"`         // Max amount allowed to carry over after bill splitting; 0 = use the configured default
 	MaxBillsplitRentalCostCents     int32  `json:"max_billsplit_rental_cost_cents"`    // Max rental cost settled by bill splitting
+	AutoActivateRentals             bool   `json:"auto_activate_rentals"`              // Activate SCHEDULED rentals on their start date
+	AdjacentMetros                  []string `json:"adjacent_metros,omitempty"`        // Nearby metros members may opt in to when searching tools
+	CurrencyCode                    string `json:"currency_code"`                      // ISO 4217 code amounts are labeled with; empty means DefaultCurrencyCode
+}
+
+// Currency returns the org's currency code, or DefaultCurrencyCode when it has none
+func (o *Organization) Currency() string {
+	if o == nil || o.CurrencyCode == "" {
+		return DefaultCurrencyCode
+	}
+	return o.CurrencyCode
+}
+
+// EffectiveSettlementThresholdCents returns the org's own settlement threshold, or defaultCents when it has none
+func (o *Organization) EffectiveSettlementThresholdCents(defaultCents int32) int32 {
+	if o.SettlementThresholdCents > 0 {
+		return o.SettlementThresholdCents
+	}
+	return defaultCents
+}
+
+// NormalizeMetro returns the canonical spelling of a metro name: trimmed, inner whitespace
+// collapsed, and each word capitalized ("  san   JOSE " -> "San Jose"). It matches Postgres
+// initcap(), which the schema backfill uses, so normalized values compare equal in SQL.
+func NormalizeMetro(metro string) string {
+	var b strings.Builder
+	prevAlnum := false
+	for i, word := range strings.Fields(metro) {
+		if i > 0 {
+			b.WriteByte(' ')
+			prevAlnum = false
+		}
+		for _, r := range word {
+			if prevAlnum {
+				b.WriteRune(unicode.ToLower(r))
+			} else {
+				b.WriteRune(unicode.ToUpper(r))
+			}
+			prevAlnum = unicode.IsLetter(r) || unicode.IsDigit(r)
+		}
+	}
+	return b.String()
+}
+
+// SettlementThreshold describes which settlement threshold applies to an org
+type SettlementThreshold struct {
+	OrgID          int32 `json:"org_id"`
+	ThresholdCents int32 `json:"threshold_cents"` // Threshold the next settlement will use
+	UsesDefault    bool  `json:"uses_default"`    // True when the org has no threshold of its own
+	DefaultCents   int32 `json:"default_cents"`   // Configured fallback
 }
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"strings"
+)
+
+// DefaultPhoneCountryCode is the calling code of numbers entered without one. Ten digit
+// numbers are read as North American numbers.
+const DefaultPhoneCountryCode = "1"
+
+// NormalizePhoneNumber returns the E.164 form ("+14155550123") of a phone number written in
+// any of the usual ways: "(415) 555-0123", "415.555.0123", "1-415-555-0123",
+// "+44 20 7183 8750" or "0044 20 7183 8750". Numbers without a country code must have ten
+// digits and are taken to be in DefaultPhoneCountryCode. An empty number normalizes to "".
+func NormalizePhoneNumber(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", nil
+	}
+
+	var b strings.Builder
+	international := false
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+			international = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '/':
+			// Separators people type between digit groups
+		default:
+			return "", Invalidf("phone number %q contains %q", phone, r)
+		}
+	}
+
+	digits := b.String()
+	switch {
+	case international:
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case len(digits) == 10:
+		digits = DefaultPhoneCountryCode + digits
+	case len(digits) == 11 && strings.HasPrefix(digits, DefaultPhoneCountryCode):
+		// A North American number written with its leading 1
+	default:
+		return "", Invalidf("phone number %q needs an area code, or a country code starting with +", phone)
+	}
+
+	// E.164 allows at most 15 digits and no country code starts with 0
+	if len(digits) < 7 || len(digits) > 15 || digits[0] == '0' {
+		return "", Invalidf("phone number %q is not a valid international number", phone)
+	}
+	if digits[0] == '1' && !validNANPNumber(digits[1:]) {
+		return "", Invalidf("phone number %q is not a valid North American number", phone)
+	}
+	return "+" + digits, nil
+}
+
+// validNANPNumber reports whether n is a 10 digit North American number whose area code and
+// exchange both start with 2-9
+func validNANPNumber(n string) bool {
+	return len(n) == 10 && n[0] >= '2' && n[3] >= '2'
+}
+
+// FormatPhoneNumber renders a number stored by NormalizePhoneNumber for display. North
+// American numbers read "+1 (415) 555-0123"; other countries keep their E.164 form, since
+// their digit grouping varies. Values that are not E.164, such as numbers saved before
+// normalization, are returned unchanged.
+func FormatPhoneNumber(e164 string) string {
+	if len(e164) == 12 && strings.HasPrefix(e164, "+1") {
+		n := e164[2:]
+		return "+1 (" + n[:3] + ") " + n[3:6] + "-" + n[6:]
+	}
+	return e164
+}
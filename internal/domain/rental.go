@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type RentalStatus string
 
 const (
@@ -13,6 +15,7 @@ const (
 	RentalStatusOverdue                  RentalStatus = "OVERDUE"
 	RentalStatusReturnDateChanged        RentalStatus = "RETURN_DATE_CHANGED"
 	RentalStatusReturnDateChangeRejected RentalStatus = "RETURN_DATE_CHANGE_REJECTED"
+	RentalStatusEarlyReturnRequested     RentalStatus = "EARLY_RETURN_REQUESTED" // Owner asked for the tool back early; waits on the renter
 )
 
 type Rental struct {
@@ -24,6 +27,9 @@ type Rental struct {
 	StartDate              string       `json:"start_date"`
 	EndDate                string       `json:"end_date"`
 	LastAgreedEndDate      *string      `json:"last_agreed_end_date,omitempty"`
+	// RequestedEndDate is the return date proposed while an extension or early return is negotiated
+	// (RETURN_DATE_CHANGED, RETURN_DATE_CHANGE_REJECTED or EARLY_RETURN_REQUESTED). EndDate keeps the date in force.
+	RequestedEndDate *string `json:"requested_end_date,omitempty"`
 	// Price snapshot fields — captured from the tool at rental creation time.
 	// All cost calculations use these snapshots, not live tool prices.
 	DurationUnit         string `json:"duration_unit"`
@@ -31,6 +37,7 @@ type Rental struct {
 	WeeklyPriceCents     int32  `json:"weekly_price_cents"`
 	MonthlyPriceCents    int32  `json:"monthly_price_cents"`
 	ReplacementCostCents int32  `json:"replacement_cost_cents"`
+	PricingRounding      string `json:"pricing_rounding"` // round_up, nearest or prorate; see utils.RoundingPolicy
 	TotalCostCents         int32        `json:"total_cost_cents"`
 	Status                 RentalStatus `json:"status"`
 	CompletedBy            *int32       `json:"completed_by,omitempty"`
@@ -43,3 +50,122 @@ type Rental struct {
 	CreatedOn              string       `json:"created_on"`
 	UpdatedOn              string       `json:"updated_on"`
 }
+
+// BusyRentalStatuses are the statuses in which a rental occupies its tool's calendar
+var BusyRentalStatuses = []RentalStatus{
+	RentalStatusScheduled,
+	RentalStatusActive,
+	RentalStatusOverdue,
+	RentalStatusReturnDateChanged,
+	RentalStatusReturnDateChangeRejected,
+	RentalStatusEarlyReturnRequested,
+}
+
+// InProgressRentalStatuses are the statuses of a rental whose tool is with the renter
+var InProgressRentalStatuses = []RentalStatus{
+	RentalStatusActive,
+	RentalStatusOverdue,
+	RentalStatusReturnDateChanged,
+	RentalStatusReturnDateChangeRejected,
+	RentalStatusEarlyReturnRequested,
+}
+
+// OwnerActionRentalStatuses are the statuses in which the owner is expected to act next:
+// answer a request or an extension, or confirm the return of an overdue tool
+var OwnerActionRentalStatuses = []RentalStatus{
+	RentalStatusPending,
+	RentalStatusReturnDateChanged,
+	RentalStatusOverdue,
+}
+
+// RenterActionRentalStatuses are the statuses in which the renter is expected to act next:
+// finalize an approval, answer a counter-proposal or an early return request, or bring back
+// an overdue tool
+var RenterActionRentalStatuses = []RentalStatus{
+	RentalStatusApproved,
+	RentalStatusReturnDateChangeRejected,
+	RentalStatusEarlyReturnRequested,
+	RentalStatusOverdue,
+}
+
+// AwaitsActionFrom reports whether the rental is waiting on userID, as its owner or renter
+func (r *Rental) AwaitsActionFrom(userID int32) bool {
+	if r.OwnerID == userID && containsRentalStatus(OwnerActionRentalStatuses, r.Status) {
+		return true
+	}
+	return r.RenterID == userID && containsRentalStatus(RenterActionRentalStatuses, r.Status)
+}
+
+func containsRentalStatus(statuses []RentalStatus, status RentalStatus) bool {
+	for _, st := range statuses {
+		if st == status {
+			return true
+		}
+	}
+	return false
+}
+
+// RentalActivityCounts summarizes a user's rentals across all their orgs
+type RentalActivityCounts struct {
+	ActiveRentals  int32 `json:"active_rentals"`  // in progress with the user as renter
+	ActiveLendings int32 `json:"active_lendings"` // in progress with the user as owner
+	// PendingActions counts rentals waiting on the user, see OwnerActionRentalStatuses and
+	// RenterActionRentalStatuses. OVERDUE rentals are left out; they count as active.
+	PendingActions int32 `json:"pending_actions"`
+}
+
+// ToolBusyRange is an inclusive date range during which a tool is committed to a rental
+type ToolBusyRange struct {
+	ToolID    int32        `json:"tool_id"`
+	RentalID  int32        `json:"rental_id"`
+	StartDate string       `json:"start_date"`
+	EndDate   string       `json:"end_date"`
+	Status    RentalStatus `json:"status"`
+}
+
+// RentalEvent records one status transition of a rental
+type RentalEvent struct {
+	ID          int32        `json:"id"`
+	RentalID    int32        `json:"rental_id"`
+	ActorUserID *int32       `json:"actor_user_id"` // nil when a scheduled job made the change
+	FromStatus  RentalStatus `json:"from_status"`   // empty for the creating event
+	ToStatus    RentalStatus `json:"to_status"`
+	Note        string       `json:"note"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// RentalReceipt is the record of a completed rental its parties can keep. All amounts are in
+// CurrencyCode; RentalCostCents + SurchargeOrCreditCents = TotalCents.
+type RentalReceipt struct {
+	RentalID     int32  `json:"rental_id"`
+	OrgID        int32  `json:"org_id"`
+	ToolID       int32  `json:"tool_id"`
+	ToolName     string `json:"tool_name"`
+	OwnerID      int32  `json:"owner_id"`
+	OwnerName    string `json:"owner_name"`
+	RenterID     int32  `json:"renter_id"`
+	RenterName   string `json:"renter_name"`
+	StartDate    string `json:"start_date"`
+	EndDate      string `json:"end_date"`
+	CompletedOn  string `json:"completed_on"`
+	DurationUnit string `json:"duration_unit"`
+	// Lines price the rental period by the units of the price snapshot. AdjustmentCents is the
+	// difference price caps and rounding make, so Lines plus AdjustmentCents add up to
+	// RentalCostCents.
+	Lines                  []RentalReceiptLine `json:"lines"`
+	AdjustmentCents        int32               `json:"adjustment_cents"`
+	RentalCostCents        int32               `json:"rental_cost_cents"`
+	SurchargeOrCreditCents int32               `json:"surcharge_or_credit_cents"`
+	TotalCents             int32               `json:"total_cents"`
+	ChargeBillsplit        bool                `json:"charge_billsplit"` // Settled through the ledger and bill splitting
+	ReturnCondition        string              `json:"return_condition"`
+	CurrencyCode           string              `json:"currency_code"`
+}
+
+// RentalReceiptLine is one priced unit of a receipt, e.g. 2 weeks at 6000 cents
+type RentalReceiptLine struct {
+	Unit           ToolDurationUnit `json:"unit"`
+	Quantity       int32            `json:"quantity"`
+	UnitPriceCents int32            `json:"unit_price_cents"`
+	AmountCents    int32            `json:"amount_cents"`
+}
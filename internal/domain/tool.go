@@ -10,6 +10,20 @@ const (
 	ToolStatusRented      ToolStatus = "RENTED"
 )
 
+// ResolveToolStatus is the status a tool should have when busyRentals of its rentals are in
+// one of BusyRentalStatuses: RENTED while any is, AVAILABLE once none is. UNAVAILABLE is the
+// owner's choice and is kept either way.
+func ResolveToolStatus(current ToolStatus, busyRentals int32) ToolStatus {
+	switch {
+	case current == ToolStatusUnavailable:
+		return current
+	case busyRentals > 0:
+		return ToolStatusRented
+	default:
+		return ToolStatusAvailable
+	}
+}
+
 type ToolCondition string
 
 const (
@@ -43,6 +57,7 @@ type Tool struct {
 	Metro                string           `json:"metro"`
 	Status               ToolStatus       `json:"status"`
 	CreatedOn            string           `json:"created_on"`
+	UpdatedOn            string           `json:"updated_on"`
 	DeletedOn            *string          `json:"deleted_on,omitempty"`
 }
 
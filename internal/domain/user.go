@@ -3,15 +3,36 @@ package domain
 import "time"
 
 type User struct {
-	ID           int32          `json:"id"`
-	Email        string         `json:"email"`
-	PhoneNumber  string         `json:"phone_number"`
-	PasswordHash string         `json:"-"`
-	Name         string         `json:"name"`
-	AvatarURL    string         `json:"avatar_url"`
-	Orgs         []Organization `json:"orgs,omitempty"` // Populated when needed
-	CreatedOn    string         `json:"created_on"`
-	UpdatedOn    string         `json:"updated_on"`
+	ID                     int32                  `json:"id"`
+	Email                  string                 `json:"email"`
+	PhoneNumber            string                 `json:"phone_number"`
+	PasswordHash           string                 `json:"-"`
+	Name                   string                 `json:"name"`
+	AvatarURL              string                 `json:"avatar_url"`
+	EmailVerified          bool                   `json:"email_verified"`
+	NotificationPreference NotificationPreference `json:"notification_preference"` // Empty reads as EMAIL
+	Orgs                   []Organization         `json:"orgs,omitempty"`          // Populated when needed
+	CreatedOn              string                 `json:"created_on"`
+	UpdatedOn              string                 `json:"updated_on"`
+}
+
+// NotificationPreference selects how a user is reached outside the app. Email and push are
+// always sent; SMS is opt-in.
+type NotificationPreference string
+
+const (
+	NotificationPreferenceEmail       NotificationPreference = "EMAIL"         // Default
+	NotificationPreferenceEmailAndSMS NotificationPreference = "EMAIL_AND_SMS" // High-priority events are also texted
+)
+
+// Valid reports whether p is a known preference
+func (p NotificationPreference) Valid() bool {
+	return p == NotificationPreferenceEmail || p == NotificationPreferenceEmailAndSMS
+}
+
+// WantsSMS reports whether the user opted in to SMS and has a number to send it to
+func (u *User) WantsSMS() bool {
+	return u.NotificationPreference == NotificationPreferenceEmailAndSMS && u.PhoneNumber != ""
 }
 
 type UserOrgStatus string
@@ -43,6 +64,17 @@ type UserOrg struct {
 	RentingBlocked      bool          `json:"renting_blocked"`
 	LendingBlocked      bool          `json:"lending_blocked"`
 	BlockedDueToBillID  *int32        `json:"blocked_due_to_bill_id"`
+	CurrencyCode        string        `json:"currency_code"` // The org's currency for BalanceCents; read from orgs, never written
+}
+
+// MemberFilter narrows an organization roster listing; zero values match everything.
+// BlockedOnly matches fully blocked members as well as those with renting or lending blocked.
+type MemberFilter struct {
+	BlockedOnly         bool
+	NegativeBalanceOnly bool
+	Role                UserOrgRole
+	Page                int32
+	PageSize            int32
 }
 
 // PendingCredential holds a temporary password for a user awaiting password reset.
@@ -53,3 +85,22 @@ type PendingCredential struct {
 	ExpiresAt         time.Time  `json:"expires_at"`
 	UsedAt            *time.Time `json:"used_at"`
 }
+
+// UserDataExport bundles everything stored about a single user for a self-service
+// data export. Other users appear only as ExportCounterparty entries.
+type UserDataExport struct {
+	ExportedAt     time.Time            `json:"exported_at"`
+	Profile        User                 `json:"profile"`
+	Memberships    []UserOrg            `json:"memberships"`
+	Rentals        []Rental             `json:"rentals"`
+	Bills          []Bill               `json:"bills"`
+	Transactions   []LedgerTransaction  `json:"transactions"`
+	Notifications  []Notification       `json:"notifications"`
+	Counterparties []ExportCounterparty `json:"counterparties"`
+}
+
+// ExportCounterparty is the redacted view of another user referenced by a rental or bill
+type ExportCounterparty struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
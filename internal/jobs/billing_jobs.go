@@ -1,40 +1,213 @@
 package jobs
 
 import (
-	"container/heap"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
 )
 
-// CheckOverdueBills checks for bills overdue by 10+ days and marks them as DISPUTED
-func (jr *JobRunner) CheckOverdueBills() {
-	jr.runWithRecovery("CheckOverdueBills", func() {
-		ctx := context.Background()
+// EscalatedBill describes a PENDING bill moved to DISPUTED by CheckOverdueBillsAt
+type EscalatedBill struct {
+	ID              int32
+	OrgID           int32
+	AmountCents     int32
+	CurrencyCode    string
+	SettlementMonth string
+	Reason          domain.DisputeReason
+	DebtorID        int32
+	DebtorName      string
+	DebtorEmail     string
+	CreditorID      int32
+	CreditorName    string
+	CreditorEmail   string
+}
 
-		// Call the database function to check overdue bills
-		_, err := jr.db.ExecContext(ctx, "SELECT check_overdue_bills()")
+// CheckOverdueBills disputes bills stuck waiting on an acknowledgment past the configured grace
+// periods. Disputes are settled by admins or, at month end, by ResolveDisputedBills.
+func (jr *JobRunner) CheckOverdueBills() {
+	jr.runWithRecovery("CheckOverdueBills", func() error {
+		escalated, err := jr.CheckOverdueBillsAt(context.Background(), jr.now())
 		if err != nil {
-			logger.Error("Failed to check overdue bills", "error", err)
-			return
+			return fmt.Errorf("failed to check overdue bills: %w", err)
 		}
 
-		logger.Info("Successfully checked overdue bills and initiated disputes")
+		logger.Info("Successfully checked overdue bills and initiated disputes", "count", len(escalated))
+		return nil
 	})
 }
 
+// CheckOverdueBillsAt disputes PENDING bills that are stuck as of now:
+//   - DEBTOR_NO_ACK: the debtor has not acknowledged paying within
+//     billing.debtor_ack_grace_days of the notice
+//   - CREDITOR_NO_ACK: the debtor acknowledged but the creditor has not confirmed receipt
+//     within billing.creditor_ack_grace_days of that acknowledgment
+//
+// Each dispute is recorded as a DISPUTE_OPENED bill action and both parties are notified. Only
+// rows still PENDING are updated, so re-running it escalates nothing twice.
+func (jr *JobRunner) CheckOverdueBillsAt(ctx context.Context, now time.Time) ([]EscalatedBill, error) {
+	query := `
+		WITH escalated AS (
+			UPDATE bills
+			SET status = 'DISPUTED',
+			    disputed_at = $1,
+			    dispute_reason = CASE WHEN debtor_acknowledged_at IS NULL THEN 'DEBTOR_NO_ACK' ELSE 'CREDITOR_NO_ACK' END,
+			    version = version + 1,
+			    updated_at = NOW()
+			WHERE status = 'PENDING'
+			  AND creditor_acknowledged_at IS NULL
+			  AND ((debtor_acknowledged_at IS NULL AND notice_sent_at < $1 - $2 * INTERVAL '1 day')
+			       OR debtor_acknowledged_at < $1 - $3 * INTERVAL '1 day')
+			RETURNING id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month, dispute_reason
+		), actions AS (
+			INSERT INTO bill_actions (bill_id, actor_user_id, action_type, action_details, notes)
+			SELECT id, NULL, 'DISPUTE_OPENED',
+			       jsonb_build_object('reason', dispute_reason, 'grace_days', CASE WHEN dispute_reason = 'DEBTOR_NO_ACK' THEN $2 ELSE $3 END),
+			       CASE WHEN dispute_reason = 'DEBTOR_NO_ACK'
+			            THEN 'Automatically opened dispute: debtor did not acknowledge payment'
+			            ELSE 'Automatically opened dispute: creditor did not confirm receipt' END
+			FROM escalated
+		)
+		SELECT e.id, e.org_id, e.amount_cents, o.currency_code, e.settlement_month, e.dispute_reason,
+		       d.id, d.name, d.email, c.id, c.name, c.email
+		FROM escalated e
+		JOIN orgs o ON o.id = e.org_id
+		JOIN users d ON d.id = e.debtor_user_id
+		JOIN users c ON c.id = e.creditor_user_id
+	`
+
+	rows, err := jr.db.QueryContext(ctx, query, now,
+		jr.config.Billing.DebtorAckGraceDays, jr.config.Billing.CreditorAckGraceDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dispute overdue bills: %w", err)
+	}
+
+	var escalated []EscalatedBill
+	for rows.Next() {
+		var e EscalatedBill
+		if err := rows.Scan(&e.ID, &e.OrgID, &e.AmountCents, &e.CurrencyCode, &e.SettlementMonth, &e.Reason,
+			&e.DebtorID, &e.DebtorName, &e.DebtorEmail, &e.CreditorID, &e.CreditorName, &e.CreditorEmail); err != nil {
+			logger.Error("Failed to scan escalated bill", "error", err)
+			continue
+		}
+		escalated = append(escalated, e)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating escalated bills: %w", err)
+	}
+	rows.Close()
+
+	for _, e := range escalated {
+		logger.Debug("Escalated overdue bill", "bill_id", e.ID, "org_id", e.OrgID, "reason", e.Reason)
+		jr.notifyBillEscalated(ctx, e)
+		if jr.config.Billing.EscalateOverdueBillsToAdmins {
+			jr.notifyAdminsOfEscalatedBill(ctx, e)
+		}
+	}
+
+	return escalated, nil
+}
+
+// notifyBillEscalated tells the debtor and creditor that their bill is now in dispute
+func (jr *JobRunner) notifyBillEscalated(ctx context.Context, e EscalatedBill) {
+	if jr.services == nil {
+		return
+	}
+
+	amount := domain.FormatCents(e.AmountCents, e.CurrencyCode)
+	var debtorMsg, creditorMsg string
+	if e.Reason == domain.DisputeReasonDebtorNoAck {
+		debtorMsg = fmt.Sprintf("You did not confirm paying %s to %s for %s, so the bill is now in dispute.", amount, e.CreditorName, e.SettlementMonth)
+		creditorMsg = fmt.Sprintf("%s did not confirm paying you %s for %s, so the bill is now in dispute.", e.DebtorName, amount, e.SettlementMonth)
+	} else {
+		debtorMsg = fmt.Sprintf("%s did not confirm receiving your %s payment for %s, so the bill is now in dispute.", e.CreditorName, amount, e.SettlementMonth)
+		creditorMsg = fmt.Sprintf("You did not confirm receiving %s from %s for %s, so the bill is now in dispute.", amount, e.DebtorName, e.SettlementMonth)
+	}
+
+	parties := []struct {
+		userID  int32
+		email   string
+		message string
+	}{
+		{e.DebtorID, e.DebtorEmail, debtorMsg},
+		{e.CreditorID, e.CreditorEmail, creditorMsg},
+	}
+
+	for _, p := range parties {
+		if jr.services.Notification != nil {
+			err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+				UserID:  p.userID,
+				OrgID:   e.OrgID,
+				Title:   "Payment Disputed",
+				Message: p.message,
+				Attributes: map[string]string{
+					"type":           "BILL_DISPUTE_OPENED",
+					"bill_id":        fmt.Sprintf("%d", e.ID),
+					"dispute_reason": string(e.Reason),
+					"channel_id":     string(domain.ChannelDispute),
+				},
+			})
+			if err != nil {
+				logger.Error("Failed to send dispute notice", "bill_id", e.ID, "user_id", p.userID, "error", err)
+			}
+		}
+		if jr.services.Email != nil {
+			body := fmt.Sprintf("%s\n\nAn org admin will review it. Unresolved disputes are settled automatically at month end.\n\nBill ID: %d\n\nThank you,\nUbertool Team", p.message, e.ID)
+			if err := jr.services.Email.SendAdminNotification(ctx, p.email, "Payment Disputed: "+e.SettlementMonth, body); err != nil {
+				logger.Error("Failed to send dispute email", "bill_id", e.ID, "user_id", p.userID, "error", err)
+			}
+		}
+	}
+}
+
+// notifyAdminsOfEscalatedBill flags an automatically opened dispute to the org's admins
+func (jr *JobRunner) notifyAdminsOfEscalatedBill(ctx context.Context, e EscalatedBill) {
+	if jr.services == nil || jr.services.Notification == nil {
+		logger.Warn("Notification service not configured, skipping dispute escalation", "bill_id", e.ID)
+		return
+	}
+
+	adminIDs, err := jr.orgAdminIDs(ctx, e.OrgID)
+	if err != nil {
+		logger.Error("Failed to query org admins for dispute escalation", "org_id", e.OrgID, "error", err)
+		return
+	}
+
+	message := fmt.Sprintf("The %s bill from %s to %s (%s) was disputed automatically (%s) and needs review.",
+		e.SettlementMonth, e.DebtorName, e.CreditorName, domain.FormatCents(e.AmountCents, e.CurrencyCode), e.Reason)
+	for _, adminID := range adminIDs {
+		err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+			UserID:  adminID,
+			OrgID:   e.OrgID,
+			Title:   "Dispute Needs Review",
+			Message: message,
+			Attributes: map[string]string{
+				"type":           "BILL_DISPUTE_ESCALATED",
+				"bill_id":        fmt.Sprintf("%d", e.ID),
+				"dispute_reason": string(e.Reason),
+				"channel_id":     string(domain.ChannelAdmin),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send dispute escalation", "bill_id", e.ID, "admin_id", adminID, "error", err)
+		}
+	}
+}
+
 // ResolveDisputedBills applies system default action to unresolved disputed bills
 func (jr *JobRunner) ResolveDisputedBills() {
-	jr.runWithRecovery("ResolveDisputedBills", func() {
+	jr.runWithRecovery("ResolveDisputedBills", func() error {
 		ctx := context.Background()
 
 		// Get all organizations
 		orgs, err := jr.store.OrganizationRepository.List(ctx)
 		if err != nil {
-			logger.Error("Failed to get organizations", "error", err)
-			return
+			return fmt.Errorf("failed to get organizations: %w", err)
 		}
 
 		// Get current settlement month (format: 'YYYY-MM')
@@ -70,48 +243,180 @@ func (jr *JobRunner) ResolveDisputedBills() {
 		logger.Info("Completed resolving disputed bills",
 			"total_orgs_processed", totalResolved,
 			"settlement_month", currentMonth)
+		return nil
 	})
 }
 
-// TakeBalanceSnapshots takes a snapshot of all user balances before bill splitting
+// TakeBalanceSnapshots takes a snapshot of all active member balances. It runs before bill
+// splitting and also feeds balance history; a second run on the same day overwrites that day's row.
 func (jr *JobRunner) TakeBalanceSnapshots() {
-	jr.runWithRecovery("TakeBalanceSnapshots", func() {
+	jr.runWithRecovery("TakeBalanceSnapshots", func() error {
 		ctx := context.Background()
 
 		// Get current settlement month (format: 'YYYY-MM')
 		settlementMonth := time.Now().Format("2006-01")
 
-		// Insert balance snapshots for all users in all orgs
+		// Upsert today's balance snapshot for every active member in all orgs
 		query := `
-			INSERT INTO balance_snapshots (user_id, org_id, balance_cents, settlement_month, snapshot_at)
-			SELECT user_id, org_id, balance_cents, $1, NOW()
+			INSERT INTO balance_snapshots (user_id, org_id, balance_cents, settlement_month, snapshot_date, snapshot_at)
+			SELECT user_id, org_id, COALESCE(balance_cents, 0), $1, CURRENT_DATE, NOW()
 			FROM users_orgs
-			ON CONFLICT (user_id, org_id, settlement_month) DO NOTHING
+			WHERE status = 'ACTIVE'
+			ON CONFLICT (user_id, org_id, snapshot_date) DO UPDATE
+			SET balance_cents = EXCLUDED.balance_cents,
+			    settlement_month = EXCLUDED.settlement_month,
+			    snapshot_at = EXCLUDED.snapshot_at
 		`
 
 		result, err := jr.db.ExecContext(ctx, query, settlementMonth)
 		if err != nil {
-			logger.Error("Failed to take balance snapshots", "error", err)
-			return
+			return fmt.Errorf("failed to take balance snapshots: %w", err)
 		}
 
 		rowsAffected, _ := result.RowsAffected()
 		logger.Info("Balance snapshots taken",
 			"count", rowsAffected,
 			"settlement_month", settlementMonth)
+		return nil
+	})
+}
+
+// LowBalanceMember is a member alerted by SendLowBalanceAlertsAt
+type LowBalanceMember struct {
+	UserID       int32
+	OrgID        int32
+	BalanceCents int32
+	CurrencyCode string
+	OrgName      string
+	Email        string
+	Name         string
+}
+
+// SendLowBalanceAlerts warns members who owe billing.low_balance_alert_cents or more, so they
+// can settle up before month-end bill splitting bills them
+func (jr *JobRunner) SendLowBalanceAlerts() {
+	jr.runWithRecovery("SendLowBalanceAlerts", func() error {
+		alerted, err := jr.SendLowBalanceAlertsAt(context.Background(), jr.now())
+		if err != nil {
+			return fmt.Errorf("failed to send low balance alerts: %w", err)
+		}
+		logger.Info("Low balance alerts sent", "count", len(alerted))
+		return nil
 	})
 }
 
-// PerformBillSplitting performs the monthly bill splitting calculation
+// SendLowBalanceAlertsAt alerts every active member whose balance is at or below minus the
+// threshold. users_orgs.low_balance_alerted_at marks a member as alerted and is cleared once
+// their balance recovers above the threshold, so each crossing is alerted once however long
+// the balance stays low.
+func (jr *JobRunner) SendLowBalanceAlertsAt(ctx context.Context, now time.Time) ([]LowBalanceMember, error) {
+	threshold := jr.config.Billing.LowBalanceAlertCents
+
+	// Re-arm members who are back above the threshold
+	_, err := jr.db.ExecContext(ctx, `
+		UPDATE users_orgs SET low_balance_alerted_at = NULL
+		WHERE low_balance_alerted_at IS NOT NULL AND COALESCE(balance_cents, 0) > -$1
+	`, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset low balance alerts: %w", err)
+	}
+
+	// Record the alert before sending so concurrent or repeated runs never send it twice
+	query := `
+		UPDATE users_orgs uo
+		SET low_balance_alerted_at = $2
+		FROM users u, orgs o
+		WHERE u.id = uo.user_id
+		  AND o.id = uo.org_id
+		  AND uo.status = 'ACTIVE'
+		  AND uo.balance_cents <= -$1
+		  AND uo.low_balance_alerted_at IS NULL
+		RETURNING uo.user_id, uo.org_id, uo.balance_cents, o.currency_code, o.name, u.email, u.name
+	`
+	rows, err := jr.db.QueryContext(ctx, query, threshold, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record low balance alerts: %w", err)
+	}
+
+	var alerted []LowBalanceMember
+	for rows.Next() {
+		var m LowBalanceMember
+		if err := rows.Scan(&m.UserID, &m.OrgID, &m.BalanceCents, &m.CurrencyCode, &m.OrgName, &m.Email, &m.Name); err != nil {
+			logger.Error("Failed to scan low balance member", "error", err)
+			continue
+		}
+		alerted = append(alerted, m)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating low balance members: %w", err)
+	}
+	rows.Close()
+
+	for _, m := range alerted {
+		logger.Debug("Alerting member of low balance", "user_id", m.UserID, "org_id", m.OrgID, "balance_cents", m.BalanceCents)
+		jr.notifyLowBalance(ctx, m)
+	}
+
+	return alerted, nil
+}
+
+// notifyLowBalance tells the member how much they owe in the org
+func (jr *JobRunner) notifyLowBalance(ctx context.Context, m LowBalanceMember) {
+	if jr.services == nil {
+		return
+	}
+
+	message := fmt.Sprintf("You owe %s in %s. Settle up with other members before bills are issued at the end of the month.",
+		domain.FormatCents(-m.BalanceCents, m.CurrencyCode), m.OrgName)
+	if jr.services.Notification != nil {
+		err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+			UserID:  m.UserID,
+			OrgID:   m.OrgID,
+			Title:   "Balance Alert",
+			Message: message,
+			Attributes: map[string]string{
+				"type":          "LOW_BALANCE",
+				"balance_cents": fmt.Sprintf("%d", m.BalanceCents),
+				"channel_id":    string(domain.ChannelBillSplitting),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send low balance notification", "user_id", m.UserID, "org_id", m.OrgID, "error", err)
+		}
+	}
+	if jr.services.Email != nil {
+		body := fmt.Sprintf("Hi %s,\n\n%s\n\nThank you,\nUbertool Team", m.Name, message)
+		if err := jr.services.Email.SendAdminNotification(ctx, m.Email, "Balance Alert: "+m.OrgName, body); err != nil {
+			logger.Error("Failed to send low balance email", "user_id", m.UserID, "org_id", m.OrgID, "error", err)
+		}
+	}
+}
+
+// ErrSettlementAlreadyRun is returned by PerformBillSplittingForOrg when bills were
+// already generated for the org and month and force is not set
+var ErrSettlementAlreadyRun = repository.ErrSettlementAlreadyRun
+
+// PerformBillSplitting performs the monthly bill splitting calculation.
+// Orgs that were already settled for the month are skipped.
 func (jr *JobRunner) PerformBillSplitting() {
-	jr.runWithRecovery("PerformBillSplitting", func() {
+	jr.performBillSplitting(false)
+}
+
+// ForcePerformBillSplitting reruns bill splitting for every org, even those already
+// settled for the month. Existing bills are kept; only missing ones are inserted.
+func (jr *JobRunner) ForcePerformBillSplitting() {
+	jr.performBillSplitting(true)
+}
+
+func (jr *JobRunner) performBillSplitting(force bool) {
+	jr.runWithRecovery("PerformBillSplitting", func() error {
 		ctx := context.Background()
 
 		// Get all organizations
 		orgs, err := jr.store.OrganizationRepository.List(ctx)
 		if err != nil {
-			logger.Error("Failed to get organizations", "error", err)
-			return
+			return fmt.Errorf("failed to get organizations: %w", err)
 		}
 
 		// Get previous month for settlement (format: 'YYYY-MM')
@@ -119,7 +424,14 @@ func (jr *JobRunner) PerformBillSplitting() {
 
 		totalBills := 0
 		for _, org := range orgs {
-			billCount, err := jr.PerformBillSplittingForOrg(ctx, org.ID, org.Name, lastMonth, int(org.SettlementThresholdCents))
+			billCount, err := jr.PerformBillSplittingForOrg(ctx, org.ID, org.Name, lastMonth, int(org.EffectiveSettlementThresholdCents(jr.config.Billing.DefaultSettlementThresholdCents)), force)
+			if errors.Is(err, ErrSettlementAlreadyRun) {
+				logger.Info("Skipping bill splitting for org: already settled",
+					"org_id", org.ID,
+					"org_name", org.Name,
+					"settlement_month", lastMonth)
+				continue
+			}
 			if err != nil {
 				logger.Error("Failed to perform bill splitting for org",
 					"org_id", org.ID,
@@ -132,125 +444,29 @@ func (jr *JobRunner) PerformBillSplitting() {
 
 		logger.Info("Bill splitting completed",
 			"total_bills_created", totalBills,
-			"settlement_month", lastMonth)
+			"settlement_month", lastMonth,
+			"forced", force)
+		return nil
 	})
 }
 
-// PerformBillSplittingForOrg performs bill splitting for a single organization
-func (jr *JobRunner) PerformBillSplittingForOrg(ctx context.Context, orgID int32, orgName, settlementMonth string, thresholdCents int) (int, error) {
-	// Get all users in the organization with their balances
-	query := `
-		SELECT user_id, balance_cents
-		FROM users_orgs
-		WHERE org_id = $1
-		  AND status = 'ACTIVE'
-		  AND balance_cents != 0
-	`
-
-	rows, err := jr.db.QueryContext(ctx, query, orgID)
+// PerformBillSplittingForOrg performs bill splitting for a single organization.
+// The run is recorded in settlement_runs in the same transaction as the bills, so a
+// second run for the same org and month returns ErrSettlementAlreadyRun unless force is set.
+func (jr *JobRunner) PerformBillSplittingForOrg(ctx context.Context, orgID int32, orgName, settlementMonth string, thresholdCents int, force bool) (int, error) {
+	// Same algorithm as the RunSettlement RPC
+	bills, err := jr.store.BillRepository.CreateSettlementBills(ctx, orgID, settlementMonth, int32(thresholdCents), force)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get user balances: %w", err)
-	}
-	defer rows.Close()
-
-	// Collect debtors (negative balance) and creditors (positive balance)
-	var debtors, creditors []Account
-	
-	for rows.Next() {
-		var userID, balance int
-		if err := rows.Scan(&userID, &balance); err != nil {
-			logger.Error("Failed to scan user balance", "error", err)
-			continue
-		}
-
-		acc := Account{UserID: userID, Balance: balance}
-		if balance < 0 {
-			debtors = append(debtors, acc)
-		} else if balance > 0 {
-			creditors = append(creditors, acc)
-		}
-	}
-
-	if err := rows.Err(); err != nil {
-		return 0, fmt.Errorf("error iterating user balances: %w", err)
-	}
-
-	// Calculate transactions using the heap-based greedy algorithm
-	transactions := CalculateTransactions(creditors, debtors, thresholdCents)
-
-	// Save transactions to DB
-	billCount := 0
-	for _, txn := range transactions {
-		insertQuery := `
-			INSERT INTO bills (
-				org_id, debtor_user_id, creditor_user_id, 
-				amount_cents, settlement_month, status, 
-				notice_sent_at, created_at, updated_at
-			)
-			VALUES ($1, $2, $3, $4, $5, 'PENDING', NULL, NOW(), NOW())
-			ON CONFLICT (org_id, debtor_user_id, creditor_user_id, settlement_month) DO NOTHING
-		`
-
-		_, err := jr.db.ExecContext(ctx, insertQuery,
-			orgID, txn.FromUserID, txn.ToUserID,
-			txn.Amount, settlementMonth)
-
-		if err != nil {
-			logger.Error("Failed to insert bill",
-				"org_id", orgID,
-				"debtor_id", txn.FromUserID,
-				"creditor_id", txn.ToUserID,
-				"amount", txn.Amount,
-				"error", err)
-		} else {
-			billCount++
-			logger.Debug("Created bill",
-				"org_id", orgID,
-				"debtor_id", txn.FromUserID,
-				"creditor_id", txn.ToUserID,
-				"amount_cents", txn.Amount)
-		}
+		return 0, err
 	}
 
 	logger.Info("Bill splitting completed for org",
 		"org_id", orgID,
 		"org_name", orgName,
-		"bills_created", billCount,
+		"bills_created", len(bills),
 		"settlement_month", settlementMonth)
 
-	return billCount, nil
-}
-
-// Account represents a user account with balance
-type Account struct {
-	UserID  int
-	Balance int // Positive = owed money, Negative = owes money
-}
-
-// InternalTransaction represents a calculated payment
-type InternalTransaction struct {
-	FromUserID int
-	ToUserID   int
-	Amount     int
-}
-
-// AccountHeap implements heap.Interface for Account prioritization
-type AccountHeap []Account
-
-func (h AccountHeap) Len() int           { return len(h) }
-func (h AccountHeap) Less(i, j int) bool { return abs(h[i].Balance) > abs(h[j].Balance) } // Max heap by absolute balance
-func (h AccountHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
-
-func (h *AccountHeap) Push(x interface{}) {
-	*h = append(*h, x.(Account))
-}
-
-func (h *AccountHeap) Pop() interface{} {
-	old := *h
-	n := len(old)
-	item := old[n-1]
-	*h = old[0 : n-1]
-	return item
+	return len(bills), nil
 }
 
 // abs returns the absolute value of an integer
@@ -260,86 +476,3 @@ func abs(x int) int {
 	}
 	return x
 }
-
-// CalculateTransactions generates optimal transactions to settle bills
-func CalculateTransactions(creditors, debtors []Account, threshold int) []InternalTransaction {
-	var transactions []InternalTransaction
-
-	// Create max heaps
-	creditorHeap := &AccountHeap{}
-	debtorHeap := &AccountHeap{}
-
-	heap.Init(creditorHeap)
-	heap.Init(debtorHeap)
-
-	// Add all creditors and debtors to heaps
-	for _, c := range creditors {
-		heap.Push(creditorHeap, c)
-	}
-	for _, d := range debtors {
-		heap.Push(debtorHeap, d)
-	}
-
-	// Greedy matching: continue while BOTH tops >= threshold
-	for creditorHeap.Len() > 0 && debtorHeap.Len() > 0 {
-		// Peek at the tops to check threshold condition
-		creditor := heap.Pop(creditorHeap).(Account)
-		debtor := heap.Pop(debtorHeap).(Account)
-
-		creditorAmount := creditor.Balance
-		debtorAmount := abs(debtor.Balance)
-
-		// Check if BOTH are below threshold - if so, stop settling
-		if creditorAmount < threshold && debtorAmount < threshold {
-			// Stop settling
-			break
-		}
-		
-		// If only one is below threshold, we still settle if the other is large enough?
-		// User requirement: "Perform greedy match ... until both the top positive and negative accounts are having the amount less than a threshold amount."
-		// This implies the loop continues as long as specific condition is met.
-		// "until both ... are less" == "while NOT (both < threshold)" == "while at least one >= threshold".
-		// However, standard debt settlement usually stops when you can't satisfy the threshold constraint.
-		// The prompt says: "until BOTH the top positive and negative accounts are having the amount less than a threshold amount."
-		// This suggests if one is large (e.g. 100) and one is small (e.g. 2), we should still match them? 
-		// "Greedy match to pay from the negative balance accounts to positive accounts"
-		// If I have Creditor +100 and Debtor -2 (Threshold 5).
-		// Creditor is > 5. Debtor is < 5. "Both < 5" is FALSE. So we continue.
-		// We pay min(100, 2) = 2.
-		// Creditor becomes +98. Debtor becomes 0.
-		// The small debtor is cleared. The large creditor remains large.
-		// This effectively sweeps up small debts into large creditors, which is good.
-		
-		// Transaction amount is limited by the smaller of the two availabilities
-		transactionAmount := creditorAmount
-		if debtorAmount < transactionAmount {
-			transactionAmount = debtorAmount
-		}
-
-		// Create transaction
-		transactions = append(transactions, InternalTransaction{
-			FromUserID: debtor.UserID,
-			ToUserID:   creditor.UserID,
-			Amount:     transactionAmount,
-		})
-
-		// Update balances
-		creditorRemaining := creditorAmount - transactionAmount
-		debtorRemaining := debtorAmount - transactionAmount
-
-		// Push back if remaining balance > 0
-		// Note: We don't check threshold here, we just push back. 
-		// The loop condition handles the stopping criteria.
-		if creditorRemaining > 0 {
-			creditor.Balance = creditorRemaining
-			heap.Push(creditorHeap, creditor)
-		}
-
-		if debtorRemaining > 0 {
-			debtor.Balance = -debtorRemaining
-			heap.Push(debtorHeap, debtor)
-		}
-	}
-
-	return transactions
-}
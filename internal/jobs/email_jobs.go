@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// retryEmailBatchSize caps how many outbox entries one run of RetryFailedEmails claims
+const retryEmailBatchSize = 200
+
+// RetryFailedEmails resends outbox emails whose last attempt failed or that never reached a worker
+func (jr *JobRunner) RetryFailedEmails() {
+	jr.runWithRecovery("RetryFailedEmails", func() error {
+		if jr.services == nil || jr.services.EmailOutbox == nil {
+			logger.Warn("Email outbox not configured, skipping retry")
+			return nil
+		}
+
+		sent, failed, err := jr.services.EmailOutbox.RetryPending(context.Background(), retryEmailBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to retry outbox emails: %w", err)
+		}
+
+		logger.Info("Outbox email retry completed",
+			"sent", sent,
+			"failed", failed)
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// JobLocker grants one instance at a time the right to run a named job
+type JobLocker interface {
+	// TryLock returns acquired=false, without an error, when another instance holds the lock.
+	// When acquired, unlock must be called once the job is done.
+	TryLock(ctx context.Context, jobName string) (unlock func(), acquired bool, err error)
+}
+
+// AdvisoryLocker implements JobLocker with Postgres session-level advisory locks keyed by job
+// name. Each lock is held on its own connection, so it is released by unlock or, if the
+// process dies, when Postgres drops the connection.
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+// NewAdvisoryLocker creates an AdvisoryLocker on db
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+// advisoryLockKey namespaces job locks so they cannot collide with other advisory lock users
+const advisoryLockKey = "hashtext('ubertool.job.' || $1)"
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection for job lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock("+advisoryLockKey+")", jobName).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to acquire job lock: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		var released bool
+		err := conn.QueryRowContext(context.Background(), "SELECT pg_advisory_unlock("+advisoryLockKey+")", jobName).Scan(&released)
+		if err != nil || !released {
+			// The session may still hold the lock; discard the connection rather than pool it
+			logger.Error("Failed to release job lock, dropping connection", "job", jobName, "error", err)
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return unlock, true, nil
+}
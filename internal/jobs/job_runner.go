@@ -1,9 +1,13 @@
 package jobs
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository/postgres"
 	"ubertool-backend-trusted/internal/service"
@@ -15,15 +19,19 @@ type JobRunner struct {
 	store    *postgres.Store
 	services *Services
 	config   *config.Config
+	now      func() time.Time
+	locker   JobLocker
 }
 
 // Services holds all service dependencies needed by jobs
 type Services struct {
-	Email  service.EmailService
-	Rental service.RentalService
-	Ledger service.LedgerService
-	Org    service.OrganizationService
-	User   service.UserService
+	Email        service.EmailService
+	Rental       service.RentalService
+	Ledger       service.LedgerService
+	Org          service.OrganizationService
+	User         service.UserService
+	Notification service.NotificationService
+	EmailOutbox  *service.EmailOutbox
 }
 
 // NewJobRunner creates a new job runner with all dependencies
@@ -33,32 +41,97 @@ func NewJobRunner(db *sql.DB, store *postgres.Store, services *Services, cfg *co
 		store:    store,
 		services: services,
 		config:   cfg,
+		now:      time.Now,
 	}
 }
 
+// SetClock replaces the time source used by jobs that compare against the current time
+func (jr *JobRunner) SetClock(now func() time.Time) {
+	jr.now = now
+}
+
+// SetLocker makes every job run only while holding its lock from locker, so replicas of the
+// cronjob never run the same job at the same time. Without a locker jobs run unguarded.
+func (jr *JobRunner) SetLocker(locker JobLocker) {
+	jr.locker = locker
+}
+
 // Config returns the configuration
 func (jr *JobRunner) Config() *config.Config {
 	return jr.config
 }
 
-// runWithRecovery executes a job function with panic recovery
-func (jr *JobRunner) runWithRecovery(jobName string, fn func()) {
+// runWithRecovery executes a job function with panic recovery, under the job's lock when a
+// locker is set. If another instance holds the lock the run is skipped. Each run that starts
+// is recorded in job_runs as SUCCEEDED, or FAILED when fn returns an error or panics.
+func (jr *JobRunner) runWithRecovery(jobName string, fn func() error) {
+	if jr.locker != nil {
+		unlock, acquired, err := jr.locker.TryLock(context.Background(), jobName)
+		if err != nil {
+			logger.Error("Failed to lock job, skipping run", "job", jobName, "error", err)
+			return
+		}
+		if !acquired {
+			logger.Info("Job is running on another instance, skipping", "job", jobName)
+			return
+		}
+		defer unlock()
+	}
+
+	run := jr.startJobRun(jobName)
+
+	var jobErr error
 	defer func() {
 		if r := recover(); r != nil {
 			logger.Error("Job panicked", "job", jobName, "panic", r)
+			jobErr = fmt.Errorf("panic: %v", r)
 		}
+		jr.finishJobRun(run, jobErr)
 	}()
 
 	logger.Info("Starting job", "job", jobName)
-	fn()
+	if jobErr = fn(); jobErr != nil {
+		logger.Error("Job failed", "job", jobName, "error", jobErr)
+		return
+	}
 	logger.Info("Job completed", "job", jobName)
 }
 
+// startJobRun records a RUNNING row; history is best effort and never blocks the job
+func (jr *JobRunner) startJobRun(jobName string) *domain.JobRun {
+	if jr.store == nil || jr.store.JobRunRepository == nil {
+		return nil
+	}
+	run, err := jr.store.JobRunRepository.Start(context.Background(), jobName)
+	if err != nil {
+		logger.Error("Failed to record job start", "job", jobName, "error", err)
+		return nil
+	}
+	return run
+}
+
+func (jr *JobRunner) finishJobRun(run *domain.JobRun, jobErr error) {
+	if run == nil {
+		return
+	}
+	status, errMsg := domain.JobRunStatusSucceeded, ""
+	if jobErr != nil {
+		status, errMsg = domain.JobRunStatusFailed, jobErr.Error()
+	}
+	if err := jr.store.JobRunRepository.Finish(context.Background(), run.ID, status, errMsg); err != nil {
+		logger.Error("Failed to record job result", "job", run.JobName, "job_run_id", run.ID, "error", err)
+	}
+}
+
 // RunAllNightlyJobs runs all nightly jobs (for manual execution)
 func (jr *JobRunner) RunAllNightlyJobs() {
+	jr.ExpireStalePendingRentals()
+	jr.AutoActivateScheduledRentals()
 	jr.MarkOverdueRentals()
 	jr.SendOverdueReminders()
 	jr.SendBillReminders()
+	jr.SendLowBalanceAlerts()
+	jr.ReconcileBalances()
 }
 
 // RunAllMonthlyJobs runs all monthly jobs (for manual execution)
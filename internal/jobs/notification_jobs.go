@@ -5,90 +5,205 @@ import (
 	"fmt"
 	"time"
 
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 )
 
-// SendOverdueReminders sends email reminders to renters with overdue rentals
+// Overdue reminder levels, stored in rentals.last_reminder_level
+const (
+	overdueReminderNone   = 0
+	overdueReminderFirst  = 1
+	overdueReminderSecond = 2
+	overdueReminderFinal  = 3 // final notice; the rental is flagged to org admins
+)
+
+// OverdueRental is an OVERDUE rental considered by SendOverdueRemindersOn
+type OverdueRental struct {
+	ID                int32
+	OrgID             int32
+	RenterID          int32
+	EndDate           string
+	DaysOverdue       int
+	LastReminderLevel int
+	RenterEmail       string
+	RenterName        string
+	ToolName          string
+}
+
+// SendOverdueReminders sends escalating email reminders to renters with overdue rentals
 func (jr *JobRunner) SendOverdueReminders() {
-	jr.runWithRecovery("SendOverdueReminders", func() {
-		ctx := context.Background()
+	jr.runWithRecovery("SendOverdueReminders", func() error {
+		count, err := jr.SendOverdueRemindersOn(context.Background(), jr.now().Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("failed to send overdue reminders: %w", err)
+		}
+		logger.Info("Overdue reminders sent", "count", count)
+		return nil
+	})
+}
 
-		// Find overdue rentals
-		query := `
-			SELECT r.id, r.renter_id, r.tool_id, r.end_date, 
-			       u.email, u.name as renter_name,
-			       t.name as tool_name, t.owner_id
-			FROM rentals r
-			JOIN users u ON r.renter_id = u.id
-			JOIN tools t ON r.tool_id = t.id
-			WHERE r.status = 'OVERDUE'
-		`
+// SendOverdueRemindersOn sends each OVERDUE rental the highest reminder level it has reached
+// as of today (YYYY-MM-DD) and not yet received. Levels only move up, so each fires once;
+// a rental that skips a level (e.g. the job did not run) gets only the higher one.
+func (jr *JobRunner) SendOverdueRemindersOn(ctx context.Context, today string) (int, error) {
+	query := `
+		SELECT r.id, r.org_id, r.renter_id, r.end_date::text, ($1::date - r.end_date) AS days_overdue,
+		       r.last_reminder_level, u.email, u.name, t.name
+		FROM rentals r
+		JOIN users u ON r.renter_id = u.id
+		JOIN tools t ON r.tool_id = t.id
+		WHERE r.status = 'OVERDUE'
+		  AND r.last_reminder_level < $2
+	`
+
+	rows, err := jr.db.QueryContext(ctx, query, today, overdueReminderFinal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query overdue rentals: %w", err)
+	}
+
+	var overdue []OverdueRental
+	for rows.Next() {
+		var o OverdueRental
+		if err := rows.Scan(&o.ID, &o.OrgID, &o.RenterID, &o.EndDate, &o.DaysOverdue,
+			&o.LastReminderLevel, &o.RenterEmail, &o.RenterName, &o.ToolName); err != nil {
+			logger.Error("Failed to scan overdue rental", "error", err)
+			continue
+		}
+		overdue = append(overdue, o)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("error iterating overdue rentals: %w", err)
+	}
+	rows.Close()
+
+	count := 0
+	for _, o := range overdue {
+		level := jr.overdueReminderLevel(o.DaysOverdue)
+		if level <= o.LastReminderLevel {
+			continue
+		}
 
-		rows, err := jr.db.QueryContext(ctx, query)
+		// Record the level before sending so concurrent or repeated runs never send it twice
+		res, err := jr.db.ExecContext(ctx,
+			"UPDATE rentals SET last_reminder_level = $2, updated_on = NOW() WHERE id = $1 AND last_reminder_level < $2",
+			o.ID, level)
 		if err != nil {
-			logger.Error("Failed to query overdue rentals", "error", err)
-			return
+			logger.Error("Failed to record overdue reminder level", "rental_id", o.ID, "level", level, "error", err)
+			continue
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			continue
 		}
-		defer rows.Close()
 
-		count := 0
-		for rows.Next() {
-			var (
-				rentalID    int
-				renterID    int
-				toolID      int
-				endDate     string
-				email       string
-				renterName  string
-				toolName    string
-				ownerID     int
-			)
+		subject, body := overdueReminderEmail(level, o)
+		if err := jr.services.Email.SendAdminNotification(ctx, o.RenterEmail, subject, body); err != nil {
+			logger.Error("Failed to send overdue reminder email",
+				"rental_id", o.ID,
+				"renter_id", o.RenterID,
+				"level", level,
+				"error", err)
+			continue
+		}
+		if level == overdueReminderFinal {
+			jr.notifyAdminsOfOverdueRental(ctx, o)
+		}
 
-			if err := rows.Scan(&rentalID, &renterID, &toolID, &endDate, &email, &renterName, &toolName, &ownerID); err != nil {
-				logger.Error("Failed to scan overdue rental", "error", err)
-				continue
-			}
+		count++
+		logger.Debug("Sent overdue reminder",
+			"rental_id", o.ID,
+			"renter_id", o.RenterID,
+			"level", level,
+			"days_overdue", o.DaysOverdue)
+	}
 
-			// Send email reminder
-			subject := "Reminder: Overdue Tool Return"
-			body := fmt.Sprintf(`Dear %s,
+	return count, nil
+}
 
-This is a reminder that your rental of "%s" (Rental ID: %d) was due on %s and is now overdue.
+// overdueReminderLevel maps days past end_date to the reminder level due
+func (jr *JobRunner) overdueReminderLevel(daysOverdue int) int {
+	cfg := jr.config.Rental
+	switch {
+	case daysOverdue >= cfg.OverdueFinalNoticeDays:
+		return overdueReminderFinal
+	case daysOverdue >= cfg.OverdueSecondNoticeDays:
+		return overdueReminderSecond
+	case daysOverdue >= cfg.OverdueReminderDays:
+		return overdueReminderFirst
+	default:
+		return overdueReminderNone
+	}
+}
 
-Please return the tool as soon as possible to avoid additional charges.
+func overdueReminderEmail(level int, o OverdueRental) (subject, body string) {
+	switch level {
+	case overdueReminderFinal:
+		return "Final Notice: Overdue Tool Return", fmt.Sprintf(`Dear %s,
+
+Your rental of "%s" (Rental ID: %d) was due on %s and is now %d days overdue.
+
+This is a final notice. Your organization's admins have been notified and will follow up with you. Please return the tool immediately.
 
 Thank you,
-Ubertool Team`, renterName, toolName, rentalID, endDate)
+Ubertool Team`, o.RenterName, o.ToolName, o.ID, o.EndDate, o.DaysOverdue)
+	case overdueReminderSecond:
+		return "Second Notice: Overdue Tool Return", fmt.Sprintf(`Dear %s,
 
-			err := jr.services.Email.SendAdminNotification(ctx, email, subject, body)
-			if err != nil {
-				logger.Error("Failed to send overdue reminder email",
-					"rental_id", rentalID,
-					"renter_id", renterID,
-					"email", email,
-					"error", err)
-				continue
-			}
+Your rental of "%s" (Rental ID: %d) was due on %s and is now %d days overdue.
 
-			count++
-			logger.Debug("Sent overdue reminder",
-				"rental_id", rentalID,
-				"renter_id", renterID,
-				"email", email)
-		}
+Please return the tool right away, or contact the owner to arrange an extension.
 
-		if err := rows.Err(); err != nil {
-			logger.Error("Error iterating overdue rentals", "error", err)
-			return
-		}
+Thank you,
+Ubertool Team`, o.RenterName, o.ToolName, o.ID, o.EndDate, o.DaysOverdue)
+	default:
+		return "Reminder: Overdue Tool Return", fmt.Sprintf(`Dear %s,
 
-		logger.Info("Overdue reminders sent", "count", count)
-	})
+This is a reminder that your rental of "%s" (Rental ID: %d) was due on %s and is now overdue.
+
+Please return the tool as soon as possible to avoid additional charges.
+
+Thank you,
+Ubertool Team`, o.RenterName, o.ToolName, o.ID, o.EndDate)
+	}
+}
+
+// notifyAdminsOfOverdueRental flags a rental that reached the final notice to the org admins
+func (jr *JobRunner) notifyAdminsOfOverdueRental(ctx context.Context, o OverdueRental) {
+	if jr.services == nil || jr.services.Notification == nil {
+		logger.Warn("Notification service not configured, skipping overdue escalation", "rental_id", o.ID)
+		return
+	}
+
+	adminIDs, err := jr.orgAdminIDs(ctx, o.OrgID)
+	if err != nil {
+		logger.Error("Failed to query org admins for overdue escalation", "org_id", o.OrgID, "error", err)
+		return
+	}
+
+	message := fmt.Sprintf("%s has not returned %s (rental %d), now %d days overdue. A final notice was sent.",
+		o.RenterName, o.ToolName, o.ID, o.DaysOverdue)
+	for _, adminID := range adminIDs {
+		err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+			UserID:  adminID,
+			OrgID:   o.OrgID,
+			Title:   "Rental Needs Attention",
+			Message: message,
+			Attributes: map[string]string{
+				"type":         "RENTAL_OVERDUE_ESCALATED",
+				"rental_id":    fmt.Sprintf("%d", o.ID),
+				"days_overdue": fmt.Sprintf("%d", o.DaysOverdue),
+				"channel_id":   string(domain.ChannelAdmin),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send overdue escalation", "rental_id", o.ID, "admin_id", adminID, "error", err)
+		}
+	}
 }
 
 // SendBillReminders sends reminders to debtors and creditors about unpaid bills
 func (jr *JobRunner) SendBillReminders() {
-	jr.runWithRecovery("SendBillReminders", func() {
+	jr.runWithRecovery("SendBillReminders", func() error {
 		ctx := context.Background()
 
 		// Find pending bills
@@ -97,7 +212,7 @@ func (jr *JobRunner) SendBillReminders() {
 			       b.settlement_month, b.notice_sent_at,
 			       debtor.email as debtor_email, debtor.name as debtor_name,
 			       creditor.email as creditor_email, creditor.name as creditor_name,
-			       o.name as org_name
+			       o.name as org_name, o.currency_code
 			FROM bills b
 			JOIN users debtor ON b.debtor_user_id = debtor.id
 			JOIN users creditor ON b.creditor_user_id = creditor.id
@@ -111,8 +226,7 @@ func (jr *JobRunner) SendBillReminders() {
 		threeDaysAgo := time.Now().Add(-72 * time.Hour)
 		rows, err := jr.db.QueryContext(ctx, query, threeDaysAgo)
 		if err != nil {
-			logger.Error("Failed to query pending bills", "error", err)
-			return
+			return fmt.Errorf("failed to query pending bills: %w", err)
 		}
 		defer rows.Close()
 
@@ -130,29 +244,29 @@ func (jr *JobRunner) SendBillReminders() {
 				creditorEmail    string
 				creditorName     string
 				orgName          string
+				currencyCode     string
 			)
 
 			if err := rows.Scan(&billID, &debtorID, &creditorID, &amountCents, &settlementMonth, &noticeSentAt,
-				&debtorEmail, &debtorName, &creditorEmail, &creditorName, &orgName); err != nil {
+				&debtorEmail, &debtorName, &creditorEmail, &creditorName, &orgName, &currencyCode); err != nil {
 				logger.Error("Failed to scan pending bill", "error", err)
 				continue
 			}
 
-			// Calculate amount in dollars
-			amountDollars := float64(amountCents) / 100.0
+			amount := domain.FormatCents(int32(amountCents), currencyCode)
 
 			// Send reminder to debtor
 			debtorSubject := fmt.Sprintf("Reminder: Payment Due for %s", orgName)
 			debtorBody := fmt.Sprintf(`Dear %s,
 
-This is a reminder that you have a pending payment of $%.2f to %s for the settlement period %s.
+This is a reminder that you have a pending payment of %s to %s for the settlement period %s.
 
 Please acknowledge your payment in the Ubertool app once completed.
 
 Bill ID: %d
 
 Thank you,
-Ubertool Team`, debtorName, amountDollars, creditorName, settlementMonth, billID)
+Ubertool Team`, debtorName, amount, creditorName, settlementMonth, billID)
 
 			err := jr.services.Email.SendAdminNotification(ctx, debtorEmail, debtorSubject, debtorBody)
 			if err != nil {
@@ -166,14 +280,14 @@ Ubertool Team`, debtorName, amountDollars, creditorName, settlementMonth, billID
 			creditorSubject := fmt.Sprintf("Reminder: Payment Expected from %s", debtorName)
 			creditorBody := fmt.Sprintf(`Dear %s,
 
-This is a reminder that you are expecting a payment of $%.2f from %s for the settlement period %s.
+This is a reminder that you are expecting a payment of %s from %s for the settlement period %s.
 
 Please confirm receipt in the Ubertool app once you receive the payment.
 
 Bill ID: %d
 
 Thank you,
-Ubertool Team`, creditorName, amountDollars, debtorName, settlementMonth, billID)
+Ubertool Team`, creditorName, amount, debtorName, settlementMonth, billID)
 
 			err = jr.services.Email.SendAdminNotification(ctx, creditorEmail, creditorSubject, creditorBody)
 			if err != nil {
@@ -191,17 +305,17 @@ Ubertool Team`, creditorName, amountDollars, debtorName, settlementMonth, billID
 		}
 
 		if err := rows.Err(); err != nil {
-			logger.Error("Error iterating pending bills", "error", err)
-			return
+			return fmt.Errorf("error iterating pending bills: %w", err)
 		}
 
 		logger.Info("Bill reminders sent", "count", count)
+		return nil
 	})
 }
 
 // SendBillSplittingNotices sends email notices for new bills
 func (jr *JobRunner) SendBillSplittingNotices() {
-	jr.runWithRecovery("SendBillSplittingNotices", func() {
+	jr.runWithRecovery("SendBillSplittingNotices", func() error {
 		ctx := context.Background()
 
 		// Find pending bills that haven't had a notice sent yet
@@ -210,7 +324,7 @@ func (jr *JobRunner) SendBillSplittingNotices() {
 			       b.settlement_month,
 			       debtor.email as debtor_email, debtor.name as debtor_name,
 			       creditor.email as creditor_email, creditor.name as creditor_name,
-			       o.name as org_name
+			       o.name as org_name, o.currency_code
 			FROM bills b
 			JOIN users debtor ON b.debtor_user_id = debtor.id
 			JOIN users creditor ON b.creditor_user_id = creditor.id
@@ -221,8 +335,7 @@ func (jr *JobRunner) SendBillSplittingNotices() {
 
 		rows, err := jr.db.QueryContext(ctx, query)
 		if err != nil {
-			logger.Error("Failed to query new bills for notices", "error", err)
-			return
+			return fmt.Errorf("failed to query new bills for notices: %w", err)
 		}
 		defer rows.Close()
 
@@ -239,30 +352,30 @@ func (jr *JobRunner) SendBillSplittingNotices() {
 				creditorEmail   string
 				creditorName    string
 				orgName         string
+				currencyCode    string
 			)
 
 			if err := rows.Scan(&billID, &debtorID, &creditorID, &amountCents, &settlementMonth,
-				&debtorEmail, &debtorName, &creditorEmail, &creditorName, &orgName); err != nil {
+				&debtorEmail, &debtorName, &creditorEmail, &creditorName, &orgName, &currencyCode); err != nil {
 				logger.Error("Failed to scan new bill", "error", err)
 				continue
 			}
 
-			// Calculate amount in dollars
-			amountDollars := float64(amountCents) / 100.0
+			amount := domain.FormatCents(int32(amountCents), currencyCode)
 
 			// Send notice to debtor
 			debtorSubject := fmt.Sprintf("New Bill: Payment Due for %s", orgName)
 			debtorBody := fmt.Sprintf(`Dear %s,
 
 A new bill has been generated for the settlement period %s.
-You owe $%.2f to %s.
+You owe %s to %s.
 
 Please arrange payment and acknowledge it in the Ubertool app.
 
 Bill ID: %d
 
 Thank you,
-Ubertool Team`, debtorName, settlementMonth, amountDollars, creditorName, billID)
+Ubertool Team`, debtorName, settlementMonth, amount, creditorName, billID)
 
 			err := jr.services.Email.SendAdminNotification(ctx, debtorEmail, debtorSubject, debtorBody)
 			if err != nil {
@@ -279,14 +392,14 @@ Ubertool Team`, debtorName, settlementMonth, amountDollars, creditorName, billID
 			creditorBody := fmt.Sprintf(`Dear %s,
 
 A new bill has been generated for the settlement period %s.
-You are owed $%.2f by %s.
+You are owed %s by %s.
 
 Please monitor the Ubertool app for payment confirmation.
 
 Bill ID: %d
 
 Thank you,
-Ubertool Team`, creditorName, settlementMonth, amountDollars, debtorName, billID)
+Ubertool Team`, creditorName, settlementMonth, amount, debtorName, billID)
 
 			err = jr.services.Email.SendAdminNotification(ctx, creditorEmail, creditorSubject, creditorBody)
 			if err != nil {
@@ -299,7 +412,7 @@ Ubertool Team`, creditorName, settlementMonth, amountDollars, debtorName, billID
 			}
 
 			// Mark as sent
-			_, err = jr.db.ExecContext(ctx, "UPDATE bills SET notice_sent_at = NOW() WHERE id = $1", billID)
+			_, err = jr.db.ExecContext(ctx, "UPDATE bills SET notice_sent_at = NOW(), version = version + 1 WHERE id = $1", billID)
 			if err != nil {
 				logger.Error("Failed to update bill notice status", "bill_id", billID, "error", err)
 			}
@@ -312,10 +425,33 @@ Ubertool Team`, creditorName, settlementMonth, amountDollars, debtorName, billID
 		}
 
 		if err := rows.Err(); err != nil {
-			logger.Error("Error iterating new bills", "error", err)
-			return
+			return fmt.Errorf("error iterating new bills: %w", err)
 		}
 
 		logger.Info("Bill splitting notices sent", "count", count)
+		return nil
+	})
+}
+
+// retryNotificationBatchSize caps how many dead letters one run of RetryFailedNotifications claims
+const retryNotificationBatchSize = 200
+
+// RetryFailedNotifications re-creates notifications whose insert failed when they were dispatched
+func (jr *JobRunner) RetryFailedNotifications() {
+	jr.runWithRecovery("RetryFailedNotifications", func() error {
+		if jr.services == nil || jr.services.Notification == nil {
+			logger.Warn("Notification service not configured, skipping retry")
+			return nil
+		}
+
+		delivered, failed, err := jr.services.Notification.RetryFailed(context.Background(), retryNotificationBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to retry notifications: %w", err)
+		}
+
+		logger.Info("Notification retry completed",
+			"delivered", delivered,
+			"failed", failed)
+		return nil
 	})
 }
@@ -0,0 +1,192 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// BalanceDrift describes a member whose stored balance differs from the ledger sum
+type BalanceDrift struct {
+	UserID        int32
+	OrgID         int32
+	StoredCents   int32 // users_orgs.balance_cents
+	LedgerCents   int32 // SUM(ledger_transactions.amount), excluding holds
+	DriftCents    int32 // StoredCents - LedgerCents
+	AutoCorrected bool
+}
+
+// ReconcileBalances compares every member balance against the ledger and reports drift
+func (jr *JobRunner) ReconcileBalances() {
+	jr.runWithRecovery("ReconcileBalances", func() error {
+		ctx := context.Background()
+
+		orgs, err := jr.store.OrganizationRepository.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get organizations: %w", err)
+		}
+
+		autoCorrect := jr.config.Billing.AutoReconcile
+		threshold := jr.config.Billing.DriftAlertThresholdCents
+
+		totalDrifts := 0
+		for _, org := range orgs {
+			drifts, err := jr.ReconcileBalancesForOrg(ctx, org.ID, org.Name, autoCorrect, threshold)
+			if err != nil {
+				logger.Error("Failed to reconcile balances for org",
+					"org_id", org.ID,
+					"org_name", org.Name,
+					"error", err)
+				continue
+			}
+			totalDrifts += len(drifts)
+		}
+
+		logger.Info("Balance reconciliation completed",
+			"total_drifts", totalDrifts,
+			"auto_correct", autoCorrect)
+		return nil
+	})
+}
+
+// ReconcileBalancesForOrg detects balance drift for a single organization. When autoCorrect
+// is set, drifted balances are overwritten with the ledger sum. Org admins are notified
+// when any drift exceeds alertThresholdCents.
+func (jr *JobRunner) ReconcileBalancesForOrg(ctx context.Context, orgID int32, orgName string, autoCorrect bool, alertThresholdCents int32) ([]BalanceDrift, error) {
+	query := `
+		SELECT uo.user_id, COALESCE(uo.balance_cents, 0), COALESCE(SUM(lt.amount), 0)
+		FROM users_orgs uo
+		LEFT JOIN ledger_transactions lt ON lt.user_id = uo.user_id AND lt.org_id = uo.org_id
+		     AND lt.type NOT IN ('HOLD', 'HOLD_RELEASE') -- holds never reach balance_cents
+		WHERE uo.org_id = $1
+		GROUP BY uo.user_id, uo.balance_cents
+		HAVING COALESCE(uo.balance_cents, 0) != COALESCE(SUM(lt.amount), 0)
+	`
+
+	rows, err := jr.db.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare balances with ledger: %w", err)
+	}
+
+	var drifts []BalanceDrift
+	for rows.Next() {
+		d := BalanceDrift{OrgID: orgID}
+		if err := rows.Scan(&d.UserID, &d.StoredCents, &d.LedgerCents); err != nil {
+			logger.Error("Failed to scan balance drift", "error", err)
+			continue
+		}
+		d.DriftCents = d.StoredCents - d.LedgerCents
+		drifts = append(drifts, d)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating balance drifts: %w", err)
+	}
+	rows.Close()
+
+	alerts := 0
+	for i := range drifts {
+		d := &drifts[i]
+		logger.Warn("Balance drift detected",
+			"org_id", orgID,
+			"user_id", d.UserID,
+			"stored_cents", d.StoredCents,
+			"ledger_cents", d.LedgerCents,
+			"drift_cents", d.DriftCents)
+
+		if autoCorrect {
+			_, err := jr.db.ExecContext(ctx,
+				"UPDATE users_orgs SET balance_cents = $1, last_balance_updated_on = CURRENT_DATE WHERE user_id = $2 AND org_id = $3",
+				d.LedgerCents, d.UserID, orgID)
+			if err != nil {
+				logger.Error("Failed to correct drifted balance",
+					"org_id", orgID,
+					"user_id", d.UserID,
+					"error", err)
+			} else {
+				d.AutoCorrected = true
+			}
+		}
+
+		if abs(int(d.DriftCents)) > int(alertThresholdCents) {
+			alerts++
+		}
+	}
+
+	if alerts > 0 {
+		jr.notifyAdminsOfDrift(ctx, orgID, orgName, drifts, alertThresholdCents)
+	}
+
+	logger.Info("Balance reconciliation completed for org",
+		"org_id", orgID,
+		"org_name", orgName,
+		"drifts", len(drifts),
+		"above_threshold", alerts)
+
+	return drifts, nil
+}
+
+// notifyAdminsOfDrift sends one summary notification to each admin of the organization
+func (jr *JobRunner) notifyAdminsOfDrift(ctx context.Context, orgID int32, orgName string, drifts []BalanceDrift, alertThresholdCents int32) {
+	if jr.services == nil || jr.services.Notification == nil {
+		logger.Warn("Notification service not configured, skipping drift alert", "org_id", orgID)
+		return
+	}
+
+	adminIDs, err := jr.orgAdminIDs(ctx, orgID)
+	if err != nil {
+		logger.Error("Failed to query org admins for drift alert", "org_id", orgID, "error", err)
+		return
+	}
+
+	var maxDrift int32
+	for _, d := range drifts {
+		if abs(int(d.DriftCents)) > int(maxDrift) {
+			maxDrift = int32(abs(int(d.DriftCents)))
+		}
+	}
+
+	message := fmt.Sprintf("%d member balance(s) in %s differ from the ledger (largest drift $%.2f, alert threshold $%.2f).",
+		len(drifts), orgName, float64(maxDrift)/100.0, float64(alertThresholdCents)/100.0)
+
+	for _, adminID := range adminIDs {
+		err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+			UserID:  adminID,
+			OrgID:   orgID,
+			Title:   "Balance Drift Detected",
+			Message: message,
+			Attributes: map[string]string{
+				"type":       "BALANCE_DRIFT",
+				"drifts":     fmt.Sprintf("%d", len(drifts)),
+				"channel_id": string(domain.ChannelAdmin),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send drift alert", "org_id", orgID, "admin_id", adminID, "error", err)
+		}
+	}
+}
+
+// orgAdminIDs returns the active admins of an organization
+func (jr *JobRunner) orgAdminIDs(ctx context.Context, orgID int32) ([]int32, error) {
+	rows, err := jr.db.QueryContext(ctx,
+		"SELECT user_id FROM users_orgs WHERE org_id = $1 AND role IN ('ADMIN', 'SUPER_ADMIN') AND status = 'ACTIVE'",
+		orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var adminIDs []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			logger.Error("Failed to scan org admin", "error", err)
+			continue
+		}
+		adminIDs = append(adminIDs, id)
+	}
+	return adminIDs, rows.Err()
+}
@@ -2,70 +2,351 @@ package jobs
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 )
 
+// MarkedOverdueRental describes an ACTIVE rental moved to OVERDUE by MarkOverdueRentalsOn
+type MarkedOverdueRental struct {
+	ID          int32
+	OrgID       int32
+	RenterID    int32
+	OwnerID     int32
+	EndDate     string
+	ToolName    string
+	RenterEmail string
+	RenterName  string
+	OwnerEmail  string
+	OwnerName   string
+}
+
 // MarkOverdueRentals marks rentals as OVERDUE if they are past their end_date
 func (jr *JobRunner) MarkOverdueRentals() {
-	jr.runWithRecovery("MarkOverdueRentals", func() {
-		ctx := context.Background()
+	jr.runWithRecovery("MarkOverdueRentals", func() error {
+		marked, err := jr.MarkOverdueRentalsOn(context.Background(), jr.now().Format("2006-01-02"))
+		if err != nil {
+			return fmt.Errorf("failed to mark overdue rentals: %w", err)
+		}
+		logger.Info("Marked rentals as overdue", "count", len(marked))
+		return nil
+	})
+}
 
-		// Find rentals that are past their end date and still in ACTIVE status
-		query := `
+// MarkOverdueRentalsOn moves ACTIVE rentals whose end_date is before today (YYYY-MM-DD) to
+// OVERDUE, records the transition in rental_events with no actor, and tells both renter and
+// owner. Rentals already OVERDUE are not selected, so each is announced once.
+func (jr *JobRunner) MarkOverdueRentalsOn(ctx context.Context, today string) ([]MarkedOverdueRental, error) {
+	query := `
+		WITH updated AS (
 			UPDATE rentals
 			SET status = 'OVERDUE',
+			    last_reminder_level = 0,
 			    updated_on = NOW()
 			WHERE status = 'ACTIVE'
 			  AND end_date < $1
-			RETURNING id, renter_id, tool_id, end_date
-		`
+			RETURNING id, org_id, renter_id, owner_id, tool_id, end_date
+		), events AS (
+			INSERT INTO rental_events (rental_id, from_status, to_status, note)
+			SELECT id, 'ACTIVE', 'OVERDUE', 'end date passed' FROM updated
+		)
+		SELECT u.id, u.org_id, u.renter_id, u.owner_id, u.end_date::text, t.name,
+		       renter.email, renter.name, owner.email, owner.name
+		FROM updated u
+		JOIN tools t ON t.id = u.tool_id
+		JOIN users renter ON renter.id = u.renter_id
+		JOIN users owner ON owner.id = u.owner_id
+	`
 
-		rows, err := jr.db.QueryContext(ctx, query, time.Now().Format("2006-01-02"))
-		if err != nil {
-			logger.Error("Failed to mark overdue rentals", "error", err)
-			return
+	rows, err := jr.db.QueryContext(ctx, query, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark overdue rentals: %w", err)
+	}
+
+	var marked []MarkedOverdueRental
+	for rows.Next() {
+		var m MarkedOverdueRental
+		if err := rows.Scan(&m.ID, &m.OrgID, &m.RenterID, &m.OwnerID, &m.EndDate, &m.ToolName,
+			&m.RenterEmail, &m.RenterName, &m.OwnerEmail, &m.OwnerName); err != nil {
+			logger.Error("Failed to scan overdue rental", "error", err)
+			continue
 		}
-		defer rows.Close()
-
-		count := 0
-		var overdueRentals []struct {
-			ID       int
-			RenterID int
-			ToolID   int
-			EndDate  string
+		marked = append(marked, m)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating overdue rentals: %w", err)
+	}
+	rows.Close()
+
+	for _, m := range marked {
+		logger.Debug("Marked rental as overdue",
+			"rental_id", m.ID,
+			"renter_id", m.RenterID,
+			"owner_id", m.OwnerID,
+			"end_date", m.EndDate)
+		jr.notifyRentalOverdue(ctx, m)
+	}
+
+	return marked, nil
+}
+
+// notifyRentalOverdue tells the renter their return is late and the owner that their tool is
+func (jr *JobRunner) notifyRentalOverdue(ctx context.Context, m MarkedOverdueRental) {
+	if jr.services == nil {
+		return
+	}
+
+	parties := []struct {
+		userID  int32
+		email   string
+		message string
+	}{
+		{m.RenterID, m.RenterEmail, fmt.Sprintf("Your rental of %s was due back on %s and is now overdue. Please return it to %s.", m.ToolName, m.EndDate, m.OwnerName)},
+		{m.OwnerID, m.OwnerEmail, fmt.Sprintf("%s has not returned your %s, which was due back on %s.", m.RenterName, m.ToolName, m.EndDate)},
+	}
+
+	for _, p := range parties {
+		if jr.services.Notification != nil {
+			err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+				UserID:  p.userID,
+				OrgID:   m.OrgID,
+				Title:   "Rental Overdue",
+				Message: p.message,
+				Attributes: map[string]string{
+					"type":       "RENTAL_OVERDUE",
+					"rental_id":  fmt.Sprintf("%d", m.ID),
+					"channel_id": string(domain.ChannelRentalRequest),
+				},
+			})
+			if err != nil {
+				logger.Error("Failed to send overdue notice", "rental_id", m.ID, "user_id", p.userID, "error", err)
+			}
+		}
+		if jr.services.Email != nil {
+			body := fmt.Sprintf("%s\n\nRental ID: %d\n\nThank you,\nUbertool Team", p.message, m.ID)
+			if err := jr.services.Email.SendAdminNotification(ctx, p.email, "Rental Overdue: "+m.ToolName, body); err != nil {
+				logger.Error("Failed to send overdue email", "rental_id", m.ID, "user_id", p.userID, "error", err)
+			}
+		}
+	}
+}
+
+// AutoActivatedRental describes a SCHEDULED rental activated by AutoActivateRentalsForOrg
+type AutoActivatedRental struct {
+	ID       int32
+	RenterID int32
+	OwnerID  int32
+	ToolName string
+}
+
+// AutoActivateScheduledRentals activates SCHEDULED rentals whose start date has arrived
+// in every organization that has opted into auto-activation
+func (jr *JobRunner) AutoActivateScheduledRentals() {
+	jr.runWithRecovery("AutoActivateScheduledRentals", func() error {
+		ctx := context.Background()
+
+		orgs, err := jr.store.OrganizationRepository.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get organizations: %w", err)
 		}
 
-		for rows.Next() {
-			var rental struct {
-				ID       int
-				RenterID int
-				ToolID   int
-				EndDate  string
+		today := jr.now().Format("2006-01-02")
+		total := 0
+		for _, org := range orgs {
+			if !org.AutoActivateRentals {
+				continue
 			}
-			if err := rows.Scan(&rental.ID, &rental.RenterID, &rental.ToolID, &rental.EndDate); err != nil {
-				logger.Error("Failed to scan overdue rental", "error", err)
+			activated, err := jr.AutoActivateRentalsForOrg(ctx, org.ID, today)
+			if err != nil {
+				logger.Error("Failed to auto-activate rentals for org",
+					"org_id", org.ID,
+					"org_name", org.Name,
+					"error", err)
 				continue
 			}
-			overdueRentals = append(overdueRentals, rental)
-			count++
+			total += len(activated)
 		}
 
-		if err := rows.Err(); err != nil {
-			logger.Error("Error iterating overdue rentals", "error", err)
-			return
+		logger.Info("Auto-activated scheduled rentals", "count", total)
+		return nil
+	})
+}
+
+// AutoActivateRentalsForOrg moves the org's SCHEDULED rentals starting on or before today
+// to ACTIVE and notifies both renter and owner. The caller is responsible for checking
+// the org's auto-activation policy.
+func (jr *JobRunner) AutoActivateRentalsForOrg(ctx context.Context, orgID int32, today string) ([]AutoActivatedRental, error) {
+	query := `
+		WITH updated AS (
+			UPDATE rentals r
+			SET status = 'ACTIVE',
+			    updated_on = NOW()
+			FROM tools t
+			WHERE t.id = r.tool_id
+			  AND r.org_id = $1
+			  AND r.status = 'SCHEDULED'
+			  AND r.start_date <= $2
+			RETURNING r.id, r.renter_id, r.owner_id, t.name
+		), events AS (
+			INSERT INTO rental_events (rental_id, from_status, to_status, note)
+			SELECT id, 'SCHEDULED', 'ACTIVE', 'auto-activated on start date' FROM updated
+		)
+		SELECT id, renter_id, owner_id, name FROM updated
+	`
+
+	rows, err := jr.db.QueryContext(ctx, query, orgID, today)
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate scheduled rentals: %w", err)
+	}
+
+	var activated []AutoActivatedRental
+	for rows.Next() {
+		var a AutoActivatedRental
+		if err := rows.Scan(&a.ID, &a.RenterID, &a.OwnerID, &a.ToolName); err != nil {
+			logger.Error("Failed to scan auto-activated rental", "error", err)
+			continue
+		}
+		activated = append(activated, a)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating auto-activated rentals: %w", err)
+	}
+	rows.Close()
+
+	for _, a := range activated {
+		logger.Debug("Auto-activated rental", "rental_id", a.ID, "org_id", orgID)
+		jr.notifyRentalAutoActivated(ctx, orgID, a)
+	}
+
+	return activated, nil
+}
+
+// notifyRentalAutoActivated tells both parties that the rental started without a pickup step
+func (jr *JobRunner) notifyRentalAutoActivated(ctx context.Context, orgID int32, a AutoActivatedRental) {
+	if jr.services == nil || jr.services.Notification == nil {
+		logger.Warn("Notification service not configured, skipping auto-activation notice", "rental_id", a.ID)
+		return
+	}
+
+	for _, userID := range []int32{a.RenterID, a.OwnerID} {
+		err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+			UserID:  userID,
+			OrgID:   orgID,
+			Title:   "Rental Started",
+			Message: fmt.Sprintf("The rental for %s has started and is now active.", a.ToolName),
+			Attributes: map[string]string{
+				"type":       "RENTAL_AUTO_ACTIVATED",
+				"rental_id":  fmt.Sprintf("%d", a.ID),
+				"channel_id": string(domain.ChannelRentalRequest),
+			},
+		})
+		if err != nil {
+			logger.Error("Failed to send auto-activation notice", "rental_id", a.ID, "user_id", userID, "error", err)
+		}
+	}
+}
+
+// ExpiredRentalRequest describes a PENDING rental rejected by ExpireStalePendingRentalsBefore
+type ExpiredRentalRequest struct {
+	ID       int32
+	OrgID    int32
+	RenterID int32
+	ToolName string
+}
+
+// ExpireStalePendingRentals rejects rental requests that have waited longer than
+// rental.request_expiry_hours for the owner to respond
+func (jr *JobRunner) ExpireStalePendingRentals() {
+	jr.runWithRecovery("ExpireStalePendingRentals", func() error {
+		hours := jr.config.Rental.RequestExpiryHours
+		if hours <= 0 {
+			logger.Warn("Rental request expiry is disabled", "request_expiry_hours", hours)
+			return nil
 		}
+		cutoff := jr.now().Add(-time.Duration(hours) * time.Hour)
+
+		expired, err := jr.ExpireStalePendingRentalsBefore(context.Background(), cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to expire stale rental requests: %w", err)
+		}
+		logger.Info("Expired stale rental requests", "count", len(expired), "cutoff", cutoff)
+		return nil
+	})
+}
 
-		logger.Info("Marked rentals as overdue", "count", count)
+// ExpireStalePendingRentalsBefore rejects PENDING rentals requested before cutoff and notifies
+// each renter. Only rows still PENDING are updated, so re-running it expires nothing twice.
+// Pending requests never hold funds (holds are placed at finalize), so there is nothing to release.
+func (jr *JobRunner) ExpireStalePendingRentalsBefore(ctx context.Context, cutoff time.Time) ([]ExpiredRentalRequest, error) {
+	query := `
+		WITH expired AS (
+			UPDATE rentals r
+			SET status = 'REJECTED',
+			    rejection_reason = 'Request expired without a response from the owner',
+			    updated_on = NOW()
+			FROM tools t
+			WHERE t.id = r.tool_id
+			  AND r.status = 'PENDING'
+			  AND r.created_at < $1
+			RETURNING r.id, r.org_id, r.renter_id, t.name
+		), events AS (
+			INSERT INTO rental_events (rental_id, from_status, to_status, note)
+			SELECT id, 'PENDING', 'REJECTED', 'request expired' FROM expired
+		)
+		SELECT id, org_id, renter_id, name FROM expired
+	`
+
+	rows, err := jr.db.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire pending rentals: %w", err)
+	}
 
-		// Log details for each overdue rental
-		for _, rental := range overdueRentals {
-			logger.Debug("Marked rental as overdue",
-				"rental_id", rental.ID,
-				"renter_id", rental.RenterID,
-				"tool_id", rental.ToolID,
-				"end_date", rental.EndDate)
+	var expired []ExpiredRentalRequest
+	for rows.Next() {
+		var e ExpiredRentalRequest
+		if err := rows.Scan(&e.ID, &e.OrgID, &e.RenterID, &e.ToolName); err != nil {
+			logger.Error("Failed to scan expired rental request", "error", err)
+			continue
 		}
+		expired = append(expired, e)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, fmt.Errorf("error iterating expired rental requests: %w", err)
+	}
+	rows.Close()
+
+	for _, e := range expired {
+		logger.Debug("Expired rental request", "rental_id", e.ID, "org_id", e.OrgID)
+		jr.notifyRentalRequestExpired(ctx, e)
+	}
+
+	return expired, nil
+}
+
+// notifyRentalRequestExpired tells the renter the owner never answered their request
+func (jr *JobRunner) notifyRentalRequestExpired(ctx context.Context, e ExpiredRentalRequest) {
+	if jr.services == nil || jr.services.Notification == nil {
+		logger.Warn("Notification service not configured, skipping expiry notice", "rental_id", e.ID)
+		return
+	}
+
+	err := jr.services.Notification.Dispatch(ctx, &domain.Notification{
+		UserID:  e.RenterID,
+		OrgID:   e.OrgID,
+		Title:   "Rental Request Expired",
+		Message: fmt.Sprintf("Your rental request for %s expired because the owner did not respond.", e.ToolName),
+		Attributes: map[string]string{
+			"type":       "RENTAL_REQUEST_EXPIRED",
+			"rental_id":  fmt.Sprintf("%d", e.ID),
+			"channel_id": string(domain.ChannelRentalRequest),
+		},
 	})
+	if err != nil {
+		logger.Error("Failed to send expiry notice", "rental_id", e.ID, "user_id", e.RenterID, "error", err)
+	}
 }
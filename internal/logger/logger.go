@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
@@ -9,8 +10,49 @@ import (
 
 var defaultLogger *slog.Logger
 
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request correlation ID
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request correlation ID stored in ctx, or ""
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID from the record's context to every log line
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
 // Initialize sets up the global logger with the specified level and format
 func Initialize(level, format string) {
+	InitializeWithWriter(os.Stdout, level, format)
+}
+
+// InitializeWithWriter is Initialize writing to w instead of stdout
+func InitializeWithWriter(w io.Writer, level, format string) {
 	var logLevel slog.Level
 	switch strings.ToLower(level) {
 	case "debug":
@@ -31,12 +73,12 @@ func Initialize(level, format string) {
 
 	var handler slog.Handler
 	if strings.ToLower(format) == "json" {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	} else {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(w, opts)
 	}
 
-	defaultLogger = slog.New(handler)
+	defaultLogger = slog.New(contextHandler{handler})
 	slog.SetDefault(defaultLogger)
 }
 
@@ -89,6 +131,15 @@ func ErrorContext(ctx context.Context, msg string, args ...any) {
 	Get().ErrorContext(ctx, msg, args...)
 }
 
+// WithContext returns a logger with the request ID from ctx attached, for code that
+// logs several lines without passing ctx to each call
+func WithContext(ctx context.Context) *slog.Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		return Get().With("request_id", id)
+	}
+	return Get()
+}
+
 // WithMethod returns a logger with method name attached
 func WithMethod(methodName string) *slog.Logger {
 	return Get().With("method", methodName)
@@ -117,6 +168,24 @@ func ExitMethodWithError(methodName string, err error, args ...any) {
 	Get().Error("← Method exited with error", allArgs...)
 }
 
+// EnterMethodContext is EnterMethod tagged with the request ID from ctx
+func EnterMethodContext(ctx context.Context, methodName string, args ...any) {
+	allArgs := append([]any{"method", methodName, "event", "enter"}, args...)
+	Get().DebugContext(ctx, "→ Method entered", allArgs...)
+}
+
+// ExitMethodContext is ExitMethod tagged with the request ID from ctx
+func ExitMethodContext(ctx context.Context, methodName string, args ...any) {
+	allArgs := append([]any{"method", methodName, "event", "exit"}, args...)
+	Get().DebugContext(ctx, "← Method exited", allArgs...)
+}
+
+// ExitMethodWithErrorContext is ExitMethodWithError tagged with the request ID from ctx
+func ExitMethodWithErrorContext(ctx context.Context, methodName string, err error, args ...any) {
+	allArgs := append([]any{"method", methodName, "event", "exit", "error", err}, args...)
+	Get().ErrorContext(ctx, "← Method exited with error", allArgs...)
+}
+
 // DatabaseCall logs database operation (debug log for external resources)
 func DatabaseCall(operation, query string, args ...any) {
 	allArgs := append([]any{"operation", operation, "query", query}, args...)
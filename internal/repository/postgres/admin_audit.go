@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type adminAuditRepository struct {
+	db DBTX
+}
+
+func NewAdminAuditRepository(db DBTX) repository.AdminAuditRepository {
+	return &adminAuditRepository{db: db}
+}
+
+func (r *adminAuditRepository) Create(ctx context.Context, entry *domain.AdminAuditEntry) error {
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return err
+	}
+	before, err := marshalAuditSnapshot(entry.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalAuditSnapshot(entry.After)
+	if err != nil {
+		return err
+	}
+
+	query := `INSERT INTO admin_audit (org_id, admin_id, action, target_type, target_id, details, before, after)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`
+	logger.DatabaseCall("INSERT", "admin_audit", "orgID", entry.OrgID, "adminID", entry.AdminID, "action", entry.Action)
+
+	err = r.db.QueryRowContext(ctx, query, entry.OrgID, entry.AdminID, entry.Action, entry.TargetType, entry.TargetID, details, before, after).
+		Scan(&entry.ID, &entry.CreatedAt)
+	logger.DatabaseResult("INSERT", 1, err, "auditID", entry.ID)
+	return err
+}
+
+// marshalAuditSnapshot stores an absent snapshot as NULL rather than a JSON null
+func marshalAuditSnapshot(snapshot map[string]string) ([]byte, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	return json.Marshal(snapshot)
+}
+
+func (r *adminAuditRepository) List(ctx context.Context, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error) {
+	query := `SELECT id, org_id, admin_id, action, target_type, target_id, details, before, after, created_at
+	          FROM admin_audit WHERE org_id = $1`
+	args := []interface{}{orgID}
+	argIndex := 2
+
+	if filter.AdminID > 0 {
+		query += fmt.Sprintf(" AND admin_id = $%d", argIndex)
+		args = append(args, filter.AdminID)
+		argIndex++
+	}
+	if filter.Action != "" {
+		query += fmt.Sprintf(" AND action = $%d", argIndex)
+		args = append(args, string(filter.Action))
+		argIndex++
+	}
+	if filter.TargetType != "" {
+		query += fmt.Sprintf(" AND target_type = $%d", argIndex)
+		args = append(args, string(filter.TargetType))
+		argIndex++
+	}
+	if filter.TargetID > 0 {
+		query += fmt.Sprintf(" AND target_id = $%d", argIndex)
+		args = append(args, filter.TargetID)
+		argIndex++
+	}
+
+	var count int32
+	countSql := "SELECT count(*) FROM (" + query + ") as sub"
+	if err := r.db.QueryRowContext(ctx, countSql, args...).Scan(&count); err != nil {
+		return nil, 0, err
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+	if filter.PageSize > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, filter.PageSize, (page-1)*filter.PageSize)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []domain.AdminAuditEntry
+	for rows.Next() {
+		var e domain.AdminAuditEntry
+		var details, before, after []byte
+		if err := rows.Scan(&e.ID, &e.OrgID, &e.AdminID, &e.Action, &e.TargetType, &e.TargetID, &details, &before, &after, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		if err := unmarshalAuditJSON(details, &e.Details); err != nil {
+			return nil, 0, err
+		}
+		if err := unmarshalAuditJSON(before, &e.Before); err != nil {
+			return nil, 0, err
+		}
+		if err := unmarshalAuditJSON(after, &e.After); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, count, rows.Err()
+}
+
+func unmarshalAuditJSON(raw []byte, dest *map[string]string) error {
+	if len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, dest)
+}
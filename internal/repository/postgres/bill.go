@@ -11,50 +11,51 @@ import (
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/utils"
 )
 
 type billRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewBillRepository(db *sql.DB) repository.BillRepository {
+func NewBillRepository(db DBTX) repository.BillRepository {
 	return &billRepository{db: db}
 }
 
 func (r *billRepository) Create(ctx context.Context, bill *domain.Bill) error {
-	logger.EnterMethod("billRepository.Create", "orgID", bill.OrgID, "debtorID", bill.DebtorUserID, "creditorID", bill.CreditorUserID)
+	logger.EnterMethodContext(ctx, "billRepository.Create", "orgID", bill.OrgID, "debtorID", bill.DebtorUserID, "creditorID", bill.CreditorUserID)
 
 	query := `
 		INSERT INTO bills (
 			org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month, 
 			status, notice_sent_at, created_at, updated_at
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
-		RETURNING id, created_at, updated_at
+		RETURNING id, version, created_at, updated_at
 	`
 	now := time.Now()
 	err := r.db.QueryRowContext(ctx, query,
 		bill.OrgID, bill.DebtorUserID, bill.CreditorUserID, bill.AmountCents, bill.SettlementMonth,
 		bill.Status, bill.NoticeSentAt, now, now,
-	).Scan(&bill.ID, &bill.CreatedAt, &bill.UpdatedAt)
+	).Scan(&bill.ID, &bill.Version, &bill.CreatedAt, &bill.UpdatedAt)
 
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.Create", err, "orgID", bill.OrgID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.Create", err, "orgID", bill.OrgID)
 		return err
 	}
 
-	logger.ExitMethod("billRepository.Create", "billID", bill.ID)
+	logger.ExitMethodContext(ctx, "billRepository.Create", "billID", bill.ID)
 	return nil
 }
 
 func (r *billRepository) GetByID(ctx context.Context, id int32) (*domain.Bill, error) {
-	logger.EnterMethod("billRepository.GetByID", "billID", id)
+	logger.EnterMethodContext(ctx, "billRepository.GetByID", "billID", id)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills WHERE id = $1
 	`
 
@@ -63,20 +64,20 @@ func (r *billRepository) GetByID(ctx context.Context, id int32) (*domain.Bill, e
 		&bill.ID, &bill.OrgID, &bill.DebtorUserID, &bill.CreditorUserID, &bill.AmountCents, &bill.SettlementMonth,
 		&bill.Status, &bill.NoticeSentAt, &bill.DebtorAcknowledgedAt, &bill.CreditorAcknowledgedAt,
 		&bill.DisputedAt, &bill.ResolvedAt, &bill.DisputeReason, &bill.ResolutionOutcome, &bill.ResolutionNotes,
-		&bill.CreatedAt, &bill.UpdatedAt,
+		&bill.VoidRequestedBy, &bill.VoidRequestedAt, &bill.Version, &bill.CreatedAt, &bill.UpdatedAt,
 	)
 
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.GetByID", err, "billID", id)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetByID", err, "billID", id)
 		return nil, err
 	}
 
-	logger.ExitMethod("billRepository.GetByID", "billID", id)
+	logger.ExitMethodContext(ctx, "billRepository.GetByID", "billID", id)
 	return bill, nil
 }
 
 func (r *billRepository) Update(ctx context.Context, bill *domain.Bill) error {
-	logger.EnterMethod("billRepository.Update", "billID", bill.ID, "status", bill.Status)
+	logger.EnterMethodContext(ctx, "billRepository.Update", "billID", bill.ID, "status", bill.Status)
 
 	query := `
 		UPDATE bills SET 
@@ -89,34 +90,42 @@ func (r *billRepository) Update(ctx context.Context, bill *domain.Bill) error {
 			dispute_reason = $7,
 			resolution_outcome = $8,
 			resolution_notes = $9,
-			updated_at = $10
-		WHERE id = $11
+			void_requested_by = $10,
+			void_requested_at = $11,
+			updated_at = $12,
+			version = version + 1
+		WHERE id = $13 AND version = $14
+		RETURNING version, updated_at
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		bill.Status, bill.NoticeSentAt, bill.DebtorAcknowledgedAt, bill.CreditorAcknowledgedAt,
 		bill.DisputedAt, bill.ResolvedAt, bill.DisputeReason, bill.ResolutionOutcome, bill.ResolutionNotes,
-		time.Now(), bill.ID,
-	)
+		bill.VoidRequestedBy, bill.VoidRequestedAt, time.Now(), bill.ID, bill.Version,
+	).Scan(&bill.Version, &bill.UpdatedAt)
 
+	if err == sql.ErrNoRows {
+		// Either the bill is gone or someone else updated it since it was read
+		err = repository.ErrBillVersionConflict
+	}
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.Update", err, "billID", bill.ID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.Update", err, "billID", bill.ID, "version", bill.Version)
 		return err
 	}
 
-	logger.ExitMethod("billRepository.Update", "billID", bill.ID)
+	logger.ExitMethodContext(ctx, "billRepository.Update", "billID", bill.ID)
 	return nil
 }
 
 func (r *billRepository) ListByDebtor(ctx context.Context, debtorID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error) {
-	logger.EnterMethod("billRepository.ListByDebtor", "debtorID", debtorID, "orgID", orgID)
+	logger.EnterMethodContext(ctx, "billRepository.ListByDebtor", "debtorID", debtorID, "orgID", orgID)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills 
 		WHERE debtor_user_id = $1
 	`
@@ -143,7 +152,7 @@ func (r *billRepository) ListByDebtor(ctx context.Context, debtorID int32, orgID
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListByDebtor", err, "debtorID", debtorID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByDebtor", err, "debtorID", debtorID)
 		return nil, err
 	}
 	defer rows.Close()
@@ -155,28 +164,28 @@ func (r *billRepository) ListByDebtor(ctx context.Context, debtorID int32, orgID
 			&b.ID, &b.OrgID, &b.DebtorUserID, &b.CreditorUserID, &b.AmountCents, &b.SettlementMonth,
 			&b.Status, &b.NoticeSentAt, &b.DebtorAcknowledgedAt, &b.CreditorAcknowledgedAt,
 			&b.DisputedAt, &b.ResolvedAt, &b.DisputeReason, &b.ResolutionOutcome, &b.ResolutionNotes,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.VoidRequestedBy, &b.VoidRequestedAt, &b.Version, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListByDebtor", err, "debtorID", debtorID)
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByDebtor", err, "debtorID", debtorID)
 			return nil, err
 		}
 		bills = append(bills, b)
 	}
 
-	logger.ExitMethod("billRepository.ListByDebtor", "debtorID", debtorID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billRepository.ListByDebtor", "debtorID", debtorID, "count", len(bills))
 	return bills, nil
 }
 
 func (r *billRepository) ListByCreditor(ctx context.Context, creditorID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error) {
-	logger.EnterMethod("billRepository.ListByCreditor", "creditorID", creditorID, "orgID", orgID)
+	logger.EnterMethodContext(ctx, "billRepository.ListByCreditor", "creditorID", creditorID, "orgID", orgID)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills 
 		WHERE creditor_user_id = $1
 	`
@@ -203,7 +212,7 @@ func (r *billRepository) ListByCreditor(ctx context.Context, creditorID int32, o
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListByCreditor", err, "creditorID", creditorID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByCreditor", err, "creditorID", creditorID)
 		return nil, err
 	}
 	defer rows.Close()
@@ -215,28 +224,30 @@ func (r *billRepository) ListByCreditor(ctx context.Context, creditorID int32, o
 			&b.ID, &b.OrgID, &b.DebtorUserID, &b.CreditorUserID, &b.AmountCents, &b.SettlementMonth,
 			&b.Status, &b.NoticeSentAt, &b.DebtorAcknowledgedAt, &b.CreditorAcknowledgedAt,
 			&b.DisputedAt, &b.ResolvedAt, &b.DisputeReason, &b.ResolutionOutcome, &b.ResolutionNotes,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.VoidRequestedBy, &b.VoidRequestedAt, &b.Version, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListByCreditor", err, "creditorID", creditorID)
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByCreditor", err, "creditorID", creditorID)
 			return nil, err
 		}
 		bills = append(bills, b)
 	}
 
-	logger.ExitMethod("billRepository.ListByCreditor", "creditorID", creditorID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billRepository.ListByCreditor", "creditorID", creditorID, "count", len(bills))
 	return bills, nil
 }
 
-func (r *billRepository) ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error) {
-	logger.EnterMethod("billRepository.ListByUser", "userID", userID, "orgID", orgID)
+// ListByUser returns bills where the user is debtor or creditor. A pageSize <= 0 returns every
+// matching bill; the returned count is always the total across all pages.
+func (r *billRepository) ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus, page, pageSize int32) ([]domain.Bill, int32, error) {
+	logger.EnterMethodContext(ctx, "billRepository.ListByUser", "userID", userID, "orgID", orgID, "page", page, "pageSize", pageSize)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills 
 		WHERE (debtor_user_id = $1 OR creditor_user_id = $1)
 	`
@@ -257,14 +268,30 @@ func (r *billRepository) ListByUser(ctx context.Context, userID int32, orgID int
 		}
 		query += fmt.Sprintf(" AND status = ANY($%d)", argIndex)
 		args = append(args, pq.Array(statusStrs))
+		argIndex++
 	}
 
-	query += " ORDER BY notice_sent_at DESC, created_at DESC"
+	var count int32
+	countSql := "SELECT count(*) FROM (" + query + ") as sub"
+	if err := r.db.QueryRowContext(ctx, countSql, args...).Scan(&count); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByUser", err, "userID", userID)
+		return nil, 0, err
+	}
+
+	// id breaks ties so pages never overlap when timestamps are equal
+	query += " ORDER BY notice_sent_at DESC, created_at DESC, id DESC"
+	if pageSize > 0 {
+		if page < 1 {
+			page = 1
+		}
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, pageSize, (page-1)*pageSize)
+	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListByUser", err, "userID", userID)
-		return nil, err
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByUser", err, "userID", userID)
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -275,28 +302,28 @@ func (r *billRepository) ListByUser(ctx context.Context, userID int32, orgID int
 			&b.ID, &b.OrgID, &b.DebtorUserID, &b.CreditorUserID, &b.AmountCents, &b.SettlementMonth,
 			&b.Status, &b.NoticeSentAt, &b.DebtorAcknowledgedAt, &b.CreditorAcknowledgedAt,
 			&b.DisputedAt, &b.ResolvedAt, &b.DisputeReason, &b.ResolutionOutcome, &b.ResolutionNotes,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.VoidRequestedBy, &b.VoidRequestedAt, &b.Version, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListByUser", err, "userID", userID)
-			return nil, err
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListByUser", err, "userID", userID)
+			return nil, 0, err
 		}
 		bills = append(bills, b)
 	}
 
-	logger.ExitMethod("billRepository.ListByUser", "userID", userID, "count", len(bills))
-	return bills, nil
+	logger.ExitMethodContext(ctx, "billRepository.ListByUser", "userID", userID, "count", len(bills), "total", count)
+	return bills, count, nil
 }
 
 func (r *billRepository) ListDisputedByOrg(ctx context.Context, orgID int32, excludeUserID *int32) ([]domain.Bill, error) {
-	logger.EnterMethod("billRepository.ListDisputedByOrg", "orgID", orgID, "excludeUserID", excludeUserID)
+	logger.EnterMethodContext(ctx, "billRepository.ListDisputedByOrg", "orgID", orgID, "excludeUserID", excludeUserID)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills 
 		WHERE org_id = $1 AND status = $2
 	`
@@ -312,7 +339,7 @@ func (r *billRepository) ListDisputedByOrg(ctx context.Context, orgID int32, exc
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListDisputedByOrg", err, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListDisputedByOrg", err, "orgID", orgID)
 		return nil, err
 	}
 	defer rows.Close()
@@ -324,28 +351,28 @@ func (r *billRepository) ListDisputedByOrg(ctx context.Context, orgID int32, exc
 			&b.ID, &b.OrgID, &b.DebtorUserID, &b.CreditorUserID, &b.AmountCents, &b.SettlementMonth,
 			&b.Status, &b.NoticeSentAt, &b.DebtorAcknowledgedAt, &b.CreditorAcknowledgedAt,
 			&b.DisputedAt, &b.ResolvedAt, &b.DisputeReason, &b.ResolutionOutcome, &b.ResolutionNotes,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.VoidRequestedBy, &b.VoidRequestedAt, &b.Version, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListDisputedByOrg", err, "orgID", orgID)
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListDisputedByOrg", err, "orgID", orgID)
 			return nil, err
 		}
 		bills = append(bills, b)
 	}
 
-	logger.ExitMethod("billRepository.ListDisputedByOrg", "orgID", orgID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billRepository.ListDisputedByOrg", "orgID", orgID, "count", len(bills))
 	return bills, nil
 }
 
 func (r *billRepository) ListResolvedDisputesByOrg(ctx context.Context, orgID int32) ([]domain.Bill, error) {
-	logger.EnterMethod("billRepository.ListResolvedDisputesByOrg", "orgID", orgID)
+	logger.EnterMethodContext(ctx, "billRepository.ListResolvedDisputesByOrg", "orgID", orgID)
 
 	query := `
 		SELECT id, org_id, debtor_user_id, creditor_user_id, amount_cents, settlement_month,
 		       status, notice_sent_at, debtor_acknowledged_at, creditor_acknowledged_at,
 		       disputed_at, resolved_at, COALESCE(dispute_reason, ''), 
 		       COALESCE(resolution_outcome, ''), COALESCE(resolution_notes, ''),
-		       created_at, updated_at
+		       void_requested_by, void_requested_at, version, created_at, updated_at
 		FROM bills 
 		WHERE org_id = $1 
 		  AND disputed_at IS NOT NULL 
@@ -356,7 +383,7 @@ func (r *billRepository) ListResolvedDisputesByOrg(ctx context.Context, orgID in
 
 	rows, err := r.db.QueryContext(ctx, query, orgID, domain.BillStatusAdminResolved, domain.BillStatusSystemDefaultAction)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListResolvedDisputesByOrg", err, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListResolvedDisputesByOrg", err, "orgID", orgID)
 		return nil, err
 	}
 	defer rows.Close()
@@ -368,21 +395,29 @@ func (r *billRepository) ListResolvedDisputesByOrg(ctx context.Context, orgID in
 			&b.ID, &b.OrgID, &b.DebtorUserID, &b.CreditorUserID, &b.AmountCents, &b.SettlementMonth,
 			&b.Status, &b.NoticeSentAt, &b.DebtorAcknowledgedAt, &b.CreditorAcknowledgedAt,
 			&b.DisputedAt, &b.ResolvedAt, &b.DisputeReason, &b.ResolutionOutcome, &b.ResolutionNotes,
-			&b.CreatedAt, &b.UpdatedAt,
+			&b.VoidRequestedBy, &b.VoidRequestedAt, &b.Version, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListResolvedDisputesByOrg", err, "orgID", orgID)
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListResolvedDisputesByOrg", err, "orgID", orgID)
 			return nil, err
 		}
 		bills = append(bills, b)
 	}
 
-	logger.ExitMethod("billRepository.ListResolvedDisputesByOrg", "orgID", orgID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billRepository.ListResolvedDisputesByOrg", "orgID", orgID, "count", len(bills))
 	return bills, nil
 }
 
 func (r *billRepository) CreateAction(ctx context.Context, action *domain.BillAction) error {
-	logger.EnterMethod("billRepository.CreateAction", "billID", action.BillID, "actionType", action.ActionType)
+	logger.EnterMethodContext(ctx, "billRepository.CreateAction", "billID", action.BillID, "actionType", action.ActionType)
+
+	// A malformed document would fail the JSONB cast; reject it before it reaches the database
+	details, err := domain.NormalizeActionDetails(action.ActionDetails)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.CreateAction", err, "billID", action.BillID)
+		return err
+	}
+	action.ActionDetails = details
 
 	query := `
 		INSERT INTO bill_actions (
@@ -391,35 +426,53 @@ func (r *billRepository) CreateAction(ctx context.Context, action *domain.BillAc
 		RETURNING id, created_at
 	`
 
-	err := r.db.QueryRowContext(ctx, query,
+	err = r.db.QueryRowContext(ctx, query,
 		action.BillID, action.ActorUserID, action.ActionType,
 		nullString(action.ActionDetails), nullString(action.Notes), time.Now(),
 	).Scan(&action.ID, &action.CreatedAt)
 
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.CreateAction", err, "billID", action.BillID)
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.CreateAction", err, "billID", action.BillID)
 		return err
 	}
 
-	logger.ExitMethod("billRepository.CreateAction", "actionID", action.ID)
+	logger.ExitMethodContext(ctx, "billRepository.CreateAction", "actionID", action.ID)
 	return nil
 }
 
-func (r *billRepository) ListActionsByBill(ctx context.Context, billID int32) ([]domain.BillAction, error) {
-	logger.EnterMethod("billRepository.ListActionsByBill", "billID", billID)
+func (r *billRepository) ListActionsByBill(ctx context.Context, billID int32, filter domain.BillActionFilter) ([]domain.BillAction, int32, error) {
+	logger.EnterMethodContext(ctx, "billRepository.ListActionsByBill", "billID", billID, "actionTypes", filter.ActionTypes, "page", filter.Page)
+
+	where := " WHERE bill_id = $1"
+	args := []interface{}{billID}
+	if len(filter.ActionTypes) > 0 {
+		types := make([]string, len(filter.ActionTypes))
+		for i, t := range filter.ActionTypes {
+			types[i] = string(t)
+		}
+		where += " AND action_type = ANY($2)"
+		args = append(args, pq.Array(types))
+	}
+
+	var total int32
+	if err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM bill_actions"+where, args...).Scan(&total); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListActionsByBill", err, "billID", billID)
+		return nil, 0, err
+	}
 
+	limit, offset := utils.Paginate(filter.Page, filter.PageSize)
 	query := `
 		SELECT id, bill_id, actor_user_id, action_type, 
-		       COALESCE(action_details::text, ''), COALESCE(notes, ''), created_at
-		FROM bill_actions 
-		WHERE bill_id = $1
-		ORDER BY created_at ASC
-	`
+		       COALESCE(action_details::text, '{}'), COALESCE(notes, ''), created_at
+		FROM bill_actions` + where + fmt.Sprintf(`
+		ORDER BY created_at ASC, id ASC
+		LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
 
-	rows, err := r.db.QueryContext(ctx, query, billID)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		logger.ExitMethodWithError("billRepository.ListActionsByBill", err, "billID", billID)
-		return nil, err
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.ListActionsByBill", err, "billID", billID)
+		return nil, 0, err
 	}
 	defer rows.Close()
 
@@ -428,14 +481,289 @@ func (r *billRepository) ListActionsByBill(ctx context.Context, billID int32) ([
 		var a domain.BillAction
 		err := rows.Scan(&a.ID, &a.BillID, &a.ActorUserID, &a.ActionType, &a.ActionDetails, &a.Notes, &a.CreatedAt)
 		if err != nil {
-			logger.ExitMethodWithError("billRepository.ListActionsByBill", err, "billID", billID)
-			return nil, err
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.ListActionsByBill", err, "billID", billID)
+			return nil, 0, err
 		}
 		actions = append(actions, a)
 	}
 
-	logger.ExitMethod("billRepository.ListActionsByBill", "billID", billID, "count", len(actions))
-	return actions, nil
+	logger.ExitMethodContext(ctx, "billRepository.ListActionsByBill", "billID", billID, "count", len(actions), "total", total)
+	return actions, total, nil
+}
+
+func (r *billRepository) GetDisputeStatistics(ctx context.Context, orgID int32, fromMonth, toMonth string) (*domain.DisputeStatistics, error) {
+	logger.EnterMethodContext(ctx, "billRepository.GetDisputeStatistics", "orgID", orgID, "fromMonth", fromMonth, "toMonth", toMonth)
+
+	where := " WHERE org_id = $1 AND disputed_at IS NOT NULL"
+	args := []interface{}{orgID}
+	argIndex := 2
+
+	if fromMonth != "" {
+		where += fmt.Sprintf(" AND settlement_month >= $%d", argIndex)
+		args = append(args, fromMonth)
+		argIndex++
+	}
+	if toMonth != "" {
+		where += fmt.Sprintf(" AND settlement_month <= $%d", argIndex)
+		args = append(args, toMonth)
+	}
+
+	stats := &domain.DisputeStatistics{
+		OrgID:          orgID,
+		FromMonth:      fromMonth,
+		ToMonth:        toMonth,
+		CountByStatus:  map[string]int32{},
+		CountByOutcome: map[string]int32{},
+	}
+
+	// Counts by current status
+	rows, err := r.db.QueryContext(ctx, "SELECT status, COUNT(*) FROM bills"+where+" GROUP BY status", args...)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetDisputeStatistics", err, "orgID", orgID)
+		return nil, err
+	}
+	for rows.Next() {
+		var status string
+		var count int32
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.GetDisputeStatistics", err, "orgID", orgID)
+			return nil, err
+		}
+		stats.CountByStatus[status] = count
+		stats.TotalDisputes += count
+	}
+	rows.Close()
+
+	// Counts by resolution outcome (resolved disputes only)
+	rows, err = r.db.QueryContext(ctx,
+		"SELECT resolution_outcome, COUNT(*) FROM bills"+where+" AND resolved_at IS NOT NULL AND resolution_outcome IS NOT NULL GROUP BY resolution_outcome",
+		args...)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetDisputeStatistics", err, "orgID", orgID)
+		return nil, err
+	}
+	for rows.Next() {
+		var outcome string
+		var count int32
+		if err := rows.Scan(&outcome, &count); err != nil {
+			rows.Close()
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.GetDisputeStatistics", err, "orgID", orgID)
+			return nil, err
+		}
+		stats.CountByOutcome[outcome] = count
+	}
+	rows.Close()
+
+	// Average resolution time and penalties. Admin resolutions deduct the bill amount from the
+	// creditor (CREDITOR_FAULT) or from both parties (BOTH_FAULT); system defaults only block.
+	query := `
+		SELECT COALESCE(AVG(EXTRACT(EPOCH FROM (resolved_at - disputed_at))), 0)::BIGINT,
+		       COALESCE(SUM(CASE
+		           WHEN status = 'ADMIN_RESOLVED' AND resolution_outcome = 'CREDITOR_FAULT' THEN amount_cents
+		           WHEN status = 'ADMIN_RESOLVED' AND resolution_outcome = 'BOTH_FAULT' THEN amount_cents * 2
+		           ELSE 0
+		       END), 0)
+		FROM bills` + where + " AND resolved_at IS NOT NULL"
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&stats.AvgResolutionSeconds, &stats.TotalPenalizedCents)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetDisputeStatistics", err, "orgID", orgID)
+		return nil, err
+	}
+
+	logger.ExitMethodContext(ctx, "billRepository.GetDisputeStatistics", "orgID", orgID, "totalDisputes", stats.TotalDisputes)
+	return stats, nil
+}
+
+func (r *billRepository) GetBalanceSheet(ctx context.Context, orgID int32) (*domain.OrgBalanceSheet, error) {
+	logger.EnterMethodContext(ctx, "billRepository.GetBalanceSheet", "orgID", orgID)
+
+	sheet := &domain.OrgBalanceSheet{
+		OrgID:         orgID,
+		BillsByStatus: map[string]domain.BillStatusTotal{},
+	}
+
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE balance_cents > 0),
+		       COUNT(*) FILTER (WHERE balance_cents < 0),
+		       COALESCE(SUM(balance_cents) FILTER (WHERE balance_cents > 0), 0),
+		       COALESCE(SUM(balance_cents) FILTER (WHERE balance_cents < 0), 0)
+		FROM users_orgs WHERE org_id = $1`
+	err := r.db.QueryRowContext(ctx, query, orgID).Scan(&sheet.MemberCount, &sheet.MembersInCredit, &sheet.MembersInDebt,
+		&sheet.TotalPositiveBalanceCents, &sheet.TotalNegativeBalanceCents)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetBalanceSheet", err, "orgID", orgID)
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT status, COUNT(*), COALESCE(SUM(amount_cents), 0) FROM bills WHERE org_id = $1 GROUP BY status", orgID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetBalanceSheet", err, "orgID", orgID)
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var total domain.BillStatusTotal
+		if err := rows.Scan(&status, &total.Count, &total.AmountCents); err != nil {
+			logger.ExitMethodWithErrorContext(ctx, "billRepository.GetBalanceSheet", err, "orgID", orgID)
+			return nil, err
+		}
+		sheet.BillsByStatus[status] = total
+	}
+	if err := rows.Err(); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.GetBalanceSheet", err, "orgID", orgID)
+		return nil, err
+	}
+
+	logger.ExitMethodContext(ctx, "billRepository.GetBalanceSheet", "orgID", orgID, "members", sheet.MemberCount)
+	return sheet, nil
+}
+
+func (r *billRepository) CreateSettlementBills(ctx context.Context, orgID int32, settlementMonth string, thresholdCents int32, force bool) ([]domain.Bill, error) {
+	logger.EnterMethodContext(ctx, "billRepository.CreateSettlementBills", "orgID", orgID, "settlementMonth", settlementMonth, "force", force)
+
+	var bills []domain.Bill
+	err := withTx(ctx, r.db, func(tx DBTX) error {
+		var err error
+		bills, err = createSettlementBills(ctx, tx, orgID, settlementMonth, thresholdCents, force)
+		return err
+	})
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billRepository.CreateSettlementBills", err, "orgID", orgID, "settlementMonth", settlementMonth)
+		return nil, err
+	}
+
+	logger.ExitMethodContext(ctx, "billRepository.CreateSettlementBills", "orgID", orgID, "settlementMonth", settlementMonth, "billsCreated", len(bills))
+	return bills, nil
+}
+
+// createSettlementBills is CreateSettlementBills inside its transaction; any error rolls back
+// the bills and the settlement_runs marker together
+func createSettlementBills(ctx context.Context, tx DBTX, orgID int32, settlementMonth string, thresholdCents int32, force bool) ([]domain.Bill, error) {
+	// Claim the org and month; the primary key makes concurrent runs race on this insert
+	markerQuery := `
+		INSERT INTO settlement_runs (org_id, settlement_month, created_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (org_id, settlement_month) DO NOTHING
+	`
+	if force {
+		markerQuery = `
+			INSERT INTO settlement_runs (org_id, settlement_month, created_at, forced_at)
+			VALUES ($1, $2, NOW(), NOW())
+			ON CONFLICT (org_id, settlement_month) DO UPDATE SET forced_at = NOW()
+		`
+	}
+	res, err := tx.ExecContext(ctx, markerQuery, orgID, settlementMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record settlement run: %w", err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to record settlement run: %w", err)
+	}
+	if claimed == 0 {
+		return nil, repository.ErrSettlementAlreadyRun
+	}
+
+	if !force {
+		// Months settled before settlement_runs existed have bills but no marker
+		var billsExist bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM bills WHERE org_id = $1 AND settlement_month = $2)`,
+			orgID, settlementMonth).Scan(&billsExist)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing bills: %w", err)
+		}
+		if billsExist {
+			return nil, repository.ErrSettlementAlreadyRun
+		}
+	}
+
+	// Get all users in the organization with their balances
+	query := `
+		SELECT user_id, balance_cents
+		FROM users_orgs
+		WHERE org_id = $1
+		  AND status = 'ACTIVE'
+		  AND balance_cents != 0
+	`
+
+	rows, err := tx.QueryContext(ctx, query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []utils.Account
+	for rows.Next() {
+		var userID, balance int
+		if err := rows.Scan(&userID, &balance); err != nil {
+			logger.Error("Failed to scan user balance", "error", err)
+			continue
+		}
+		balances = append(balances, utils.Account{UserID: userID, Balance: balance})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating user balances: %w", err)
+	}
+	rows.Close()
+
+	// Same minimization the PreviewSettlement RPC runs, so previews match the bills created here
+	transactions := utils.PlanSettlement(balances, int(thresholdCents))
+
+	var bills []domain.Bill
+	for _, txn := range transactions {
+		insertQuery := `
+			INSERT INTO bills (
+				org_id, debtor_user_id, creditor_user_id, 
+				amount_cents, settlement_month, status, 
+				notice_sent_at, created_at, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, 'PENDING', NULL, NOW(), NOW())
+			ON CONFLICT (org_id, debtor_user_id, creditor_user_id, settlement_month) DO NOTHING
+			RETURNING id, version, created_at, updated_at
+		`
+
+		bill := domain.Bill{
+			OrgID:           orgID,
+			DebtorUserID:    int32(txn.FromUserID),
+			CreditorUserID:  int32(txn.ToUserID),
+			AmountCents:     int32(txn.Amount),
+			SettlementMonth: settlementMonth,
+			Status:          domain.BillStatusPending,
+		}
+		err := tx.QueryRowContext(ctx, insertQuery,
+			orgID, txn.FromUserID, txn.ToUserID,
+			txn.Amount, settlementMonth).Scan(&bill.ID, &bill.Version, &bill.CreatedAt, &bill.UpdatedAt)
+		// A forced rerun keeps the bills it already created
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert bill from user %d to user %d: %w", txn.FromUserID, txn.ToUserID, err)
+		}
+		bills = append(bills, bill)
+		logger.Debug("Created bill",
+			"org_id", orgID,
+			"debtor_id", txn.FromUserID,
+			"creditor_id", txn.ToUserID,
+			"amount_cents", txn.Amount)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE settlement_runs
+		SET bills_created = bills_created + $3, completed_at = NOW()
+		WHERE org_id = $1 AND settlement_month = $2
+	`, orgID, settlementMonth, len(bills))
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete settlement run: %w", err)
+	}
+	return bills, nil
 }
 
 // Helper function to convert empty string to SQL NULL
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type emailOutboxRepository struct {
+	db DBTX
+}
+
+func NewEmailOutboxRepository(db DBTX) repository.EmailOutboxRepository {
+	return &emailOutboxRepository{db: db}
+}
+
+func (r *emailOutboxRepository) Create(ctx context.Context, e *domain.EmailOutboxEntry) error {
+	if e.Status == "" {
+		e.Status = domain.EmailOutboxStatusPending
+	}
+	query := `INSERT INTO email_outbox (recipients, cc, subject, body, is_html, html_body, status)
+	          VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7) RETURNING id, next_attempt_at, created_at`
+	logger.DatabaseCall("INSERT", "email_outbox", "recipients", len(e.Recipients), "subject", e.Subject)
+
+	err := r.db.QueryRowContext(ctx, query, pq.Array(e.Recipients), pq.Array(e.Cc), e.Subject, e.Body, e.IsHTML, e.HTMLBody, e.Status).
+		Scan(&e.ID, &e.NextAttemptAt, &e.CreatedAt)
+	logger.DatabaseResult("INSERT", 1, err, "outboxID", e.ID)
+	return err
+}
+
+func (r *emailOutboxRepository) Claim(ctx context.Context, id int64) (bool, error) {
+	query := `UPDATE email_outbox SET status = 'SENDING', attempts = attempts + 1, updated_at = NOW()
+	          WHERE id = $1 AND status = 'PENDING'`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *emailOutboxRepository) ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.EmailOutboxEntry, error) {
+	// SKIP LOCKED lets the server worker and the retry job run side by side without double claims
+	query := `UPDATE email_outbox SET status = 'SENDING', attempts = attempts + 1, updated_at = NOW()
+	          WHERE id IN (
+	              SELECT id FROM email_outbox
+	              WHERE attempts < $1
+	                AND ((status = 'FAILED' AND next_attempt_at <= NOW())
+	                     OR (status IN ('PENDING', 'SENDING') AND updated_at < NOW() - $2 * INTERVAL '1 second'))
+	              ORDER BY next_attempt_at
+	              LIMIT $3
+	              FOR UPDATE SKIP LOCKED)
+	          RETURNING id, recipients, cc, subject, body, is_html, COALESCE(html_body, ''), status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at`
+	logger.DatabaseCall("UPDATE", "email_outbox", "maxAttempts", maxAttempts, "limit", limit)
+
+	rows, err := r.db.QueryContext(ctx, query, maxAttempts, int64(staleAfter/time.Second), limit)
+	if err != nil {
+		logger.DatabaseResult("UPDATE", 0, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.EmailOutboxEntry
+	for rows.Next() {
+		var e domain.EmailOutboxEntry
+		if err := rows.Scan(&e.ID, pq.Array(&e.Recipients), pq.Array(&e.Cc), &e.Subject, &e.Body, &e.IsHTML,
+			&e.HTMLBody, &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	logger.DatabaseResult("UPDATE", int64(len(entries)), rows.Err())
+	return entries, rows.Err()
+}
+
+func (r *emailOutboxRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `UPDATE email_outbox SET status = 'SENT', last_error = NULL, sent_at = NOW(), updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *emailOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE email_outbox SET status = 'FAILED', last_error = $2, next_attempt_at = $3, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, lastError, nextAttemptAt)
+	return err
+}
@@ -2,7 +2,6 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 
 	"github.com/lib/pq"
@@ -12,10 +11,10 @@ import (
 )
 
 type fcmTokenRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewFcmTokenRepository(db *sql.DB) repository.FcmTokenRepository {
+func NewFcmTokenRepository(db DBTX) repository.FcmTokenRepository {
 	return &fcmTokenRepository{db: db}
 }
 
@@ -5,17 +5,20 @@ import (
 	"crypto/rand"
 	"database/sql"
 	"errors"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
 )
 
 type invitationRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewInvitationRepository(db *sql.DB) repository.InvitationRepository {
+func NewInvitationRepository(db DBTX) repository.InvitationRepository {
 	return &invitationRepository{db: db}
 }
 
@@ -62,11 +65,11 @@ func (r *invitationRepository) Create(ctx context.Context, inv *domain.Invitatio
 
 func (r *invitationRepository) GetByInvitationCode(ctx context.Context, invitationCode string) (*domain.Invitation, error) {
 	inv := &domain.Invitation{}
-	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, created_on FROM invitations WHERE invitation_code = $1`
+	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, revoked_on, created_on FROM invitations WHERE invitation_code = $1`
 	var expiresOn, createdOn time.Time
-	var usedOn sql.NullTime
+	var usedOn, revokedOn sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, invitationCode).Scan(&inv.ID, &inv.InvitationCode, &inv.OrgID, &inv.Email, &inv.JoinRequestID, &inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &createdOn)
+	err := r.db.QueryRowContext(ctx, query, invitationCode).Scan(&inv.ID, &inv.InvitationCode, &inv.OrgID, &inv.Email, &inv.JoinRequestID, &inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &revokedOn, &createdOn)
 	if err != nil {
 		return nil, err
 	}
@@ -76,18 +79,22 @@ func (r *invitationRepository) GetByInvitationCode(ctx context.Context, invitati
 		dateStr := usedOn.Time.Format("2006-01-02")
 		inv.UsedOn = &dateStr
 	}
+	if revokedOn.Valid {
+		dateStr := revokedOn.Time.Format("2006-01-02")
+		inv.RevokedOn = &dateStr
+	}
 	return inv, nil
 }
 
 func (r *invitationRepository) GetByInvitationCodeAndEmail(ctx context.Context, invitationCode, email string) (*domain.Invitation, error) {
 	inv := &domain.Invitation{}
-	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, created_on 
+	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, revoked_on, created_on 
 	          FROM invitations 
 	          WHERE invitation_code = $1 AND LOWER(email) = LOWER($2)`
 	var expiresOn, createdOn time.Time
-	var usedOn sql.NullTime
+	var usedOn, revokedOn sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, invitationCode, email).Scan(&inv.ID, &inv.InvitationCode, &inv.OrgID, &inv.Email, &inv.JoinRequestID, &inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &createdOn)
+	err := r.db.QueryRowContext(ctx, query, invitationCode, email).Scan(&inv.ID, &inv.InvitationCode, &inv.OrgID, &inv.Email, &inv.JoinRequestID, &inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &revokedOn, &createdOn)
 	if err != nil {
 		return nil, err
 	}
@@ -97,22 +104,26 @@ func (r *invitationRepository) GetByInvitationCodeAndEmail(ctx context.Context,
 		dateStr := usedOn.Time.Format("2006-01-02")
 		inv.UsedOn = &dateStr
 	}
+	if revokedOn.Valid {
+		dateStr := revokedOn.Time.Format("2006-01-02")
+		inv.RevokedOn = &dateStr
+	}
 	return inv, nil
 }
 
 func (r *invitationRepository) GetByJoinRequestID(ctx context.Context, joinRequestID int32) (*domain.Invitation, error) {
 	inv := &domain.Invitation{}
-	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, created_on
+	query := `SELECT id, invitation_code, org_id, email, join_request_id, created_by, expires_on, used_on, used_by_user_id, revoked_on, created_on
 	          FROM invitations
 	          WHERE join_request_id = $1
 	          ORDER BY created_on DESC
 	          LIMIT 1`
 	var expiresOn, createdOn time.Time
-	var usedOn sql.NullTime
+	var usedOn, revokedOn sql.NullTime
 
 	err := r.db.QueryRowContext(ctx, query, joinRequestID).Scan(
 		&inv.ID, &inv.InvitationCode, &inv.OrgID, &inv.Email, &inv.JoinRequestID,
-		&inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &createdOn,
+		&inv.CreatedBy, &expiresOn, &usedOn, &inv.UsedByUserID, &revokedOn, &createdOn,
 	)
 	if err != nil {
 		return nil, err
@@ -123,6 +134,10 @@ func (r *invitationRepository) GetByJoinRequestID(ctx context.Context, joinReque
 		dateStr := usedOn.Time.Format("2006-01-02")
 		inv.UsedOn = &dateStr
 	}
+	if revokedOn.Valid {
+		dateStr := revokedOn.Time.Format("2006-01-02")
+		inv.RevokedOn = &dateStr
+	}
 	return inv, nil
 }
 
@@ -170,3 +185,47 @@ func formatInvitationCode(code string) string {
 	}
 	return code[0:3] + "-" + code[3:6] + "-" + code[6:9]
 }
+
+func (r *invitationRepository) Revoke(ctx context.Context, id int32) error {
+	query := `UPDATE invitations SET revoked_on = CURRENT_DATE WHERE id = $1 AND used_on IS NULL AND revoked_on IS NULL`
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *invitationRepository) ListOutstandingEmails(ctx context.Context, orgID int32, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	lower := make([]string, len(emails))
+	for i, e := range emails {
+		lower[i] = strings.ToLower(e)
+	}
+	query := `SELECT DISTINCT LOWER(email) FROM invitations
+	          WHERE org_id = $1 AND LOWER(email) = ANY($2)
+	            AND used_on IS NULL AND revoked_on IS NULL AND expires_on >= CURRENT_DATE`
+	rows, err := r.db.QueryContext(ctx, query, orgID, pq.Array(lower))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outstanding []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		outstanding = append(outstanding, email)
+	}
+	return outstanding, rows.Err()
+}
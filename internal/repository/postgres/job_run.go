@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type jobRunRepository struct {
+	db DBTX
+}
+
+func NewJobRunRepository(db DBTX) repository.JobRunRepository {
+	return &jobRunRepository{db: db}
+}
+
+func (r *jobRunRepository) Start(ctx context.Context, jobName string) (*domain.JobRun, error) {
+	run := &domain.JobRun{JobName: jobName, Status: domain.JobRunStatusRunning}
+	query := `INSERT INTO job_runs (job_name, status) VALUES ($1, $2) RETURNING id, started_at`
+	logger.DatabaseCall("INSERT", "job_runs", "jobName", jobName)
+
+	err := r.db.QueryRowContext(ctx, query, jobName, run.Status).Scan(&run.ID, &run.StartedAt)
+	logger.DatabaseResult("INSERT", 1, err, "jobRunID", run.ID)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (r *jobRunRepository) Finish(ctx context.Context, id int64, status domain.JobRunStatus, errMsg string) error {
+	query := `UPDATE job_runs SET status = $2, error = NULLIF($3, ''), finished_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, status, errMsg)
+	return err
+}
+
+func (r *jobRunRepository) List(ctx context.Context, filter domain.JobRunFilter) ([]domain.JobRun, error) {
+	query := `SELECT id, job_name, status, COALESCE(error, ''), started_at, finished_at FROM job_runs WHERE 1=1`
+	var args []interface{}
+	argIndex := 1
+
+	if filter.JobName != "" {
+		query += fmt.Sprintf(" AND job_name = $%d", argIndex)
+		args = append(args, filter.JobName)
+		argIndex++
+	}
+	if filter.Status != "" {
+		query += fmt.Sprintf(" AND status = $%d", argIndex)
+		args = append(args, string(filter.Status))
+		argIndex++
+	}
+	query += " ORDER BY started_at DESC, id DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argIndex)
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []domain.JobRun
+	for rows.Next() {
+		var run domain.JobRun
+		if err := rows.Scan(&run.ID, &run.JobName, &run.Status, &run.Error, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
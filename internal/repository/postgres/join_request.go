@@ -11,15 +11,15 @@ import (
 )
 
 type joinRequestRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewJoinRequestRepository(db *sql.DB) repository.JoinRequestRepository {
+func NewJoinRequestRepository(db DBTX) repository.JoinRequestRepository {
 	return &joinRequestRepository{db: db}
 }
 
 func (r *joinRequestRepository) Create(ctx context.Context, req *domain.JoinRequest) error {
-	logger.EnterMethod("joinRequestRepository.Create", "orgID", req.OrgID, "email", req.Email, "name", req.Name)
+	logger.EnterMethodContext(ctx, "joinRequestRepository.Create", "orgID", req.OrgID, "email", req.Email, "name", req.Name)
 
 	query := `INSERT INTO join_requests (org_id, user_id, name, email, note, status, created_on) 
 	          VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
@@ -30,9 +30,9 @@ func (r *joinRequestRepository) Create(ctx context.Context, req *domain.JoinRequ
 	logger.DatabaseResult("INSERT", 1, err, "requestID", req.ID)
 
 	if err != nil {
-		logger.ExitMethodWithError("joinRequestRepository.Create", err, "orgID", req.OrgID, "email", req.Email)
+		logger.ExitMethodWithErrorContext(ctx, "joinRequestRepository.Create", err, "orgID", req.OrgID, "email", req.Email)
 	} else {
-		logger.ExitMethod("joinRequestRepository.Create", "requestID", req.ID)
+		logger.ExitMethodContext(ctx, "joinRequestRepository.Create", "requestID", req.ID)
 	}
 	return err
 }
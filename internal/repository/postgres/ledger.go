@@ -2,7 +2,7 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"fmt"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
@@ -10,10 +10,10 @@ import (
 )
 
 type ledgerRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewLedgerRepository(db *sql.DB) repository.LedgerRepository {
+func NewLedgerRepository(db DBTX) repository.LedgerRepository {
 	return &ledgerRepository{db: db}
 }
 
@@ -31,6 +31,14 @@ func (r *ledgerRepository) GetBalance(ctx context.Context, userID, orgID int32)
 	return balance, err
 }
 
+func (r *ledgerRepository) GetRentalHold(ctx context.Context, rentalID int32) (int32, error) {
+	var held int32
+	query := `SELECT COALESCE(-SUM(amount), 0) FROM ledger_transactions
+	          WHERE related_rental_id = $1 AND type IN ('HOLD', 'HOLD_RELEASE')`
+	err := r.db.QueryRowContext(ctx, query, rentalID).Scan(&held)
+	return held, err
+}
+
 func (r *ledgerRepository) ListTransactions(ctx context.Context, userID, orgID int32, page, pageSize int32) ([]domain.LedgerTransaction, int32, error) {
 	offset := (page - 1) * pageSize
 	query := `SELECT id, org_id, user_id, amount, type, related_rental_id, COALESCE(description, ''), charged_on, created_on 
@@ -66,12 +74,23 @@ func (r *ledgerRepository) GetSummary(ctx context.Context, userID, orgID int32)
 		StatusCount: make(map[string]int32),
 	}
 
-	// Balance
-	balance, err := r.GetBalance(ctx, userID, orgID)
+	// Balance from the ledger itself. Every balance change, bill settlements and dispute
+	// penalties included, is a ledger entry, so the cached users_orgs balance returned alongside
+	// it only differs when something bypassed the ledger. Holds never reach balance_cents, so
+	// they are summed separately.
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(uo.balance_cents, 0),
+		       COALESCE((SELECT SUM(lt.amount) FROM ledger_transactions lt
+		                 WHERE lt.user_id = uo.user_id AND lt.org_id = uo.org_id AND lt.type NOT IN ('HOLD', 'HOLD_RELEASE')), 0),
+		       COALESCE((SELECT -SUM(lt.amount) FROM ledger_transactions lt
+		                 WHERE lt.user_id = uo.user_id AND lt.org_id = uo.org_id AND lt.type IN ('HOLD', 'HOLD_RELEASE')), 0)
+		FROM users_orgs uo
+		WHERE uo.user_id = $1 AND uo.org_id = $2`, userID, orgID).Scan(&summary.StoredBalance, &summary.Balance, &summary.HeldBalance)
 	if err != nil {
 		return nil, err
 	}
-	summary.Balance = balance
+	summary.BalanceMismatch = summary.StoredBalance != summary.Balance
+	summary.AvailableBalance = summary.Balance - summary.HeldBalance
 
 	// Active Rentals Count
 	err = r.db.QueryRowContext(ctx, "SELECT count(*) FROM rentals WHERE renter_id = $1 AND org_id = $2 AND status = 'ACTIVE'", userID, orgID).Scan(&summary.ActiveRentalsCount)
@@ -113,3 +132,71 @@ func (r *ledgerRepository) GetSummary(ctx context.Context, userID, orgID int32)
 
 	return summary, nil
 }
+
+func (r *ledgerRepository) GetBalanceHistory(ctx context.Context, userID, orgID int32, from, to string) ([]domain.BalanceSnapshot, error) {
+	query := `SELECT user_id, org_id, balance_cents, settlement_month, snapshot_date
+	          FROM balance_snapshots WHERE user_id = $1 AND org_id = $2`
+	args := []interface{}{userID, orgID}
+	if from != "" {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND snapshot_date >= $%d", len(args))
+	}
+	if to != "" {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND snapshot_date <= $%d", len(args))
+	}
+	query += " ORDER BY snapshot_date ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []domain.BalanceSnapshot
+	for rows.Next() {
+		var s domain.BalanceSnapshot
+		var snapshotDate time.Time
+		if err := rows.Scan(&s.UserID, &s.OrgID, &s.BalanceCents, &s.SettlementMonth, &snapshotDate); err != nil {
+			return nil, err
+		}
+		s.SnapshotDate = snapshotDate.Format("2006-01-02")
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+func (r *ledgerRepository) StreamTransactions(ctx context.Context, userID, orgID int32, from, to string, fn func(domain.LedgerTransaction) error) error {
+	query := `SELECT id, org_id, user_id, amount, type, related_rental_id, COALESCE(description, ''), charged_on, created_on
+	          FROM ledger_transactions WHERE user_id = $1 AND org_id = $2`
+	args := []interface{}{userID, orgID}
+	if from != "" {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND charged_on >= $%d", len(args))
+	}
+	if to != "" {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND charged_on <= $%d", len(args))
+	}
+	query += " ORDER BY charged_on, id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tx domain.LedgerTransaction
+		var chargedOn, createdOn time.Time
+		if err := rows.Scan(&tx.ID, &tx.OrgID, &tx.UserID, &tx.Amount, &tx.Type, &tx.RelatedRentalID, &tx.Description, &chargedOn, &createdOn); err != nil {
+			return err
+		}
+		tx.ChargedOn = chargedOn.Format("2006-01-02")
+		tx.CreatedOn = createdOn.Format("2006-01-02")
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
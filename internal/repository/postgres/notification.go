@@ -13,19 +13,19 @@ import (
 )
 
 type notificationRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewNotificationRepository(db *sql.DB) repository.NotificationRepository {
+func NewNotificationRepository(db DBTX) repository.NotificationRepository {
 	return &notificationRepository{db: db}
 }
 
 func (r *notificationRepository) Create(ctx context.Context, n *domain.Notification) error {
-	logger.EnterMethod("notificationRepository.Create", "userID", n.UserID, "orgID", n.OrgID, "title", n.Title)
+	logger.EnterMethodContext(ctx, "notificationRepository.Create", "userID", n.UserID, "orgID", n.OrgID, "title", n.Title)
 
 	attrs, err := json.Marshal(n.Attributes)
 	if err != nil {
-		logger.ExitMethodWithError("notificationRepository.Create", err, "reason", "failed to marshal attributes")
+		logger.ExitMethodWithErrorContext(ctx, "notificationRepository.Create", err, "reason", "failed to marshal attributes")
 		return err
 	}
 
@@ -39,25 +39,35 @@ func (r *notificationRepository) Create(ctx context.Context, n *domain.Notificat
 	logger.DatabaseResult("INSERT", 1, err, "notificationID", n.ID)
 
 	if err != nil {
-		logger.ExitMethodWithError("notificationRepository.Create", err, "userID", n.UserID, "orgID", n.OrgID)
+		logger.ExitMethodWithErrorContext(ctx, "notificationRepository.Create", err, "userID", n.UserID, "orgID", n.OrgID)
 	} else {
-		logger.ExitMethod("notificationRepository.Create", "notificationID", n.ID)
+		logger.ExitMethodContext(ctx, "notificationRepository.Create", "notificationID", n.ID)
 	}
 	return err
 }
 
-func (r *notificationRepository) List(ctx context.Context, userID int32, limit, offset int32) ([]domain.Notification, int32, error) {
-	query := `SELECT id, user_id, org_id, title, message, delivered_at, clicked_at, read_at, attributes, created_at
-	          FROM notifications WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`
-	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+// List returns a page of the user's notifications, newest first. A non-empty notificationType
+// matches the "type" or "topic" attribute; the count is the total across all pages.
+func (r *notificationRepository) List(ctx context.Context, userID int32, notificationType string, limit, offset int32) ([]domain.Notification, int32, error) {
+	where := "user_id = $1"
+	args := []interface{}{userID}
+	if notificationType != "" {
+		// Containment keeps the lookup on the GIN index over attributes
+		where += " AND (attributes @> jsonb_build_object('type', $2::text) OR attributes @> jsonb_build_object('topic', $2::text))"
+		args = append(args, notificationType)
+	}
+
+	query := fmt.Sprintf(`SELECT id, user_id, org_id, title, message, delivered_at, clicked_at, read_at, attributes, created_at
+	          FROM notifications WHERE %s ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var count int32
-	countQuery := `SELECT COUNT(*) FROM notifications WHERE user_id = $1`
-	if err := r.db.QueryRowContext(ctx, countQuery, userID).Scan(&count); err != nil {
+	countQuery := `SELECT COUNT(*) FROM notifications WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&count); err != nil {
 		return nil, 0, err
 	}
 
@@ -108,6 +118,38 @@ func (r *notificationRepository) MarkAsRead(ctx context.Context, id int64, userI
 	return nil
 }
 
+func (r *notificationRepository) CountUnread(ctx context.Context, userID, orgID int32) (int32, error) {
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+	args := []interface{}{userID}
+	if orgID > 0 {
+		query += " AND org_id = $2"
+		args = append(args, orgID)
+	}
+	var count int32
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`
+	args := []interface{}{userID}
+	if orgID > 0 {
+		query += " AND org_id = $2"
+		args = append(args, orgID)
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int32(rows), nil
+}
+
 func (r *notificationRepository) MarkDelivered(ctx context.Context, id int64, userID int32, t time.Time) error {
 	query := `UPDATE notifications SET delivered_at = COALESCE(delivered_at, $3) WHERE id = $1 AND user_id = $2`
 	_, err := r.db.ExecContext(ctx, query, id, userID, t)
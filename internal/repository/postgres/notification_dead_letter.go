@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type notificationDeadLetterRepository struct {
+	db DBTX
+}
+
+func NewNotificationDeadLetterRepository(db DBTX) repository.NotificationDeadLetterRepository {
+	return &notificationDeadLetterRepository{db: db}
+}
+
+func (r *notificationDeadLetterRepository) Create(ctx context.Context, e *domain.NotificationDeadLetter) error {
+	attrs, err := json.Marshal(e.Notification.Attributes)
+	if err != nil {
+		return err
+	}
+	if e.Status == "" {
+		e.Status = domain.NotificationDeadLetterStatusFailed
+	}
+	if e.Attempts == 0 {
+		e.Attempts = 1
+	}
+	query := `INSERT INTO notification_dead_letters (user_id, org_id, title, message, attributes, silent, status, attempts, last_error, next_attempt_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, created_at`
+	logger.DatabaseCall("INSERT", "notification_dead_letters", "userID", e.Notification.UserID, "title", e.Notification.Title)
+
+	n := e.Notification
+	err = r.db.QueryRowContext(ctx, query, n.UserID, n.OrgID, n.Title, n.Message, attrs, e.Silent, e.Status, e.Attempts, e.LastError, e.NextAttemptAt).
+		Scan(&e.ID, &e.CreatedAt)
+	logger.DatabaseResult("INSERT", 1, err, "deadLetterID", e.ID)
+	return err
+}
+
+func (r *notificationDeadLetterRepository) ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.NotificationDeadLetter, error) {
+	// SKIP LOCKED keeps overlapping runs of the retry job from claiming the same entry
+	query := `UPDATE notification_dead_letters SET status = 'RETRYING', attempts = attempts + 1, updated_at = NOW()
+	          WHERE id IN (
+	              SELECT id FROM notification_dead_letters
+	              WHERE attempts < $1
+	                AND ((status = 'FAILED' AND next_attempt_at <= NOW())
+	                     OR (status = 'RETRYING' AND updated_at < NOW() - $2 * INTERVAL '1 second'))
+	              ORDER BY next_attempt_at
+	              LIMIT $3
+	              FOR UPDATE SKIP LOCKED)
+	          RETURNING id, user_id, org_id, title, message, attributes, silent, status, attempts, COALESCE(last_error, ''), next_attempt_at, created_at`
+	logger.DatabaseCall("UPDATE", "notification_dead_letters", "maxAttempts", maxAttempts, "limit", limit)
+
+	rows, err := r.db.QueryContext(ctx, query, maxAttempts, int64(staleAfter/time.Second), limit)
+	if err != nil {
+		logger.DatabaseResult("UPDATE", 0, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.NotificationDeadLetter
+	for rows.Next() {
+		var e domain.NotificationDeadLetter
+		var attrs []byte
+		if err := rows.Scan(&e.ID, &e.Notification.UserID, &e.Notification.OrgID, &e.Notification.Title, &e.Notification.Message,
+			&attrs, &e.Silent, &e.Status, &e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(attrs) > 0 {
+			if err := json.Unmarshal(attrs, &e.Notification.Attributes); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, e)
+	}
+	logger.DatabaseResult("UPDATE", int64(len(entries)), rows.Err())
+	return entries, rows.Err()
+}
+
+func (r *notificationDeadLetterRepository) MarkDelivered(ctx context.Context, id, notificationID int64) error {
+	query := `UPDATE notification_dead_letters SET status = 'DELIVERED', notification_id = $2, last_error = NULL, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, notificationID)
+	return err
+}
+
+func (r *notificationDeadLetterRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE notification_dead_letters SET status = 'FAILED', last_error = $2, next_attempt_at = $3, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, lastError, nextAttemptAt)
+	return err
+}
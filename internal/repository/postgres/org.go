@@ -5,30 +5,34 @@ import (
 	"database/sql"
 	"time"
 
+	"github.com/lib/pq"
+
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
 )
 
 type organizationRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewOrganizationRepository(db *sql.DB) repository.OrganizationRepository {
+func NewOrganizationRepository(db DBTX) repository.OrganizationRepository {
 	return &organizationRepository{db: db}
 }
 
 func (r *organizationRepository) Create(ctx context.Context, o *domain.Organization) error {
-	query := `INSERT INTO orgs (name, description, address, metro, admin_phone_number, admin_email, created_on) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	query := `INSERT INTO orgs (name, description, address, metro, admin_phone_number, admin_email, currency_code, created_on) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
 	now := time.Now().Format("2006-01-02")
-	return r.db.QueryRowContext(ctx, query, o.Name, o.Description, o.Address, o.Metro, o.AdminPhoneNumber, o.AdminEmail, now).Scan(&o.ID)
+	o.Metro = domain.NormalizeMetro(o.Metro)
+	o.CurrencyCode = o.Currency()
+	return r.db.QueryRowContext(ctx, query, o.Name, o.Description, o.Address, o.Metro, o.AdminPhoneNumber, o.AdminEmail, o.CurrencyCode, now).Scan(&o.ID)
 }
 
 func (r *organizationRepository) GetByID(ctx context.Context, id int32) (*domain.Organization, error) {
 	o := &domain.Organization{}
-	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, billsplit_settlement_threshold_cents, max_billsplit_rental_cost_cents FROM orgs WHERE id = $1`
+	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, COALESCE(billsplit_settlement_threshold_cents, 0), max_billsplit_rental_cost_cents, auto_activate_rentals, adjacent_metros, currency_code FROM orgs WHERE id = $1`
 	var createdOn time.Time
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents, &o.AutoActivateRentals, pq.Array(&o.AdjacentMetros), &o.CurrencyCode)
 	if err != nil {
 		return nil, err
 	}
@@ -36,8 +40,32 @@ func (r *organizationRepository) GetByID(ctx context.Context, id int32) (*domain
 	return o, nil
 }
 
+func (r *organizationRepository) GetByIDs(ctx context.Context, ids []int32) ([]domain.Organization, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, COALESCE(billsplit_settlement_threshold_cents, 0), max_billsplit_rental_cost_cents, auto_activate_rentals, adjacent_metros, currency_code FROM orgs WHERE id = ANY($1) ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orgs []domain.Organization
+	for rows.Next() {
+		var o domain.Organization
+		var createdOn time.Time
+		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents, &o.AutoActivateRentals, pq.Array(&o.AdjacentMetros), &o.CurrencyCode); err != nil {
+			return nil, err
+		}
+		o.CreatedOn = createdOn.Format("2006-01-02")
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
 func (r *organizationRepository) List(ctx context.Context) ([]domain.Organization, error) {
-	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, billsplit_settlement_threshold_cents, max_billsplit_rental_cost_cents FROM orgs`
+	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, COALESCE(billsplit_settlement_threshold_cents, 0), max_billsplit_rental_cost_cents, auto_activate_rentals, adjacent_metros, currency_code FROM orgs`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
@@ -48,7 +76,7 @@ func (r *organizationRepository) List(ctx context.Context) ([]domain.Organizatio
 	for rows.Next() {
 		var o domain.Organization
 		var createdOn time.Time
-		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents); err != nil {
+		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents, &o.AutoActivateRentals, pq.Array(&o.AdjacentMetros), &o.CurrencyCode); err != nil {
 			return nil, err
 		}
 		o.CreatedOn = createdOn.Format("2006-01-02")
@@ -58,9 +86,9 @@ func (r *organizationRepository) List(ctx context.Context) ([]domain.Organizatio
 }
 
 func (r *organizationRepository) Search(ctx context.Context, name, metro string) ([]domain.Organization, error) {
-	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, billsplit_settlement_threshold_cents, max_billsplit_rental_cost_cents FROM orgs 
+	query := `SELECT id, name, COALESCE(description, ''), COALESCE(address, ''), metro, COALESCE(admin_phone_number, ''), COALESCE(admin_email, ''), created_on, COALESCE(billsplit_settlement_threshold_cents, 0), max_billsplit_rental_cost_cents, auto_activate_rentals, adjacent_metros, currency_code FROM orgs 
 	          WHERE name ILIKE $1 AND metro ILIKE $2`
-	rows, err := r.db.QueryContext(ctx, query, "%"+name+"%", "%"+metro+"%")
+	rows, err := r.db.QueryContext(ctx, query, "%"+name+"%", "%"+domain.NormalizeMetro(metro)+"%")
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +98,7 @@ func (r *organizationRepository) Search(ctx context.Context, name, metro string)
 	for rows.Next() {
 		var o domain.Organization
 		var createdOn time.Time
-		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents); err != nil {
+		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.Address, &o.Metro, &o.AdminPhoneNumber, &o.AdminEmail, &createdOn, &o.SettlementThresholdCents, &o.MaxBillsplitRentalCostCents, &o.AutoActivateRentals, pq.Array(&o.AdjacentMetros), &o.CurrencyCode); err != nil {
 			return nil, err
 		}
 		o.CreatedOn = createdOn.Format("2006-01-02")
@@ -79,7 +107,62 @@ func (r *organizationRepository) Search(ctx context.Context, name, metro string)
 	return orgs, nil
 }
 func (r *organizationRepository) Update(ctx context.Context, o *domain.Organization) error {
-	query := `UPDATE orgs SET name = $1, description = $2, address = $3, metro = $4, admin_phone_number = $5, admin_email = $6, billsplit_settlement_threshold_cents = $7, max_billsplit_rental_cost_cents = $8 WHERE id = $9`
-	_, err := r.db.ExecContext(ctx, query, o.Name, o.Description, o.Address, o.Metro, o.AdminPhoneNumber, o.AdminEmail, o.SettlementThresholdCents, o.MaxBillsplitRentalCostCents, o.ID)
+	query := `UPDATE orgs SET name = $1, description = $2, address = $3, metro = $4, admin_phone_number = $5, admin_email = $6, billsplit_settlement_threshold_cents = NULLIF($7, 0), max_billsplit_rental_cost_cents = $8, auto_activate_rentals = $9, currency_code = $10 WHERE id = $11`
+	o.Metro = domain.NormalizeMetro(o.Metro)
+	o.CurrencyCode = o.Currency()
+	_, err := r.db.ExecContext(ctx, query, o.Name, o.Description, o.Address, o.Metro, o.AdminPhoneNumber, o.AdminEmail, o.SettlementThresholdCents, o.MaxBillsplitRentalCostCents, o.AutoActivateRentals, o.CurrencyCode, o.ID)
 	return err
 }
+
+func (r *organizationRepository) SetSettlementThreshold(ctx context.Context, orgID int32, thresholdCents *int32) error {
+	query := `UPDATE orgs SET billsplit_settlement_threshold_cents = $1 WHERE id = $2`
+	res, err := r.db.ExecContext(ctx, query, thresholdCents, orgID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *organizationRepository) SetAdjacentMetros(ctx context.Context, orgID int32, metros []string) error {
+	query := `UPDATE orgs SET adjacent_metros = $1 WHERE id = $2`
+	res, err := r.db.ExecContext(ctx, query, pq.Array(metros), orgID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *organizationRepository) ListMetros(ctx context.Context) ([]string, error) {
+	query := `SELECT metro FROM orgs WHERE metro <> ''
+	          UNION
+	          SELECT metro FROM tools WHERE metro <> '' AND deleted_on IS NULL
+	          ORDER BY metro`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var metros []string
+	for rows.Next() {
+		var m string
+		if err := rows.Scan(&m); err != nil {
+			return nil, err
+		}
+		metros = append(metros, m)
+	}
+	return metros, rows.Err()
+}
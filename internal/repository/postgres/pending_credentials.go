@@ -10,10 +10,10 @@ import (
 )
 
 type pendingCredentialsRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewPendingCredentialsRepository(db *sql.DB) repository.PendingCredentialsRepository {
+func NewPendingCredentialsRepository(db DBTX) repository.PendingCredentialsRepository {
 	return &pendingCredentialsRepository{db: db}
 }
 
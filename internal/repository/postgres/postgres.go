@@ -1,40 +1,204 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"time"
+	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
+// Defaults for retrying a WithTx transaction that failed on a transient conflict
+const (
+	DefaultTxMaxAttempts  = 3
+	DefaultTxRetryBackoff = 50 * time.Millisecond
+)
+
+// StoreOptions tunes a Store. Zero values fall back to the package defaults.
+type StoreOptions struct {
+	// TxMaxAttempts bounds how many times WithTx runs a transaction that keeps failing with a
+	// retryable error, the first attempt included
+	TxMaxAttempts int
+	// TxRetryBackoff is the wait before the second attempt; it doubles for each one after
+	TxRetryBackoff time.Duration
+}
+
+// DBTX is what the repositories need from a database handle. Both *sql.DB and *sql.Tx
+// satisfy it, so the same repository code runs inside or outside a transaction.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type Store struct {
-	db *sql.DB
+	db   *sql.DB // nil for a store bound to a transaction by WithTx
+	opts StoreOptions
 	repository.UserRepository
 	repository.OrganizationRepository
 	repository.ToolRepository
 	repository.RentalRepository
+	repository.RentalEventRepository
 	repository.LedgerRepository
 	repository.NotificationRepository
+	repository.NotificationDeadLetterRepository
+	repository.EmailOutboxRepository
 	repository.FcmTokenRepository
 	repository.InvitationRepository
 	repository.JoinRequestRepository
 	repository.BillRepository
 	repository.PendingCredentialsRepository
+	repository.RevokedTokenRepository
+	repository.AdminAuditRepository
+	repository.JobRunRepository
 }
 
 func NewStore(db *sql.DB) *Store {
+	return NewStoreWithOptions(db, StoreOptions{})
+}
+
+func NewStoreWithOptions(db *sql.DB, opts StoreOptions) *Store {
+	if opts.TxMaxAttempts <= 0 {
+		opts.TxMaxAttempts = DefaultTxMaxAttempts
+	}
+	if opts.TxRetryBackoff <= 0 {
+		opts.TxRetryBackoff = DefaultTxRetryBackoff
+	}
+	s := newStore(db)
+	s.db = db
+	s.opts = opts
+	// Memberships read outside transactions are memoized per request (see
+	// repository.WithUserOrgCache); transactions always read them from the database
+	s.UserRepository = repository.NewCachedUserRepository(s.UserRepository)
+	s.LedgerRepository = repository.NewUserOrgInvalidatingLedgerRepository(s.LedgerRepository)
+	return s
+}
+
+func newStore(db DBTX) *Store {
 	return &Store{
-		db:                           db,
-		UserRepository:               NewUserRepository(db),
-		OrganizationRepository:       NewOrganizationRepository(db),
-		ToolRepository:               NewToolRepository(db),
-		RentalRepository:             NewRentalRepository(db),
-		LedgerRepository:             NewLedgerRepository(db),
-		NotificationRepository:       NewNotificationRepository(db),
-		FcmTokenRepository:           NewFcmTokenRepository(db),
-		InvitationRepository:         NewInvitationRepository(db),
-		JoinRequestRepository:        NewJoinRequestRepository(db),
-		BillRepository:               NewBillRepository(db),
-		PendingCredentialsRepository: NewPendingCredentialsRepository(db),
+		UserRepository:                   NewUserRepository(db),
+		OrganizationRepository:           NewOrganizationRepository(db),
+		ToolRepository:                   NewToolRepository(db),
+		RentalRepository:                 NewRentalRepository(db),
+		RentalEventRepository:            NewRentalEventRepository(db),
+		LedgerRepository:                 NewLedgerRepository(db),
+		NotificationRepository:           NewNotificationRepository(db),
+		NotificationDeadLetterRepository: NewNotificationDeadLetterRepository(db),
+		EmailOutboxRepository:            NewEmailOutboxRepository(db),
+		FcmTokenRepository:               NewFcmTokenRepository(db),
+		InvitationRepository:             NewInvitationRepository(db),
+		JoinRequestRepository:            NewJoinRequestRepository(db),
+		BillRepository:                   NewBillRepository(db),
+		PendingCredentialsRepository:     NewPendingCredentialsRepository(db),
+		RevokedTokenRepository:           NewRevokedTokenRepository(db),
+		AdminAuditRepository:             NewAdminAuditRepository(db),
+		JobRunRepository:                 NewJobRunRepository(db),
+	}
+}
+
+// WithTx runs fn with a store whose repositories all use one transaction. The transaction
+// commits when fn returns nil and rolls back when it returns an error or panics. Called on a
+// store that is already bound to a transaction, fn simply joins it.
+//
+// When the transaction fails with a retryable error (see IsRetryable), the whole transaction
+// is run again, up to StoreOptions.TxMaxAttempts times with exponential backoff, so fn must
+// not have side effects outside the transaction. A joined fn is never retried on its own;
+// the outermost WithTx retries it together with the rest of the transaction.
+//
+// The transaction's writes bypass the membership cache, so every attempt, committed or not,
+// drops whatever ctx has memoized.
+func (s *Store) WithTx(ctx context.Context, fn func(tx *Store) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+	backoff := s.opts.TxRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, fn)
+		if err == nil {
+			return nil
+		}
+		if !IsRetryable(err) || attempt >= s.opts.TxMaxAttempts {
+			return err
+		}
+		logger.WarnContext(ctx, "Transient transaction failure, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// runTx makes a single attempt at the transaction for WithTx
+func (s *Store) runTx(ctx context.Context, fn func(tx *Store) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer repository.InvalidateUserOrgCache(ctx)
+	defer tx.Rollback()
+
+	if err := fn(newStore(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsRetryable reports whether err is a Postgres serialization failure or deadlock. Either
+// one aborts the transaction through no fault of its own, so running it again can succeed.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	switch pqErr.Code.Name() {
+	case "serialization_failure", "deadlock_detected":
+		return true
+	}
+	return false
+}
+
+// Transactor exposes WithTx to the service layer, which only knows the repository interfaces
+func (s *Store) Transactor() repository.Transactor {
+	return storeTransactor{store: s}
+}
+
+type storeTransactor struct {
+	store *Store
+}
+
+func (t storeTransactor) WithTx(ctx context.Context, fn func(tx repository.TxRepositories) error) error {
+	return t.store.WithTx(ctx, func(tx *Store) error {
+		return fn(repository.TxRepositories{
+			Users:        tx.UserRepository,
+			Tools:        tx.ToolRepository,
+			Rentals:      tx.RentalRepository,
+			RentalEvents: tx.RentalEventRepository,
+			Ledger:       tx.LedgerRepository,
+			Bills:        tx.BillRepository,
+		})
+	})
+}
+
+// withTx runs fn in a transaction on db for repository methods that must be atomic on their
+// own. When db is already a transaction, fn joins it instead of nesting.
+func withTx(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	conn, ok := db.(*sql.DB)
+	if !ok {
+		return fn(db)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
 	}
+	return tx.Commit()
 }
@@ -9,31 +9,34 @@ import (
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/utils"
+
+	"github.com/lib/pq"
 )
 
 type rentalRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewRentalRepository(db *sql.DB) repository.RentalRepository {
+func NewRentalRepository(db DBTX) repository.RentalRepository {
 	return &rentalRepository{db: db}
 }
 
 func (r *rentalRepository) Create(ctx context.Context, rt *domain.Rental) error {
-	query := `INSERT INTO rentals (org_id, tool_id, renter_id, owner_id, start_date, end_date, duration_unit, daily_price_cents, weekly_price_cents, monthly_price_cents, replacement_cost_cents, total_cost_cents, status, created_on, updated_on)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id`
+	query := `INSERT INTO rentals (org_id, tool_id, renter_id, owner_id, start_date, end_date, duration_unit, daily_price_cents, weekly_price_cents, monthly_price_cents, replacement_cost_cents, pricing_rounding, total_cost_cents, status, created_on, updated_on)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id`
 	now := time.Now().Format("2006-01-02")
-	return r.db.QueryRowContext(ctx, query, rt.OrgID, rt.ToolID, rt.RenterID, rt.OwnerID, rt.StartDate, rt.EndDate, rt.DurationUnit, rt.DailyPriceCents, rt.WeeklyPriceCents, rt.MonthlyPriceCents, rt.ReplacementCostCents, rt.TotalCostCents, rt.Status, now, now).Scan(&rt.ID)
+	return r.db.QueryRowContext(ctx, query, rt.OrgID, rt.ToolID, rt.RenterID, rt.OwnerID, rt.StartDate, rt.EndDate, rt.DurationUnit, rt.DailyPriceCents, rt.WeeklyPriceCents, rt.MonthlyPriceCents, rt.ReplacementCostCents, rt.PricingRounding, rt.TotalCostCents, rt.Status, now, now).Scan(&rt.ID)
 }
 
 func (r *rentalRepository) GetByID(ctx context.Context, id int32) (*domain.Rental, error) {
 	rt := &domain.Rental{}
-	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on FROM rentals WHERE id = $1`
+	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, requested_end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(pricing_rounding, 'round_up'), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on FROM rentals WHERE id = $1`
 
 	var startDate, endDate, createdOn, updatedOn time.Time
-	var lastAgreedEndDate sql.NullTime
+	var lastAgreedEndDate, requestedEndDate sql.NullTime
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &requestedEndDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.PricingRounding, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn)
 	if err != nil {
 		return nil, err
 	}
@@ -45,19 +48,28 @@ func (r *rentalRepository) GetByID(ctx context.Context, id int32) (*domain.Renta
 		dateStr := lastAgreedEndDate.Time.Format("2006-01-02")
 		rt.LastAgreedEndDate = &dateStr
 	}
+	if requestedEndDate.Valid {
+		dateStr := requestedEndDate.Time.Format("2006-01-02")
+		rt.RequestedEndDate = &dateStr
+	}
 
 	return rt, nil
 }
 
 func (r *rentalRepository) Update(ctx context.Context, rt *domain.Rental) error {
-	query := `UPDATE rentals SET status=$1, pickup_note=$2, start_date=$3, last_agreed_end_date=$4, end_date=$5, total_cost_cents=$6, rejection_reason=$7, completed_by=$8, return_condition=$9, surcharge_or_credit_cents=$10, return_note=$11, charge_billsplit=$12, updated_on=$13 WHERE id=$14`
-	_, err := r.db.ExecContext(ctx, query, rt.Status, rt.PickupNote, rt.StartDate, rt.LastAgreedEndDate, rt.EndDate, rt.TotalCostCents, rt.RejectionReason, rt.CompletedBy, rt.ReturnCondition, rt.SurchargeOrCreditCents, rt.Notes, rt.ChargeBillsplit, time.Now().Format("2006-01-02"), rt.ID)
+	query := `UPDATE rentals SET status=$1, pickup_note=$2, start_date=$3, last_agreed_end_date=$4, end_date=$5, total_cost_cents=$6, rejection_reason=$7, completed_by=$8, return_condition=$9, surcharge_or_credit_cents=$10, return_note=$11, charge_billsplit=$12, updated_on=$13, requested_end_date=$14 WHERE id=$15`
+	_, err := r.db.ExecContext(ctx, query, rt.Status, rt.PickupNote, rt.StartDate, rt.LastAgreedEndDate, rt.EndDate, rt.TotalCostCents, rt.RejectionReason, rt.CompletedBy, rt.ReturnCondition, rt.SurchargeOrCreditCents, rt.Notes, rt.ChargeBillsplit, time.Now().Format("2006-01-02"), rt.RequestedEndDate, rt.ID)
 	return err
 }
 
+// rentalListOrder is the ordering of every paginated rental listing. created_on is a date, so
+// id breaks the ties that would otherwise let rows repeat or vanish between pages; the
+// idx_rentals_*_listing indexes serve it directly.
+const rentalListOrder = "created_on DESC, id DESC"
+
 func (r *rentalRepository) ListByRenter(ctx context.Context, renterID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
-	offset := (page - 1) * pageSize
-	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
+	limit, offset := utils.Paginate(page, pageSize)
+	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, requested_end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(pricing_rounding, 'round_up'), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
 	        FROM rentals WHERE renter_id = $1 AND org_id = $2`
 
 	args := []interface{}{renterID, orgID}
@@ -79,8 +91,8 @@ func (r *rentalRepository) ListByRenter(ctx context.Context, renterID, orgID int
 		return nil, 0, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY created_on DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pageSize, offset)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", rentalListOrder, argIdx, argIdx+1)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -92,9 +104,9 @@ func (r *rentalRepository) ListByRenter(ctx context.Context, renterID, orgID int
 	for rows.Next() {
 		var rt domain.Rental
 		var startDate, endDate, createdOn, updatedOn time.Time
-		var lastAgreedEndDate sql.NullTime
+		var lastAgreedEndDate, requestedEndDate sql.NullTime
 
-		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
+		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &requestedEndDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.PricingRounding, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
 			return nil, 0, err
 		}
 		rt.StartDate = startDate.Format("2006-01-02")
@@ -105,14 +117,18 @@ func (r *rentalRepository) ListByRenter(ctx context.Context, renterID, orgID int
 			dateStr := lastAgreedEndDate.Time.Format("2006-01-02")
 			rt.LastAgreedEndDate = &dateStr
 		}
+		if requestedEndDate.Valid {
+			dateStr := requestedEndDate.Time.Format("2006-01-02")
+			rt.RequestedEndDate = &dateStr
+		}
 		rentals = append(rentals, rt)
 	}
 	return rentals, count, nil
 }
 
 func (r *rentalRepository) ListByOwner(ctx context.Context, ownerID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
-	offset := (page - 1) * pageSize
-	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
+	limit, offset := utils.Paginate(page, pageSize)
+	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, requested_end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(pricing_rounding, 'round_up'), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
 	        FROM rentals WHERE owner_id = $1 AND org_id = $2`
 
 	args := []interface{}{ownerID, orgID}
@@ -134,8 +150,8 @@ func (r *rentalRepository) ListByOwner(ctx context.Context, ownerID, orgID int32
 		return nil, 0, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY created_on DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pageSize, offset)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", rentalListOrder, argIdx, argIdx+1)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -147,9 +163,9 @@ func (r *rentalRepository) ListByOwner(ctx context.Context, ownerID, orgID int32
 	for rows.Next() {
 		var rt domain.Rental
 		var startDate, endDate, createdOn, updatedOn time.Time
-		var lastAgreedEndDate sql.NullTime
+		var lastAgreedEndDate, requestedEndDate sql.NullTime
 
-		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
+		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &requestedEndDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.PricingRounding, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
 			return nil, 0, err
 		}
 		rt.StartDate = startDate.Format("2006-01-02")
@@ -160,14 +176,18 @@ func (r *rentalRepository) ListByOwner(ctx context.Context, ownerID, orgID int32
 			dateStr := lastAgreedEndDate.Time.Format("2006-01-02")
 			rt.LastAgreedEndDate = &dateStr
 		}
+		if requestedEndDate.Valid {
+			dateStr := requestedEndDate.Time.Format("2006-01-02")
+			rt.RequestedEndDate = &dateStr
+		}
 		rentals = append(rentals, rt)
 	}
 	return rentals, count, nil
 }
 
-func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
-	offset := (page - 1) * pageSize
-	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
+func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error) {
+	limit, offset := utils.Paginate(page, pageSize)
+	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, requested_end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(pricing_rounding, 'round_up'), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
 	        FROM rentals WHERE tool_id = $1`
 
 	args := []interface{}{toolID}
@@ -189,6 +209,18 @@ func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32,
 		query += " AND status IN (" + strings.Join(placeholders, ", ") + ")"
 	}
 
+	// Overlap with the inclusive window; either bound may be open
+	if fromDate != "" {
+		query += fmt.Sprintf(" AND end_date >= $%d", argIdx)
+		args = append(args, fromDate)
+		argIdx++
+	}
+	if toDate != "" {
+		query += fmt.Sprintf(" AND start_date <= $%d", argIdx)
+		args = append(args, toDate)
+		argIdx++
+	}
+
 	var count int32
 	countSql := "SELECT count(*) FROM (" + query + ") as sub"
 	err := r.db.QueryRowContext(ctx, countSql, args...).Scan(&count)
@@ -196,8 +228,8 @@ func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32,
 		return nil, 0, err
 	}
 
-	query += fmt.Sprintf(" ORDER BY created_on DESC LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pageSize, offset)
+	query += fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", rentalListOrder, argIdx, argIdx+1)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -209,9 +241,9 @@ func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32,
 	for rows.Next() {
 		var rt domain.Rental
 		var startDate, endDate, createdOn, updatedOn time.Time
-		var lastAgreedEndDate sql.NullTime
+		var lastAgreedEndDate, requestedEndDate sql.NullTime
 
-		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
+		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &requestedEndDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.PricingRounding, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
 			return nil, 0, err
 		}
 		rt.StartDate = startDate.Format("2006-01-02")
@@ -222,7 +254,126 @@ func (r *rentalRepository) ListByTool(ctx context.Context, toolID, orgID int32,
 			dateStr := lastAgreedEndDate.Time.Format("2006-01-02")
 			rt.LastAgreedEndDate = &dateStr
 		}
+		if requestedEndDate.Valid {
+			dateStr := requestedEndDate.Time.Format("2006-01-02")
+			rt.RequestedEndDate = &dateStr
+		}
 		rentals = append(rentals, rt)
 	}
 	return rentals, count, nil
 }
+
+func (r *rentalRepository) ListBusyRanges(ctx context.Context, toolIDs []int32, fromDate, toDate string) ([]domain.ToolBusyRange, error) {
+	statuses := make([]string, len(domain.BusyRentalStatuses))
+	for i, st := range domain.BusyRentalStatuses {
+		statuses[i] = string(st)
+	}
+
+	// One query across all tools; a range is busy if it overlaps [fromDate, toDate].
+	// A pending extension keeps the requested dates blocked (GREATEST skips a NULL requested_end_date).
+	query := `SELECT tool_id, id, start_date, GREATEST(end_date, requested_end_date), status
+	        FROM rentals
+	        WHERE tool_id = ANY($1) AND status = ANY($2) AND start_date <= $4 AND GREATEST(end_date, requested_end_date) >= $3
+	        ORDER BY tool_id, start_date, id`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(toolIDs), pq.Array(statuses), fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges []domain.ToolBusyRange
+	for rows.Next() {
+		var br domain.ToolBusyRange
+		var startDate, endDate time.Time
+		if err := rows.Scan(&br.ToolID, &br.RentalID, &startDate, &endDate, &br.Status); err != nil {
+			return nil, err
+		}
+		br.StartDate = startDate.Format("2006-01-02")
+		br.EndDate = endDate.Format("2006-01-02")
+		ranges = append(ranges, br)
+	}
+	return ranges, rows.Err()
+}
+
+// rentalStatusStrings converts statuses for binding as a text[] parameter
+func rentalStatusStrings(statuses []domain.RentalStatus) []string {
+	out := make([]string, len(statuses))
+	for i, st := range statuses {
+		out[i] = string(st)
+	}
+	return out
+}
+
+// withoutRentalStatus returns statuses minus drop
+func withoutRentalStatus(statuses []domain.RentalStatus, drop domain.RentalStatus) []domain.RentalStatus {
+	out := make([]domain.RentalStatus, 0, len(statuses))
+	for _, st := range statuses {
+		if st != drop {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// CountActivity leaves OVERDUE rentals out of PendingActions: the dashboard already reports
+// them under ActiveRentals and ActiveLendings, and counting them twice would inflate the badge.
+func (r *rentalRepository) CountActivity(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error) {
+	query := `SELECT
+	            COUNT(*) FILTER (WHERE renter_id = $1 AND status = ANY($2)),
+	            COUNT(*) FILTER (WHERE owner_id = $1 AND status = ANY($2)),
+	            COUNT(*) FILTER (WHERE (owner_id = $1 AND status = ANY($3)) OR (renter_id = $1 AND status = ANY($4)))
+	        FROM rentals
+	        WHERE renter_id = $1 OR owner_id = $1`
+
+	counts := &domain.RentalActivityCounts{}
+	err := r.db.QueryRowContext(ctx, query, userID, pq.Array(rentalStatusStrings(domain.InProgressRentalStatuses)),
+		pq.Array(rentalStatusStrings(withoutRentalStatus(domain.OwnerActionRentalStatuses, domain.RentalStatusOverdue))),
+		pq.Array(rentalStatusStrings(withoutRentalStatus(domain.RenterActionRentalStatuses, domain.RentalStatusOverdue)))).
+		Scan(&counts.ActiveRentals, &counts.ActiveLendings, &counts.PendingActions)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ListActionable returns the rentals in orgID waiting on the user, most recent first. The set is
+// bounded by the user's open negotiations, so it is not paginated.
+func (r *rentalRepository) ListActionable(ctx context.Context, userID, orgID int32) ([]domain.Rental, error) {
+	query := `SELECT id, org_id, tool_id, renter_id, owner_id, start_date, last_agreed_end_date, end_date, requested_end_date, COALESCE(duration_unit, ''), COALESCE(daily_price_cents, 0), COALESCE(weekly_price_cents, 0), COALESCE(monthly_price_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(pricing_rounding, 'round_up'), COALESCE(total_cost_cents, 0), status, COALESCE(pickup_note, ''), COALESCE(rejection_reason, ''), completed_by, COALESCE(return_condition, ''), COALESCE(surcharge_or_credit_cents, 0), COALESCE(return_note, ''), COALESCE(charge_billsplit, true), created_on, updated_on
+	        FROM rentals
+	        WHERE org_id = $2 AND ((owner_id = $1 AND status = ANY($3)) OR (renter_id = $1 AND status = ANY($4)))
+	        ORDER BY ` + rentalListOrder
+
+	rows, err := r.db.QueryContext(ctx, query, userID, orgID,
+		pq.Array(rentalStatusStrings(domain.OwnerActionRentalStatuses)), pq.Array(rentalStatusStrings(domain.RenterActionRentalStatuses)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rentals []domain.Rental
+	for rows.Next() {
+		var rt domain.Rental
+		var startDate, endDate, createdOn, updatedOn time.Time
+		var lastAgreedEndDate, requestedEndDate sql.NullTime
+
+		if err := rows.Scan(&rt.ID, &rt.OrgID, &rt.ToolID, &rt.RenterID, &rt.OwnerID, &startDate, &lastAgreedEndDate, &endDate, &requestedEndDate, &rt.DurationUnit, &rt.DailyPriceCents, &rt.WeeklyPriceCents, &rt.MonthlyPriceCents, &rt.ReplacementCostCents, &rt.PricingRounding, &rt.TotalCostCents, &rt.Status, &rt.PickupNote, &rt.RejectionReason, &rt.CompletedBy, &rt.ReturnCondition, &rt.SurchargeOrCreditCents, &rt.Notes, &rt.ChargeBillsplit, &createdOn, &updatedOn); err != nil {
+			return nil, err
+		}
+		rt.StartDate = startDate.Format("2006-01-02")
+		rt.EndDate = endDate.Format("2006-01-02")
+		rt.CreatedOn = createdOn.Format("2006-01-02")
+		rt.UpdatedOn = updatedOn.Format("2006-01-02")
+		if lastAgreedEndDate.Valid {
+			dateStr := lastAgreedEndDate.Time.Format("2006-01-02")
+			rt.LastAgreedEndDate = &dateStr
+		}
+		if requestedEndDate.Valid {
+			dateStr := requestedEndDate.Time.Format("2006-01-02")
+			rt.RequestedEndDate = &dateStr
+		}
+		rentals = append(rentals, rt)
+	}
+	return rentals, rows.Err()
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type rentalEventRepository struct {
+	db DBTX
+}
+
+func NewRentalEventRepository(db DBTX) repository.RentalEventRepository {
+	return &rentalEventRepository{db: db}
+}
+
+func (r *rentalEventRepository) Create(ctx context.Context, e *domain.RentalEvent) error {
+	query := `INSERT INTO rental_events (rental_id, actor_user_id, from_status, to_status, note)
+	          VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, '')) RETURNING id, created_at`
+	logger.DatabaseCall("INSERT", "rental_events", "rentalID", e.RentalID, "from", e.FromStatus, "to", e.ToStatus)
+
+	err := r.db.QueryRowContext(ctx, query, e.RentalID, e.ActorUserID, string(e.FromStatus), string(e.ToStatus), e.Note).
+		Scan(&e.ID, &e.CreatedAt)
+	logger.DatabaseResult("INSERT", 1, err, "eventID", e.ID)
+	return err
+}
+
+func (r *rentalEventRepository) ListByRental(ctx context.Context, rentalID int32) ([]domain.RentalEvent, error) {
+	query := `SELECT id, rental_id, actor_user_id, COALESCE(from_status, ''), to_status, COALESCE(note, ''), created_at
+	          FROM rental_events WHERE rental_id = $1 ORDER BY created_at, id`
+	logger.DatabaseCall("SELECT", "rental_events", "rentalID", rentalID)
+
+	rows, err := r.db.QueryContext(ctx, query, rentalID)
+	if err != nil {
+		logger.DatabaseResult("SELECT", 0, err, "rentalID", rentalID)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.RentalEvent
+	for rows.Next() {
+		var e domain.RentalEvent
+		var actor sql.NullInt32
+		if err := rows.Scan(&e.ID, &e.RentalID, &actor, &e.FromStatus, &e.ToStatus, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if actor.Valid {
+			e.ActorUserID = &actor.Int32
+		}
+		events = append(events, e)
+	}
+	logger.DatabaseResult("SELECT", int64(len(events)), rows.Err(), "rentalID", rentalID)
+	return events, rows.Err()
+}
@@ -4,39 +4,49 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
+	"unicode"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/utils"
 
 	"github.com/lib/pq"
 )
 
 type toolRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewToolRepository(db *sql.DB) repository.ToolRepository {
+func NewToolRepository(db DBTX) repository.ToolRepository {
 	return &toolRepository{db: db}
 }
 
 func (r *toolRepository) Create(ctx context.Context, t *domain.Tool) error {
-	query := `INSERT INTO tools (owner_id, name, description, categories, price_per_day_cents, price_per_week_cents, price_per_month_cents, replacement_cost_cents, duration_unit, condition, metro, status, created_on) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id`
+	query := `INSERT INTO tools (owner_id, name, description, categories, price_per_day_cents, price_per_week_cents, price_per_month_cents, replacement_cost_cents, duration_unit, condition, metro, status, created_on, updated_on) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13) RETURNING id`
 	now := time.Now().Format("2006-01-02")
-	return r.db.QueryRowContext(ctx, query, t.OwnerID, t.Name, t.Description, pq.Array(t.Categories), t.PricePerDayCents, t.PricePerWeekCents, t.PricePerMonthCents, t.ReplacementCostCents, t.DurationUnit, t.Condition, t.Metro, t.Status, now).Scan(&t.ID)
+	t.Metro = domain.NormalizeMetro(t.Metro)
+	if err := r.db.QueryRowContext(ctx, query, t.OwnerID, t.Name, t.Description, pq.Array(t.Categories), t.PricePerDayCents, t.PricePerWeekCents, t.PricePerMonthCents, t.ReplacementCostCents, t.DurationUnit, t.Condition, t.Metro, t.Status, now).Scan(&t.ID); err != nil {
+		return err
+	}
+	t.CreatedOn = now
+	t.UpdatedOn = now
+	return nil
 }
 
 func (r *toolRepository) GetByID(ctx context.Context, id int32) (*domain.Tool, error) {
 	t := &domain.Tool{}
-	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, deleted_on FROM tools WHERE id = $1`
-	var createdOn time.Time
+	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, COALESCE(updated_on, created_on), deleted_on FROM tools WHERE id = $1`
+	var createdOn, updatedOn time.Time
 	var deletedOn sql.NullTime
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &deletedOn)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &updatedOn, &deletedOn)
 	if err != nil {
 		return nil, err
 	}
 	t.CreatedOn = createdOn.Format("2006-01-02")
+	t.UpdatedOn = updatedOn.Format("2006-01-02")
 	if deletedOn.Valid {
 		dateStr := deletedOn.Time.Format("2006-01-02")
 		t.DeletedOn = &dateStr
@@ -45,9 +55,15 @@ func (r *toolRepository) GetByID(ctx context.Context, id int32) (*domain.Tool, e
 }
 
 func (r *toolRepository) Update(ctx context.Context, t *domain.Tool) error {
-	query := `UPDATE tools SET name=$1, description=$2, categories=$3, price_per_day_cents=$4, price_per_week_cents=$5, price_per_month_cents=$6, replacement_cost_cents=$7, condition=$8, metro=$9, status=$10, duration_unit=$11 WHERE id=$12`
-	_, err := r.db.ExecContext(ctx, query, t.Name, t.Description, pq.Array(t.Categories), t.PricePerDayCents, t.PricePerWeekCents, t.PricePerMonthCents, t.ReplacementCostCents, t.Condition, t.Metro, t.Status, t.DurationUnit, t.ID)
-	return err
+	query := `UPDATE tools SET name=$1, description=$2, categories=$3, price_per_day_cents=$4, price_per_week_cents=$5, price_per_month_cents=$6, replacement_cost_cents=$7, condition=$8, metro=$9, status=$10, duration_unit=$11, updated_on=$12 WHERE id=$13`
+	now := time.Now().Format("2006-01-02")
+	t.Metro = domain.NormalizeMetro(t.Metro)
+	_, err := r.db.ExecContext(ctx, query, t.Name, t.Description, pq.Array(t.Categories), t.PricePerDayCents, t.PricePerWeekCents, t.PricePerMonthCents, t.ReplacementCostCents, t.Condition, t.Metro, t.Status, t.DurationUnit, now, t.ID)
+	if err != nil {
+		return err
+	}
+	t.UpdatedOn = now
+	return nil
 }
 
 func (r *toolRepository) Delete(ctx context.Context, id int32) error {
@@ -56,32 +72,38 @@ func (r *toolRepository) Delete(ctx context.Context, id int32) error {
 	return err
 }
 
-func (r *toolRepository) ListByOrg(ctx context.Context, orgID int32, page, pageSize int32) ([]domain.Tool, int32, error) {
+func (r *toolRepository) ListByOrg(ctx context.Context, orgID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error) {
 	// Note: tools table doesn't have org_id, but the requirement says "List tools in an organization".
 	// This usually means tools belonging to users who are members of the organization.
 	// However, the schema shows tools are global but can be filtered by metro.
 	// Looking at the PRD: "Initial Context: User selects a 'Current Org' (e.g., Church A) to start searching. ... Auto-Metro Filter: The search automatically filters for Tools in Church A's metro."
 	// So we'll filter by metro of the organization.
 
-	orgQuery := `SELECT metro FROM orgs WHERE id = $1`
+	orgQuery := `SELECT metro, adjacent_metros FROM orgs WHERE id = $1`
 	var metro string
-	err := r.db.QueryRowContext(ctx, orgQuery, orgID).Scan(&metro)
+	var adjacent []string
+	err := r.db.QueryRowContext(ctx, orgQuery, orgID).Scan(&metro, pq.Array(&adjacent))
 	if err != nil {
 		return nil, 0, err
 	}
+	metros := []string{metro}
+	if includeAdjacent {
+		metros = append(metros, adjacent...)
+	}
+	keys := pq.Array(metroKeys(metros))
 
-	offset := (page - 1) * pageSize
-	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, deleted_on 
-	          FROM tools WHERE metro = $1 AND deleted_on IS NULL LIMIT $2 OFFSET $3`
-	rows, err := r.db.QueryContext(ctx, query, metro, pageSize, offset)
+	limit, offset := utils.Paginate(page, pageSize)
+	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, COALESCE(updated_on, created_on), deleted_on 
+	          FROM tools WHERE lower(metro) = ANY($1) AND deleted_on IS NULL LIMIT $2 OFFSET $3`
+	rows, err := r.db.QueryContext(ctx, query, keys, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var count int32
-	countQuery := `SELECT count(*) FROM tools WHERE metro = $1 AND deleted_on IS NULL`
-	err = r.db.QueryRowContext(ctx, countQuery, metro).Scan(&count)
+	countQuery := `SELECT count(*) FROM tools WHERE lower(metro) = ANY($1) AND deleted_on IS NULL`
+	err = r.db.QueryRowContext(ctx, countQuery, keys).Scan(&count)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -89,7 +111,7 @@ func (r *toolRepository) ListByOrg(ctx context.Context, orgID int32, page, pageS
 	var tools []domain.Tool
 	for rows.Next() {
 		var t domain.Tool
-		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &t.CreatedOn, &t.DeletedOn); err != nil {
+		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &t.CreatedOn, &t.UpdatedOn, &t.DeletedOn); err != nil {
 			return nil, 0, err
 		}
 		tools = append(tools, t)
@@ -97,11 +119,21 @@ func (r *toolRepository) ListByOrg(ctx context.Context, orgID int32, page, pageS
 	return tools, count, nil
 }
 
+func (r *toolRepository) CountAvailableByMetro(ctx context.Context, metro string) (int32, error) {
+	query := `SELECT count(*) FROM tools WHERE lower(metro) = ANY($1) AND deleted_on IS NULL AND status != $2`
+	var count int32
+	err := r.db.QueryRowContext(ctx, query, pq.Array(metroKeys([]string{metro})), domain.ToolStatusUnavailable).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *toolRepository) ListByOwner(ctx context.Context, ownerID int32, page, pageSize int32) ([]domain.Tool, int32, error) {
-	offset := (page - 1) * pageSize
-	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, deleted_on 
+	limit, offset := utils.Paginate(page, pageSize)
+	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, COALESCE(updated_on, created_on), deleted_on 
 	          FROM tools WHERE owner_id = $1 AND deleted_on IS NULL LIMIT $2 OFFSET $3`
-	rows, err := r.db.QueryContext(ctx, query, ownerID, pageSize, offset)
+	rows, err := r.db.QueryContext(ctx, query, ownerID, limit, offset)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -117,12 +149,13 @@ func (r *toolRepository) ListByOwner(ctx context.Context, ownerID int32, page, p
 	var tools []domain.Tool
 	for rows.Next() {
 		var t domain.Tool
-		var createdOn time.Time
+		var createdOn, updatedOn time.Time
 		var deletedOn sql.NullTime
-		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &deletedOn); err != nil {
+		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &updatedOn, &deletedOn); err != nil {
 			return nil, 0, err
 		}
 		t.CreatedOn = createdOn.Format("2006-01-02")
+		t.UpdatedOn = updatedOn.Format("2006-01-02")
 		if deletedOn.Valid {
 			dateStr := deletedOn.Time.Format("2006-01-02")
 			t.DeletedOn = &dateStr
@@ -132,19 +165,29 @@ func (r *toolRepository) ListByOwner(ctx context.Context, ownerID int32, page, p
 	return tools, count, nil
 }
 
-func (r *toolRepository) Search(ctx context.Context, userID int32, metro, queryTerm string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
-	offset := (page - 1) * pageSize
-	// Basic filters: metro, not deleted, not owner, status not UNAVAILABLE
-	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, deleted_on 
-	          FROM tools WHERE metro = $1 AND deleted_on IS NULL AND owner_id != $2 AND status != $3`
+func (r *toolRepository) Search(ctx context.Context, userID int32, metros []string, queryTerm string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
+	limit, offset := utils.Paginate(page, pageSize)
+	// Basic filters: metros, not deleted, not owner, status not UNAVAILABLE
+	query := `SELECT id, owner_id, name, COALESCE(description, ''), categories, price_per_day_cents, COALESCE(price_per_week_cents, 0), COALESCE(price_per_month_cents, 0), COALESCE(replacement_cost_cents, 0), COALESCE(duration_unit, 'day'), condition, metro, status, created_on, COALESCE(updated_on, created_on), deleted_on 
+	          FROM tools WHERE lower(metro) = ANY($1) AND deleted_on IS NULL AND owner_id != $2 AND status != $3`
 
-	args := []interface{}{metro, userID, domain.ToolStatusUnavailable}
+	args := []interface{}{pq.Array(metroKeys(metros)), userID, domain.ToolStatusUnavailable}
 	argIdx := 4
 
+	// Full-text match ranked by ts_rank; falls back to ILIKE when the term has no lexemes
+	// (punctuation only, or nothing but stop words such as "the")
+	orderBy := ""
 	if queryTerm != "" {
-		query += fmt.Sprintf(" AND (name ILIKE $%d OR description ILIKE $%d)", argIdx, argIdx)
-		args = append(args, "%"+queryTerm+"%")
-		argIdx++
+		if tsQuery := buildToolTSQuery(queryTerm); tsQuery != "" {
+			query += fmt.Sprintf(" AND (search_vector @@ to_tsquery('english', $%d) OR (numnode(to_tsquery('english', $%d)) = 0 AND (name ILIKE $%d OR description ILIKE $%d)))", argIdx, argIdx, argIdx+1, argIdx+1)
+			orderBy = fmt.Sprintf(" ORDER BY ts_rank(search_vector, to_tsquery('english', $%d)) DESC, id", argIdx)
+			args = append(args, tsQuery, "%"+queryTerm+"%")
+			argIdx += 2
+		} else {
+			query += fmt.Sprintf(" AND (name ILIKE $%d OR description ILIKE $%d)", argIdx, argIdx)
+			args = append(args, "%"+queryTerm+"%")
+			argIdx++
+		}
 	}
 	if len(categories) > 0 {
 		query += fmt.Sprintf(" AND categories && $%d", argIdx)
@@ -176,8 +219,9 @@ func (r *toolRepository) Search(ctx context.Context, userID int32, metro, queryT
 		return nil, 0, err
 	}
 
+	query += orderBy
 	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIdx, argIdx+1)
-	args = append(args, pageSize, offset)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -188,12 +232,13 @@ func (r *toolRepository) Search(ctx context.Context, userID int32, metro, queryT
 	var tools []domain.Tool
 	for rows.Next() {
 		var t domain.Tool
-		var createdOn time.Time
+		var createdOn, updatedOn time.Time
 		var deletedOn sql.NullTime
-		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &deletedOn); err != nil {
+		if err := rows.Scan(&t.ID, &t.OwnerID, &t.Name, &t.Description, pq.Array(&t.Categories), &t.PricePerDayCents, &t.PricePerWeekCents, &t.PricePerMonthCents, &t.ReplacementCostCents, &t.DurationUnit, &t.Condition, &t.Metro, &t.Status, &createdOn, &updatedOn, &deletedOn); err != nil {
 			return nil, 0, err
 		}
 		t.CreatedOn = createdOn.Format("2006-01-02")
+		t.UpdatedOn = updatedOn.Format("2006-01-02")
 		if deletedOn.Valid {
 			dateStr := deletedOn.Time.Format("2006-01-02")
 			t.DeletedOn = &dateStr
@@ -286,16 +331,25 @@ func (r *toolRepository) GetPendingImagesByUser(ctx context.Context, userID int3
 	return images, nil
 }
 
-// UpdateImage updates an existing image record
+// UpdateImage updates an existing image record. Making a confirmed image primary
+// unsets the tool's previous primary in the same transaction.
 func (r *toolRepository) UpdateImage(ctx context.Context, img *domain.ToolImage) error {
-	query := `UPDATE tool_images 
-	          SET tool_id = $2, file_path = $3, thumbnail_path = $4, file_size = $5, 
-	              is_primary = $6, display_order = $7, status = $8, confirmed_at = $9
-	          WHERE id = $1`
+	return withTx(ctx, r.db, func(tx DBTX) error {
+		if img.IsPrimary && img.Status == "CONFIRMED" {
+			_, err := tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = false WHERE tool_id = $1 AND id <> $2 AND is_primary = true`, img.ToolID, img.ID)
+			if err != nil {
+				return err
+			}
+		}
 
-	_, err := r.db.ExecContext(ctx, query, img.ID, img.ToolID, img.FilePath, img.ThumbnailPath,
-		img.FileSize, img.IsPrimary, img.DisplayOrder, img.Status, img.ConfirmedOn)
-	return err
+		query := `UPDATE tool_images 
+		          SET tool_id = $2, file_path = $3, thumbnail_path = $4, file_size = $5, 
+		              is_primary = $6, display_order = $7, status = $8, confirmed_at = $9
+		          WHERE id = $1`
+		_, err := tx.ExecContext(ctx, query, img.ID, img.ToolID, img.FilePath, img.ThumbnailPath,
+			img.FileSize, img.IsPrimary, img.DisplayOrder, img.Status, img.ConfirmedOn)
+		return err
+	})
 }
 
 // ConfirmImage transitions a pending image to confirmed status
@@ -320,42 +374,78 @@ func (r *toolRepository) ConfirmImage(ctx context.Context, imageID int32, toolID
 	return nil
 }
 
-// DeleteImage soft deletes an image
+// DeleteImage soft deletes an image. If it was the tool's primary, the next confirmed
+// image by display_order is promoted in the same transaction.
 func (r *toolRepository) DeleteImage(ctx context.Context, imageID int32) error {
-	query := `UPDATE tool_images SET status = 'DELETED', deleted_at = $1 WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, time.Now(), imageID)
-	return err
+	return withTx(ctx, r.db, func(tx DBTX) error {
+		var toolID int32
+		var wasPrimary bool
+		err := tx.QueryRowContext(ctx, `SELECT tool_id, is_primary FROM tool_images WHERE id = $1 FOR UPDATE`, imageID).Scan(&toolID, &wasPrimary)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE tool_images SET status = 'DELETED', is_primary = false, deleted_at = $1 WHERE id = $2`, time.Now(), imageID)
+		if err != nil {
+			return err
+		}
+
+		if wasPrimary {
+			_, err = tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = true
+			          WHERE id = (SELECT id FROM tool_images
+			                      WHERE tool_id = $1 AND status = 'CONFIRMED' AND deleted_at IS NULL
+			                      ORDER BY display_order ASC, created_at ASC, id ASC LIMIT 1)`, toolID)
+		}
+		return err
+	})
 }
 
 // SetPrimaryImage sets a specific image as primary for a tool
 func (r *toolRepository) SetPrimaryImage(ctx context.Context, toolID int32, imageID int32) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	// Unset all primaries for this tool
-	_, err = tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = false WHERE tool_id = $1 AND status = 'CONFIRMED'`, toolID)
-	if err != nil {
-		return err
-	}
+	return withTx(ctx, r.db, func(tx DBTX) error {
+		// Unset all primaries for this tool
+		_, err := tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = false WHERE tool_id = $1 AND status = 'CONFIRMED'`, toolID)
+		if err != nil {
+			return err
+		}
 
-	// Set new primary
-	result, err := tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = true WHERE id = $1 AND tool_id = $2 AND status = 'CONFIRMED'`, imageID, toolID)
-	if err != nil {
-		return err
-	}
+		// Set new primary
+		result, err := tx.ExecContext(ctx, `UPDATE tool_images SET is_primary = true WHERE id = $1 AND tool_id = $2 AND status = 'CONFIRMED'`, imageID, toolID)
+		if err != nil {
+			return err
+		}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if rows == 0 {
-		return fmt.Errorf("image not found or not confirmed")
-	}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("image not found or not confirmed")
+		}
+		return nil
+	})
+}
 
-	return tx.Commit()
+// ReorderImages sets display_order to each image's 0-based position in imageIDs. Nothing is
+// changed unless every listed image is a confirmed image of the tool.
+func (r *toolRepository) ReorderImages(ctx context.Context, toolID int32, imageIDs []int32) error {
+	return withTx(ctx, r.db, func(tx DBTX) error {
+		query := `UPDATE tool_images t SET display_order = o.ord - 1
+		          FROM unnest($2::int[]) WITH ORDINALITY AS o(id, ord)
+		          WHERE t.id = o.id AND t.tool_id = $1 AND t.status = 'CONFIRMED'`
+		result, err := tx.ExecContext(ctx, query, toolID, pq.Array(imageIDs))
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows != int64(len(imageIDs)) {
+			return fmt.Errorf("reordered %d of %d images", rows, len(imageIDs))
+		}
+		return nil
+	})
 }
 
 // DeleteExpiredPendingImages removes expired pending images
@@ -366,3 +456,46 @@ func (r *toolRepository) DeleteExpiredPendingImages(ctx context.Context) error {
 	_, err := r.db.ExecContext(ctx, query, time.Now())
 	return err
 }
+
+func (r *toolRepository) ListCategories(ctx context.Context) ([]string, error) {
+	query := `SELECT name FROM tool_categories ORDER BY sort_order, name`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var categories []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// metroKeys returns the distinct lowercased canonical forms of metros, matching the
+// lower(metro) index on tools
+func metroKeys(metros []string) []string {
+	keys := make([]string, 0, len(metros))
+	seen := make(map[string]bool, len(metros))
+	for _, m := range metros {
+		k := strings.ToLower(domain.NormalizeMetro(m))
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// buildToolTSQuery turns free text into an OR-ed to_tsquery expression so tools matching
+// more of the words rank higher. Returns "" when the text has no word characters.
+func buildToolTSQuery(term string) string {
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " | ")
+}
@@ -3,35 +3,42 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/utils"
 )
 
 type userRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
-func NewUserRepository(db *sql.DB) repository.UserRepository {
+func NewUserRepository(db DBTX) repository.UserRepository {
 	return &userRepository{db: db}
 }
 
 func (r *userRepository) Create(ctx context.Context, u *domain.User) error {
-	query := `INSERT INTO users (email, phone_number, password_hash, name, avatar_url, created_on, updated_on) 
-	          VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	query := `INSERT INTO users (email, phone_number, password_hash, name, avatar_url, email_verified, notification_preference, created_on, updated_on) 
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
 	now := time.Now().Format("2006-01-02")
 	u.CreatedOn = now
 	u.UpdatedOn = now
-	return r.db.QueryRowContext(ctx, query, u.Email, u.PhoneNumber, u.PasswordHash, u.Name, u.AvatarURL, u.CreatedOn, u.UpdatedOn).Scan(&u.ID)
+	if u.NotificationPreference == "" {
+		u.NotificationPreference = domain.NotificationPreferenceEmail
+	}
+	return r.db.QueryRowContext(ctx, query, u.Email, u.PhoneNumber, u.PasswordHash, u.Name, u.AvatarURL, u.EmailVerified, u.NotificationPreference, u.CreatedOn, u.UpdatedOn).Scan(&u.ID)
 }
 
 func (r *userRepository) GetByID(ctx context.Context, id int32) (*domain.User, error) {
 	u := &domain.User{}
-	query := `SELECT id, email, phone_number, password_hash, name, COALESCE(avatar_url, ''), created_on, updated_on FROM users WHERE id = $1`
+	query := `SELECT id, email, phone_number, password_hash, name, COALESCE(avatar_url, ''), email_verified, notification_preference, created_on, updated_on FROM users WHERE id = $1`
 	var createdOn, updatedOn time.Time
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &createdOn, &updatedOn)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &u.EmailVerified, &u.NotificationPreference, &createdOn, &updatedOn)
 	if err != nil {
 		return nil, err
 	}
@@ -40,11 +47,40 @@ func (r *userRepository) GetByID(ctx context.Context, id int32) (*domain.User, e
 	return u, nil
 }
 
+func (r *userRepository) GetByIDs(ctx context.Context, ids []int32) ([]domain.User, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	query := `SELECT id, email, phone_number, password_hash, name, COALESCE(avatar_url, ''), email_verified, notification_preference, created_on, updated_on FROM users WHERE id = ANY($1)`
+	logger.DatabaseCall("SELECT", "users", "ids", len(ids))
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		logger.DatabaseResult("SELECT", 0, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		var u domain.User
+		var createdOn, updatedOn time.Time
+		if err := rows.Scan(&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &u.EmailVerified, &u.NotificationPreference, &createdOn, &updatedOn); err != nil {
+			return nil, err
+		}
+		u.CreatedOn = createdOn.Format("2006-01-02")
+		u.UpdatedOn = updatedOn.Format("2006-01-02")
+		users = append(users, u)
+	}
+	logger.DatabaseResult("SELECT", int64(len(users)), rows.Err())
+	return users, rows.Err()
+}
+
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	u := &domain.User{}
-	query := `SELECT id, email, phone_number, password_hash, name, COALESCE(avatar_url, ''), created_on, updated_on FROM users WHERE LOWER(email) = LOWER($1)`
+	query := `SELECT id, email, phone_number, password_hash, name, COALESCE(avatar_url, ''), email_verified, notification_preference, created_on, updated_on FROM users WHERE LOWER(email) = LOWER($1)`
 	var createdOn, updatedOn time.Time
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &createdOn, &updatedOn)
+	err := r.db.QueryRowContext(ctx, query, email).Scan(&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &u.EmailVerified, &u.NotificationPreference, &createdOn, &updatedOn)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +104,33 @@ func (r *userRepository) UpdatePassword(ctx context.Context, userID int32, passw
 	return err
 }
 
+func (r *userRepository) MarkEmailVerified(ctx context.Context, userID int32) error {
+	query := `UPDATE users SET email_verified=TRUE, updated_on=$1 WHERE id=$2`
+	now := time.Now().Format("2006-01-02")
+	_, err := r.db.ExecContext(ctx, query, now, userID)
+	return err
+}
+
+func (r *userRepository) UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error {
+	query := `UPDATE users SET notification_preference=$1, updated_on=$2 WHERE id=$3`
+	now := time.Now().Format("2006-01-02")
+	_, err := r.db.ExecContext(ctx, query, pref, now, userID)
+	return err
+}
+
+func (r *userRepository) GetTokenVersion(ctx context.Context, userID int32) (int32, error) {
+	var version int32
+	err := r.db.QueryRowContext(ctx, `SELECT token_version FROM users WHERE id = $1`, userID).Scan(&version)
+	return version, err
+}
+
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, userID int32) (int32, error) {
+	var version int32
+	query := `UPDATE users SET token_version = token_version + 1 WHERE id = $1 RETURNING token_version`
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&version)
+	return version, err
+}
+
 func (r *userRepository) AddUserToOrg(ctx context.Context, uo *domain.UserOrg) error {
 	query := `INSERT INTO users_orgs (user_id, org_id, joined_on, balance_cents, last_balance_updated_on, status, role, blocked_on, blocked_reason, renting_blocked, lending_blocked, blocked_due_to_bill_id) 
 	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
@@ -79,7 +142,7 @@ func (r *userRepository) AddUserToOrg(ctx context.Context, uo *domain.UserOrg) e
 
 func (r *userRepository) GetUserOrg(ctx context.Context, userID, orgID int32) (*domain.UserOrg, error) {
 	uo := &domain.UserOrg{}
-	query := `SELECT user_id, org_id, joined_on, balance_cents, last_balance_updated_on, status, role, blocked_on, COALESCE(blocked_reason, ''), renting_blocked, lending_blocked, blocked_due_to_bill_id FROM users_orgs WHERE user_id = $1 AND org_id = $2`
+	query := `SELECT uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id, o.currency_code FROM users_orgs uo JOIN orgs o ON o.id = uo.org_id WHERE uo.user_id = $1 AND uo.org_id = $2`
 
 	var lastBalanceUpdateOn sql.NullTime
 	var blockedDate sql.NullTime
@@ -88,7 +151,7 @@ func (r *userRepository) GetUserOrg(ctx context.Context, userID, orgID int32) (*
 	err := r.db.QueryRowContext(ctx, query, userID, orgID).Scan(
 		&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn,
 		&uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked,
-		&uo.LendingBlocked, &uo.BlockedDueToBillID,
+		&uo.LendingBlocked, &uo.BlockedDueToBillID, &uo.CurrencyCode,
 	)
 	if err != nil {
 		return nil, err
@@ -108,7 +171,7 @@ func (r *userRepository) GetUserOrg(ctx context.Context, userID, orgID int32) (*
 }
 
 func (r *userRepository) ListUserOrgs(ctx context.Context, userID int32) ([]domain.UserOrg, error) {
-	query := `SELECT user_id, org_id, joined_on, balance_cents, last_balance_updated_on, status, role, blocked_on, COALESCE(blocked_reason, ''), renting_blocked, lending_blocked, blocked_due_to_bill_id FROM users_orgs WHERE user_id = $1`
+	query := `SELECT uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id, o.currency_code FROM users_orgs uo JOIN orgs o ON o.id = uo.org_id WHERE uo.user_id = $1`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
@@ -125,7 +188,7 @@ func (r *userRepository) ListUserOrgs(ctx context.Context, userID int32) ([]doma
 		if err := rows.Scan(
 			&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn,
 			&uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked,
-			&uo.LendingBlocked, &uo.BlockedDueToBillID,
+			&uo.LendingBlocked, &uo.BlockedDueToBillID, &uo.CurrencyCode,
 		); err != nil {
 			return nil, err
 		}
@@ -146,31 +209,34 @@ func (r *userRepository) ListUserOrgs(ctx context.Context, userID int32) ([]doma
 }
 
 func (r *userRepository) UpdateUserOrg(ctx context.Context, uo *domain.UserOrg) error {
-	query := `UPDATE users_orgs SET balance_cents=$1, last_balance_updated_on=$2, status=$3, role=$4, blocked_on=$5, blocked_reason=$6, renting_blocked=$7, lending_blocked=$8, blocked_due_to_bill_id=$9 WHERE user_id=$10 AND org_id=$11`
-
-	var lastBalanceUpdateOn interface{} = nil
-	if uo.LastBalanceUpdateOn != nil {
-		lastBalanceUpdateOn = *uo.LastBalanceUpdateOn
-	}
-
-	_, err := r.db.ExecContext(ctx, query, uo.BalanceCents, lastBalanceUpdateOn, uo.Status, uo.Role, uo.BlockedOn, uo.BlockedReason, uo.RentingBlocked, uo.LendingBlocked, uo.BlockedDueToBillID, uo.UserID, uo.OrgID)
+	query := `UPDATE users_orgs SET status=$1, role=$2, blocked_on=$3, blocked_reason=$4, renting_blocked=$5, lending_blocked=$6, blocked_due_to_bill_id=$7 WHERE user_id=$8 AND org_id=$9`
+	_, err := r.db.ExecContext(ctx, query, uo.Status, uo.Role, uo.BlockedOn, uo.BlockedReason, uo.RentingBlocked, uo.LendingBlocked, uo.BlockedDueToBillID, uo.UserID, uo.OrgID)
 	return err
 }
 
+func (r *userRepository) AdjustBalance(ctx context.Context, userID, orgID, delta int32) (int32, error) {
+	var balance int32
+	query := `UPDATE users_orgs SET balance_cents = balance_cents + $1, last_balance_updated_on = CURRENT_DATE
+	          WHERE user_id = $2 AND org_id = $3 RETURNING balance_cents`
+	err := r.db.QueryRowContext(ctx, query, delta, userID, orgID).Scan(&balance)
+	return balance, err
+}
+
 func (r *userRepository) ListMembersByOrg(ctx context.Context, orgID int32) ([]domain.User, []domain.UserOrg, error) {
-	logger.EnterMethod("userRepository.ListMembersByOrg", "orgID", orgID)
+	logger.EnterMethodContext(ctx, "userRepository.ListMembersByOrg", "orgID", orgID)
 
 	query := `SELECT u.id, u.email, u.phone_number, u.password_hash, u.name, COALESCE(u.avatar_url, ''), u.created_on, u.updated_on,
-	                 uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id
+	                 uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id, o.currency_code
 	          FROM users u
 	          JOIN users_orgs uo ON u.id = uo.user_id
+	          JOIN orgs o ON o.id = uo.org_id
 	          WHERE uo.org_id = $1`
 	logger.DatabaseCall("SELECT", "users JOIN users_orgs", "orgID", orgID)
 
 	rows, err := r.db.QueryContext(ctx, query, orgID)
 	if err != nil {
 		logger.DatabaseResult("SELECT", 0, err, "orgID", orgID)
-		logger.ExitMethodWithError("userRepository.ListMembersByOrg", err, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "userRepository.ListMembersByOrg", err, "orgID", orgID)
 		return nil, nil, err
 	}
 	defer rows.Close()
@@ -188,11 +254,11 @@ func (r *userRepository) ListMembersByOrg(ctx context.Context, orgID int32) ([]d
 			&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &createdOn, &updatedOn,
 			&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn,
 			&uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked,
-			&uo.LendingBlocked, &uo.BlockedDueToBillID,
+			&uo.LendingBlocked, &uo.BlockedDueToBillID, &uo.CurrencyCode,
 		)
 		if err != nil {
 			logger.DatabaseResult("SELECT", int64(len(users)), err, "orgID", orgID)
-			logger.ExitMethodWithError("userRepository.ListMembersByOrg", err, "orgID", orgID)
+			logger.ExitMethodWithErrorContext(ctx, "userRepository.ListMembersByOrg", err, "orgID", orgID)
 			return nil, nil, err
 		}
 		u.CreatedOn = createdOn.Format("2006-01-02")
@@ -213,7 +279,7 @@ func (r *userRepository) ListMembersByOrg(ctx context.Context, orgID int32) ([]d
 	}
 
 	logger.DatabaseResult("SELECT", int64(len(users)), nil, "orgID", orgID, "membersFound", len(users))
-	logger.ExitMethod("userRepository.ListMembersByOrg", "orgID", orgID, "count", len(users))
+	logger.ExitMethodContext(ctx, "userRepository.ListMembersByOrg", "orgID", orgID, "count", len(users))
 	return users, uos, nil
 }
 
@@ -229,9 +295,10 @@ func (r *userRepository) CountMembersByOrg(ctx context.Context, orgID int32) (in
 
 func (r *userRepository) SearchMembersByOrg(ctx context.Context, orgID int32, query string) ([]domain.User, []domain.UserOrg, error) {
 	sqlQuery := `SELECT u.id, u.email, u.phone_number, u.password_hash, u.name, COALESCE(u.avatar_url, ''), u.created_on, u.updated_on,
-	                 uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id
+	                 uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id, o.currency_code
 	          FROM users u
 	          JOIN users_orgs uo ON u.id = uo.user_id
+	          JOIN orgs o ON o.id = uo.org_id
 	          WHERE uo.org_id = $1 AND (u.name ILIKE $2 OR u.email ILIKE $2)`
 	rows, err := r.db.QueryContext(ctx, sqlQuery, orgID, "%"+query+"%")
 	if err != nil {
@@ -250,7 +317,7 @@ func (r *userRepository) SearchMembersByOrg(ctx context.Context, orgID int32, qu
 
 		err := rows.Scan(
 			&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &createdOn, &updatedOn,
-			&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn, &uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked, &uo.LendingBlocked, &uo.BlockedDueToBillID,
+			&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn, &uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked, &uo.LendingBlocked, &uo.BlockedDueToBillID, &uo.CurrencyCode,
 		)
 		if err != nil {
 			return nil, nil, err
@@ -273,3 +340,95 @@ func (r *userRepository) SearchMembersByOrg(ctx context.Context, orgID int32, qu
 	}
 	return users, uos, nil
 }
+
+// ListMembersByOrgFiltered returns the members of an organization matching the filter,
+// ordered by name. A PageSize <= 0 returns every match, larger ones are capped at
+// utils.MaxPageSize; the count is always the total across all pages.
+func (r *userRepository) ListMembersByOrgFiltered(ctx context.Context, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error) {
+	logger.EnterMethodContext(ctx, "userRepository.ListMembersByOrgFiltered", "orgID", orgID, "filter", filter)
+
+	query := `SELECT u.id, u.email, u.phone_number, u.password_hash, u.name, COALESCE(u.avatar_url, ''), u.created_on, u.updated_on,
+	                 uo.user_id, uo.org_id, uo.joined_on, uo.balance_cents, uo.last_balance_updated_on, uo.status, uo.role, uo.blocked_on, COALESCE(uo.blocked_reason, ''), uo.renting_blocked, uo.lending_blocked, uo.blocked_due_to_bill_id, o.currency_code
+	          FROM users u
+	          JOIN users_orgs uo ON u.id = uo.user_id
+	          JOIN orgs o ON o.id = uo.org_id
+	          WHERE uo.org_id = $1`
+
+	args := []interface{}{orgID}
+	argIndex := 2
+
+	if filter.BlockedOnly {
+		query += " AND (uo.status = 'BLOCK' OR uo.renting_blocked OR uo.lending_blocked)"
+	}
+	if filter.NegativeBalanceOnly {
+		query += " AND uo.balance_cents < 0"
+	}
+	if filter.Role != "" {
+		query += fmt.Sprintf(" AND uo.role = $%d", argIndex)
+		args = append(args, filter.Role)
+		argIndex++
+	}
+
+	var count int32
+	countSql := "SELECT count(*) FROM (" + query + ") as sub"
+	if err := r.db.QueryRowContext(ctx, countSql, args...).Scan(&count); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "userRepository.ListMembersByOrgFiltered", err, "orgID", orgID)
+		return nil, nil, 0, err
+	}
+
+	query += " ORDER BY u.name, u.id"
+	if filter.PageSize > 0 {
+		limit, offset := utils.Paginate(filter.Page, filter.PageSize)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argIndex, argIndex+1)
+		args = append(args, limit, offset)
+	}
+	logger.DatabaseCall("SELECT", "users JOIN users_orgs", "orgID", orgID)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.DatabaseResult("SELECT", 0, err, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "userRepository.ListMembersByOrgFiltered", err, "orgID", orgID)
+		return nil, nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []domain.User{}
+	uos := []domain.UserOrg{}
+	for rows.Next() {
+		var u domain.User
+		var uo domain.UserOrg
+		var createdOn, updatedOn, joinedOn time.Time
+		var lastBalanceUpdateOn sql.NullTime
+		var blockedDate sql.NullTime
+
+		err := rows.Scan(
+			&u.ID, &u.Email, &u.PhoneNumber, &u.PasswordHash, &u.Name, &u.AvatarURL, &createdOn, &updatedOn,
+			&uo.UserID, &uo.OrgID, &joinedOn, &uo.BalanceCents, &lastBalanceUpdateOn,
+			&uo.Status, &uo.Role, &blockedDate, &uo.BlockedReason, &uo.RentingBlocked,
+			&uo.LendingBlocked, &uo.BlockedDueToBillID, &uo.CurrencyCode,
+		)
+		if err != nil {
+			logger.ExitMethodWithErrorContext(ctx, "userRepository.ListMembersByOrgFiltered", err, "orgID", orgID)
+			return nil, nil, 0, err
+		}
+		u.CreatedOn = createdOn.Format("2006-01-02")
+		u.UpdatedOn = updatedOn.Format("2006-01-02")
+		uo.JoinedOn = joinedOn.Format("2006-01-02")
+
+		if lastBalanceUpdateOn.Valid {
+			dateStr := lastBalanceUpdateOn.Time.Format("2006-01-02")
+			uo.LastBalanceUpdateOn = &dateStr
+		}
+		if blockedDate.Valid {
+			dateStr := blockedDate.Time.Format("2006-01-02")
+			uo.BlockedOn = &dateStr
+		}
+
+		users = append(users, u)
+		uos = append(uos, uo)
+	}
+
+	logger.DatabaseResult("SELECT", int64(len(users)), nil, "orgID", orgID)
+	logger.ExitMethodContext(ctx, "userRepository.ListMembersByOrgFiltered", "orgID", orgID, "count", len(users), "total", count)
+	return users, uos, count, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
@@ -10,26 +11,47 @@ import (
 type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByID(ctx context.Context, id int32) (*domain.User, error)
+	// GetByIDs returns the users with the given IDs; unknown IDs are skipped
+	GetByIDs(ctx context.Context, ids []int32) ([]domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	Update(ctx context.Context, user *domain.User) error
 	UpdatePassword(ctx context.Context, userID int32, passwordHash string) error
+	MarkEmailVerified(ctx context.Context, userID int32) error
+	UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error
+	GetTokenVersion(ctx context.Context, userID int32) (int32, error)
+	// IncrementTokenVersion bumps the user's token version and returns the new value
+	IncrementTokenVersion(ctx context.Context, userID int32) (int32, error)
 
 	// User Organizations
 	AddUserToOrg(ctx context.Context, userOrg *domain.UserOrg) error
 	GetUserOrg(ctx context.Context, userID, orgID int32) (*domain.UserOrg, error)
 	ListUserOrgs(ctx context.Context, userID int32) ([]domain.UserOrg, error)
+	// UpdateUserOrg saves the membership's status, role and blocking fields. It never writes
+	// balance_cents; balances change only through AdjustBalance or the ledger trigger.
 	UpdateUserOrg(ctx context.Context, userOrg *domain.UserOrg) error
+	// AdjustBalance atomically adds delta to the member's balance and returns the new balance.
+	// Returns sql.ErrNoRows when the user is not a member of the org.
+	AdjustBalance(ctx context.Context, userID, orgID, delta int32) (int32, error)
 	ListMembersByOrg(ctx context.Context, orgID int32) ([]domain.User, []domain.UserOrg, error)
 	CountMembersByOrg(ctx context.Context, orgID int32) (int32, error)
 	SearchMembersByOrg(ctx context.Context, orgID int32, query string) ([]domain.User, []domain.UserOrg, error)
+	ListMembersByOrgFiltered(ctx context.Context, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error)
 }
 
 type OrganizationRepository interface {
 	Create(ctx context.Context, org *domain.Organization) error
 	GetByID(ctx context.Context, id int32) (*domain.Organization, error)
+	// GetByIDs returns the orgs with the given ids, ordered by id; unknown ids are skipped
+	GetByIDs(ctx context.Context, ids []int32) ([]domain.Organization, error)
 	List(ctx context.Context) ([]domain.Organization, error)
 	Search(ctx context.Context, name, metro string) ([]domain.Organization, error)
 	Update(ctx context.Context, org *domain.Organization) error
+	// SetSettlementThreshold sets the org's own threshold; nil clears it so the configured default applies
+	SetSettlementThreshold(ctx context.Context, orgID int32, thresholdCents *int32) error
+	// SetAdjacentMetros replaces the metros members of the org may opt in to when searching tools
+	SetAdjacentMetros(ctx context.Context, orgID int32, metros []string) error
+	// ListMetros returns the distinct metros of orgs and live tools, sorted
+	ListMetros(ctx context.Context) ([]string, error)
 }
 
 type ToolRepository interface {
@@ -37,20 +59,31 @@ type ToolRepository interface {
 	GetByID(ctx context.Context, id int32) (*domain.Tool, error)
 	Update(ctx context.Context, tool *domain.Tool) error
 	Delete(ctx context.Context, id int32) error
-	ListByOrg(ctx context.Context, orgID int32, page, pageSize int32) ([]domain.Tool, int32, error)
+	// ListByOrg lists tools in the org's metro, and in its adjacent metros when includeAdjacent is set
+	ListByOrg(ctx context.Context, orgID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error)
 	ListByOwner(ctx context.Context, ownerID int32, page, pageSize int32) ([]domain.Tool, int32, error)
-	Search(ctx context.Context, userID int32, metro, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error)
+	// CountAvailableByMetro counts the tools in metro that Search would list: not deleted and not UNAVAILABLE
+	CountAvailableByMetro(ctx context.Context, metro string) (int32, error)
+	// Search matches tools whose metro is any of metros, compared case-insensitively
+	Search(ctx context.Context, userID int32, metros []string, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error)
 
 	// Image management (unified pending + confirmed)
 	CreateImage(ctx context.Context, image *domain.ToolImage) error
 	GetImageByID(ctx context.Context, imageID int32) (*domain.ToolImage, error)
 	GetImages(ctx context.Context, toolID int32) ([]domain.ToolImage, error)
 	GetPendingImagesByUser(ctx context.Context, userID int32) ([]domain.ToolImage, error)
+	// UpdateImage unsets any other primary of the tool when a confirmed image is saved as primary
 	UpdateImage(ctx context.Context, image *domain.ToolImage) error
 	ConfirmImage(ctx context.Context, imageID int32, toolID int32) error
+	// DeleteImage soft deletes the image and, if it was primary, promotes the next by display_order
 	DeleteImage(ctx context.Context, imageID int32) error
+	// SetPrimaryImage makes imageID the tool's only primary image
 	SetPrimaryImage(ctx context.Context, toolID int32, imageID int32) error
+	// ReorderImages sets each image's display_order to its index in imageIDs
+	ReorderImages(ctx context.Context, toolID int32, imageIDs []int32) error
 	DeleteExpiredPendingImages(ctx context.Context) error
+	// ListCategories returns the curated tool categories in display order
+	ListCategories(ctx context.Context) ([]string, error)
 }
 
 type RentalRepository interface {
@@ -59,24 +92,73 @@ type RentalRepository interface {
 	Update(ctx context.Context, rental *domain.Rental) error
 	ListByRenter(ctx context.Context, renterID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
 	ListByOwner(ctx context.Context, ownerID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
-	ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
+	// ListByTool lists the tool's rentals; orgID 0 covers all orgs. Non-empty fromDate/toDate
+	// ('YYYY-MM-DD', inclusive) keep only rentals whose [start_date, end_date] overlaps the window.
+	ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error)
+	ListBusyRanges(ctx context.Context, toolIDs []int32, fromDate, toDate string) ([]domain.ToolBusyRange, error)
+	// CountActivity counts the user's in-progress rentals and lendings and the rentals waiting on
+	// them, across all orgs, in one query.
+	CountActivity(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error)
+	// ListActionable lists the user's rentals in orgID that wait on them, as owner or renter
+	// (see domain.OwnerActionRentalStatuses and domain.RenterActionRentalStatuses)
+	ListActionable(ctx context.Context, userID, orgID int32) ([]domain.Rental, error)
+}
+
+type RentalEventRepository interface {
+	Create(ctx context.Context, event *domain.RentalEvent) error
+	// ListByRental returns the rental's events, oldest first
+	ListByRental(ctx context.Context, rentalID int32) ([]domain.RentalEvent, error)
 }
 
 type LedgerRepository interface {
 	CreateTransaction(ctx context.Context, tx *domain.LedgerTransaction) error
 	GetBalance(ctx context.Context, userID, orgID int32) (int32, error)
+	// GetRentalHold returns the amount still reserved for the rental: its HOLD entries less its HOLD_RELEASE entries.
+	GetRentalHold(ctx context.Context, rentalID int32) (int32, error)
 	ListTransactions(ctx context.Context, userID, orgID int32, page, pageSize int32) ([]domain.LedgerTransaction, int32, error)
 	GetSummary(ctx context.Context, userID, orgID int32) (*domain.LedgerSummary, error)
+	// GetBalanceHistory returns snapshots ordered by date; from/to are inclusive 'YYYY-MM-DD' bounds, empty for unbounded.
+	GetBalanceHistory(ctx context.Context, userID, orgID int32, from, to string) ([]domain.BalanceSnapshot, error)
+	// StreamTransactions calls fn for each transaction charged within the inclusive 'YYYY-MM-DD' bounds
+	// (empty for unbounded), oldest first, without buffering the result set. An error from fn stops the scan.
+	StreamTransactions(ctx context.Context, userID, orgID int32, from, to string, fn func(domain.LedgerTransaction) error) error
 }
 
 type NotificationRepository interface {
 	Create(ctx context.Context, note *domain.Notification) error
-	List(ctx context.Context, userID int32, limit, offset int32) ([]domain.Notification, int32, error)
+	// List filters on the "type" or "topic" attribute when notificationType is non-empty.
+	List(ctx context.Context, userID int32, notificationType string, limit, offset int32) ([]domain.Notification, int32, error)
+	// CountUnread counts notifications without read_at; orgID 0 covers all orgs.
+	CountUnread(ctx context.Context, userID, orgID int32) (int32, error)
 	MarkAsRead(ctx context.Context, id int64, userID int32) error
+	// MarkAllRead stamps read_at on every unread notification of the user; orgID 0 covers all orgs.
+	MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error)
 	MarkDelivered(ctx context.Context, id int64, userID int32, t time.Time) error
 	MarkClicked(ctx context.Context, id int64, userID int32, t time.Time) error
 }
 
+// NotificationDeadLetterRepository keeps notifications whose insert failed until they are retried
+type NotificationDeadLetterRepository interface {
+	// Create records a failed notification with one attempt, due for retry at NextAttemptAt
+	Create(ctx context.Context, entry *domain.NotificationDeadLetter) error
+	// ClaimRetryable claims up to limit entries that have attempts left and are either FAILED and due,
+	// or stuck in RETRYING for longer than staleAfter.
+	ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.NotificationDeadLetter, error)
+	MarkDelivered(ctx context.Context, id, notificationID int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+}
+
+type EmailOutboxRepository interface {
+	Create(ctx context.Context, entry *domain.EmailOutboxEntry) error
+	// Claim moves a PENDING entry to SENDING and counts the attempt; false when another worker took it.
+	Claim(ctx context.Context, id int64) (bool, error)
+	// ClaimRetryable claims up to limit entries that have attempts left and are either FAILED and due,
+	// or stuck in PENDING/SENDING for longer than staleAfter.
+	ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.EmailOutboxEntry, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+}
+
 type FcmTokenRepository interface {
 	Upsert(ctx context.Context, token *domain.FcmToken) error
 	GetActiveByUserID(ctx context.Context, userID int32) ([]domain.FcmToken, error)
@@ -94,6 +176,10 @@ type InvitationRepository interface {
 	GetByJoinRequestID(ctx context.Context, joinRequestID int32) (*domain.Invitation, error)
 	Update(ctx context.Context, invite *domain.Invitation) error
 	ExpireInvitation(ctx context.Context, id int32, expiresOn string) error
+	// Revoke marks an unused invitation as revoked; sql.ErrNoRows if it is used or already revoked
+	Revoke(ctx context.Context, id int32) error
+	// ListOutstandingEmails returns which of emails, lowercased, already have a usable invitation to orgID
+	ListOutstandingEmails(ctx context.Context, orgID int32, emails []string) ([]string, error)
 }
 
 type JoinRequestRepository interface {
@@ -114,21 +200,80 @@ type PendingCredentialsRepository interface {
 	StampUsedAt(ctx context.Context, userID int32) error
 }
 
+type RevokedTokenRepository interface {
+	// Revoke records the token's jti as revoked; revoking twice is a no-op.
+	Revoke(ctx context.Context, jti string, userID int32, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type JobRunRepository interface {
+	// Start records a RUNNING row for jobName and returns it
+	Start(ctx context.Context, jobName string) (*domain.JobRun, error)
+	// Finish sets the final status, error (empty for none) and finished_at of a run
+	Finish(ctx context.Context, id int64, status domain.JobRunStatus, errMsg string) error
+	// List returns runs newest first
+	List(ctx context.Context, filter domain.JobRunFilter) ([]domain.JobRun, error)
+}
+
+type AdminAuditRepository interface {
+	Create(ctx context.Context, entry *domain.AdminAuditEntry) error
+	// List returns an org's audit entries newest first, with the total across all pages.
+	// A filter.PageSize <= 0 returns every matching entry.
+	List(ctx context.Context, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error)
+}
+
+// ErrBillVersionConflict is returned by BillRepository.Update when the bill was modified
+// after it was read
+var ErrBillVersionConflict = errors.New("bill was modified by another request")
+
 type BillRepository interface {
 	Create(ctx context.Context, bill *domain.Bill) error
 	GetByID(ctx context.Context, id int32) (*domain.Bill, error)
+	// Update writes the bill only if bill.Version matches the stored version, then increments it
 	Update(ctx context.Context, bill *domain.Bill) error
 	
 	// Query bills by user involvement
 	ListByDebtor(ctx context.Context, debtorID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error)
 	ListByCreditor(ctx context.Context, creditorID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error)
-	ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error)
+	ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus, page, pageSize int32) ([]domain.Bill, int32, error)
 	
 	// Query for disputed bills
 	ListDisputedByOrg(ctx context.Context, orgID int32, excludeUserID *int32) ([]domain.Bill, error)
 	ListResolvedDisputesByOrg(ctx context.Context, orgID int32) ([]domain.Bill, error)
+	GetDisputeStatistics(ctx context.Context, orgID int32, fromMonth, toMonth string) (*domain.DisputeStatistics, error)
+	// GetBalanceSheet totals the org's member balances and its bills by status
+	GetBalanceSheet(ctx context.Context, orgID int32) (*domain.OrgBalanceSheet, error)
 	
 	// Bill actions
 	CreateAction(ctx context.Context, action *domain.BillAction) error
-	ListActionsByBill(ctx context.Context, billID int32) ([]domain.BillAction, error)
+	// ListActionsByBill returns one page of the bill's actions matching filter, oldest first,
+	// and the number of matching actions across all pages
+	ListActionsByBill(ctx context.Context, billID int32, filter domain.BillActionFilter) ([]domain.BillAction, int32, error)
+
+	// CreateSettlementBills runs bill splitting for one org and month: it plans transfers over
+	// the active members' balances and inserts them as PENDING bills. The run is recorded in
+	// settlement_runs in the same transaction, so a second run for the org and month returns
+	// ErrSettlementAlreadyRun unless force is set. Only newly inserted bills are returned.
+	CreateSettlementBills(ctx context.Context, orgID int32, settlementMonth string, thresholdCents int32, force bool) ([]domain.Bill, error)
+}
+
+// ErrSettlementAlreadyRun is returned by BillRepository.CreateSettlementBills when bills were
+// already generated for the org and month and force is not set
+var ErrSettlementAlreadyRun = errors.New("bill splitting already ran for this settlement month")
+
+// TxRepositories are repositories bound to one database transaction by Transactor.WithTx.
+// Everything written through them commits or rolls back together.
+type TxRepositories struct {
+	Users        UserRepository
+	Tools        ToolRepository
+	Rentals      RentalRepository
+	RentalEvents RentalEventRepository
+	Ledger       LedgerRepository
+	Bills        BillRepository
+}
+
+// Transactor runs multi-step writes atomically. fn's repositories share a transaction that
+// commits when fn returns nil and rolls back when it returns an error.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(tx TxRepositories) error) error
 }
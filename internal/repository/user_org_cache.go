@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"ubertool-backend-trusted/internal/domain"
+)
+
+type userOrgCacheKey struct{}
+
+type userOrgKey struct {
+	userID, orgID int32
+}
+
+// userOrgCache memoizes memberships for the lifetime of one request
+type userOrgCache struct {
+	mu      sync.Mutex
+	entries map[userOrgKey]domain.UserOrg
+}
+
+// WithUserOrgCache returns a context in which GetUserOrg reads through a cached user
+// repository are memoized until the context ends. The cache is per request rather than
+// process-wide, so a block saved by one replica is seen by the next request on every other.
+func WithUserOrgCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, userOrgCacheKey{}, &userOrgCache{entries: make(map[userOrgKey]domain.UserOrg)})
+}
+
+// InvalidateUserOrgCache drops every membership memoized in ctx. Writes that bypass the
+// cached repositories, such as a transaction (committed or not), must call it.
+func InvalidateUserOrgCache(ctx context.Context) {
+	if c := userOrgCacheFrom(ctx); c != nil {
+		c.mu.Lock()
+		c.entries = make(map[userOrgKey]domain.UserOrg)
+		c.mu.Unlock()
+	}
+}
+
+func userOrgCacheFrom(ctx context.Context) *userOrgCache {
+	c, _ := ctx.Value(userOrgCacheKey{}).(*userOrgCache)
+	return c
+}
+
+func (c *userOrgCache) get(userID, orgID int32) (domain.UserOrg, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	userOrg, ok := c.entries[userOrgKey{userID, orgID}]
+	return userOrg, ok
+}
+
+func (c *userOrgCache) put(userOrg domain.UserOrg) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[userOrgKey{userOrg.UserID, userOrg.OrgID}] = userOrg
+}
+
+func forgetUserOrg(ctx context.Context, userID, orgID int32) {
+	if c := userOrgCacheFrom(ctx); c != nil {
+		c.mu.Lock()
+		delete(c.entries, userOrgKey{userID, orgID})
+		c.mu.Unlock()
+	}
+}
+
+type cachedUserRepository struct {
+	UserRepository
+}
+
+// NewCachedUserRepository memoizes GetUserOrg in contexts set up by WithUserOrgCache and
+// forgets a membership whenever it is written through the repository. Without the cache
+// in the context every call goes straight to inner.
+func NewCachedUserRepository(inner UserRepository) UserRepository {
+	return &cachedUserRepository{UserRepository: inner}
+}
+
+// GetUserOrg returns a copy of the memoized membership, so callers may modify it freely
+func (r *cachedUserRepository) GetUserOrg(ctx context.Context, userID, orgID int32) (*domain.UserOrg, error) {
+	cache := userOrgCacheFrom(ctx)
+	if cache == nil {
+		return r.UserRepository.GetUserOrg(ctx, userID, orgID)
+	}
+	if userOrg, ok := cache.get(userID, orgID); ok {
+		return &userOrg, nil
+	}
+	userOrg, err := r.UserRepository.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(*userOrg)
+	return userOrg, nil
+}
+
+func (r *cachedUserRepository) AddUserToOrg(ctx context.Context, userOrg *domain.UserOrg) error {
+	defer forgetUserOrg(ctx, userOrg.UserID, userOrg.OrgID)
+	return r.UserRepository.AddUserToOrg(ctx, userOrg)
+}
+
+func (r *cachedUserRepository) UpdateUserOrg(ctx context.Context, userOrg *domain.UserOrg) error {
+	defer forgetUserOrg(ctx, userOrg.UserID, userOrg.OrgID)
+	return r.UserRepository.UpdateUserOrg(ctx, userOrg)
+}
+
+func (r *cachedUserRepository) AdjustBalance(ctx context.Context, userID, orgID, delta int32) (int32, error) {
+	defer forgetUserOrg(ctx, userID, orgID)
+	return r.UserRepository.AdjustBalance(ctx, userID, orgID, delta)
+}
+
+type userOrgInvalidatingLedgerRepository struct {
+	LedgerRepository
+}
+
+// NewUserOrgInvalidatingLedgerRepository forgets the member's memoized membership after each
+// ledger entry, since the users_orgs trigger moves their balance
+func NewUserOrgInvalidatingLedgerRepository(inner LedgerRepository) LedgerRepository {
+	return &userOrgInvalidatingLedgerRepository{LedgerRepository: inner}
+}
+
+func (r *userOrgInvalidatingLedgerRepository) CreateTransaction(ctx context.Context, tx *domain.LedgerTransaction) error {
+	defer forgetUserOrg(ctx, tx.UserID, tx.OrgID)
+	return r.LedgerRepository.CreateTransaction(ctx, tx)
+}
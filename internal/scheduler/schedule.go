@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// specParser accepts six-field expressions with leading seconds, plus descriptors like @hourly
+var specParser = cron.NewParser(
+	cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ParseSchedule parses a cron expression with seconds precision. robfig/cron has no "L"
+// (last day of month), so an "L" day-of-month is expanded to 28-31 and filtered to the
+// month's final day.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	fields := strings.Fields(spec)
+	lastDay := len(fields) == 6 && fields[3] == "L"
+	if lastDay {
+		fields[3] = "28-31"
+	}
+
+	schedule, err := specParser.Parse(strings.Join(fields, " "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+	}
+	if lastDay {
+		return lastDayOfMonthSchedule{schedule}, nil
+	}
+	return schedule, nil
+}
+
+// lastDayOfMonthSchedule skips activations that are not on the last day of their month
+type lastDayOfMonthSchedule struct {
+	cron.Schedule
+}
+
+func (s lastDayOfMonthSchedule) Next(t time.Time) time.Time {
+	for {
+		t = s.Schedule.Next(t)
+		if t.IsZero() || t.AddDate(0, 0, 1).Day() == 1 {
+			return t
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -14,11 +15,17 @@ type Scheduler struct {
 	jobs *jobs.JobRunner
 }
 
-// NewScheduler creates a new scheduler with the provided job runner
-func NewScheduler(jobRunner *jobs.JobRunner) *Scheduler {
-	// Create cron with UTC timezone and seconds precision
+// NewScheduler creates a new scheduler with the provided job runner. It fails if the configured
+// timezone or any cron expression is invalid, so a bad deployment never starts half-scheduled.
+func NewScheduler(jobRunner *jobs.JobRunner) (*Scheduler, error) {
+	loc, err := time.LoadLocation(jobRunner.Config().Scheduler.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler timezone: %w", err)
+	}
+
+	// Create cron in the configured timezone with seconds precision
 	c := cron.New(
-		cron.WithLocation(time.UTC),
+		cron.WithLocation(loc),
 		cron.WithSeconds(),
 	)
 
@@ -27,65 +34,56 @@ func NewScheduler(jobRunner *jobs.JobRunner) *Scheduler {
 		jobs: jobRunner,
 	}
 
-	s.registerJobs()
-	return s
+	if err := s.registerJobs(); err != nil {
+		return nil, err
+	}
+	return s, nil
 }
 
 // registerJobs registers all scheduled jobs with the cron scheduler
-func (s *Scheduler) registerJobs() {
+func (s *Scheduler) registerJobs() error {
 	cfg := s.jobs.Config().Scheduler
 
-	// Nightly jobs
-	// Mark overdue rentals
-	_, err := s.cron.AddFunc(cfg.MarkOverdueRentals, s.jobs.MarkOverdueRentals)
-	if err != nil {
-		logger.Error("Failed to register MarkOverdueRentals job", "error", err)
-	}
-
-	// Send overdue reminders
-	_, err = s.cron.AddFunc(cfg.SendOverdueReminders, s.jobs.SendOverdueReminders)
-	if err != nil {
-		logger.Error("Failed to register SendOverdueReminders job", "error", err)
-	}
-
-	// Send bill reminders
-	_, err = s.cron.AddFunc(cfg.SendBillReminders, s.jobs.SendBillReminders)
-	if err != nil {
-		logger.Error("Failed to register SendBillReminders job", "error", err)
-	}
-
-	// Check overdue bills daily
-	_, err = s.cron.AddFunc(cfg.CheckOverdueBills, s.jobs.CheckOverdueBills)
-	if err != nil {
-		logger.Error("Failed to register CheckOverdueBills job", "error", err)
-	}
-
-	// Monthly jobs
-	// Resolve disputed bills
-	_, err = s.cron.AddFunc(cfg.ResolveDisputedBills, s.jobs.ResolveDisputedBills)
-	if err != nil {
-		logger.Error("Failed to register ResolveDisputedBills job", "error", err)
-	}
-
-	// Take balance snapshots
-	_, err = s.cron.AddFunc(cfg.TakeBalanceSnapshots, s.jobs.TakeBalanceSnapshots)
-	if err != nil {
-		logger.Error("Failed to register TakeBalanceSnapshots job", "error", err)
-	}
-
-	// Perform bill splitting
-	_, err = s.cron.AddFunc(cfg.PerformBillSplitting, s.jobs.PerformBillSplitting)
-	if err != nil {
-		logger.Error("Failed to register PerformBillSplitting job", "error", err)
+	entries := []struct {
+		name string
+		spec string
+		run  func()
+	}{
+		// Nightly jobs
+		{"MarkOverdueRentals", cfg.MarkOverdueRentals, s.jobs.MarkOverdueRentals},
+		{"SendOverdueReminders", cfg.SendOverdueReminders, s.jobs.SendOverdueReminders},
+		{"SendBillReminders", cfg.SendBillReminders, s.jobs.SendBillReminders},
+		{"CheckOverdueBills", cfg.CheckOverdueBills, s.jobs.CheckOverdueBills},
+		// Warn members who owe more than the low balance threshold
+		{"SendLowBalanceAlerts", cfg.SendLowBalanceAlerts, s.jobs.SendLowBalanceAlerts},
+		// Reconcile stored balances against the ledger
+		{"ReconcileBalances", cfg.ReconcileBalances, s.jobs.ReconcileBalances},
+		// Activate scheduled rentals on their start date for orgs that opted in
+		{"AutoActivateScheduledRentals", cfg.AutoActivateRentals, s.jobs.AutoActivateScheduledRentals},
+		// Expire rental requests the owner never answered
+		{"ExpireStalePendingRentals", cfg.ExpireStalePendingRentals, s.jobs.ExpireStalePendingRentals},
+		// Retry outbox emails that failed or were never delivered
+		{"RetryFailedEmails", cfg.RetryFailedEmails, s.jobs.RetryFailedEmails},
+		// Re-create notifications whose insert failed
+		{"RetryFailedNotifications", cfg.RetryFailedNotifications, s.jobs.RetryFailedNotifications},
+
+		// Monthly jobs
+		{"ResolveDisputedBills", cfg.ResolveDisputedBills, s.jobs.ResolveDisputedBills},
+		{"TakeBalanceSnapshots", cfg.TakeBalanceSnapshots, s.jobs.TakeBalanceSnapshots},
+		{"PerformBillSplitting", cfg.PerformBillSplitting, s.jobs.PerformBillSplitting},
+		{"SendBillSplittingNotices", cfg.SendBillNotices, s.jobs.SendBillSplittingNotices},
 	}
 
-	// Send bill splitting notices
-	_, err = s.cron.AddFunc(cfg.SendBillNotices, s.jobs.SendBillSplittingNotices)
-	if err != nil {
-		logger.Error("Failed to register SendBillSplittingNotices job", "error", err)
+	for _, e := range entries {
+		schedule, err := ParseSchedule(e.spec)
+		if err != nil {
+			return fmt.Errorf("failed to register %s job: %w", e.name, err)
+		}
+		s.cron.Schedule(schedule, cron.FuncJob(e.run))
 	}
 
-	logger.Info("All cron jobs registered successfully")
+	logger.Info("All cron jobs registered successfully", "timezone", s.cron.Location().String())
+	return nil
 }
 
 // Start begins the cron scheduler
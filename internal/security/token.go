@@ -1,6 +1,9 @@
 package security
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"strconv"
 	"time"
@@ -17,11 +20,24 @@ var (
 type TokenType string
 
 const (
-	TokenTypeAccess     TokenType = "access"
-	TokenTypeRefresh    TokenType = "refresh"
-	TokenType2FAPending TokenType = "2fa_pending"
+	TokenTypeAccess      TokenType = "access"
+	TokenTypeRefresh     TokenType = "refresh"
+	TokenType2FAPending  TokenType = "2fa_pending"
+	TokenTypeEmailVerify TokenType = "email_verify"
 )
 
+// EmailVerificationTokenTTL is how long an emailed verification link stays valid
+const EmailVerificationTokenTTL = 48 * time.Hour
+
+// DefaultOrgClaimsTTL bounds how long the membership snapshot in an access token is trusted
+const DefaultOrgClaimsTTL = 5 * time.Minute
+
+// OrgMembership is one active org membership as recorded in an access token
+type OrgMembership struct {
+	OrgID int32  `json:"id"`
+	Role  string `json:"role"`
+}
+
 // UserClaims defines the standard claims for our application
 type UserClaims struct {
 	UserID    int32     `json:"user_id"` // Standard field for our application
@@ -32,53 +48,159 @@ type UserClaims struct {
 	Permissions []string `json:"permissions,omitempty"`
 	AuthMethod string    `json:"2fa_method,omitempty"`
 	TempPwd   bool      `json:"temp_pwd,omitempty"` // True when login used a temporary password
+	TokenVersion int32  `json:"tv,omitempty"`       // users.token_version when a refresh token was issued
+	// Orgs snapshots the user's active memberships when an access token is issued. It goes
+	// stale when a member is removed or changes role, so it is only trusted until OrgsExpiresAt.
+	Orgs          []OrgMembership  `json:"orgs,omitempty"`
+	OrgsExpiresAt *jwt.NumericDate `json:"orgs_exp,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsMemberOf reports whether the token's membership snapshot lists orgID. False means the
+// token can't vouch for the membership, not that the user is no member: the snapshot may be
+// missing (older tokens), past its TTL, or older than the membership.
+func (c *UserClaims) IsMemberOf(orgID int32) bool {
+	_, ok := c.RoleIn(orgID)
+	return ok
+}
+
+// RoleIn returns the user's role in orgID from the membership snapshot, with the same
+// caveats as IsMemberOf when ok is false
+func (c *UserClaims) RoleIn(orgID int32) (string, bool) {
+	if c.OrgsExpiresAt == nil || !time.Now().Before(c.OrgsExpiresAt.Time) {
+		return "", false
+	}
+	for _, m := range c.Orgs {
+		if m.OrgID == orgID {
+			return m.Role, true
+		}
+	}
+	return "", false
+}
+
+type claimsContextKey struct{}
+
+// ContextWithClaims returns a copy of ctx carrying the verified claims of the request's token
+func ContextWithClaims(ctx context.Context, claims *UserClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims stored by ContextWithClaims, if any
+func ClaimsFromContext(ctx context.Context) (*UserClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*UserClaims)
+	return claims, ok && claims != nil
+}
+
 type TokenManager interface {
-	GenerateAccessToken(userID int32, email string, roles []string) (string, error)
-	GenerateRefreshToken(userID int32, email string) (string, error)
+	// GenerateAccessToken embeds orgs as the membership snapshot, trusted for the org claims TTL
+	GenerateAccessToken(userID int32, email string, roles []string, orgs []OrgMembership) (string, error)
+	// GenerateRefreshToken embeds tokenVersion so bumping users.token_version revokes the token
+	GenerateRefreshToken(userID int32, email string, tokenVersion int32) (string, error)
 	Generate2FAToken(userID int32, method string, tempPwd bool) (string, error)
+	GenerateEmailVerificationToken(userID int32, email string) (string, error)
 	ValidateToken(tokenString string) (*UserClaims, error)
 }
 
+// Default token lifetimes, used when TokenConfig leaves a TTL unset
+const (
+	DefaultAccessTokenTTL  = 1 * time.Hour
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+	Default2FATokenTTL     = 10 * time.Minute
+)
+
+// TokenConfig holds the signing keys and lifetimes of issued tokens. Refresh tokens are signed
+// with RefreshSecret and every other type with AccessSecret, so a leaked access key cannot mint
+// refresh tokens. An empty RefreshSecret reuses AccessSecret; zero TTLs use the defaults.
+type TokenConfig struct {
+	AccessSecret  string
+	RefreshSecret string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+	TwoFATTL      time.Duration
+	OrgClaimsTTL  time.Duration
+}
+
 type tokenManager struct {
-	secret []byte
+	accessSecret  []byte
+	refreshSecret []byte
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+	twoFATTL      time.Duration
+	orgClaimsTTL  time.Duration
 }
 
+// NewTokenManager signs every token type with secret and uses the default lifetimes
 func NewTokenManager(secret string) TokenManager {
-	return &tokenManager{
-		secret: []byte(secret),
+	return NewTokenManagerWithConfig(TokenConfig{AccessSecret: secret})
+}
+
+func NewTokenManagerWithConfig(cfg TokenConfig) TokenManager {
+	m := &tokenManager{
+		accessSecret:  []byte(cfg.AccessSecret),
+		refreshSecret: []byte(cfg.RefreshSecret),
+		accessTTL:     cfg.AccessTTL,
+		refreshTTL:    cfg.RefreshTTL,
+		twoFATTL:      cfg.TwoFATTL,
+		orgClaimsTTL:  cfg.OrgClaimsTTL,
+	}
+	if cfg.RefreshSecret == "" {
+		m.refreshSecret = m.accessSecret
+	}
+	if m.accessTTL <= 0 {
+		m.accessTTL = DefaultAccessTokenTTL
 	}
+	if m.refreshTTL <= 0 {
+		m.refreshTTL = DefaultRefreshTokenTTL
+	}
+	if m.twoFATTL <= 0 {
+		m.twoFATTL = Default2FATokenTTL
+	}
+	if m.orgClaimsTTL <= 0 {
+		m.orgClaimsTTL = DefaultOrgClaimsTTL
+	}
+	return m
 }
 
-func (m *tokenManager) GenerateAccessToken(userID int32, email string, roles []string) (string, error) {
+// keyFor returns the signing key of a token type
+func (m *tokenManager) keyFor(t TokenType) []byte {
+	if t == TokenTypeRefresh {
+		return m.refreshSecret
+	}
+	return m.accessSecret
+}
+
+func (m *tokenManager) GenerateAccessToken(userID int32, email string, roles []string, orgs []OrgMembership) (string, error) {
 	claims := UserClaims{
 		UserID:  userID,
 		Email:   email,
 		Type:    TokenTypeAccess,
 		Roles:   roles,
+		Orgs:    orgs,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.Itoa(int(userID)),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(1 * time.Hour)), // 1 hour
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.accessTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "auth-service",
 			Audience:  jwt.ClaimStrings{"api-access"},
 			ID:        generateJTI(),
 		},
 	}
+	if len(orgs) > 0 {
+		claims.OrgsExpiresAt = jwt.NewNumericDate(time.Now().Add(m.orgClaimsTTL))
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	return token.SignedString(m.accessSecret)
 }
 
-func (m *tokenManager) GenerateRefreshToken(userID int32, email string) (string, error) {
+func (m *tokenManager) GenerateRefreshToken(userID int32, email string, tokenVersion int32) (string, error) {
 	claims := UserClaims{
-		UserID: userID,
-		Email:  email,
-		Type:   TokenTypeRefresh,
+		UserID:       userID,
+		Email:        email,
+		Type:         TokenTypeRefresh,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.Itoa(int(userID)),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * 7 * time.Hour)), // 7 days
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.refreshTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "auth-service",
 			Audience:  jwt.ClaimStrings{"token-refresh"},
@@ -86,7 +208,7 @@ func (m *tokenManager) GenerateRefreshToken(userID int32, email string) (string,
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	return token.SignedString(m.refreshSecret)
 }
 
 func (m *tokenManager) Generate2FAToken(userID int32, method string, tempPwd bool) (string, error) {
@@ -97,7 +219,7 @@ func (m *tokenManager) Generate2FAToken(userID int32, method string, tempPwd boo
 		TempPwd:    tempPwd,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   strconv.Itoa(int(userID)),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(10 * time.Minute)), // 10 minutes
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.twoFATTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "auth-service",
 			Audience:  jwt.ClaimStrings{"2fa-verification"},
@@ -105,7 +227,25 @@ func (m *tokenManager) Generate2FAToken(userID int32, method string, tempPwd boo
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.secret)
+	return token.SignedString(m.accessSecret)
+}
+
+func (m *tokenManager) GenerateEmailVerificationToken(userID int32, email string) (string, error) {
+	claims := UserClaims{
+		UserID: userID,
+		Email:  email,
+		Type:   TokenTypeEmailVerify,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(int(userID)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(EmailVerificationTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "auth-service",
+			Audience:  jwt.ClaimStrings{"email-verification"},
+			ID:        generateJTI(),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(m.accessSecret)
 }
 
 func (m *tokenManager) ValidateToken(tokenString string) (*UserClaims, error) {
@@ -113,7 +253,13 @@ func (m *tokenManager) ValidateToken(tokenString string) (*UserClaims, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, ErrInvalidToken
 		}
-		return m.secret, nil
+		// Claims are decoded before the key is chosen; a token relabelled to another type
+		// fails verification whenever the two keys differ
+		claims, ok := token.Claims.(*UserClaims)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return m.keyFor(claims.Type), nil
 	})
 
 	if err != nil {
@@ -135,7 +281,11 @@ func (m *tokenManager) ValidateToken(tokenString string) (*UserClaims, error) {
 	return nil, ErrInvalidToken
 }
 
-// Simple unique ID generator
+// generateJTI returns a random token ID; refresh tokens are revoked by jti, so it must be unique
 func generateJTI() string {
-	return strconv.FormatInt(time.Now().UnixNano(), 16)
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
 }
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// DefaultShutdownTimeout is used when Shutdown is given a non-positive timeout
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Drainer is a background component that finishes in-flight work before the process exits
+type Drainer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// NamedDrainer labels a Drainer for the shutdown log
+type NamedDrainer struct {
+	Name    string
+	Drainer Drainer
+}
+
+// Components are the parts of the server stopped by Shutdown. Nil fields are skipped.
+type Components struct {
+	GRPC     *grpc.Server
+	Health   *health.Server
+	HTTP     *http.Server
+	Drainers []NamedDrainer // drained in order after the servers stop
+	DB       io.Closer
+}
+
+// Shutdown stops the server in dependency order, sharing one deadline across all steps:
+// health checks report NOT_SERVING, the HTTP server stops, gRPC drains in-flight RPCs
+// (and is stopped forcibly at the deadline), background workers drain, and the database
+// is closed last. Every step runs even if an earlier one fails; the errors are joined.
+func Shutdown(c Components, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+
+	if c.Health != nil {
+		c.Health.Shutdown()
+		logger.Info("Health service set to NOT_SERVING")
+	}
+
+	if c.HTTP != nil {
+		if err := c.HTTP.Shutdown(ctx); err != nil {
+			logger.Warn("HTTP server shutdown timed out", "error", err)
+			errs = append(errs, fmt.Errorf("http: %w", err))
+		} else {
+			logger.Info("HTTP server stopped")
+		}
+	}
+
+	if c.GRPC != nil {
+		if err := stopGRPC(ctx, c.GRPC); err != nil {
+			logger.Warn("gRPC drain timed out; remaining RPCs were cancelled", "error", err)
+			errs = append(errs, fmt.Errorf("grpc: %w", err))
+		} else {
+			logger.Info("gRPC server stopped")
+		}
+	}
+
+	for _, d := range c.Drainers {
+		if err := d.Drainer.Shutdown(ctx); err != nil {
+			logger.Warn("Drain timed out", "component", d.Name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", d.Name, err))
+		} else {
+			logger.Info("Drained", "component", d.Name)
+		}
+	}
+
+	if c.DB != nil {
+		if err := c.DB.Close(); err != nil {
+			logger.Error("Failed to close database", "error", err)
+			errs = append(errs, fmt.Errorf("database: %w", err))
+		} else {
+			logger.Info("Database connection closed")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopGRPC waits for in-flight RPCs to finish, falling back to Stop when ctx expires
+func stopGRPC(ctx context.Context, s *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
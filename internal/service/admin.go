@@ -2,20 +2,55 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
 )
 
+// invitationValidity is how long a new or extended invitation can be used
+const invitationValidity = 7 * 24 * time.Hour
+
+// DefaultMaxInvitationBatch is the number of emails BulkCreateInvitations accepts when not configured
+const DefaultMaxInvitationBatch = 100
+
+var (
+	// ErrJoinRequestProcessed is returned when approving or rejecting a join request that is no longer open
+	ErrJoinRequestProcessed = errors.New("join request has already been processed")
+	// ErrInvitationBatchTooLarge is returned when BulkCreateInvitations gets more emails than allowed
+	ErrInvitationBatchTooLarge = errors.New("too many emails in one invitation batch")
+	// ErrCrossOrgSuperAdminRequired is returned when a cross-org RPC is called by an account not
+	// listed in AdminOptions.SuperAdminEmails, whatever its roles in individual orgs
+	ErrCrossOrgSuperAdminRequired = errors.New("unauthorized: cross-org super admin privileges required")
+)
+
+// AdminOptions holds tunables for the admin service
+type AdminOptions struct {
+	MaxInvitationBatch int                         // Emails accepted by BulkCreateInvitations; <= 0 uses DefaultMaxInvitationBatch
+	JobRuns            repository.JobRunRepository // Read by GetJobRuns; nil returns no runs
+	// SuperAdminEmails are the platform operators allowed to call the cross-org RPCs. Org-level
+	// SUPER_ADMIN does not qualify: every org creator holds it.
+	SuperAdminEmails []string
+}
+
 type adminService struct {
 	reqRepo    repository.JoinRequestRepository
 	userRepo   repository.UserRepository
 	ledgerRepo repository.LedgerRepository
 	orgRepo    repository.OrganizationRepository
 	inviteRepo repository.InvitationRepository
+	billRepo   repository.BillRepository
 	emailSvc   EmailService
+	audit      AdminAudit
+	jobRunRepo repository.JobRunRepository
+
+	maxInvitationBatch int
+	superAdminEmails   []string
 }
 
 func NewAdminService(
@@ -24,15 +59,40 @@ func NewAdminService(
 	ledgerRepo repository.LedgerRepository,
 	orgRepo repository.OrganizationRepository,
 	inviteRepo repository.InvitationRepository,
+	billRepo repository.BillRepository,
+	emailSvc EmailService,
+	audit AdminAudit,
+) AdminService {
+	return NewAdminServiceWithOptions(reqRepo, userRepo, ledgerRepo, orgRepo, inviteRepo, billRepo, emailSvc, audit, AdminOptions{})
+}
+
+// NewAdminServiceWithOptions is NewAdminService with explicit admin limits
+func NewAdminServiceWithOptions(
+	reqRepo repository.JoinRequestRepository,
+	userRepo repository.UserRepository,
+	ledgerRepo repository.LedgerRepository,
+	orgRepo repository.OrganizationRepository,
+	inviteRepo repository.InvitationRepository,
+	billRepo repository.BillRepository,
 	emailSvc EmailService,
+	audit AdminAudit,
+	opts AdminOptions,
 ) AdminService {
+	if opts.MaxInvitationBatch <= 0 {
+		opts.MaxInvitationBatch = DefaultMaxInvitationBatch
+	}
 	return &adminService{
-		reqRepo:    reqRepo,
-		userRepo:   userRepo,
-		ledgerRepo: ledgerRepo,
-		orgRepo:    orgRepo,
-		inviteRepo: inviteRepo,
-		emailSvc:   emailSvc,
+		reqRepo:            reqRepo,
+		userRepo:           userRepo,
+		ledgerRepo:         ledgerRepo,
+		orgRepo:            orgRepo,
+		inviteRepo:         inviteRepo,
+		billRepo:           billRepo,
+		emailSvc:           emailSvc,
+		audit:              audit,
+		jobRunRepo:         opts.JobRuns,
+		maxInvitationBatch: opts.MaxInvitationBatch,
+		superAdminEmails:   opts.SuperAdminEmails,
 	}
 }
 
@@ -45,6 +105,9 @@ func (s *adminService) ApproveJoinRequest(ctx context.Context, adminID, orgID, j
 	if joinReq.OrgID != orgID {
 		return "", fmt.Errorf("join request does not belong to the given organization")
 	}
+	if joinReq.Status != domain.JoinRequestStatusPending {
+		return "", fmt.Errorf("%w: %s", ErrJoinRequestProcessed, joinReq.Status)
+	}
 
 	// 2. Get Organization
 	org, err := s.orgRepo.GetByID(ctx, orgID)
@@ -54,20 +117,27 @@ func (s *adminService) ApproveJoinRequest(ctx context.Context, adminID, orgID, j
 
 	var invitationCode string
 
-	// 3. Check if user already exists
-	user, err := s.userRepo.GetByEmail(ctx, joinReq.Email)
+	// 3. Check if user already exists, preferring the account linked when the request was made
+	var user *domain.User
+	if joinReq.UserID != nil {
+		user, err = s.userRepo.GetByID(ctx, *joinReq.UserID)
+	} else {
+		user, err = s.userRepo.GetByEmail(ctx, joinReq.Email)
+	}
 	if err == nil && user != nil {
-		// User exists, add to org
-		userOrg := &domain.UserOrg{
-			UserID:       user.ID,
-			OrgID:        orgID,
-			JoinedOn:     time.Now().Format("2006-01-02"),
-			Status:       domain.UserOrgStatusActive,
-			Role:         domain.UserOrgRoleMember,
-			BalanceCents: 0,
-		}
-		if err := s.userRepo.AddUserToOrg(ctx, userOrg); err != nil {
-			return "", fmt.Errorf("failed to add existing user to org: %w", err)
+		// User exists, add to org unless an earlier invitation already did
+		if uo, err := s.userRepo.GetUserOrg(ctx, user.ID, orgID); err != nil || uo == nil {
+			userOrg := &domain.UserOrg{
+				UserID:       user.ID,
+				OrgID:        orgID,
+				JoinedOn:     time.Now().Format("2006-01-02"),
+				Status:       domain.UserOrgStatusActive,
+				Role:         domain.UserOrgRoleMember,
+				BalanceCents: 0,
+			}
+			if err := s.userRepo.AddUserToOrg(ctx, userOrg); err != nil {
+				return "", fmt.Errorf("failed to add existing user to org: %w", err)
+			}
 		}
 
 		// Notify user
@@ -80,7 +150,7 @@ func (s *adminService) ApproveJoinRequest(ctx context.Context, adminID, orgID, j
 			Email:         joinReq.Email,
 			JoinRequestID: &joinReq.ID,
 			CreatedBy:     adminID,
-			ExpiresOn:     time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02"),
+			ExpiresOn:     time.Now().Add(invitationValidity).Format("2006-01-02"),
 		}
 		if err := s.inviteRepo.Create(ctx, inv); err != nil {
 			return "", fmt.Errorf("failed to create invitation: %w", err)
@@ -99,12 +169,20 @@ func (s *adminService) ApproveJoinRequest(ctx context.Context, adminID, orgID, j
 		invitationCode = inv.InvitationCode
 	}
 
-	// 4. Mark the join request as INVITED
-	joinReq.Status = domain.JoinRequestStatusInvited
+	// 4. Mark the join request as JOINED, or INVITED until the applicant signs up
+	joinReq.Status = domain.JoinRequestStatusJoined
+	if invitationCode != "" {
+		joinReq.Status = domain.JoinRequestStatusInvited
+	}
 	if err := s.reqRepo.Update(ctx, joinReq); err != nil {
 		return "", fmt.Errorf("failed to update join request status: %w", err)
 	}
 
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionApproveJoinRequest, domain.AdminAuditTargetJoinRequest, joinReq.ID,
+			map[string]string{"email": joinReq.Email, "invited": fmt.Sprintf("%t", invitationCode != "")})
+	}
+
 	if invitationCode != "" {
 		return invitationCode, nil
 	}
@@ -127,6 +205,8 @@ func (s *adminService) BlockUser(ctx context.Context, adminID, userID, orgID int
 		return err
 	}
 
+	before := map[string]string{}
+	memberAuditSnapshot(before, "", uo)
 	uo.RentingBlocked = blockRenting
 	uo.LendingBlocked = blockLending
 
@@ -145,6 +225,20 @@ func (s *adminService) BlockUser(ctx context.Context, adminID, userID, orgID int
 		return err
 	}
 
+	if s.audit != nil {
+		action := domain.AdminAuditActionUnblockMember
+		if blockRenting || blockLending {
+			action = domain.AdminAuditActionBlockMember
+		}
+		after := map[string]string{}
+		memberAuditSnapshot(after, "", uo)
+		s.audit.RecordChange(ctx, adminID, orgID, action, domain.AdminAuditTargetUser, userID, map[string]string{
+			"renting_blocked": fmt.Sprintf("%t", blockRenting),
+			"lending_blocked": fmt.Sprintf("%t", blockLending),
+			"reason":          reason,
+		}, before, after)
+	}
+
 	// Notify user
 	statusStr := string(uo.Status)
 	_ = s.emailSvc.SendAccountStatusNotification(ctx, user.Email, user.Name, org.Name, statusStr, reason)
@@ -152,6 +246,76 @@ func (s *adminService) BlockUser(ctx context.Context, adminID, userID, orgID int
 	return nil
 }
 
+// UnblockMember lifts every block on a member: renting, lending and a full account block.
+// Admins cannot lift a block that stems from a bill they are a party to.
+func (s *adminService) UnblockMember(ctx context.Context, adminID, orgID, userID int32) error {
+	adminUO, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
+	if err != nil {
+		return fmt.Errorf("unauthorized: not a member of this organization")
+	}
+	if adminUO.Role != domain.UserOrgRoleAdmin && adminUO.Role != domain.UserOrgRoleSuperAdmin {
+		return fmt.Errorf("unauthorized: admin privileges required")
+	}
+
+	uo, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get user org: %w", err)
+	}
+	if !uo.RentingBlocked && !uo.LendingBlocked && uo.Status != domain.UserOrgStatusBlock {
+		return fmt.Errorf("member is not blocked")
+	}
+
+	var billID int32
+	if uo.BlockedDueToBillID != nil {
+		billID = *uo.BlockedDueToBillID
+		bill, err := s.billRepo.GetByID(ctx, billID)
+		if err != nil {
+			return fmt.Errorf("failed to get originating bill: %w", err)
+		}
+		if bill.DebtorUserID == adminID || bill.CreditorUserID == adminID {
+			return fmt.Errorf("admins cannot lift blocks from bills they are involved in")
+		}
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return err
+	}
+
+	previousReason := uo.BlockedReason
+	before := map[string]string{}
+	memberAuditSnapshot(before, "", uo)
+	uo.RentingBlocked = false
+	uo.LendingBlocked = false
+	uo.BlockedReason = ""
+	uo.BlockedOn = nil
+	uo.BlockedDueToBillID = nil
+	if uo.Status == domain.UserOrgStatusBlock {
+		uo.Status = domain.UserOrgStatusActive
+	}
+
+	if err := s.userRepo.UpdateUserOrg(ctx, uo); err != nil {
+		return err
+	}
+
+	if s.audit != nil {
+		after := map[string]string{}
+		memberAuditSnapshot(after, "", uo)
+		s.audit.RecordChange(ctx, adminID, orgID, domain.AdminAuditActionUnblockMember, domain.AdminAuditTargetUser, userID, map[string]string{
+			"previous_reason":        previousReason,
+			"blocked_due_to_bill_id": fmt.Sprintf("%d", billID),
+		}, before, after)
+	}
+
+	_ = s.emailSvc.SendAccountStatusNotification(ctx, user.Email, user.Name, org.Name, string(uo.Status), "Your renting and lending privileges have been restored.")
+
+	return nil
+}
+
 func (s *adminService) ListMembers(ctx context.Context, orgID int32) ([]domain.User, []domain.UserOrg, error) {
 	return s.userRepo.ListMembersByOrg(ctx, orgID)
 }
@@ -173,6 +337,10 @@ func (s *adminService) RejectJoinRequest(ctx context.Context, adminID, orgID, jo
 	if joinReq.OrgID != orgID {
 		return fmt.Errorf("join request does not belong to the given organization")
 	}
+	// An INVITED request can still be withdrawn; its invitation is expired below
+	if joinReq.Status != domain.JoinRequestStatusPending && joinReq.Status != domain.JoinRequestStatusInvited {
+		return fmt.Errorf("%w: %s", ErrJoinRequestProcessed, joinReq.Status)
+	}
 
 	// 2. Update status to REJECTED, set reason and rejected_by_user_id
 	joinReq.Status = domain.JoinRequestStatusRejected
@@ -181,6 +349,10 @@ func (s *adminService) RejectJoinRequest(ctx context.Context, adminID, orgID, jo
 	if err := s.reqRepo.Update(ctx, joinReq); err != nil {
 		return fmt.Errorf("failed to update join request: %w", err)
 	}
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionRejectJoinRequest, domain.AdminAuditTargetJoinRequest, joinReq.ID,
+			map[string]string{"email": joinReq.Email, "reason": reason})
+	}
 
 	// 3. Expire any linked invitation immediately (set expires_on to yesterday)
 	if inv, err := s.inviteRepo.GetByJoinRequestID(ctx, joinRequestID); err == nil && inv != nil && inv.UsedOn == nil && inv.UsedByUserID == nil {
@@ -219,7 +391,7 @@ func (s *adminService) SendInvitation(ctx context.Context, adminID, orgID int32,
 		OrgID:     orgID,
 		Email:     email,
 		CreatedBy: adminID,
-		ExpiresOn: time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02"),
+		ExpiresOn: time.Now().Add(invitationValidity).Format("2006-01-02"),
 	}
 	if err := s.inviteRepo.Create(ctx, inv); err != nil {
 		return "", fmt.Errorf("failed to create invitation: %w", err)
@@ -235,9 +407,209 @@ func (s *adminService) SendInvitation(ctx context.Context, adminID, orgID int32,
 		return "", fmt.Errorf("failed to send invitation email: %w", err)
 	}
 
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionSendInvitation, domain.AdminAuditTargetInvitation, inv.ID,
+			map[string]string{"email": email})
+	}
+
 	return inv.InvitationCode, nil
 }
 
+// ResendInvitation emails an outstanding invitation code again. With extendExpiry the
+// invitation is valid for another invitationValidity from today; without it an expired
+// invitation cannot be resent.
+func (s *adminService) ResendInvitation(ctx context.Context, adminID, orgID int32, invitationCode, email string, extendExpiry bool) (*domain.Invitation, error) {
+	inv, org, admin, err := s.getOutstandingInvitation(ctx, adminID, orgID, invitationCode, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if extendExpiry {
+		inv.ExpiresOn = time.Now().Add(invitationValidity).Format("2006-01-02")
+		if err := s.inviteRepo.Update(ctx, inv); err != nil {
+			return nil, fmt.Errorf("failed to extend invitation: %w", err)
+		}
+	} else if expiresOn, err := time.Parse("2006-01-02", inv.ExpiresOn); err != nil || expiresOn.Before(time.Now()) {
+		return nil, ErrInviteExpired
+	}
+
+	name := inv.Email
+	if inv.JoinRequestID != nil {
+		if joinReq, err := s.reqRepo.GetByID(ctx, *inv.JoinRequestID); err == nil && joinReq != nil {
+			name = joinReq.Name
+		}
+	}
+	if err := s.emailSvc.SendInvitation(ctx, inv.Email, name, inv.InvitationCode, org.Name, admin.Email); err != nil {
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionResendInvitation, domain.AdminAuditTargetInvitation, inv.ID,
+			map[string]string{"email": inv.Email, "expires_on": inv.ExpiresOn})
+	}
+	return inv, nil
+}
+
+// RevokeInvitation makes an outstanding invitation unusable for signup and joining
+func (s *adminService) RevokeInvitation(ctx context.Context, adminID, orgID int32, invitationCode, email string) error {
+	inv, _, _, err := s.getOutstandingInvitation(ctx, adminID, orgID, invitationCode, email)
+	if err != nil {
+		return err
+	}
+	if err := s.inviteRepo.Revoke(ctx, inv.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInviteUsed
+		}
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionRevokeInvitation, domain.AdminAuditTargetInvitation, inv.ID,
+			map[string]string{"email": inv.Email})
+	}
+	return nil
+}
+
+// getOutstandingInvitation checks that adminID administers orgID and loads the org's unused,
+// unrevoked invitation identified by (invitationCode, email)
+func (s *adminService) getOutstandingInvitation(ctx context.Context, adminID, orgID int32, invitationCode, email string) (*domain.Invitation, *domain.Organization, *domain.User, error) {
+	if err := s.requireOrgAdmin(ctx, adminID, orgID); err != nil {
+		return nil, nil, nil, err
+	}
+
+	inv, err := s.inviteRepo.GetByInvitationCodeAndEmail(ctx, invitationCode, email)
+	if err != nil || inv.OrgID != orgID {
+		return nil, nil, nil, fmt.Errorf("invitation not found in this organization")
+	}
+	if inv.UsedOn != nil {
+		return nil, nil, nil, ErrInviteUsed
+	}
+	if inv.RevokedOn != nil {
+		return nil, nil, nil, ErrInviteRevoked
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+	return inv, org, admin, nil
+}
+
+// BulkCreateInvitations invites every email in the list to orgID. Emails are trimmed and
+// deduplicated case-insensitively; members and emails with a usable invitation are skipped.
+// One failed email does not stop the rest, so results has an entry per input email.
+func (s *adminService) BulkCreateInvitations(ctx context.Context, adminID, orgID int32, emails []string) ([]domain.BulkInvitationResult, error) {
+	if err := s.requireOrgAdmin(ctx, adminID, orgID); err != nil {
+		return nil, err
+	}
+	if len(emails) > s.maxInvitationBatch {
+		return nil, fmt.Errorf("%w: %d emails, at most %d allowed", ErrInvitationBatchTooLarge, len(emails), s.maxInvitationBatch)
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	admin, err := s.userRepo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin user: %w", err)
+	}
+
+	results := make([]domain.BulkInvitationResult, len(emails))
+	seen := make(map[string]bool, len(emails))
+	var unique []string
+	for i, raw := range emails {
+		email := strings.ToLower(strings.TrimSpace(raw))
+		results[i].Email = email
+		switch {
+		case !isValidEmail(email):
+			results[i].Email = raw
+			results[i].Status = domain.BulkInvitationStatusFailed
+			results[i].Message = "invalid email address"
+		case seen[email]:
+			results[i].Status = domain.BulkInvitationStatusSkipped
+			results[i].Message = "duplicate in request"
+		default:
+			seen[email] = true
+			unique = append(unique, email)
+		}
+	}
+
+	outstanding, err := s.inviteRepo.ListOutstandingEmails(ctx, orgID, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing invitations: %w", err)
+	}
+	invited := make(map[string]bool, len(outstanding))
+	for _, e := range outstanding {
+		invited[e] = true
+	}
+
+	for i := range results {
+		r := &results[i]
+		if r.Status != "" {
+			continue
+		}
+		if invited[r.Email] {
+			r.Status = domain.BulkInvitationStatusSkipped
+			r.Message = "already invited"
+			continue
+		}
+		if user, err := s.userRepo.GetByEmail(ctx, r.Email); err == nil && user != nil {
+			if uo, err := s.userRepo.GetUserOrg(ctx, user.ID, orgID); err == nil && uo != nil {
+				r.Status = domain.BulkInvitationStatusSkipped
+				r.Message = "already a member"
+				continue
+			}
+		}
+
+		inv := &domain.Invitation{
+			OrgID:     orgID,
+			Email:     r.Email,
+			CreatedBy: adminID,
+			ExpiresOn: time.Now().Add(invitationValidity).Format("2006-01-02"),
+		}
+		if err := s.inviteRepo.Create(ctx, inv); err != nil {
+			r.Status = domain.BulkInvitationStatusFailed
+			r.Message = fmt.Sprintf("failed to create invitation: %v", err)
+			continue
+		}
+		r.InvitationCode = inv.InvitationCode
+		if s.audit != nil {
+			s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionSendInvitation, domain.AdminAuditTargetInvitation, inv.ID,
+				map[string]string{"email": r.Email, "bulk": "true"})
+		}
+		if err := s.emailSvc.SendInvitation(ctx, r.Email, r.Email, inv.InvitationCode, org.Name, admin.Email); err != nil {
+			// The invitation exists; ResendInvitation can deliver it later
+			r.Status = domain.BulkInvitationStatusFailed
+			r.Message = fmt.Sprintf("invitation created but email failed: %v", err)
+			continue
+		}
+		r.Status = domain.BulkInvitationStatusCreated
+	}
+	return results, nil
+}
+
+// requireOrgAdmin fails unless userID is an ADMIN or SUPER_ADMIN of orgID
+func (s *adminService) requireOrgAdmin(ctx context.Context, userID, orgID int32) error {
+	uo, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("unauthorized: not a member of this organization")
+	}
+	if uo.Role != domain.UserOrgRoleAdmin && uo.Role != domain.UserOrgRoleSuperAdmin {
+		return fmt.Errorf("unauthorized: admin privileges required")
+	}
+	return nil
+}
+
+func isValidEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
 func (s *adminService) GetMemberProfile(ctx context.Context, orgID, userID int32) (*domain.User, *domain.UserOrg, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
@@ -251,3 +623,164 @@ func (s *adminService) GetMemberProfile(ctx context.Context, orgID, userID int32
 
 	return user, uo, nil
 }
+
+// GetDisputeStatistics reports dispute counts, resolution time and penalties for an org.
+// Months are 'YYYY-MM' and inclusive; an empty bound is unbounded.
+func (s *adminService) GetDisputeStatistics(ctx context.Context, adminID, orgID int32, fromMonth, toMonth string) (*domain.DisputeStatistics, error) {
+	uo, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: not a member of this organization")
+	}
+	if uo.Role != domain.UserOrgRoleAdmin && uo.Role != domain.UserOrgRoleSuperAdmin {
+		return nil, fmt.Errorf("unauthorized: admin privileges required")
+	}
+
+	for _, m := range []string{fromMonth, toMonth} {
+		if m == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01", m); err != nil {
+			return nil, fmt.Errorf("invalid month %q, expected YYYY-MM", m)
+		}
+	}
+	if fromMonth != "" && toMonth != "" && fromMonth > toMonth {
+		return nil, fmt.Errorf("from month must not be after to month")
+	}
+
+	return s.billRepo.GetDisputeStatistics(ctx, orgID, fromMonth, toMonth)
+}
+
+func (s *adminService) GetOrgBalanceSheet(ctx context.Context, adminID, orgID int32) (*domain.OrgBalanceSheet, error) {
+	uo, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
+	if err != nil {
+		return nil, domain.Unauthorizedf("unauthorized: not a member of this organization")
+	}
+	if uo.Role != domain.UserOrgRoleAdmin && uo.Role != domain.UserOrgRoleSuperAdmin {
+		return nil, domain.Unauthorizedf("unauthorized: admin privileges required")
+	}
+	return s.billRepo.GetBalanceSheet(ctx, orgID)
+}
+
+// ListAuditLog returns the org's admin audit trail. Only a SUPER_ADMIN of the org may read it.
+func (s *adminService) ListAuditLog(ctx context.Context, superAdminID, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error) {
+	uo, err := s.userRepo.GetUserOrg(ctx, superAdminID, orgID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unauthorized: not a member of this organization")
+	}
+	if uo.Role != domain.UserOrgRoleSuperAdmin {
+		return nil, 0, fmt.Errorf("unauthorized: super admin privileges required")
+	}
+	if s.audit == nil {
+		return nil, 0, nil
+	}
+	return s.audit.List(ctx, orgID, filter)
+}
+
+// Job run listing limits for GetJobRuns
+const (
+	defaultJobRunLimit = 50
+	maxJobRunLimit     = 200
+)
+
+// GetJobRuns returns recent cronjob runs. Runs are system-wide, so only a SUPER_ADMIN may read them.
+func (s *adminService) GetJobRuns(ctx context.Context, superAdminID, orgID int32, filter domain.JobRunFilter) ([]domain.JobRun, error) {
+	uo, err := s.userRepo.GetUserOrg(ctx, superAdminID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: not a member of this organization")
+	}
+	if uo.Role != domain.UserOrgRoleSuperAdmin {
+		return nil, fmt.Errorf("unauthorized: super admin privileges required")
+	}
+	switch filter.Status {
+	case "", domain.JobRunStatusRunning, domain.JobRunStatusSucceeded, domain.JobRunStatusFailed:
+	default:
+		return nil, fmt.Errorf("invalid job run status: %s", filter.Status)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobRunLimit
+	}
+	if filter.Limit > maxJobRunLimit {
+		filter.Limit = maxJobRunLimit
+	}
+	if s.jobRunRepo == nil {
+		return nil, nil
+	}
+	return s.jobRunRepo.List(ctx, filter)
+}
+
+// requireCrossOrgSuperAdmin fails unless userID's email is listed in AdminOptions.SuperAdminEmails.
+// The check is deliberately independent of org memberships.
+func (s *adminService) requireCrossOrgSuperAdmin(ctx context.Context, userID int32) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrCrossOrgSuperAdminRequired
+	}
+	for _, email := range s.superAdminEmails {
+		if strings.EqualFold(email, user.Email) {
+			return nil
+		}
+	}
+	return ErrCrossOrgSuperAdminRequired
+}
+
+// ListAllOrganizations returns every organization on the platform. Cross-org super admins only.
+func (s *adminService) ListAllOrganizations(ctx context.Context, superAdminID int32) ([]domain.Organization, error) {
+	if err := s.requireCrossOrgSuperAdmin(ctx, superAdminID); err != nil {
+		return nil, err
+	}
+	return s.orgRepo.List(ctx)
+}
+
+// GetAnyMemberProfile returns a member's profile in any organization. Cross-org super admins only.
+func (s *adminService) GetAnyMemberProfile(ctx context.Context, superAdminID, orgID, userID int32) (*domain.User, *domain.UserOrg, error) {
+	if err := s.requireCrossOrgSuperAdmin(ctx, superAdminID); err != nil {
+		return nil, nil, err
+	}
+	return s.GetMemberProfile(ctx, orgID, userID)
+}
+
+// AdjustAnyBalance records an ADJUSTMENT ledger entry for a member of any organization and
+// returns the resulting balance; the ledger trigger updates users_orgs.balance_cents. The
+// adjustment is audited in the member's org. Cross-org super admins only.
+func (s *adminService) AdjustAnyBalance(ctx context.Context, superAdminID, orgID, userID, amountCents int32, reason string) (int32, error) {
+	if err := s.requireCrossOrgSuperAdmin(ctx, superAdminID); err != nil {
+		return 0, err
+	}
+	reason = strings.TrimSpace(reason)
+	if amountCents == 0 {
+		return 0, fmt.Errorf("adjustment amount must not be zero")
+	}
+	if reason == "" {
+		return 0, fmt.Errorf("adjustment reason is required")
+	}
+	prev, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return 0, fmt.Errorf("user is not a member of this organization")
+	}
+	before := map[string]string{"balance_cents": fmt.Sprintf("%d", prev.BalanceCents)}
+
+	tx := &domain.LedgerTransaction{
+		OrgID:       orgID,
+		UserID:      userID,
+		Amount:      amountCents,
+		Type:        domain.TransactionTypeAdjustment,
+		Description: "Balance adjustment by platform admin: " + reason,
+	}
+	if err := s.ledgerRepo.CreateTransaction(ctx, tx); err != nil {
+		return 0, fmt.Errorf("failed to record adjustment: %w", err)
+	}
+
+	uo, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read adjusted balance: %w", err)
+	}
+	if s.audit != nil {
+		s.audit.RecordChange(ctx, superAdminID, orgID, domain.AdminAuditActionAdjustBalance, domain.AdminAuditTargetUser, userID, map[string]string{
+			"amount_cents":      fmt.Sprintf("%d", amountCents),
+			"reason":            reason,
+			"ledger_id":         fmt.Sprintf("%d", tx.ID),
+			"new_balance_cents": fmt.Sprintf("%d", uo.BalanceCents),
+		}, before, map[string]string{"balance_cents": fmt.Sprintf("%d", uo.BalanceCents)})
+	}
+	return uo.BalanceCents, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+type adminAudit struct {
+	auditRepo repository.AdminAuditRepository
+}
+
+func NewAdminAudit(auditRepo repository.AdminAuditRepository) AdminAudit {
+	return &adminAudit{auditRepo: auditRepo}
+}
+
+func (a *adminAudit) Record(ctx context.Context, adminID, orgID int32, action domain.AdminAuditAction, targetType domain.AdminAuditTargetType, targetID int32, details map[string]string) {
+	a.RecordChange(ctx, adminID, orgID, action, targetType, targetID, details, nil, nil)
+}
+
+func (a *adminAudit) RecordChange(ctx context.Context, adminID, orgID int32, action domain.AdminAuditAction, targetType domain.AdminAuditTargetType, targetID int32, details, before, after map[string]string) {
+	entry := &domain.AdminAuditEntry{
+		OrgID:      orgID,
+		AdminID:    adminID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    details,
+		Before:     before,
+		After:      after,
+	}
+	if err := a.auditRepo.Create(ctx, entry); err != nil {
+		// The mutation already happened; keep a trace in the logs rather than failing it
+		logger.Error("Failed to record admin audit entry",
+			"adminID", adminID, "orgID", orgID, "action", action,
+			"targetType", targetType, "targetID", targetID, "error", err)
+	}
+}
+
+func (a *adminAudit) List(ctx context.Context, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error) {
+	return a.auditRepo.List(ctx, orgID, filter)
+}
+
+// memberAuditSnapshot captures the membership fields that admin and dispute actions change.
+// prefix distinguishes parties when one entry covers several members, e.g. "debtor_".
+func memberAuditSnapshot(snapshot map[string]string, prefix string, uo *domain.UserOrg) {
+	snapshot[prefix+"balance_cents"] = fmt.Sprintf("%d", uo.BalanceCents)
+	snapshot[prefix+"status"] = string(uo.Status)
+	snapshot[prefix+"renting_blocked"] = fmt.Sprintf("%t", uo.RentingBlocked)
+	snapshot[prefix+"lending_blocked"] = fmt.Sprintf("%t", uo.LendingBlocked)
+	snapshot[prefix+"blocked_reason"] = uo.BlockedReason
+}
@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	randmath "math/rand"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,12 +22,17 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrInviteExpired      = errors.New("invitation has expired")
-	ErrInviteUsed         = errors.New("invitation already used")
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrInvalid2FACode     = errors.New("invalid 2fa code")
-	ErrOrgNotFound        = errors.New("organization not found")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInviteInvalid       = domain.NotFoundf("invitation code is invalid")
+	ErrInviteExpired       = domain.Conflictf("invitation has expired")
+	ErrInviteUsed          = domain.Conflictf("invitation already used")
+	ErrInviteRevoked       = domain.Conflictf("invitation has been revoked")
+	ErrInvalidToken        = errors.New("invalid token")
+	ErrInvalid2FACode      = errors.New("invalid 2fa code")
+	ErrOrgNotFound         = errors.New("organization not found")
+	ErrEmailNotVerified    = errors.New("email address has not been verified")
+	ErrVerificationExpired = errors.New("verification link has expired")
+	ErrTokenRevoked        = errors.New("token has been revoked")
 )
 
 type authService struct {
@@ -37,10 +45,28 @@ type authService struct {
 	tm               security.TokenManager
 	fcmRepo          repository.FcmTokenRepository
 	pendingCredsRepo repository.PendingCredentialsRepository
+	revokedRepo      repository.RevokedTokenRepository
+	policy           AuthPolicy
 	pending2FACodes  sync.Map // key: userID (int32), value: string (5-digit code)
 }
 
-func NewAuthService(userRepo repository.UserRepository, inviteRepo repository.InvitationRepository, reqRepo repository.JoinRequestRepository, orgRepo repository.OrganizationRepository, noteSvc NotificationService, emailSvc EmailService, secret string, fcmRepo repository.FcmTokenRepository, pendingCredsRepo repository.PendingCredentialsRepository) AuthService {
+// TwoFAExemptions lists who may log in without 2FA. A user is exempt when their email is
+// listed or they hold one of the roles in any active org membership.
+type TwoFAExemptions struct {
+	Roles  []domain.UserOrgRole
+	Emails []string
+}
+
+// AuthPolicy holds the configurable login rules
+type AuthPolicy struct {
+	TwoFAExempt TwoFAExemptions
+	// RequireEmailVerification blocks login until the emailed verification link is opened
+	RequireEmailVerification bool
+	// EmailVerificationURL is the link base; the token is appended as ?token=...
+	EmailVerificationURL string
+}
+
+func NewAuthService(userRepo repository.UserRepository, inviteRepo repository.InvitationRepository, reqRepo repository.JoinRequestRepository, orgRepo repository.OrganizationRepository, noteSvc NotificationService, emailSvc EmailService, tm security.TokenManager, fcmRepo repository.FcmTokenRepository, pendingCredsRepo repository.PendingCredentialsRepository, revokedRepo repository.RevokedTokenRepository, policy AuthPolicy) AuthService {
 	return &authService{
 		userRepo:         userRepo,
 		inviteRepo:       inviteRepo,
@@ -48,9 +74,11 @@ func NewAuthService(userRepo repository.UserRepository, inviteRepo repository.In
 		orgRepo:          orgRepo,
 		noteSvc:          noteSvc,
 		emailSvc:         emailSvc,
-		tm:               security.NewTokenManager(secret),
+		tm:               tm,
 		fcmRepo:          fcmRepo,
 		pendingCredsRepo: pendingCredsRepo,
+		revokedRepo:      revokedRepo,
+		policy:           policy,
 	}
 }
 
@@ -60,16 +88,17 @@ func (s *authService) ValidateInvite(ctx context.Context, inviteCode, email stri
 	if err != nil {
 		return false, "invitation code is invalid", nil, err
 	}
-	if inv.UsedOn != nil {
-		return false, "invitation already used", nil, ErrInviteUsed
-	}
-	// Explicitly check expiration and return error
-	expiresOn, err := time.Parse("2006-01-02", inv.ExpiresOn)
-	if err != nil {
-		return false, "invalid expiration date format", nil, err
-	}
-	if expiresOn.Before(time.Now()) {
-		return false, "invitation has expired", nil, ErrInviteExpired
+	if err := checkInvitationUsable(inv, time.Now()); err != nil {
+		switch {
+		case errors.Is(err, ErrInviteUsed):
+			return false, "invitation already used", nil, err
+		case errors.Is(err, ErrInviteRevoked):
+			return false, "invitation has been revoked by an organization admin", nil, err
+		case errors.Is(err, ErrInviteExpired):
+			return false, "invitation has expired", nil, err
+		default:
+			return false, "invalid expiration date format", nil, err
+		}
 	}
 
 	// 2. Check if a user with this email exists
@@ -89,8 +118,51 @@ func (s *authService) ValidateInvite(ctx context.Context, inviteCode, email stri
 	return true, "", user, nil
 }
 
+// checkInvitationUsable returns ErrInviteUsed, ErrInviteRevoked or ErrInviteExpired when the
+// invitation can no longer be accepted
+func checkInvitationUsable(inv *domain.Invitation, now time.Time) error {
+	if inv.UsedOn != nil {
+		return ErrInviteUsed
+	}
+	if inv.RevokedOn != nil {
+		return ErrInviteRevoked
+	}
+	expiresOn, err := time.Parse("2006-01-02", inv.ExpiresOn)
+	if err != nil {
+		return err
+	}
+	if expiresOn.Before(now) {
+		return ErrInviteExpired
+	}
+	return nil
+}
+
+func (s *authService) GetInvitationInfo(ctx context.Context, inviteCode, email string) (*domain.InvitationInfo, error) {
+	inv, err := s.inviteRepo.GetByInvitationCodeAndEmail(ctx, inviteCode, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInviteInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkInvitationUsable(inv, time.Now()); err != nil {
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, inv.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.InvitationInfo{
+		OrgName:        org.Name,
+		OrgDescription: org.Description,
+		Metro:          org.Metro,
+		ExpiresOn:      inv.ExpiresOn,
+	}, nil
+}
+
 func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, email, note, adminEmail string) error {
-	logger.EnterMethod("authService.RequestToJoin", "orgID", orgID, "name", name, "email", email, "adminEmail", adminEmail)
+	logger.EnterMethodContext(ctx, "authService.RequestToJoin", "orgID", orgID, "name", name, "email", email, "adminEmail", adminEmail)
 
 	// 1. Verify org exists
 	logger.Debug("Fetching organization", "orgID", orgID)
@@ -98,11 +170,11 @@ func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, emai
 	org, err := s.orgRepo.GetByID(ctx, orgID)
 	logger.DatabaseResult("SELECT", 1, err, "orgID", orgID)
 	if err != nil {
-		logger.ExitMethodWithError("authService.RequestToJoin", err, "reason", "org not found")
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", err, "reason", "org not found")
 		return err // Could verify if err is NotFound and return ErrOrgNotFound
 	}
 	if org == nil {
-		logger.ExitMethodWithError("authService.RequestToJoin", ErrOrgNotFound, "reason", "org is nil")
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", ErrOrgNotFound, "reason", "org is nil")
 		return ErrOrgNotFound
 	}
 	logger.Debug("Organization found", "orgID", orgID, "orgName", org.Name)
@@ -133,7 +205,7 @@ func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, emai
 	logger.DatabaseCall("INSERT", "join_requests (org_id, user_id, name, email, note, status)")
 	if err := s.reqRepo.Create(ctx, req); err != nil {
 		logger.DatabaseResult("INSERT", 0, err)
-		logger.ExitMethodWithError("authService.RequestToJoin", err, "reason", "failed to create join request")
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", err, "reason", "failed to create join request")
 		return err
 	}
 	logger.DatabaseResult("INSERT", 1, nil, "requestID", req.ID)
@@ -145,11 +217,11 @@ func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, emai
 	adminUser, err := s.userRepo.GetByEmail(ctx, adminEmail)
 	logger.DatabaseResult("SELECT", 1, err)
 	if err != nil {
-		logger.ExitMethodWithError("authService.RequestToJoin", err, "reason", "admin user not found")
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", err, "reason", "admin user not found")
 		return fmt.Errorf("admin email %s not found: %w", adminEmail, err)
 	}
 	if adminUser == nil {
-		logger.ExitMethodWithError("authService.RequestToJoin", fmt.Errorf("admin email not found"), "adminEmail", adminEmail)
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", fmt.Errorf("admin email not found"), "adminEmail", adminEmail)
 		return fmt.Errorf("admin email %s not found", adminEmail)
 	}
 
@@ -158,11 +230,11 @@ func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, emai
 	adminUserOrg, err := s.userRepo.GetUserOrg(ctx, adminUser.ID, orgID)
 	logger.DatabaseResult("SELECT", 1, err)
 	if err != nil {
-		logger.ExitMethodWithError("authService.RequestToJoin", err, "reason", "admin not member of org")
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", err, "reason", "admin not member of org")
 		return fmt.Errorf("admin email %s is not a member of organization %d: %w", adminEmail, orgID, err)
 	}
 	if adminUserOrg.Role != domain.UserOrgRoleAdmin && adminUserOrg.Role != domain.UserOrgRoleSuperAdmin {
-		logger.ExitMethodWithError("authService.RequestToJoin", fmt.Errorf("insufficient permissions"), "adminEmail", adminEmail, "role", adminUserOrg.Role)
+		logger.ExitMethodWithErrorContext(ctx, "authService.RequestToJoin", fmt.Errorf("insufficient permissions"), "adminEmail", adminEmail, "role", adminUserOrg.Role)
 		return fmt.Errorf("user %s does not have admin permissions in organization %d", adminEmail, orgID)
 	}
 	logger.Debug("Admin verified", "adminID", adminUser.ID, "adminEmail", adminEmail, "role", adminUserOrg.Role)
@@ -204,7 +276,7 @@ func (s *authService) RequestToJoin(ctx context.Context, orgID int32, name, emai
 
 	logger.Info("Join request processing completed", "requestID", req.ID, "adminEmail", adminEmail)
 
-	logger.ExitMethod("authService.RequestToJoin", "requestID", req.ID)
+	logger.ExitMethodContext(ctx, "authService.RequestToJoin", "requestID", req.ID)
 	return nil
 }
 
@@ -224,6 +296,11 @@ func (s *authService) Signup(ctx context.Context, inviteToken, name, email, phon
 		return err
 	}
 
+	phone, err = domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return err
+	}
+
 	// 3. Check if user already exists
 	existingUser, err := s.userRepo.GetByEmail(ctx, email)
 	if err == nil && existingUser != nil {
@@ -277,16 +354,77 @@ func (s *authService) Signup(ctx context.Context, inviteToken, name, email, phon
 		return err
 	}
 
+	// 8. Email a verification link; a failed send is logged and does not undo the signup
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		logger.Warn("Failed to send verification email", "userID", user.ID, "error", err)
+	}
+
 	return nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (string, bool, bool, error) {
-	logger.EnterMethod("authService.Login", "email", email)
+// sendVerificationEmail emails the user a link that proves ownership of their address
+func (s *authService) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	token, err := s.tm.GenerateEmailVerificationToken(user.ID, user.Email)
+	if err != nil {
+		return err
+	}
+	link := token
+	if s.policy.EmailVerificationURL != "" {
+		link = fmt.Sprintf("%s?token=%s", s.policy.EmailVerificationURL, url.QueryEscape(token))
+	}
+	subject := "Verify your email address"
+	message := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link within %d hours:\n%s",
+		user.Name, int(security.EmailVerificationTokenTTL.Hours()), link)
+	return s.emailSvc.SendAdminNotification(ctx, user.Email, subject, message)
+}
+
+func (s *authService) VerifyEmail(ctx context.Context, token string) error {
+	logger.EnterMethodContext(ctx, "authService.VerifyEmail")
+
+	claims, err := s.tm.ValidateToken(token)
+	if err != nil {
+		if errors.Is(err, security.ErrExpiredToken) {
+			logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", ErrVerificationExpired)
+			return ErrVerificationExpired
+		}
+		logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", ErrInvalidToken)
+		return ErrInvalidToken
+	}
+	if claims.Type != security.TokenTypeEmailVerify {
+		logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", ErrInvalidToken, "reason", "wrong token type")
+		return ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", err, "reason", "user not found")
+		return ErrInvalidToken
+	}
+	// The link only proves ownership of the address it was sent to
+	if !strings.EqualFold(user.Email, claims.Email) {
+		logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", ErrInvalidToken, "reason", "email changed since link was sent")
+		return ErrInvalidToken
+	}
+	if user.EmailVerified {
+		logger.ExitMethodContext(ctx, "authService.VerifyEmail", "userID", user.ID, "alreadyVerified", true)
+		return nil
+	}
+
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "authService.VerifyEmail", err)
+		return err
+	}
+	logger.ExitMethodContext(ctx, "authService.VerifyEmail", "userID", user.ID)
+	return nil
+}
+
+func (s *authService) Login(ctx context.Context, email, password string) (string, string, string, bool, bool, error) {
+	logger.EnterMethodContext(ctx, "authService.Login", "email", email)
 
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		logger.ExitMethodWithError("authService.Login", ErrInvalidCredentials, "reason", "user not found")
-		return "", false, false, ErrInvalidCredentials
+		logger.ExitMethodWithErrorContext(ctx, "authService.Login", ErrInvalidCredentials, "reason", "user not found")
+		return "", "", "", false, false, ErrInvalidCredentials
 	}
 
 	// First try the canonical password in the users table.
@@ -296,17 +434,17 @@ func (s *authService) Login(ctx context.Context, email, password string) (string
 		logger.Debug("Canonical password mismatch — checking pending_credentials", "userID", user.ID)
 		cred, credErr := s.pendingCredsRepo.GetByUserID(ctx, user.ID)
 		if credErr != nil || cred == nil {
-			logger.ExitMethodWithError("authService.Login", ErrInvalidCredentials, "reason", "password mismatch, no pending credential")
-			return "", false, false, ErrInvalidCredentials
+			logger.ExitMethodWithErrorContext(ctx, "authService.Login", ErrInvalidCredentials, "reason", "password mismatch, no pending credential")
+			return "", "", "", false, false, ErrInvalidCredentials
 		}
 		// Validate: not used, not expired
 		if cred.UsedAt != nil || cred.ExpiresAt.Before(time.Now()) {
-			logger.ExitMethodWithError("authService.Login", ErrInvalidCredentials, "reason", "pending credential expired or already used")
-			return "", false, false, ErrInvalidCredentials
+			logger.ExitMethodWithErrorContext(ctx, "authService.Login", ErrInvalidCredentials, "reason", "pending credential expired or already used")
+			return "", "", "", false, false, ErrInvalidCredentials
 		}
 		if bcryptErr := bcrypt.CompareHashAndPassword([]byte(cred.TempPasswordHash), []byte(password)); bcryptErr != nil {
-			logger.ExitMethodWithError("authService.Login", ErrInvalidCredentials, "reason", "password mismatch")
-			return "", false, false, ErrInvalidCredentials
+			logger.ExitMethodWithErrorContext(ctx, "authService.Login", ErrInvalidCredentials, "reason", "password mismatch")
+			return "", "", "", false, false, ErrInvalidCredentials
 		}
 		tempPwd = true
 		logger.Info("Authenticated via temporary password", "userID", user.ID)
@@ -314,12 +452,29 @@ func (s *authService) Login(ctx context.Context, email, password string) (string
 		logger.Info("Password validated successfully", "userID", user.ID, "email", email)
 	}
 
-	// 2FA is always required for the trusted backend.
+	if s.policy.RequireEmailVerification && !user.EmailVerified {
+		logger.ExitMethodWithErrorContext(ctx, "authService.Login", ErrEmailNotVerified, "userID", user.ID)
+		return "", "", "", false, false, ErrEmailNotVerified
+	}
+
+	// 2FA is required unless the user is explicitly exempt. Temporary-password logins always
+	// go through 2FA so the reset_password flag reaches the client.
+	if !tempPwd && s.isTwoFAExempt(ctx, user) {
+		access, refresh, err := s.issueTokens(ctx, user)
+		if err != nil {
+			logger.ExitMethodWithErrorContext(ctx, "authService.Login", err, "reason", "failed to generate tokens")
+			return "", "", "", false, false, err
+		}
+		logger.Info("Login exempt from 2FA", "userID", user.ID)
+		logger.ExitMethodContext(ctx, "authService.Login", "userID", user.ID, "requires2FA", false)
+		return "", access, refresh, false, false, nil
+	}
+
 	logger.Debug("Generating 2FA token", "userID", user.ID, "tempPwd", tempPwd)
 	sessionToken, err := s.tm.Generate2FAToken(user.ID, "email", tempPwd)
 	if err != nil {
-		logger.ExitMethodWithError("authService.Login", err, "reason", "failed to generate 2FA token")
-		return "", false, false, err
+		logger.ExitMethodWithErrorContext(ctx, "authService.Login", err, "reason", "failed to generate 2FA token")
+		return "", "", "", false, false, err
 	}
 	logger.Debug("2FA token generated", "userID", user.ID, "tokenPrefix", sessionToken[:20])
 
@@ -327,28 +482,91 @@ func (s *authService) Login(ctx context.Context, email, password string) (string
 	code := fmt.Sprintf("%05d", randmath.Intn(100000))
 	s.pending2FACodes.Store(user.ID, code)
 	logger.Info("2FA code generated and emailed", "userID", user.ID)
-	subject := fmt.Sprintf("Your 2FA Code - %s", code)
-	message := fmt.Sprintf("Your login code is: %s", code)
-	_ = s.emailSvc.SendAdminNotification(ctx, user.Email, subject, message)
+	_ = s.emailSvc.SendTwoFactorCode(ctx, user.Email, TwoFactorCodeEmail{Name: user.Name, Code: code})
 
-	logger.ExitMethod("authService.Login", "userID", user.ID, "requires2FA", true, "tempPwd", tempPwd)
-	return sessionToken, true, tempPwd, nil
+	logger.ExitMethodContext(ctx, "authService.Login", "userID", user.ID, "requires2FA", true, "tempPwd", tempPwd)
+	return sessionToken, "", "", true, tempPwd, nil
+}
+
+// isTwoFAExempt reports whether the user matches a configured 2FA exemption
+func (s *authService) isTwoFAExempt(ctx context.Context, user *domain.User) bool {
+	for _, email := range s.policy.TwoFAExempt.Emails {
+		if strings.EqualFold(email, user.Email) {
+			return true
+		}
+	}
+	if len(s.policy.TwoFAExempt.Roles) == 0 {
+		return false
+	}
+	userOrgs, err := s.userRepo.ListUserOrgs(ctx, user.ID)
+	if err != nil {
+		// Fail closed: without membership data, fall back to 2FA
+		logger.Warn("Failed to load memberships for 2FA exemption check", "userID", user.ID, "error", err)
+		return false
+	}
+	for _, uo := range userOrgs {
+		if uo.Status != domain.UserOrgStatusActive {
+			continue
+		}
+		for _, role := range s.policy.TwoFAExempt.Roles {
+			if uo.Role == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// issueTokens generates the access and refresh token pair for an authenticated user. The
+// refresh token carries the user's current token version so RevokeAllSessions can void it.
+func (s *authService) issueTokens(ctx context.Context, user *domain.User) (string, string, error) {
+	version, err := s.userRepo.GetTokenVersion(ctx, user.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get token version: %w", err)
+	}
+	// TODO: Retrieve actual roles from database
+	access, err := s.tm.GenerateAccessToken(user.ID, user.Email, []string{"user"}, s.orgClaims(ctx, user.ID))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	refresh, err := s.tm.GenerateRefreshToken(user.ID, user.Email, version)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return access, refresh, nil
+}
+
+// orgClaims lists the user's active memberships for the access token. A failed lookup only
+// costs the fast path, so the token is issued without them.
+func (s *authService) orgClaims(ctx context.Context, userID int32) []security.OrgMembership {
+	userOrgs, err := s.userRepo.ListUserOrgs(ctx, userID)
+	if err != nil {
+		logger.Warn("Failed to load memberships for token claims", "userID", userID, "error", err)
+		return nil
+	}
+	var orgs []security.OrgMembership
+	for _, uo := range userOrgs {
+		if uo.Status == domain.UserOrgStatusActive {
+			orgs = append(orgs, security.OrgMembership{OrgID: uo.OrgID, Role: string(uo.Role)})
+		}
+	}
+	return orgs
 }
 
 func (s *authService) Verify2FA(ctx context.Context, userID int32, code string, tempPwd bool) (string, string, *domain.User, bool, error) {
-	logger.EnterMethod("authService.Verify2FA", "userID", userID, "codeProvided", code, "tempPwd", tempPwd)
+	logger.EnterMethodContext(ctx, "authService.Verify2FA", "userID", userID, "codeProvided", code, "tempPwd", tempPwd)
 
 	// Retrieve and validate the stored 2FA code for this user.
 	expected, ok := s.pending2FACodes.Load(userID)
 	if !ok {
 		logger.Warn("No pending 2FA code found", "userID", userID)
-		logger.ExitMethodWithError("authService.Verify2FA", ErrInvalid2FACode, "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "authService.Verify2FA", ErrInvalid2FACode, "userID", userID)
 		return "", "", nil, false, ErrInvalid2FACode
 	}
 	logger.Debug("Validating 2FA code", "userID", userID)
 	if code != expected.(string) {
 		logger.Warn("2FA code validation FAILED", "userID", userID)
-		logger.ExitMethodWithError("authService.Verify2FA", ErrInvalid2FACode, "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "authService.Verify2FA", ErrInvalid2FACode, "userID", userID)
 		return "", "", nil, false, ErrInvalid2FACode
 	}
 	// Delete after successful validation to prevent code reuse.
@@ -359,35 +577,29 @@ func (s *authService) Verify2FA(ctx context.Context, userID int32, code string,
 	logger.Debug("Verifying user exists", "userID", userID)
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		logger.ExitMethodWithError("authService.Verify2FA", err, "reason", "user not found", "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "authService.Verify2FA", err, "reason", "user not found", "userID", userID)
 		return "", "", nil, false, err
 	}
 
 	// Generate tokens
 	logger.Debug("Generating access and refresh tokens", "userID", userID)
-	// TODO: Retrieve actual roles from database
-	access, err := s.tm.GenerateAccessToken(userID, user.Email, []string{"user"})
-	if err != nil {
-		logger.ExitMethodWithError("authService.Verify2FA", err, "reason", "failed to generate access token")
-		return "", "", nil, false, err
-	}
-	refresh, err := s.tm.GenerateRefreshToken(userID, user.Email)
+	access, refresh, err := s.issueTokens(ctx, user)
 	if err != nil {
-		logger.ExitMethodWithError("authService.Verify2FA", err, "reason", "failed to generate refresh token")
+		logger.ExitMethodWithErrorContext(ctx, "authService.Verify2FA", err, "reason", "failed to generate tokens")
 		return "", "", nil, false, err
 	}
 
 	logger.Info("2FA verification completed successfully", "userID", userID, "resetPassword", tempPwd)
-	logger.ExitMethod("authService.Verify2FA", "userID", userID)
+	logger.ExitMethodContext(ctx, "authService.Verify2FA", "userID", userID)
 	return access, refresh, user, tempPwd, nil
 }
 
 func (s *authService) ChangePassword(ctx context.Context, userID int32, oldPassword, newPassword string) error {
-	logger.EnterMethod("authService.ChangePassword", "userID", userID)
+	logger.EnterMethodContext(ctx, "authService.ChangePassword", "userID", userID)
 
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		logger.ExitMethodWithError("authService.ChangePassword", err, "reason", "user not found")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ChangePassword", err, "reason", "user not found")
 		return ErrInvalidCredentials
 	}
 
@@ -397,11 +609,11 @@ func (s *authService) ChangePassword(ctx context.Context, userID int32, oldPassw
 		// Check pending credential
 		cred, credErr := s.pendingCredsRepo.GetByUserID(ctx, userID)
 		if credErr != nil || cred == nil || cred.UsedAt != nil || cred.ExpiresAt.Before(time.Now()) {
-			logger.ExitMethodWithError("authService.ChangePassword", ErrInvalidCredentials, "reason", "old password mismatch")
+			logger.ExitMethodWithErrorContext(ctx, "authService.ChangePassword", ErrInvalidCredentials, "reason", "old password mismatch")
 			return ErrInvalidCredentials
 		}
 		if bcrypt.CompareHashAndPassword([]byte(cred.TempPasswordHash), []byte(oldPassword)) != nil {
-			logger.ExitMethodWithError("authService.ChangePassword", ErrInvalidCredentials, "reason", "old password mismatch")
+			logger.ExitMethodWithErrorContext(ctx, "authService.ChangePassword", ErrInvalidCredentials, "reason", "old password mismatch")
 			return ErrInvalidCredentials
 		}
 	}
@@ -409,11 +621,11 @@ func (s *authService) ChangePassword(ctx context.Context, userID int32, oldPassw
 	// Hash and store the new password.
 	newHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		logger.ExitMethodWithError("authService.ChangePassword", err, "reason", "bcrypt error")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ChangePassword", err, "reason", "bcrypt error")
 		return err
 	}
 	if err := s.userRepo.UpdatePassword(ctx, userID, string(newHash)); err != nil {
-		logger.ExitMethodWithError("authService.ChangePassword", err, "reason", "failed to update password")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ChangePassword", err, "reason", "failed to update password")
 		return err
 	}
 
@@ -424,12 +636,12 @@ func (s *authService) ChangePassword(ctx context.Context, userID int32, oldPassw
 	}
 
 	logger.Info("Password changed successfully", "userID", userID)
-	logger.ExitMethod("authService.ChangePassword", "userID", userID)
+	logger.ExitMethodContext(ctx, "authService.ChangePassword", "userID", userID)
 	return nil
 }
 
 func (s *authService) ResetPassword(ctx context.Context, email string) error {
-	logger.EnterMethod("authService.ResetPassword", "email", email)
+	logger.EnterMethodContext(ctx, "authService.ResetPassword", "email", email)
 
 	// Validate the email exists in users table.
 	user, err := s.userRepo.GetByEmail(ctx, email)
@@ -442,7 +654,7 @@ func (s *authService) ResetPassword(ctx context.Context, email string) error {
 	// Generate a secure random temporary password (16 hex chars = 8 bytes).
 	rawBytes := make([]byte, 8)
 	if _, err := rand.Read(rawBytes); err != nil {
-		logger.ExitMethodWithError("authService.ResetPassword", err, "reason", "failed to generate temp password")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ResetPassword", err, "reason", "failed to generate temp password")
 		return err
 	}
 	tempPassword := hex.EncodeToString(rawBytes)
@@ -450,7 +662,7 @@ func (s *authService) ResetPassword(ctx context.Context, email string) error {
 	// Hash the temporary password before storing.
 	tempHash, err := bcrypt.GenerateFromPassword([]byte(tempPassword), bcrypt.DefaultCost)
 	if err != nil {
-		logger.ExitMethodWithError("authService.ResetPassword", err, "reason", "bcrypt error")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ResetPassword", err, "reason", "bcrypt error")
 		return err
 	}
 
@@ -461,7 +673,7 @@ func (s *authService) ResetPassword(ctx context.Context, email string) error {
 		UsedAt:           nil,
 	}
 	if err := s.pendingCredsRepo.Upsert(ctx, cred); err != nil {
-		logger.ExitMethodWithError("authService.ResetPassword", err, "reason", "failed to upsert pending_credentials")
+		logger.ExitMethodWithErrorContext(ctx, "authService.ResetPassword", err, "reason", "failed to upsert pending_credentials")
 		return err
 	}
 
@@ -479,7 +691,7 @@ func (s *authService) ResetPassword(ctx context.Context, email string) error {
 	}
 
 	logger.Info("Password reset credential created and emailed", "userID", user.ID)
-	logger.ExitMethod("authService.ResetPassword", "userID", user.ID)
+	logger.ExitMethodContext(ctx, "authService.ResetPassword", "userID", user.ID)
 	return nil
 }
 
@@ -493,13 +705,29 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (st
 		return "", "", ErrInvalidToken
 	}
 
-	// Preserve email from existing token
-	access, err := s.tm.GenerateAccessToken(claims.UserID, claims.Email, claims.Roles)
+	// Reject tokens revoked at logout or issued before the last RevokeAllSessions
+	revoked, err := s.revokedRepo.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return "", "", ErrTokenRevoked
+	}
+	version, err := s.userRepo.GetTokenVersion(ctx, claims.UserID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get token version: %w", err)
+	}
+	if claims.TokenVersion < version {
+		return "", "", ErrTokenRevoked
+	}
+
+	// Preserve email from existing token; memberships are re-read so a refresh renews the snapshot
+	access, err := s.tm.GenerateAccessToken(claims.UserID, claims.Email, claims.Roles, s.orgClaims(ctx, claims.UserID))
 	if err != nil {
 		return "", "", err
 	}
 
-	refresh, err := s.tm.GenerateRefreshToken(claims.UserID, claims.Email)
+	refresh, err := s.tm.GenerateRefreshToken(claims.UserID, claims.Email, version)
 	if err != nil {
 		return "", "", err
 	}
@@ -508,6 +736,17 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (st
 }
 
 func (s *authService) Logout(ctx context.Context, userID int32, refresh, androidDeviceID string) error {
+	// Revoke the session's refresh token so it can no longer mint access tokens.
+	// Tokens that are already invalid or belong to another user are ignored.
+	if refresh != "" {
+		claims, err := s.tm.ValidateToken(refresh)
+		if err == nil && claims.Type == security.TokenTypeRefresh && claims.UserID == userID {
+			if err := s.revokedRepo.Revoke(ctx, claims.ID, userID, claims.ExpiresAt.Time); err != nil {
+				return fmt.Errorf("failed to revoke refresh token: %w", err)
+			}
+		}
+	}
+
 	// Mark the device's FCM tokens as OBSOLETE so push notifications are no longer
 	// routed to a logged-out device.
 	if s.fcmRepo != nil && androidDeviceID != "" {
@@ -518,3 +757,11 @@ func (s *authService) Logout(ctx context.Context, userID int32, refresh, android
 	}
 	return nil
 }
+
+func (s *authService) RevokeAllSessions(ctx context.Context, userID int32) error {
+	if _, err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	logger.Info("Revoked all sessions", "userID", userID)
+	return nil
+}
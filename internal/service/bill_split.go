@@ -2,45 +2,82 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/utils"
 )
 
+// DefaultSettlementThresholdCents is the settlement threshold for orgs without their own
+// when BillSplitOptions does not set one
+const DefaultSettlementThresholdCents int32 = 500
+
+// BillSplitOptions holds the configurable bill splitting settings
+type BillSplitOptions struct {
+	DefaultSettlementThresholdCents int32 // Used for orgs with no threshold of their own; <= 0 uses DefaultSettlementThresholdCents
+}
+
 type billSplitService struct {
-	billRepo repository.BillRepository
-	userRepo repository.UserRepository
-	orgRepo  repository.OrganizationRepository
-	noteSvc  NotificationService
-	emailSvc EmailService
+	billRepo              repository.BillRepository
+	userRepo              repository.UserRepository
+	ledgerRepo            repository.LedgerRepository
+	orgRepo               repository.OrganizationRepository
+	noteSvc               NotificationService
+	emailSvc              EmailService
+	audit                 AdminAudit
+	defaultThresholdCents int32
 }
 
 func NewBillSplitService(
 	billRepo repository.BillRepository,
 	userRepo repository.UserRepository,
+	ledgerRepo repository.LedgerRepository,
+	orgRepo repository.OrganizationRepository,
+	noteSvc NotificationService,
+	emailSvc EmailService,
+	audit AdminAudit,
+) BillSplitService {
+	return NewBillSplitServiceWithOptions(billRepo, userRepo, ledgerRepo, orgRepo, noteSvc, emailSvc, audit, BillSplitOptions{})
+}
+
+// NewBillSplitServiceWithOptions is NewBillSplitService with explicit billing settings
+func NewBillSplitServiceWithOptions(
+	billRepo repository.BillRepository,
+	userRepo repository.UserRepository,
+	ledgerRepo repository.LedgerRepository,
 	orgRepo repository.OrganizationRepository,
 	noteSvc NotificationService,
 	emailSvc EmailService,
+	audit AdminAudit,
+	opts BillSplitOptions,
 ) BillSplitService {
+	if opts.DefaultSettlementThresholdCents <= 0 {
+		opts.DefaultSettlementThresholdCents = DefaultSettlementThresholdCents
+	}
 	return &billSplitService{
-		billRepo: billRepo,
-		userRepo: userRepo,
-		orgRepo:  orgRepo,
-		noteSvc:  noteSvc,
-		emailSvc: emailSvc,
+		billRepo:              billRepo,
+		userRepo:              userRepo,
+		ledgerRepo:            ledgerRepo,
+		orgRepo:               orgRepo,
+		noteSvc:               noteSvc,
+		emailSvc:              emailSvc,
+		audit:                 audit,
+		defaultThresholdCents: opts.DefaultSettlementThresholdCents,
 	}
 }
 
 func (s *billSplitService) GetGlobalBillSplitSummary(ctx context.Context, userID int32) (int32, int32, int32, int32, error) {
-	logger.EnterMethod("billSplitService.GetGlobalBillSplitSummary", "userID", userID)
+	logger.EnterMethodContext(ctx, "billSplitService.GetGlobalBillSplitSummary", "userID", userID)
 
 	// Get all organizations for the user
 	userOrgs, err := s.userRepo.ListUserOrgs(ctx, userID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.GetGlobalBillSplitSummary", err, "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetGlobalBillSplitSummary", err, "userID", userID)
 		return 0, 0, 0, 0, err
 	}
 
@@ -57,7 +94,7 @@ func (s *billSplitService) GetGlobalBillSplitSummary(ctx context.Context, userID
 		receiptsInDispute += rd
 	}
 
-	logger.ExitMethod("billSplitService.GetGlobalBillSplitSummary", "userID", userID,
+	logger.ExitMethodContext(ctx, "billSplitService.GetGlobalBillSplitSummary", "userID", userID,
 		"paymentsToMake", paymentsToMake, "receiptsToVerify", receiptsToVerify,
 		"paymentsInDispute", paymentsInDispute, "receiptsInDispute", receiptsInDispute)
 
@@ -65,12 +102,12 @@ func (s *billSplitService) GetGlobalBillSplitSummary(ctx context.Context, userID
 }
 
 func (s *billSplitService) GetOrganizationBillSplitSummary(ctx context.Context, userID int32) ([]domain.Organization, []int32, []int32, []int32, []int32, error) {
-	logger.EnterMethod("billSplitService.GetOrganizationBillSplitSummary", "userID", userID)
+	logger.EnterMethodContext(ctx, "billSplitService.GetOrganizationBillSplitSummary", "userID", userID)
 
 	// Get all organizations for the user
 	userOrgs, err := s.userRepo.ListUserOrgs(ctx, userID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.GetOrganizationBillSplitSummary", err, "userID", userID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetOrganizationBillSplitSummary", err, "userID", userID)
 		return nil, nil, nil, nil, nil, err
 	}
 
@@ -98,13 +135,13 @@ func (s *billSplitService) GetOrganizationBillSplitSummary(ctx context.Context,
 		receiptsInDispute = append(receiptsInDispute, rd)
 	}
 
-	logger.ExitMethod("billSplitService.GetOrganizationBillSplitSummary", "userID", userID, "orgCount", len(orgs))
+	logger.ExitMethodContext(ctx, "billSplitService.GetOrganizationBillSplitSummary", "userID", userID, "orgCount", len(orgs))
 	return orgs, paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute, nil
 }
 
 func (s *billSplitService) getOrgSummary(ctx context.Context, userID, orgID int32) (int32, int32, int32, int32, error) {
 	// Get all bills for this user in this org
-	bills, err := s.billRepo.ListByUser(ctx, userID, orgID, nil)
+	bills, _, err := s.billRepo.ListByUser(ctx, userID, orgID, nil, 0, 0)
 	if err != nil {
 		return 0, 0, 0, 0, err
 	}
@@ -112,75 +149,58 @@ func (s *billSplitService) getOrgSummary(ctx context.Context, userID, orgID int3
 	var paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute int32
 
 	for _, bill := range bills {
-		isDebtor := bill.DebtorUserID == userID
-		isCreditor := bill.CreditorUserID == userID
-
-		switch bill.Status {
-		case domain.BillStatusPending:
-			if isDebtor && bill.DebtorAcknowledgedAt == nil {
-				paymentsToMake++
-			} else if isCreditor && bill.DebtorAcknowledgedAt != nil {
-				receiptsToVerify++
-			}
-		case domain.BillStatusDisputed:
-			if isDebtor {
-				paymentsInDispute++
-			} else if isCreditor {
-				receiptsInDispute++
-			}
+		switch bill.GetPaymentCategory(userID) {
+		case domain.PaymentCategoryPaymentToMake:
+			paymentsToMake++
+		case domain.PaymentCategoryReceiptToVerify:
+			receiptsToVerify++
+		case domain.PaymentCategoryPaymentInDispute:
+			paymentsInDispute++
+		case domain.PaymentCategoryReceiptInDispute:
+			receiptsInDispute++
 		}
 	}
 
 	return paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute, nil
 }
 
-func (s *billSplitService) ListPayments(ctx context.Context, userID, orgID int32, showHistory bool) ([]domain.Bill, error) {
-	logger.EnterMethod("billSplitService.ListPayments", "userID", userID, "orgID", orgID, "showHistory", showHistory)
+func (s *billSplitService) ListPayments(ctx context.Context, userID, orgID int32, showHistory bool, page, pageSize int32) ([]domain.Bill, int32, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.ListPayments", "userID", userID, "orgID", orgID, "showHistory", showHistory, "page", page, "pageSize", pageSize)
 
 	// Verify user is a member of the organization
 	userOrg, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.ListPayments", err, "userID", userID, "orgID", orgID)
-		return nil, fmt.Errorf("user is not a member of this organization")
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListPayments", err, "userID", userID, "orgID", orgID)
+		return nil, 0, domain.Unauthorizedf("user is not a member of this organization")
 	}
 	if userOrg == nil {
-		return nil, fmt.Errorf("user is not a member of this organization")
+		return nil, 0, domain.Unauthorizedf("user is not a member of this organization")
 	}
 
-	// Get all bills for this user in this org
-	var bills []domain.Bill
+	// Get one page of bills for this user in this org. History holds the bills that are
+	// COMPLETED for both parties, the active list everything still open.
+	statuses := domain.OpenBillStatuses()
 	if showHistory {
-		// Return completed bills
-		bills, err = s.billRepo.ListByUser(ctx, userID, orgID, []domain.BillStatus{
-			domain.BillStatusPaid,
-			domain.BillStatusAdminResolved,
-			domain.BillStatusSystemDefaultAction,
-		})
-	} else {
-		// Return active bills (pending, disputed)
-		bills, err = s.billRepo.ListByUser(ctx, userID, orgID, []domain.BillStatus{
-			domain.BillStatusPending,
-			domain.BillStatusDisputed,
-		})
+		statuses = domain.ClosedBillStatuses()
 	}
-
+	bills, total, err := s.billRepo.ListByUser(ctx, userID, orgID, statuses, page, pageSize)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.ListPayments", err, "userID", userID, "orgID", orgID)
-		return nil, err
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListPayments", err, "userID", userID, "orgID", orgID)
+		return nil, 0, err
 	}
 
-	logger.ExitMethod("billSplitService.ListPayments", "userID", userID, "orgID", orgID, "count", len(bills))
-	return bills, nil
+	logger.ExitMethodContext(ctx, "billSplitService.ListPayments", "userID", userID, "orgID", orgID, "count", len(bills), "total", total)
+	return bills, total, nil
 }
 
-func (s *billSplitService) GetPaymentDetail(ctx context.Context, userID, paymentID int32) (*domain.Bill, []domain.BillAction, bool, error) {
-	logger.EnterMethod("billSplitService.GetPaymentDetail", "userID", userID, "paymentID", paymentID)
+func (s *billSplitService) GetPaymentDetail(ctx context.Context, userID, paymentID int32, filter domain.BillActionFilter) (*domain.Bill, []domain.BillAction, int32, bool, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.GetPaymentDetail", "userID", userID, "paymentID", paymentID)
 
 	// Get the bill
 	bill, err := s.billRepo.GetByID(ctx, paymentID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.GetPaymentDetail", err, "paymentID", paymentID)
-		return nil, nil, false, err
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetPaymentDetail", err, "paymentID", paymentID)
+		return nil, nil, 0, false, err
 	}
 
 	// Verify user is involved (debtor, creditor, or admin)
@@ -189,18 +209,18 @@ func (s *billSplitService) GetPaymentDetail(ctx context.Context, userID, payment
 		// Check if user is admin
 		userOrg, err := s.userRepo.GetUserOrg(ctx, userID, bill.OrgID)
 		if err != nil || userOrg == nil {
-			return nil, nil, false, fmt.Errorf("unauthorized to view this payment")
+			return nil, nil, 0, false, domain.Unauthorizedf("unauthorized to view this payment")
 		}
 		if userOrg.Role != domain.UserOrgRoleAdmin && userOrg.Role != domain.UserOrgRoleSuperAdmin {
-			return nil, nil, false, fmt.Errorf("unauthorized to view this payment")
+			return nil, nil, 0, false, domain.Unauthorizedf("unauthorized to view this payment")
 		}
 	}
 
-	// Get bill actions history
-	actions, err := s.billRepo.ListActionsByBill(ctx, paymentID)
+	// Get one page of the bill actions history
+	actions, totalActions, err := s.billRepo.ListActionsByBill(ctx, paymentID, filter)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.GetPaymentDetail", err, "paymentID", paymentID)
-		return nil, nil, false, err
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetPaymentDetail", err, "paymentID", paymentID)
+		return nil, nil, 0, false, err
 	}
 
 	// Determine if user can acknowledge
@@ -211,16 +231,16 @@ func (s *billSplitService) GetPaymentDetail(ctx context.Context, userID, payment
 		canAcknowledge = true
 	}
 
-	logger.ExitMethod("billSplitService.GetPaymentDetail", "paymentID", paymentID, "canAcknowledge", canAcknowledge)
-	return bill, actions, canAcknowledge, nil
+	logger.ExitMethodContext(ctx, "billSplitService.GetPaymentDetail", "paymentID", paymentID, "canAcknowledge", canAcknowledge)
+	return bill, actions, totalActions, canAcknowledge, nil
 }
 
 func (s *billSplitService) AcknowledgePayment(ctx context.Context, userID, paymentID int32) error {
-	logger.EnterMethod("billSplitService.AcknowledgePayment", "userID", userID, "paymentID", paymentID)
+	logger.EnterMethodContext(ctx, "billSplitService.AcknowledgePayment", "userID", userID, "paymentID", paymentID)
 
 	bill, err := s.billRepo.GetByID(ctx, paymentID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.AcknowledgePayment", err, "paymentID", paymentID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.AcknowledgePayment", err, "paymentID", paymentID)
 		return err
 	}
 
@@ -237,135 +257,282 @@ func (s *billSplitService) AcknowledgePayment(ctx context.Context, userID, payme
 	case bill.CreditorUserID == userID:
 		err = s.acknowledgeAsCreditor(ctx, bill, user, now)
 	default:
-		return fmt.Errorf("user is not involved in this payment")
+		return domain.Unauthorizedf("user is not involved in this payment")
 	}
 
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.AcknowledgePayment", err, "paymentID", paymentID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.AcknowledgePayment", err, "paymentID", paymentID)
 		return err
 	}
 
-	logger.ExitMethod("billSplitService.AcknowledgePayment", "paymentID", paymentID, "success", true)
+	logger.ExitMethodContext(ctx, "billSplitService.AcknowledgePayment", "paymentID", paymentID, "success", true)
 	return nil
 }
 
-func (s *billSplitService) updateBalances(ctx context.Context, bill *domain.Bill) error {
-	// Update creditor's balance (add amount)
-	creditorUserOrg, err := s.userRepo.GetUserOrg(ctx, bill.CreditorUserID, bill.OrgID)
+// VoidBill cancels a pending bill once both parties ask for it. The first call records the
+// request; the other party's call voids the bill. No balances move either way.
+func (s *billSplitService) VoidBill(ctx context.Context, userID, paymentID int32, notes string) error {
+	logger.EnterMethodContext(ctx, "billSplitService.VoidBill", "userID", userID, "paymentID", paymentID)
+
+	bill, err := s.billRepo.GetByID(ctx, paymentID)
 	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.VoidBill", err, "paymentID", paymentID)
 		return err
 	}
-	creditorUserOrg.BalanceCents += bill.AmountCents
-	nowDate := time.Now().Format("2006-01-02")
-	creditorUserOrg.LastBalanceUpdateOn = &nowDate
-	if err := s.userRepo.UpdateUserOrg(ctx, creditorUserOrg); err != nil {
+
+	if bill.DebtorUserID != userID && bill.CreditorUserID != userID {
+		return domain.Unauthorizedf("user is not involved in this payment")
+	}
+	if bill.Status != domain.BillStatusPending {
+		return domain.Conflictf("only pending payments can be voided")
+	}
+	if bill.DebtorAcknowledgedAt != nil {
+		return domain.Conflictf("payment already acknowledged by debtor")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
 		return err
 	}
 
-	// Update debtor's balance (subtract amount)
-	debtorUserOrg, err := s.userRepo.GetUserOrg(ctx, bill.DebtorUserID, bill.OrgID)
+	now := time.Now()
+	switch {
+	case bill.VoidRequestedBy == nil:
+		err = s.requestVoid(ctx, bill, user, notes, now)
+	case *bill.VoidRequestedBy == userID:
+		err = domain.Conflictf("void already requested, waiting for the other party")
+	default:
+		err = s.confirmVoid(ctx, bill, user, notes, now)
+	}
+
 	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.VoidBill", err, "paymentID", paymentID)
+		return err
+	}
+
+	logger.ExitMethodContext(ctx, "billSplitService.VoidBill", "paymentID", paymentID, "status", bill.Status)
+	return nil
+}
+
+// updateBalances moves the bill amount from debtor to creditor. snapshots may be nil.
+func (s *billSplitService) updateBalances(ctx context.Context, bill *domain.Bill, snapshots *disputeAuditSnapshots) error {
+	// Credit the creditor, then debit the debtor
+	description := fmt.Sprintf("Settlement of bill %d for %s", bill.ID, bill.SettlementMonth)
+	if err := s.adjustMemberBalance(ctx, bill.CreditorUserID, bill.OrgID, bill.AmountCents, domain.TransactionTypeBillSettlement, description, "creditor_", snapshots); err != nil {
 		return err
 	}
-	debtorUserOrg.BalanceCents -= bill.AmountCents
-	debtorUserOrg.LastBalanceUpdateOn = &nowDate
-	if err := s.userRepo.UpdateUserOrg(ctx, debtorUserOrg); err != nil {
+	return s.adjustMemberBalance(ctx, bill.DebtorUserID, bill.OrgID, -bill.AmountCents, domain.TransactionTypeBillSettlement, description, "debtor_", snapshots)
+}
+
+// adjustMemberBalance records delta in the member's ledger; the ledger trigger applies it to
+// balance_cents with an atomic increment, so concurrent changes are not lost. The membership
+// is only read when snapshots are being collected.
+func (s *billSplitService) adjustMemberBalance(ctx context.Context, userID, orgID, delta int32, txType domain.TransactionType, description, prefix string, snapshots *disputeAuditSnapshots) error {
+	var userOrg *domain.UserOrg
+	if snapshots != nil {
+		var err error
+		if userOrg, err = s.userRepo.GetUserOrg(ctx, userID, orgID); err != nil {
+			return err
+		}
+		snapshots.captureBefore(prefix, userOrg)
+	}
+	if err := s.recordBalanceChange(ctx, userID, orgID, delta, txType, description); err != nil {
 		return err
 	}
+	if userOrg != nil {
+		if err := s.refreshBalance(ctx, userOrg); err != nil {
+			return err
+		}
+		snapshots.captureAfter(prefix, userOrg)
+	}
+	return nil
+}
 
+// recordBalanceChange writes a ledger entry for a balance change made by bill settlement or
+// a dispute penalty, so the ledger keeps summing to users_orgs.balance_cents
+func (s *billSplitService) recordBalanceChange(ctx context.Context, userID, orgID, delta int32, txType domain.TransactionType, description string) error {
+	return s.ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+		OrgID:       orgID,
+		UserID:      userID,
+		Amount:      delta,
+		Type:        txType,
+		Description: description,
+	})
+}
+
+// refreshBalance reloads the balance the ledger trigger left on userOrg
+func (s *billSplitService) refreshBalance(ctx context.Context, userOrg *domain.UserOrg) error {
+	balance, err := s.ledgerRepo.GetBalance(ctx, userOrg.UserID, userOrg.OrgID)
+	if err != nil {
+		return err
+	}
+	userOrg.BalanceCents = balance
+	nowDate := time.Now().Format("2006-01-02")
+	userOrg.LastBalanceUpdateOn = &nowDate
 	return nil
 }
 
 func (s *billSplitService) ListDisputedPayments(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error) {
-	logger.EnterMethod("billSplitService.ListDisputedPayments", "adminID", adminID, "orgID", orgID)
+	logger.EnterMethodContext(ctx, "billSplitService.ListDisputedPayments", "adminID", adminID, "orgID", orgID)
 
 	if err := s.verifyAdminRights(ctx, adminID, orgID); err != nil {
-		logger.ExitMethodWithError("billSplitService.ListDisputedPayments", err, "adminID", adminID, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListDisputedPayments", err, "adminID", adminID, "orgID", orgID)
 		return nil, err
 	}
 
 	// Get disputed bills, excluding those involving this admin
 	bills, err := s.billRepo.ListDisputedByOrg(ctx, orgID, &adminID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.ListDisputedPayments", err, "adminID", adminID, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListDisputedPayments", err, "adminID", adminID, "orgID", orgID)
 		return nil, err
 	}
 
-	logger.ExitMethod("billSplitService.ListDisputedPayments", "adminID", adminID, "orgID", orgID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billSplitService.ListDisputedPayments", "adminID", adminID, "orgID", orgID, "count", len(bills))
 	return bills, nil
 }
 
+// GetDisputeDetail returns a disputed bill with its whole action history and both parties'
+// profiles. Like ResolveDispute, it is closed to admins who are a party to the bill.
+func (s *billSplitService) GetDisputeDetail(ctx context.Context, adminID, paymentID int32) (*domain.DisputeDetail, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.GetDisputeDetail", "adminID", adminID, "paymentID", paymentID)
+
+	bill, err := s.billRepo.GetByID(ctx, paymentID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetDisputeDetail", err, "paymentID", paymentID)
+		return nil, err
+	}
+
+	if err := s.verifyAdminRights(ctx, adminID, bill.OrgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetDisputeDetail", err, "adminID", adminID, "orgID", bill.OrgID)
+		return nil, err
+	}
+	if bill.DebtorUserID == adminID || bill.CreditorUserID == adminID {
+		return nil, domain.Unauthorizedf("admins cannot review disputes they are involved in")
+	}
+	if bill.DisputedAt == nil {
+		return nil, domain.Conflictf("payment has not been disputed")
+	}
+
+	detail := &domain.DisputeDetail{Bill: *bill}
+	// Read the history a full page at a time; disputes rarely have more than a handful of actions
+	filter := domain.BillActionFilter{Page: 1, PageSize: utils.MaxPageSize}
+	for {
+		actions, total, err := s.billRepo.ListActionsByBill(ctx, paymentID, filter)
+		if err != nil {
+			logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetDisputeDetail", err, "paymentID", paymentID)
+			return nil, err
+		}
+		detail.Actions = append(detail.Actions, actions...)
+		if len(actions) == 0 || int32(len(detail.Actions)) >= total {
+			break
+		}
+		filter.Page++
+	}
+
+	if detail.Debtor, err = s.disputeParty(ctx, bill.DebtorUserID, bill.OrgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetDisputeDetail", err, "debtorID", bill.DebtorUserID)
+		return nil, err
+	}
+	if detail.Creditor, err = s.disputeParty(ctx, bill.CreditorUserID, bill.OrgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetDisputeDetail", err, "creditorID", bill.CreditorUserID)
+		return nil, err
+	}
+
+	logger.ExitMethodContext(ctx, "billSplitService.GetDisputeDetail", "paymentID", paymentID, "actions", len(detail.Actions))
+	return detail, nil
+}
+
+func (s *billSplitService) disputeParty(ctx context.Context, userID, orgID int32) (domain.DisputeParty, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return domain.DisputeParty{}, err
+	}
+	userOrg, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return domain.DisputeParty{}, err
+	}
+	return domain.DisputeParty{User: *user, Membership: *userOrg}, nil
+}
+
 func (s *billSplitService) ListResolvedDisputes(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error) {
-	logger.EnterMethod("billSplitService.ListResolvedDisputes", "adminID", adminID, "orgID", orgID)
+	logger.EnterMethodContext(ctx, "billSplitService.ListResolvedDisputes", "adminID", adminID, "orgID", orgID)
 
 	if err := s.verifyAdminRights(ctx, adminID, orgID); err != nil {
-		logger.ExitMethodWithError("billSplitService.ListResolvedDisputes", err, "adminID", adminID, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListResolvedDisputes", err, "adminID", adminID, "orgID", orgID)
 		return nil, err
 	}
 
 	bills, err := s.billRepo.ListResolvedDisputesByOrg(ctx, orgID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.ListResolvedDisputes", err, "adminID", adminID, "orgID", orgID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ListResolvedDisputes", err, "adminID", adminID, "orgID", orgID)
 		return nil, err
 	}
 
-	logger.ExitMethod("billSplitService.ListResolvedDisputes", "adminID", adminID, "orgID", orgID, "count", len(bills))
+	logger.ExitMethodContext(ctx, "billSplitService.ListResolvedDisputes", "adminID", adminID, "orgID", orgID, "count", len(bills))
 	return bills, nil
 }
 
 func (s *billSplitService) ResolveDispute(ctx context.Context, adminID, paymentID int32, resolution, notes string) error {
-	logger.EnterMethod("billSplitService.ResolveDispute", "adminID", adminID, "paymentID", paymentID, "resolution", resolution, "notes", notes)
+	logger.EnterMethodContext(ctx, "billSplitService.ResolveDispute", "adminID", adminID, "paymentID", paymentID, "resolution", resolution, "notes", notes)
 
 	bill, err := s.billRepo.GetByID(ctx, paymentID)
 	if err != nil {
-		logger.ExitMethodWithError("billSplitService.ResolveDispute", err, "paymentID", paymentID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ResolveDispute", err, "paymentID", paymentID)
 		return err
 	}
 
 	if err := s.verifyAdminRights(ctx, adminID, bill.OrgID); err != nil {
-		logger.ExitMethodWithError("billSplitService.ResolveDispute", err, "adminID", adminID, "orgID", bill.OrgID)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ResolveDispute", err, "adminID", adminID, "orgID", bill.OrgID)
 		return err
 	}
 
 	if bill.DebtorUserID == adminID || bill.CreditorUserID == adminID {
-		return fmt.Errorf("admins cannot resolve disputes they are involved in")
+		return domain.Unauthorizedf("admins cannot resolve disputes they are involved in")
 	}
 	if bill.Status != domain.BillStatusDisputed {
-		return fmt.Errorf("payment is not in disputed status")
+		return domain.Conflictf("payment is not in disputed status")
+	}
+
+	switch resolution {
+	case string(domain.ResolutionOutcomeDebtorFault), string(domain.ResolutionOutcomeCreditorFault),
+		string(domain.ResolutionOutcomeBothFault), string(domain.ResolutionOutcomeGraceful):
+	default:
+		return domain.Invalidf("invalid resolution type: %s", resolution)
 	}
 
+	notes = strings.TrimSpace(notes)
 	now := time.Now()
+	snapshots := newDisputeAuditSnapshots(bill)
 	bill.Status = domain.BillStatusAdminResolved
 	bill.ResolvedAt = &now
 	bill.ResolutionOutcome = resolution
-	bill.ResolutionNotes = notes
+	bill.ResolutionNotes = disputeResolutionNotes(resolution, notes)
+
+	// Claim the resolution first so a concurrent acknowledgement (stale version) cannot
+	// race us into applying balance changes twice
+	if err := s.billRepo.Update(ctx, bill); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.ResolveDispute", err, "paymentID", paymentID)
+		return wrapBillUpdateError(err)
+	}
 
 	switch resolution {
 	case string(domain.ResolutionOutcomeDebtorFault):
-		if err := s.updateBalances(ctx, bill); err != nil {
+		if err := s.updateBalances(ctx, bill, snapshots); err != nil {
 			return fmt.Errorf("failed to update balances: %w", err)
 		}
-		s.blockDebtorFromRenting(ctx, bill.DebtorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (debtor at fault)")
+		s.blockDebtorFromRenting(ctx, bill.DebtorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (debtor at fault)", snapshots)
 
 	case string(domain.ResolutionOutcomeCreditorFault):
-		s.penalizeAndBlockCreditorFromLending(ctx, bill.CreditorUserID, bill.OrgID, bill, "Blocked due to dispute resolution (creditor at fault)")
+		s.penalizeAndBlockCreditorFromLending(ctx, bill.CreditorUserID, bill.OrgID, bill, "Blocked due to dispute resolution (creditor at fault)", snapshots)
 
 	case string(domain.ResolutionOutcomeBothFault):
-		s.penalizeAndBlockDebtorFromRenting(ctx, bill.DebtorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (both at fault)")
-		s.penalizeAndBlockCreditorFromLending(ctx, bill.CreditorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (both at fault)")
+		s.penalizeAndBlockDebtorFromRenting(ctx, bill.DebtorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (both at fault)", snapshots)
+		s.penalizeAndBlockCreditorFromLending(ctx, bill.CreditorUserID, bill.OrgID, bill, "Blocked due to unresolved payment dispute (both at fault)", snapshots)
 
 	case string(domain.ResolutionOutcomeGraceful):
-		if err := s.updateBalances(ctx, bill); err != nil {
+		if err := s.updateBalances(ctx, bill, snapshots); err != nil {
 			return fmt.Errorf("failed to update balances: %w", err)
 		}
-
-	default:
-		return fmt.Errorf("invalid resolution type: %s", resolution)
-	}
-
-	if err := s.billRepo.Update(ctx, bill); err != nil {
-		logger.ExitMethodWithError("billSplitService.ResolveDispute", err, "paymentID", paymentID)
-		return err
 	}
 
 	action := &domain.BillAction{
@@ -377,76 +544,327 @@ func (s *billSplitService) ResolveDispute(ctx context.Context, adminID, paymentI
 	}
 	_ = s.billRepo.CreateAction(ctx, action)
 
-	orgName := s.getOrgName(ctx, bill.OrgID)
+	if s.audit != nil {
+		snapshots.after["status"] = string(bill.Status)
+		s.audit.RecordChange(ctx, adminID, bill.OrgID, domain.AdminAuditActionResolveDispute, domain.AdminAuditTargetBill, bill.ID, map[string]string{
+			"resolution":   resolution,
+			"notes":        notes,
+			"amount_cents": fmt.Sprintf("%d", bill.AmountCents),
+		}, snapshots.before, snapshots.after)
+	}
+
+	orgName, currency := s.getOrgLabel(ctx, bill.OrgID)
 	debtor, _ := s.userRepo.GetByID(ctx, bill.DebtorUserID)
 	creditor, _ := s.userRepo.GetByID(ctx, bill.CreditorUserID)
 	if debtor != nil {
-		s.sendDisputeResolutionNotification(ctx, debtor, bill, resolution, notes, orgName)
+		s.sendDisputeResolutionNotification(ctx, debtor, bill, resolution, notes, orgName, currency)
 	}
 	if creditor != nil {
-		s.sendDisputeResolutionNotification(ctx, creditor, bill, resolution, notes, orgName)
+		s.sendDisputeResolutionNotification(ctx, creditor, bill, resolution, notes, orgName, currency)
 	}
 
-	logger.ExitMethod("billSplitService.ResolveDispute", "paymentID", paymentID, "success", true)
+	logger.ExitMethodContext(ctx, "billSplitService.ResolveDispute", "paymentID", paymentID, "success", true)
 	return nil
 }
 
-func (s *billSplitService) getOrgName(ctx context.Context, orgID int32) string {
+// disputeResolutionSystemNotes describes what an admin resolution did to each party, in the
+// same register as the notes auto_resolve_disputed_bills writes at month end
+var disputeResolutionSystemNotes = map[string]string{
+	string(domain.ResolutionOutcomeDebtorFault):   "Resolved by admin - debtor at fault, payment enforced and debtor blocked from renting",
+	string(domain.ResolutionOutcomeCreditorFault): "Resolved by admin - creditor at fault, creditor penalized and blocked from lending",
+	string(domain.ResolutionOutcomeBothFault):     "Resolved by admin - both parties at fault, both penalized and blocked from renting/lending",
+	string(domain.ResolutionOutcomeGraceful):      "Resolved by admin - settled gracefully, payment enforced",
+}
+
+// disputeResolutionNotes is the resolution_notes value: the system note for the outcome,
+// followed by the admin's own explanation when one was given
+func disputeResolutionNotes(resolution, adminNotes string) string {
+	system := disputeResolutionSystemNotes[resolution]
+	if adminNotes == "" {
+		return system
+	}
+	return system + ". Admin notes: " + adminNotes
+}
+
+// getOrgLabel returns the org's name and currency for notifications; a missing org
+// yields an empty name and the default currency
+func (s *billSplitService) getOrgLabel(ctx context.Context, orgID int32) (string, string) {
 	org, _ := s.orgRepo.GetByID(ctx, orgID)
 	if org != nil {
-		return org.Name
+		return org.Name, org.Currency()
+	}
+	return "", domain.DefaultCurrencyCode
+}
+
+// PreviewSettlement runs the bill splitting minimization over the org's current
+// balances without creating bills, so admins can see what the next settlement will be
+func (s *billSplitService) PreviewSettlement(ctx context.Context, adminID, orgID int32) (*domain.SettlementPreview, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.PreviewSettlement", "adminID", adminID, "orgID", orgID)
+
+	if err := s.verifyAdminRights(ctx, adminID, orgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.PreviewSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.PreviewSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	users, userOrgs, err := s.userRepo.ListMembersByOrg(ctx, orgID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.PreviewSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	// Same member selection as the bill splitting job: active members with a non-zero balance
+	names := make(map[int32]string, len(users))
+	var balances []utils.Account
+	for i, uo := range userOrgs {
+		if uo.Status != domain.UserOrgStatusActive || uo.BalanceCents == 0 {
+			continue
+		}
+		names[uo.UserID] = users[i].Name
+		balances = append(balances, utils.Account{UserID: int(uo.UserID), Balance: int(uo.BalanceCents)})
+	}
+
+	threshold := org.EffectiveSettlementThresholdCents(s.defaultThresholdCents)
+	transactions := utils.PlanSettlement(balances, int(threshold))
+	transfers := make([]domain.SettlementTransfer, len(transactions))
+	for i, txn := range transactions {
+		transfers[i] = domain.SettlementTransfer{
+			DebtorUserID:   int32(txn.FromUserID),
+			DebtorName:     names[int32(txn.FromUserID)],
+			CreditorUserID: int32(txn.ToUserID),
+			CreditorName:   names[int32(txn.ToUserID)],
+			AmountCents:    int32(txn.Amount),
+		}
+	}
+
+	preview := &domain.SettlementPreview{
+		OrgID:           orgID,
+		SettlementMonth: time.Now().Format("2006-01"), // The job settles the previous month on the 1st
+		ThresholdCents:  threshold,
+		Transfers:       transfers,
+	}
+
+	logger.ExitMethodContext(ctx, "billSplitService.PreviewSettlement", "adminID", adminID, "orgID", orgID, "transfers", len(transfers))
+	return preview, nil
+}
+
+// RunSettlement runs bill splitting for the org and a given month on demand, e.g. after the
+// scheduled run was missed or balances were corrected. Like the job it refuses a month that
+// already has bills.
+func (s *billSplitService) RunSettlement(ctx context.Context, adminID, orgID int32, month string) ([]domain.Bill, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.RunSettlement", "adminID", adminID, "orgID", orgID, "month", month)
+
+	if err := s.verifyAdminRights(ctx, adminID, orgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.RunSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	m, err := time.Parse("2006-01", month)
+	if err != nil || m.Format("2006-01") != month {
+		err = domain.Invalidf("invalid settlement month %q: expected YYYY-MM", month)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.RunSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+	if month > time.Now().Format("2006-01") {
+		err = domain.Invalidf("settlement month %s is in the future", month)
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.RunSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.RunSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	threshold := org.EffectiveSettlementThresholdCents(s.defaultThresholdCents)
+	bills, err := s.billRepo.CreateSettlementBills(ctx, orgID, month, threshold, false)
+	if errors.Is(err, repository.ErrSettlementAlreadyRun) {
+		err = domain.Conflictf("settlement for %s already ran", month)
+	}
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.RunSettlement", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionRunSettlement, domain.AdminAuditTargetOrganization, orgID, map[string]string{
+			"settlement_month": month,
+			"threshold_cents":  fmt.Sprintf("%d", threshold),
+			"bills_created":    fmt.Sprintf("%d", len(bills)),
+		})
+	}
+
+	logger.ExitMethodContext(ctx, "billSplitService.RunSettlement", "adminID", adminID, "orgID", orgID, "month", month, "billsCreated", len(bills))
+	return bills, nil
+}
+
+func (s *billSplitService) GetSettlementThreshold(ctx context.Context, adminID, orgID int32) (*domain.SettlementThreshold, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.GetSettlementThreshold", "adminID", adminID, "orgID", orgID)
+
+	if err := s.verifyAdminRights(ctx, adminID, orgID); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.GetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	threshold := s.settlementThreshold(org)
+	logger.ExitMethodContext(ctx, "billSplitService.GetSettlementThreshold", "adminID", adminID, "orgID", orgID, "thresholdCents", threshold.ThresholdCents)
+	return threshold, nil
+}
+
+func (s *billSplitService) SetSettlementThreshold(ctx context.Context, adminID, orgID int32, thresholdCents *int32) (*domain.SettlementThreshold, error) {
+	logger.EnterMethodContext(ctx, "billSplitService.SetSettlementThreshold", "adminID", adminID, "orgID", orgID)
+
+	// Same rule as UpdateOrganization: only SUPER_ADMIN may change payment thresholds
+	userOrg, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
+	if err != nil {
+		err = domain.Unauthorizedf("unauthorized: not a member of this organization")
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.SetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+	if userOrg.Role != domain.UserOrgRoleSuperAdmin {
+		err = domain.Unauthorizedf("unauthorized: only SUPER_ADMIN can modify payment threshold values")
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.SetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+	if thresholdCents != nil && *thresholdCents <= 0 {
+		err = domain.Invalidf("settlement threshold must be positive")
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.SetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	if err := s.orgRepo.SetSettlementThreshold(ctx, orgID, thresholdCents); err != nil {
+		logger.ExitMethodWithErrorContext(ctx, "billSplitService.SetSettlementThreshold", err, "adminID", adminID, "orgID", orgID)
+		return nil, err
+	}
+
+	org := &domain.Organization{ID: orgID}
+	detail := "default"
+	if thresholdCents != nil {
+		org.SettlementThresholdCents = *thresholdCents
+		detail = fmt.Sprintf("%d", *thresholdCents)
+	}
+	if s.audit != nil {
+		s.audit.Record(ctx, adminID, orgID, domain.AdminAuditActionSetThreshold, domain.AdminAuditTargetOrganization, orgID, map[string]string{
+			"settlement_threshold_cents": detail,
+		})
+	}
+
+	threshold := s.settlementThreshold(org)
+	logger.ExitMethodContext(ctx, "billSplitService.SetSettlementThreshold", "adminID", adminID, "orgID", orgID, "thresholdCents", threshold.ThresholdCents)
+	return threshold, nil
+}
+
+func (s *billSplitService) settlementThreshold(org *domain.Organization) *domain.SettlementThreshold {
+	return &domain.SettlementThreshold{
+		OrgID:          org.ID,
+		ThresholdCents: org.EffectiveSettlementThresholdCents(s.defaultThresholdCents),
+		UsesDefault:    org.SettlementThresholdCents <= 0,
+		DefaultCents:   s.defaultThresholdCents,
 	}
-	return ""
 }
 
 func (s *billSplitService) verifyAdminRights(ctx context.Context, adminID, orgID int32) error {
 	userOrg, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
 	if err != nil {
-		return fmt.Errorf("unauthorized: not a member of this organization")
+		return domain.Unauthorizedf("unauthorized: not a member of this organization")
 	}
 	if userOrg.Role != domain.UserOrgRoleAdmin && userOrg.Role != domain.UserOrgRoleSuperAdmin {
-		return fmt.Errorf("unauthorized: admin privileges required")
+		return domain.Unauthorizedf("unauthorized: admin privileges required")
 	}
 	return nil
 }
 
-func (s *billSplitService) blockDebtorFromRenting(ctx context.Context, debtorID, orgID int32, bill *domain.Bill, reason string) {
+func (s *billSplitService) blockDebtorFromRenting(ctx context.Context, debtorID, orgID int32, bill *domain.Bill, reason string, snapshots *disputeAuditSnapshots) {
 	userOrg, err := s.userRepo.GetUserOrg(ctx, debtorID, orgID)
 	if err == nil {
+		snapshots.captureBefore("debtor_", userOrg)
 		userOrg.RentingBlocked = true
 		userOrg.BlockedDueToBillID = &bill.ID
 		userOrg.BlockedReason = reason
-		_ = s.userRepo.UpdateUserOrg(ctx, userOrg)
+		if s.userRepo.UpdateUserOrg(ctx, userOrg) == nil {
+			snapshots.captureAfter("debtor_", userOrg)
+		}
 	}
 }
 
-func (s *billSplitService) penalizeAndBlockDebtorFromRenting(ctx context.Context, debtorID, orgID int32, bill *domain.Bill, reason string) {
+func (s *billSplitService) penalizeAndBlockDebtorFromRenting(ctx context.Context, debtorID, orgID int32, bill *domain.Bill, reason string, snapshots *disputeAuditSnapshots) {
 	userOrg, err := s.userRepo.GetUserOrg(ctx, debtorID, orgID)
 	if err == nil {
-		userOrg.BalanceCents -= bill.AmountCents
-		nowDate := time.Now().Format("2006-01-02")
-		userOrg.LastBalanceUpdateOn = &nowDate
+		snapshots.captureBefore("debtor_", userOrg)
+		description := fmt.Sprintf("Dispute penalty for bill %d for %s", bill.ID, bill.SettlementMonth)
+		if s.recordBalanceChange(ctx, debtorID, orgID, -bill.AmountCents, domain.TransactionTypeBillPenalty, description) == nil {
+			s.refreshBalance(ctx, userOrg) //nolint:errcheck // the snapshot keeps the old balance on failure
+		}
 		userOrg.RentingBlocked = true
 		userOrg.BlockedDueToBillID = &bill.ID
 		userOrg.BlockedReason = reason
-		_ = s.userRepo.UpdateUserOrg(ctx, userOrg)
+		if s.userRepo.UpdateUserOrg(ctx, userOrg) == nil {
+			snapshots.captureAfter("debtor_", userOrg)
+		}
 	}
 }
 
-func (s *billSplitService) penalizeAndBlockCreditorFromLending(ctx context.Context, creditorID, orgID int32, bill *domain.Bill, reason string) {
+func (s *billSplitService) penalizeAndBlockCreditorFromLending(ctx context.Context, creditorID, orgID int32, bill *domain.Bill, reason string, snapshots *disputeAuditSnapshots) {
 	userOrg, err := s.userRepo.GetUserOrg(ctx, creditorID, orgID)
 	if err == nil {
-		userOrg.BalanceCents -= bill.AmountCents
-		nowDate := time.Now().Format("2006-01-02")
-		userOrg.LastBalanceUpdateOn = &nowDate
+		snapshots.captureBefore("creditor_", userOrg)
+		description := fmt.Sprintf("Dispute penalty for bill %d for %s", bill.ID, bill.SettlementMonth)
+		if s.recordBalanceChange(ctx, creditorID, orgID, -bill.AmountCents, domain.TransactionTypeBillPenalty, description) == nil {
+			s.refreshBalance(ctx, userOrg) //nolint:errcheck // the snapshot keeps the old balance on failure
+		}
 		userOrg.LendingBlocked = true
 		userOrg.BlockedDueToBillID = &bill.ID
 		userOrg.BlockedReason = reason
-		_ = s.userRepo.UpdateUserOrg(ctx, userOrg)
+		if s.userRepo.UpdateUserOrg(ctx, userOrg) == nil {
+			snapshots.captureAfter("creditor_", userOrg)
+		}
+	}
+}
+
+// disputeAuditSnapshots collects the bill status and each party's membership fields around
+// a dispute resolution, as the before/after of its audit entry. A nil collector ignores captures.
+type disputeAuditSnapshots struct {
+	before map[string]string
+	after  map[string]string
+}
+
+func newDisputeAuditSnapshots(bill *domain.Bill) *disputeAuditSnapshots {
+	return &disputeAuditSnapshots{
+		before: map[string]string{"status": string(bill.Status)},
+		after:  map[string]string{},
 	}
 }
 
-func (s *billSplitService) sendDisputeResolutionNotification(ctx context.Context, user *domain.User, bill *domain.Bill, resolution, notes, orgName string) {
+// captureBefore keeps the first state seen for a party, since one outcome may update it twice
+func (d *disputeAuditSnapshots) captureBefore(prefix string, uo *domain.UserOrg) {
+	if d == nil {
+		return
+	}
+	if _, seen := d.before[prefix+"balance_cents"]; !seen {
+		memberAuditSnapshot(d.before, prefix, uo)
+	}
+}
+
+func (d *disputeAuditSnapshots) captureAfter(prefix string, uo *domain.UserOrg) {
+	if d == nil {
+		return
+	}
+	memberAuditSnapshot(d.after, prefix, uo)
+}
+
+func (s *billSplitService) sendDisputeResolutionNotification(ctx context.Context, user *domain.User, bill *domain.Bill, resolution, notes, orgName, currency string) {
 	notification := &domain.Notification{
 		UserID:  user.ID,
 		OrgID:   bill.OrgID,
@@ -460,20 +878,113 @@ func (s *billSplitService) sendDisputeResolutionNotification(ctx context.Context
 		},
 	}
 	_ = s.noteSvc.Dispatch(ctx, notification)
-	_ = s.emailSvc.SendBillDisputeResolutionNotification(ctx, user.Email, user.Name, bill.AmountCents, resolution, notes, orgName)
+	_ = s.emailSvc.SendBillDisputeResolutionNotification(ctx, user.Email, BillDisputeResolutionEmail{
+		Name:         user.Name,
+		AmountCents:  bill.AmountCents,
+		CurrencyCode: currency,
+		Resolution:   resolution,
+		Notes:        notes,
+		OrgName:      orgName,
+	})
+}
+
+// wrapBillUpdateError turns a version conflict into a retryable message for the caller
+func wrapBillUpdateError(err error) error {
+	if errors.Is(err, repository.ErrBillVersionConflict) {
+		return domain.Conflictf("payment was updated by someone else, please reload and try again: %w", err)
+	}
+	return err
+}
+
+func (s *billSplitService) requestVoid(ctx context.Context, bill *domain.Bill, user *domain.User, notes string, now time.Time) error {
+	bill.VoidRequestedBy = &user.ID
+	bill.VoidRequestedAt = &now
+	if err := s.billRepo.Update(ctx, bill); err != nil {
+		return wrapBillUpdateError(err)
+	}
+
+	if notes == "" {
+		notes = "Requested to void payment"
+	}
+	action := &domain.BillAction{
+		BillID:      bill.ID,
+		ActorUserID: &user.ID,
+		ActionType:  domain.BillActionTypeVoidRequested,
+		Notes:       notes,
+		CreatedAt:   now,
+	}
+	_ = s.billRepo.CreateAction(ctx, action)
+
+	s.notifyVoid(ctx, bill, user, "Void Requested",
+		"%s asked to void the payment of %s for %s settlement", "bill_void_requested")
+	return nil
+}
+
+func (s *billSplitService) confirmVoid(ctx context.Context, bill *domain.Bill, user *domain.User, notes string, now time.Time) error {
+	bill.Status = domain.BillStatusVoided
+	bill.ResolvedAt = &now
+	if err := s.billRepo.Update(ctx, bill); err != nil {
+		return wrapBillUpdateError(err)
+	}
+
+	if notes == "" {
+		notes = "Agreed to void payment"
+	}
+	action := &domain.BillAction{
+		BillID:      bill.ID,
+		ActorUserID: &user.ID,
+		ActionType:  domain.BillActionTypeVoidConfirmed,
+		Notes:       notes,
+		CreatedAt:   now,
+	}
+	_ = s.billRepo.CreateAction(ctx, action)
+
+	s.notifyVoid(ctx, bill, user, "Payment Voided",
+		"%s agreed to void the payment of %s for %s settlement", "bill_voided")
+	return nil
+}
+
+// notifyVoid tells the party other than actor about a void request or confirmation
+func (s *billSplitService) notifyVoid(ctx context.Context, bill *domain.Bill, actor *domain.User, title, format, topic string) {
+	otherID := bill.CreditorUserID
+	if actor.ID == bill.CreditorUserID {
+		otherID = bill.DebtorUserID
+	}
+	other, err := s.userRepo.GetByID(ctx, otherID)
+	if err != nil {
+		return
+	}
+
+	orgName, currency := s.getOrgLabel(ctx, bill.OrgID)
+	message := fmt.Sprintf(format, actor.Name, domain.FormatCents(bill.AmountCents, currency), bill.SettlementMonth)
+	notification := &domain.Notification{
+		UserID:  other.ID,
+		OrgID:   bill.OrgID,
+		Title:   title,
+		Message: message,
+		Attributes: map[string]string{
+			"topic":        topic,
+			"bill_id":      fmt.Sprintf("%d", bill.ID),
+			"actor_id":     fmt.Sprintf("%d", actor.ID),
+			"amount_cents": fmt.Sprintf("%d", bill.AmountCents),
+			"channel_id":   string(domain.ChannelBillSplitting),
+		},
+	}
+	_ = s.noteSvc.Dispatch(ctx, notification)
+	_ = s.emailSvc.SendAdminNotification(ctx, other.Email, title+": "+orgName, message)
 }
 
 func (s *billSplitService) acknowledgeAsDebtor(ctx context.Context, bill *domain.Bill, user *domain.User, now time.Time) error {
 	if bill.Status != domain.BillStatusPending && bill.Status != domain.BillStatusDisputed {
-		return fmt.Errorf("payment is not in pending or disputed status")
+		return domain.Conflictf("payment is not in pending or disputed status")
 	}
 	if bill.DebtorAcknowledgedAt != nil {
-		return fmt.Errorf("payment already acknowledged by debtor")
+		return domain.Conflictf("payment already acknowledged by debtor")
 	}
 
 	bill.DebtorAcknowledgedAt = &now
 	if err := s.billRepo.Update(ctx, bill); err != nil {
-		return err
+		return wrapBillUpdateError(err)
 	}
 
 	action := &domain.BillAction{
@@ -487,12 +998,12 @@ func (s *billSplitService) acknowledgeAsDebtor(ctx context.Context, bill *domain
 
 	creditor, err := s.userRepo.GetByID(ctx, bill.CreditorUserID)
 	if err == nil {
-		orgName := s.getOrgName(ctx, bill.OrgID)
+		orgName, currency := s.getOrgLabel(ctx, bill.OrgID)
 		notification := &domain.Notification{
 			UserID:  creditor.ID,
 			OrgID:   bill.OrgID,
 			Title:   "Payment Acknowledged",
-			Message: fmt.Sprintf("%s acknowledged sending payment of $%.2f for %s settlement", user.Name, float64(bill.AmountCents)/100, bill.SettlementMonth),
+			Message: fmt.Sprintf("%s acknowledged sending payment of %s for %s settlement", user.Name, domain.FormatCents(bill.AmountCents, currency), bill.SettlementMonth),
 			Attributes: map[string]string{
 				"topic":        "bill_payment_acknowledged",
 				"bill_id":      fmt.Sprintf("%d", bill.ID),
@@ -502,20 +1013,20 @@ func (s *billSplitService) acknowledgeAsDebtor(ctx context.Context, bill *domain
 			},
 		}
 		_ = s.noteSvc.Dispatch(ctx, notification)
-		_ = s.emailSvc.SendBillPaymentAcknowledgment(ctx, creditor.Email, creditor.Name, user.Name, bill.AmountCents, bill.SettlementMonth, orgName)
+		_ = s.emailSvc.SendBillPaymentAcknowledgment(ctx, creditor.Email, creditor.Name, user.Name, bill.AmountCents, bill.SettlementMonth, orgName, currency)
 	}
 	return nil
 }
 
 func (s *billSplitService) acknowledgeAsCreditor(ctx context.Context, bill *domain.Bill, user *domain.User, now time.Time) error {
 	if bill.Status != domain.BillStatusPending && bill.Status != domain.BillStatusDisputed {
-		return fmt.Errorf("payment is not in pending or disputed status")
+		return domain.Conflictf("payment is not in pending or disputed status")
 	}
 	if bill.DebtorAcknowledgedAt == nil {
-		return fmt.Errorf("debtor has not acknowledged payment yet")
+		return domain.Conflictf("debtor has not acknowledged payment yet")
 	}
 	if bill.CreditorAcknowledgedAt != nil {
-		return fmt.Errorf("payment already acknowledged by creditor")
+		return domain.Conflictf("payment already acknowledged by creditor")
 	}
 
 	bill.CreditorAcknowledgedAt = &now
@@ -523,7 +1034,7 @@ func (s *billSplitService) acknowledgeAsCreditor(ctx context.Context, bill *doma
 	bill.ResolvedAt = &now
 	bill.ResolutionOutcome = string(domain.ResolutionOutcomeGraceful)
 	if err := s.billRepo.Update(ctx, bill); err != nil {
-		return err
+		return wrapBillUpdateError(err)
 	}
 
 	action := &domain.BillAction{
@@ -535,18 +1046,18 @@ func (s *billSplitService) acknowledgeAsCreditor(ctx context.Context, bill *doma
 	}
 	_ = s.billRepo.CreateAction(ctx, action)
 
-	if err := s.updateBalances(ctx, bill); err != nil {
+	if err := s.updateBalances(ctx, bill, nil); err != nil {
 		return fmt.Errorf("failed to update balances: %w", err)
 	}
 
 	debtor, err := s.userRepo.GetByID(ctx, bill.DebtorUserID)
 	if err == nil {
-		orgName := s.getOrgName(ctx, bill.OrgID)
+		orgName, currency := s.getOrgLabel(ctx, bill.OrgID)
 		notification := &domain.Notification{
 			UserID:  debtor.ID,
 			OrgID:   bill.OrgID,
 			Title:   "Payment Receipt Confirmed",
-			Message: fmt.Sprintf("%s confirmed receiving payment of $%.2f for %s settlement", user.Name, float64(bill.AmountCents)/100, bill.SettlementMonth),
+			Message: fmt.Sprintf("%s confirmed receiving payment of %s for %s settlement", user.Name, domain.FormatCents(bill.AmountCents, currency), bill.SettlementMonth),
 			Attributes: map[string]string{
 				"topic":        "bill_receipt_confirmed",
 				"bill_id":      fmt.Sprintf("%d", bill.ID),
@@ -556,7 +1067,7 @@ func (s *billSplitService) acknowledgeAsCreditor(ctx context.Context, bill *doma
 			},
 		}
 		_ = s.noteSvc.Dispatch(ctx, notification)
-		_ = s.emailSvc.SendBillReceiptConfirmation(ctx, debtor.Email, debtor.Name, user.Name, bill.AmountCents, bill.SettlementMonth, orgName)
+		_ = s.emailSvc.SendBillReceiptConfirmation(ctx, debtor.Email, debtor.Name, user.Name, bill.AmountCents, bill.SettlementMonth, orgName, currency)
 	}
 	return nil
 }
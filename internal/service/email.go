@@ -1,45 +1,127 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
 	"net/smtp"
+	"net/textproto"
 	"strings"
 	"sync"
+	"time"
 
 	"ubertool-backend-trusted/internal/logger"
 )
 
+// TLSMode selects how the SMTP connection is secured
+type TLSMode string
+
+const (
+	TLSModeStartTLS TLSMode = "starttls" // plain connect, then upgrade; fails if the server does not offer STARTTLS
+	TLSModeImplicit TLSMode = "implicit" // TLS from the first byte (SMTPS, usually port 465)
+	TLSModeNone     TLSMode = "none"     // no TLS; only for trusted relays
+)
+
+const (
+	defaultSMTPTimeout      = 10 * time.Second
+	defaultSMTPMaxAttempts  = 3
+	defaultSMTPRetryBackoff = 500 * time.Millisecond
+	defaultSMTPIdleTimeout  = 30 * time.Second
+)
+
+// EmailOptions tunes the SMTP transport. Zero values fall back to defaults.
+type EmailOptions struct {
+	TLSMode      TLSMode       // empty infers implicit for port 465, STARTTLS otherwise
+	Timeout      time.Duration // dial timeout and per-send I/O deadline
+	MaxAttempts  int           // total tries per message, including the first
+	RetryBackoff time.Duration // delay before the first retry; doubled after each failure
+	IdleTimeout  time.Duration // pooled connections idle longer than this are reopened
+	TLSConfig    *tls.Config   // optional override, e.g. custom root CAs
+}
+
 type emailService struct {
 	smtpHost    string
 	smtpPort    string
 	senderEmail string
 	senderPass  string
 	senderName  string
+	opts        EmailOptions
+	outbox      *EmailOutbox // when set, messages are persisted for a worker instead of sent inline
+
+	// A single pooled connection, reused across sends while it stays healthy
+	mu       sync.Mutex
+	conn     net.Conn
+	client   *smtp.Client
+	lastUsed time.Time
 }
 
 func NewEmailService(host, port, email, password, name string) EmailService {
+	return NewEmailServiceWithOptions(host, port, email, password, name, EmailOptions{})
+}
+
+// NewEmailServiceWithOptions creates an EmailService with explicit TLS, timeout and retry settings
+func NewEmailServiceWithOptions(host, port, email, password, name string, opts EmailOptions) EmailService {
+	if opts.TLSMode == "" {
+		if port == "465" {
+			opts.TLSMode = TLSModeImplicit
+		} else {
+			opts.TLSMode = TLSModeStartTLS
+		}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultSMTPTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultSMTPMaxAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultSMTPRetryBackoff
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = defaultSMTPIdleTimeout
+	}
 	return &emailService{
 		smtpHost:    host,
 		smtpPort:    port,
 		senderEmail: email,
 		senderPass:  password,
 		senderName:  name,
+		opts:        opts,
 	}
 }
 
+// NewOutboxEmailService creates an EmailService that composes messages and hands them to
+// the outbox, which persists them and delivers through its own transport.
+func NewOutboxEmailService(outbox *EmailOutbox) EmailService {
+	return &emailService{outbox: outbox}
+}
+
 // EmailMessage represents an email to be sent
 type EmailMessage struct {
-	To      []string
-	Cc      []string // CC recipients
-	Subject string
-	Body    string
-	IsHTML  bool
+	To       []string
+	Cc       []string // CC recipients
+	Subject  string
+	Body     string
+	IsHTML   bool
+	HTMLBody string // When set, sent as multipart/alternative with Body as the plaintext part
 }
 
-func (s *emailService) sendEmail(msg EmailMessage) error {
+func (s *emailService) sendEmail(ctx context.Context, msg EmailMessage) error {
+	if s.outbox != nil {
+		return s.outbox.Enqueue(ctx, msg)
+	}
+	return s.SendMessage(ctx, msg)
+}
+
+// SendMessage delivers a composed message over SMTP, bypassing any outbox
+func (s *emailService) SendMessage(ctx context.Context, msg EmailMessage) error {
 	if s.smtpHost == "" || s.smtpHost == "mock" || s.smtpHost == "localhost" {
 		log.Printf("[MOCK EMAIL] To: %v, Subject: %s", msg.To, msg.Subject)
 		return nil
@@ -54,7 +136,17 @@ func (s *emailService) sendEmail(msg EmailMessage) error {
 	}
 	headers["Subject"] = msg.Subject
 
-	if msg.IsHTML {
+	content := msg.Body
+	switch {
+	case msg.HTMLBody != "":
+		parts, boundary, err := multipartAlternative(msg.Body, msg.HTMLBody)
+		if err != nil {
+			return fmt.Errorf("failed to build multipart body: %w", err)
+		}
+		headers["MIME-Version"] = "1.0"
+		headers["Content-Type"] = "multipart/alternative; boundary=" + boundary
+		content = parts
+	case msg.IsHTML:
 		headers["MIME-Version"] = "1.0"
 		headers["Content-Type"] = "text/html; charset=UTF-8"
 	}
@@ -65,494 +157,325 @@ func (s *emailService) sendEmail(msg EmailMessage) error {
 		emailBody.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
 	}
 	emailBody.WriteString("\r\n")
-	emailBody.WriteString(msg.Body)
+	emailBody.WriteString(content)
 
 	// Combine To and Cc for SMTP recipients
 	recipients := append([]string{}, msg.To...)
 	recipients = append(recipients, msg.Cc...)
 
-	addr := fmt.Sprintf("%s:%s", s.smtpHost, s.smtpPort)
-	auth := smtp.PlainAuth("", s.senderEmail, s.senderPass, s.smtpHost)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// TLS Configuration
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		ServerName:         s.smtpHost,
-	}
-
-	var client *smtp.Client
+	backoff := s.opts.RetryBackoff
 	var err error
-
-	if s.smtpPort == "465" {
-		// Implicit TLS (SMTPS)
-		conn, err := tls.Dial("tcp", addr, tlsConfig)
-		if err != nil {
-			return fmt.Errorf("failed to dial TLS: %v", err)
+	for attempt := 1; attempt <= s.opts.MaxAttempts; attempt++ {
+		err = s.deliver(recipients, []byte(emailBody.String()))
+		if err == nil {
+			return nil
 		}
-		client, err = smtp.NewClient(conn, s.smtpHost)
-		if err != nil {
-			return fmt.Errorf("failed to create SMTP client: %v", err)
+		// The connection state is unknown after a failure; never reuse it
+		s.closeConn()
+		if !isTransientSMTPError(err) || attempt == s.opts.MaxAttempts {
+			break
 		}
-	} else {
-		// STARTTLS (usually port 587)
-		client, err = smtp.Dial(addr)
+		logger.Warn("Transient SMTP failure, retrying",
+			"attempt", attempt,
+			"backoff", backoff.String(),
+			"error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return err
+}
+
+// multipartAlternative encodes a plaintext and an HTML part, plaintext first so
+// clients that cannot render HTML fall back to it
+func multipartAlternative(text, html string) (string, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", text},
+		{"text/html; charset=UTF-8", html},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
 		if err != nil {
-			return fmt.Errorf("failed to dial SMTP: %v", err)
+			return "", "", err
 		}
-
-		// Perform STARTTLS if supported/needed
-		if ok, _ := client.Extension("STARTTLS"); ok {
-			if err = client.StartTLS(tlsConfig); err != nil {
-				client.Close()
-				return fmt.Errorf("failed to start TLS: %v", err)
-			}
+		qp := quotedprintable.NewWriter(pw)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return "", "", err
+		}
+		if err := qp.Close(); err != nil {
+			return "", "", err
 		}
 	}
-	defer client.Quit()
+	if err := w.Close(); err != nil {
+		return "", "", err
+	}
+	return buf.String(), w.Boundary(), nil
+}
 
-	// Authenticate
-	if err = client.Auth(auth); err != nil {
-		return fmt.Errorf("failed to authenticate: %v", err)
+// deliver sends one message over the pooled connection, dialing a new one if needed.
+// Callers must hold s.mu.
+func (s *emailService) deliver(recipients []string, body []byte) error {
+	if err := s.ensureConn(); err != nil {
+		return err
+	}
+	if err := s.conn.SetDeadline(time.Now().Add(s.opts.Timeout)); err != nil {
+		return fmt.Errorf("failed to set deadline: %w", err)
 	}
 
 	// Set sender and recipients
-	if err = client.Mail(s.senderEmail); err != nil {
-		return fmt.Errorf("failed to set sender: %v", err)
+	if err := s.client.Mail(s.senderEmail); err != nil {
+		return fmt.Errorf("failed to set sender: %w", err)
 	}
 	for _, recipient := range recipients {
-		if err = client.Rcpt(recipient); err != nil {
-			return fmt.Errorf("failed to set recipient %s: %v", recipient, err)
+		if err := s.client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed to set recipient %s: %w", recipient, err)
 		}
 	}
 
 	// Send body
-	w, err := client.Data()
+	w, err := s.client.Data()
 	if err != nil {
-		return fmt.Errorf("failed to open data writer: %v", err)
+		return fmt.Errorf("failed to open data writer: %w", err)
 	}
-	_, err = w.Write([]byte(emailBody.String()))
-	if err != nil {
-		return fmt.Errorf("failed to write email body: %v", err)
+	if _, err = w.Write(body); err != nil {
+		return fmt.Errorf("failed to write email body: %w", err)
 	}
-	err = w.Close()
-	if err != nil {
-		return fmt.Errorf("failed to close data writer: %v", err)
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("failed to close data writer: %w", err)
 	}
 
+	s.lastUsed = time.Now()
 	return nil
 }
 
-func (s *emailService) SendInvitation(ctx context.Context, email, name, token string, orgName string, ccEmail string) error {
-	subject := fmt.Sprintf("Invitation to join %s", orgName)
-	body := fmt.Sprintf("Hello %s,\n\nYou have been invited to join %s.\nYour invitation code is: %s\n\nPlease use this code to sign up.", name, orgName, token)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
-	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendAccountStatusNotification(ctx context.Context, email, name, orgName, status, reason string) error {
-	subject := fmt.Sprintf("Account Status Update for %s", orgName)
-	body := fmt.Sprintf("Hello %s,\n\nYour account status in %s has been updated to: %s.\nReason: %s", name, orgName, status, reason)
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-// Rental notifications
-
-func (s *emailService) SendRentalRequestNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error {
-	subject := fmt.Sprintf("New Rental Request for %s", toolName)
-	body := fmt.Sprintf("Hello,\n\n%s has requested to rent your tool: %s.\nPlease log in to approve or reject the request.", renterName, toolName)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
-	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{ownerEmail},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendRentalApprovalNotification(ctx context.Context, renterEmail, toolName, ownerName, pickupNote string, ccEmail string) error {
-	subject := fmt.Sprintf("Rental Request Approved: %s", toolName)
-	body := fmt.Sprintf("Hello,\n\nYour rental request for %s has been approved by %s.\n\nPickup Instructions:\n%s", toolName, ownerName, pickupNote)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
+// ensureConn reuses the pooled connection when it is fresh and answers RSET,
+// otherwise dials, secures and authenticates a new one. Callers must hold s.mu.
+func (s *emailService) ensureConn() error {
+	if s.client != nil {
+		if time.Since(s.lastUsed) < s.opts.IdleTimeout {
+			if err := s.conn.SetDeadline(time.Now().Add(s.opts.Timeout)); err == nil {
+				if err := s.client.Reset(); err == nil {
+					return nil
+				}
+			}
+		}
+		s.closeConn()
 	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{renterEmail},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
 
-func (s *emailService) SendRentalRejectionNotification(ctx context.Context, renterEmail, toolName, ownerName string, ccEmail string) error {
-	subject := fmt.Sprintf("Rental Request Rejected: %s", toolName)
-	body := fmt.Sprintf("Hello,\n\nYour rental request for %s has been rejected by %s.", toolName, ownerName)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
-	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{renterEmail},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendRentalConfirmationNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error {
-	subject := fmt.Sprintf("Rental Confirmed: %s", toolName)
-	body := fmt.Sprintf("Hello,\n\n%s has confirmed the rental for %s. The transaction is now scheduled.", renterName, toolName)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
-	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{ownerEmail},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
+	addr := net.JoinHostPort(s.smtpHost, s.smtpPort)
+	dialer := &net.Dialer{Timeout: s.opts.Timeout}
+	tlsConfig := s.tlsConfig()
 
-func (s *emailService) SendRentalCancellationNotification(ctx context.Context, ownerEmail, renterName, toolName, reason string, ccEmail string) error {
-	subject := fmt.Sprintf("Rental Canceled: %s", toolName)
-	body := fmt.Sprintf("Hello,\n\n%s has canceled the rental request for %s.\nReason: %s", renterName, toolName, reason)
-	var cc []string
-	if ccEmail != "" {
-		cc = []string{ccEmail}
+	var conn net.Conn
+	var err error
+	if s.opts.TLSMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("failed to dial TLS: %w", err)
+		}
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to dial SMTP: %w", err)
+		}
+	}
+	if err = conn.SetDeadline(time.Now().Add(s.opts.Timeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set deadline: %w", err)
 	}
-	return s.sendEmail(EmailMessage{
-		To:      []string{ownerEmail},
-		Cc:      cc,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendRentalCompletionNotification(ctx context.Context, email, role, toolName string, amount int32) error {
-	subject := fmt.Sprintf("Rental Completed: %s", toolName)
-	body := fmt.Sprintf("Hello,\n\nThe rental for %s has been completed.\nAmount: %d cents\nRole: %s", toolName, amount, role)
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendRentalPickupNotification(ctx context.Context, email, name, toolName, startDate, endDate string) error {
-	subject := fmt.Sprintf("Rental Picked Up: %s", toolName)
-	body := fmt.Sprintf("Hello %s,\n\nThe tool %s has been picked up.\nStart Date: %s\nScheduled End Date: %s", name, toolName, startDate, endDate)
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32) error {
-	subject := fmt.Sprintf("Return Date Extension Rejected: %s", toolName)
-	costInDollars := float64(totalCostCents) / 100.0
-	body := fmt.Sprintf("Hello,\n\nYour request to extend the return date for %s has been rejected.\n\nRejection Reason: %s\nNew Return Date Set by Owner: %s\nUpdated Rental Cost: $%.2f\n\nPlease acknowledge this change to continue.",
-		toolName, reason, newEndDate, costInDollars)
-	return s.sendEmail(EmailMessage{
-		To:      []string{renterEmail},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendAdminNotification(ctx context.Context, adminEmail, subject, message string) error {
-	return s.sendEmail(EmailMessage{
-		To:      []string{adminEmail},
-		Subject: subject,
-		Body:    message,
-		IsHTML:  false,
-	})
-}
-
-// Bill Split Email Methods
-
-func (s *emailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
-	subject := fmt.Sprintf("Payment Notice: $%.2f Due to %s (%s)", float64(amountCents)/100, creditorName, orgName)
-	body := fmt.Sprintf("Hello %s,\n\nYou have a payment due for the %s settlement period.\n\nAmount: $%.2f\nPayable to: %s\nOrganization: %s\n\nPlease settle this payment using your mutually agreed-upon payment method, then acknowledge the payment in the app.\n\nBest regards,\nUbertool Team",
-		debtorName, settlementMonth, float64(amountCents)/100, creditorName, orgName)
-	return s.sendEmail(EmailMessage{
-		To:      []string{debtorEmail},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string) error {
-	subject := fmt.Sprintf("Payment Acknowledgment: %s sent $%.2f (%s)", debtorName, float64(amountCents)/100, orgName)
-	body := fmt.Sprintf("Hello %s,\n\n%s has acknowledged sending you a payment for the %s settlement period.\n\nAmount: $%.2f\nOrganization: %s\n\nPlease confirm receipt of this payment in the app once you have received it.\n\nBest regards,\nUbertool Team",
-		creditorName, debtorName, settlementMonth, float64(amountCents)/100, orgName)
-	return s.sendEmail(EmailMessage{
-		To:      []string{creditorEmail},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
-	subject := fmt.Sprintf("Receipt Confirmed: %s received $%.2f (%s)", creditorName, float64(amountCents)/100, orgName)
-	body := fmt.Sprintf("Hello %s,\n\n%s has confirmed receiving your payment for the %s settlement period.\n\nAmount: $%.2f\nOrganization: %s\n\nYour account balances have been updated accordingly.\n\nBest regards,\nUbertool Team",
-		debtorName, creditorName, settlementMonth, float64(amountCents)/100, orgName)
-	return s.sendEmail(EmailMessage{
-		To:      []string{debtorEmail},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string) error {
-	subject := fmt.Sprintf("Payment Dispute Opened: $%.2f with %s (%s)", float64(amountCents)/100, otherPartyName, orgName)
-	body := fmt.Sprintf("Hello %s,\n\nA payment dispute has been opened for a $%.2f transaction with %s.\n\nReason: %s\nOrganization: %s\n\nPlease work with the other party to resolve this dispute. If the dispute cannot be resolved, an admin may need to intervene.\n\nBest regards,\nUbertool Team",
-		name, float64(amountCents)/100, otherPartyName, reason, orgName)
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-func (s *emailService) SendBillDisputeResolutionNotification(ctx context.Context, email, name string, amountCents int32, resolution, notes string, orgName string) error {
-	subject := fmt.Sprintf("Dispute Resolved: $%.2f Payment (%s)", float64(amountCents)/100, orgName)
-	body := fmt.Sprintf("Hello %s,\n\nThe dispute for a $%.2f payment has been resolved by an admin.\n\nResolution: %s\nNotes: %s\nOrganization: %s\n\nPlease check the app for details and any actions you may need to take.\n\nBest regards,\nUbertool Team",
-		name, float64(amountCents)/100, resolution, notes, orgName)
-	return s.sendEmail(EmailMessage{
-		To:      []string{email},
-		Subject: subject,
-		Body:    body,
-		IsHTML:  false,
-	})
-}
-
-// ---------------------------------------------------------------------------
-// AsyncEmailService — fire-and-forget wrapper around EmailService
-// ---------------------------------------------------------------------------
-//
-// AsyncEmailService wraps any EmailService implementation and dispatches every
-// send call to a bounded background worker pool, returning nil to the caller
-// immediately. This decouples transactional email delivery from the gRPC hot
-// path so that SMTP latency never stalls an RPC handler.
-//
-// The pool is started by NewAsyncEmailService and must be stopped by calling
-// Shutdown during graceful server shutdown.
 
-const (
-	// emailWorkerCount is the number of long-running SMTP sender goroutines.
-	emailWorkerCount = 3
+	client, err := smtp.NewClient(conn, s.smtpHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create SMTP client: %w", err)
+	}
 
-	// emailJobQueueSize is the capacity of the buffered job channel. Jobs are
-	// dropped (with a warning) only when this many are already waiting.
-	emailJobQueueSize = 128
-)
+	if s.opts.TLSMode == TLSModeStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+		if err = client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
 
-type emailJob struct {
-	fn         func() error
-	methodName string
-}
+	// Authenticate
+	if s.senderPass != "" {
+		auth := smtp.PlainAuth("", s.senderEmail, s.senderPass, s.smtpHost)
+		if err = client.Auth(auth); err != nil {
+			client.Close()
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
 
-// AsyncEmailService implements EmailService. Every method enqueues the send
-// operation and returns nil immediately; actual delivery happens in a worker.
-type AsyncEmailService struct {
-	underlying EmailService
-	jobs       chan emailJob
-	jobsMu     sync.Mutex
-	jobsClosed bool
-	wg         sync.WaitGroup
+	s.conn = conn
+	s.client = client
+	s.lastUsed = time.Now()
+	return nil
 }
 
-// NewAsyncEmailService creates an AsyncEmailService that delegates real sends
-// to underlying and starts the worker pool immediately.
-func NewAsyncEmailService(underlying EmailService) *AsyncEmailService {
-	svc := &AsyncEmailService{
-		underlying: underlying,
-		jobs:       make(chan emailJob, emailJobQueueSize),
+func (s *emailService) tlsConfig() *tls.Config {
+	if s.opts.TLSConfig != nil {
+		cfg := s.opts.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName = s.smtpHost
+		}
+		return cfg
 	}
-	for i := 0; i < emailWorkerCount; i++ {
-		svc.wg.Add(1)
-		go svc.runWorker()
+	return &tls.Config{
+		ServerName: s.smtpHost,
+		MinVersion: tls.VersionTLS12,
 	}
-	return svc
 }
 
-// Shutdown closes the job queue and waits for all in-flight sends to finish.
-// Returns ctx.Err() if the deadline is exceeded before draining completes.
-func (s *AsyncEmailService) Shutdown(ctx context.Context) error {
-	s.jobsMu.Lock()
-	if !s.jobsClosed {
-		s.jobsClosed = true
-		close(s.jobs)
+// closeConn drops the pooled connection. Callers must hold s.mu.
+func (s *emailService) closeConn() {
+	if s.client == nil {
+		return
 	}
-	s.jobsMu.Unlock()
-
-	done := make(chan struct{})
-	go func() {
-		s.wg.Wait()
-		close(done)
-	}()
-	select {
-	case <-done:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
+	if err := s.client.Quit(); err != nil {
+		s.client.Close()
 	}
+	s.client = nil
+	s.conn = nil
 }
 
-func (s *AsyncEmailService) runWorker() {
-	defer s.wg.Done()
-	for job := range s.jobs {
-		if err := job.fn(); err != nil {
-			logger.Warn("Async email send failed", "method", job.methodName, "error", err)
-		}
+// isTransientSMTPError reports whether a send is worth retrying: 4xx replies and
+// network-level failures are, 5xx replies and TLS/certificate errors are not.
+func isTransientSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
 	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
 }
 
-// enqueue places a send closure on the job channel and returns nil immediately.
-// If the queue is full or already closed the job is dropped with a warning.
-func (s *AsyncEmailService) enqueue(methodName string, fn func() error) error {
-	s.jobsMu.Lock()
-	defer s.jobsMu.Unlock()
-	if s.jobsClosed {
-		logger.Warn("Email job dropped: service is shutting down", "method", methodName)
+// ccList returns the optional CC address as a recipient list
+func ccList(ccEmail string) []string {
+	if ccEmail == "" {
 		return nil
 	}
-	select {
-	case s.jobs <- emailJob{fn: fn, methodName: methodName}:
-	default:
-		logger.Warn("Email job dropped: queue is full", "method", methodName)
-	}
-	return nil
+	return []string{ccEmail}
 }
 
-// -- EmailService interface implementation -----------------------------------
-// Each method captures its arguments in a closure and enqueues it. The closure
-// uses context.Background() because the original request context is cancelled
-// as soon as the RPC returns — before the worker picks up the job.
+func (s *emailService) SendInvitation(ctx context.Context, email, name, token string, orgName string, ccEmail string) error {
+	data := InvitationEmail{Name: name, OrgName: orgName, Token: token}
+	return s.sendTemplate(ctx, EmailTemplateInvitation, data, []string{email}, ccList(ccEmail))
+}
 
-func (s *AsyncEmailService) SendInvitation(_ context.Context, email, name, token, orgName, ccEmail string) error {
-	return s.enqueue("SendInvitation", func() error {
-		return s.underlying.SendInvitation(context.Background(), email, name, token, orgName, ccEmail)
-	})
+func (s *emailService) SendAccountStatusNotification(ctx context.Context, email, name, orgName, status, reason string) error {
+	return s.Send(ctx, email, EmailTemplateAccountStatus, AccountStatusEmail{Name: name, OrgName: orgName, Status: status, Reason: reason})
 }
 
-func (s *AsyncEmailService) SendAccountStatusNotification(_ context.Context, email, name, orgName, status, reason string) error {
-	return s.enqueue("SendAccountStatusNotification", func() error {
-		return s.underlying.SendAccountStatusNotification(context.Background(), email, name, orgName, status, reason)
-	})
+// Rental notifications
+
+func (s *emailService) SendRentalRequestNotification(ctx context.Context, ownerEmail string, data RentalRequestEmail, ccEmail string) error {
+	return s.sendTemplate(ctx, EmailTemplateRentalRequest, data, []string{ownerEmail}, ccList(ccEmail))
 }
 
-func (s *AsyncEmailService) SendRentalRequestNotification(_ context.Context, ownerEmail, renterName, toolName, ccEmail string) error {
-	return s.enqueue("SendRentalRequestNotification", func() error {
-		return s.underlying.SendRentalRequestNotification(context.Background(), ownerEmail, renterName, toolName, ccEmail)
-	})
+func (s *emailService) SendRentalApprovalNotification(ctx context.Context, renterEmail string, data RentalApprovalEmail, ccEmail string) error {
+	return s.sendTemplate(ctx, EmailTemplateRentalApproval, data, []string{renterEmail}, ccList(ccEmail))
 }
 
-func (s *AsyncEmailService) SendRentalApprovalNotification(_ context.Context, renterEmail, toolName, ownerName, pickupNote, ccEmail string) error {
-	return s.enqueue("SendRentalApprovalNotification", func() error {
-		return s.underlying.SendRentalApprovalNotification(context.Background(), renterEmail, toolName, ownerName, pickupNote, ccEmail)
-	})
+func (s *emailService) SendRentalRejectionNotification(ctx context.Context, renterEmail, toolName, ownerName string, ccEmail string) error {
+	data := RentalRejectionEmail{ToolName: toolName, OwnerName: ownerName}
+	return s.sendTemplate(ctx, EmailTemplateRentalRejection, data, []string{renterEmail}, ccList(ccEmail))
 }
 
-func (s *AsyncEmailService) SendRentalRejectionNotification(_ context.Context, renterEmail, toolName, ownerName, ccEmail string) error {
-	return s.enqueue("SendRentalRejectionNotification", func() error {
-		return s.underlying.SendRentalRejectionNotification(context.Background(), renterEmail, toolName, ownerName, ccEmail)
-	})
+func (s *emailService) SendRentalConfirmationNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error {
+	data := RentalConfirmationEmail{RenterName: renterName, ToolName: toolName}
+	return s.sendTemplate(ctx, EmailTemplateRentalConfirmation, data, []string{ownerEmail}, ccList(ccEmail))
 }
 
-func (s *AsyncEmailService) SendRentalConfirmationNotification(_ context.Context, ownerEmail, renterName, toolName, ccEmail string) error {
-	return s.enqueue("SendRentalConfirmationNotification", func() error {
-		return s.underlying.SendRentalConfirmationNotification(context.Background(), ownerEmail, renterName, toolName, ccEmail)
-	})
+func (s *emailService) SendRentalCancellationNotification(ctx context.Context, recipientEmail, cancellerName, toolName, reason string, ccEmail string) error {
+	data := RentalCancellationEmail{CancellerName: cancellerName, ToolName: toolName, Reason: reason}
+	return s.sendTemplate(ctx, EmailTemplateRentalCancellation, data, []string{recipientEmail}, ccList(ccEmail))
 }
 
-func (s *AsyncEmailService) SendRentalCancellationNotification(_ context.Context, ownerEmail, renterName, toolName, reason, ccEmail string) error {
-	return s.enqueue("SendRentalCancellationNotification", func() error {
-		return s.underlying.SendRentalCancellationNotification(context.Background(), ownerEmail, renterName, toolName, reason, ccEmail)
-	})
+func (s *emailService) SendRentalCompletionNotification(ctx context.Context, email, role, toolName string, amount int32) error {
+	return s.Send(ctx, email, EmailTemplateRentalCompletion, RentalCompletionEmail{Role: role, ToolName: toolName, AmountCents: amount})
 }
 
-func (s *AsyncEmailService) SendRentalCompletionNotification(_ context.Context, email, role, toolName string, amount int32) error {
-	return s.enqueue("SendRentalCompletionNotification", func() error {
-		return s.underlying.SendRentalCompletionNotification(context.Background(), email, role, toolName, amount)
-	})
+func (s *emailService) SendRentalPickupNotification(ctx context.Context, email, name, toolName, startDate, endDate string) error {
+	return s.Send(ctx, email, EmailTemplateRentalPickup, RentalPickupEmail{Name: name, ToolName: toolName, StartDate: startDate, EndDate: endDate})
 }
 
-func (s *AsyncEmailService) SendRentalPickupNotification(_ context.Context, email, name, toolName, startDate, endDate string) error {
-	return s.enqueue("SendRentalPickupNotification", func() error {
-		return s.underlying.SendRentalPickupNotification(context.Background(), email, name, toolName, startDate, endDate)
+func (s *emailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32, currencyCode string) error {
+	return s.Send(ctx, renterEmail, EmailTemplateReturnDateRejection, ReturnDateRejectionEmail{
+		ToolName:       toolName,
+		NewEndDate:     newEndDate,
+		Reason:         reason,
+		TotalCostCents: totalCostCents,
+		CurrencyCode:   currencyCode,
 	})
 }
 
-func (s *AsyncEmailService) SendReturnDateRejectionNotification(_ context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32) error {
-	return s.enqueue("SendReturnDateRejectionNotification", func() error {
-		return s.underlying.SendReturnDateRejectionNotification(context.Background(), renterEmail, toolName, newEndDate, reason, totalCostCents)
-	})
+func (s *emailService) SendTwoFactorCode(ctx context.Context, email string, data TwoFactorCodeEmail) error {
+	return s.Send(ctx, email, EmailTemplateTwoFactorCode, data)
 }
 
-func (s *AsyncEmailService) SendAdminNotification(_ context.Context, adminEmail, subject, message string) error {
-	return s.enqueue("SendAdminNotification", func() error {
-		return s.underlying.SendAdminNotification(context.Background(), adminEmail, subject, message)
-	})
+func (s *emailService) SendAdminNotification(ctx context.Context, adminEmail, subject, message string) error {
+	return s.Send(ctx, adminEmail, EmailTemplateAdminNotification, AdminNotificationEmail{Subject: subject, Message: message})
 }
 
-func (s *AsyncEmailService) SendBillPaymentNotice(_ context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth, orgName string) error {
-	return s.enqueue("SendBillPaymentNotice", func() error {
-		return s.underlying.SendBillPaymentNotice(context.Background(), debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName)
+// Bill Split Email Methods
+
+func (s *emailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	return s.Send(ctx, debtorEmail, EmailTemplateBillPaymentNotice, BillPaymentEmail{
+		DebtorName:      debtorName,
+		CreditorName:    creditorName,
+		AmountCents:     amountCents,
+		CurrencyCode:    currencyCode,
+		SettlementMonth: settlementMonth,
+		OrgName:         orgName,
 	})
 }
 
-func (s *AsyncEmailService) SendBillPaymentAcknowledgment(_ context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth, orgName string) error {
-	return s.enqueue("SendBillPaymentAcknowledgment", func() error {
-		return s.underlying.SendBillPaymentAcknowledgment(context.Background(), creditorEmail, creditorName, debtorName, amountCents, settlementMonth, orgName)
+func (s *emailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	return s.Send(ctx, creditorEmail, EmailTemplateBillPaymentAcknowledgment, BillPaymentEmail{
+		DebtorName:      debtorName,
+		CreditorName:    creditorName,
+		AmountCents:     amountCents,
+		CurrencyCode:    currencyCode,
+		SettlementMonth: settlementMonth,
+		OrgName:         orgName,
 	})
 }
 
-func (s *AsyncEmailService) SendBillReceiptConfirmation(_ context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth, orgName string) error {
-	return s.enqueue("SendBillReceiptConfirmation", func() error {
-		return s.underlying.SendBillReceiptConfirmation(context.Background(), debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName)
+func (s *emailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	return s.Send(ctx, debtorEmail, EmailTemplateBillReceiptConfirmation, BillPaymentEmail{
+		DebtorName:      debtorName,
+		CreditorName:    creditorName,
+		AmountCents:     amountCents,
+		CurrencyCode:    currencyCode,
+		SettlementMonth: settlementMonth,
+		OrgName:         orgName,
 	})
 }
 
-func (s *AsyncEmailService) SendBillDisputeNotification(_ context.Context, email, name, otherPartyName string, amountCents int32, reason, orgName string) error {
-	return s.enqueue("SendBillDisputeNotification", func() error {
-		return s.underlying.SendBillDisputeNotification(context.Background(), email, name, otherPartyName, amountCents, reason, orgName)
+func (s *emailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string, currencyCode string) error {
+	return s.Send(ctx, email, EmailTemplateBillDispute, BillDisputeEmail{
+		Name:           name,
+		OtherPartyName: otherPartyName,
+		AmountCents:    amountCents,
+		CurrencyCode:   currencyCode,
+		Reason:         reason,
+		OrgName:        orgName,
 	})
 }
 
-func (s *AsyncEmailService) SendBillDisputeResolutionNotification(_ context.Context, email, name string, amountCents int32, resolution, notes, orgName string) error {
-	return s.enqueue("SendBillDisputeResolutionNotification", func() error {
-		return s.underlying.SendBillDisputeResolutionNotification(context.Background(), email, name, amountCents, resolution, notes, orgName)
-	})
+func (s *emailService) SendBillDisputeResolutionNotification(ctx context.Context, email string, data BillDisputeResolutionEmail) error {
+	return s.Send(ctx, email, EmailTemplateBillDisputeResolution, data)
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/repository"
+)
+
+// ---------------------------------------------------------------------------
+// EmailOutbox — durable, asynchronous email delivery
+// ---------------------------------------------------------------------------
+//
+// Every email is first written to the email_outbox table and then handed to a
+// bounded worker pool, so RPC handlers return as soon as the row is persisted
+// and SMTP latency never stalls them. Workers record SENT or FAILED on the row.
+// Entries that fail, or that never reach a worker (full queue, restart), are
+// picked up again by RetryPending, which the cronjob runs on a schedule.
+//
+// The pool is started by NewEmailOutbox and must be stopped by calling
+// Shutdown during graceful shutdown.
+
+const (
+	// emailWorkerCount is the number of long-running delivery goroutines.
+	emailWorkerCount = 3
+
+	// emailJobQueueSize is the capacity of the buffered job channel. When it is
+	// full the entry stays PENDING in the table for the retry job.
+	emailJobQueueSize = 128
+
+	// defaultOutboxMaxAttempts bounds delivery attempts per entry across the
+	// worker and the retry job; exhausted entries stay FAILED.
+	defaultOutboxMaxAttempts = 6
+
+	// outboxRetryBackoff is the wait after the first failed attempt; it doubles
+	// with each further attempt, up to outboxMaxBackoff.
+	outboxRetryBackoff = time.Minute
+	outboxMaxBackoff   = 6 * time.Hour
+
+	// outboxStaleAfter is how long an entry may sit in PENDING or SENDING before
+	// the retry job assumes its worker is gone and reclaims it.
+	outboxStaleAfter = 10 * time.Minute
+)
+
+// EmailTransport delivers a fully composed message, e.g. over SMTP
+type EmailTransport interface {
+	SendMessage(ctx context.Context, msg EmailMessage) error
+}
+
+// EmailOutbox persists outgoing email and delivers it in the background
+type EmailOutbox struct {
+	repo        repository.EmailOutboxRepository
+	transport   EmailTransport
+	maxAttempts int32
+
+	jobs       chan domain.EmailOutboxEntry
+	jobsMu     sync.Mutex
+	jobsClosed bool
+	wg         sync.WaitGroup
+}
+
+// NewEmailOutbox creates an EmailOutbox and starts its worker pool. maxAttempts <= 0 uses the default.
+func NewEmailOutbox(repo repository.EmailOutboxRepository, transport EmailTransport, maxAttempts int32) *EmailOutbox {
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxMaxAttempts
+	}
+	o := &EmailOutbox{
+		repo:        repo,
+		transport:   transport,
+		maxAttempts: maxAttempts,
+		jobs:        make(chan domain.EmailOutboxEntry, emailJobQueueSize),
+	}
+	for i := 0; i < emailWorkerCount; i++ {
+		o.wg.Add(1)
+		go o.runWorker()
+	}
+	return o
+}
+
+// Enqueue persists msg and schedules it for delivery. It only fails when the
+// entry cannot be stored; delivery errors are recorded on the entry.
+func (o *EmailOutbox) Enqueue(ctx context.Context, msg EmailMessage) error {
+	entry := &domain.EmailOutboxEntry{
+		Recipients: msg.To,
+		Cc:         msg.Cc,
+		Subject:    msg.Subject,
+		Body:       msg.Body,
+		IsHTML:     msg.IsHTML,
+		HTMLBody:   msg.HTMLBody,
+		Status:     domain.EmailOutboxStatusPending,
+	}
+	if err := o.repo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to persist email: %w", err)
+	}
+
+	o.jobsMu.Lock()
+	defer o.jobsMu.Unlock()
+	if o.jobsClosed {
+		logger.Warn("Email left in outbox: service is shutting down", "outbox_id", entry.ID)
+		return nil
+	}
+	select {
+	case o.jobs <- *entry:
+	default:
+		logger.Warn("Email left in outbox: queue is full", "outbox_id", entry.ID)
+	}
+	return nil
+}
+
+// Shutdown closes the job queue and waits for all in-flight sends to finish.
+// Returns ctx.Err() if the deadline is exceeded before draining completes.
+func (o *EmailOutbox) Shutdown(ctx context.Context) error {
+	o.jobsMu.Lock()
+	if !o.jobsClosed {
+		o.jobsClosed = true
+		close(o.jobs)
+	}
+	o.jobsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *EmailOutbox) runWorker() {
+	defer o.wg.Done()
+	for entry := range o.jobs {
+		ctx := context.Background()
+		claimed, err := o.repo.Claim(ctx, entry.ID)
+		if err != nil {
+			logger.Error("Failed to claim outbox email", "outbox_id", entry.ID, "error", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		entry.Attempts++
+		o.deliver(ctx, entry)
+	}
+}
+
+// RetryPending claims up to limit failed or stranded entries and delivers them
+// inline. Returns how many were sent and how many failed again.
+func (o *EmailOutbox) RetryPending(ctx context.Context, limit int32) (sent, failed int, err error) {
+	entries, err := o.repo.ClaimRetryable(ctx, o.maxAttempts, outboxStaleAfter, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to claim outbox entries: %w", err)
+	}
+	for _, entry := range entries {
+		if o.deliver(ctx, entry) {
+			sent++
+		} else {
+			failed++
+		}
+	}
+	return sent, failed, nil
+}
+
+// deliver sends a claimed entry and records the outcome on it
+func (o *EmailOutbox) deliver(ctx context.Context, entry domain.EmailOutboxEntry) bool {
+	err := o.transport.SendMessage(ctx, EmailMessage{
+		To:       entry.Recipients,
+		Cc:       entry.Cc,
+		Subject:  entry.Subject,
+		Body:     entry.Body,
+		IsHTML:   entry.IsHTML,
+		HTMLBody: entry.HTMLBody,
+	})
+	if err == nil {
+		if err := o.repo.MarkSent(ctx, entry.ID); err != nil {
+			logger.Error("Failed to mark outbox email sent", "outbox_id", entry.ID, "error", err)
+		}
+		return true
+	}
+
+	nextAttempt := time.Now().Add(outboxBackoff(entry.Attempts))
+	logger.Warn("Outbox email delivery failed",
+		"outbox_id", entry.ID,
+		"attempt", entry.Attempts,
+		"max_attempts", o.maxAttempts,
+		"error", err)
+	if err := o.repo.MarkFailed(ctx, entry.ID, err.Error(), nextAttempt); err != nil {
+		logger.Error("Failed to mark outbox email failed", "outbox_id", entry.ID, "error", err)
+	}
+	return false
+}
+
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := outboxRetryBackoff
+	for i := int32(1); i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > outboxMaxBackoff {
+		backoff = outboxMaxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"ubertool-backend-trusted/internal/domain"
+)
+
+// Named email templates. Each has templates/email/<name>.html, rendered inside the
+// shared layout, and <name>.txt, which defines "subject" and the plaintext part.
+const (
+	EmailTemplateInvitation                = "invitation"
+	EmailTemplateAccountStatus             = "account_status"
+	EmailTemplateRentalRequest             = "rental_request"
+	EmailTemplateRentalApproval            = "rental_approval"
+	EmailTemplateRentalRejection           = "rental_rejection"
+	EmailTemplateRentalConfirmation        = "rental_confirmation"
+	EmailTemplateRentalCancellation        = "rental_cancellation"
+	EmailTemplateRentalCompletion          = "rental_completion"
+	EmailTemplateRentalPickup              = "rental_pickup"
+	EmailTemplateReturnDateRejection       = "return_date_rejection"
+	EmailTemplateTwoFactorCode             = "two_factor_code"
+	EmailTemplateAdminNotification         = "admin_notification"
+	EmailTemplateBillPaymentNotice         = "bill_payment_notice"
+	EmailTemplateBillPaymentAcknowledgment = "bill_payment_acknowledgment"
+	EmailTemplateBillReceiptConfirmation   = "bill_receipt_confirmation"
+	EmailTemplateBillDispute               = "bill_dispute"
+	EmailTemplateBillDisputeResolution     = "bill_dispute_resolution"
+)
+
+var emailTemplateNames = []string{
+	EmailTemplateInvitation,
+	EmailTemplateAccountStatus,
+	EmailTemplateRentalRequest,
+	EmailTemplateRentalApproval,
+	EmailTemplateRentalRejection,
+	EmailTemplateRentalConfirmation,
+	EmailTemplateRentalCancellation,
+	EmailTemplateRentalCompletion,
+	EmailTemplateRentalPickup,
+	EmailTemplateReturnDateRejection,
+	EmailTemplateTwoFactorCode,
+	EmailTemplateAdminNotification,
+	EmailTemplateBillPaymentNotice,
+	EmailTemplateBillPaymentAcknowledgment,
+	EmailTemplateBillReceiptConfirmation,
+	EmailTemplateBillDispute,
+	EmailTemplateBillDisputeResolution,
+}
+
+//go:embed templates/email/*.html templates/email/*.txt
+var emailTemplateFS embed.FS
+
+// InvitationEmail is the data for EmailTemplateInvitation
+type InvitationEmail struct {
+	Name    string
+	OrgName string
+	Token   string
+}
+
+// AccountStatusEmail is the data for EmailTemplateAccountStatus
+type AccountStatusEmail struct {
+	Name    string
+	OrgName string
+	Status  string
+	Reason  string
+}
+
+// RentalRequestEmail is the data for EmailTemplateRentalRequest
+type RentalRequestEmail struct {
+	OwnerName  string
+	RenterName string
+	ToolName   string
+}
+
+// RentalApprovalEmail is the data for EmailTemplateRentalApproval
+type RentalApprovalEmail struct {
+	RenterName string
+	OwnerName  string
+	ToolName   string
+	PickupNote string
+}
+
+// RentalRejectionEmail is the data for EmailTemplateRentalRejection
+type RentalRejectionEmail struct {
+	ToolName  string
+	OwnerName string
+}
+
+// RentalConfirmationEmail is the data for EmailTemplateRentalConfirmation
+type RentalConfirmationEmail struct {
+	RenterName string
+	ToolName   string
+}
+
+// RentalCancellationEmail is the data for EmailTemplateRentalCancellation
+type RentalCancellationEmail struct {
+	CancellerName string
+	ToolName      string
+	Reason        string
+}
+
+// RentalCompletionEmail is the data for EmailTemplateRentalCompletion
+type RentalCompletionEmail struct {
+	Role        string
+	ToolName    string
+	AmountCents int32
+}
+
+// RentalPickupEmail is the data for EmailTemplateRentalPickup
+type RentalPickupEmail struct {
+	Name      string
+	ToolName  string
+	StartDate string
+	EndDate   string
+}
+
+// ReturnDateRejectionEmail is the data for EmailTemplateReturnDateRejection
+type ReturnDateRejectionEmail struct {
+	ToolName       string
+	NewEndDate     string
+	Reason         string
+	TotalCostCents int32
+	CurrencyCode   string
+}
+
+// AdminNotificationEmail is the data for EmailTemplateAdminNotification
+type AdminNotificationEmail struct {
+	Subject string
+	Message string
+}
+
+// BillPaymentEmail is the data for EmailTemplateBillPaymentNotice,
+// EmailTemplateBillPaymentAcknowledgment and EmailTemplateBillReceiptConfirmation
+type BillPaymentEmail struct {
+	DebtorName      string
+	CreditorName    string
+	AmountCents     int32
+	CurrencyCode    string
+	SettlementMonth string
+	OrgName         string
+}
+
+// BillDisputeEmail is the data for EmailTemplateBillDispute
+type BillDisputeEmail struct {
+	Name           string
+	OtherPartyName string
+	AmountCents    int32
+	CurrencyCode   string
+	Reason         string
+	OrgName        string
+}
+
+// BillDisputeResolutionEmail is the data for EmailTemplateBillDisputeResolution
+type BillDisputeResolutionEmail struct {
+	Name         string
+	AmountCents  int32
+	CurrencyCode string // The org's currency; empty renders as USD
+	Resolution   string
+	Notes        string
+	OrgName      string
+}
+
+// TwoFactorCodeEmail is the data for EmailTemplateTwoFactorCode
+type TwoFactorCodeEmail struct {
+	Name string
+	Code string
+}
+
+var emailTemplateFuncs = map[string]interface{}{
+	"cents": domain.FormatCents,
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// EmailRenderer renders named templates into a subject, plaintext and HTML body
+type EmailRenderer struct {
+	templates map[string]*emailTemplate
+}
+
+// RenderedEmail is the output of EmailRenderer.Render
+type RenderedEmail struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// defaultEmailRenderer is parsed once; the templates are embedded, so a parse error is a build defect
+var defaultEmailRenderer = mustNewEmailRenderer()
+
+// NewEmailRenderer parses the embedded email templates
+func NewEmailRenderer() (*EmailRenderer, error) {
+	r := &EmailRenderer{templates: make(map[string]*emailTemplate, len(emailTemplateNames))}
+	for _, name := range emailTemplateNames {
+		html, err := htmltemplate.New(name).Funcs(emailTemplateFuncs).
+			ParseFS(emailTemplateFS, "templates/email/layout.html", "templates/email/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s.html: %w", name, err)
+		}
+		text, err := texttemplate.New(name+".txt").Funcs(emailTemplateFuncs).
+			ParseFS(emailTemplateFS, "templates/email/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse email template %s.txt: %w", name, err)
+		}
+		r.templates[name] = &emailTemplate{html: html, text: text}
+	}
+	return r, nil
+}
+
+func mustNewEmailRenderer() *EmailRenderer {
+	r, err := NewEmailRenderer()
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Render executes the named template with data
+func (r *EmailRenderer) Render(name string, data interface{}) (*RenderedEmail, error) {
+	tmpl, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template: %s", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	view := struct {
+		Subject string
+		Data    interface{}
+	}{Subject: subject.String(), Data: data}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", view); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return &RenderedEmail{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// Send renders the named template with data and sends it to a single recipient
+func (s *emailService) Send(ctx context.Context, to, templateName string, data interface{}) error {
+	return s.sendTemplate(ctx, templateName, data, []string{to}, nil)
+}
+
+// sendTemplate renders a named template and sends it as a multipart message
+func (s *emailService) sendTemplate(ctx context.Context, name string, data interface{}, to, cc []string) error {
+	rendered, err := defaultEmailRenderer.Render(name, data)
+	if err != nil {
+		return err
+	}
+	return s.sendEmail(ctx, EmailMessage{
+		To:       to,
+		Cc:       cc,
+		Subject:  rendered.Subject,
+		Body:     rendered.Text,
+		HTMLBody: rendered.HTML,
+	})
+}
@@ -3,14 +3,18 @@ package service
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
+	_ "image/gif" // register GIF decoder
 	"image/jpeg"
 	_ "image/png" // register PNG decoder
 	"path"
+	"strings"
 	"time"
 
 	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/logger"
@@ -18,11 +22,37 @@ import (
 	"ubertool-backend-trusted/internal/storage"
 )
 
+// Image storage defaults, used when the corresponding ImageStorageOptions field is zero
+const (
+	defaultDownloadURLExpiry     = time.Hour
+	defaultThumbnailMaxDimension = 300 // pixels on the longer side
+	defaultMaxImageBytes         = 10 << 20
+	defaultMaxImagesPerTool      = 10
+)
+
+var defaultAllowedImageTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+var (
+	ErrImageTypeNotAllowed = errors.New("image type not allowed")
+	ErrImageTooLarge       = errors.New("image is too large")
+	ErrTooManyImages       = errors.New("tool has reached the maximum number of images")
+)
+
+// ImageStorageOptions tunes upload validation and thumbnails. Zero values fall back to defaults.
+type ImageStorageOptions struct {
+	DownloadURLExpiry time.Duration // lifetime of signed download URLs
+	ThumbnailMaxDim   int           // thumbnails fit within this many pixels per side
+	AllowedTypes      []string      // accepted MIME types
+	MaxImageBytes     int64         // largest accepted upload
+	MaxImagesPerTool  int           // confirmed images a tool may hold
+}
+
 type imageStorageService struct {
 	toolRepo repository.ToolRepository
 	userRepo repository.UserRepository
 	orgRepo  repository.OrganizationRepository
 	storage  storage.StorageInterface
+	opts     ImageStorageOptions
 }
 
 func NewImageStorageService(
@@ -30,13 +60,53 @@ func NewImageStorageService(
 	userRepo repository.UserRepository,
 	orgRepo repository.OrganizationRepository,
 	storage storage.StorageInterface,
+	opts ImageStorageOptions,
 ) ImageStorageService {
+	if opts.DownloadURLExpiry <= 0 {
+		opts.DownloadURLExpiry = defaultDownloadURLExpiry
+	}
+	if opts.ThumbnailMaxDim <= 0 {
+		opts.ThumbnailMaxDim = defaultThumbnailMaxDimension
+	}
+	if len(opts.AllowedTypes) == 0 {
+		opts.AllowedTypes = defaultAllowedImageTypes
+	}
+	if opts.MaxImageBytes <= 0 {
+		opts.MaxImageBytes = defaultMaxImageBytes
+	}
+	if opts.MaxImagesPerTool <= 0 {
+		opts.MaxImagesPerTool = defaultMaxImagesPerTool
+	}
 	return &imageStorageService{
 		toolRepo: toolRepo,
 		userRepo: userRepo,
 		orgRepo:  orgRepo,
 		storage:  storage,
+		opts:     opts,
+	}
+}
+
+// checkContentType rejects MIME types outside the allowlist
+func (s *imageStorageService) checkContentType(contentType string) error {
+	ct := strings.ToLower(strings.TrimSpace(contentType))
+	for _, allowed := range s.opts.AllowedTypes {
+		if ct == strings.ToLower(allowed) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q (allowed: %s)", ErrImageTypeNotAllowed, contentType, strings.Join(s.opts.AllowedTypes, ", "))
+}
+
+// checkImageCount rejects another image for a tool that already holds the maximum
+func (s *imageStorageService) checkImageCount(ctx context.Context, toolID int32) error {
+	images, err := s.toolRepo.GetImages(ctx, toolID)
+	if err != nil {
+		return fmt.Errorf("failed to get tool images: %w", err)
+	}
+	if len(images) >= s.opts.MaxImagesPerTool {
+		return fmt.Errorf("%w (%d)", ErrTooManyImages, s.opts.MaxImagesPerTool)
 	}
+	return nil
 }
 
 // GetUploadUrl generates a presigned URL for uploading an image
@@ -55,6 +125,12 @@ func (s *imageStorageService) GetUploadUrl(
 	if tool.OwnerID != userID {
 		return nil, "", "", 0, fmt.Errorf("unauthorized: you do not own this tool")
 	}
+	if err := s.checkContentType(contentType); err != nil {
+		return nil, "", "", 0, err
+	}
+	if err := s.checkImageCount(ctx, toolID); err != nil {
+		return nil, "", "", 0, err
+	}
 
 	// Determine storage path
 	storagePath := fmt.Sprintf("tools/%d/%s", toolID, filename)
@@ -97,8 +173,8 @@ func (s *imageStorageService) GetUploadUrl(
 		return nil, "", "", 0, fmt.Errorf("failed to generate upload URL: %w", err)
 	}
 
-	// Generate presigned download URL (1 hour expiration)
-	downloadURL, err := s.storage.GeneratePresignedDownloadURL(ctx, storagePath, 1*time.Hour)
+	// Generate signed download URL
+	downloadURL, err := s.storage.GeneratePresignedDownloadURL(ctx, storagePath, s.opts.DownloadURLExpiry)
 	if err != nil {
 		return nil, "", "", 0, fmt.Errorf("failed to generate download URL: %w", err)
 	}
@@ -138,19 +214,45 @@ func (s *imageStorageService) ConfirmImageUpload(
 	if !exists {
 		return nil, fmt.Errorf("image file not found in storage")
 	}
+	if actualSize > s.opts.MaxImageBytes || fileSize > s.opts.MaxImageBytes {
+		size := actualSize
+		if fileSize > size {
+			size = fileSize
+		}
+		if err := s.storage.DeleteFile(ctx, image.FilePath); err != nil {
+			logger.Warn("Failed to delete oversized upload", "image_id", image.ID, "error", err)
+		}
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", ErrImageTooLarge, size, s.opts.MaxImageBytes)
+	}
+	if err := s.checkContentType(image.MimeType); err != nil {
+		return nil, err
+	}
+	existingImages, err := s.toolRepo.GetImages(ctx, toolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool images: %w", err)
+	}
+	if len(existingImages) >= s.opts.MaxImagesPerTool {
+		return nil, fmt.Errorf("%w (%d)", ErrTooManyImages, s.opts.MaxImagesPerTool)
+	}
+
+	// Generate the thumbnail before confirming, so uploads that are not decodable images stay pending
+	thumbnailPath, err := s.generateThumbnail(image.FilePath)
+	if err != nil {
+		return nil, err
+	}
 
 	// Update image record
 	if fileSize == 0 {
 		fileSize = actualSize
 	}
 	image.FileSize = fileSize
+	image.ThumbnailPath = thumbnailPath
 	image.Status = "CONFIRMED"
 	now := time.Now()
 	image.ConfirmedOn = &now
 
 	// If this is the first image for the tool, set as primary
-	existingImages, err := s.toolRepo.GetImages(ctx, toolID)
-	if err == nil && len(existingImages) == 0 {
+	if len(existingImages) == 0 {
 		image.IsPrimary = true
 	}
 
@@ -158,18 +260,15 @@ func (s *imageStorageService) ConfirmImageUpload(
 		return nil, fmt.Errorf("failed to update image: %w", err)
 	}
 
-	// Kick off thumbnail generation asynchronously so the RPC returns immediately.
-	go s.generateThumbnail(image.ID, image.FilePath)
-
+	logger.Info("thumbnail: generated", "image_id", image.ID, "path", thumbnailPath)
 	return image, nil
 }
 
-// generateThumbnail reads the confirmed image from storage, resizes it to fit within
-// 300×300 pixels (preserving aspect ratio), saves the result as JPEG, and updates
-// the thumbnail_path column. Runs in a background goroutine.
-func (s *imageStorageService) generateThumbnail(imageID int32, filePath string) {
-	ctx := context.Background()
-
+// generateThumbnail reads the uploaded image from storage, resizes it to fit within
+// ThumbnailMaxDim pixels on each side (preserving aspect ratio), saves the result as JPEG
+// beside the original and returns its storage key. The decoded format, not the type the
+// client declared, must be on the allowlist.
+func (s *imageStorageService) generateThumbnail(filePath string) (string, error) {
 	// Derive thumbnail storage key beside the original, always as JPEG.
 	dir := path.Dir(filePath)
 	base := path.Base(filePath)
@@ -180,47 +279,29 @@ func (s *imageStorageService) generateThumbnail(imageID int32, filePath string)
 	// Read original image from storage.
 	reader, err := s.storage.ReadFile(filePath)
 	if err != nil {
-		logger.Error("thumbnail: failed to read image", "image_id", imageID, "error", err)
-		return
+		return "", fmt.Errorf("failed to read image: %w", err)
 	}
 	defer reader.Close()
 
-	// Decode (JPEG and PNG are registered; add more blank imports above for other formats).
-	src, _, err := image.Decode(reader)
+	// Decode (JPEG, PNG, GIF and WebP are registered above).
+	src, format, err := image.Decode(reader)
 	if err != nil {
-		logger.Error("thumbnail: failed to decode image", "image_id", imageID, "error", err)
-		return
+		return "", fmt.Errorf("uploaded file is not a valid image: %w", err)
+	}
+	if err := s.checkContentType("image/" + format); err != nil {
+		return "", err
 	}
 
-	// Resize to fit within 300×300 preserving aspect ratio.
-	dst := resizeToFit(src, 300, 300)
+	dst := resizeToFit(src, s.opts.ThumbnailMaxDim, s.opts.ThumbnailMaxDim)
 
-	// Encode as JPEG.
 	var buf bytes.Buffer
 	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85}); err != nil {
-		logger.Error("thumbnail: failed to encode", "image_id", imageID, "error", err)
-		return
+		return "", fmt.Errorf("failed to encode thumbnail: %w", err)
 	}
-
-	// Persist thumbnail.
 	if err := s.storage.SaveFile(thumbnailPath, &buf); err != nil {
-		logger.Error("thumbnail: failed to save", "image_id", imageID, "error", err)
-		return
-	}
-
-	// Update the DB record with the thumbnail path.
-	img, err := s.toolRepo.GetImageByID(ctx, imageID)
-	if err != nil {
-		logger.Error("thumbnail: failed to fetch image record", "image_id", imageID, "error", err)
-		return
-	}
-	img.ThumbnailPath = thumbnailPath
-	if err := s.toolRepo.UpdateImage(ctx, img); err != nil {
-		logger.Error("thumbnail: failed to update record", "image_id", imageID, "error", err)
-		return
+		return "", fmt.Errorf("failed to save thumbnail: %w", err)
 	}
-
-	logger.Info("thumbnail: generated", "image_id", imageID, "path", thumbnailPath)
+	return thumbnailPath, nil
 }
 
 // resizeToFit scales src so that it fits within maxW×maxH while preserving the
@@ -299,13 +380,13 @@ func (s *imageStorageService) GetDownloadUrl(
 		path = targetImage.ThumbnailPath
 	}
 
-	// Generate presigned download URL (1 hour expiration)
-	downloadURL, err := s.storage.GeneratePresignedDownloadURL(ctx, path, 1*time.Hour)
+	// Generate signed download URL
+	expiresAt := time.Now().Add(s.opts.DownloadURLExpiry).Unix()
+	downloadURL, err := s.storage.GeneratePresignedDownloadURL(ctx, path, s.opts.DownloadURLExpiry)
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to generate download URL: %w", err)
 	}
 
-	expiresAt := time.Now().Add(1 * time.Hour).Unix()
 	return downloadURL, expiresAt, nil
 }
 
@@ -335,6 +416,9 @@ func (s *imageStorageService) DeleteImage(
 	if tool.OwnerID != userID {
 		return fmt.Errorf("unauthorized: you do not own this tool")
 	}
+	if image.ToolID != toolID {
+		return fmt.Errorf("image does not belong to this tool")
+	}
 
 	// Delete files from storage
 	if err := s.storage.DeleteFile(ctx, image.FilePath); err != nil {
@@ -347,20 +431,11 @@ func (s *imageStorageService) DeleteImage(
 		}
 	}
 
-	// Soft delete in database
+	// Soft delete in database; the repository promotes the next image if this was the primary
 	if err := s.toolRepo.DeleteImage(ctx, imageID); err != nil {
 		return fmt.Errorf("failed to delete image record: %w", err)
 	}
 
-	// If this was the primary image, set another as primary
-	if image.IsPrimary {
-		images, err := s.toolRepo.GetImages(ctx, toolID)
-		if err == nil && len(images) > 0 {
-			// Set the first remaining image as primary
-			s.toolRepo.SetPrimaryImage(ctx, toolID, images[0].ID)
-		}
-	}
-
 	return nil
 }
 
@@ -395,3 +470,45 @@ func (s *imageStorageService) SetPrimaryImage(
 	// Set as primary
 	return s.toolRepo.SetPrimaryImage(ctx, toolID, imageID)
 }
+
+// ReorderToolImages sets the display order of a tool's confirmed images
+func (s *imageStorageService) ReorderToolImages(
+	ctx context.Context,
+	userID int32,
+	toolID int32,
+	imageIDs []int32,
+) ([]domain.ToolImage, error) {
+	// Verify tool ownership
+	tool, err := s.toolRepo.GetByID(ctx, toolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify tool: %w", err)
+	}
+	if tool.OwnerID != userID {
+		return nil, fmt.Errorf("unauthorized: you do not own this tool")
+	}
+
+	images, err := s.toolRepo.GetImages(ctx, toolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tool images: %w", err)
+	}
+
+	// The list must be a permutation of the tool's confirmed images
+	remaining := make(map[int32]bool, len(images))
+	for _, img := range images {
+		remaining[img.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !remaining[id] {
+			return nil, fmt.Errorf("image %d does not belong to this tool or is listed twice", id)
+		}
+		delete(remaining, id)
+	}
+	if len(remaining) > 0 {
+		return nil, fmt.Errorf("image order must list all %d images of the tool, got %d", len(images), len(imageIDs))
+	}
+
+	if err := s.toolRepo.ReorderImages(ctx, toolID, imageIDs); err != nil {
+		return nil, fmt.Errorf("failed to reorder images: %w", err)
+	}
+	return s.toolRepo.GetImages(ctx, toolID)
+}
@@ -2,16 +2,27 @@ package service
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
 )
 
+// ledgerExportHeader is the first row of every ledger CSV export
+var ledgerExportHeader = []string{"date", "type", "amount_cents", "description", "related_rental_id"}
+
 type ledgerService struct {
 	ledgerRepo repository.LedgerRepository
+	userRepo   repository.UserRepository
 }
 
-func NewLedgerService(ledgerRepo repository.LedgerRepository) LedgerService {
-	return &ledgerService{ledgerRepo: ledgerRepo}
+func NewLedgerService(ledgerRepo repository.LedgerRepository, userRepo repository.UserRepository) LedgerService {
+	return &ledgerService{ledgerRepo: ledgerRepo, userRepo: userRepo}
 }
 
 func (s *ledgerService) GetBalance(ctx context.Context, userID, orgID int32) (int32, error) {
@@ -23,5 +34,71 @@ func (s *ledgerService) GetTransactions(ctx context.Context, userID, orgID int32
 }
 
 func (s *ledgerService) GetLedgerSummary(ctx context.Context, userID, orgID int32) (*domain.LedgerSummary, error) {
-	return s.ledgerRepo.GetSummary(ctx, userID, orgID)
+	summary, err := s.ledgerRepo.GetSummary(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if summary.BalanceMismatch {
+		logger.Warn("Stored balance differs from ledger",
+			"user_id", userID, "org_id", orgID,
+			"stored_cents", summary.StoredBalance, "ledger_cents", summary.Balance)
+	}
+	return summary, nil
+}
+
+func (s *ledgerService) GetBalanceHistory(ctx context.Context, userID, orgID int32, from, to string) ([]domain.BalanceSnapshot, error) {
+	if err := validateDateBounds(from, to); err != nil {
+		return nil, err
+	}
+	return s.ledgerRepo.GetBalanceHistory(ctx, userID, orgID, from, to)
+}
+
+func (s *ledgerService) ExportLedger(ctx context.Context, callerID, userID, orgID int32, from, to string, w io.Writer) error {
+	if userID == 0 {
+		userID = callerID
+	}
+	if err := validateDateBounds(from, to); err != nil {
+		return err
+	}
+
+	role, ok := claimedRole(ctx, callerID, orgID)
+	if !ok {
+		caller, err := s.userRepo.GetUserOrg(ctx, callerID, orgID)
+		if err != nil || caller == nil {
+			return fmt.Errorf("unauthorized: not a member of this organization")
+		}
+		role = caller.Role
+	}
+	if userID != callerID && role != domain.UserOrgRoleAdmin && role != domain.UserOrgRoleSuperAdmin {
+		return fmt.Errorf("unauthorized: only admins can export another member's ledger")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(ledgerExportHeader); err != nil {
+		return err
+	}
+	err := s.ledgerRepo.StreamTransactions(ctx, userID, orgID, from, to, func(tx domain.LedgerTransaction) error {
+		rentalID := ""
+		if tx.RelatedRentalID != nil {
+			rentalID = strconv.Itoa(int(*tx.RelatedRentalID))
+		}
+		return cw.Write([]string{tx.ChargedOn, string(tx.Type), strconv.Itoa(int(tx.Amount)), tx.Description, rentalID})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func validateDateBounds(from, to string) error {
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+	return nil
 }
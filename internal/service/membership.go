@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/security"
+)
+
+// claimedRole returns userID's role in orgID from the membership snapshot of the request's
+// access token. ok is false when the request carries no snapshot for userID, the snapshot is
+// past its TTL, or it doesn't list the org; callers then read the membership from the
+// database. A listed org is trusted without a lookup, so a member removed since the token was
+// issued keeps passing for up to the TTL; only use it where that staleness is acceptable.
+func claimedRole(ctx context.Context, userID, orgID int32) (role domain.UserOrgRole, ok bool) {
+	claims, found := security.ClaimsFromContext(ctx)
+	if !found || claims.Type != security.TokenTypeAccess || claims.UserID != userID {
+		return "", false
+	}
+	r, ok := claims.RoleIn(orgID)
+	return domain.UserOrgRole(r), ok
+}
@@ -10,14 +10,52 @@ import (
 	"ubertool-backend-trusted/internal/repository"
 )
 
+const (
+	defaultNotificationMaxAttempts = 6
+
+	// notificationStaleAfter is how long a dead letter may sit in RETRYING before
+	// the retry job assumes the run that claimed it is gone and reclaims it.
+	notificationStaleAfter = 10 * time.Minute
+)
+
+// NotificationOptions tunes how failed notification inserts are kept for retry and whether
+// high-priority notifications are also texted. Zero values fall back to defaults.
+type NotificationOptions struct {
+	DeadLetters repository.NotificationDeadLetterRepository // nil only logs failed inserts
+	MaxAttempts int32                                       // total tries per notification, including the original dispatch
+	SMS         SMSService                                  // nil disables SMS
+	Users       repository.UserRepository                   // looks up each recipient's notification preference; required with SMS
+}
+
 type notificationService struct {
-	noteRepo repository.NotificationRepository
-	fcmRepo  repository.FcmTokenRepository
-	pushSvc  PushNotificationService // nil when FCM is not configured
+	noteRepo    repository.NotificationRepository
+	fcmRepo     repository.FcmTokenRepository
+	deadLetters repository.NotificationDeadLetterRepository // nil when failed inserts are not kept
+	maxAttempts int32
+	pushSvc     PushNotificationService // nil when FCM is not configured
+	sms         SMSService              // nil when SMS is disabled
+	userRepo    repository.UserRepository
+	broker      *NotificationBroker
 }
 
 func NewNotificationService(noteRepo repository.NotificationRepository, fcmRepo repository.FcmTokenRepository) NotificationService {
-	return &notificationService{noteRepo: noteRepo, fcmRepo: fcmRepo}
+	return NewNotificationServiceWithOptions(noteRepo, fcmRepo, NotificationOptions{})
+}
+
+// NewNotificationServiceWithOptions creates a NotificationService that records failed inserts in opts.DeadLetters
+func NewNotificationServiceWithOptions(noteRepo repository.NotificationRepository, fcmRepo repository.FcmTokenRepository, opts NotificationOptions) NotificationService {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultNotificationMaxAttempts
+	}
+	return &notificationService{
+		noteRepo:    noteRepo,
+		fcmRepo:     fcmRepo,
+		deadLetters: opts.DeadLetters,
+		maxAttempts: opts.MaxAttempts,
+		sms:         opts.SMS,
+		userRepo:    opts.Users,
+		broker:      NewNotificationBroker(defaultSubscriberBuffer),
+	}
 }
 
 // SetPushService wires in the FCM push service after construction (avoids circular init).
@@ -25,33 +63,142 @@ func (s *notificationService) SetPushService(pushSvc PushNotificationService) {
 	s.pushSvc = pushSvc
 }
 
-func (s *notificationService) GetNotifications(ctx context.Context, userID int32, page, pageSize int32) ([]domain.Notification, int32, error) {
+func (s *notificationService) GetNotifications(ctx context.Context, userID int32, notificationType string, page, pageSize int32) ([]domain.Notification, int32, error) {
 	offset := (page - 1) * pageSize
-	return s.noteRepo.List(ctx, userID, pageSize, offset)
+	return s.noteRepo.List(ctx, userID, notificationType, pageSize, offset)
+}
+
+func (s *notificationService) UnreadCount(ctx context.Context, userID, orgID int32) (int32, error) {
+	return s.noteRepo.CountUnread(ctx, userID, orgID)
 }
 
 func (s *notificationService) MarkAsRead(ctx context.Context, userID int32, notificationID int64) error {
 	return s.noteRepo.MarkAsRead(ctx, notificationID, userID)
 }
 
+func (s *notificationService) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	return s.noteRepo.MarkAllRead(ctx, userID, orgID)
+}
+
 // Dispatch inserts a notification into the database and asynchronously sends an FCM push if configured.
+// High-priority notifications are also texted to recipients who opted in to SMS.
+// A failed insert is recorded for RetryFailed before the error is returned.
 func (s *notificationService) Dispatch(ctx context.Context, n *domain.Notification) error {
+	if err := s.deliver(ctx, n, true); err != nil {
+		s.deadLetter(ctx, n, false, err)
+		return err
+	}
+	return nil
+}
+
+// DispatchSilent inserts a notification into the database without firing a push notification.
+// Use this when the caller handles push delivery separately (e.g. via FCM multicast broadcast).
+func (s *notificationService) DispatchSilent(ctx context.Context, n *domain.Notification) error {
+	if err := s.deliver(ctx, n, false); err != nil {
+		s.deadLetter(ctx, n, true, err)
+		return err
+	}
+	return nil
+}
+
+func (s *notificationService) deliver(ctx context.Context, n *domain.Notification, push bool) error {
 	if err := s.noteRepo.Create(ctx, n); err != nil {
 		return err
 	}
+	s.broker.Publish(*n)
+	if !push {
+		return nil
+	}
 	if s.pushSvc != nil && n.ID > 0 {
 		logger.Debug("Dispatching push notification", "userID", n.UserID, "notificationID", n.ID, "title", n.Title)
 		s.pushSvc.SendToUser(ctx, n.UserID, n.Title, n.Message, n.ID, n.Attributes) //nolint:errcheck
 	} else if s.pushSvc == nil {
 		logger.Debug("Push service not configured, skipping push for notification", "notificationID", n.ID)
 	}
+	if s.sms != nil && n.IsHighPriority() {
+		s.sendSMS(ctx, n)
+	}
 	return nil
 }
 
-// DispatchSilent inserts a notification into the database without firing a push notification.
-// Use this when the caller handles push delivery separately (e.g. via FCM multicast broadcast).
-func (s *notificationService) DispatchSilent(ctx context.Context, n *domain.Notification) error {
-	return s.noteRepo.Create(ctx, n)
+// sendSMS texts the notification if its recipient opted in. Like the push, a failure is
+// only logged: the notification itself was delivered.
+func (s *notificationService) sendSMS(ctx context.Context, n *domain.Notification) {
+	user, err := s.userRepo.GetByID(ctx, n.UserID)
+	if err != nil {
+		logger.Warn("Failed to look up SMS preference", "userID", n.UserID, "notificationID", n.ID, "error", err)
+		return
+	}
+	if !user.WantsSMS() {
+		return
+	}
+	if err := s.sms.Send(ctx, user.PhoneNumber, n.Title+": "+n.Message); err != nil {
+		logger.Error("Failed to send SMS", "userID", n.UserID, "notificationID", n.ID, "error", err)
+	}
+}
+
+// deadLetter logs a failed insert and keeps the notification for RetryFailed. Callers
+// routinely ignore Dispatch errors, so this is the only trace the notification leaves.
+func (s *notificationService) deadLetter(ctx context.Context, n *domain.Notification, silent bool, cause error) {
+	logger.Error("Failed to create notification",
+		"userID", n.UserID,
+		"orgID", n.OrgID,
+		"title", n.Title,
+		"error", cause)
+	if s.deadLetters == nil {
+		return
+	}
+	entry := &domain.NotificationDeadLetter{
+		Notification:  *n,
+		Silent:        silent,
+		LastError:     cause.Error(),
+		NextAttemptAt: time.Now().Add(outboxBackoff(1)),
+	}
+	// The request may have been canceled, which is often why the insert failed
+	if err := s.deadLetters.Create(context.WithoutCancel(ctx), entry); err != nil {
+		logger.Error("Failed to record notification for retry, notification is lost",
+			"userID", n.UserID,
+			"title", n.Title,
+			"error", err)
+	}
+}
+
+// RetryFailed claims up to limit dead-lettered notifications and re-creates them, pushing
+// those that were not dispatched silently. Returns how many were delivered and how many failed again.
+func (s *notificationService) RetryFailed(ctx context.Context, limit int32) (delivered, failed int, err error) {
+	if s.deadLetters == nil {
+		return 0, 0, nil
+	}
+	entries, err := s.deadLetters.ClaimRetryable(ctx, s.maxAttempts, notificationStaleAfter, limit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to claim dead-lettered notifications: %w", err)
+	}
+	for _, entry := range entries {
+		n := entry.Notification
+		if err := s.deliver(ctx, &n, !entry.Silent); err != nil {
+			logger.Warn("Notification retry failed",
+				"dead_letter_id", entry.ID,
+				"attempt", entry.Attempts,
+				"max_attempts", s.maxAttempts,
+				"error", err)
+			if err := s.deadLetters.MarkFailed(ctx, entry.ID, err.Error(), time.Now().Add(outboxBackoff(entry.Attempts))); err != nil {
+				logger.Error("Failed to mark notification dead letter failed", "dead_letter_id", entry.ID, "error", err)
+			}
+			failed++
+			continue
+		}
+		if err := s.deadLetters.MarkDelivered(ctx, entry.ID, n.ID); err != nil {
+			logger.Error("Failed to mark notification dead letter delivered", "dead_letter_id", entry.ID, "error", err)
+		}
+		delivered++
+	}
+	return delivered, failed, nil
+}
+
+// Subscribe streams the user's notifications as they are dispatched; call the returned
+// function when the client disconnects.
+func (s *notificationService) Subscribe(userID int32) (<-chan domain.Notification, func()) {
+	return s.broker.Subscribe(userID)
 }
 
 // SyncDeviceToken upserts an FCM token for the user's device.
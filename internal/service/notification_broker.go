@@ -0,0 +1,83 @@
+package service
+
+import (
+	"sync"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// defaultSubscriberBuffer is how many notifications a slow stream may fall behind before
+// new ones are dropped for it. Dropped notifications are still in the database.
+const defaultSubscriberBuffer = 32
+
+// NotificationBroker fans newly created notifications out to in-process subscribers, keyed by
+// recipient. It only reaches streams connected to this server process; notifications created
+// elsewhere (e.g. the cronjob binary) are picked up by polling GetNotifications.
+type NotificationBroker struct {
+	mu     sync.RWMutex
+	subs   map[int32]map[*notificationSubscriber]struct{}
+	buffer int
+}
+
+type notificationSubscriber struct {
+	ch   chan domain.Notification
+	once sync.Once
+}
+
+func NewNotificationBroker(buffer int) *NotificationBroker {
+	if buffer <= 0 {
+		buffer = defaultSubscriberBuffer
+	}
+	return &NotificationBroker{
+		subs:   make(map[int32]map[*notificationSubscriber]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscribe registers a channel receiving the user's new notifications. The returned function
+// unregisters and closes the channel; it is safe to call more than once.
+func (b *NotificationBroker) Subscribe(userID int32) (<-chan domain.Notification, func()) {
+	sub := &notificationSubscriber{ch: make(chan domain.Notification, b.buffer)}
+
+	b.mu.Lock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*notificationSubscriber]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		sub.once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[userID], sub)
+			if len(b.subs[userID]) == 0 {
+				delete(b.subs, userID)
+			}
+			close(sub.ch)
+			b.mu.Unlock()
+		})
+	}
+	return sub.ch, unsubscribe
+}
+
+// Publish delivers n to every subscriber of n.UserID without blocking. A subscriber whose
+// buffer is full misses the notification.
+func (b *NotificationBroker) Publish(n domain.Notification) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs[n.UserID] {
+		select {
+		case sub.ch <- n:
+		default:
+			logger.Warn("Notification stream subscriber is full, dropping notification", "userID", n.UserID, "notificationID", n.ID)
+		}
+	}
+}
+
+// SubscriberCount returns the number of open subscriptions for the user
+func (b *NotificationBroker) SubscriberCount(userID int32) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[userID])
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
@@ -15,19 +16,23 @@ type organizationService struct {
 	orgRepo    repository.OrganizationRepository
 	userRepo   repository.UserRepository
 	inviteRepo repository.InvitationRepository
+	toolRepo   repository.ToolRepository
 	noteSvc    NotificationService
 	emailSvc   EmailService
 	pushSvc    PushNotificationService
+	audit      AdminAudit
 }
 
-func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, inviteRepo repository.InvitationRepository, noteSvc NotificationService, emailSvc EmailService, pushSvc PushNotificationService) OrganizationService {
+func NewOrganizationService(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, inviteRepo repository.InvitationRepository, toolRepo repository.ToolRepository, noteSvc NotificationService, emailSvc EmailService, pushSvc PushNotificationService, audit AdminAudit) OrganizationService {
 	return &organizationService{
 		orgRepo:    orgRepo,
 		userRepo:   userRepo,
 		inviteRepo: inviteRepo,
+		toolRepo:   toolRepo,
 		noteSvc:    noteSvc,
 		emailSvc:   emailSvc,
 		pushSvc:    pushSvc,
+		audit:      audit,
 	}
 }
 
@@ -41,13 +46,7 @@ func (s *organizationService) GetOrganization(ctx context.Context, id int32, cal
 		return nil, nil, err
 	}
 
-	// Get member count (non-blocked users)
-	memberCount, err := s.userRepo.CountMembersByOrg(ctx, id)
-	if err != nil {
-		logger.Warn("Failed to fetch member count for org", "orgID", id, "error", err)
-	} else {
-		org.MemberCount = memberCount
-	}
+	s.populateCounts(ctx, org, nil)
 
 	// Get calling user's role in this org
 	var userOrg *domain.UserOrg
@@ -68,7 +67,8 @@ func (s *organizationService) SearchOrganizations(ctx context.Context, name, met
 		return nil, err
 	}
 
-	// Populate admins and member count for each organization
+	// Populate admins and counts for each organization; orgs in one metro share a tool count
+	toolCounts := make(map[string]int32)
 	for i := range orgs {
 		users, userOrgs, err := s.userRepo.ListMembersByOrg(ctx, orgs[i].ID)
 		if err != nil {
@@ -85,19 +85,92 @@ func (s *organizationService) SearchOrganizations(ctx context.Context, name, met
 		}
 		orgs[i].Admins = admins
 
-		// Get member count (non-blocked users)
-		memberCount, err := s.userRepo.CountMembersByOrg(ctx, orgs[i].ID)
-		if err != nil {
-			logger.Warn("Failed to fetch member count for org", "orgID", orgs[i].ID, "error", err)
-		} else {
-			orgs[i].MemberCount = memberCount
-		}
+		s.populateCounts(ctx, &orgs[i], toolCounts)
 	}
 
 	return orgs, nil
 }
 
+// populateCounts sets the org's member count (non-blocked users) and tool count (tools in its
+// metro that tool search lists). toolCounts, keyed by metro, may be nil; a failed count is
+// logged and left at zero.
+func (s *organizationService) populateCounts(ctx context.Context, org *domain.Organization, toolCounts map[string]int32) {
+	memberCount, err := s.userRepo.CountMembersByOrg(ctx, org.ID)
+	if err != nil {
+		logger.Warn("Failed to fetch member count for org", "orgID", org.ID, "error", err)
+	} else {
+		org.MemberCount = memberCount
+	}
+
+	if s.toolRepo == nil {
+		return
+	}
+	metro := strings.ToLower(domain.NormalizeMetro(org.Metro))
+	if count, ok := toolCounts[metro]; ok {
+		org.ToolCount = count
+		return
+	}
+	toolCount, err := s.toolRepo.CountAvailableByMetro(ctx, org.Metro)
+	if err != nil {
+		logger.Warn("Failed to fetch tool count for org", "orgID", org.ID, "metro", org.Metro, "error", err)
+		return
+	}
+	org.ToolCount = toolCount
+	if toolCounts != nil {
+		toolCounts[metro] = toolCount
+	}
+}
+
+func (s *organizationService) ListMetros(ctx context.Context) ([]string, error) {
+	return s.orgRepo.ListMetros(ctx)
+}
+
+func (s *organizationService) SetAdjacentMetros(ctx context.Context, callerID, orgID int32, metros []string) (*domain.Organization, error) {
+	callerUserOrg, err := s.userRepo.GetUserOrg(ctx, callerID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("permission denied: not a member of this organization")
+	}
+	if callerUserOrg.Role != domain.UserOrgRoleSuperAdmin && callerUserOrg.Role != domain.UserOrgRoleAdmin {
+		return nil, fmt.Errorf("permission denied: ADMIN or SUPER_ADMIN role required to update organization")
+	}
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("organization not found: %w", err)
+	}
+
+	// Store canonical names once each; the org's own metro is always searched
+	seen := map[string]bool{strings.ToLower(domain.NormalizeMetro(org.Metro)): true}
+	adjacent := []string{}
+	for _, m := range metros {
+		m = domain.NormalizeMetro(m)
+		if m == "" || seen[strings.ToLower(m)] {
+			continue
+		}
+		seen[strings.ToLower(m)] = true
+		adjacent = append(adjacent, m)
+	}
+
+	if err := s.orgRepo.SetAdjacentMetros(ctx, orgID, adjacent); err != nil {
+		return nil, err
+	}
+	org.AdjacentMetros = adjacent
+	if s.audit != nil {
+		s.audit.Record(ctx, callerID, orgID, domain.AdminAuditActionSetAdjacentMetros, domain.AdminAuditTargetOrganization, orgID, map[string]string{
+			"adjacent_metros": strings.Join(adjacent, ", "),
+		})
+	}
+	return org, nil
+}
+
 func (s *organizationService) CreateOrganization(ctx context.Context, userID int32, org *domain.Organization) error {
+	currency, err := domain.NormalizeCurrencyCode(org.CurrencyCode)
+	if err != nil {
+		return err
+	}
+	org.CurrencyCode = currency
+	if org.AdminPhoneNumber, err = domain.NormalizePhoneNumber(org.AdminPhoneNumber); err != nil {
+		return err
+	}
 	if err := s.orgRepo.Create(ctx, org); err != nil {
 		return err
 	}
@@ -148,11 +221,28 @@ func (s *organizationService) UpdateOrganization(ctx context.Context, callerID i
 	if org.MaxBillsplitRentalCostCents == 0 {
 		org.MaxBillsplitRentalCostCents = current.MaxBillsplitRentalCostCents
 	}
+	// An empty currency keeps the current one
+	if org.CurrencyCode == "" {
+		org.CurrencyCode = current.CurrencyCode
+	} else if org.CurrencyCode, err = domain.NormalizeCurrencyCode(org.CurrencyCode); err != nil {
+		return err
+	}
+	if org.AdminPhoneNumber, err = domain.NormalizePhoneNumber(org.AdminPhoneNumber); err != nil {
+		return err
+	}
 
 	// 5. Persist the update.
 	if err := s.orgRepo.Update(ctx, org); err != nil {
 		return err
 	}
+	if s.audit != nil {
+		s.audit.Record(ctx, callerID, org.ID, domain.AdminAuditActionUpdateOrganization, domain.AdminAuditTargetOrganization, org.ID, map[string]string{
+			"settlement_threshold_cents":      fmt.Sprintf("%d", org.SettlementThresholdCents),
+			"max_billsplit_rental_cost_cents": fmt.Sprintf("%d", org.MaxBillsplitRentalCostCents),
+			"auto_activate_rentals":           fmt.Sprintf("%t", org.AutoActivateRentals),
+			"currency_code":                   org.Currency(),
+		})
+	}
 
 	// 6. Broadcast to all active members when either price threshold changed.
 	if thresholdChanged || maxCostChanged {
@@ -163,6 +253,19 @@ func (s *organizationService) UpdateOrganization(ctx context.Context, callerID i
 	return nil
 }
 
+// ListMembers returns the organization roster matching filter. Only ADMIN and
+// SUPER_ADMIN members may list it.
+func (s *organizationService) ListMembers(ctx context.Context, adminID, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error) {
+	callerUserOrg, err := s.userRepo.GetUserOrg(ctx, adminID, orgID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("permission denied: not a member of this organization")
+	}
+	if callerUserOrg.Role != domain.UserOrgRoleSuperAdmin && callerUserOrg.Role != domain.UserOrgRoleAdmin {
+		return nil, nil, 0, fmt.Errorf("permission denied: ADMIN or SUPER_ADMIN role required to list members")
+	}
+	return s.userRepo.ListMembersByOrgFiltered(ctx, orgID, filter)
+}
+
 // broadcastThresholdUpdate notifies all non-blocked org members that the payment
 // thresholds have changed. It runs in a background goroutine so that the gRPC
 // handler returns immediately. Each member receives:
@@ -181,10 +284,10 @@ func (s *organizationService) broadcastThresholdUpdate(org *domain.Organization)
 
 	subject := fmt.Sprintf("[%s] Payment Threshold Update", org.Name)
 	msgBody := fmt.Sprintf(
-		"Settlement threshold updated to $%.2f; max bill-split rental cost updated to $%.2f. "+
+		"Settlement threshold updated to %s; max bill-split rental cost updated to %s. "+
 			"Rentals above the cap must be settled directly between Lender and Renter.",
-		float64(org.SettlementThresholdCents)/100,
-		float64(org.MaxBillsplitRentalCostCents)/100,
+		domain.FormatCents(org.SettlementThresholdCents, org.Currency()),
+		domain.FormatCents(org.MaxBillsplitRentalCostCents, org.Currency()),
 	)
 
 	var memberUserIDs []int32
@@ -218,12 +321,12 @@ func (s *organizationService) broadcastThresholdUpdate(org *domain.Organization)
 			user := users[i]
 			emailBody := fmt.Sprintf(
 				"Hello %s,\n\nThe payment thresholds for %s have been updated.\n\n"+
-					"Settlement Threshold: $%.2f\nMax Rental Cost for Bill Split: $%.2f\n\n"+
+					"Settlement Threshold: %s\nMax Rental Cost for Bill Split: %s\n\n"+
 					"Note: Rentals above the cap must be settled directly between Lender and Renter.\n\n"+
 					"Best regards,\nUbertool Team",
 				user.Name, org.Name,
-				float64(org.SettlementThresholdCents)/100,
-				float64(org.MaxBillsplitRentalCostCents)/100,
+				domain.FormatCents(org.SettlementThresholdCents, org.Currency()),
+				domain.FormatCents(org.MaxBillsplitRentalCostCents, org.Currency()),
 			)
 			_ = s.emailSvc.SendAdminNotification(ctx, user.Email, subject, emailBody)
 		}
@@ -239,9 +342,9 @@ func (s *organizationService) broadcastThresholdUpdate(org *domain.Organization)
 			"max_billsplit_rental_cost_cents":          fmt.Sprintf("%d", org.MaxBillsplitRentalCostCents),
 		}
 		pushBody := fmt.Sprintf(
-			"Settlement threshold: $%.2f | Max rental cost: $%.2f",
-			float64(org.SettlementThresholdCents)/100,
-			float64(org.MaxBillsplitRentalCostCents)/100,
+			"Settlement threshold: %s | Max rental cost: %s",
+			domain.FormatCents(org.SettlementThresholdCents, org.Currency()),
+			domain.FormatCents(org.MaxBillsplitRentalCostCents, org.Currency()),
 		)
 		if err := s.pushSvc.SendMulticastToUsers(ctx, memberUserIDs, subject, pushBody, fcmData); err != nil {
 			logger.Warn("broadcastThresholdUpdate: FCM multicast failed",
@@ -257,19 +360,14 @@ func (s *organizationService) ListMyOrganizations(ctx context.Context, userID in
 	}
 
 	var orgs []domain.Organization
+	toolCounts := make(map[string]int32)
 	for _, uo := range userOrgs {
 		org, err := s.orgRepo.GetByID(ctx, uo.OrgID)
 		if err != nil {
 			continue
 		}
 		if org != nil {
-			// Get member count (non-blocked users)
-			memberCount, err := s.userRepo.CountMembersByOrg(ctx, org.ID)
-			if err != nil {
-				logger.Warn("Failed to fetch member count for org", "orgID", org.ID, "error", err)
-			} else {
-				org.MemberCount = memberCount
-			}
+			s.populateCounts(ctx, org, toolCounts)
 			orgs = append(orgs, *org)
 		}
 	}
@@ -291,6 +389,9 @@ func (s *organizationService) JoinOrganizationWithInvite(ctx context.Context, us
 	if inv.UsedOn != nil {
 		return nil, nil, errors.New("invitation already used")
 	}
+	if inv.RevokedOn != nil {
+		return nil, nil, ErrInviteRevoked
+	}
 	expiresOn, _ := time.Parse("2006-01-02", inv.ExpiresOn)
 	if expiresOn.Before(time.Now()) {
 		return nil, nil, errors.New("invitation code is invalid or expired")
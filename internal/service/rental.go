@@ -2,22 +2,62 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
 	"ubertool-backend-trusted/internal/repository"
 	"ubertool-backend-trusted/internal/utils"
 )
 
+// ErrExtensionPending is returned when a new return-date negotiation is started while another
+// is still open. A rental carries at most one extension negotiation at a time; the renter may
+// amend their own pending request but not open a second one.
+var ErrExtensionPending = domain.Conflictf("an extension request is already pending for this rental")
+
+// ErrRentingBlocked and ErrLendingBlocked are returned when a member whose privileges were
+// revoked, e.g. by dispute resolution, tries to rent or lend. The block reason is appended.
+var (
+	ErrRentingBlocked = domain.Unauthorizedf("renting privileges are blocked in this organization")
+	ErrLendingBlocked = domain.Unauthorizedf("lending privileges are blocked in this organization")
+)
+
+// ErrRentalNotCancellable is returned when the renter cancels a rental that has already been
+// picked up or has ended. An active rental is closed by returning the tool and completing it.
+var ErrRentalNotCancellable = domain.Conflictf("rental can no longer be cancelled")
+
+// ErrCancelReasonRequired is returned when the owner cancels without telling the renter why
+var ErrCancelReasonRequired = domain.Invalidf("a reason is required when the owner cancels a rental")
+
+// DefaultRentalStartDateGrace is how far behind UTC midnight a new start date may fall, so a
+// renter west of UTC can still book "today" after UTC has rolled over to tomorrow
+const DefaultRentalStartDateGrace = 12 * time.Hour
+
+// RentalOptions holds rental settings that come from configuration
+type RentalOptions struct {
+	StartDateGrace time.Duration // Timezone skew allowed for start dates; <= 0 uses DefaultRentalStartDateGrace
+	// Transactor makes multi-step rental writes atomic; nil runs each step on its own
+	Transactor repository.Transactor
+	// PricingRounding is recorded on new rentals and prices their partial weeks and months;
+	// empty uses utils.RoundingPolicyRoundUp
+	PricingRounding utils.RoundingPolicy
+}
+
 type rentalService struct {
-	rentalRepo repository.RentalRepository
-	toolRepo   repository.ToolRepository
-	ledgerRepo repository.LedgerRepository
-	userRepo   repository.UserRepository
-	emailSvc   EmailService
-	noteSvc    NotificationService
+	rentalRepo     repository.RentalRepository
+	toolRepo       repository.ToolRepository
+	ledgerRepo     repository.LedgerRepository
+	userRepo       repository.UserRepository
+	emailSvc       EmailService
+	noteSvc        NotificationService
+	eventRepo      repository.RentalEventRepository
+	startDateGrace time.Duration
+	transactor     repository.Transactor
+	rounding       utils.RoundingPolicy
 }
 
 func NewRentalService(
@@ -27,38 +67,96 @@ func NewRentalService(
 	userRepo repository.UserRepository,
 	emailSvc EmailService,
 	noteSvc NotificationService,
+	eventRepo repository.RentalEventRepository,
+) RentalService {
+	return NewRentalServiceWithOptions(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteSvc, eventRepo, RentalOptions{})
+}
+
+// NewRentalServiceWithOptions is NewRentalService with explicit rental settings
+func NewRentalServiceWithOptions(
+	rentalRepo repository.RentalRepository,
+	toolRepo repository.ToolRepository,
+	ledgerRepo repository.LedgerRepository,
+	userRepo repository.UserRepository,
+	emailSvc EmailService,
+	noteSvc NotificationService,
+	eventRepo repository.RentalEventRepository,
+	opts RentalOptions,
 ) RentalService {
+	if opts.StartDateGrace <= 0 {
+		opts.StartDateGrace = DefaultRentalStartDateGrace
+	}
+	if opts.PricingRounding == "" {
+		opts.PricingRounding = utils.RoundingPolicyRoundUp
+	}
 	return &rentalService{
-		rentalRepo: rentalRepo,
-		toolRepo:   toolRepo,
-		ledgerRepo: ledgerRepo,
-		userRepo:   userRepo,
-		emailSvc:   emailSvc,
-		noteSvc:    noteSvc,
+		rentalRepo:     rentalRepo,
+		toolRepo:       toolRepo,
+		ledgerRepo:     ledgerRepo,
+		userRepo:       userRepo,
+		emailSvc:       emailSvc,
+		noteSvc:        noteSvc,
+		eventRepo:      eventRepo,
+		startDateGrace: opts.StartDateGrace,
+		transactor:     opts.Transactor,
+		rounding:       opts.PricingRounding,
+	}
+}
+
+// inTx runs fn on a copy of the service whose repositories share one transaction, so the
+// steps fn writes commit or roll back together. Without a transactor fn runs on s itself.
+func (s *rentalService) inTx(ctx context.Context, fn func(tx *rentalService) error) error {
+	if s.transactor == nil {
+		return fn(s)
+	}
+	return s.transactor.WithTx(ctx, func(repos repository.TxRepositories) error {
+		tx := *s
+		tx.rentalRepo = repos.Rentals
+		tx.toolRepo = repos.Tools
+		tx.ledgerRepo = repos.Ledger
+		tx.userRepo = repos.Users
+		tx.eventRepo = repos.RentalEvents
+		tx.transactor = nil
+		return fn(&tx)
+	})
+}
+
+// recordTransition appends a status change to the rental's history. It is best-effort:
+// the transition has already been persisted, so a failure is logged and not returned.
+func (s *rentalService) recordTransition(ctx context.Context, rt *domain.Rental, from domain.RentalStatus, actorID int32, note string) {
+	if s.eventRepo == nil || from == rt.Status {
+		return
+	}
+	event := &domain.RentalEvent{
+		RentalID:    rt.ID,
+		ActorUserID: &actorID,
+		FromStatus:  from,
+		ToStatus:    rt.Status,
+		Note:        note,
+	}
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		logger.Error("Failed to record rental event", "rental_id", rt.ID, "from", from, "to", rt.Status, "error", err)
 	}
 }
 
 func (s *rentalService) CreateRentalRequest(ctx context.Context, renterID, toolID, orgID int32, startDateStr, endDateStr string) (*domain.Rental, error) {
-	tool, err := s.toolRepo.GetByID(ctx, toolID)
+	start, end, err := validateNewRentalPeriod(startDateStr, endDateStr, time.Now(), s.startDateGrace)
 	if err != nil {
 		return nil, err
 	}
-	// Verify tool availability (simplified: check status)
-	// Ideally check if tool is already rented in this period.
 
-	start, err := time.Parse("2006-01-02", startDateStr)
+	tool, err := s.toolRepo.GetByID(ctx, toolID)
 	if err != nil {
 		return nil, err
 	}
-	end, err := time.Parse("2006-01-02", endDateStr)
-	if err != nil {
+	if err := s.checkNotBlocked(ctx, renterID, orgID, false); err != nil {
 		return nil, err
 	}
-
-	// Calculate cost using tiered pricing algorithm
-	if !end.After(start) {
-		return nil, errors.New("end date must be after start date (minimum 1 day rental)")
+	if err := s.checkNotBlocked(ctx, tool.OwnerID, orgID, true); err != nil {
+		return nil, err
 	}
+	// Verify tool availability (simplified: check status)
+	// Ideally check if tool is already rented in this period.
 
 	// Build price snapshot from tool at the time of rental creation
 	snapshot := utils.RentalPriceSnapshot{
@@ -66,6 +164,7 @@ func (s *rentalService) CreateRentalRequest(ctx context.Context, renterID, toolI
 		PricePerDayCents:   tool.PricePerDayCents,
 		PricePerWeekCents:  tool.PricePerWeekCents,
 		PricePerMonthCents: tool.PricePerMonthCents,
+		Rounding:           s.rounding,
 	}
 
 	totalCost, err := utils.CalculateRentalCost(start, end, snapshot)
@@ -95,6 +194,7 @@ func (s *rentalService) CreateRentalRequest(ctx context.Context, renterID, toolI
 		WeeklyPriceCents:     tool.PricePerWeekCents,
 		MonthlyPriceCents:    tool.PricePerMonthCents,
 		ReplacementCostCents: tool.ReplacementCostCents,
+		PricingRounding:      string(s.rounding),
 		TotalCostCents:       totalCost,
 		Status:               domain.RentalStatusPending,
 	}
@@ -102,12 +202,17 @@ func (s *rentalService) CreateRentalRequest(ctx context.Context, renterID, toolI
 	if err := s.rentalRepo.Create(ctx, rental); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rental, "", renterID, "")
 
 	// Notify owner
 	owner, _ := s.userRepo.GetByID(ctx, tool.OwnerID)
 	renter, _ := s.userRepo.GetByID(ctx, renterID)
 	if owner != nil && renter != nil {
-		_ = s.emailSvc.SendRentalRequestNotification(ctx, owner.Email, renter.Name, tool.Name, renter.Email)
+		_ = s.emailSvc.SendRentalRequestNotification(ctx, owner.Email, RentalRequestEmail{
+			OwnerName:  owner.Name,
+			RenterName: renter.Name,
+			ToolName:   tool.Name,
+		}, renter.Email)
 
 		notif := &domain.Notification{
 			UserID:  owner.ID,
@@ -132,10 +237,13 @@ func (s *rentalService) ApproveRentalRequest(ctx context.Context, ownerID, renta
 		return nil, err
 	}
 	if rt.OwnerID != ownerID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusPending {
-		return nil, errors.New("rental is not pending")
+		return nil, domain.Conflictf("rental is not pending")
+	}
+	if err := s.checkNotBlocked(ctx, ownerID, rt.OrgID, true); err != nil {
+		return nil, err
 	}
 
 	rt.Status = domain.RentalStatusApproved
@@ -143,6 +251,7 @@ func (s *rentalService) ApproveRentalRequest(ctx context.Context, ownerID, renta
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusPending, ownerID, pickupNote)
 
 	// Notify renter
 	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
@@ -150,7 +259,12 @@ func (s *rentalService) ApproveRentalRequest(ctx context.Context, ownerID, renta
 	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
 
 	if renter != nil && owner != nil && tool != nil {
-		_ = s.emailSvc.SendRentalApprovalNotification(ctx, renter.Email, tool.Name, owner.Name, pickupNote, owner.Email)
+		_ = s.emailSvc.SendRentalApprovalNotification(ctx, renter.Email, RentalApprovalEmail{
+			RenterName: renter.Name,
+			OwnerName:  owner.Name,
+			ToolName:   tool.Name,
+			PickupNote: pickupNote,
+		}, owner.Email)
 
 		notif := &domain.Notification{
 			UserID:  renter.ID,
@@ -169,19 +283,48 @@ func (s *rentalService) ApproveRentalRequest(ctx context.Context, ownerID, renta
 	return rt, nil
 }
 
+// checkNotBlocked rejects a member whose renting (lending=false) or lending (lending=true)
+// privileges are blocked in the organization.
+func (s *rentalService) checkNotBlocked(ctx context.Context, userID, orgID int32, lending bool) error {
+	uo, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to get membership for user %d: %w", userID, err)
+	}
+	blockErr := ErrRentingBlocked
+	blocked := uo.RentingBlocked
+	if lending {
+		blockErr = ErrLendingBlocked
+		blocked = uo.LendingBlocked
+	}
+	if !blocked {
+		return nil
+	}
+	if uo.BlockedReason != "" {
+		return fmt.Errorf("%w: %s", blockErr, uo.BlockedReason)
+	}
+	return blockErr
+}
+
 func (s *rentalService) RejectRentalRequest(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error) {
 	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
 	if err != nil {
 		return nil, err
 	}
 	if rt.OwnerID != ownerID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 
+	from := rt.Status
 	rt.Status = domain.RentalStatusRejected
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, from, ownerID, "")
+	if holdsFunds(from) {
+		if err := s.releaseRentalHold(ctx, rt, "rejected"); err != nil {
+			return nil, err
+		}
+	}
 
 	// Notify renter
 	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
@@ -207,38 +350,72 @@ func (s *rentalService) RejectRentalRequest(ctx context.Context, ownerID, rental
 	return rt, nil
 }
 
-func (s *rentalService) CancelRental(ctx context.Context, renterID, rentalID int32, reason string) (*domain.Rental, error) {
+func (s *rentalService) CancelRental(ctx context.Context, userID, rentalID int32, reason string) (*domain.Rental, error) {
 	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
 	if err != nil {
 		return nil, err
 	}
-	if rt.RenterID != renterID {
-		return nil, errors.New("unauthorized")
+	byOwner := rt.OwnerID == userID
+	if rt.RenterID != userID && !byOwner {
+		return nil, domain.ErrUnauthorized
+	}
+	if byOwner && strings.TrimSpace(reason) == "" {
+		return nil, ErrCancelReasonRequired
+	}
+	switch rt.Status {
+	case domain.RentalStatusPending, domain.RentalStatusApproved, domain.RentalStatusScheduled:
+	default:
+		return nil, fmt.Errorf("%w: status is %s", ErrRentalNotCancellable, rt.Status)
 	}
 
+	from := rt.Status
 	rt.Status = domain.RentalStatusCancelled
 	// rt.CancelReason = reason // Not in domain
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, from, userID, reason)
+	if holdsFunds(from) {
+		if err := s.releaseRentalHold(ctx, rt, "cancelled"); err != nil {
+			return nil, err
+		}
+	}
 
-	// Notify owner
-	renter, _ := s.userRepo.GetByID(ctx, renterID)
+	// A scheduled rental marked the tool RENTED at finalize
+	var tool *domain.Tool
+	if from == domain.RentalStatusScheduled {
+		if tool, err = s.syncToolStatus(ctx, rt); err != nil {
+			return nil, err
+		}
+	} else {
+		tool, _ = s.toolRepo.GetByID(ctx, rt.ToolID)
+	}
+
+	// Notify the other party
+	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
 	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
-	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
 
 	if renter != nil && owner != nil && tool != nil {
-		_ = s.emailSvc.SendRentalCancellationNotification(ctx, owner.Email, renter.Name, tool.Name, reason, renter.Email)
+		canceller, recipient := renter, owner
+		title, message := "Rental Cancelled", fmt.Sprintf("%s cancelled rental request for %s", renter.Name, tool.Name)
+		cancelledBy := "RENTER"
+		if byOwner {
+			canceller, recipient = owner, renter
+			title, message = "Rental Cancelled by Owner", fmt.Sprintf("%s cancelled your rental of %s: %s", owner.Name, tool.Name, reason)
+			cancelledBy = "OWNER"
+		}
+		_ = s.emailSvc.SendRentalCancellationNotification(ctx, recipient.Email, canceller.Name, tool.Name, reason, canceller.Email)
 
 		notif := &domain.Notification{
-			UserID:  owner.ID,
+			UserID:  recipient.ID,
 			OrgID:   rt.OrgID,
-			Title:   "Rental Cancelled",
-			Message: fmt.Sprintf("%s cancelled rental request for %s", renter.Name, tool.Name),
+			Title:   title,
+			Message: message,
 			Attributes: map[string]string{
-				"type":       "RENTAL_CANCELLED",
-				"rental_id":  fmt.Sprintf("%d", rt.ID),
-				"channel_id": string(domain.ChannelRentalRequest),
+				"type":         "RENTAL_CANCELLED",
+				"rental_id":    fmt.Sprintf("%d", rt.ID),
+				"cancelled_by": cancelledBy,
+				"channel_id":   string(domain.ChannelRentalRequest),
 			},
 		}
 		_ = s.noteSvc.Dispatch(ctx, notif)
@@ -253,27 +430,30 @@ func (s *rentalService) FinalizeRentalRequest(ctx context.Context, renterID, ren
 		return nil, nil, nil, err
 	}
 	if rt.RenterID != renterID {
-		return nil, nil, nil, errors.New("unauthorized")
+		return nil, nil, nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusApproved {
-		return nil, nil, nil, errors.New("rental is not approved by owner")
+		return nil, nil, nil, domain.Conflictf("rental is not approved by owner")
 	}
 
-	// No payment deduction at this stage - all transactions happen at rental completion
-
 	// Update rental
 	rt.Status = domain.RentalStatusScheduled
-	// Copy EndDate to LastAgreedEndDate since renter has confirmed the rental
-	rt.LastAgreedEndDate = &rt.EndDate
+	// The renter has confirmed the rental, so its end date is now agreed by both parties
+	agreeEndDate(rt)
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, nil, nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusApproved, renterID, "")
 
-	// Update tool status
-	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
-	if tool != nil {
-		tool.Status = domain.ToolStatusRented
-		_ = s.toolRepo.Update(ctx, tool)
+	// Reserve the estimated cost; the renter is only charged when the rental completes
+	if err := s.placeRentalHold(ctx, rt); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// The scheduled rental now occupies the tool
+	tool, err := s.syncToolStatus(ctx, rt)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	// Notify owner
@@ -298,12 +478,12 @@ func (s *rentalService) FinalizeRentalRequest(ctx context.Context, renterID, ren
 	}
 
 	// Search other rentals for lists
-	approved, _, err := s.rentalRepo.ListByTool(ctx, rt.ToolID, 0, []string{string(domain.RentalStatusApproved)}, 1, 100)
+	approved, _, err := s.rentalRepo.ListByTool(ctx, rt.ToolID, 0, []string{string(domain.RentalStatusApproved)}, "", "", 1, 100)
 	if err != nil {
 		// Log error but don't fail?
 		approved = []domain.Rental{}
 	}
-	pending, _, err := s.rentalRepo.ListByTool(ctx, rt.ToolID, 0, []string{string(domain.RentalStatusPending)}, 1, 100)
+	pending, _, err := s.rentalRepo.ListByTool(ctx, rt.ToolID, 0, []string{string(domain.RentalStatusPending)}, "", "", 1, 100)
 	if err != nil {
 		pending = []domain.Rental{}
 	}
@@ -317,16 +497,17 @@ func (s *rentalService) ActivateRental(ctx context.Context, userID, rentalID int
 		return nil, err
 	}
 	if rt.Status != domain.RentalStatusScheduled {
-		return nil, errors.New("rental is not in scheduled status")
+		return nil, domain.Conflictf("rental is not in scheduled status")
 	}
 	if rt.RenterID != userID && rt.OwnerID != userID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 
 	rt.Status = domain.RentalStatusActive
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusScheduled, userID, "")
 
 	// Notify other party
 	var otherID int32
@@ -398,12 +579,19 @@ func (s *rentalService) ChangeRentalDates(ctx context.Context, userID, rentalID
 	if err != nil {
 		return nil, err
 	}
+	// Moving the start of a rental that has not begun is held to the same rule as a new request
+	if isPreActive(rt.Status) && nStart.Format("2006-01-02") != rt.StartDate {
+		if err := checkStartNotPast(nStart, time.Now(), s.startDateGrace); err != nil {
+			return nil, err
+		}
+	}
 
 	newCost, err := s.calcCost(rt, nStart.Format("2006-01-02"), nEnd.Format("2006-01-02"))
 	if err != nil {
 		return nil, err
 	}
 
+	from := rt.Status
 	if err := s.applyDateChange(ctx, rt, tool, isRenter, isOwner, newStart, nStart, nEnd, newCost); err != nil {
 		return nil, err
 	}
@@ -411,6 +599,7 @@ func (s *rentalService) ChangeRentalDates(ctx context.Context, userID, rentalID
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, from, userID, "requested end date "+rt.EndDate)
 	return rt, nil
 }
 
@@ -422,7 +611,7 @@ func (s *rentalService) resolveRentalRole(userID int32, rt *domain.Rental) (isRe
 	case rt.OwnerID:
 		return false, true, nil
 	default:
-		return false, false, errors.New("unauthorized")
+		return false, false, domain.ErrUnauthorized
 	}
 }
 
@@ -437,18 +626,7 @@ func (s *rentalService) resolveNewDates(newStart, newEnd string, rt *domain.Rent
 	if newEnd != "" {
 		endStr = newEnd
 	}
-	nStart, err = time.Parse("2006-01-02", startStr)
-	if err != nil {
-		return nStart, nEnd, fmt.Errorf("invalid start date: %w", err)
-	}
-	nEnd, err = time.Parse("2006-01-02", endStr)
-	if err != nil {
-		return nStart, nEnd, fmt.Errorf("invalid end date: %w", err)
-	}
-	if !nEnd.After(nStart) {
-		return nStart, nEnd, errors.New("end date must be after start date (minimum 1 day rental)")
-	}
-	return nStart, nEnd, nil
+	return parseRentalPeriod(startStr, endStr)
 }
 
 // applyDateChange mutates rt in-place based on the transition rule that applies to the current
@@ -467,29 +645,51 @@ func (s *rentalService) applyDateChange(ctx context.Context, rt *domain.Rental,
 	case isRenter && isActiveOrOverdue(rt.Status):
 		// Active/overdue: renter may only extend the end date (start is locked).
 		if newStart != "" && nStart.Format("2006-01-02") != rt.StartDate {
-			return errors.New("cannot change start date of active rental")
+			return domain.Conflictf("cannot change start date of active rental")
 		}
-		rt.EndDate = nEnd.Format("2006-01-02")
-		rt.TotalCostCents = newCost
+		// The request is held in RequestedEndDate; EndDate and its cost stay as agreed until approval.
+		requested := nEnd.Format("2006-01-02")
+		rt.RequestedEndDate = &requested
 		rt.Status = domain.RentalStatusReturnDateChanged
-		return s.notifyOwnerExtensionRequest(ctx, rt, tool, rentalIDStr, nEnd, "Return Date Extension Request",
+		return s.notifyOwnerExtensionRequest(ctx, rt, tool, rentalIDStr, newCost, "Return Date Extension Request",
 			fmt.Sprintf("Renter requests to extend return date for %s to %s.", tool.Name, nEnd.Format("2006-01-02")),
 			"RETURN_DATE_CHANGE_REQUEST")
 
 	case isRenter && rt.Status == domain.RentalStatusReturnDateChanged:
 		// Renter is amending their pending extension request.
 		if newStart != "" && nStart.Format("2006-01-02") != rt.StartDate {
-			return errors.New("cannot change start date of active rental")
+			return domain.Conflictf("cannot change start date of active rental")
 		}
-		rt.EndDate = nEnd.Format("2006-01-02")
-		rt.TotalCostCents = newCost
+		requested := nEnd.Format("2006-01-02")
+		rt.RequestedEndDate = &requested
 		// Status stays RETURN_DATE_CHANGED.
-		return s.notifyOwnerExtensionRequest(ctx, rt, tool, rentalIDStr, nEnd, "Extension Request Updated",
+		return s.notifyOwnerExtensionRequest(ctx, rt, tool, rentalIDStr, newCost, "Extension Request Updated",
 			fmt.Sprintf("Renter updated their extension request for %s to %s.", tool.Name, nEnd.Format("2006-01-02")),
 			"RETURN_DATE_CHANGE_REQUEST_UPDATED")
 
+	case isOwner && isActiveOrOverdue(rt.Status):
+		// Active/overdue: owner may ask for the tool back early; the renter has to accept.
+		if newStart != "" && nStart.Format("2006-01-02") != rt.StartDate {
+			return domain.Conflictf("cannot change start date of active rental")
+		}
+		if err := checkEarlyReturnDate(rt, nEnd, time.Now()); err != nil {
+			return err
+		}
+		requested := nEnd.Format("2006-01-02")
+		rt.RequestedEndDate = &requested
+		rt.Status = domain.RentalStatusEarlyReturnRequested
+		return s.notifyRenterEarlyReturnRequest(ctx, rt, tool, rentalIDStr, newCost)
+
+	case rt.Status == domain.RentalStatusReturnDateChanged,
+		rt.Status == domain.RentalStatusReturnDateChangeRejected,
+		rt.Status == domain.RentalStatusEarlyReturnRequested:
+		// The owner must answer the pending request, or the renter must acknowledge the
+		// owner's counter-proposal or answer their early return request, before another
+		// date change can start.
+		return ErrExtensionPending
+
 	default:
-		return errors.New("cannot change dates in current status and role")
+		return domain.Conflictf("cannot change dates in current status and role")
 	}
 }
 
@@ -531,7 +731,7 @@ func (s *rentalService) notifyPreActiveDateChange(ctx context.Context, rt *domai
 }
 
 // notifyOwnerExtensionRequest notifies the owner about a new or updated return-date extension request.
-func (s *rentalService) notifyOwnerExtensionRequest(ctx context.Context, rt *domain.Rental, tool *domain.Tool, rentalIDStr string, nEnd time.Time, title, message, notifType string) error {
+func (s *rentalService) notifyOwnerExtensionRequest(ctx context.Context, rt *domain.Rental, tool *domain.Tool, rentalIDStr string, requestedCost int32, title, message, notifType string) error {
 	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
 	if owner != nil {
 		_ = s.noteSvc.Dispatch(ctx, &domain.Notification{
@@ -541,13 +741,43 @@ func (s *rentalService) notifyOwnerExtensionRequest(ctx context.Context, rt *dom
 			Message: message,
 			Attributes: map[string]string{
 				"type": notifType, "rental_id": rentalIDStr,
-				"channel_id": string(domain.ChannelRentalRequest),
+				"requested_end_date":   *rt.RequestedEndDate,
+				"requested_cost_cents": fmt.Sprintf("%d", requestedCost),
+				"channel_id":           string(domain.ChannelRentalRequest),
 			},
 		})
 	}
 	return nil
 }
 
+// notifyRenterEarlyReturnRequest asks the renter to answer the owner's early return request.
+func (s *rentalService) notifyRenterEarlyReturnRequest(ctx context.Context, rt *domain.Rental, tool *domain.Tool, rentalIDStr string, requestedCost int32) error {
+	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
+	if renter != nil {
+		_ = s.noteSvc.Dispatch(ctx, &domain.Notification{
+			UserID:  renter.ID,
+			OrgID:   rt.OrgID,
+			Title:   "Early Return Requested",
+			Message: fmt.Sprintf("Owner asks to have %s back by %s. Please accept or decline.", tool.Name, *rt.RequestedEndDate),
+			Attributes: map[string]string{
+				"type": "EARLY_RETURN_REQUEST", "rental_id": rentalIDStr,
+				"requested_end_date":   *rt.RequestedEndDate,
+				"requested_cost_cents": fmt.Sprintf("%d", requestedCost),
+				"channel_id":           string(domain.ChannelRentalRequest),
+			},
+		})
+	}
+	return nil
+}
+
+// agreeEndDate records the current end date as LastAgreedEndDate, the date an extension
+// negotiation rolls back to when it is cancelled or its rejection acknowledged. It stores a
+// copy so later edits to EndDate do not move the agreed date with it.
+func agreeEndDate(rt *domain.Rental) {
+	agreed := rt.EndDate
+	rt.LastAgreedEndDate = &agreed
+}
+
 // isPreActive reports whether the rental is in a state that precedes the tool being picked up.
 func isPreActive(status domain.RentalStatus) bool {
 	return status == domain.RentalStatusPending ||
@@ -566,23 +796,41 @@ func (s *rentalService) ApproveReturnDateChange(ctx context.Context, ownerID, re
 		return nil, err
 	}
 	if rt.OwnerID != ownerID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusReturnDateChanged {
-		return nil, errors.New("invalid status")
+		return nil, domain.Conflictf("invalid status")
+	}
+	if rt.RequestedEndDate == nil {
+		return nil, domain.Conflictf("no extension request is pending")
 	}
 
-	// Copy EndDate to LastAgreedEndDate to save the new agreed date
-	rt.LastAgreedEndDate = &rt.EndDate
+	// The approved extension becomes the new agreed end date
+	rt.EndDate = *rt.RequestedEndDate
+	rt.RequestedEndDate = nil
+	newCost, err := s.calcCost(rt, "", "")
+	if err != nil {
+		return nil, err
+	}
+	rt.TotalCostCents = newCost
+	agreeEndDate(rt)
 	rt.Status = domain.RentalStatusActive
 
 	// Check overdue?
-	endDate, _ := time.Parse("2006-01-02", rt.EndDate)
-	if time.Now().After(endDate) {
+	if endDatePassed(rt, time.Now()) {
 		rt.Status = domain.RentalStatusOverdue
 	}
 
-	if err := s.rentalRepo.Update(ctx, rt); err != nil {
+	err = s.inTx(ctx, func(tx *rentalService) error {
+		if err := tx.rentalRepo.Update(ctx, rt); err != nil {
+			return err
+		}
+		tx.recordTransition(ctx, rt, domain.RentalStatusReturnDateChanged, ownerID, "")
+		// The hold follows the newly agreed cost: a longer rental reserves the increment,
+		// a shorter one releases the difference
+		return tx.adjustRentalHold(ctx, rt, "return date changed")
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -608,26 +856,26 @@ func (s *rentalService) RejectReturnDateChange(ctx context.Context, ownerID, ren
 		return nil, err
 	}
 	if rt.OwnerID != ownerID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusReturnDateChanged {
-		return nil, errors.New("invalid status")
+		return nil, domain.Conflictf("invalid status")
 	}
 
 	// Validate new_end_date is provided (mandatory)
 	if newEndDateStr == "" {
-		return nil, errors.New("new end date is required")
+		return nil, domain.Invalidf("new end date is required")
 	}
 
 	// Parse and validate date format
 	newEndDate, err := time.Parse("2006-01-02", newEndDateStr)
 	if err != nil {
-		return nil, errors.New("invalid date format, expected YYYY-MM-DD")
+		return nil, domain.Invalidf("invalid date format, expected YYYY-MM-DD")
 	}
 
 	// Validate new_end_date is different from requested date
-	if newEndDate.Format("2006-01-02") == rt.EndDate {
-		return nil, errors.New("new end date must be different from the requested date")
+	if rt.RequestedEndDate != nil && newEndDate.Format("2006-01-02") == *rt.RequestedEndDate {
+		return nil, domain.Invalidf("new end date must be different from the requested date")
 	}
 
 	// Get tool for cost recalculation
@@ -636,61 +884,76 @@ func (s *rentalService) RejectReturnDateChange(ctx context.Context, ownerID, ren
 		return nil, err
 	}
 
-	// Update status and rejection reason
-	rt.Status = domain.RentalStatusReturnDateChangeRejected
-	rt.RejectionReason = reason
-
-	// Update end_date with owner's counter-proposal
-	rt.EndDate = newEndDate.Format("2006-01-02")
-
-	newCost, err := s.calcCost(rt, "", "")
+	// The counter-proposal replaces the renter's request; EndDate stays as agreed
+	counter := newEndDate.Format("2006-01-02")
+	counterCost, err := s.calcCost(rt, "", counter)
 	if err != nil {
 		return nil, err
 	}
-	rt.TotalCostCents = newCost
+
+	// Update status and rejection reason
+	rt.Status = domain.RentalStatusReturnDateChangeRejected
+	rt.RejectionReason = reason
+	rt.RequestedEndDate = &counter
 
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusReturnDateChanged, ownerID,
+		fmt.Sprintf("counter-proposed end date %s: %s", rt.EndDate, reason))
 
 	// Notify Renter with counter-proposal details
 	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
 	if renter != nil && tool != nil {
+		currency := s.orgCurrency(ctx, renter.ID, rt.OrgID)
 		notif := &domain.Notification{
 			UserID: renter.ID,
 			OrgID:  rt.OrgID,
 			Title:  "Extension Rejected - Counter-Proposal",
-			Message: fmt.Sprintf("Extension for %s rejected. Owner set new return date: %s. Reason: %s. Updated cost: $%.2f",
-				tool.Name, newEndDate.Format("2006-01-02"), reason, float64(rt.TotalCostCents)/100),
+			Message: fmt.Sprintf("Extension for %s rejected. Owner set new return date: %s. Reason: %s. Updated cost: %s",
+				tool.Name, counter, reason, domain.FormatCents(counterCost, currency)),
 			Attributes: map[string]string{
 				"type":             "RETURN_DATE_CHANGE_REJECTED",
 				"rental_id":        fmt.Sprintf("%d", rt.ID),
-				"new_end_date":     newEndDate.Format("2006-01-02"),
-				"total_cost_cents": fmt.Sprintf("%d", rt.TotalCostCents),
+				"new_end_date":     counter,
+				"total_cost_cents": fmt.Sprintf("%d", counterCost),
 				"channel_id":       string(domain.ChannelRentalRequest),
 			},
 		}
 		_ = s.noteSvc.Dispatch(ctx, notif)
 
 		// Send email notification to renter
-		_ = s.emailSvc.SendReturnDateRejectionNotification(ctx, renter.Email, tool.Name, newEndDate.Format("2006-01-02"), reason, rt.TotalCostCents)
+		_ = s.emailSvc.SendReturnDateRejectionNotification(ctx, renter.Email, tool.Name, counter, reason, counterCost, currency)
 	}
 	return rt, nil
 }
 
+// orgCurrency returns the currency of the org a member belongs to, read through their
+// membership; the default currency is used if the membership can't be loaded
+func (s *rentalService) orgCurrency(ctx context.Context, userID, orgID int32) string {
+	uo, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
+	if err != nil || uo == nil || uo.CurrencyCode == "" {
+		return domain.DefaultCurrencyCode
+	}
+	return uo.CurrencyCode
+}
+
 func (s *rentalService) AcknowledgeReturnDateRejection(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error) {
 	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
 	if err != nil {
 		return nil, err
 	}
 	if rt.RenterID != renterID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusReturnDateChangeRejected {
-		return nil, errors.New("invalid status")
+		return nil, domain.Conflictf("invalid status")
 	}
 
-	// Rollback: restore the last agreed end date and recalculate cost from the rental's snapshot.
+	// Rollback: drop the proposal, restore the last agreed end date and reprice from the
+	// rental's snapshot. EndDate normally already holds the agreed date; rows migrated from
+	// before requested_end_date existed are corrected here.
+	rt.RequestedEndDate = nil
 	if rt.LastAgreedEndDate != nil {
 		rt.EndDate = *rt.LastAgreedEndDate
 		originalCost, err := s.calcCost(rt, "", "")
@@ -702,8 +965,7 @@ func (s *rentalService) AcknowledgeReturnDateRejection(ctx context.Context, rent
 
 	rt.RejectionReason = ""
 
-	endDate, _ := time.Parse("2006-01-02", rt.EndDate)
-	if time.Now().After(endDate) {
+	if endDatePassed(rt, time.Now()) {
 		rt.Status = domain.RentalStatusOverdue
 	} else {
 		rt.Status = domain.RentalStatusActive
@@ -712,6 +974,7 @@ func (s *rentalService) AcknowledgeReturnDateRejection(ctx context.Context, rent
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusReturnDateChangeRejected, renterID, "")
 
 	// Notify Owner
 	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
@@ -734,13 +997,16 @@ func (s *rentalService) CancelReturnDateChange(ctx context.Context, renterID, re
 		return nil, err
 	}
 	if rt.RenterID != renterID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusReturnDateChanged {
-		return nil, errors.New("invalid status")
+		return nil, domain.Conflictf("invalid status")
 	}
 
-	// Rollback: restore the last agreed end date and recalculate cost from the rental's snapshot.
+	// Rollback: drop the proposal, restore the last agreed end date and reprice from the
+	// rental's snapshot. EndDate normally already holds the agreed date; rows migrated from
+	// before requested_end_date existed are corrected here.
+	rt.RequestedEndDate = nil
 	if rt.LastAgreedEndDate != nil {
 		rt.EndDate = *rt.LastAgreedEndDate
 		originalCost, err := s.calcCost(rt, "", "")
@@ -750,8 +1016,7 @@ func (s *rentalService) CancelReturnDateChange(ctx context.Context, renterID, re
 		rt.TotalCostCents = originalCost
 	}
 
-	endDate, _ := time.Parse("2006-01-02", rt.EndDate)
-	if time.Now().After(endDate) {
+	if endDatePassed(rt, time.Now()) {
 		rt.Status = domain.RentalStatusOverdue
 	} else {
 		rt.Status = domain.RentalStatusActive
@@ -760,6 +1025,7 @@ func (s *rentalService) CancelReturnDateChange(ctx context.Context, renterID, re
 	if err := s.rentalRepo.Update(ctx, rt); err != nil {
 		return nil, err
 	}
+	s.recordTransition(ctx, rt, domain.RentalStatusReturnDateChanged, renterID, "")
 
 	// Notify Owner
 	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
@@ -776,17 +1042,263 @@ func (s *rentalService) CancelReturnDateChange(ctx context.Context, renterID, re
 	return rt, nil
 }
 
-func (s *rentalService) ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
+// AcceptEarlyReturn is the renter agreeing to the owner's early return request: the requested
+// date becomes the agreed end date and the rental, and its hold, are repriced for the shorter period.
+func (s *rentalService) AcceptEarlyReturn(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error) {
+	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rt.RenterID != renterID {
+		return nil, domain.ErrUnauthorized
+	}
+	if rt.Status != domain.RentalStatusEarlyReturnRequested {
+		return nil, domain.Conflictf("invalid status")
+	}
+	if rt.RequestedEndDate == nil {
+		return nil, domain.Conflictf("no early return request is pending")
+	}
+
+	rt.EndDate = *rt.RequestedEndDate
+	rt.RequestedEndDate = nil
+	newCost, err := s.calcCost(rt, "", "")
+	if err != nil {
+		return nil, err
+	}
+	rt.TotalCostCents = newCost
+	agreeEndDate(rt)
+
+	if endDatePassed(rt, time.Now()) {
+		rt.Status = domain.RentalStatusOverdue
+	} else {
+		rt.Status = domain.RentalStatusActive
+	}
+
+	err = s.inTx(ctx, func(tx *rentalService) error {
+		if err := tx.rentalRepo.Update(ctx, rt); err != nil {
+			return err
+		}
+		tx.recordTransition(ctx, rt, domain.RentalStatusEarlyReturnRequested, renterID, "")
+		return tx.adjustRentalHold(ctx, rt, "early return")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Notify Owner
+	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
+	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
+	if owner != nil && tool != nil {
+		notif := &domain.Notification{
+			UserID:     owner.ID,
+			OrgID:      rt.OrgID,
+			Title:      "Early Return Accepted",
+			Message:    fmt.Sprintf("Renter will return %s by %s.", tool.Name, rt.EndDate),
+			Attributes: map[string]string{"type": "EARLY_RETURN_ACCEPTED", "rental_id": fmt.Sprintf("%d", rt.ID), "channel_id": string(domain.ChannelRentalRequest)},
+		}
+		_ = s.noteSvc.Dispatch(ctx, notif)
+	}
+	return rt, nil
+}
+
+// DeclineEarlyReturn is the renter turning down the owner's early return request; the agreed
+// end date and cost stay in force.
+func (s *rentalService) DeclineEarlyReturn(ctx context.Context, renterID, rentalID int32, reason string) (*domain.Rental, error) {
+	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rt.RenterID != renterID {
+		return nil, domain.ErrUnauthorized
+	}
+	if rt.Status != domain.RentalStatusEarlyReturnRequested {
+		return nil, domain.Conflictf("invalid status")
+	}
+
+	rt.RequestedEndDate = nil
+	if endDatePassed(rt, time.Now()) {
+		rt.Status = domain.RentalStatusOverdue
+	} else {
+		rt.Status = domain.RentalStatusActive
+	}
+
+	if err := s.rentalRepo.Update(ctx, rt); err != nil {
+		return nil, err
+	}
+	s.recordTransition(ctx, rt, domain.RentalStatusEarlyReturnRequested, renterID, reason)
+
+	// Notify Owner
+	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
+	owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID)
+	if owner != nil && tool != nil {
+		message := fmt.Sprintf("Renter declined to return %s early. It is still due %s.", tool.Name, rt.EndDate)
+		if reason != "" {
+			message += " Reason: " + reason
+		}
+		notif := &domain.Notification{
+			UserID:     owner.ID,
+			OrgID:      rt.OrgID,
+			Title:      "Early Return Declined",
+			Message:    message,
+			Attributes: map[string]string{"type": "EARLY_RETURN_DECLINED", "rental_id": fmt.Sprintf("%d", rt.ID), "channel_id": string(domain.ChannelRentalRequest)},
+		}
+		_ = s.noteSvc.Dispatch(ctx, notif)
+	}
+	return rt, nil
+}
+
+// CancelEarlyReturn withdraws the owner's early return request before the renter answers it.
+func (s *rentalService) CancelEarlyReturn(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error) {
+	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rt.OwnerID != ownerID {
+		return nil, domain.ErrUnauthorized
+	}
+	if rt.Status != domain.RentalStatusEarlyReturnRequested {
+		return nil, domain.Conflictf("invalid status")
+	}
+
+	rt.RequestedEndDate = nil
+	if endDatePassed(rt, time.Now()) {
+		rt.Status = domain.RentalStatusOverdue
+	} else {
+		rt.Status = domain.RentalStatusActive
+	}
+
+	if err := s.rentalRepo.Update(ctx, rt); err != nil {
+		return nil, err
+	}
+	s.recordTransition(ctx, rt, domain.RentalStatusEarlyReturnRequested, ownerID, "")
+
+	// Notify Renter
+	renter, _ := s.userRepo.GetByID(ctx, rt.RenterID)
+	if renter != nil {
+		notif := &domain.Notification{
+			UserID:     renter.ID,
+			OrgID:      rt.OrgID,
+			Title:      "Early Return Request Withdrawn",
+			Message:    fmt.Sprintf("Owner withdrew the early return request. The return date stays %s.", rt.EndDate),
+			Attributes: map[string]string{"type": "EARLY_RETURN_CANCELLED", "rental_id": fmt.Sprintf("%d", rt.ID), "channel_id": string(domain.ChannelRentalRequest)},
+		}
+		_ = s.noteSvc.Dispatch(ctx, notif)
+	}
+	return rt, nil
+}
+
+func (s *rentalService) ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error) {
+	if err := validateDateWindow(fromDate, toDate); err != nil {
+		return nil, 0, err
+	}
+
 	// Verify ownership
 	tool, err := s.toolRepo.GetByID(ctx, toolID)
 	if err != nil {
 		return nil, 0, err
 	}
 	if tool.OwnerID != ownerID {
-		return nil, 0, errors.New("unauthorized")
+		return nil, 0, domain.ErrUnauthorized
 	}
 
-	return s.rentalRepo.ListByTool(ctx, toolID, orgID, statuses, page, pageSize)
+	return s.rentalRepo.ListByTool(ctx, toolID, orgID, statuses, fromDate, toDate, page, pageSize)
+}
+
+// maxBatchAvailabilityTools bounds the number of tools in a single availability lookup
+const maxBatchAvailabilityTools = 50
+
+func (s *rentalService) GetBatchAvailability(ctx context.Context, userID int32, toolIDs []int32, fromDate, toDate string) (map[int32][]domain.ToolBusyRange, error) {
+	if len(toolIDs) == 0 {
+		return nil, domain.Invalidf("at least one tool id is required")
+	}
+	if len(toolIDs) > maxBatchAvailabilityTools {
+		return nil, domain.Invalidf("at most %d tools can be queried at once", maxBatchAvailabilityTools)
+	}
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		return nil, domain.Invalidf("invalid from date: %w", err)
+	}
+	to, err := time.Parse("2006-01-02", toDate)
+	if err != nil {
+		return nil, domain.Invalidf("invalid to date: %w", err)
+	}
+	if to.Before(from) {
+		return nil, domain.Invalidf("to date must not be before from date")
+	}
+
+	visible, err := s.visibleToolIDs(ctx, userID, toolIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every visible tool gets an entry so an empty slice means fully available
+	availability := make(map[int32][]domain.ToolBusyRange, len(visible))
+	for _, id := range visible {
+		availability[id] = []domain.ToolBusyRange{}
+	}
+	if len(visible) == 0 {
+		return availability, nil
+	}
+
+	ranges, err := s.rentalRepo.ListBusyRanges(ctx, visible, fromDate, toDate)
+	if err != nil {
+		return nil, err
+	}
+	for _, br := range ranges {
+		availability[br.ToolID] = append(availability[br.ToolID], br)
+	}
+	return availability, nil
+}
+
+// visibleToolIDs keeps the tools whose owner shares an active org with the user. Missing
+// tools are dropped the same way, so the result does not reveal which ids exist.
+func (s *rentalService) visibleToolIDs(ctx context.Context, userID int32, toolIDs []int32) ([]int32, error) {
+	userOrgs, err := s.userRepo.ListUserOrgs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orgs for user %d: %w", userID, err)
+	}
+	activeOrgs := make(map[int32]bool, len(userOrgs))
+	for _, uo := range userOrgs {
+		if uo.Status == domain.UserOrgStatusActive {
+			activeOrgs[uo.OrgID] = true
+		}
+	}
+
+	sharesOrg := make(map[int32]bool) // by owner
+	seen := make(map[int32]bool, len(toolIDs))
+	var visible []int32
+	for _, id := range toolIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		tool, err := s.toolRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return nil, err
+		}
+		shared, ok := sharesOrg[tool.OwnerID]
+		if !ok {
+			ownerOrgs, err := s.userRepo.ListUserOrgs(ctx, tool.OwnerID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list orgs for user %d: %w", tool.OwnerID, err)
+			}
+			for _, uo := range ownerOrgs {
+				if uo.Status == domain.UserOrgStatusActive && activeOrgs[uo.OrgID] {
+					shared = true
+					break
+				}
+			}
+			sharesOrg[tool.OwnerID] = shared
+		}
+		if shared {
+			visible = append(visible, id)
+		}
+	}
+	return visible, nil
 }
 
 func (s *rentalService) Update(ctx context.Context, rt *domain.Rental) error {
@@ -815,36 +1327,45 @@ func (s *rentalService) CompleteRental(ctx context.Context, userID, rentalID int
 	rt.TotalCostCents = totalCostCents
 	rt.Notes = notes
 	rt.ChargeBillsplit = chargeBillsplit
+	from := rt.Status
 	rt.Status = domain.RentalStatusCompleted
 	rt.CompletedBy = &userID
-	if err := s.rentalRepo.Update(ctx, rt); err != nil {
-		return nil, err
-	}
 
-	// Steps 7, 11: Apply financial settlement (balance + ledger) — skipped when chargeBillsplit=false.
-	ownerLedgerID, err := s.applyOwnerSettlement(ctx, rt, settlementCents, chargeBillsplit)
-	if err != nil {
-		return nil, err
-	}
-	renterLedgerID, err := s.applyRenterSettlement(ctx, rt, settlementCents, chargeBillsplit)
-	if err != nil {
-		return nil, err
-	}
-
-	// Step 15: Set tool status to AVAILABLE or RENTED based on remaining active rentals.
-	tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID)
+	// The status change, ledger entries and tool status are written in one transaction, so a
+	// failed step never leaves a completed rental without its settlement or the reverse.
+	var ownerLedgerID, renterLedgerID int32
 	toolName := ""
-	if tool != nil {
-		toolName = tool.Name
-		_, activeCount, _ := s.rentalRepo.ListByTool(ctx, rt.ToolID, rt.OrgID, []string{
-			string(domain.RentalStatusActive), string(domain.RentalStatusScheduled),
-		}, 1, 1)
-		if activeCount > 0 {
-			tool.Status = domain.ToolStatusRented
-		} else {
-			tool.Status = domain.ToolStatusAvailable
+	err = s.inTx(ctx, func(tx *rentalService) error {
+		if err := tx.rentalRepo.Update(ctx, rt); err != nil {
+			return err
+		}
+		tx.recordTransition(ctx, rt, from, userID, notes)
+
+		// The hold placed at finalize is released whether or not the rental is charged,
+		// so the renter is never left with both a hold and a debit for the same rental.
+		if err := tx.releaseRentalHold(ctx, rt, "settled"); err != nil {
+			return err
+		}
+
+		// Steps 7, 11: Apply financial settlement (balance + ledger) — skipped when chargeBillsplit=false.
+		var err error
+		if ownerLedgerID, err = tx.applyOwnerSettlement(ctx, rt, settlementCents, chargeBillsplit); err != nil {
+			return err
+		}
+		if renterLedgerID, err = tx.applyRenterSettlement(ctx, rt, settlementCents, chargeBillsplit); err != nil {
+			return err
+		}
+
+		// Step 15: Set tool status to AVAILABLE or RENTED based on remaining active rentals.
+		tool, err := tx.syncToolStatus(ctx, rt)
+		if err != nil {
+			return err
 		}
-		_ = s.toolRepo.Update(ctx, tool)
+		toolName = tool.Name
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Steps 8-14, 16-21: Notifications and emails are fire-and-forget.
@@ -858,6 +1379,34 @@ func (s *rentalService) CompleteRental(ctx context.Context, userID, rentalID int
 	return rt, nil
 }
 
+// syncToolStatus recomputes the status of the rental's tool from all of its rentals, in every
+// org, that still occupy it (see domain.ResolveToolStatus). Call it after a rental enters or
+// leaves domain.BusyRentalStatuses; the tool is only written when its status changes, so
+// repeated calls are harmless. Returns the tool.
+func (s *rentalService) syncToolStatus(ctx context.Context, rt *domain.Rental) (*domain.Tool, error) {
+	tool, err := s.toolRepo.GetByID(ctx, rt.ToolID)
+	if err != nil {
+		return nil, err
+	}
+	busy := make([]string, len(domain.BusyRentalStatuses))
+	for i, st := range domain.BusyRentalStatuses {
+		busy[i] = string(st)
+	}
+	_, busyCount, err := s.rentalRepo.ListByTool(ctx, rt.ToolID, 0, busy, "", "", 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	status := domain.ResolveToolStatus(tool.Status, busyCount)
+	if status == tool.Status {
+		return tool, nil
+	}
+	tool.Status = status
+	if err := s.toolRepo.Update(ctx, tool); err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
 // loadAndValidateRental fetches the rental and checks that the caller is a participant and the
 // rental is in a state that allows completion (steps 1-2).
 func (s *rentalService) loadAndValidateRental(ctx context.Context, userID, rentalID int32) (*domain.Rental, error) {
@@ -866,10 +1415,10 @@ func (s *rentalService) loadAndValidateRental(ctx context.Context, userID, renta
 		return nil, err
 	}
 	if rt.OwnerID != userID && rt.RenterID != userID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	if rt.Status != domain.RentalStatusActive && rt.Status != domain.RentalStatusScheduled && rt.Status != domain.RentalStatusOverdue {
-		return nil, fmt.Errorf("rental cannot be completed: status is %s", rt.Status)
+		return nil, domain.Conflictf("rental cannot be completed: status is %s", rt.Status)
 	}
 	return rt, nil
 }
@@ -883,20 +1432,26 @@ func (s *rentalService) calcCost(rt *domain.Rental, startStr, endStr string) (in
 	if endStr == "" {
 		endStr = rt.EndDate
 	}
-	start, err := time.Parse("2006-01-02", startStr)
+	start, err := parseRentalDate("start", startStr)
 	if err != nil {
-		return 0, fmt.Errorf("invalid start date: %w", err)
+		return 0, err
 	}
-	end, err := time.Parse("2006-01-02", endStr)
+	end, err := parseRentalDate("end", endStr)
 	if err != nil {
-		return 0, fmt.Errorf("invalid end date: %w", err)
+		return 0, err
 	}
-	return utils.CalculateRentalCost(start, end, utils.RentalPriceSnapshot{
+	return utils.CalculateRentalCost(start, end, rentalPriceSnapshot(rt))
+}
+
+// rentalPriceSnapshot returns the prices and rounding policy captured on rt when it was requested
+func rentalPriceSnapshot(rt *domain.Rental) utils.RentalPriceSnapshot {
+	return utils.RentalPriceSnapshot{
 		DurationUnit:       domain.ToolDurationUnit(rt.DurationUnit),
 		PricePerDayCents:   rt.DailyPriceCents,
 		PricePerWeekCents:  rt.WeeklyPriceCents,
 		PricePerMonthCents: rt.MonthlyPriceCents,
-	})
+		Rounding:           utils.RoundingPolicy(rt.PricingRounding),
+	}
 }
 
 // applyOwnerSettlement creates a LENDING_CREDIT ledger entry for the owner.
@@ -941,6 +1496,95 @@ func (s *rentalService) applyRenterSettlement(ctx context.Context, rt *domain.Re
 	return renterDebit.ID, nil
 }
 
+// placeRentalHold records a HOLD for the renter's estimated cost. Holds do not change
+// balance_cents; they only reduce the available balance reported with the ledger summary.
+// No-ops when the rental already holds funds or costs nothing.
+func (s *rentalService) placeRentalHold(ctx context.Context, rt *domain.Rental) error {
+	held, err := s.ledgerRepo.GetRentalHold(ctx, rt.ID)
+	if err != nil {
+		return err
+	}
+	if held > 0 {
+		return nil
+	}
+	costCents, err := s.calcCost(rt, "", "")
+	if err != nil {
+		return err
+	}
+	if costCents <= 0 {
+		return nil
+	}
+	return s.ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+		OrgID:           rt.OrgID,
+		UserID:          rt.RenterID,
+		Amount:          -costCents,
+		Type:            domain.TransactionTypeHold,
+		RelatedRentalID: &rt.ID,
+		Description:     fmt.Sprintf("Hold for rental of tool %d", rt.ToolID),
+	})
+}
+
+// releaseRentalHold records a HOLD_RELEASE for whatever the rental still holds. reason ends up
+// in the ledger description. No-ops when nothing is held.
+func (s *rentalService) releaseRentalHold(ctx context.Context, rt *domain.Rental, reason string) error {
+	held, err := s.ledgerRepo.GetRentalHold(ctx, rt.ID)
+	if err != nil {
+		return err
+	}
+	if held <= 0 {
+		return nil
+	}
+	return s.ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+		OrgID:           rt.OrgID,
+		UserID:          rt.RenterID,
+		Amount:          held,
+		Type:            domain.TransactionTypeHoldRelease,
+		RelatedRentalID: &rt.ID,
+		Description:     fmt.Sprintf("Hold released for rental of tool %d (%s)", rt.ToolID, reason),
+	})
+}
+
+// adjustRentalHold moves what the rental holds to its current TotalCostCents, recording a HOLD
+// for an increase or a HOLD_RELEASE for a decrease. reason ends up in the ledger description.
+func (s *rentalService) adjustRentalHold(ctx context.Context, rt *domain.Rental, reason string) error {
+	held, err := s.ledgerRepo.GetRentalHold(ctx, rt.ID)
+	if err != nil {
+		return err
+	}
+	delta := rt.TotalCostCents - held
+	switch {
+	case delta > 0:
+		return s.ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+			OrgID:           rt.OrgID,
+			UserID:          rt.RenterID,
+			Amount:          -delta,
+			Type:            domain.TransactionTypeHold,
+			RelatedRentalID: &rt.ID,
+			Description:     fmt.Sprintf("Hold increased for rental of tool %d (%s)", rt.ToolID, reason),
+		})
+	case delta < 0:
+		return s.ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+			OrgID:           rt.OrgID,
+			UserID:          rt.RenterID,
+			Amount:          -delta,
+			Type:            domain.TransactionTypeHoldRelease,
+			RelatedRentalID: &rt.ID,
+			Description:     fmt.Sprintf("Hold reduced for rental of tool %d (%s)", rt.ToolID, reason),
+		})
+	}
+	return nil
+}
+
+// holdsFunds reports whether a rental in status st has been finalized and may carry a hold
+func holdsFunds(st domain.RentalStatus) bool {
+	for _, busy := range domain.BusyRentalStatuses {
+		if st == busy {
+			return true
+		}
+	}
+	return false
+}
+
 // dispatchSettlementNotifications sends credit/debit update notifications and emails to the owner
 // and renter (steps 8-14). When chargeBillsplit=false the notification body includes a highlighted
 // reminder that settlement should happen directly between the parties.
@@ -1046,13 +1690,125 @@ func (s *rentalService) ListLendings(ctx context.Context, userID, orgID int32, s
 	return s.rentalRepo.ListByOwner(ctx, userID, orgID, statuses, page, pageSize)
 }
 
+func (s *rentalService) ListActionableRentals(ctx context.Context, userID, orgID int32) ([]domain.Rental, error) {
+	return s.rentalRepo.ListActionable(ctx, userID, orgID)
+}
+
+func (s *rentalService) GetActivityCounts(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error) {
+	return s.rentalRepo.CountActivity(ctx, userID)
+}
+
 func (s *rentalService) GetRental(ctx context.Context, userID, rentalID int32) (*domain.Rental, error) {
 	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
 	if err != nil {
 		return nil, err
 	}
 	if rt.RenterID != userID && rt.OwnerID != userID {
-		return nil, errors.New("unauthorized")
+		return nil, domain.ErrUnauthorized
 	}
 	return rt, nil
 }
+
+// ErrReceiptNotAvailable is returned for a receipt of a rental that has not completed
+var ErrReceiptNotAvailable = domain.Conflictf("a receipt is only available once the rental is completed")
+
+// GetRentalReceipt builds the receipt of a completed rental on demand from the rental's price
+// snapshot. Besides the renter and the owner, admins of the rental's org may read it.
+func (s *rentalService) GetRentalReceipt(ctx context.Context, userID, rentalID int32) (*domain.RentalReceipt, error) {
+	rt, err := s.rentalRepo.GetByID(ctx, rentalID)
+	if err != nil {
+		return nil, err
+	}
+	if rt.RenterID != userID && rt.OwnerID != userID {
+		role, ok := claimedRole(ctx, userID, rt.OrgID)
+		if !ok {
+			uo, err := s.userRepo.GetUserOrg(ctx, userID, rt.OrgID)
+			if err != nil || uo == nil {
+				return nil, domain.ErrUnauthorized
+			}
+			role = uo.Role
+		}
+		if role != domain.UserOrgRoleAdmin && role != domain.UserOrgRoleSuperAdmin {
+			return nil, domain.ErrUnauthorized
+		}
+	}
+	if rt.Status != domain.RentalStatusCompleted {
+		return nil, ErrReceiptNotAvailable
+	}
+
+	start, err := parseRentalDate("start", rt.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseRentalDate("end", rt.EndDate)
+	if err != nil {
+		return nil, err
+	}
+	breakdown, err := utils.CalculateRentalCostWithBreakdown(start, end, rentalPriceSnapshot(rt))
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := &domain.RentalReceipt{
+		RentalID:               rt.ID,
+		OrgID:                  rt.OrgID,
+		ToolID:                 rt.ToolID,
+		OwnerID:                rt.OwnerID,
+		RenterID:               rt.RenterID,
+		StartDate:              rt.StartDate,
+		EndDate:                rt.EndDate,
+		CompletedOn:            rt.UpdatedOn,
+		DurationUnit:           rt.DurationUnit,
+		Lines:                  receiptLines(rt, breakdown),
+		RentalCostCents:        rt.TotalCostCents,
+		SurchargeOrCreditCents: rt.SurchargeOrCreditCents,
+		TotalCents:             rt.TotalCostCents + rt.SurchargeOrCreditCents,
+		ChargeBillsplit:        rt.ChargeBillsplit,
+		ReturnCondition:        rt.ReturnCondition,
+		CurrencyCode:           s.orgCurrency(ctx, rt.RenterID, rt.OrgID),
+	}
+	var linesTotal int32
+	for _, line := range receipt.Lines {
+		linesTotal += line.AmountCents
+	}
+	receipt.AdjustmentCents = rt.TotalCostCents - linesTotal
+
+	// Names are decoration; a receipt is still returned if a party or the tool can't be loaded
+	if tool, _ := s.toolRepo.GetByID(ctx, rt.ToolID); tool != nil {
+		receipt.ToolName = tool.Name
+	}
+	if owner, _ := s.userRepo.GetByID(ctx, rt.OwnerID); owner != nil {
+		receipt.OwnerName = owner.Name
+	}
+	if renter, _ := s.userRepo.GetByID(ctx, rt.RenterID); renter != nil {
+		receipt.RenterName = renter.Name
+	}
+	return receipt, nil
+}
+
+// receiptLines turns a cost breakdown into the receipt's non-empty unit lines, priced from the
+// rental's snapshot
+func receiptLines(rt *domain.Rental, b utils.RentalCostBreakdown) []domain.RentalReceiptLine {
+	var lines []domain.RentalReceiptLine
+	add := func(unit domain.ToolDurationUnit, quantity int, price, amount int32) {
+		if quantity > 0 {
+			lines = append(lines, domain.RentalReceiptLine{Unit: unit, Quantity: int32(quantity), UnitPriceCents: price, AmountCents: amount})
+		}
+	}
+	add(domain.ToolDurationUnitMonth, b.Months, rt.MonthlyPriceCents, b.MonthsCost)
+	add(domain.ToolDurationUnitWeek, b.Weeks, rt.WeeklyPriceCents, b.WeeksCost)
+	add(domain.ToolDurationUnitDay, b.Days, rt.DailyPriceCents, b.DaysCost)
+	return lines
+}
+
+// GetRentalHistory returns the rental's status transitions, oldest first. Only the renter
+// and the owner may read it.
+func (s *rentalService) GetRentalHistory(ctx context.Context, userID, rentalID int32) ([]domain.RentalEvent, error) {
+	if _, err := s.GetRental(ctx, userID, rentalID); err != nil {
+		return nil, err
+	}
+	if s.eventRepo == nil {
+		return nil, nil
+	}
+	return s.eventRepo.ListByRental(ctx, rentalID)
+}
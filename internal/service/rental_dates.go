@@ -0,0 +1,128 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/utils"
+)
+
+// rentalDateLayout is the only accepted format for rental dates
+const rentalDateLayout = "2006-01-02"
+
+// Rental date validation errors. All are of kind domain.ErrValidation and are returned wrapped
+// with the name of the offending field, e.g. "invalid start date: date is required".
+var (
+	ErrRentalDateRequired     = domain.Invalidf("date is required")
+	ErrRentalDateMalformed    = domain.Invalidf("expected YYYY-MM-DD")
+	ErrRentalStartInPast      = domain.Invalidf("start date must not be in the past")
+	ErrRentalEndNotAfterStart = domain.Invalidf("end date must be after start date (minimum 1 day rental)")
+)
+
+// parseRentalDate strictly parses a YYYY-MM-DD rental date as midnight UTC. Values that
+// utils.ParseDate tolerates but that are not canonical, e.g. "2026-1-5" or "2026-02-30",
+// are rejected so a bad date never reaches cost computation as a zero or shifted time.
+func parseRentalDate(field, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("invalid %s date: %w", field, ErrRentalDateRequired)
+	}
+	d, err := utils.ParseDate(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s date %q: %w: %v", field, value, ErrRentalDateMalformed, err)
+	}
+	if d.Day > utils.DaysInMonth(d.Year, d.Month) {
+		return time.Time{}, fmt.Errorf("invalid %s date %q: %w: day is out of range for month", field, value, ErrRentalDateMalformed)
+	}
+	t := time.Date(d.Year, time.Month(d.Month), d.Day, 0, 0, 0, 0, time.UTC)
+	if t.Format(rentalDateLayout) != value {
+		return time.Time{}, fmt.Errorf("invalid %s date %q: %w", field, value, ErrRentalDateMalformed)
+	}
+	return t, nil
+}
+
+// parseRentalPeriod parses both ends of a rental and checks that end is strictly after start
+func parseRentalPeriod(startStr, endStr string) (start, end time.Time, err error) {
+	start, err = parseRentalDate("start", startStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err = parseRentalDate("end", endStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, ErrRentalEndNotAfterStart
+	}
+	return start, end, nil
+}
+
+// validateNewRentalPeriod is parseRentalPeriod for a rental that has not started yet, whose
+// start date must not be in the past (see checkStartNotPast)
+func validateNewRentalPeriod(startStr, endStr string, now time.Time, grace time.Duration) (start, end time.Time, err error) {
+	start, end, err = parseRentalPeriod(startStr, endStr)
+	if err != nil {
+		return start, end, err
+	}
+	if err := checkStartNotPast(start, now, grace); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// checkStartNotPast rejects a start date before today (UTC). Today is taken grace before now,
+// so a client a few hours behind UTC may still pick its local today.
+func checkStartNotPast(start, now time.Time, grace time.Duration) error {
+	now = now.UTC().Add(-grace)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if start.Before(today) {
+		return ErrRentalStartInPast
+	}
+	return nil
+}
+
+// checkEarlyReturnDate validates the return date an owner asks for while a rental is out: it has
+// to come before the agreed end date, and may not be before today (UTC) since the renter can't
+// hand the tool back in the past.
+func checkEarlyReturnDate(rt *domain.Rental, end, now time.Time) error {
+	agreed, err := parseRentalDate("end", rt.EndDate)
+	if err != nil {
+		return err
+	}
+	if !end.Before(agreed) {
+		return domain.Invalidf("early return date must be before the agreed return date %s", rt.EndDate)
+	}
+	now = now.UTC()
+	if end.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)) {
+		return domain.Invalidf("early return date must not be in the past")
+	}
+	return nil
+}
+
+// validateDateWindow checks optional inclusive 'YYYY-MM-DD' bounds of a listing filter. Either
+// may be empty; when both are set, to must not be before from.
+func validateDateWindow(fromStr, toStr string) error {
+	var from, to time.Time
+	var err error
+	if fromStr != "" {
+		if from, err = parseRentalDate("from", fromStr); err != nil {
+			return err
+		}
+	}
+	if toStr != "" {
+		if to, err = parseRentalDate("to", toStr); err != nil {
+			return err
+		}
+	}
+	if fromStr != "" && toStr != "" && to.Before(from) {
+		return domain.Invalidf("to date must not be before from date")
+	}
+	return nil
+}
+
+// endDatePassed reports whether the rental's agreed end date is behind now. A stored date that
+// does not parse never marks a rental overdue.
+func endDatePassed(rt *domain.Rental, now time.Time) bool {
+	end, err := parseRentalDate("end", rt.EndDate)
+	return err == nil && now.After(end)
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
@@ -9,14 +10,23 @@ import (
 
 type AuthService interface {
 	ValidateInvite(ctx context.Context, inviteCode, email string) (bool, string, *domain.User, error)
+	// GetInvitationInfo describes the org a valid invitation is for. It fails with
+	// ErrInviteInvalid, ErrInviteUsed, ErrInviteRevoked or ErrInviteExpired otherwise.
+	GetInvitationInfo(ctx context.Context, inviteCode, email string) (*domain.InvitationInfo, error)
 	RequestToJoin(ctx context.Context, orgID int32, name, email, note, adminEmail string) error
+	// Signup creates the account unverified and emails a verification link.
 	Signup(ctx context.Context, inviteToken, name, email, phone, password string) error
-	// Login returns (session 2FA token, requires2FA, tempPwd, error).
+	// VerifyEmail consumes the token from the verification link and marks the email verified.
+	VerifyEmail(ctx context.Context, token string) error
+	// Login returns (session 2FA token, accessToken, refreshToken, requires2FA, tempPwd, error).
+	// When the user is exempt from 2FA, requires2FA is false and the access/refresh tokens are
+	// issued directly; otherwise only the session token is set.
 	// tempPwd is true when authentication succeeded via a temporary password from pending_credentials.
-	Login(ctx context.Context, email, password string) (string, bool, bool, error)
+	Login(ctx context.Context, email, password string) (string, string, string, bool, bool, error)
 	// Verify2FA returns (accessToken, refreshToken, user, resetPassword, error).
 	// resetPassword is true when the user authenticated via a temporary password and must change it.
 	Verify2FA(ctx context.Context, userID int32, code string, tempPwd bool) (string, string, *domain.User, bool, error)
+	// RefreshToken rejects tokens revoked at logout or issued before the last RevokeAllSessions.
 	RefreshToken(ctx context.Context, refresh string) (string, string, error)
 	// ChangePassword verifies oldPassword (against users table and/or pending_credentials),
 	// updates users.password_hash, and stamps pending_credentials.used_at when applicable.
@@ -24,12 +34,22 @@ type AuthService interface {
 	// ResetPassword validates the email, generates a temporary password, upserts pending_credentials,
 	// and emails the temporary password to the user.
 	ResetPassword(ctx context.Context, email string) error
+	// Logout revokes the given refresh token and retires the device's FCM tokens.
 	Logout(ctx context.Context, userID int32, refresh, androidDeviceID string) error
+	// RevokeAllSessions invalidates every refresh token previously issued to the user.
+	RevokeAllSessions(ctx context.Context, userID int32) error
 }
 
 type UserService interface {
 	GetUserProfile(ctx context.Context, userID int32) (*domain.User, []domain.Organization, []domain.UserOrg, error)
+	// GetUsersByIDs loads several users in one query, keyed by ID. Unknown IDs are absent from the map.
+	GetUsersByIDs(ctx context.Context, ids []int32) (map[int32]*domain.User, error)
 	UpdateProfile(ctx context.Context, userID int32, name, email, phone, avatarURL string) error
+	// UpdateNotificationPreference opts the user in or out of SMS; opting in needs a phone number
+	UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error
+	// ExportMyData returns a JSON document with the caller's profile, memberships, rentals,
+	// bills, transactions and notifications. Other users are reduced to ID and name.
+	ExportMyData(ctx context.Context, userID int32) ([]byte, error)
 }
 
 type OrganizationService interface {
@@ -37,9 +57,13 @@ type OrganizationService interface {
 	GetOrganization(ctx context.Context, id int32, callingUserID int32) (*domain.Organization, *domain.UserOrg, error)
 	CreateOrganization(ctx context.Context, userID int32, org *domain.Organization) error
 	SearchOrganizations(ctx context.Context, name, metro string) ([]domain.Organization, error)
+	ListMetros(ctx context.Context) ([]string, error)
+	// SetAdjacentMetros replaces the org's adjacent metros; ADMIN or SUPER_ADMIN only
+	SetAdjacentMetros(ctx context.Context, callerID, orgID int32, metros []string) (*domain.Organization, error)
 	UpdateOrganization(ctx context.Context, callerID int32, org *domain.Organization) error
 	ListMyOrganizations(ctx context.Context, userID int32) ([]domain.Organization, []domain.UserOrg, error)
 	JoinOrganizationWithInvite(ctx context.Context, userID int32, inviteCode string) (*domain.Organization, *domain.User, error)
+	ListMembers(ctx context.Context, adminID, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error)
 }
 
 type ImageStorageService interface {
@@ -49,6 +73,8 @@ type ImageStorageService interface {
 	GetToolImages(ctx context.Context, toolID int32) ([]domain.ToolImage, error)
 	DeleteImage(ctx context.Context, userID int32, imageID int32, toolID int32) error
 	SetPrimaryImage(ctx context.Context, userID int32, toolID int32, imageID int32) error
+	// ReorderToolImages sets display_order from imageIDs, which must list every confirmed image of the tool exactly once
+	ReorderToolImages(ctx context.Context, userID int32, toolID int32, imageIDs []int32) ([]domain.ToolImage, error)
 }
 
 type ToolService interface {
@@ -56,9 +82,10 @@ type ToolService interface {
 	GetTool(ctx context.Context, id, requestingUserID int32) (*domain.Tool, []domain.ToolImage, error)
 	UpdateTool(ctx context.Context, tool *domain.Tool) error
 	DeleteTool(ctx context.Context, id int32) error
-	ListTools(ctx context.Context, orgID, requestingUserID int32, page, pageSize int32) ([]domain.Tool, int32, error)
+	ListTools(ctx context.Context, orgID, requestingUserID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error)
 	ListMyTools(ctx context.Context, userID int32, page, pageSize int32) ([]domain.Tool, int32, error)
-	SearchTools(ctx context.Context, userID, orgID int32, metro, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error)
+	// SearchTools searches the org's metro, or metro when orgID is 0; includeAdjacent adds the org's adjacent metros
+	SearchTools(ctx context.Context, userID, orgID int32, metro string, includeAdjacent bool, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error)
 	ListCategories(ctx context.Context) ([]string, error)
 }
 
@@ -66,13 +93,20 @@ type RentalService interface {
 	CreateRentalRequest(ctx context.Context, renterID, toolID, orgID int32, startDate, endDate string) (*domain.Rental, error)
 	ApproveRentalRequest(ctx context.Context, ownerID, rentalID int32, pickupNote string) (*domain.Rental, error)
 	RejectRentalRequest(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error)
-	CancelRental(ctx context.Context, renterID, rentalID int32, reason string) (*domain.Rental, error)
+	// CancelRental lets either party cancel before pickup; the owner must give a reason.
+	CancelRental(ctx context.Context, userID, rentalID int32, reason string) (*domain.Rental, error)
 	FinalizeRentalRequest(ctx context.Context, renterID, rentalID int32) (*domain.Rental, []domain.Rental, []domain.Rental, error)
 	CompleteRental(ctx context.Context, userID, rentalID int32, returnCondition string, surchargeOrCreditCents int32, notes string, chargeBillsplit bool) (*domain.Rental, error)
 	Update(ctx context.Context, rt *domain.Rental) error
 	ListRentals(ctx context.Context, userID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
 	ListLendings(ctx context.Context, userID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
+	// ListActionableRentals lists the user's rentals and lendings in orgID where they are the
+	// party expected to act next
+	ListActionableRentals(ctx context.Context, userID, orgID int32) ([]domain.Rental, error)
 	GetRental(ctx context.Context, userID, rentalID int32) (*domain.Rental, error)
+	GetRentalHistory(ctx context.Context, userID, rentalID int32) ([]domain.RentalEvent, error)
+	// GetRentalReceipt builds the receipt of a completed rental for either party or an org admin
+	GetRentalReceipt(ctx context.Context, userID, rentalID int32) (*domain.RentalReceipt, error)
 
 	// New methods
 	ActivateRental(ctx context.Context, userID, rentalID int32) (*domain.Rental, error)
@@ -81,18 +115,43 @@ type RentalService interface {
 	RejectReturnDateChange(ctx context.Context, ownerID, rentalID int32, reason, newEndDate string) (*domain.Rental, error)
 	AcknowledgeReturnDateRejection(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error)
 	CancelReturnDateChange(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error)
-	ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error)
+	// AcceptEarlyReturn moves the return date to the owner's earlier request and reprices the rental
+	AcceptEarlyReturn(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error)
+	// DeclineEarlyReturn keeps the agreed return date; reason is passed on to the owner
+	DeclineEarlyReturn(ctx context.Context, renterID, rentalID int32, reason string) (*domain.Rental, error)
+	// CancelEarlyReturn withdraws the owner's early return request
+	CancelEarlyReturn(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error)
+	// ListToolRentals lists a tool's rentals for its owner. Non-empty fromDate/toDate ('YYYY-MM-DD',
+	// inclusive) keep only rentals overlapping that window.
+	ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error)
+	// GetBatchAvailability returns the busy ranges of each tool in the window. Tools the user
+	// shares no active org with are left out.
+	GetBatchAvailability(ctx context.Context, userID int32, toolIDs []int32, fromDate, toDate string) (map[int32][]domain.ToolBusyRange, error)
+	// GetActivityCounts counts the user's in-progress rentals and lendings and the rentals
+	// waiting on them, across all their orgs.
+	GetActivityCounts(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error)
 }
 
 type LedgerService interface {
 	GetBalance(ctx context.Context, userID, orgID int32) (int32, error)
 	GetTransactions(ctx context.Context, userID, orgID int32, page, pageSize int32) ([]domain.LedgerTransaction, int32, error)
 	GetLedgerSummary(ctx context.Context, userID, orgID int32) (*domain.LedgerSummary, error)
+	GetBalanceHistory(ctx context.Context, userID, orgID int32, from, to string) ([]domain.BalanceSnapshot, error)
+	// ExportLedger writes the target member's transactions in the date range to w as CSV.
+	// Callers may export their own ledger; org admins may export any member's.
+	ExportLedger(ctx context.Context, callerID, userID, orgID int32, from, to string, w io.Writer) error
 }
 
 type NotificationService interface {
-	GetNotifications(ctx context.Context, userID int32, page, pageSize int32) ([]domain.Notification, int32, error)
+	// GetNotifications lists the user's notifications; a non-empty notificationType keeps only
+	// those whose "type" or "topic" attribute matches.
+	GetNotifications(ctx context.Context, userID int32, notificationType string, page, pageSize int32) ([]domain.Notification, int32, error)
+	// UnreadCount returns the number of unread notifications, optionally scoped to an org (0 = all).
+	UnreadCount(ctx context.Context, userID, orgID int32) (int32, error)
 	MarkAsRead(ctx context.Context, userID int32, notificationID int64) error
+	// MarkAllRead marks every unread notification of the user as read, optionally scoped to an
+	// org (orgID 0 means all orgs), and returns how many were updated.
+	MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error)
 	SyncDeviceToken(ctx context.Context, userID int32, fcmToken, androidDeviceID, deviceName string) error
 	ReportMessageEvent(ctx context.Context, userID int32, notificationID int64, eventType string, eventTime time.Time) error
 	// Dispatch creates the notification row in DB and fires a push notification if a push service is configured.
@@ -100,6 +159,12 @@ type NotificationService interface {
 	// DispatchSilent creates the notification row in DB without firing a push notification.
 	// Use this when the caller will handle push delivery separately (e.g. via multicast).
 	DispatchSilent(ctx context.Context, n *domain.Notification) error
+	// RetryFailed re-creates up to limit notifications whose insert failed at dispatch and
+	// returns how many were delivered and how many failed again.
+	RetryFailed(ctx context.Context, limit int32) (delivered, failed int, err error)
+	// Subscribe returns a channel of the user's notifications dispatched from now on and a
+	// function that ends the subscription and closes the channel.
+	Subscribe(userID int32) (<-chan domain.Notification, func())
 	// SetPushService wires the FCM push service after construction (allows nil-safe late binding).
 	SetPushService(pushSvc PushNotificationService)
 }
@@ -116,49 +181,102 @@ type PushNotificationService interface {
 	Shutdown(ctx context.Context) error
 }
 
+// SMSService texts a message to a phone number in E.164 form
+type SMSService interface {
+	Send(ctx context.Context, to, body string) error
+}
+
 type AdminService interface {
 	ApproveJoinRequest(ctx context.Context, adminID, orgID, joinRequestID int32) (invitationCode string, err error)
 	BlockUser(ctx context.Context, adminID, userID, orgID int32, blockRenting, blockLending bool, reason string) error
+	// UnblockMember clears renting/lending blocks, including those set by dispute resolution.
+	UnblockMember(ctx context.Context, adminID, orgID, userID int32) error
 	ListMembers(ctx context.Context, orgID int32) ([]domain.User, []domain.UserOrg, error)
 	SearchUsers(ctx context.Context, orgID int32, query string) ([]domain.User, []domain.UserOrg, error)
 	ListJoinRequests(ctx context.Context, orgID int32) ([]domain.JoinRequest, error)
 	RejectJoinRequest(ctx context.Context, adminID, orgID, joinRequestID int32, reason string) error
 	SendInvitation(ctx context.Context, adminID, orgID int32, email, name string) (string, error)
+	// ResendInvitation re-emails an outstanding invitation, optionally extending its expiry.
+	ResendInvitation(ctx context.Context, adminID, orgID int32, invitationCode, email string, extendExpiry bool) (*domain.Invitation, error)
+	// RevokeInvitation makes an outstanding invitation unusable.
+	RevokeInvitation(ctx context.Context, adminID, orgID int32, invitationCode, email string) error
+	// BulkCreateInvitations invites a list of emails, reporting a result per email.
+	BulkCreateInvitations(ctx context.Context, adminID, orgID int32, emails []string) ([]domain.BulkInvitationResult, error)
 	GetMemberProfile(ctx context.Context, orgID, userID int32) (*domain.User, *domain.UserOrg, error)
+	GetDisputeStatistics(ctx context.Context, adminID, orgID int32, fromMonth, toMonth string) (*domain.DisputeStatistics, error)
+	// GetOrgBalanceSheet totals member balances and bills by status for the org. Admins only.
+	GetOrgBalanceSheet(ctx context.Context, adminID, orgID int32) (*domain.OrgBalanceSheet, error)
+	// ListAuditLog returns the org's admin audit trail, newest first. SUPER_ADMIN only.
+	ListAuditLog(ctx context.Context, superAdminID, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error)
+	// GetJobRuns returns recent cronjob runs, newest first. SUPER_ADMIN only.
+	GetJobRuns(ctx context.Context, superAdminID, orgID int32, filter domain.JobRunFilter) ([]domain.JobRun, error)
+
+	// Cross-org RPCs, for platform operators listed in AdminOptions.SuperAdminEmails. Org roles do not grant them.
+	ListAllOrganizations(ctx context.Context, superAdminID int32) ([]domain.Organization, error)
+	GetAnyMemberProfile(ctx context.Context, superAdminID, orgID, userID int32) (*domain.User, *domain.UserOrg, error)
+	// AdjustAnyBalance credits (positive) or debits (negative) a member and returns the new balance.
+	AdjustAnyBalance(ctx context.Context, superAdminID, orgID, userID, amountCents int32, reason string) (int32, error)
+}
+
+// AdminAudit is the central record of privileged mutations. Record is best-effort: a failed
+// write is logged and never undoes or fails the mutation being audited. RecordChange also
+// stores before/after snapshots of the fields the mutation changed.
+type AdminAudit interface {
+	Record(ctx context.Context, adminID, orgID int32, action domain.AdminAuditAction, targetType domain.AdminAuditTargetType, targetID int32, details map[string]string)
+	RecordChange(ctx context.Context, adminID, orgID int32, action domain.AdminAuditAction, targetType domain.AdminAuditTargetType, targetID int32, details, before, after map[string]string)
+	List(ctx context.Context, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error)
 }
 
 type BillSplitService interface {
 	GetGlobalBillSplitSummary(ctx context.Context, userID int32) (paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute int32, err error)
 	GetOrganizationBillSplitSummary(ctx context.Context, userID int32) ([]domain.Organization, []int32, []int32, []int32, []int32, error)
-	ListPayments(ctx context.Context, userID, orgID int32, showHistory bool) ([]domain.Bill, error)
-	GetPaymentDetail(ctx context.Context, userID, paymentID int32) (*domain.Bill, []domain.BillAction, bool, error)
+	ListPayments(ctx context.Context, userID, orgID int32, showHistory bool, page, pageSize int32) ([]domain.Bill, int32, error)
+	// GetPaymentDetail returns the bill, one page of its actions matching filter with the total
+	// number matching, and whether the user can acknowledge it
+	GetPaymentDetail(ctx context.Context, userID, paymentID int32, filter domain.BillActionFilter) (*domain.Bill, []domain.BillAction, int32, bool, error)
 	AcknowledgePayment(ctx context.Context, userID, paymentID int32) error
+	VoidBill(ctx context.Context, userID, paymentID int32, notes string) error
 	ListDisputedPayments(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error)
 	ListResolvedDisputes(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error)
+	// GetDisputeDetail returns a disputed bill with all of its actions and both parties' profiles
+	GetDisputeDetail(ctx context.Context, adminID, paymentID int32) (*domain.DisputeDetail, error)
 	ResolveDispute(ctx context.Context, adminID, paymentID int32, resolution, notes string) error
+	PreviewSettlement(ctx context.Context, adminID, orgID int32) (*domain.SettlementPreview, error)
+	// RunSettlement creates the org's bills for a 'YYYY-MM' month the job has not settled yet
+	RunSettlement(ctx context.Context, adminID, orgID int32, month string) ([]domain.Bill, error)
+	GetSettlementThreshold(ctx context.Context, adminID, orgID int32) (*domain.SettlementThreshold, error)
+	// SetSettlementThreshold sets the org's own threshold; nil reverts to the configured default
+	SetSettlementThreshold(ctx context.Context, adminID, orgID int32, thresholdCents *int32) (*domain.SettlementThreshold, error)
 }
 
 type EmailService interface {
+	// Send renders the named template (one of the EmailTemplate* constants) with its
+	// data struct and emails it to one recipient. The methods below wrap it.
+	Send(ctx context.Context, to, templateName string, data interface{}) error
+
 	SendInvitation(ctx context.Context, email, name, token string, orgName string, ccEmail string) error
 	SendAccountStatusNotification(ctx context.Context, email, name, orgName, status, reason string) error
 
 	// Rental Notifications
-	SendRentalRequestNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error
-	SendRentalApprovalNotification(ctx context.Context, renterEmail, toolName, ownerName, pickupNote string, ccEmail string) error
+	SendRentalRequestNotification(ctx context.Context, ownerEmail string, data RentalRequestEmail, ccEmail string) error
+	SendRentalApprovalNotification(ctx context.Context, renterEmail string, data RentalApprovalEmail, ccEmail string) error
 	SendRentalRejectionNotification(ctx context.Context, renterEmail, toolName, ownerName string, ccEmail string) error
 	SendRentalConfirmationNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error
-	SendRentalCancellationNotification(ctx context.Context, ownerEmail, renterName, toolName, reason string, ccEmail string) error
+	SendRentalCancellationNotification(ctx context.Context, recipientEmail, cancellerName, toolName, reason string, ccEmail string) error
 	SendRentalCompletionNotification(ctx context.Context, email, role, toolName string, amount int32) error
 	SendRentalPickupNotification(ctx context.Context, email, name, toolName, startDate, endDate string) error
-	SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32) error
+	SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32, currencyCode string) error
+
+	// Auth Notifications
+	SendTwoFactorCode(ctx context.Context, email string, data TwoFactorCodeEmail) error
 
 	// Admin Notifications
 	SendAdminNotification(ctx context.Context, adminEmail, subject, message string) error
 
 	// Bill Split Notifications
-	SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error
-	SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string) error
-	SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error
-	SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string) error
-	SendBillDisputeResolutionNotification(ctx context.Context, email, name string, amountCents int32, resolution, notes string, orgName string) error
+	SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error
+	SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error
+	SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error
+	SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string, currencyCode string) error
+	SendBillDisputeResolutionNotification(ctx context.Context, email string, data BillDisputeResolutionEmail) error
 }
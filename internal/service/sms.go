@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"ubertool-backend-trusted/internal/logger"
+)
+
+// SMS providers accepted in the sms.provider config
+const (
+	SMSProviderNoop   = "noop"   // logs messages instead of sending them; for development
+	SMSProviderTwilio = "twilio" // Twilio Programmable Messaging
+)
+
+const (
+	defaultSMSTimeout   = 10 * time.Second
+	defaultTwilioAPIURL = "https://api.twilio.com/2010-04-01"
+)
+
+// NewSMSProvider returns the SMSService for provider, one of the SMSProvider constants
+func NewSMSProvider(provider, accountSID, authToken, fromNumber string, opts TwilioOptions) (SMSService, error) {
+	switch provider {
+	case "", SMSProviderNoop:
+		return NewNoopSMSService(), nil
+	case SMSProviderTwilio:
+		return NewTwilioSMSService(accountSID, authToken, fromNumber, opts), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", provider)
+	}
+}
+
+// noopSMSService stands in for a provider in development so opted-in users can be tested without sending texts
+type noopSMSService struct{}
+
+func NewNoopSMSService() SMSService {
+	return noopSMSService{}
+}
+
+func (noopSMSService) Send(ctx context.Context, to, body string) error {
+	logger.Info("SMS provider not configured, message not sent", "to", to, "body", body)
+	return nil
+}
+
+// TwilioOptions tunes the Twilio client. Zero values fall back to defaults.
+type TwilioOptions struct {
+	Timeout time.Duration // per-request deadline
+	BaseURL string        // API root; tests point it at a local server
+}
+
+type twilioSMSService struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	client     *http.Client
+}
+
+// NewTwilioSMSService creates an SMSService that sends from the given Twilio number
+func NewTwilioSMSService(accountSID, authToken, fromNumber string, opts TwilioOptions) SMSService {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultSMSTimeout
+	}
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultTwilioAPIURL
+	}
+	return &twilioSMSService{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       fromNumber,
+		baseURL:    strings.TrimRight(opts.BaseURL, "/"),
+		client:     &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+func (s *twilioSMSService) Send(ctx context.Context, to, body string) error {
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.baseURL, url.PathEscape(s.accountSID))
+	form := url.Values{"To": {to}, "From": {s.from}, "Body": {body}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.accountSID, s.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body) //nolint:errcheck
+		return nil
+	}
+
+	// Twilio explains rejections (unverified number, bad recipient) in a JSON body
+	var apiErr struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr) //nolint:errcheck
+	return fmt.Errorf("twilio rejected SMS to %s: status %d, code %d: %s", to, resp.StatusCode, apiErr.Code, apiErr.Message)
+}
+
+// AsyncSMSService sends each message in the background so a slow provider never holds up
+// the notification's caller. Failures are logged; Shutdown waits for sends in flight.
+type AsyncSMSService struct {
+	provider SMSService
+	wg       sync.WaitGroup
+}
+
+func NewAsyncSMSService(provider SMSService) *AsyncSMSService {
+	return &AsyncSMSService{provider: provider}
+}
+
+// Send starts the send and returns immediately; it never fails
+func (s *AsyncSMSService) Send(ctx context.Context, to, body string) error {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// The request that triggered the notification usually ends before the send does
+		if err := s.provider.Send(context.WithoutCancel(ctx), to, body); err != nil {
+			logger.Error("Failed to send SMS", "to", to, "error", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown waits for in-flight sends. Returns ctx.Err() if the deadline is exceeded.
+func (s *AsyncSMSService) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
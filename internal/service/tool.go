@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
+
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
 )
 
+var ErrUnknownCategory = errors.New("unknown tool category")
+
 type toolService struct {
 	toolRepo repository.ToolRepository
 	userRepo repository.UserRepository
@@ -22,6 +28,9 @@ func NewToolService(toolRepo repository.ToolRepository, userRepo repository.User
 }
 
 func (s *toolService) AddTool(ctx context.Context, tool *domain.Tool, images []string) error {
+	if err := s.canonicalizeCategories(ctx, tool); err != nil {
+		return err
+	}
 	if err := s.toolRepo.Create(ctx, tool); err != nil {
 		return err
 	}
@@ -59,15 +68,59 @@ func (s *toolService) GetTool(ctx context.Context, id, requestingUserID int32) (
 }
 
 func (s *toolService) UpdateTool(ctx context.Context, tool *domain.Tool) error {
+	if err := s.canonicalizeCategories(ctx, tool); err != nil {
+		return err
+	}
 	return s.toolRepo.Update(ctx, tool)
 }
 
+// canonicalizeCategories replaces tool.Categories with their curated spellings, dropping
+// duplicates. Matching ignores case, spaces and punctuation, so "power-tools" is "Power Tools".
+func (s *toolService) canonicalizeCategories(ctx context.Context, tool *domain.Tool) error {
+	if len(tool.Categories) == 0 {
+		return nil
+	}
+	curated, err := s.toolRepo.ListCategories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load tool categories: %w", err)
+	}
+	byKey := make(map[string]string, len(curated))
+	for _, c := range curated {
+		byKey[categoryKey(c)] = c
+	}
+
+	categories := make([]string, 0, len(tool.Categories))
+	seen := make(map[string]bool, len(tool.Categories))
+	for _, c := range tool.Categories {
+		name, ok := byKey[categoryKey(c)]
+		if !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownCategory, c)
+		}
+		if !seen[name] {
+			seen[name] = true
+			categories = append(categories, name)
+		}
+	}
+	tool.Categories = categories
+	return nil
+}
+
+func categoryKey(category string) string {
+	var b strings.Builder
+	for _, r := range category {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
 func (s *toolService) DeleteTool(ctx context.Context, id int32) error {
 	return s.toolRepo.Delete(ctx, id)
 }
 
-func (s *toolService) ListTools(ctx context.Context, orgID, requestingUserID int32, page, pageSize int32) ([]domain.Tool, int32, error) {
-	tools, count, err := s.toolRepo.ListByOrg(ctx, orgID, page, pageSize)
+func (s *toolService) ListTools(ctx context.Context, orgID, requestingUserID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error) {
+	tools, count, err := s.toolRepo.ListByOrg(ctx, orgID, includeAdjacent, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -87,9 +140,9 @@ func (s *toolService) ListMyTools(ctx context.Context, userID int32, page, pageS
 	return s.toolRepo.ListByOwner(ctx, userID, page, pageSize)
 }
 
-func (s *toolService) SearchTools(ctx context.Context, userID, orgID int32, metro, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
-	fmt.Printf("DEBUG SearchTools: userID=%d, orgID=%d, metro=%q, query=%q, categories=%v, maxPrice=%d, condition=%q, page=%d, pageSize=%d\n",
-		userID, orgID, metro, query, categories, maxPrice, condition, page, pageSize)
+func (s *toolService) SearchTools(ctx context.Context, userID, orgID int32, metro string, includeAdjacent bool, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
+	fmt.Printf("DEBUG SearchTools: userID=%d, orgID=%d, metro=%q, includeAdjacent=%t, query=%q, categories=%v, maxPrice=%d, condition=%q, page=%d, pageSize=%d\n",
+		userID, orgID, metro, includeAdjacent, query, categories, maxPrice, condition, page, pageSize)
 
 	// Validate required parameters
 	if query == "" {
@@ -97,21 +150,26 @@ func (s *toolService) SearchTools(ctx context.Context, userID, orgID int32, metr
 		return nil, 0, fmt.Errorf("query parameter is required and cannot be empty")
 	}
 
-	// Get metro from org if orgID is provided, otherwise metro must be specified
-	var searchMetro string
+	// Get metro from org if orgID is provided, otherwise metro must be specified.
+	// Adjacent metros come from the org, so they only apply when orgID is provided.
+	var searchMetros []string
 	if orgID != 0 {
 		fmt.Printf("DEBUG SearchTools: orgID provided (%d), fetching metro from organization\n", orgID)
-		// verify user belongs to this organization
-		_, err := s.userRepo.GetUserOrg(ctx, userID, orgID)
-		if err != nil {
-			return nil, 0, fmt.Errorf("user does not belong to organization %d: %w", orgID, err)
+		// verify user belongs to this organization, from the token when it vouches for it
+		if _, ok := claimedRole(ctx, userID, orgID); !ok {
+			if _, err := s.userRepo.GetUserOrg(ctx, userID, orgID); err != nil {
+				return nil, 0, fmt.Errorf("user does not belong to organization %d: %w", orgID, err)
+			}
 		}
 		// Get metro from organization
 		org, err := s.orgRepo.GetByID(ctx, orgID)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to get organization: %w", err)
 		}
-		searchMetro = org.Metro
+		searchMetros = []string{org.Metro}
+		if includeAdjacent {
+			searchMetros = append(searchMetros, org.AdjacentMetros...)
+		}
 	} else {
 		// When orgID not provided, metro must be specified
 		fmt.Printf("DEBUG SearchTools: orgID=0, using metro from request parameter\n")
@@ -119,9 +177,9 @@ func (s *toolService) SearchTools(ctx context.Context, userID, orgID int32, metr
 			fmt.Printf("ERROR SearchTools: metro parameter is empty when orgID=0\n")
 			return nil, 0, fmt.Errorf("metro parameter is required when organization_id is not specified")
 		}
-		searchMetro = metro
+		searchMetros = []string{metro}
 	}
-	fmt.Printf("DEBUG SearchTools: searchMetro=%q\n", searchMetro)
+	fmt.Printf("DEBUG SearchTools: searchMetros=%q\n", searchMetros)
 
 	// Default condition to exclude damaged tools if not specified
 	if condition == "" {
@@ -129,8 +187,8 @@ func (s *toolService) SearchTools(ctx context.Context, userID, orgID int32, metr
 		fmt.Printf("DEBUG SearchTools: condition defaulted to NOT_DAMAGED\n")
 	}
 
-	fmt.Printf("DEBUG SearchTools: calling repository Search with metro=%q, query=%q, condition=%q\n", searchMetro, query, condition)
-	tools, count, err := s.toolRepo.Search(ctx, userID, searchMetro, query, categories, maxPrice, condition, page, pageSize)
+	fmt.Printf("DEBUG SearchTools: calling repository Search with metros=%q, query=%q, condition=%q\n", searchMetros, query, condition)
+	tools, count, err := s.toolRepo.Search(ctx, userID, searchMetros, query, categories, maxPrice, condition, page, pageSize)
 	if err != nil {
 		fmt.Printf("ERROR SearchTools: repository Search failed: %v\n", err)
 		return nil, 0, err
@@ -212,14 +270,25 @@ func (s *toolService) getSharedOrganizations(ctx context.Context, ownerID, reque
 		requestingOrgIDs[userOrg.OrgID] = true
 	}
 
-	// Find shared organizations
-	var sharedOrgs []domain.Organization
+	// Find shared organizations and fetch their details in one query
+	var sharedIDs []int32
 	for _, ownerOrg := range ownerOrgs {
 		if requestingOrgIDs[ownerOrg.OrgID] {
-			// This is a shared organization, fetch its details
-			org, err := s.orgRepo.GetByID(ctx, ownerOrg.OrgID)
+			sharedIDs = append(sharedIDs, ownerOrg.OrgID)
+		}
+	}
+	if len(sharedIDs) == 0 {
+		return nil, nil
+	}
+	sharedOrgs, err := s.orgRepo.GetByIDs(ctx, sharedIDs)
+	if err != nil {
+		// Fall back to one lookup per org so a single bad org does not hide the owner
+		fmt.Printf("DEBUG: Failed to get org details for orgs %v: %v\n", sharedIDs, err)
+		sharedOrgs = nil
+		for _, orgID := range sharedIDs {
+			org, err := s.orgRepo.GetByID(ctx, orgID)
 			if err != nil {
-				fmt.Printf("DEBUG: Failed to get org details for org %d: %v\n", ownerOrg.OrgID, err)
+				fmt.Printf("DEBUG: Failed to get org details for org %d: %v\n", orgID, err)
 				continue // Skip if we can't fetch org details
 			}
 			sharedOrgs = append(sharedOrgs, *org)
@@ -231,6 +300,5 @@ func (s *toolService) getSharedOrganizations(ctx context.Context, ownerID, reque
 }
 
 func (s *toolService) ListCategories(ctx context.Context) ([]string, error) {
-	// Static list for now, or could be fetched from DB
-	return []string{"Hand Tools", "Power Tools", "Gardening", "Plumbing", "Electrical", "Automotive", "Painting", "Cleaning"}, nil
+	return s.toolRepo.ListCategories(ctx)
 }
@@ -2,19 +2,35 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository"
 )
 
+// exportPageSize is the page size used when walking paginated repositories for a data export
+const exportPageSize = 100
+
 type userService struct {
-	userRepo repository.UserRepository
-	orgRepo  repository.OrganizationRepository
+	userRepo   repository.UserRepository
+	orgRepo    repository.OrganizationRepository
+	rentalRepo repository.RentalRepository
+	billRepo   repository.BillRepository
+	ledgerRepo repository.LedgerRepository
+	noteRepo   repository.NotificationRepository
 }
 
-func NewUserService(userRepo repository.UserRepository, orgRepo repository.OrganizationRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, orgRepo repository.OrganizationRepository, rentalRepo repository.RentalRepository, billRepo repository.BillRepository, ledgerRepo repository.LedgerRepository, noteRepo repository.NotificationRepository) UserService {
 	return &userService{
-		userRepo: userRepo,
-		orgRepo:  orgRepo,
+		userRepo:   userRepo,
+		orgRepo:    orgRepo,
+		rentalRepo: rentalRepo,
+		billRepo:   billRepo,
+		ledgerRepo: ledgerRepo,
+		noteRepo:   noteRepo,
 	}
 }
 
@@ -41,7 +57,31 @@ func (s *userService) GetUserProfile(ctx context.Context, userID int32) (*domain
 	return user, orgs, userOrgs, nil
 }
 
+func (s *userService) GetUsersByIDs(ctx context.Context, ids []int32) (map[int32]*domain.User, error) {
+	seen := make(map[int32]bool, len(ids))
+	unique := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	users, err := s.userRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]*domain.User, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	return byID, nil
+}
+
 func (s *userService) UpdateProfile(ctx context.Context, userID int32, name, email, phone, avatarURL string) error {
+	phone, err := domain.NormalizePhoneNumber(phone)
+	if err != nil {
+		return err
+	}
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return err
@@ -52,3 +92,148 @@ func (s *userService) UpdateProfile(ctx context.Context, userID int32, name, ema
 	user.AvatarURL = avatarURL
 	return s.userRepo.Update(ctx, user)
 }
+
+func (s *userService) UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error {
+	if !pref.Valid() {
+		return domain.Invalidf("unknown notification preference %q", pref)
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if pref == domain.NotificationPreferenceEmailAndSMS && user.PhoneNumber == "" {
+		return domain.Invalidf("add a phone number to your profile before turning on SMS")
+	}
+	return s.userRepo.UpdateNotificationPreference(ctx, userID, pref)
+}
+
+func (s *userService) ExportMyData(ctx context.Context, userID int32) ([]byte, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	userOrgs, err := s.userRepo.ListUserOrgs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &domain.UserDataExport{
+		ExportedAt:  time.Now().UTC(),
+		Profile:     *user,
+		Memberships: userOrgs,
+	}
+	export.Profile.Orgs = nil
+
+	for _, uo := range userOrgs {
+		rentals, err := s.exportRentals(ctx, userID, uo.OrgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export rentals: %w", err)
+		}
+		export.Rentals = append(export.Rentals, rentals...)
+
+		bills, _, err := s.billRepo.ListByUser(ctx, userID, uo.OrgID, nil, 0, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export bills: %w", err)
+		}
+		export.Bills = append(export.Bills, bills...)
+
+		txs, err := s.exportTransactions(ctx, userID, uo.OrgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export transactions: %w", err)
+		}
+		export.Transactions = append(export.Transactions, txs...)
+	}
+
+	notes, err := s.exportNotifications(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export notifications: %w", err)
+	}
+	export.Notifications = notes
+
+	// Other users are reduced to ID and display name; contact details are never exported.
+	seen := map[int32]bool{userID: true}
+	var others []int32
+	addOther := func(id int32) {
+		if !seen[id] {
+			seen[id] = true
+			others = append(others, id)
+		}
+	}
+	for _, r := range export.Rentals {
+		addOther(r.RenterID)
+		addOther(r.OwnerID)
+	}
+	for _, b := range export.Bills {
+		addOther(b.DebtorUserID)
+		addOther(b.CreditorUserID)
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+	for _, id := range others {
+		cp := domain.ExportCounterparty{ID: id}
+		if other, err := s.userRepo.GetByID(ctx, id); err == nil && other != nil {
+			cp.Name = other.Name
+		}
+		export.Counterparties = append(export.Counterparties, cp)
+	}
+
+	return json.Marshal(export)
+}
+
+// exportRentals returns every rental in the org where the user is the renter or the owner
+func (s *userService) exportRentals(ctx context.Context, userID, orgID int32) ([]domain.Rental, error) {
+	var all []domain.Rental
+	seen := make(map[int32]bool)
+	for _, list := range []func(ctx context.Context, userID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error){
+		s.rentalRepo.ListByRenter,
+		s.rentalRepo.ListByOwner,
+	} {
+		for page := int32(1); ; page++ {
+			rentals, count, err := list(ctx, userID, orgID, nil, page, exportPageSize)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rentals {
+				if !seen[r.ID] {
+					seen[r.ID] = true
+					all = append(all, r)
+				}
+			}
+			if len(rentals) == 0 || page*exportPageSize >= count {
+				break
+			}
+		}
+	}
+	return all, nil
+}
+
+// exportTransactions returns every ledger transaction of the user in the org
+func (s *userService) exportTransactions(ctx context.Context, userID, orgID int32) ([]domain.LedgerTransaction, error) {
+	var all []domain.LedgerTransaction
+	for page := int32(1); ; page++ {
+		txs, count, err := s.ledgerRepo.ListTransactions(ctx, userID, orgID, page, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, txs...)
+		if len(txs) == 0 || page*exportPageSize >= count {
+			break
+		}
+	}
+	return all, nil
+}
+
+// exportNotifications returns every notification addressed to the user
+func (s *userService) exportNotifications(ctx context.Context, userID int32) ([]domain.Notification, error) {
+	var all []domain.Notification
+	for offset := int32(0); ; offset += exportPageSize {
+		notes, count, err := s.noteRepo.List(ctx, userID, "", exportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, notes...)
+		if len(notes) == 0 || offset+exportPageSize >= count {
+			break
+		}
+	}
+	return all, nil
+}
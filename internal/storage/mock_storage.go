@@ -2,17 +2,31 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrInvalidSignature is returned when a download URL signature does not match its key and expiry
+	ErrInvalidSignature = errors.New("invalid download signature")
+	// ErrURLExpired is returned when a signed download URL is past its expiry
+	ErrURLExpired = errors.New("download URL expired")
+	// ErrInvalidKey is returned when a storage key would resolve outside the uploads directory
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
 // MockStorageService implements image storage using local filesystem
 // This is for demo/testing without AWS S3 or Azure Blob Storage
 type MockStorageService struct {
@@ -20,10 +34,11 @@ type MockStorageService struct {
 	uploadsDir   string // Local directory for uploads (e.g., "./uploads")
 	imagesDir    string // Subdirectory for images
 	thumbnailDir string // Subdirectory for thumbnails
+	signingKey   []byte // HMAC key for signed download URLs
 }
 
 // NewMockStorageService creates a new mock storage service
-func NewMockStorageService(baseURL, uploadsDir string) (*MockStorageService, error) {
+func NewMockStorageService(baseURL, uploadsDir, signingKey string) (*MockStorageService, error) {
 	imagesDir := filepath.Join(uploadsDir, "images")
 	thumbnailDir := filepath.Join(uploadsDir, "thumbnails")
 
@@ -40,6 +55,7 @@ func NewMockStorageService(baseURL, uploadsDir string) (*MockStorageService, err
 		uploadsDir:   uploadsDir,
 		imagesDir:    imagesDir,
 		thumbnailDir: thumbnailDir,
+		signingKey:   []byte(signingKey),
 	}, nil
 }
 
@@ -60,7 +76,8 @@ func (m *MockStorageService) GeneratePresignedUploadURL(
 	return uploadURL, nil
 }
 
-// GeneratePresignedDownloadURL generates a mock download URL
+// GeneratePresignedDownloadURL generates a mock download URL signed with HMAC-SHA256.
+// The URL is only served by the download handler until expiresIn has elapsed.
 func (m *MockStorageService) GeneratePresignedDownloadURL(
 	ctx context.Context,
 	key string,
@@ -68,17 +85,76 @@ func (m *MockStorageService) GeneratePresignedDownloadURL(
 ) (string, error) {
 	// Encode the key for URL safety
 	encodedKey := encodeKey(key)
+	expires := time.Now().Add(expiresIn).Unix()
 
 	// Generate download URL pointing to server
-	// The actual key is in query parameter
-	downloadURL := fmt.Sprintf("%s/api/v1/download/%s?key=%s", m.baseURL, encodedKey, key)
+	// The actual key is in query parameter, alongside expiry and signature
+	downloadURL := fmt.Sprintf("%s/api/v1/download/%s?key=%s&expires=%d&signature=%s",
+		m.baseURL, encodedKey, url.QueryEscape(key), expires, m.sign(key, expires))
 
 	return downloadURL, nil
 }
 
+// GeneratePresignedDeleteURL generates a mock URL that removes the file when sent a DELETE.
+// Its signature is distinct from download signatures, so a download URL cannot delete.
+func (m *MockStorageService) GeneratePresignedDeleteURL(
+	ctx context.Context,
+	key string,
+	expiresIn time.Duration,
+) (string, error) {
+	expires := time.Now().Add(expiresIn).Unix()
+	deleteURL := fmt.Sprintf("%s/api/v1/files/%s?key=%s&expires=%d&signature=%s",
+		m.baseURL, encodeKey(key), url.QueryEscape(key), expires, m.sign("DELETE\n"+key, expires))
+	return deleteURL, nil
+}
+
+// VerifyDeleteSignature checks that a delete URL was issued by this service and has not expired
+func (m *MockStorageService) VerifyDeleteSignature(key, expires, signature string) error {
+	return m.VerifyDownloadSignature("DELETE\n"+key, expires, signature)
+}
+
+// VerifyDownloadSignature checks that a download URL was issued by this service and has not expired
+func (m *MockStorageService) VerifyDownloadSignature(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(m.sign(key, expiresAt))) {
+		return ErrInvalidSignature
+	}
+	if time.Now().Unix() > expiresAt {
+		return ErrURLExpired
+	}
+	return nil
+}
+
+// sign computes the hex HMAC of the key and expiry
+func (m *MockStorageService) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, m.signingKey)
+	fmt.Fprintf(mac, "%s\n%d", key, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// resolvePath maps a storage key to a path inside the images directory, rejecting keys
+// such as "../x" or absolute paths that would escape it
+func (m *MockStorageService) resolvePath(key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	fullPath := filepath.Join(m.imagesDir, key)
+	rel, err := filepath.Rel(m.imagesDir, fullPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return fullPath, nil
+}
+
 // FileExists checks if file exists in local filesystem
 func (m *MockStorageService) FileExists(ctx context.Context, key string) (bool, int64, error) {
-	fullPath := filepath.Join(m.imagesDir, key)
+	fullPath, err := m.resolvePath(key)
+	if err != nil {
+		return false, 0, err
+	}
 
 	// Debug logging
 	fmt.Printf("[DEBUG FileExists] key=%s, fullPath=%s\n", key, fullPath)
@@ -99,9 +175,12 @@ func (m *MockStorageService) FileExists(ctx context.Context, key string) (bool,
 
 // DeleteFile deletes file from local filesystem
 func (m *MockStorageService) DeleteFile(ctx context.Context, key string) error {
-	fullPath := filepath.Join(m.imagesDir, key)
+	fullPath, err := m.resolvePath(key)
+	if err != nil {
+		return err
+	}
 
-	err := os.Remove(fullPath)
+	err = os.Remove(fullPath)
 	if err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
@@ -112,7 +191,10 @@ func (m *MockStorageService) DeleteFile(ctx context.Context, key string) error {
 // SaveFile saves uploaded file to local filesystem
 func (m *MockStorageService) SaveFile(key string, reader io.Reader) error {
 	// Determine full path
-	fullPath := filepath.Join(m.imagesDir, key)
+	fullPath, err := m.resolvePath(key)
+	if err != nil {
+		return err
+	}
 
 	// Create parent directories
 	dir := filepath.Dir(fullPath)
@@ -138,7 +220,10 @@ func (m *MockStorageService) SaveFile(key string, reader io.Reader) error {
 
 // ReadFile reads file from local filesystem
 func (m *MockStorageService) ReadFile(key string) (io.ReadCloser, error) {
-	fullPath := filepath.Join(m.imagesDir, key)
+	fullPath, err := m.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
 
 	file, err := os.Open(fullPath)
 	if err != nil {
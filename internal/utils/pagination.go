@@ -0,0 +1,30 @@
+package utils
+
+import "math"
+
+const (
+	// DefaultPageSize is used when a caller asks for a page size of zero or less
+	DefaultPageSize int32 = 10
+	// MaxPageSize caps the rows a single page may return
+	MaxPageSize int32 = 100
+)
+
+// Paginate clamps a 1-based page and a page size to safe bounds and returns the SQL LIMIT and
+// OFFSET for them. A page below 1 is the first page, a page size below 1 is DefaultPageSize
+// and one above MaxPageSize is MaxPageSize. The offset saturates instead of overflowing.
+func Paginate(page, pageSize int32) (limit, offset int32) {
+	if page < 1 {
+		page = 1
+	}
+	switch {
+	case pageSize < 1:
+		pageSize = DefaultPageSize
+	case pageSize > MaxPageSize:
+		pageSize = MaxPageSize
+	}
+	off := int64(page-1) * int64(pageSize)
+	if off > math.MaxInt32 {
+		off = math.MaxInt32
+	}
+	return pageSize, int32(off)
+}
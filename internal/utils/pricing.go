@@ -22,6 +22,23 @@ type DateDifference struct {
 	Days   int
 }
 
+// RoundingPolicy controls how a partial week or month is charged for week- and month-unit tools
+type RoundingPolicy string
+
+const (
+	// RoundingPolicyRoundUp charges any partial unit as a full unit (default)
+	RoundingPolicyRoundUp RoundingPolicy = "round_up"
+	// RoundingPolicyNearest charges a partial unit as full when at least half of it is used
+	RoundingPolicyNearest RoundingPolicy = "nearest"
+	// RoundingPolicyProrate charges partial units by the day (unit price / days per unit)
+	RoundingPolicyProrate RoundingPolicy = "prorate"
+)
+
+const (
+	daysPerWeek  = 7
+	daysPerMonth = 30 // Used to size a partial month for nearest/prorate rounding
+)
+
 // RentalPriceSnapshot holds price data captured from a tool at the time a rental is created.
 // All cost calculations use this snapshot so that subsequent tool price changes do not affect
 // already-created rentals.
@@ -30,6 +47,7 @@ type RentalPriceSnapshot struct {
 	PricePerDayCents   int32
 	PricePerWeekCents  int32
 	PricePerMonthCents int32
+	Rounding           RoundingPolicy // Empty means RoundingPolicyRoundUp
 }
 
 // RentalCostBreakdown provides detailed cost breakdown
@@ -180,45 +198,96 @@ func CalculateRentalCost(startDate, endDate time.Time, prices RentalPriceSnapsho
 	}
 }
 
-// calculateMonthUnitCost calculates cost for month-based duration
-// If diff.Days == 0, charges exact months; otherwise rounds up to next month
-func calculateMonthUnitCost(prices RentalPriceSnapshot, diff DateDifference) int32 {
-	var months int32
-	if diff.Days == 0 {
-		months = int32(diff.Months)
-	} else {
-		months = int32(diff.Months + 1)
+// roundUnits returns the number of whole units charged for full units plus leftover days
+// under the round-up or nearest policy. Prorate is handled separately by the callers.
+func roundUnits(full, leftoverDays, daysPerUnit int, policy RoundingPolicy) int {
+	if leftoverDays == 0 {
+		return full
+	}
+	if policy == RoundingPolicyNearest && leftoverDays*2 < daysPerUnit {
+		return full
+	}
+	return full + 1
+}
+
+// prorateCents charges days at unitPrice/daysPerUnit, rounded to the nearest cent
+func prorateCents(days int, unitPrice int32, daysPerUnit int) int32 {
+	return int32((int64(days)*int64(unitPrice) + int64(daysPerUnit)/2) / int64(daysPerUnit))
+}
+
+// monthUnitBreakdown charges whole months and applies the rounding policy to leftover days.
+// Round-up and nearest charge at least one month.
+func monthUnitBreakdown(prices RentalPriceSnapshot, diff DateDifference) RentalCostBreakdown {
+	if prices.Rounding == RoundingPolicyProrate {
+		monthsCost := int32(diff.Months) * prices.PricePerMonthCents
+		daysCost := prorateCents(diff.Days, prices.PricePerMonthCents, daysPerMonth)
+		return RentalCostBreakdown{
+			Months:     diff.Months,
+			Days:       diff.Days,
+			MonthsCost: monthsCost,
+			DaysCost:   daysCost,
+			TotalCost:  monthsCost + daysCost,
+		}
 	}
 
+	months := roundUnits(diff.Months, diff.Days, daysPerMonth, prices.Rounding)
 	if months < 1 {
 		months = 1
 	}
-
-	return months * prices.PricePerMonthCents
+	totalCost := int32(months) * prices.PricePerMonthCents
+	return RentalCostBreakdown{
+		Months:     months,
+		MonthsCost: totalCost,
+		TotalCost:  totalCost,
+	}
 }
 
-// calculateWeekUnitCost calculates cost for week-based duration
-// Rounds up to nearest full week
-func calculateWeekUnitCost(prices RentalPriceSnapshot, diff DateDifference) int32 {
-	const daysPerWeek = 7
+// weekUnitBreakdown charges whole months, then weeks for the leftover days under the rounding policy.
+// Round-up and nearest charge at least one week. Leftover weeks never cost more than a month.
+func weekUnitBreakdown(prices RentalPriceSnapshot, diff DateDifference) RentalCostBreakdown {
+	b := RentalCostBreakdown{
+		Months:     diff.Months,
+		MonthsCost: int32(diff.Months) * prices.PricePerMonthCents,
+	}
 
-	// Calculate total weeks, rounding up
-	weeks := int32(diff.Days / daysPerWeek)
-	if diff.Days%daysPerWeek > 0 {
-		weeks += 1
+	if prices.Rounding == RoundingPolicyProrate {
+		b.Weeks = diff.Days / daysPerWeek
+		b.Days = diff.Days % daysPerWeek
+		b.WeeksCost = int32(b.Weeks) * prices.PricePerWeekCents
+		b.DaysCost = prorateCents(b.Days, prices.PricePerWeekCents, daysPerWeek)
+	} else {
+		b.Weeks = roundUnits(diff.Days/daysPerWeek, diff.Days%daysPerWeek, daysPerWeek, prices.Rounding)
+		if diff.Months == 0 && b.Weeks < 1 {
+			b.Weeks = 1
+		}
+		b.WeeksCost = int32(b.Weeks) * prices.PricePerWeekCents
 	}
 
-	// Add cost for full months (converted to weeks)
-	monthsCost := int32(diff.Months) * prices.PricePerMonthCents
-	weeksCost := min(weeks*prices.PricePerWeekCents, prices.PricePerMonthCents) // Cap week cost at month cost
+	// Cap week cost at month cost: charge the leftover as one more month instead
+	if b.WeeksCost+b.DaysCost > prices.PricePerMonthCents {
+		b.Months++
+		b.MonthsCost += prices.PricePerMonthCents
+		b.Weeks, b.Days, b.WeeksCost, b.DaysCost = 0, 0, 0, 0
+	}
+	b.TotalCost = b.MonthsCost + b.WeeksCost + b.DaysCost
+	return b
+}
+
+// calculateMonthUnitCost calculates cost for month-based duration.
+// With the default round-up policy, exact months are charged as-is and any leftover days
+// round up to the next month.
+func calculateMonthUnitCost(prices RentalPriceSnapshot, diff DateDifference) int32 {
+	return monthUnitBreakdown(prices, diff).TotalCost
+}
 
-	return monthsCost + weeksCost
+// calculateWeekUnitCost calculates cost for week-based duration.
+// With the default round-up policy, leftover days round up to a full week.
+func calculateWeekUnitCost(prices RentalPriceSnapshot, diff DateDifference) int32 {
+	return weekUnitBreakdown(prices, diff).TotalCost
 }
 
 // calculateDayUnitCost calculates cost using tiered pricing (months + weeks + days)
 func calculateDayUnitCost(prices RentalPriceSnapshot, diff DateDifference) int32 {
-	const daysPerWeek = 7
-
 	// Break down remaining days into weeks and days
 	weeks := int32(diff.Days / daysPerWeek)
 	days := int32(diff.Days % daysPerWeek)
@@ -257,46 +326,12 @@ func CalculateRentalCostWithBreakdown(startDate, endDate time.Time, prices Renta
 	// Calculate breakdown based on duration unit
 	switch prices.DurationUnit {
 	case domain.ToolDurationUnitMonth:
-		var months int
-		if diff.Days == 0 {
-			months = diff.Months
-		} else {
-			months = diff.Months + 1
-		}
-		if months < 1 {
-			months = 1
-		}
-		totalCost := int32(months) * prices.PricePerMonthCents
-		return RentalCostBreakdown{
-			Months:     months,
-			Weeks:      0,
-			Days:       0,
-			MonthsCost: totalCost,
-			WeeksCost:  0,
-			DaysCost:   0,
-			TotalCost:  totalCost,
-		}, nil
+		return monthUnitBreakdown(prices, diff), nil
 
 	case domain.ToolDurationUnitWeek:
-		const daysPerWeek = 7
-		weeks := diff.Days / daysPerWeek
-		if diff.Days%daysPerWeek > 0 {
-			weeks += 1
-		}
-		monthsCost := int32(diff.Months) * prices.PricePerMonthCents
-		weeksCost := int32(weeks) * prices.PricePerWeekCents
-		return RentalCostBreakdown{
-			Months:     diff.Months,
-			Weeks:      weeks,
-			Days:       0,
-			MonthsCost: monthsCost,
-			WeeksCost:  weeksCost,
-			DaysCost:   0,
-			TotalCost:  monthsCost + weeksCost,
-		}, nil
+		return weekUnitBreakdown(prices, diff), nil
 
 	case domain.ToolDurationUnitDay:
-		const daysPerWeek = 7
 		weeks := diff.Days / daysPerWeek
 		days := diff.Days % daysPerWeek
 		monthsCost := int32(diff.Months) * prices.PricePerMonthCents
@@ -314,7 +349,6 @@ func CalculateRentalCostWithBreakdown(startDate, endDate time.Time, prices Renta
 
 	default:
 		// Default to day unit
-		const daysPerWeek = 7
 		weeks := diff.Days / daysPerWeek
 		days := diff.Days % daysPerWeek
 		monthsCost := int32(diff.Months) * prices.PricePerMonthCents
@@ -9,7 +9,7 @@
 -- CREATE TYPE tool_status_enum AS ENUM ('AVAILABLE', 'UNAVAILABLE', 'RENTED');
 -- CREATE TYPE tool_condition_enum AS ENUM ('EXCELLENT', 'GOOD', 'ACCEPTABLE', 'DAMAGED/NEEDS_REPAIR');
 -- CREATE TYPE ledger_transaction_type_enum AS ENUM ('RENTAL_DEBIT', 'LENDING_CREDIT', 'REFUND', 'ADJUSTMENT');
--- CREATE TYPE rental_status_enum AS ENUM ('PENDING', 'APPROVED', 'REJECTED', 'SCHEDULED', 'ACTIVE', 'COMPLETED', 'CANCELLED', 'OVERDUE', 'RETURN_DATE_CHANGED', 'RETURN_DATE_CHANGE_REJECTED');
+-- CREATE TYPE rental_status_enum AS ENUM ('PENDING', 'APPROVED', 'REJECTED', 'SCHEDULED', 'ACTIVE', 'COMPLETED', 'CANCELLED', 'OVERDUE', 'RETURN_DATE_CHANGED', 'RETURN_DATE_CHANGE_REJECTED', 'EARLY_RETURN_REQUESTED');
 -- CREATE TYPE rental_dispute_status_enum AS ENUM ('INITIALIZED', 'RESOLVED', 'ADMIN_RESOLVED');
 
 -- 1. Organizations (Community/Church Groups)
@@ -19,25 +19,50 @@ CREATE TABLE orgs (
     description TEXT,
     address TEXT NOT NULL,
     metro TEXT NOT NULL, -- Required metro for location-based features
-    admin_phone_number TEXT NOT NULL,
+    admin_phone_number TEXT NOT NULL, -- E.164, normalized on write (see domain.NormalizePhoneNumber)
     admin_email TEXT NOT NULL,
     max_replacement_cost_cents INTEGER NOT NULL DEFAULT 30000, -- Max allowed replacement cost for tools in this org
     max_billsplit_rental_cost_cents INTEGER NOT NULL DEFAULT 1000, -- Max rental cost allowed to be settled by bill splitting. 
-    billsplit_settlement_threshold_cents INTEGER NOT NULL DEFAULT 500, -- Max amount allowed to carry over to next billing cycle after bill splitting.
+    billsplit_settlement_threshold_cents INTEGER CHECK (billsplit_settlement_threshold_cents > 0), -- Max amount allowed to carry over to next billing cycle after bill splitting. NULL uses billing.default_settlement_threshold_cents
+    auto_activate_rentals BOOLEAN NOT NULL DEFAULT FALSE, -- Activate SCHEDULED rentals on their start date without a manual pickup step
+    adjacent_metros TEXT[] NOT NULL DEFAULT '{}', -- Nearby metros members may opt in to when searching tools
+    currency_code TEXT NOT NULL DEFAULT 'USD', -- ISO 4217 code amounts are labeled with in notifications and responses
     created_on DATE DEFAULT CURRENT_DATE
 );
+-- Backfill for databases created before auto_activate_rentals existed:
+-- ALTER TABLE orgs ADD COLUMN IF NOT EXISTS auto_activate_rentals BOOLEAN NOT NULL DEFAULT FALSE;
+-- Backfill for databases created before adjacent_metros existed:
+-- ALTER TABLE orgs ADD COLUMN IF NOT EXISTS adjacent_metros TEXT[] NOT NULL DEFAULT '{}';
+-- Backfill for databases created before currency_code existed:
+-- ALTER TABLE orgs ADD COLUMN IF NOT EXISTS currency_code TEXT NOT NULL DEFAULT 'USD';
+-- Backfill for databases created before metros were normalized on write (see domain.NormalizeMetro):
+-- UPDATE orgs SET metro = initcap(regexp_replace(btrim(metro), '\s+', ' ', 'g'));
+-- Backfill for databases created before the settlement threshold became optional (existing orgs keep their value):
+-- ALTER TABLE orgs ALTER COLUMN billsplit_settlement_threshold_cents DROP NOT NULL;
+-- ALTER TABLE orgs ALTER COLUMN billsplit_settlement_threshold_cents DROP DEFAULT;
+-- ALTER TABLE orgs ADD CONSTRAINT orgs_billsplit_settlement_threshold_cents_check CHECK (billsplit_settlement_threshold_cents > 0);
 
 -- 2. Users & Auth
 CREATE TABLE users (
     id SERIAL PRIMARY KEY,
     email TEXT UNIQUE NOT NULL,
-    phone_number TEXT NOT NULL,
+    phone_number TEXT NOT NULL, -- E.164, normalized on write (see domain.NormalizePhoneNumber)
     password_hash TEXT NOT NULL,
     name TEXT NOT NULL,
     avatar_url TEXT,
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE, -- Set once the user opens the emailed verification link
+    token_version INTEGER NOT NULL DEFAULT 0, -- Bumped to invalidate every refresh token issued earlier
+    notification_preference TEXT NOT NULL DEFAULT 'EMAIL' CHECK (notification_preference IN ('EMAIL', 'EMAIL_AND_SMS')), -- EMAIL_AND_SMS also texts high-priority events
     created_on DATE DEFAULT CURRENT_DATE,
     updated_on DATE DEFAULT CURRENT_DATE
 );
+-- Backfill for databases created before email_verified existed (existing accounts are trusted):
+-- ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+-- UPDATE users SET email_verified = TRUE;
+-- Backfill for databases created before token_version existed:
+-- ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
+-- Backfill for databases created before notification_preference existed:
+-- ALTER TABLE users ADD COLUMN IF NOT EXISTS notification_preference TEXT NOT NULL DEFAULT 'EMAIL';
 
 -- Join table for Many-to-Many (Users <-> Orgs)
 CREATE TABLE users_orgs (
@@ -53,9 +78,13 @@ CREATE TABLE users_orgs (
     blocked_due_to_bill_id INTEGER, -- FK constrain added after bills table creation
     blocked_reason TEXT,
     blocked_on Date,
+    low_balance_alerted_at TIMESTAMP, -- Set when SendLowBalanceAlerts warns the member; cleared once the balance recovers
     PRIMARY KEY (user_id, org_id)
 );
 
+-- Backfill for databases created before low_balance_alerted_at existed:
+-- ALTER TABLE users_orgs ADD COLUMN IF NOT EXISTS low_balance_alerted_at TIMESTAMP;
+
 CREATE INDEX idx_users_orgs_renting_blocked ON users_orgs(user_id, org_id) WHERE renting_blocked = TRUE;
 CREATE INDEX idx_users_orgs_lending_blocked ON users_orgs(user_id, org_id) WHERE lending_blocked = TRUE;
 
@@ -82,9 +111,12 @@ CREATE TABLE invitations (
     expires_on DATE NOT NULL,
     used_on DATE, -- NULL if unused
     used_by_user_id INTEGER REFERENCES users(id), -- User who used the invitation
+    revoked_on DATE, -- Set by RevokeInvitation; a revoked invitation can no longer be used
     created_on DATE DEFAULT CURRENT_DATE,
     UNIQUE(invitation_code, email) -- Ensure uniqueness of invitation tuple
 );
+-- Backfill for databases created before revoked_on existed:
+-- ALTER TABLE invitations ADD COLUMN IF NOT EXISTS revoked_on DATE;
 
 -- One pending 2FA code per user at a time (upsert on user_id keeps the table bounded).
 -- expires_at allows the server to reject stale codes without a separate cleanup job.
@@ -102,13 +134,59 @@ CREATE TABLE pending_credentials (
     PRIMARY KEY (user_id)
 );
 
+-- Refresh tokens revoked by logout, keyed by their jti claim.
+-- Rows past expires_at no longer matter and can be purged.
+CREATE TABLE revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL, -- Expiry of the revoked token
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
 -- 3. Tools
+-- Curated tool categories. AddTool and UpdateTool only accept these names (see toolService.canonicalizeCategories)
+CREATE TABLE tool_categories (
+    name TEXT PRIMARY KEY,
+    sort_order INTEGER NOT NULL DEFAULT 0 -- Order returned by ListToolCategories
+);
+
+INSERT INTO tool_categories (name, sort_order) VALUES
+    ('Hand Tools', 1),
+    ('Power Tools', 2),
+    ('Gardening', 3),
+    ('Plumbing', 4),
+    ('Electrical', 5),
+    ('Automotive', 6),
+    ('Painting', 7),
+    ('Cleaning', 8),
+    ('Construction', 9),
+    ('Ladders', 10),
+    ('Outdoor & Camping', 11),
+    ('Kitchen', 12),
+    ('Other', 13);
+
+-- Backfill for databases created before tool_categories existed: create and seed the table above, then map
+-- common variants of existing tool categories to their curated names. Unmatched values are left for review.
+-- UPDATE tools t SET categories = ARRAY(
+--     SELECT DISTINCT COALESCE(c.name, v.name, u.category)
+--     FROM unnest(t.categories) AS u(category)
+--     LEFT JOIN tool_categories c ON lower(regexp_replace(c.name, '[^[:alnum:]]', '', 'g')) = lower(regexp_replace(u.category, '[^[:alnum:]]', '', 'g'))
+--     LEFT JOIN (VALUES ('powertool', 'Power Tools'), ('power', 'Power Tools'), ('handtool', 'Hand Tools'),
+--                       ('garden', 'Gardening'), ('yard', 'Gardening'), ('lawn', 'Gardening'),
+--                       ('electric', 'Electrical'), ('auto', 'Automotive'), ('car', 'Automotive'),
+--                       ('paint', 'Painting'), ('ladder', 'Ladders'), ('camping', 'Outdoor & Camping'))
+--         AS v(variant, name) ON lower(regexp_replace(u.category, '[^[:alnum:]]', '', 'g')) = v.variant)
+-- WHERE categories IS NOT NULL;
+-- SELECT DISTINCT unnest(categories) FROM tools EXCEPT SELECT name FROM tool_categories; -- left for review
+
 CREATE TABLE tools (
     id SERIAL PRIMARY KEY,
     owner_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
     name TEXT NOT NULL,
     description TEXT, -- Description/Details
-    categories TEXT[], -- Array of categories
+    categories TEXT[], -- Array of tool_categories names
     price_per_day_cents INTEGER NOT NULL DEFAULT 0,
     price_per_week_cents INTEGER NOT NULL DEFAULT 0,
     price_per_month_cents INTEGER NOT NULL DEFAULT 0,
@@ -118,9 +196,32 @@ CREATE TABLE tools (
     metro TEXT, -- Optional location indicator
     status TEXT NOT NULL DEFAULT 'AVAILABLE',
     created_on DATE DEFAULT CURRENT_DATE,
-    deleted_on DATE
+    updated_on DATE DEFAULT CURRENT_DATE, -- Set by every tool update
+    deleted_on DATE,
+    -- Full-text search document; name matches are weighted above description matches
+    search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED
 );
 
+CREATE INDEX idx_tools_search_vector ON tools USING GIN (search_vector);
+-- Search and ListByOrg compare metros case-insensitively
+CREATE INDEX idx_tools_metro_lower ON tools (lower(metro)) WHERE deleted_on IS NULL;
+-- Backfill for databases created before metros were normalized on write:
+-- UPDATE tools SET metro = initcap(regexp_replace(btrim(metro), '\s+', ' ', 'g')) WHERE metro IS NOT NULL;
+-- Backfill for databases created before updated_on existed:
+-- ALTER TABLE tools ADD COLUMN IF NOT EXISTS updated_on DATE DEFAULT CURRENT_DATE;
+-- UPDATE tools SET updated_on = created_on;
+-- Backfill for databases created before search_vector existed. Adding a stored generated column
+-- computes it for existing rows; the UPDATE recomputes every row explicitly before indexing:
+-- ALTER TABLE tools ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+--     setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+--     setweight(to_tsvector('english', coalesce(description, '')), 'B')
+-- ) STORED;
+-- UPDATE tools SET name = name;
+-- CREATE INDEX IF NOT EXISTS idx_tools_search_vector ON tools USING GIN (search_vector);
+
 -- Unified table for both pending and confirmed tool images
 CREATE TABLE tool_images (
     id SERIAL PRIMARY KEY,
@@ -159,12 +260,14 @@ CREATE TABLE rentals (
     owner_id INTEGER REFERENCES users(id),
     start_date DATE NOT NULL,
     last_agreed_end_date DATE, -- Last agreed return date (agreed by both renter and owner,can be updated with return date change flow)
-    end_date DATE NOT NULL, -- Actual return date
+    end_date DATE NOT NULL, -- Return date currently in force; total_cost_cents is priced against it
+    requested_end_date DATE, -- Return date proposed in an open extension or early return negotiation (RETURN_DATE_CHANGED / RETURN_DATE_CHANGE_REJECTED / EARLY_RETURN_REQUESTED), NULL otherwise
     duration_unit TEXT NOT NULL DEFAULT 'day',
     daily_price_cents INTEGER NOT NULL,
     weekly_price_cents INTEGER NOT NULL,
     monthly_price_cents INTEGER NOT NULL,
     replacement_cost_cents INTEGER NOT NULL,
+    pricing_rounding TEXT NOT NULL DEFAULT 'round_up' CHECK (pricing_rounding IN ('round_up', 'nearest', 'prorate')), -- rental.pricing_rounding when the rental was requested
     total_cost_cents INTEGER NOT NULL,
     status TEXT NOT NULL DEFAULT 'PENDING',
     pickup_note TEXT,
@@ -175,8 +278,50 @@ CREATE TABLE rentals (
     surcharge_or_credit_cents INTEGER, -- For late return or damage fees or credits for early return
     charge_billsplit BOOLEAN NOT NULL DEFAULT TRUE, -- Whether the rental cost should be included in bill splitting calculation
     created_on DATE DEFAULT CURRENT_DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- Request time; pending requests expire rental.request_expiry_hours after it
+    last_reminder_level INTEGER NOT NULL DEFAULT 0, -- Highest overdue reminder sent: 0 none, 1 reminder, 2 second notice, 3 final notice (flagged for admins)
     updated_on DATE DEFAULT CURRENT_DATE
 );
+CREATE INDEX idx_rentals_pending_created_at ON rentals(created_at) WHERE status = 'PENDING';
+CREATE INDEX idx_rentals_tool_dates ON rentals(tool_id, start_date, end_date);
+-- Listing indexes match the ORDER BY created_on DESC, id DESC of the paginated rental listers
+CREATE INDEX idx_rentals_renter_listing ON rentals(renter_id, org_id, created_on DESC, id DESC);
+CREATE INDEX idx_rentals_owner_listing ON rentals(owner_id, org_id, created_on DESC, id DESC);
+CREATE INDEX idx_rentals_tool_listing ON rentals(tool_id, created_on DESC, id DESC);
+-- Backfill for databases created before created_at existed:
+-- ALTER TABLE rentals ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- UPDATE rentals SET created_at = created_on::TIMESTAMPTZ WHERE created_on IS NOT NULL;
+-- CREATE INDEX IF NOT EXISTS idx_rentals_pending_created_at ON rentals(created_at) WHERE status = 'PENDING';
+-- Backfill for databases created before idx_rentals_tool_dates existed:
+-- CREATE INDEX IF NOT EXISTS idx_rentals_tool_dates ON rentals(tool_id, start_date, end_date);
+-- Backfill for databases created before the listing indexes existed:
+-- CREATE INDEX IF NOT EXISTS idx_rentals_renter_listing ON rentals(renter_id, org_id, created_on DESC, id DESC);
+-- CREATE INDEX IF NOT EXISTS idx_rentals_owner_listing ON rentals(owner_id, org_id, created_on DESC, id DESC);
+-- CREATE INDEX IF NOT EXISTS idx_rentals_tool_listing ON rentals(tool_id, created_on DESC, id DESC);
+-- Backfill for databases created before last_reminder_level existed:
+-- ALTER TABLE rentals ADD COLUMN IF NOT EXISTS last_reminder_level INTEGER NOT NULL DEFAULT 0;
+-- Backfill for databases created before requested_end_date existed. In-flight negotiations kept
+-- the proposed date in end_date; move it over and put the agreed date back (cost is repriced on
+-- approval, cancellation or acknowledgement):
+-- ALTER TABLE rentals ADD COLUMN IF NOT EXISTS requested_end_date DATE;
+-- UPDATE rentals SET requested_end_date = end_date, end_date = COALESCE(last_agreed_end_date, end_date)
+--     WHERE status IN ('RETURN_DATE_CHANGED', 'RETURN_DATE_CHANGE_REJECTED') AND requested_end_date IS NULL;
+-- Backfill for databases created before pricing_rounding existed (existing rentals were priced with round_up):
+-- ALTER TABLE rentals ADD COLUMN IF NOT EXISTS pricing_rounding TEXT NOT NULL DEFAULT 'round_up'
+--     CHECK (pricing_rounding IN ('round_up', 'nearest', 'prorate'));
+
+-- Rental events: one row per status transition, the rental's audit timeline
+CREATE TABLE rental_events (
+    id SERIAL PRIMARY KEY,
+    rental_id INTEGER NOT NULL REFERENCES rentals(id) ON DELETE CASCADE,
+    actor_user_id INTEGER REFERENCES users(id), -- NULL for transitions made by scheduled jobs
+    from_status TEXT, -- NULL for the creating event
+    to_status TEXT NOT NULL,
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_rental_events_rental ON rental_events(rental_id, created_at);
 
 CREATE TABLE rental_disputes (
     id SERIAL PRIMARY KEY,
@@ -224,10 +369,66 @@ CREATE TABLE notifications (
     created_at TIMESTAMPTZ DEFAULT NOW()
 );
 
+-- Type/topic filtering uses attributes @> '{"type": ...}', served by the GIN index
+CREATE INDEX idx_notifications_attributes ON notifications USING GIN (attributes jsonb_path_ops);
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- Notifications whose insert failed, re-created by the retry_failed_notifications job
+CREATE TABLE notification_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    org_id INTEGER REFERENCES orgs(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL,
+    attributes JSONB,
+    silent BOOLEAN NOT NULL DEFAULT FALSE, -- dispatched without a push notification
+    status TEXT NOT NULL DEFAULT 'FAILED', -- FAILED, RETRYING, DELIVERED
+    attempts INTEGER NOT NULL DEFAULT 1, -- includes the original dispatch
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    notification_id BIGINT REFERENCES notifications(id) ON DELETE SET NULL, -- set once re-created
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_dead_letters_retry ON notification_dead_letters(next_attempt_at) WHERE status <> 'DELIVERED';
+
 -- Implementation note: when processing ReportEventRequest from client, update the corresponding timestamp based on event_type
 -- Use below SQL as reference for the update query (example for DELIVERED event):
 -- UPDATE notifications SET delivered_at = COALESCE(delivered_at, NOW()) WHERE id = $1
 
+-- Email outbox: transactional emails are persisted here, then delivered by a worker
+CREATE TABLE email_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    recipients TEXT[] NOT NULL,
+    cc TEXT[],
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    is_html BOOLEAN NOT NULL DEFAULT FALSE,
+    html_body TEXT, -- HTML alternative to body, sent as multipart/alternative
+    status TEXT NOT NULL DEFAULT 'PENDING', -- PENDING, SENDING, SENT, FAILED
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    sent_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_email_outbox_retry ON email_outbox(next_attempt_at) WHERE status <> 'SENT';
+
+-- Job runs: one row per cronjob job execution, written by JobRunner
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job_name TEXT NOT NULL, -- e.g. PerformBillSplitting
+    status TEXT NOT NULL DEFAULT 'RUNNING', -- RUNNING, SUCCEEDED, FAILED
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_job_runs_job_started ON job_runs(job_name, started_at DESC);
+
 CREATE TABLE fcm_tokens (
     id SERIAL PRIMARY KEY,
     user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
@@ -243,8 +444,13 @@ CREATE TABLE fcm_tokens (
 CREATE INDEX idx_fcm_tokens_user_id ON fcm_tokens(user_id) WHERE status = 'ACTIVE';
 
 -- Function to update balance on insert
+-- HOLD / HOLD_RELEASE entries only reserve funds for a scheduled rental and leave the balance untouched.
+-- Databases created before holds existed pick this up by re-running the CREATE OR REPLACE below.
 CREATE OR REPLACE FUNCTION update_user_balance() RETURNS TRIGGER AS $$
 BEGIN
+    IF NEW.type IN ('HOLD', 'HOLD_RELEASE') THEN
+        RETURN NEW;
+    END IF;
     UPDATE users_orgs
     SET balance_cents = balance_cents + NEW.amount,
         last_balance_updated_on = CURRENT_DATE
@@ -258,23 +464,46 @@ AFTER INSERT ON ledger_transactions
 FOR EACH ROW
 EXECUTE FUNCTION update_user_balance();
 
+-- Backfill for databases created before bill settlements and dispute penalties wrote ledger entries.
+-- Their balance changes never reached the ledger, so record the difference once (with the trigger off,
+-- as balance_cents already includes it) before enabling billing.auto_reconcile:
+-- ALTER TABLE ledger_transactions DISABLE TRIGGER trigger_update_balance;
+-- INSERT INTO ledger_transactions (org_id, user_id, amount, type, description)
+--     SELECT uo.org_id, uo.user_id, uo.balance_cents - COALESCE(SUM(lt.amount), 0), 'ADJUSTMENT',
+--            'Bill settlements and penalties recorded before ledger entries existed'
+--     FROM users_orgs uo
+--     LEFT JOIN ledger_transactions lt ON lt.user_id = uo.user_id AND lt.org_id = uo.org_id
+--          AND lt.type NOT IN ('HOLD', 'HOLD_RELEASE')
+--     GROUP BY uo.org_id, uo.user_id, uo.balance_cents
+--     HAVING uo.balance_cents != COALESCE(SUM(lt.amount), 0);
+-- ALTER TABLE ledger_transactions ENABLE TRIGGER trigger_update_balance;
+
 -- 7. Bill Splitting & Dispute Resolution
 
--- Captures user account balance snapshot before bill splitting calculation
+-- Captures user account balance snapshots (before bill splitting and for balance history)
+-- One row per member per day; re-running the snapshot job on the same day overwrites the row.
 CREATE TABLE balance_snapshots (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     org_id INTEGER NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
     balance_cents INTEGER NOT NULL,
     settlement_month TEXT NOT NULL, -- Format: 'YYYY-MM' (e.g., '2026-01')
+    snapshot_date DATE NOT NULL DEFAULT CURRENT_DATE,
     snapshot_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
     created_at TIMESTAMPTZ DEFAULT NOW(),
-    UNIQUE(user_id, org_id, settlement_month)
+    UNIQUE(user_id, org_id, snapshot_date)
 );
 
-CREATE INDEX idx_balance_snapshots_user_org ON balance_snapshots(user_id, org_id);
+CREATE INDEX idx_balance_snapshots_user_org ON balance_snapshots(user_id, org_id, snapshot_date);
 CREATE INDEX idx_balance_snapshots_settlement ON balance_snapshots(settlement_month);
 CREATE INDEX idx_balance_snapshots_org_settlement ON balance_snapshots(org_id, settlement_month);
+-- Backfill for databases created before snapshot_date existed (snapshots were unique per settlement month):
+-- ALTER TABLE balance_snapshots ADD COLUMN IF NOT EXISTS snapshot_date DATE NOT NULL DEFAULT CURRENT_DATE;
+-- UPDATE balance_snapshots SET snapshot_date = snapshot_at::DATE;
+-- ALTER TABLE balance_snapshots DROP CONSTRAINT IF EXISTS balance_snapshots_user_id_org_id_settlement_month_key;
+-- ALTER TABLE balance_snapshots ADD CONSTRAINT balance_snapshots_user_id_org_id_snapshot_date_key UNIQUE (user_id, org_id, snapshot_date);
+-- DROP INDEX IF EXISTS idx_balance_snapshots_user_org;
+-- CREATE INDEX idx_balance_snapshots_user_org ON balance_snapshots(user_id, org_id, snapshot_date);
 
 -- Bills table: result of bill splitting calculation (who should pay whom how much)
 CREATE TABLE bills (
@@ -285,8 +514,8 @@ CREATE TABLE bills (
     amount_cents INTEGER NOT NULL CHECK (amount_cents > 0),
     settlement_month TEXT NOT NULL, -- Format: 'YYYY-MM' (e.g., '2026-01')
     
-    -- Bill status: PENDING -> PAID (or -> DISPUTED -> ADMIN_RESOLVED/SYSTEM_DEFAULT_ACTION)
-    status TEXT NOT NULL DEFAULT 'PENDING', -- PENDING, PAID, DISPUTED, ADMIN_RESOLVED, SYSTEM_DEFAULT_ACTION
+    -- Bill status: PENDING -> PAID (or -> DISPUTED -> ADMIN_RESOLVED/SYSTEM_DEFAULT_ACTION, or -> VOIDED by both parties)
+    status TEXT NOT NULL DEFAULT 'PENDING', -- PENDING, PAID, DISPUTED, ADMIN_RESOLVED, SYSTEM_DEFAULT_ACTION, VOIDED
     
     -- Timestamps for tracking state transitions (denormalized for quick queries)
     notice_sent_at TIMESTAMPTZ,
@@ -300,6 +529,13 @@ CREATE TABLE bills (
     resolution_outcome TEXT, -- GRACEFUL, DEBTOR_FAULT, CREDITOR_FAULT, BOTH_FAULT
     resolution_notes TEXT,
     
+    -- Void tracking: the first party to ask; the bill is voided when the other party agrees
+    void_requested_by INTEGER REFERENCES users(id),
+    void_requested_at TIMESTAMPTZ,
+    
+    -- Optimistic concurrency: incremented on every update, writes must match the read version
+    version INTEGER NOT NULL DEFAULT 1,
+    
     created_at TIMESTAMPTZ DEFAULT NOW(),
     updated_at TIMESTAMPTZ DEFAULT NOW(),
     
@@ -315,6 +551,23 @@ CREATE INDEX idx_bills_settlement ON bills(settlement_month);
 CREATE INDEX idx_bills_notice_sent ON bills(notice_sent_at) WHERE status = 'PENDING';
 CREATE INDEX idx_bills_disputed ON bills(disputed_at) WHERE status = 'DISPUTED';
 
+-- Backfill for databases created before bill voiding existed:
+-- ALTER TABLE bills ADD COLUMN IF NOT EXISTS void_requested_by INTEGER REFERENCES users(id);
+-- ALTER TABLE bills ADD COLUMN IF NOT EXISTS void_requested_at TIMESTAMPTZ;
+-- Backfill for databases created before bill versions existed:
+-- ALTER TABLE bills ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Settlement runs: one marker per org and month, so bill splitting never runs twice for a period
+CREATE TABLE settlement_runs (
+    org_id INTEGER NOT NULL REFERENCES orgs(id) ON DELETE CASCADE,
+    settlement_month TEXT NOT NULL, -- Format: 'YYYY-MM' (e.g., '2026-01')
+    bills_created INTEGER NOT NULL DEFAULT 0,
+    forced_at TIMESTAMPTZ, -- set when the run was repeated with the cronjob -force flag
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (org_id, settlement_month)
+);
+
 -- Add FK constraint in users_orgs now
 ALTER TABLE users_orgs 
     ADD CONSTRAINT fk_blocked_bill 
@@ -327,7 +580,8 @@ CREATE TABLE bill_actions (
     bill_id INTEGER NOT NULL REFERENCES bills(id) ON DELETE CASCADE,
     actor_user_id INTEGER REFERENCES users(id), -- NULL for system actions
     action_type TEXT NOT NULL, -- NOTICE_SENT, DEBTOR_ACKNOWLEDGED, CREDITOR_ACKNOWLEDGED, 
-                                -- DISPUTE_OPENED, ADMIN_COMMENT, ADMIN_RESOLUTION, SYSTEM_AUTO_RESOLVE
+                                -- DISPUTE_OPENED, ADMIN_COMMENT, ADMIN_RESOLUTION, SYSTEM_AUTO_RESOLVE,
+                                -- VOID_REQUESTED, VOID_CONFIRMED
     action_details JSONB, -- Flexible storage for action metadata
     notes TEXT,
     created_at TIMESTAMPTZ DEFAULT NOW()
@@ -338,7 +592,32 @@ CREATE INDEX idx_bill_actions_actor ON bill_actions(actor_user_id);
 CREATE INDEX idx_bill_actions_type ON bill_actions(action_type);
 CREATE INDEX idx_bill_actions_created ON bill_actions(created_at);
 
+-- Admin audit: one row per privileged mutation, across services
+CREATE TABLE admin_audit (
+    id SERIAL PRIMARY KEY,
+    org_id INTEGER NOT NULL REFERENCES orgs(id),
+    admin_id INTEGER NOT NULL REFERENCES users(id),
+    action TEXT NOT NULL, -- RESOLVE_DISPUTE, BLOCK_MEMBER, UNBLOCK_MEMBER, APPROVE_JOIN_REQUEST,
+                          -- REJECT_JOIN_REQUEST, SEND_INVITATION, RESEND_INVITATION, REVOKE_INVITATION,
+                          -- UPDATE_ORGANIZATION, SET_SETTLEMENT_THRESHOLD, RUN_SETTLEMENT, SET_ADJACENT_METROS,
+                          -- ADJUST_BALANCE
+    target_type TEXT NOT NULL, -- BILL, USER, JOIN_REQUEST, INVITATION, ORGANIZATION
+    target_id INTEGER NOT NULL,
+    details JSONB, -- Action-specific key/value pairs
+    before JSONB, -- Snapshot of the changed fields before the mutation (NULL when not captured)
+    after JSONB, -- Snapshot of the same fields after the mutation
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+-- Backfill for databases created before before/after snapshots existed:
+-- ALTER TABLE admin_audit ADD COLUMN IF NOT EXISTS before JSONB;
+-- ALTER TABLE admin_audit ADD COLUMN IF NOT EXISTS after JSONB;
+
+CREATE INDEX idx_admin_audit_org_created ON admin_audit(org_id, created_at);
+CREATE INDEX idx_admin_audit_admin ON admin_audit(admin_id);
+
 -- Function to automatically initiate disputes after 10 days
+-- Superseded by the CheckOverdueBills cronjob, which applies configurable grace periods.
+-- Like every write to bills it bumps version, so a request holding the old row cannot overwrite it.
 CREATE OR REPLACE FUNCTION check_overdue_bills() RETURNS void AS $$
 BEGIN
     -- Identify bills that are overdue (10+ days) and not yet disputed
@@ -349,6 +628,7 @@ BEGIN
             WHEN debtor_acknowledged_at IS NULL THEN 'DEBTOR_NO_ACK'
             WHEN creditor_acknowledged_at IS NULL THEN 'CREDITOR_NO_ACK'
         END,
+        version = version + 1,
         updated_at = NOW()
     WHERE status = 'PENDING' 
         AND notice_sent_at IS NOT NULL
@@ -368,7 +648,9 @@ BEGIN
 END;
 $$ LANGUAGE plpgsql;
 
--- Function to auto-resolve disputed bills at end of month (blocks both parties by default)
+-- Function to auto-resolve disputed bills at end of month (blocks both parties by default).
+-- Bumping version makes an admin resolution based on the DISPUTED row fail instead of overwriting
+-- this one. Databases created before this change pick it up by re-running the CREATE OR REPLACE below.
 CREATE OR REPLACE FUNCTION auto_resolve_disputed_bills(p_org_id INTEGER, p_settlement_month TEXT) RETURNS void AS $$
 DECLARE
     bill_record RECORD;
@@ -387,6 +669,7 @@ BEGIN
             resolution_outcome = 'BOTH_FAULT',
             resolution_notes = 'Auto-resolved by system at end of month - both parties blocked',
             resolved_at = NOW(),
+            version = version + 1,
             updated_at = NOW()
         WHERE id = bill_record.id;
         
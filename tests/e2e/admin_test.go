@@ -60,7 +60,7 @@ func TestAdminService_E2E(t *testing.T) {
 		var status string
 		err = db.QueryRow("SELECT status FROM join_requests WHERE email = $1 AND org_id = $2", "e2e-test-existing@test.com", orgID).Scan(&status)
 		assert.NoError(t, err)
-		assert.Equal(t, "INVITED", status)
+		assert.Equal(t, "JOINED", status)
 	})
 
 	t.Run("ApproveJoinRequest for New User (Send Invitation)", func(t *testing.T) {
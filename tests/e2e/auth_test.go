@@ -39,7 +39,7 @@ func TestAuthService_E2E(t *testing.T) {
 			InvitationCode: token,
 			Name:           "New User",
 			Email:          email,
-			Phone:          "555-1234",
+			Phone:          "408-555-0123",
 			Password:       "password123",
 		}
 
@@ -34,7 +34,7 @@ func TestOrganizationService_E2E(t *testing.T) {
 			Address:     "123 Test St",
 			Metro:       "San Jose",
 			AdminEmail:  "admin@e2etest.com",
-			AdminPhone:  "555-9999",
+			AdminPhone:  "408-555-0199",
 		}
 
 		resp, err := orgClient.CreateOrganization(ctx, req)
@@ -153,6 +153,18 @@ func assertLedgerCount(t *testing.T, db *TestDB, userID, orgID int32, txType str
 	assert.Equal(t, want, got)
 }
 
+// assertRentalHold verifies the amount still held for a rental (HOLD less HOLD_RELEASE entries).
+func assertRentalHold(t *testing.T, db *TestDB, rentalID int32, want int32) {
+	t.Helper()
+	var got int32
+	err := db.QueryRow(
+		"SELECT COALESCE(-SUM(amount), 0) FROM ledger_transactions WHERE related_rental_id = $1 AND type IN ('HOLD', 'HOLD_RELEASE')",
+		rentalID,
+	).Scan(&got)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
 // assertNotifiedAtLeastOnce verifies there is at least one notification for a user/org pair.
 func assertNotifiedAtLeastOnce(t *testing.T, db *TestDB, userID, orgID int32) {
 	t.Helper()
@@ -210,17 +222,19 @@ func assertDirectSettlementReminders(t *testing.T, db *TestDB, ownerID, renterID
 	assert.True(t, renterOK, "Renter debit notification must contain the direct-settlement reminder")
 }
 
-// assertExtensionDatesInDB checks that end_date and total_cost_cents stored in the DB
-// match the expected values after a ChangeRentalDates call.
-func assertExtensionDatesInDB(t *testing.T, db *TestDB, rentalID int32, expectedEndDate time.Time, expectedCostCents int32) {
+// assertExtensionDatesInDB checks that requested_end_date holds the renter's request after a
+// ChangeRentalDates call while end_date and total_cost_cents keep the agreed values.
+func assertExtensionDatesInDB(t *testing.T, db *TestDB, rentalID int32, expectedRequested, agreedEnd time.Time, agreedCostCents int32) {
 	t.Helper()
-	var endDate *time.Time
+	var requested *time.Time
+	var endDate time.Time
 	var cost int32
 	err := db.QueryRow(
-		"SELECT end_date, total_cost_cents FROM rentals WHERE id = $1", rentalID,
-	).Scan(&endDate, &cost)
+		"SELECT requested_end_date, end_date, total_cost_cents FROM rentals WHERE id = $1", rentalID,
+	).Scan(&requested, &endDate, &cost)
 	require.NoError(t, err)
-	require.NotNil(t, endDate, "end_date must not be null after an extension request")
-	assert.Equal(t, expectedEndDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-	assert.Equal(t, expectedCostCents, cost)
+	require.NotNil(t, requested, "requested_end_date must not be null after an extension request")
+	assert.Equal(t, expectedRequested.Format("2006-01-02"), requested.Format("2006-01-02"))
+	assert.Equal(t, agreedEnd.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	assert.Equal(t, agreedCostCents, cost)
 }
@@ -32,13 +32,17 @@ func TestRentalService_E2E(t *testing.T) {
 		assertNotifiedAtLeastOnce(t, db, env.renterID, env.orgID)
 
 		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
-		assertBalance(t, db, env.renterID, env.orgID, 5000) // balance unchanged before completion
+		assertBalance(t, db, env.renterID, env.orgID, 5000) // holds reserve funds without charging them
+		assertRentalHold(t, db, rentalID, 2000)
+		assertLedgerCount(t, db, env.renterID, env.orgID, "HOLD", 1)
 		assertLedgerCount(t, db, env.renterID, env.orgID, "LENDING_DEBIT", 0)
 		assertToolStatus(t, db, env.toolID, "RENTED")
 
 		doCompleteRental(t, rentalClient, env.ownerID, rentalID, true)
-		assertBalance(t, db, env.renterID, env.orgID, 3000) // 5000 - 2000 cents
+		assertBalance(t, db, env.renterID, env.orgID, 3000) // 5000 - 2000 cents, charged once
 		assertBalance(t, db, env.ownerID, env.orgID, 2000)  // 2 days * 1000 cents/day
+		assertRentalHold(t, db, rentalID, 0)
+		assertLedgerCount(t, db, env.renterID, env.orgID, "HOLD_RELEASE", 1)
 		assertLedgerCount(t, db, env.renterID, env.orgID, "LENDING_DEBIT", 1)
 		assertLedgerCount(t, db, env.ownerID, env.orgID, "LENDING_CREDIT", 1)
 		assertToolStatus(t, db, env.toolID, "AVAILABLE")
@@ -72,28 +76,33 @@ func TestRentalService_E2E(t *testing.T) {
 		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
 		doActivateRental(t, rentalClient, env.ownerID, rentalID)
 
-		// First extension: extend to 2-day duration (start -> start+2d = 2000 cents).
+		// First extension request: 2-day duration. Until approval the rental stays priced at 1 day.
 		ext1 := start.Add(48 * time.Hour)
 		doChangeRentalDates(t, rentalClient, env.renterID, rentalID, ext1)
-		assertExtensionDatesInDB(t, db, rentalID, ext1, 2000)
+		assertExtensionDatesInDB(t, db, rentalID, ext1, end, 1000)
 		ext1Str := ext1.Format("2006-01-02")
 
-		// Second extension: overwrite to 3-day duration (start -> start+3d = 3000 cents).
+		// Second extension request overwrites the first: 3-day duration.
 		ext2 := start.Add(72 * time.Hour)
 		doChangeRentalDates(t, rentalClient, env.renterID, rentalID, ext2)
-		assertExtensionDatesInDB(t, db, rentalID, ext2, 3000)
-		assert.NotEqual(t, ext1Str, ext2.Format("2006-01-02"), "end_date must update between extension requests")
+		assertExtensionDatesInDB(t, db, rentalID, ext2, end, 1000)
+		assert.NotEqual(t, ext1Str, ext2.Format("2006-01-02"), "requested_end_date must update between extension requests")
 		assertNotifiedAtLeastOnce(t, db, env.ownerID, env.orgID)
 
 		// Owner approves the final extension; last_agreed_end_date must be set.
 		doApproveReturnDateChange(t, rentalClient, env.ownerID, rentalID)
-		var lastAgreed *time.Time
+		var lastAgreed, requested *time.Time
 		var finalEnd time.Time
-		err := db.QueryRow("SELECT last_agreed_end_date, end_date FROM rentals WHERE id = $1", rentalID).Scan(&lastAgreed, &finalEnd)
+		var finalCost int32
+		err := db.QueryRow("SELECT last_agreed_end_date, end_date, requested_end_date, total_cost_cents FROM rentals WHERE id = $1", rentalID).
+			Scan(&lastAgreed, &finalEnd, &requested, &finalCost)
 		require.NoError(t, err)
 		require.NotNil(t, lastAgreed)
 		assert.Equal(t, ext2.Format("2006-01-02"), finalEnd.Format("2006-01-02"))
 		assert.Equal(t, ext2.Format("2006-01-02"), lastAgreed.Format("2006-01-02"))
+		assert.Nil(t, requested, "approval must clear the request")
+		assert.Equal(t, int32(3000), finalCost) // start -> start+3d
+
 	})
 
 	t.Run("Cancel Rental Request", func(t *testing.T) {
@@ -114,6 +123,121 @@ func TestRentalService_E2E(t *testing.T) {
 		assertNotifiedAtLeastOnce(t, db, env.ownerID, env.orgID)
 	})
 
+	t.Run("Cancel After Finalize Refunds Hold And Frees Tool", func(t *testing.T) {
+		env := setupRentalTestEnv(t, db, "cancelhold", 5000)
+		start := time.Now().Add(24 * time.Hour)
+		end := start.Add(48 * time.Hour)
+
+		rentalID := doCreateRentalRequest(t, rentalClient, env, start, end)
+		doApproveRentalRequest(t, rentalClient, env.ownerID, rentalID, "Pick up at my garage")
+		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
+		assertRentalHold(t, db, rentalID, 2000)
+
+		ctx, cancel := ContextWithUserIDAndTimeout(env.renterID, 5*time.Second)
+		defer cancel()
+		_, err := rentalClient.CancelRental(ctx, &pb.CancelRentalRequest{
+			RequestId: rentalID,
+			Reason:    "Plans changed",
+		})
+		require.NoError(t, err)
+		assertRentalHold(t, db, rentalID, 0)
+		assertBalance(t, db, env.renterID, env.orgID, 5000)
+		assertLedgerCount(t, db, env.renterID, env.orgID, "HOLD_RELEASE", 1)
+		assertToolStatus(t, db, env.toolID, "AVAILABLE")
+	})
+
+	t.Run("Owner Cancels Scheduled Rental", func(t *testing.T) {
+		env := setupRentalTestEnv(t, db, "ownercancel", 5000)
+		start := time.Now().Add(24 * time.Hour)
+		end := start.Add(48 * time.Hour)
+
+		rentalID := doCreateRentalRequest(t, rentalClient, env, start, end)
+		doApproveRentalRequest(t, rentalClient, env.ownerID, rentalID, "Pick up at my garage")
+		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
+
+		ctx, cancel := ContextWithUserIDAndTimeout(env.ownerID, 5*time.Second)
+		defer cancel()
+		resp, err := rentalClient.CancelRental(ctx, &pb.CancelRentalRequest{
+			RequestId: rentalID,
+			Reason:    "Tool broke",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, pb.RentalStatus_RENTAL_STATUS_CANCELLED, resp.RentalRequest.Status)
+		assertRentalHold(t, db, rentalID, 0)
+		assertToolStatus(t, db, env.toolID, "AVAILABLE")
+		assertNotifiedAtLeastOnce(t, db, env.renterID, env.orgID)
+	})
+
+	t.Run("Cancel Active Rental Is Rejected", func(t *testing.T) {
+		env := setupRentalTestEnv(t, db, "cancelactive", 5000)
+		start := time.Now().Add(24 * time.Hour)
+		end := start.Add(24 * time.Hour)
+
+		rentalID := doCreateRentalRequest(t, rentalClient, env, start, end)
+		doApproveRentalRequest(t, rentalClient, env.ownerID, rentalID, "Pick up at my garage")
+		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
+		doActivateRental(t, rentalClient, env.ownerID, rentalID)
+
+		ctx, cancel := ContextWithUserIDAndTimeout(env.renterID, 5*time.Second)
+		defer cancel()
+		_, err := rentalClient.CancelRental(ctx, &pb.CancelRentalRequest{
+			RequestId: rentalID,
+			Reason:    "Too late",
+		})
+		assert.Error(t, err)
+		assertRentalHold(t, db, rentalID, 1000)
+		assertToolStatus(t, db, env.toolID, "RENTED")
+	})
+
+	t.Run("CreateRentalRequest Rejected When Renter Is Renting Blocked", func(t *testing.T) {
+		env := setupRentalTestEnv(t, db, "rentblock", 5000)
+		db.Exec("UPDATE users_orgs SET renting_blocked = true, blocked_reason = $1 WHERE user_id = $2 AND org_id = $3",
+			"Blocked due to unresolved payment dispute (debtor at fault)", env.renterID, env.orgID)
+		start := time.Now().Add(24 * time.Hour)
+
+		ctx, cancel := ContextWithUserIDAndTimeout(env.renterID, 5*time.Second)
+		defer cancel()
+		_, err := rentalClient.CreateRentalRequest(ctx, &pb.CreateRentalRequestRequest{
+			ToolId:         env.toolID,
+			StartDate:      start.Format("2006-01-02"),
+			EndDate:        start.Add(24 * time.Hour).Format("2006-01-02"),
+			OrganizationId: env.orgID,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "renting privileges are blocked")
+		assert.Contains(t, err.Error(), "debtor at fault")
+	})
+
+	t.Run("ApproveRentalRequest Rejected When Owner Is Lending Blocked", func(t *testing.T) {
+		env := setupRentalTestEnv(t, db, "lendblock", 5000)
+		start := time.Now().Add(24 * time.Hour)
+		rentalID := doCreateRentalRequest(t, rentalClient, env, start, start.Add(24*time.Hour))
+
+		db.Exec("UPDATE users_orgs SET lending_blocked = true, blocked_reason = $1 WHERE user_id = $2 AND org_id = $3",
+			"Blocked due to dispute resolution (creditor at fault)", env.ownerID, env.orgID)
+
+		ctx, cancel := ContextWithUserIDAndTimeout(env.ownerID, 5*time.Second)
+		defer cancel()
+		_, err := rentalClient.ApproveRentalRequest(ctx, &pb.ApproveRentalRequestRequest{
+			RequestId:          rentalID,
+			PickupInstructions: "Front porch",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "lending privileges are blocked")
+
+		// A lending-blocked owner's tools cannot be requested either
+		ctx2, cancel2 := ContextWithUserIDAndTimeout(env.renterID, 5*time.Second)
+		defer cancel2()
+		_, err = rentalClient.CreateRentalRequest(ctx2, &pb.CreateRentalRequestRequest{
+			ToolId:         env.toolID,
+			StartDate:      start.Add(72 * time.Hour).Format("2006-01-02"),
+			EndDate:        start.Add(96 * time.Hour).Format("2006-01-02"),
+			OrganizationId: env.orgID,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "creditor at fault")
+	})
+
 	// Balance check is disabled for now
 	// t.Run("CreateRentalRequest with Insufficient Balance", func(t *testing.T) { ... })
 
@@ -126,6 +250,7 @@ func TestRentalService_E2E(t *testing.T) {
 		doApproveRentalRequest(t, rentalClient, env.ownerID, rentalID, "Leave at front door")
 		doFinalizeRentalRequest(t, rentalClient, env.renterID, rentalID)
 		assertBalance(t, db, env.renterID, env.orgID, 5000) // unchanged before completion
+		assertRentalHold(t, db, rentalID, 2000)
 
 		rt := doCompleteRental(t, rentalClient, env.ownerID, rentalID, false)
 		assert.False(t, rt.ChargeBillsplit, "charge_billsplit must be persisted as false on the rental record")
@@ -135,12 +260,13 @@ func TestRentalService_E2E(t *testing.T) {
 		require.NoError(t, err)
 		assert.False(t, chargeBillsplitInDB)
 
-		// No balance changes and no ledger transactions when charge_billsplit=false.
+		// No balance changes and no charges when charge_billsplit=false; only the hold is released.
 		assertBalance(t, db, env.renterID, env.orgID, 5000)
 		assertBalance(t, db, env.ownerID, env.orgID, 0)
+		assertRentalHold(t, db, rentalID, 0)
 		var txCount int
 		err = db.QueryRow(
-			"SELECT COUNT(*) FROM ledger_transactions WHERE org_id = $1 AND (user_id = $2 OR user_id = $3)",
+			"SELECT COUNT(*) FROM ledger_transactions WHERE org_id = $1 AND (user_id = $2 OR user_id = $3) AND type NOT IN ('HOLD', 'HOLD_RELEASE')",
 			env.orgID, env.ownerID, env.renterID,
 		).Scan(&txCount)
 		require.NoError(t, err)
@@ -73,7 +73,7 @@ func TestUserService_E2E(t *testing.T) {
 		req := &pb.UpdateProfileRequest{
 			Name:      "Updated Name",
 			Email:     "e2e-test-updated@test.com",
-			Phone:     "555-9999",
+			Phone:     "(408) 555-0199",
 			AvatarUrl: "https://example.com/avatar.jpg",
 		}
 
@@ -82,7 +82,8 @@ func TestUserService_E2E(t *testing.T) {
 
 		assert.Equal(t, "Updated Name", resp.User.Name)
 		assert.Equal(t, "e2e-test-updated@test.com", resp.User.Email)
-		assert.Equal(t, "555-9999", resp.User.Phone)
+		assert.Equal(t, "+14085550199", resp.User.Phone)
+		assert.Equal(t, "+1 (408) 555-0199", resp.User.PhoneDisplay)
 		assert.Equal(t, "https://example.com/avatar.jpg", resp.User.AvatarUrl)
 
 		// Verify: Database was updated
@@ -91,7 +92,7 @@ func TestUserService_E2E(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "Updated Name", name)
 		assert.Equal(t, "e2e-test-updated@test.com", email)
-		assert.Equal(t, "555-9999", phone)
+		assert.Equal(t, "+14085550199", phone)
 		assert.Equal(t, "https://example.com/avatar.jpg", avatarURL)
 	})
 
@@ -124,7 +125,7 @@ func TestUserService_E2E(t *testing.T) {
 		req := &pb.UpdateProfileRequest{
 			Name:  "User 2",
 			Email: "e2e-test-user1-unique@test.com", // Duplicate email
-			Phone: "555-0001",
+			Phone: "408-555-0101",
 		}
 
 		_, err := userClient.UpdateProfile(ctx, req)
@@ -92,7 +92,9 @@ func TestGmailIntegration(t *testing.T) {
 		renterName := "Test Renter"
 		toolName := "Power Drill"
 
-		err := emailService.SendRentalRequestNotification(context.Background(), testEmailTo, renterName, toolName, testEmailCC)
+		err := emailService.SendRentalRequestNotification(context.Background(), testEmailTo, service.RentalRequestEmail{
+			OwnerName: "Owner Name", RenterName: renterName, ToolName: toolName,
+		}, testEmailCC)
 		require.NoError(t, err, "Failed to send rental request notification via Gmail")
 
 		t.Logf("✅ Successfully sent rental request notification to %s via Gmail", testEmailTo)
@@ -107,7 +109,9 @@ func TestGmailIntegration(t *testing.T) {
 		toolName := "Power Drill"
 		pickupNote := "Please pick up the tool from my garage at 123 Main St. Available after 5 PM."
 
-		err := emailService.SendRentalApprovalNotification(context.Background(), testEmailTo, toolName, "Owner Name", pickupNote, testEmailCC)
+		err := emailService.SendRentalApprovalNotification(context.Background(), testEmailTo, service.RentalApprovalEmail{
+			RenterName: "Renter Name", OwnerName: "Owner Name", ToolName: toolName, PickupNote: pickupNote,
+		}, testEmailCC)
 		require.NoError(t, err, "Failed to send rental approval notification via Gmail")
 
 		t.Logf("✅ Successfully sent rental approval notification to %s via Gmail", testEmailTo)
@@ -26,7 +26,7 @@ func TestAdminService_ListJoinRequests_UsedOnField(t *testing.T) {
 	inviteRepo := postgres.NewInvitationRepository(db)
 
 	// Create admin service (emailSvc can be nil for this test)
-	adminSvc := service.NewAdminService(joinReqRepo, userRepo, ledgerRepo, orgRepo, inviteRepo, nil)
+	adminSvc := service.NewAdminService(joinReqRepo, userRepo, ledgerRepo, orgRepo, inviteRepo, nil, nil, nil)
 
 	ctx := context.Background()
 
@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestUserRepository_AdjustBalanceConcurrent fires many concurrent balance adjustments at one
+// membership and verifies none of them is lost.
+func TestUserRepository_AdjustBalanceConcurrent(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("AdjustBalanceOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+	user := &domain.User{
+		Email:        fmt.Sprintf("adj-%d@t.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("adj-%d", time.Now().UnixNano()),
+		PasswordHash: "h", Name: "Adjusted",
+	}
+	assert.NoError(t, userRepo.Create(ctx, user))
+	assert.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+		UserID: user.ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+		BalanceCents: 100,
+	}))
+
+	const workers = 50
+	var wg sync.WaitGroup
+	var expected int32 = 100
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		delta := int32(i*10 + 1)
+		if i%3 == 0 {
+			delta = -delta
+		}
+		expected += delta
+		wg.Add(1)
+		go func(delta int32) {
+			defer wg.Done()
+			if _, err := userRepo.AdjustBalance(ctx, user.ID, org.ID, delta); err != nil {
+				errs <- err
+			}
+		}(delta)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	uo, err := userRepo.GetUserOrg(ctx, user.ID, org.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, uo.BalanceCents)
+	assert.NotNil(t, uo.LastBalanceUpdateOn)
+
+	// Saving membership flags must not overwrite the balance with a stale value
+	uo.BalanceCents = 0
+	uo.RentingBlocked = true
+	assert.NoError(t, userRepo.UpdateUserOrg(ctx, uo))
+	balance, err := userRepo.AdjustBalance(ctx, user.ID, org.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, balance)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository"
 	"ubertool-backend-trusted/internal/repository/postgres"
 	"ubertool-backend-trusted/internal/service"
 
@@ -27,6 +28,7 @@ func TestBillSplitService_Integration(t *testing.T) {
 	userRepo := postgres.NewUserRepository(db)
 	orgRepo := postgres.NewOrganizationRepository(db)
 	billRepo := postgres.NewBillRepository(db)
+	ledgerRepo := postgres.NewLedgerRepository(db)
 	notifRepo := &MockNotificationRepo{} // Using the mock from rental_ledger_test.go if package-level
 
 	// Check if we need to redefine mocks or if they are shared.
@@ -34,7 +36,7 @@ func TestBillSplitService_Integration(t *testing.T) {
 	emailSvc := &MockEmailService{}
 
 	// Initialize Service
-	billSvc := service.NewBillSplitService(billRepo, userRepo, orgRepo, notifRepo, emailSvc)
+	billSvc := service.NewBillSplitService(billRepo, userRepo, ledgerRepo, orgRepo, notifRepo, emailSvc, nil)
 	ctx := context.Background()
 
 	// 1. Setup Data: Org, Creditor, Debtor
@@ -134,6 +136,13 @@ func TestBillSplitService_Integration(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, domain.BillStatusPaid, updatedBill.Status)
 		assert.NotNil(t, updatedBill.CreditorAcknowledgedAt)
+
+		// The settlement went through the ledger, so it still sums to the stored balances
+		for _, userID := range []int32{debtor.ID, creditor.ID} {
+			summary, err := ledgerRepo.GetSummary(ctx, userID, org.ID)
+			assert.NoError(t, err)
+			assert.False(t, summary.BalanceMismatch)
+		}
 	})
 
 	t.Run("Dispute Lifecycle", func(t *testing.T) {
@@ -183,3 +192,169 @@ func TestBillSplitService_Integration(t *testing.T) {
 		assert.Equal(t, string(domain.ResolutionOutcomeDebtorFault), updatedBill.ResolutionOutcome)
 	})
 }
+
+// TestBillRepository_ListByUser_Pagination seeds five bills and verifies that paging
+// returns disjoint pages in a stable order along with the total count.
+func TestBillRepository_ListByUser_Pagination(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	billRepo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("BillPageOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+
+	debtor := &domain.User{
+		Email:        fmt.Sprintf("pgdebtor-%d@t.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("pd-%d", time.Now().UnixNano()),
+		PasswordHash: "h", Name: "Debtor",
+	}
+	assert.NoError(t, userRepo.Create(ctx, debtor))
+	creditor := &domain.User{
+		Email:        fmt.Sprintf("pgcreditor-%d@t.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("pc-%d", time.Now().UnixNano()),
+		PasswordHash: "h", Name: "Creditor",
+	}
+	assert.NoError(t, userRepo.Create(ctx, creditor))
+
+	for i := 1; i <= 5; i++ {
+		assert.NoError(t, billRepo.Create(ctx, &domain.Bill{
+			OrgID: org.ID, DebtorUserID: debtor.ID, CreditorUserID: creditor.ID,
+			AmountCents: int32(i * 100), SettlementMonth: fmt.Sprintf("2026-0%d", i), Status: domain.BillStatusPending,
+		}))
+	}
+
+	seen := map[int32]bool{}
+	for page, want := range []int{2, 2, 1} {
+		bills, total, err := billRepo.ListByUser(ctx, debtor.ID, org.ID, nil, int32(page+1), 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		assert.Len(t, bills, want)
+		for _, b := range bills {
+			assert.False(t, seen[b.ID], "bill %d returned on more than one page", b.ID)
+			seen[b.ID] = true
+		}
+	}
+	assert.Len(t, seen, 5)
+
+	bills, total, err := billRepo.ListByUser(ctx, debtor.ID, org.ID, nil, 4, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), total)
+	assert.Empty(t, bills)
+
+	all, total, err := billRepo.ListByUser(ctx, creditor.ID, org.ID, []domain.BillStatus{domain.BillStatusPending}, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), total)
+	assert.Len(t, all, 5)
+}
+
+// TestBillRepository_ListActionsByBill_Pagination seeds a long dispute history and pages
+// through it oldest first, with and without an action type filter.
+func TestBillRepository_ListActionsByBill_Pagination(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	billRepo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("BillActionsOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+	debtor := &domain.User{Email: fmt.Sprintf("adebtor-%d@t.com", time.Now().UnixNano()), PhoneNumber: fmt.Sprintf("ad-%d", time.Now().UnixNano()), PasswordHash: "h", Name: "Debtor"}
+	assert.NoError(t, userRepo.Create(ctx, debtor))
+	creditor := &domain.User{Email: fmt.Sprintf("acreditor-%d@t.com", time.Now().UnixNano()), PhoneNumber: fmt.Sprintf("ac-%d", time.Now().UnixNano()), PasswordHash: "h", Name: "Creditor"}
+	assert.NoError(t, userRepo.Create(ctx, creditor))
+
+	bill := &domain.Bill{
+		OrgID: org.ID, DebtorUserID: debtor.ID, CreditorUserID: creditor.ID,
+		AmountCents: 1200, SettlementMonth: "2026-01", Status: domain.BillStatusDisputed,
+	}
+	assert.NoError(t, billRepo.Create(ctx, bill))
+
+	types := []domain.BillActionType{
+		domain.BillActionTypeNoticeSent,
+		domain.BillActionTypeDisputeOpened,
+		domain.BillActionTypeAdminComment,
+		domain.BillActionTypeAdminComment,
+		domain.BillActionTypeAdminComment,
+	}
+	var seeded []int32
+	for _, actionType := range types {
+		action := &domain.BillAction{BillID: bill.ID, ActorUserID: &creditor.ID, ActionType: actionType}
+		assert.NoError(t, billRepo.CreateAction(ctx, action))
+		seeded = append(seeded, action.ID)
+	}
+
+	var paged []int32
+	for page, want := range []int{2, 2, 1} {
+		actions, total, err := billRepo.ListActionsByBill(ctx, bill.ID, domain.BillActionFilter{Page: int32(page + 1), PageSize: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		assert.Len(t, actions, want)
+		for _, a := range actions {
+			paged = append(paged, a.ID)
+		}
+	}
+	assert.Equal(t, seeded, paged, "pages are disjoint and in insertion order")
+
+	comments, total, err := billRepo.ListActionsByBill(ctx, bill.ID, domain.BillActionFilter{
+		ActionTypes: []domain.BillActionType{domain.BillActionTypeAdminComment}, Page: 2, PageSize: 2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), total)
+	if assert.Len(t, comments, 1) {
+		assert.Equal(t, seeded[4], comments[0].ID)
+	}
+}
+
+// TestBillRepository_Update_StaleVersion simulates an admin resolution and a debtor
+// acknowledgement that both read the same bill; the second write must be rejected.
+func TestBillRepository_Update_StaleVersion(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	billRepo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("BillVersionOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+	debtor := &domain.User{Email: fmt.Sprintf("vdebtor-%d@t.com", time.Now().UnixNano()), PhoneNumber: fmt.Sprintf("vd-%d", time.Now().UnixNano()), PasswordHash: "h", Name: "Debtor"}
+	assert.NoError(t, userRepo.Create(ctx, debtor))
+	creditor := &domain.User{Email: fmt.Sprintf("vcreditor-%d@t.com", time.Now().UnixNano()), PhoneNumber: fmt.Sprintf("vc-%d", time.Now().UnixNano()), PasswordHash: "h", Name: "Creditor"}
+	assert.NoError(t, userRepo.Create(ctx, creditor))
+
+	bill := &domain.Bill{
+		OrgID: org.ID, DebtorUserID: debtor.ID, CreditorUserID: creditor.ID,
+		AmountCents: 1200, SettlementMonth: "2026-01", Status: domain.BillStatusDisputed,
+	}
+	assert.NoError(t, billRepo.Create(ctx, bill))
+	assert.Equal(t, int32(1), bill.Version)
+
+	adminCopy, err := billRepo.GetByID(ctx, bill.ID)
+	assert.NoError(t, err)
+	debtorCopy, err := billRepo.GetByID(ctx, bill.ID)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	adminCopy.Status = domain.BillStatusAdminResolved
+	adminCopy.ResolvedAt = &now
+	adminCopy.ResolutionOutcome = string(domain.ResolutionOutcomeDebtorFault)
+	assert.NoError(t, billRepo.Update(ctx, adminCopy))
+	assert.Equal(t, int32(2), adminCopy.Version)
+
+	debtorCopy.DebtorAcknowledgedAt = &now
+	err = billRepo.Update(ctx, debtorCopy)
+	assert.ErrorIs(t, err, repository.ErrBillVersionConflict)
+
+	stored, err := billRepo.GetByID(ctx, bill.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BillStatusAdminResolved, stored.Status)
+	assert.Nil(t, stored.DebtorAcknowledgedAt)
+	assert.Equal(t, int32(2), stored.Version)
+}
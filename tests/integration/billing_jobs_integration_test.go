@@ -22,13 +22,21 @@ func TestPerformBillSplittingForOrg(t *testing.T) {
 
 	// Setup JobRunner
 	// We only need DB and Config for this function
-	jr := jobs.NewJobRunner(db, &postgres.Store{}, nil, &config.Config{})
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
 
 	// Test Data
 	orgID := int32(101)
 	orgName := "Test Org"
 	settlementMonth := "2026-02"
 
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO settlement_runs`).
+		WithArgs(orgID, settlementMonth).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM bills WHERE org_id = \$1 AND settlement_month = \$2\)`).
+		WithArgs(orgID, settlementMonth).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
 	// Mock SELECT query for user balances
 	// Scenario: A owes B $10.00 (1000 cents). Threshold is $5.00.
 	// Users: A (-1000), B (+1000)
@@ -43,7 +51,8 @@ func TestPerformBillSplittingForOrg(t *testing.T) {
 	// Mock INSERT statements for bills
 	// Algorithm: A pays B 1000.
 	// Expect 1 bill.
-	mock.ExpectExec(`INSERT INTO bills`).
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO bills`).
 		WithArgs(
 			orgID,
 			1,    // Debtor (User 1)
@@ -51,13 +60,18 @@ func TestPerformBillSplittingForOrg(t *testing.T) {
 			1000, // Amount
 			settlementMonth,
 		).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(1, 1, now, now))
+
+	mock.ExpectExec(`UPDATE settlement_runs`).
+		WithArgs(orgID, settlementMonth, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	// Call function
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	count, err := jr.PerformBillSplittingForOrg(ctx, orgID, orgName, settlementMonth, 500)
+	count, err := jr.PerformBillSplittingForOrg(ctx, orgID, orgName, settlementMonth, 500, false)
 
 	// Assertions
 	assert.NoError(t, err)
@@ -77,7 +91,7 @@ func TestPerformBillSplittingForOrg_BelowThreshold(t *testing.T) {
 	}
 	defer db.Close()
 
-	jr := jobs.NewJobRunner(db, &postgres.Store{}, nil, &config.Config{})
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
 
 	orgID := int32(102)
 	orgName := "Small Org"
@@ -88,13 +102,24 @@ func TestPerformBillSplittingForOrg_BelowThreshold(t *testing.T) {
 		AddRow(1, -400).
 		AddRow(2, 400)
 
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO settlement_runs`).
+		WithArgs(orgID, settlementMonth).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(orgID, settlementMonth).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 	mock.ExpectQuery(`SELECT user_id, balance_cents FROM users_orgs WHERE org_id = \$1`).
 		WithArgs(orgID).
 		WillReturnRows(rows)
 
 	// Expect NO insert statements because balances are below threshold
-	
-	count, err := jr.PerformBillSplittingForOrg(context.Background(), orgID, orgName, settlementMonth, 500)
+	mock.ExpectExec(`UPDATE settlement_runs`).
+		WithArgs(orgID, settlementMonth, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := jr.PerformBillSplittingForOrg(context.Background(), orgID, orgName, settlementMonth, 500, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 0, count)
@@ -103,3 +128,42 @@ func TestPerformBillSplittingForOrg_BelowThreshold(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestPerformBillSplittingForOrg_ForcedRerunSkipsExistingBill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
+
+	orgID := int32(103)
+	settlementMonth := "2026-02"
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO settlement_runs .* DO UPDATE SET forced_at = NOW\(\)`).
+		WithArgs(orgID, settlementMonth).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT user_id, balance_cents FROM users_orgs WHERE org_id = \$1`).
+		WithArgs(orgID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance_cents"}).AddRow(1, -1000).AddRow(2, 1000))
+
+	// The first run already created this bill, so ON CONFLICT DO NOTHING returns no row
+	mock.ExpectQuery(`INSERT INTO bills`).
+		WithArgs(orgID, 1, 2, 1000, settlementMonth).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}))
+
+	mock.ExpectExec(`UPDATE settlement_runs`).
+		WithArgs(orgID, settlementMonth, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	count, err := jr.PerformBillSplittingForOrg(context.Background(), orgID, "Rerun Org", settlementMonth, 500, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
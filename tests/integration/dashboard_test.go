@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRentalRepository_CountActivity seeds rentals in every status on both sides of a user
+// and checks the dashboard counts against the rows returned by the listing queries.
+func TestRentalRepository_CountActivity(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	toolRepo := postgres.NewToolRepository(db)
+	rentalRepo := postgres.NewRentalRepository(db)
+	noteRepo := postgres.NewNotificationRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("DashboardOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	require.NoError(t, orgRepo.Create(ctx, org))
+
+	newMember := func(name string) *domain.User {
+		u := &domain.User{
+			Email:        fmt.Sprintf("%s-%d@t.com", name, time.Now().UnixNano()),
+			PhoneNumber:  fmt.Sprintf("%s-%d", name, time.Now().UnixNano()),
+			PasswordHash: "h", Name: name,
+		}
+		require.NoError(t, userRepo.Create(ctx, u))
+		require.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+			UserID: u.ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+		}))
+		return u
+	}
+	me := newMember("me")
+	other := newMember("other")
+
+	newTool := func(owner *domain.User) *domain.Tool {
+		tool := &domain.Tool{
+			OwnerID: owner.ID, Name: "Drill", PricePerDayCents: 1000, DurationUnit: domain.ToolDurationUnitDay,
+			Metro: "San Jose", Status: domain.ToolStatusAvailable,
+		}
+		require.NoError(t, toolRepo.Create(ctx, tool))
+		return tool
+	}
+	myTool, theirTool := newTool(me), newTool(other)
+
+	seed := func(tool *domain.Tool, renter *domain.User, status domain.RentalStatus) {
+		rt := &domain.Rental{
+			OrgID: org.ID, ToolID: tool.ID, RenterID: renter.ID, OwnerID: tool.OwnerID,
+			StartDate: "2026-03-01", EndDate: "2026-03-05", TotalCostCents: 4000, Status: status,
+		}
+		require.NoError(t, rentalRepo.Create(ctx, rt))
+		_, err := db.Exec("UPDATE rentals SET status = $1 WHERE id = $2", string(status), rt.ID)
+		require.NoError(t, err)
+	}
+
+	// As renter: two in progress (one overdue to bring back), one approval to finalize, one
+	// rejected extension to acknowledge
+	seed(theirTool, me, domain.RentalStatusActive)
+	seed(theirTool, me, domain.RentalStatusOverdue)
+	seed(theirTool, me, domain.RentalStatusApproved)
+	seed(theirTool, me, domain.RentalStatusReturnDateChangeRejected)
+	seed(theirTool, me, domain.RentalStatusCompleted)
+	seed(theirTool, me, domain.RentalStatusPending) // waiting on the owner, not me
+	// As owner: one in progress with an extension to answer, one request to answer
+	seed(myTool, other, domain.RentalStatusReturnDateChanged)
+	seed(myTool, other, domain.RentalStatusPending)
+	seed(myTool, other, domain.RentalStatusScheduled)
+	seed(myTool, other, domain.RentalStatusCancelled)
+
+	counts, err := rentalRepo.CountActivity(ctx, me.ID)
+	require.NoError(t, err)
+
+	inProgress := make([]string, len(domain.InProgressRentalStatuses))
+	for i, st := range domain.InProgressRentalStatuses {
+		inProgress[i] = string(st)
+	}
+	_, renting, err := rentalRepo.ListByRenter(ctx, me.ID, org.ID, inProgress, 1, 100)
+	require.NoError(t, err)
+	_, lending, err := rentalRepo.ListByOwner(ctx, me.ID, org.ID, inProgress, 1, 100)
+	require.NoError(t, err)
+	_, toAnswer, err := rentalRepo.ListByOwner(ctx, me.ID, org.ID, []string{"PENDING", "RETURN_DATE_CHANGED"}, 1, 100)
+	require.NoError(t, err)
+	_, toFollowUp, err := rentalRepo.ListByRenter(ctx, me.ID, org.ID, []string{"APPROVED", "RETURN_DATE_CHANGE_REJECTED"}, 1, 100)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), counts.ActiveRentals)
+	assert.Equal(t, renting, counts.ActiveRentals)
+	assert.Equal(t, int32(1), counts.ActiveLendings)
+	assert.Equal(t, lending, counts.ActiveLendings)
+	assert.Equal(t, int32(4), counts.PendingActions)
+	assert.Equal(t, toAnswer+toFollowUp, counts.PendingActions)
+
+	// The actionable listing returns the rentals the count covers, plus the overdue one
+	actionable, err := rentalRepo.ListActionable(ctx, me.ID, org.ID)
+	require.NoError(t, err)
+	assert.Len(t, actionable, int(counts.PendingActions)+1)
+	for _, rt := range actionable {
+		assert.True(t, rt.AwaitsActionFrom(me.ID), "rental %d in %s", rt.ID, rt.Status)
+	}
+
+	// The unread count the dashboard reports comes straight from the notifications table
+	for i := 0; i < 3; i++ {
+		require.NoError(t, noteRepo.Create(ctx, &domain.Notification{UserID: me.ID, OrgID: org.ID, Title: "t", Message: "m"}))
+	}
+	unread, err := noteRepo.CountUnread(ctx, me.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), unread)
+
+	// A user with no rentals reports zeroes
+	empty, err := rentalRepo.CountActivity(ctx, newMember("nobody").ID)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.RentalActivityCounts{}, empty)
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBillRepository_GetDisputeStatistics seeds disputed and resolved bills and verifies
+// the aggregate counts, average resolution time and penalized total.
+func TestBillRepository_GetDisputeStatistics(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	billRepo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("DisputeStatsOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+
+	users := make([]*domain.User, 4)
+	for i := range users {
+		users[i] = &domain.User{
+			Email:        fmt.Sprintf("ds%d-%d@t.com", i, time.Now().UnixNano()),
+			PhoneNumber:  fmt.Sprintf("ds%d-%d", i, time.Now().UnixNano()),
+			PasswordHash: "h", Name: fmt.Sprintf("User %d", i),
+		}
+		assert.NoError(t, userRepo.Create(ctx, users[i]))
+		assert.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+			UserID: users[i].ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+		}))
+	}
+
+	disputedAt := time.Now().Add(-4 * time.Hour)
+	resolvedAt := disputedAt.Add(2 * time.Hour)
+
+	seed := func(debtor, creditor *domain.User, month string, amount int32, status domain.BillStatus, outcome string, resolved bool) {
+		bill := &domain.Bill{
+			OrgID: org.ID, DebtorUserID: debtor.ID, CreditorUserID: creditor.ID,
+			AmountCents: amount, SettlementMonth: month, Status: domain.BillStatusPending,
+		}
+		assert.NoError(t, billRepo.Create(ctx, bill))
+		bill.Status = status
+		bill.DisputedAt = &disputedAt
+		bill.DisputeReason = string(domain.DisputeReasonDebtorNoAck)
+		bill.ResolutionOutcome = outcome
+		if resolved {
+			bill.ResolvedAt = &resolvedAt
+		}
+		assert.NoError(t, billRepo.Update(ctx, bill))
+	}
+
+	seed(users[0], users[1], "2026-01", 1000, domain.BillStatusDisputed, "", false)
+	seed(users[0], users[2], "2026-01", 2000, domain.BillStatusAdminResolved, string(domain.ResolutionOutcomeCreditorFault), true)
+	seed(users[1], users[3], "2026-02", 1500, domain.BillStatusAdminResolved, string(domain.ResolutionOutcomeBothFault), true)
+	seed(users[2], users[3], "2026-02", 700, domain.BillStatusSystemDefaultAction, string(domain.ResolutionOutcomeBothFault), true)
+	// Outside the requested range
+	seed(users[3], users[0], "2026-05", 900, domain.BillStatusDisputed, "", false)
+
+	stats, err := billRepo.GetDisputeStatistics(ctx, org.ID, "2026-01", "2026-02")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), stats.TotalDisputes)
+	assert.Equal(t, int32(1), stats.CountByStatus[string(domain.BillStatusDisputed)])
+	assert.Equal(t, int32(2), stats.CountByStatus[string(domain.BillStatusAdminResolved)])
+	assert.Equal(t, int32(1), stats.CountByStatus[string(domain.BillStatusSystemDefaultAction)])
+	assert.Equal(t, int32(1), stats.CountByOutcome[string(domain.ResolutionOutcomeCreditorFault)])
+	assert.Equal(t, int32(2), stats.CountByOutcome[string(domain.ResolutionOutcomeBothFault)])
+	assert.InDelta(t, int64(2*time.Hour/time.Second), stats.AvgResolutionSeconds, 1)
+	// CREDITOR_FAULT 2000 + admin BOTH_FAULT 1500*2; system default action applies no penalty
+	assert.Equal(t, int64(5000), stats.TotalPenalizedCents)
+
+	all, err := billRepo.GetDisputeStatistics(ctx, org.ID, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), all.TotalDisputes)
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+	"ubertool-backend-trusted/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLedgerService_GetLedgerSummary_ReconcilesWithTransactions seeds ledger transactions,
+// drifts the cached balance and verifies the summary reports the ledger sum and the mismatch.
+func TestLedgerService_GetLedgerSummary_ReconcilesWithTransactions(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	ledgerRepo := postgres.NewLedgerRepository(db)
+	svc := service.NewLedgerService(ledgerRepo, userRepo)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("LedgerSummaryOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	require.NoError(t, orgRepo.Create(ctx, org))
+	user := &domain.User{
+		Email:        fmt.Sprintf("ls-%d@t.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("ls-%d", time.Now().UnixNano()),
+		PasswordHash: "h", Name: "Ledger User",
+	}
+	require.NoError(t, userRepo.Create(ctx, user))
+	require.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+		UserID: user.ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+	}))
+
+	for _, amount := range []int32{2000, -500, -300} {
+		txType := domain.TransactionTypeLendingCredit
+		if amount < 0 {
+			txType = domain.TransactionTypeRentalDebit
+		}
+		require.NoError(t, ledgerRepo.CreateTransaction(ctx, &domain.LedgerTransaction{
+			OrgID: org.ID, UserID: user.ID, Amount: amount, Type: txType, Description: "seed",
+		}))
+	}
+
+	t.Run("Matching balance", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE users_orgs SET balance_cents = 1200 WHERE user_id = $1 AND org_id = $2`, user.ID, org.ID)
+		require.NoError(t, err)
+
+		summary, err := svc.GetLedgerSummary(ctx, user.ID, org.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1200), summary.Balance)
+		assert.False(t, summary.BalanceMismatch)
+	})
+
+	t.Run("Drifted balance", func(t *testing.T) {
+		_, err := db.Exec(`UPDATE users_orgs SET balance_cents = 5000 WHERE user_id = $1 AND org_id = $2`, user.ID, org.ID)
+		require.NoError(t, err)
+
+		summary, err := svc.GetLedgerSummary(ctx, user.ID, org.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1200), summary.Balance, "balance is the sum of the transactions")
+		assert.Equal(t, int32(5000), summary.StoredBalance)
+		assert.True(t, summary.BalanceMismatch)
+	})
+}
@@ -0,0 +1,86 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBillRepository_GetBalanceSheet seeds members with mixed balances and bills in several
+// statuses and verifies the balance totals and per-status bill aggregates.
+func TestBillRepository_GetBalanceSheet(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	billRepo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("BalanceSheetOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, org))
+
+	balances := []int32{2500, 1000, 0, -1500, -2000}
+	users := make([]*domain.User, len(balances))
+	for i, balance := range balances {
+		users[i] = &domain.User{
+			Email:        fmt.Sprintf("bs%d-%d@t.com", i, time.Now().UnixNano()),
+			PhoneNumber:  fmt.Sprintf("bs%d-%d", i, time.Now().UnixNano()),
+			PasswordHash: "h", Name: fmt.Sprintf("User %d", i),
+		}
+		assert.NoError(t, userRepo.Create(ctx, users[i]))
+		assert.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+			UserID: users[i].ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+			BalanceCents: balance,
+		}))
+	}
+
+	seed := func(debtor, creditor *domain.User, amount int32, status domain.BillStatus) {
+		bill := &domain.Bill{
+			OrgID: org.ID, DebtorUserID: debtor.ID, CreditorUserID: creditor.ID,
+			AmountCents: amount, SettlementMonth: "2026-01", Status: domain.BillStatusPending,
+		}
+		assert.NoError(t, billRepo.Create(ctx, bill))
+		if status != domain.BillStatusPending {
+			bill.Status = status
+			assert.NoError(t, billRepo.Update(ctx, bill))
+		}
+	}
+
+	seed(users[3], users[0], 1500, domain.BillStatusPending)
+	seed(users[4], users[1], 1000, domain.BillStatusPending)
+	seed(users[4], users[0], 1000, domain.BillStatusDisputed)
+	seed(users[3], users[1], 800, domain.BillStatusPaid)
+	seed(users[4], users[2], 300, domain.BillStatusAdminResolved)
+
+	sheet, err := billRepo.GetBalanceSheet(ctx, org.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, org.ID, sheet.OrgID)
+	assert.Equal(t, int32(5), sheet.MemberCount)
+	assert.Equal(t, int32(2), sheet.MembersInCredit)
+	assert.Equal(t, int32(2), sheet.MembersInDebt)
+	assert.Equal(t, int64(3500), sheet.TotalPositiveBalanceCents)
+	assert.Equal(t, int64(-3500), sheet.TotalNegativeBalanceCents)
+
+	assert.Equal(t, domain.BillStatusTotal{Count: 2, AmountCents: 2500}, sheet.BillsByStatus[string(domain.BillStatusPending)])
+	assert.Equal(t, domain.BillStatusTotal{Count: 1, AmountCents: 1000}, sheet.BillsByStatus[string(domain.BillStatusDisputed)])
+	assert.Equal(t, domain.BillStatusTotal{Count: 1, AmountCents: 800}, sheet.BillsByStatus[string(domain.BillStatusPaid)])
+	assert.Equal(t, domain.BillStatusTotal{Count: 1, AmountCents: 300}, sheet.BillsByStatus[string(domain.BillStatusAdminResolved)])
+	_, ok := sheet.BillsByStatus[string(domain.BillStatusSystemDefaultAction)]
+	assert.False(t, ok)
+
+	// An org with no members or bills reports zeroes
+	empty := &domain.Organization{Name: fmt.Sprintf("EmptyBalanceSheetOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	assert.NoError(t, orgRepo.Create(ctx, empty))
+	emptySheet, err := billRepo.GetBalanceSheet(ctx, empty.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), emptySheet.MemberCount)
+	assert.Equal(t, int64(0), emptySheet.TotalPositiveBalanceCents)
+	assert.Empty(t, emptySheet.BillsByStatus)
+}
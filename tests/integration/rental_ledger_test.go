@@ -20,7 +20,11 @@ type MockEmailService struct {
 	mock.Mock
 }
 
-func (m *MockEmailService) SendRentalRequestNotification(ctx context.Context, ownerEmail, renterName, toolName, renterEmail string) error {
+func (m *MockEmailService) Send(ctx context.Context, to, templateName string, data interface{}) error {
+	return nil
+}
+
+func (m *MockEmailService) SendRentalRequestNotification(ctx context.Context, ownerEmail string, data service.RentalRequestEmail, renterEmail string) error {
 	return nil
 }
 func (m *MockEmailService) SendRentalConfirmationNotification(ctx context.Context, ownerEmail, renterName, toolName, renterEmail string) error {
@@ -44,30 +48,33 @@ func (m *MockEmailService) SendInvitation(ctx context.Context, email, name, toke
 func (m *MockEmailService) SendAccountStatusNotification(ctx context.Context, email, name, orgName, status, reason string) error {
 	return nil
 }
-func (m *MockEmailService) SendRentalApprovalNotification(ctx context.Context, renterEmail, toolName, ownerName, pickupNote string, ccEmail string) error {
+func (m *MockEmailService) SendRentalApprovalNotification(ctx context.Context, renterEmail string, data service.RentalApprovalEmail, ccEmail string) error {
 	return nil
 }
 func (m *MockEmailService) SendRentalRejectionNotification(ctx context.Context, renterEmail, toolName, ownerName string, ccEmail string) error {
 	return nil
 }
-func (m *MockEmailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32) error {
+func (m *MockEmailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32, currencyCode string) error {
 	return nil
 }
 
 // Bill Split Notifications
-func (m *MockEmailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
+func (m *MockEmailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
 	return nil
 }
-func (m *MockEmailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string) error {
+func (m *MockEmailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
 	return nil
 }
-func (m *MockEmailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
+func (m *MockEmailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
 	return nil
 }
-func (m *MockEmailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string) error {
+func (m *MockEmailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string, currencyCode string) error {
 	return nil
 }
-func (m *MockEmailService) SendBillDisputeResolutionNotification(ctx context.Context, email, name string, amountCents int32, resolution, notes string, orgName string) error {
+func (m *MockEmailService) SendBillDisputeResolutionNotification(ctx context.Context, email string, data service.BillDisputeResolutionEmail) error {
+	return nil
+}
+func (m *MockEmailService) SendTwoFactorCode(ctx context.Context, email string, data service.TwoFactorCodeEmail) error {
 	return nil
 }
 
@@ -75,18 +82,31 @@ type MockNotificationRepo struct {
 	mock.Mock
 }
 
-func (m *MockNotificationRepo) GetNotifications(ctx context.Context, userID int32, page, pageSize int32) ([]domain.Notification, int32, error) {
+func (m *MockNotificationRepo) GetNotifications(ctx context.Context, userID int32, notificationType string, page, pageSize int32) ([]domain.Notification, int32, error) {
 	return nil, 0, nil
 }
+func (m *MockNotificationRepo) UnreadCount(ctx context.Context, userID, orgID int32) (int32, error) {
+	return 0, nil
+}
+func (m *MockNotificationRepo) Subscribe(userID int32) (<-chan domain.Notification, func()) {
+	ch := make(chan domain.Notification)
+	return ch, func() {}
+}
 func (m *MockNotificationRepo) MarkAsRead(ctx context.Context, userID int32, notificationID int64) error {
 	return nil
 }
+func (m *MockNotificationRepo) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	return 0, nil
+}
 func (m *MockNotificationRepo) Dispatch(ctx context.Context, n *domain.Notification) error {
 	return nil
 }
 func (m *MockNotificationRepo) DispatchSilent(ctx context.Context, n *domain.Notification) error {
 	return nil
 }
+func (m *MockNotificationRepo) RetryFailed(ctx context.Context, limit int32) (int, int, error) {
+	return 0, 0, nil
+}
 func (m *MockNotificationRepo) SyncDeviceToken(ctx context.Context, userID int32, fcmToken, androidDeviceID, deviceName string) error {
 	return nil
 }
@@ -186,7 +206,7 @@ func TestRentalDateChange_Integration(t *testing.T) {
 	emailSvc := new(MockEmailService)
 	noteRepo := new(MockNotificationRepo)
 
-	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 	ctx := context.Background()
 
 	// 2. Setup Data
@@ -280,8 +300,11 @@ func TestRentalDateChange_Integration(t *testing.T) {
 		t.Logf("ChangeRental Result: Status=%s, Cost=%d, EndDate=%v, LastAgreedEndDate=%v",
 			chgRental.Status, chgRental.TotalCostCents, chgRental.EndDate, chgRental.LastAgreedEndDate)
 		assert.Equal(t, domain.RentalStatusReturnDateChanged, chgRental.Status)
-		// Cost should be 2 days (2025-01-01 to 2025-01-03 end-exclusive) * 1000 = 2000
-		assert.Equal(t, int32(2000), chgRental.TotalCostCents)
+		// The request is held in RequestedEndDate; the agreed end date and its cost are unchanged
+		require.NotNil(t, chgRental.RequestedEndDate)
+		assert.Equal(t, newEnd, *chgRental.RequestedEndDate)
+		assert.Equal(t, "2025-01-02", chgRental.EndDate)
+		assert.Equal(t, int32(1000), chgRental.TotalCostCents)
 
 		// 3. Approve Extension
 		appRental, err := svc.ApproveReturnDateChange(ctx, owner.ID, rental.ID)
@@ -293,11 +316,19 @@ func TestRentalDateChange_Integration(t *testing.T) {
 		// Note: Status becomes OVERDUE because the rental dates are in the past
 		assert.Equal(t, domain.RentalStatusOverdue, appRental.Status)
 		assert.NotNil(t, appRental.LastAgreedEndDate) // Should be set to approved date
+		// Cost should be 2 days (2025-01-01 to 2025-01-03 end-exclusive) * 1000 = 2000
+		assert.Equal(t, int32(2000), appRental.TotalCostCents)
 		// Verify EndDate and LastAgreedEndDate are updated (check logic persistence)
 		// Since we use DB, let's fetch fresh
 		finalRental, _ := rentalRepo.GetByID(ctx, rental.ID)
 		assert.Equal(t, newEnd, finalRental.EndDate)
 		assert.NotNil(t, finalRental.LastAgreedEndDate)
 		assert.Equal(t, newEnd, *finalRental.LastAgreedEndDate)
+		assert.Nil(t, finalRental.RequestedEndDate)
+
+		// No hold was placed for the manually scheduled rental, so the approval reserves the full new cost
+		held, err := ledgerRepo.GetRentalHold(ctx, rental.ID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2000), held)
 	})
 }
@@ -0,0 +1,100 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRentalRepository_ListingPagination seeds rentals created on the same day, so created_on
+// alone cannot order them, and walks every lister page by page checking that each rental
+// appears exactly once and that the reported total matches.
+func TestRentalRepository_ListingPagination(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	orgRepo := postgres.NewOrganizationRepository(db)
+	toolRepo := postgres.NewToolRepository(db)
+	rentalRepo := postgres.NewRentalRepository(db)
+	ctx := context.Background()
+
+	org := &domain.Organization{Name: fmt.Sprintf("ListingOrg-%d", time.Now().UnixNano()), Metro: "San Jose"}
+	require.NoError(t, orgRepo.Create(ctx, org))
+
+	newMember := func(name string) *domain.User {
+		u := &domain.User{
+			Email:        fmt.Sprintf("%s-%d@t.com", name, time.Now().UnixNano()),
+			PhoneNumber:  fmt.Sprintf("%s-%d", name, time.Now().UnixNano()),
+			PasswordHash: "h", Name: name,
+		}
+		require.NoError(t, userRepo.Create(ctx, u))
+		require.NoError(t, userRepo.AddUserToOrg(ctx, &domain.UserOrg{
+			UserID: u.ID, OrgID: org.ID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive,
+		}))
+		return u
+	}
+	owner := newMember("owner")
+	renter := newMember("renter")
+
+	tool := &domain.Tool{
+		OwnerID: owner.ID, Name: "Ladder", PricePerDayCents: 1000, DurationUnit: domain.ToolDurationUnitDay,
+		Metro: "San Jose", Status: domain.ToolStatusAvailable,
+	}
+	require.NoError(t, toolRepo.Create(ctx, tool))
+
+	const seeded = 7
+	want := make(map[int32]bool, seeded)
+	for i := 0; i < seeded; i++ {
+		rt := &domain.Rental{
+			OrgID: org.ID, ToolID: tool.ID, RenterID: renter.ID, OwnerID: owner.ID,
+			StartDate: "2026-03-01", EndDate: "2026-03-05", TotalCostCents: 4000, Status: domain.RentalStatusCompleted,
+		}
+		require.NoError(t, rentalRepo.Create(ctx, rt))
+		want[rt.ID] = true
+	}
+
+	type lister func(page, pageSize int32) ([]domain.Rental, int32, error)
+	listers := map[string]lister{
+		"ListByRenter": func(page, pageSize int32) ([]domain.Rental, int32, error) {
+			return rentalRepo.ListByRenter(ctx, renter.ID, org.ID, nil, page, pageSize)
+		},
+		"ListByOwner": func(page, pageSize int32) ([]domain.Rental, int32, error) {
+			return rentalRepo.ListByOwner(ctx, owner.ID, org.ID, nil, page, pageSize)
+		},
+		"ListByTool": func(page, pageSize int32) ([]domain.Rental, int32, error) {
+			return rentalRepo.ListByTool(ctx, tool.ID, org.ID, nil, "", "", page, pageSize)
+		},
+	}
+
+	for name, list := range listers {
+		t.Run(name, func(t *testing.T) {
+			seen := make(map[int32]bool, seeded)
+			var ids []int32
+			for page := int32(1); ; page++ {
+				rentals, total, err := list(page, 3)
+				require.NoError(t, err)
+				assert.Equal(t, int32(seeded), total)
+				if len(rentals) == 0 {
+					break
+				}
+				for _, rt := range rentals {
+					assert.False(t, seen[rt.ID], "rental %d listed twice", rt.ID)
+					seen[rt.ID] = true
+					ids = append(ids, rt.ID)
+				}
+			}
+			assert.Equal(t, want, seen)
+			for i := 1; i < len(ids); i++ {
+				assert.Greater(t, ids[i-1], ids[i], "same-day rentals are ordered newest id first")
+			}
+		})
+	}
+}
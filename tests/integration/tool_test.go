@@ -3,6 +3,7 @@ package integration
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -79,7 +80,7 @@ func TestToolRepository_Integration(t *testing.T) {
 		var orgID int32
 		db.QueryRow("SELECT id FROM orgs WHERE name = 'Org SJ'").Scan(&orgID)
 
-		tools, total, err := repo.ListByOrg(ctx, orgID, 1, 10)
+		tools, total, err := repo.ListByOrg(ctx, orgID, false, 1, 10)
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, total, int32(1))
 
@@ -94,3 +95,146 @@ func TestToolRepository_Integration(t *testing.T) {
 		assert.True(t, foundHammer)
 	})
 }
+
+// TestToolRepository_SearchRanking verifies full-text search orders tools by relevance and
+// still answers stop-word-only queries through the ILIKE fallback.
+func TestToolRepository_SearchRanking(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	owner := &domain.User{
+		Email:        fmt.Sprintf("rank-owner-%d@test.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("rank-%d", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Name:         "Rank Owner",
+	}
+	assert.NoError(t, userRepo.Create(ctx, owner))
+	searcher := &domain.User{
+		Email:        fmt.Sprintf("rank-searcher-%d@test.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("rank-s-%d", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Name:         "Rank Searcher",
+	}
+	assert.NoError(t, userRepo.Create(ctx, searcher))
+
+	metro := fmt.Sprintf("RankMetro-%d", time.Now().UnixNano())
+	for _, tl := range []struct{ name, desc string }{
+		{"Leaf Blower", "Cordless blower for the yard"},
+		{"Cordless Drill", "Compact cordless drill with two batteries"},
+		{"Hammer", "Claw hammer"},
+		{"Drill Bit Set", "Bits for any drill"},
+	} {
+		assert.NoError(t, repo.Create(ctx, &domain.Tool{
+			OwnerID: owner.ID, Name: tl.name, Description: tl.desc, Categories: []string{"Power Tools"},
+			PricePerDayCents: 100, DurationUnit: domain.ToolDurationUnitDay, Condition: domain.ToolConditionGood,
+			Metro: metro, Status: domain.ToolStatusAvailable,
+		}))
+	}
+
+	tools, total, err := repo.Search(ctx, searcher.ID, []string{metro}, "cordless drill", nil, 0, "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), total)
+	if assert.Len(t, tools, 3) {
+		// Both words in the name outrank a single word in the name or description
+		assert.Equal(t, "Cordless Drill", tools[0].Name)
+		assert.Equal(t, "Drill Bit Set", tools[1].Name)
+		assert.Equal(t, "Leaf Blower", tools[2].Name)
+	}
+
+	// "the" is a stop word, so the query has no lexemes and ILIKE is used instead
+	tools, total, err = repo.Search(ctx, searcher.ID, []string{metro}, "the", nil, 0, "", 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), total)
+	if assert.Len(t, tools, 1) {
+		assert.Equal(t, "Leaf Blower", tools[0].Name)
+	}
+}
+
+// TestToolRepository_CountAvailableByMetro counts only the metro's tools that search would list
+func TestToolRepository_CountAvailableByMetro(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	owner := &domain.User{
+		Email:        fmt.Sprintf("count-owner-%d@test.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("count-%d", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Name:         "Count Owner",
+	}
+	assert.NoError(t, userRepo.Create(ctx, owner))
+
+	metro := fmt.Sprintf("CountMetro %d", time.Now().UnixNano())
+	create := func(name, toolMetro string, status domain.ToolStatus) *domain.Tool {
+		tool := &domain.Tool{
+			OwnerID: owner.ID, Name: name, Categories: []string{"Hand Tools"},
+			PricePerDayCents: 100, DurationUnit: domain.ToolDurationUnitDay, Condition: domain.ToolConditionGood,
+			Metro: toolMetro, Status: status,
+		}
+		assert.NoError(t, repo.Create(ctx, tool))
+		return tool
+	}
+	create("Hammer", metro, domain.ToolStatusAvailable)
+	create("Ladder", metro, domain.ToolStatusRented)
+	create("Broken Saw", metro, domain.ToolStatusUnavailable)
+	assert.NoError(t, repo.Delete(ctx, create("Sold Drill", metro, domain.ToolStatusAvailable).ID))
+	create("Faraway Rake", metro+" North", domain.ToolStatusAvailable)
+
+	count, err := repo.CountAvailableByMetro(ctx, metro)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+
+	// Metro names compare case-insensitively, as in search
+	count, err = repo.CountAvailableByMetro(ctx, strings.ToUpper(metro))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), count)
+}
+
+// TestToolRepository_UpdateAdvancesUpdatedOn backdates a tool and verifies that an update
+// moves updated_on forward while created_on stays put.
+func TestToolRepository_UpdateAdvancesUpdatedOn(t *testing.T) {
+	db := prepareDB(t)
+	defer db.Close()
+
+	userRepo := postgres.NewUserRepository(db)
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	owner := &domain.User{
+		Email:        fmt.Sprintf("upd-owner-%d@test.com", time.Now().UnixNano()),
+		PhoneNumber:  fmt.Sprintf("upd-%d", time.Now().UnixNano()),
+		PasswordHash: "hash",
+		Name:         "Update Owner",
+	}
+	assert.NoError(t, userRepo.Create(ctx, owner))
+
+	tool := &domain.Tool{
+		OwnerID: owner.ID, Name: "Sander", Description: "Orbital sander", Categories: []string{"Power Tools"},
+		PricePerDayCents: 300, DurationUnit: domain.ToolDurationUnitDay, Condition: domain.ToolConditionGood,
+		Metro: "San Jose", Status: domain.ToolStatusAvailable,
+	}
+	assert.NoError(t, repo.Create(ctx, tool))
+
+	_, err := db.Exec("UPDATE tools SET created_on = '2025-01-10', updated_on = '2025-01-10' WHERE id = $1", tool.ID)
+	assert.NoError(t, err)
+
+	before, err := repo.GetByID(ctx, tool.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-10", before.UpdatedOn)
+
+	before.PricePerDayCents = 350
+	assert.NoError(t, repo.Update(ctx, before))
+
+	after, err := repo.GetByID(ctx, tool.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-01-10", after.CreatedOn)
+	assert.Equal(t, time.Now().Format("2006-01-02"), after.UpdatedOn)
+	assert.Greater(t, after.UpdatedOn, after.CreatedOn)
+}
@@ -0,0 +1,196 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestAdminAudit_ResolveDispute(t *testing.T) {
+	ctx := context.Background()
+	mockBillRepo := new(MockBillRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockOrgRepo := new(MockOrganizationRepo)
+	mockNotifRepo := new(MockNotificationRepo)
+	mockEmailSvc := new(MockEmailService)
+	auditRepo := new(MockAdminAuditRepo)
+	ledgerRepo := new(MockLedgerRepo)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, ledgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc, service.NewAdminAudit(auditRepo))
+
+	bill := &domain.Bill{
+		ID: 9, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
+		AmountCents: 1000, Status: domain.BillStatusDisputed, SettlementMonth: "2026-01",
+	}
+	mockBillRepo.On("GetByID", ctx, int32(9)).Return(bill, nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+	mockBillRepo.On("Update", ctx, mock.Anything).Return(nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(&domain.UserOrg{UserID: 2, OrgID: 1}, nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(3), int32(1)).Return(&domain.UserOrg{UserID: 3, OrgID: 1}, nil)
+	ledgerRepo.On("CreateTransaction", ctx, mock.Anything).Return(nil)
+	ledgerRepo.On("GetBalance", ctx, mock.Anything, int32(1)).Return(int32(0), nil)
+	mockBillRepo.On("CreateAction", ctx, mock.Anything).Return(nil)
+	mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Org"}, nil)
+	mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Email: "d@test.com"}, nil)
+	mockUserRepo.On("GetByID", ctx, int32(3)).Return(&domain.User{ID: 3, Email: "c@test.com"}, nil)
+	mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil)
+	mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+		return e.AdminID == 1 && e.OrgID == 1 &&
+			e.Action == domain.AdminAuditActionResolveDispute &&
+			e.TargetType == domain.AdminAuditTargetBill && e.TargetID == 9 &&
+			e.Details["resolution"] == string(domain.ResolutionOutcomeGraceful) &&
+			e.Details["notes"] == "settled in person" &&
+			e.Details["amount_cents"] == "1000"
+	})).Return(nil).Once()
+
+	err := svc.ResolveDispute(ctx, 1, 9, string(domain.ResolutionOutcomeGraceful), "settled in person")
+	assert.NoError(t, err)
+	auditRepo.AssertExpectations(t)
+}
+
+func TestAdminAudit_ResolveDisputeSnapshots(t *testing.T) {
+	ctx := context.Background()
+	mockBillRepo := new(MockBillRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockOrgRepo := new(MockOrganizationRepo)
+	mockNotifRepo := new(MockNotificationRepo)
+	mockEmailSvc := new(MockEmailService)
+	auditRepo := new(MockAdminAuditRepo)
+	ledgerRepo := new(MockLedgerRepo)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, ledgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc, service.NewAdminAudit(auditRepo))
+
+	bill := &domain.Bill{
+		ID: 9, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
+		AmountCents: 1000, Status: domain.BillStatusDisputed, SettlementMonth: "2026-01",
+	}
+	mockBillRepo.On("GetByID", ctx, int32(9)).Return(bill, nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+	mockBillRepo.On("Update", ctx, mock.Anything).Return(nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(&domain.UserOrg{UserID: 2, OrgID: 1, BalanceCents: 200, Status: domain.UserOrgStatusActive}, nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(3), int32(1)).Return(&domain.UserOrg{UserID: 3, OrgID: 1, BalanceCents: -500, Status: domain.UserOrgStatusActive}, nil)
+	ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+		return tx.UserID == 3 && tx.Amount == 1000 && tx.Type == domain.TransactionTypeBillSettlement
+	})).Return(nil).Once()
+	ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+		return tx.UserID == 2 && tx.Amount == -1000 && tx.Type == domain.TransactionTypeBillSettlement
+	})).Return(nil).Once()
+	ledgerRepo.On("GetBalance", ctx, int32(3), int32(1)).Return(int32(500), nil)
+	ledgerRepo.On("GetBalance", ctx, int32(2), int32(1)).Return(int32(-800), nil)
+	mockUserRepo.On("UpdateUserOrg", ctx, mock.Anything).Return(nil)
+	mockBillRepo.On("CreateAction", ctx, mock.Anything).Return(nil)
+	mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Org"}, nil)
+	mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Email: "d@test.com"}, nil)
+	mockUserRepo.On("GetByID", ctx, int32(3)).Return(&domain.User{ID: 3, Email: "c@test.com"}, nil)
+	mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil)
+	mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, mock.Anything, mock.Anything).Return(nil)
+
+	var recorded *domain.AdminAuditEntry
+	auditRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		recorded = args.Get(1).(*domain.AdminAuditEntry)
+	}).Return(nil).Once()
+
+	err := svc.ResolveDispute(ctx, 1, 9, string(domain.ResolutionOutcomeDebtorFault), "")
+	assert.NoError(t, err)
+	if !assert.NotNil(t, recorded) {
+		return
+	}
+
+	assert.Equal(t, "DISPUTED", recorded.Before["status"])
+	assert.Equal(t, "ADMIN_RESOLVED", recorded.After["status"])
+	// The debtor is charged and then blocked; the snapshot spans both updates
+	assert.Equal(t, "200", recorded.Before["debtor_balance_cents"])
+	assert.Equal(t, "false", recorded.Before["debtor_renting_blocked"])
+	assert.Equal(t, "-800", recorded.After["debtor_balance_cents"])
+	assert.Equal(t, "true", recorded.After["debtor_renting_blocked"])
+	assert.Equal(t, "-500", recorded.Before["creditor_balance_cents"])
+	assert.Equal(t, "500", recorded.After["creditor_balance_cents"])
+}
+
+func TestAdminAudit_BlockUser(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (service.AdminService, *MockUserRepo, *MockAdminAuditRepo) {
+		mockUserRepo := new(MockUserRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockEmailSvc := new(MockEmailService)
+		auditRepo := new(MockAdminAuditRepo)
+		svc := service.NewAdminService(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, mockEmailSvc, service.NewAdminAudit(auditRepo))
+
+		mockUserRepo.On("GetUserOrg", ctx, int32(5), int32(1)).Return(&domain.UserOrg{UserID: 5, OrgID: 1, Status: domain.UserOrgStatusActive}, nil)
+		mockUserRepo.On("GetByID", ctx, int32(5)).Return(&domain.User{ID: 5, Name: "Member", Email: "m@test.com"}, nil)
+		mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Org"}, nil)
+		mockUserRepo.On("UpdateUserOrg", ctx, mock.Anything).Return(nil)
+		mockEmailSvc.On("SendAccountStatusNotification", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+		return svc, mockUserRepo, auditRepo
+	}
+
+	t.Run("Block records BLOCK_MEMBER", func(t *testing.T) {
+		svc, _, auditRepo := setup()
+		auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+			return e.AdminID == 10 && e.OrgID == 1 &&
+				e.Action == domain.AdminAuditActionBlockMember &&
+				e.TargetType == domain.AdminAuditTargetUser && e.TargetID == 5 &&
+				e.Details["renting_blocked"] == "true" && e.Details["lending_blocked"] == "false" &&
+				e.Details["reason"] == "late returns" &&
+				e.Before["status"] == string(domain.UserOrgStatusActive) && e.Before["renting_blocked"] == "false" &&
+				e.After["status"] == string(domain.UserOrgStatusBlock) && e.After["renting_blocked"] == "true"
+		})).Return(nil).Once()
+
+		assert.NoError(t, svc.BlockUser(ctx, 10, 5, 1, true, false, "late returns"))
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unblock records UNBLOCK_MEMBER", func(t *testing.T) {
+		svc, _, auditRepo := setup()
+		auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+			return e.Action == domain.AdminAuditActionUnblockMember && e.TargetID == 5
+		})).Return(nil).Once()
+
+		assert.NoError(t, svc.BlockUser(ctx, 10, 5, 1, false, false, ""))
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Audit write failure does not fail the block", func(t *testing.T) {
+		svc, _, auditRepo := setup()
+		auditRepo.On("Create", ctx, mock.Anything).Return(assert.AnError).Once()
+
+		assert.NoError(t, svc.BlockUser(ctx, 10, 5, 1, true, true, "fraud"))
+	})
+}
+
+func TestAdminService_ListAuditLog(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	auditRepo := new(MockAdminAuditRepo)
+	svc := service.NewAdminService(nil, mockUserRepo, nil, nil, nil, nil, nil, service.NewAdminAudit(auditRepo))
+
+	filter := domain.AdminAuditFilter{Action: domain.AdminAuditActionBlockMember, Page: 1, PageSize: 20}
+
+	t.Run("Super admin lists entries", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(7)).Return(&domain.UserOrg{UserID: 1, OrgID: 7, Role: domain.UserOrgRoleSuperAdmin}, nil).Once()
+		auditRepo.On("List", ctx, int32(7), filter).Return([]domain.AdminAuditEntry{
+			{ID: 3, OrgID: 7, AdminID: 2, Action: domain.AdminAuditActionBlockMember, TargetType: domain.AdminAuditTargetUser, TargetID: 5},
+		}, int32(1), nil).Once()
+
+		entries, total, err := svc.ListAuditLog(ctx, 1, 7, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("Org admin is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(7)).Return(&domain.UserOrg{UserID: 2, OrgID: 7, Role: domain.UserOrgRoleAdmin}, nil).Once()
+
+		_, _, err := svc.ListAuditLog(ctx, 2, 7, filter)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "super admin")
+	})
+
+	auditRepo.AssertExpectations(t)
+}
@@ -2,6 +2,8 @@ package unit
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -19,7 +21,7 @@ func TestAdminService_BlockUser(t *testing.T) {
 	mockOrgRepo := new(MockOrganizationRepo)
 	mockInviteRepo := new(MockInviteRepo)
 	mockEmailSvc := new(MockEmailService)
-	svc := service.NewAdminService(mockJoinRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockInviteRepo, mockEmailSvc)
+	svc := service.NewAdminService(mockJoinRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockInviteRepo, nil, mockEmailSvc, nil)
 	ctx := context.Background()
 
 	t.Run("Block", func(t *testing.T) {
@@ -57,7 +59,7 @@ func TestAdminService_BlockUser(t *testing.T) {
 
 func TestAdminService_ListMembers(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
-	svc := service.NewAdminService(nil, mockUserRepo, nil, nil, nil, nil)
+	svc := service.NewAdminService(nil, mockUserRepo, nil, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
 	users := []domain.User{{ID: 1, Name: "User 1"}}
@@ -79,7 +81,7 @@ func TestAdminService_ApproveJoinRequest(t *testing.T) {
 	mockJoinRepo := new(MockJoinRequestRepo)
 	mockLedgerRepo := new(MockLedgerRepo)
 
-	svc := service.NewAdminService(mockJoinRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockInviteRepo, mockEmailSvc)
+	svc := service.NewAdminService(mockJoinRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockInviteRepo, nil, mockEmailSvc, nil)
 	ctx := context.Background()
 
 	adminID := int32(1)
@@ -127,3 +129,568 @@ func TestAdminService_ApproveJoinRequest(t *testing.T) {
 	mockInviteRepo.AssertExpectations(t)
 	mockEmailSvc.AssertExpectations(t)
 }
+
+func TestAdminService_ApproveJoinRequest_ExistingUser(t *testing.T) {
+	ctx := context.Background()
+	adminID, orgID, userID := int32(1), int32(10), int32(7)
+	org := &domain.Organization{ID: orgID, Name: "Test Org"}
+
+	t.Run("Linked user is added to the org and the request is JOINED", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		joinRepo := new(MockJoinRequestRepo)
+		emailSvc := new(MockEmailService)
+		svc := service.NewAdminService(joinRepo, userRepo, nil, orgRepo, new(MockInviteRepo), nil, emailSvc, nil)
+
+		joinRepo.On("GetByID", ctx, int32(42)).Return(&domain.JoinRequest{
+			ID: 42, OrgID: orgID, UserID: &userID, Name: "Member", Email: "member@test.com", Status: domain.JoinRequestStatusPending,
+		}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(org, nil)
+		userRepo.On("GetByID", ctx, userID).Return(&domain.User{ID: userID, Email: "member@test.com"}, nil)
+		userRepo.On("GetUserOrg", ctx, userID, orgID).Return(nil, sql.ErrNoRows)
+		userRepo.On("AddUserToOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
+			return uo.UserID == userID && uo.OrgID == orgID && uo.Role == domain.UserOrgRoleMember
+		})).Return(nil).Once()
+		emailSvc.On("SendAccountStatusNotification", ctx, "member@test.com", "Member", "Test Org", "APPROVED", mock.Anything).Return(nil)
+		joinRepo.On("Update", ctx, mock.MatchedBy(func(jr *domain.JoinRequest) bool {
+			return jr.Status == domain.JoinRequestStatusJoined
+		})).Return(nil)
+
+		code, err := svc.ApproveJoinRequest(ctx, adminID, orgID, 42)
+		assert.NoError(t, err)
+		assert.Empty(t, code)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		userRepo.AssertExpectations(t)
+		joinRepo.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Existing membership is not added twice", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		joinRepo := new(MockJoinRequestRepo)
+		emailSvc := new(MockEmailService)
+		svc := service.NewAdminService(joinRepo, userRepo, nil, orgRepo, new(MockInviteRepo), nil, emailSvc, nil)
+
+		joinRepo.On("GetByID", ctx, int32(43)).Return(&domain.JoinRequest{
+			ID: 43, OrgID: orgID, Name: "Member", Email: "member@test.com", Status: domain.JoinRequestStatusPending,
+		}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(org, nil)
+		userRepo.On("GetByEmail", ctx, "member@test.com").Return(&domain.User{ID: userID, Email: "member@test.com"}, nil)
+		userRepo.On("GetUserOrg", ctx, userID, orgID).Return(&domain.UserOrg{UserID: userID, OrgID: orgID}, nil)
+		emailSvc.On("SendAccountStatusNotification", ctx, "member@test.com", "Member", "Test Org", "APPROVED", mock.Anything).Return(nil)
+		joinRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+		_, err := svc.ApproveJoinRequest(ctx, adminID, orgID, 43)
+		assert.NoError(t, err)
+		userRepo.AssertNotCalled(t, "AddUserToOrg", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Processed requests cannot be approved again", func(t *testing.T) {
+		joinRepo := new(MockJoinRequestRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewAdminService(joinRepo, userRepo, nil, new(MockOrganizationRepo), new(MockInviteRepo), nil, new(MockEmailService), nil)
+
+		for i, status := range []domain.JoinRequestStatus{domain.JoinRequestStatusInvited, domain.JoinRequestStatusJoined, domain.JoinRequestStatusRejected} {
+			id := int32(50 + i)
+			joinRepo.On("GetByID", ctx, id).Return(&domain.JoinRequest{ID: id, OrgID: orgID, Email: "x@test.com", Status: status}, nil)
+
+			_, err := svc.ApproveJoinRequest(ctx, adminID, orgID, id)
+			assert.ErrorIs(t, err, service.ErrJoinRequestProcessed, string(status))
+		}
+		joinRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		userRepo.AssertNotCalled(t, "AddUserToOrg", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_RejectJoinRequest(t *testing.T) {
+	ctx := context.Background()
+	adminID, orgID := int32(1), int32(10)
+
+	t.Run("Records reason and rejecting admin", func(t *testing.T) {
+		joinRepo := new(MockJoinRequestRepo)
+		orgRepo := new(MockOrganizationRepo)
+		inviteRepo := new(MockInviteRepo)
+		emailSvc := new(MockEmailService)
+		svc := service.NewAdminService(joinRepo, new(MockUserRepo), nil, orgRepo, inviteRepo, nil, emailSvc, nil)
+
+		joinRepo.On("GetByID", ctx, int32(42)).Return(&domain.JoinRequest{
+			ID: 42, OrgID: orgID, Name: "Applicant", Email: "applicant@test.com", Status: domain.JoinRequestStatusInvited,
+		}, nil)
+		joinRepo.On("Update", ctx, mock.MatchedBy(func(jr *domain.JoinRequest) bool {
+			return jr.Status == domain.JoinRequestStatusRejected && jr.Reason == "not a neighbour" &&
+				jr.RejectedByUserID != nil && *jr.RejectedByUserID == adminID
+		})).Return(nil)
+		inviteRepo.On("GetByJoinRequestID", ctx, int32(42)).Return(&domain.Invitation{ID: 5}, nil)
+		inviteRepo.On("ExpireInvitation", ctx, int32(5), mock.Anything).Return(nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Test Org"}, nil)
+		emailSvc.On("SendAccountStatusNotification", ctx, "applicant@test.com", "Applicant", "Test Org", "REJECTED", "not a neighbour").Return(nil)
+
+		assert.NoError(t, svc.RejectJoinRequest(ctx, adminID, orgID, 42, "not a neighbour"))
+		joinRepo.AssertExpectations(t)
+		inviteRepo.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Joined requests cannot be rejected", func(t *testing.T) {
+		joinRepo := new(MockJoinRequestRepo)
+		svc := service.NewAdminService(joinRepo, new(MockUserRepo), nil, new(MockOrganizationRepo), new(MockInviteRepo), nil, new(MockEmailService), nil)
+
+		joinRepo.On("GetByID", ctx, int32(43)).Return(&domain.JoinRequest{ID: 43, OrgID: orgID, Status: domain.JoinRequestStatusJoined}, nil)
+
+		err := svc.RejectJoinRequest(ctx, adminID, orgID, 43, "late")
+		assert.ErrorIs(t, err, service.ErrJoinRequestProcessed)
+		joinRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_ResendInvitation(t *testing.T) {
+	ctx := context.Background()
+	adminID, orgID := int32(1), int32(10)
+	code, email := "ABC12345", "invitee@test.com"
+
+	setup := func(inv *domain.Invitation) (service.AdminService, *MockInviteRepo, *MockEmailService) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		inviteRepo := new(MockInviteRepo)
+		emailSvc := new(MockEmailService)
+		userRepo.On("GetUserOrg", ctx, adminID, orgID).Return(&domain.UserOrg{UserID: adminID, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+		userRepo.On("GetByID", ctx, adminID).Return(&domain.User{ID: adminID, Email: "admin@test.com"}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Test Org"}, nil)
+		inviteRepo.On("GetByInvitationCodeAndEmail", ctx, code, email).Return(inv, nil)
+		return service.NewAdminService(new(MockJoinRequestRepo), userRepo, nil, orgRepo, inviteRepo, nil, emailSvc, nil), inviteRepo, emailSvc
+	}
+
+	t.Run("Extends expiry and re-emails the code", func(t *testing.T) {
+		yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+		svc, inviteRepo, emailSvc := setup(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: orgID, ExpiresOn: yesterday})
+		want := time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02")
+		inviteRepo.On("Update", ctx, mock.MatchedBy(func(inv *domain.Invitation) bool {
+			return inv.ID == 5 && inv.ExpiresOn == want
+		})).Return(nil).Once()
+		emailSvc.On("SendInvitation", ctx, email, email, code, "Test Org", "admin@test.com").Return(nil).Once()
+
+		inv, err := svc.ResendInvitation(ctx, adminID, orgID, code, email, true)
+		assert.NoError(t, err)
+		assert.Equal(t, want, inv.ExpiresOn)
+		inviteRepo.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Expired invitation is not resent without extending", func(t *testing.T) {
+		yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+		svc, inviteRepo, emailSvc := setup(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: orgID, ExpiresOn: yesterday})
+
+		_, err := svc.ResendInvitation(ctx, adminID, orgID, code, email, false)
+		assert.ErrorIs(t, err, service.ErrInviteExpired)
+		inviteRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		emailSvc.AssertNotCalled(t, "SendInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Revoked invitation cannot be resent", func(t *testing.T) {
+		revokedOn := time.Now().Format("2006-01-02")
+		svc, _, _ := setup(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: orgID, RevokedOn: &revokedOn})
+
+		_, err := svc.ResendInvitation(ctx, adminID, orgID, code, email, true)
+		assert.ErrorIs(t, err, service.ErrInviteRevoked)
+	})
+
+	t.Run("Non-admin is rejected", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetUserOrg", ctx, int32(2), orgID).Return(&domain.UserOrg{UserID: 2, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil)
+		svc := service.NewAdminService(nil, userRepo, nil, nil, new(MockInviteRepo), nil, nil, nil)
+
+		_, err := svc.ResendInvitation(ctx, 2, orgID, code, email, true)
+		assert.ErrorContains(t, err, "admin privileges required")
+	})
+}
+
+func TestAdminService_RevokeInvitation(t *testing.T) {
+	ctx := context.Background()
+	adminID, orgID := int32(1), int32(10)
+	code, email := "ABC12345", "invitee@test.com"
+
+	newSvc := func(inv *domain.Invitation) (service.AdminService, *MockInviteRepo) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		inviteRepo := new(MockInviteRepo)
+		userRepo.On("GetUserOrg", ctx, adminID, orgID).Return(&domain.UserOrg{UserID: adminID, OrgID: orgID, Role: domain.UserOrgRoleSuperAdmin}, nil)
+		userRepo.On("GetByID", ctx, adminID).Return(&domain.User{ID: adminID, Email: "admin@test.com"}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Test Org"}, nil)
+		inviteRepo.On("GetByInvitationCodeAndEmail", ctx, code, email).Return(inv, nil)
+		return service.NewAdminService(nil, userRepo, nil, orgRepo, inviteRepo, nil, nil, nil), inviteRepo
+	}
+
+	t.Run("Outstanding invitation is revoked", func(t *testing.T) {
+		svc, inviteRepo := newSvc(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: orgID})
+		inviteRepo.On("Revoke", ctx, int32(5)).Return(nil).Once()
+
+		assert.NoError(t, svc.RevokeInvitation(ctx, adminID, orgID, code, email))
+		inviteRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invitation from another org is not found", func(t *testing.T) {
+		svc, inviteRepo := newSvc(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: 99})
+
+		assert.ErrorContains(t, svc.RevokeInvitation(ctx, adminID, orgID, code, email), "not found")
+		inviteRepo.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Used invitation cannot be revoked", func(t *testing.T) {
+		usedOn := time.Now().Format("2006-01-02")
+		svc, inviteRepo := newSvc(&domain.Invitation{ID: 5, InvitationCode: code, Email: email, OrgID: orgID, UsedOn: &usedOn})
+
+		assert.ErrorIs(t, svc.RevokeInvitation(ctx, adminID, orgID, code, email), service.ErrInviteUsed)
+		inviteRepo.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_BulkCreateInvitations(t *testing.T) {
+	ctx := context.Background()
+	adminID, orgID := int32(1), int32(10)
+
+	t.Run("Partial success reports a result per email", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		inviteRepo := new(MockInviteRepo)
+		emailSvc := new(MockEmailService)
+		svc := service.NewAdminService(nil, userRepo, nil, orgRepo, inviteRepo, nil, emailSvc, nil)
+
+		userRepo.On("GetUserOrg", ctx, adminID, orgID).Return(&domain.UserOrg{UserID: adminID, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+		userRepo.On("GetByID", ctx, adminID).Return(&domain.User{ID: adminID, Email: "admin@test.com"}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Grace Church"}, nil)
+		inviteRepo.On("ListOutstandingEmails", ctx, orgID,
+			[]string{"new@test.com", "member@test.com", "invited@test.com", "broken@test.com", "bounce@test.com"}).
+			Return([]string{"invited@test.com"}, nil)
+
+		userRepo.On("GetByEmail", ctx, "member@test.com").Return(&domain.User{ID: 7}, nil)
+		userRepo.On("GetUserOrg", ctx, int32(7), orgID).Return(&domain.UserOrg{UserID: 7, OrgID: orgID}, nil)
+		for _, e := range []string{"new@test.com", "broken@test.com", "bounce@test.com"} {
+			userRepo.On("GetByEmail", ctx, e).Return(nil, sql.ErrNoRows)
+		}
+
+		created := func(email, code string) {
+			inviteRepo.On("Create", ctx, mock.MatchedBy(func(inv *domain.Invitation) bool { return inv.Email == email })).
+				Run(func(args mock.Arguments) { args.Get(1).(*domain.Invitation).InvitationCode = code }).Return(nil).Once()
+		}
+		created("new@test.com", "NEW00001")
+		created("bounce@test.com", "BNC00001")
+		inviteRepo.On("Create", ctx, mock.MatchedBy(func(inv *domain.Invitation) bool { return inv.Email == "broken@test.com" })).
+			Return(assert.AnError).Once()
+		emailSvc.On("SendInvitation", ctx, "new@test.com", "new@test.com", "NEW00001", "Grace Church", "admin@test.com").Return(nil).Once()
+		emailSvc.On("SendInvitation", ctx, "bounce@test.com", "bounce@test.com", "BNC00001", "Grace Church", "admin@test.com").Return(assert.AnError).Once()
+
+		results, err := svc.BulkCreateInvitations(ctx, adminID, orgID, []string{
+			"new@test.com", " New@Test.com ", "not-an-email", "member@test.com", "invited@test.com", "broken@test.com", "bounce@test.com",
+		})
+		assert.NoError(t, err)
+		if assert.Len(t, results, 7) {
+			assert.Equal(t, domain.BulkInvitationResult{Email: "new@test.com", Status: domain.BulkInvitationStatusCreated, InvitationCode: "NEW00001"}, results[0])
+			assert.Equal(t, domain.BulkInvitationStatusSkipped, results[1].Status)
+			assert.Equal(t, "duplicate in request", results[1].Message)
+			assert.Equal(t, domain.BulkInvitationStatusFailed, results[2].Status)
+			assert.Equal(t, "not-an-email", results[2].Email)
+			assert.Equal(t, "already a member", results[3].Message)
+			assert.Equal(t, "already invited", results[4].Message)
+			assert.Equal(t, domain.BulkInvitationStatusFailed, results[5].Status)
+			assert.Empty(t, results[5].InvitationCode)
+			assert.Equal(t, domain.BulkInvitationStatusFailed, results[6].Status)
+			assert.Equal(t, "BNC00001", results[6].InvitationCode, "created invitation is reported so it can be resent")
+		}
+		inviteRepo.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Batch over the configured limit is rejected", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		inviteRepo := new(MockInviteRepo)
+		svc := service.NewAdminServiceWithOptions(nil, userRepo, nil, nil, inviteRepo, nil, nil, nil, service.AdminOptions{MaxInvitationBatch: 2})
+		userRepo.On("GetUserOrg", ctx, adminID, orgID).Return(&domain.UserOrg{UserID: adminID, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+
+		_, err := svc.BulkCreateInvitations(ctx, adminID, orgID, []string{"a@test.com", "b@test.com", "c@test.com"})
+		assert.ErrorIs(t, err, service.ErrInvitationBatchTooLarge)
+		inviteRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Non-admin is rejected", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		svc := service.NewAdminService(nil, userRepo, nil, nil, new(MockInviteRepo), nil, nil, nil)
+		userRepo.On("GetUserOrg", ctx, int32(2), orgID).Return(&domain.UserOrg{UserID: 2, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil)
+
+		_, err := svc.BulkCreateInvitations(ctx, 2, orgID, []string{"a@test.com"})
+		assert.ErrorContains(t, err, "admin privileges required")
+	})
+}
+
+func TestAdminService_GetDisputeStatistics(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	mockBillRepo := new(MockBillRepo)
+	svc := service.NewAdminService(nil, mockUserRepo, nil, nil, nil, mockBillRepo, nil, nil)
+	ctx := context.Background()
+
+	t.Run("Admin gets aggregates", func(t *testing.T) {
+		stats := &domain.DisputeStatistics{
+			OrgID:                1,
+			TotalDisputes:        3,
+			CountByStatus:        map[string]int32{"DISPUTED": 1, "ADMIN_RESOLVED": 2},
+			CountByOutcome:       map[string]int32{"CREDITOR_FAULT": 1, "GRACEFUL": 1},
+			AvgResolutionSeconds: 7200,
+			TotalPenalizedCents:  2500,
+		}
+		mockUserRepo.On("GetUserOrg", ctx, int32(10), int32(1)).Return(&domain.UserOrg{UserID: 10, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil).Once()
+		mockBillRepo.On("GetDisputeStatistics", ctx, int32(1), "2026-01", "2026-03").Return(stats, nil).Once()
+
+		res, err := svc.GetDisputeStatistics(ctx, 10, 1, "2026-01", "2026-03")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), res.TotalDisputes)
+		assert.Equal(t, int64(2500), res.TotalPenalizedCents)
+	})
+
+	t.Run("Member is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(11), int32(1)).Return(&domain.UserOrg{UserID: 11, OrgID: 1, Role: domain.UserOrgRoleMember}, nil).Once()
+
+		_, err := svc.GetDisputeStatistics(ctx, 11, 1, "", "")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "admin privileges required")
+	})
+
+	t.Run("Invalid month range", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(10), int32(1)).Return(&domain.UserOrg{UserID: 10, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil).Once()
+
+		_, err := svc.GetDisputeStatistics(ctx, 10, 1, "2026-04", "2026-01")
+		assert.Error(t, err)
+	})
+
+	mockUserRepo.AssertExpectations(t)
+	mockBillRepo.AssertExpectations(t)
+}
+
+func TestAdminService_GetOrgBalanceSheet(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	mockBillRepo := new(MockBillRepo)
+	svc := service.NewAdminService(nil, mockUserRepo, nil, nil, nil, mockBillRepo, nil, nil)
+	ctx := context.Background()
+
+	t.Run("Admin gets balance sheet", func(t *testing.T) {
+		sheet := &domain.OrgBalanceSheet{
+			OrgID:                     1,
+			MemberCount:               4,
+			MembersInCredit:           2,
+			MembersInDebt:             1,
+			TotalPositiveBalanceCents: 3000,
+			TotalNegativeBalanceCents: -3000,
+			BillsByStatus: map[string]domain.BillStatusTotal{
+				"PENDING":  {Count: 2, AmountCents: 1800},
+				"DISPUTED": {Count: 1, AmountCents: 500},
+			},
+		}
+		mockUserRepo.On("GetUserOrg", ctx, int32(10), int32(1)).Return(&domain.UserOrg{UserID: 10, OrgID: 1, Role: domain.UserOrgRoleSuperAdmin}, nil).Once()
+		mockBillRepo.On("GetBalanceSheet", ctx, int32(1)).Return(sheet, nil).Once()
+
+		res, err := svc.GetOrgBalanceSheet(ctx, 10, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3000), res.TotalPositiveBalanceCents)
+		assert.Equal(t, int32(2), res.BillsByStatus["PENDING"].Count)
+	})
+
+	t.Run("Member is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(11), int32(1)).Return(&domain.UserOrg{UserID: 11, OrgID: 1, Role: domain.UserOrgRoleMember}, nil).Once()
+
+		_, err := svc.GetOrgBalanceSheet(ctx, 11, 1)
+		assert.ErrorContains(t, err, "admin privileges required")
+	})
+
+	t.Run("Non-member is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(12), int32(1)).Return(nil, errors.New("not found")).Once()
+
+		_, err := svc.GetOrgBalanceSheet(ctx, 12, 1)
+		assert.ErrorContains(t, err, "not a member")
+	})
+
+	mockUserRepo.AssertExpectations(t)
+	mockBillRepo.AssertExpectations(t)
+}
+
+func TestAdminService_UnblockMember(t *testing.T) {
+	ctx := context.Background()
+	const orgID = int32(1)
+	const memberID = int32(5)
+	billID := int32(9)
+
+	type deps struct {
+		userRepo  *MockUserRepo
+		orgRepo   *MockOrganizationRepo
+		billRepo  *MockBillRepo
+		emailSvc  *MockEmailService
+		auditRepo *MockAdminAuditRepo
+	}
+	setup := func(adminRole domain.UserOrgRole, member *domain.UserOrg) (service.AdminService, deps) {
+		d := deps{new(MockUserRepo), new(MockOrganizationRepo), new(MockBillRepo), new(MockEmailService), new(MockAdminAuditRepo)}
+		svc := service.NewAdminService(nil, d.userRepo, nil, d.orgRepo, nil, d.billRepo, d.emailSvc, service.NewAdminAudit(d.auditRepo))
+		d.userRepo.On("GetUserOrg", ctx, int32(10), orgID).Return(&domain.UserOrg{UserID: 10, OrgID: orgID, Role: adminRole}, nil)
+		d.userRepo.On("GetUserOrg", ctx, memberID, orgID).Return(member, nil)
+		d.userRepo.On("GetByID", ctx, memberID).Return(&domain.User{ID: memberID, Name: "Member", Email: "m@test.com"}, nil)
+		d.orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Org"}, nil)
+		return svc, d
+	}
+	disputeBlocked := func() *domain.UserOrg {
+		blockedOn := "2026-01-05"
+		return &domain.UserOrg{
+			UserID: memberID, OrgID: orgID, Status: domain.UserOrgStatusActive, Role: domain.UserOrgRoleMember,
+			RentingBlocked: true, LendingBlocked: true, BlockedOn: &blockedOn,
+			BlockedReason: "Blocked due to unresolved payment dispute (both at fault)", BlockedDueToBillID: &billID,
+		}
+	}
+
+	t.Run("Clears dispute block, audits and notifies", func(t *testing.T) {
+		svc, d := setup(domain.UserOrgRoleAdmin, disputeBlocked())
+		d.billRepo.On("GetByID", ctx, billID).Return(&domain.Bill{ID: billID, OrgID: orgID, DebtorUserID: memberID, CreditorUserID: 6}, nil)
+		d.userRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
+			return !uo.RentingBlocked && !uo.LendingBlocked && uo.BlockedReason == "" &&
+				uo.BlockedOn == nil && uo.BlockedDueToBillID == nil && uo.Status == domain.UserOrgStatusActive
+		})).Return(nil)
+		d.auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+			return e.Action == domain.AdminAuditActionUnblockMember && e.TargetID == memberID && e.Details["blocked_due_to_bill_id"] == "9"
+		})).Return(nil)
+		d.emailSvc.On("SendAccountStatusNotification", ctx, "m@test.com", "Member", "Org", "ACTIVE", mock.Anything).Return(nil)
+
+		assert.NoError(t, svc.UnblockMember(ctx, 10, orgID, memberID))
+		d.userRepo.AssertExpectations(t)
+		d.auditRepo.AssertExpectations(t)
+		d.emailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Clears full account block", func(t *testing.T) {
+		svc, d := setup(domain.UserOrgRoleSuperAdmin, &domain.UserOrg{
+			UserID: memberID, OrgID: orgID, Status: domain.UserOrgStatusBlock, RentingBlocked: true, BlockedReason: "late returns",
+		})
+		d.userRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
+			return uo.Status == domain.UserOrgStatusActive && !uo.RentingBlocked && uo.BlockedReason == ""
+		})).Return(nil)
+		d.auditRepo.On("Create", ctx, mock.Anything).Return(nil)
+		d.emailSvc.On("SendAccountStatusNotification", ctx, mock.Anything, mock.Anything, mock.Anything, "ACTIVE", mock.Anything).Return(nil)
+
+		assert.NoError(t, svc.UnblockMember(ctx, 10, orgID, memberID))
+		d.billRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		d.userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Non-admin is rejected", func(t *testing.T) {
+		svc, d := setup(domain.UserOrgRoleMember, disputeBlocked())
+
+		err := svc.UnblockMember(ctx, 10, orgID, memberID)
+		assert.ErrorContains(t, err, "admin privileges required")
+		d.userRepo.AssertNotCalled(t, "UpdateUserOrg", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin involved in originating bill is rejected", func(t *testing.T) {
+		svc, d := setup(domain.UserOrgRoleAdmin, disputeBlocked())
+		d.billRepo.On("GetByID", ctx, billID).Return(&domain.Bill{ID: billID, OrgID: orgID, DebtorUserID: memberID, CreditorUserID: 10}, nil)
+
+		err := svc.UnblockMember(ctx, 10, orgID, memberID)
+		assert.ErrorContains(t, err, "involved")
+		d.userRepo.AssertNotCalled(t, "UpdateUserOrg", mock.Anything, mock.Anything)
+		d.auditRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Member without block is rejected", func(t *testing.T) {
+		svc, d := setup(domain.UserOrgRoleAdmin, &domain.UserOrg{UserID: memberID, OrgID: orgID, Status: domain.UserOrgStatusActive})
+
+		err := svc.UnblockMember(ctx, 10, orgID, memberID)
+		assert.ErrorContains(t, err, "not blocked")
+		d.userRepo.AssertNotCalled(t, "UpdateUserOrg", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_CrossOrgSuperAdmin(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func() (service.AdminService, *MockUserRepo, *MockOrganizationRepo, *MockLedgerRepo, *MockAdminAuditRepo) {
+		mockUserRepo := new(MockUserRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockLedgerRepo := new(MockLedgerRepo)
+		auditRepo := new(MockAdminAuditRepo)
+		svc := service.NewAdminServiceWithOptions(nil, mockUserRepo, mockLedgerRepo, mockOrgRepo, nil, nil, nil, service.NewAdminAudit(auditRepo),
+			service.AdminOptions{SuperAdminEmails: []string{"ops@ubertool.example"}})
+
+		mockUserRepo.On("GetByID", ctx, int32(1)).Return(&domain.User{ID: 1, Email: "Ops@Ubertool.example"}, nil)
+		mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Email: "founder@example.com"}, nil)
+		return svc, mockUserRepo, mockOrgRepo, mockLedgerRepo, auditRepo
+	}
+
+	t.Run("Super admin lists every organization", func(t *testing.T) {
+		svc, _, mockOrgRepo, _, _ := setup()
+		mockOrgRepo.On("List", ctx).Return([]domain.Organization{{ID: 7}, {ID: 8}}, nil).Once()
+
+		orgs, err := svc.ListAllOrganizations(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, orgs, 2)
+	})
+
+	t.Run("Super admin reads a member of an org they do not belong to", func(t *testing.T) {
+		svc, mockUserRepo, _, _, _ := setup()
+		mockUserRepo.On("GetByID", ctx, int32(5)).Return(&domain.User{ID: 5, Name: "Member"}, nil).Once()
+		mockUserRepo.On("GetUserOrg", ctx, int32(5), int32(8)).Return(&domain.UserOrg{UserID: 5, OrgID: 8, BalanceCents: 300}, nil).Once()
+
+		user, uo, err := svc.GetAnyMemberProfile(ctx, 1, 8, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, "Member", user.Name)
+		assert.Equal(t, int32(300), uo.BalanceCents)
+		mockUserRepo.AssertNotCalled(t, "GetUserOrg", ctx, int32(1), int32(8))
+	})
+
+	t.Run("Super admin adjusts a balance through the ledger", func(t *testing.T) {
+		svc, mockUserRepo, _, mockLedgerRepo, auditRepo := setup()
+		mockUserRepo.On("GetUserOrg", ctx, int32(5), int32(8)).Return(&domain.UserOrg{UserID: 5, OrgID: 8, BalanceCents: 300}, nil).Once()
+		mockLedgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.OrgID == 8 && tx.UserID == 5 && tx.Amount == -250 && tx.Type == domain.TransactionTypeAdjustment &&
+				tx.Description == "Balance adjustment by platform admin: duplicate rental charge"
+		})).Return(nil).Once()
+		mockUserRepo.On("GetUserOrg", ctx, int32(5), int32(8)).Return(&domain.UserOrg{UserID: 5, OrgID: 8, BalanceCents: 50}, nil).Once()
+		auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+			return e.AdminID == 1 && e.OrgID == 8 && e.Action == domain.AdminAuditActionAdjustBalance &&
+				e.TargetType == domain.AdminAuditTargetUser && e.TargetID == 5 &&
+				e.Details["amount_cents"] == "-250" && e.Details["new_balance_cents"] == "50"
+		})).Return(nil).Once()
+
+		balance, err := svc.AdjustAnyBalance(ctx, 1, 8, 5, -250, " duplicate rental charge ")
+		assert.NoError(t, err)
+		assert.Equal(t, int32(50), balance)
+		mockLedgerRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Adjustment needs an amount and a reason", func(t *testing.T) {
+		svc, _, _, mockLedgerRepo, _ := setup()
+
+		_, err := svc.AdjustAnyBalance(ctx, 1, 8, 5, 0, "nothing")
+		assert.ErrorContains(t, err, "must not be zero")
+		_, err = svc.AdjustAnyBalance(ctx, 1, 8, 5, 100, "  ")
+		assert.ErrorContains(t, err, "reason is required")
+		mockLedgerRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Org admins and org super admins are rejected", func(t *testing.T) {
+		svc, mockUserRepo, mockOrgRepo, mockLedgerRepo, _ := setup()
+		// User 2 created org 8, so holds SUPER_ADMIN there, but is not a platform operator
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(8)).Return(&domain.UserOrg{UserID: 2, OrgID: 8, Role: domain.UserOrgRoleSuperAdmin}, nil).Maybe()
+
+		_, err := svc.ListAllOrganizations(ctx, 2)
+		assert.ErrorIs(t, err, service.ErrCrossOrgSuperAdminRequired)
+		_, _, err = svc.GetAnyMemberProfile(ctx, 2, 8, 5)
+		assert.ErrorIs(t, err, service.ErrCrossOrgSuperAdminRequired)
+		_, err = svc.AdjustAnyBalance(ctx, 2, 8, 5, 100, "goodwill")
+		assert.ErrorIs(t, err, service.ErrCrossOrgSuperAdminRequired)
+
+		mockOrgRepo.AssertNotCalled(t, "List", mock.Anything)
+		mockLedgerRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown caller is rejected", func(t *testing.T) {
+		svc, mockUserRepo, _, _, _ := setup()
+		mockUserRepo.On("GetByID", ctx, int32(9)).Return(nil, sql.ErrNoRows).Once()
+
+		_, err := svc.ListAllOrganizations(ctx, 9)
+		assert.ErrorIs(t, err, service.ErrCrossOrgSuperAdminRequired)
+	})
+}
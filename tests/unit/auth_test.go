@@ -2,14 +2,18 @@ package unit
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/security"
 	"ubertool-backend-trusted/internal/service"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestAuthService_ValidateInvite(t *testing.T) {
@@ -21,7 +25,7 @@ func TestAuthService_ValidateInvite(t *testing.T) {
 	emailSvc := new(MockEmailService)
 	fcmRepo := new(MockFcmTokenRepo)
 	pendingCredsRepo := new(MockPendingCredentialsRepo)
-	svc := service.NewAuthService(userRepo, inviteRepo, reqRepo, orgRepo, noteRepo, emailSvc, "secret", fcmRepo, pendingCredsRepo)
+	svc := service.NewAuthService(userRepo, inviteRepo, reqRepo, orgRepo, noteRepo, emailSvc, security.NewTokenManager("secret"), fcmRepo, pendingCredsRepo, new(MockRevokedTokenRepo), service.AuthPolicy{})
 
 	ctx := context.Background()
 	token := "valid-token"
@@ -83,6 +87,87 @@ func TestAuthService_ValidateInvite(t *testing.T) {
 		assert.Contains(t, msg, "already used")
 		assert.Nil(t, user)
 	})
+
+	t.Run("Revoked Token", func(t *testing.T) {
+		revokedOn := time.Now().Format("2006-01-02")
+		invite := &domain.Invitation{
+			InvitationCode: token,
+			Email:          email,
+			OrgID:          1,
+			ExpiresOn:      time.Now().Add(48 * time.Hour).Format("2006-01-02"),
+			RevokedOn:      &revokedOn,
+		}
+		inviteRepo.ExpectedCalls = nil
+		inviteRepo.On("GetByInvitationCodeAndEmail", ctx, token, email).Return(invite, nil)
+
+		valid, msg, _, err := svc.ValidateInvite(ctx, token, email)
+		assert.ErrorIs(t, err, service.ErrInviteRevoked)
+		assert.False(t, valid)
+		assert.Contains(t, msg, "revoked")
+
+		err = svc.Signup(ctx, token, "New User", email, "555-0100", "password123")
+		assert.ErrorIs(t, err, service.ErrInviteRevoked)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuthService_GetInvitationInfo(t *testing.T) {
+	ctx := context.Background()
+	code, email := "invite-code", "new@example.com"
+	future := time.Now().Add(48 * time.Hour).Format("2006-01-02")
+	today := time.Now().Format("2006-01-02")
+
+	newSvc := func(inv *domain.Invitation, lookupErr error) (service.AuthService, *MockOrganizationRepo) {
+		inviteRepo, orgRepo := new(MockInviteRepo), new(MockOrganizationRepo)
+		inviteRepo.On("GetByInvitationCodeAndEmail", ctx, code, email).Return(inv, lookupErr)
+		orgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{
+			ID: 1, Name: "Maple Street", Description: "Neighbors sharing tools", Metro: "San Jose",
+			AdminEmail: "admin@example.com", AdminPhoneNumber: "555-0100",
+		}, nil)
+		svc := service.NewAuthService(new(MockUserRepo), inviteRepo, new(MockJoinRequestRepo), orgRepo, new(MockNotificationRepo), new(MockEmailService),
+			security.NewTokenManager("secret"), new(MockFcmTokenRepo), new(MockPendingCredentialsRepo), new(MockRevokedTokenRepo), service.AuthPolicy{})
+		return svc, orgRepo
+	}
+
+	t.Run("Valid invitation describes the org", func(t *testing.T) {
+		svc, _ := newSvc(&domain.Invitation{InvitationCode: code, Email: email, OrgID: 1, ExpiresOn: future}, nil)
+
+		info, err := svc.GetInvitationInfo(ctx, code, email)
+		assert.NoError(t, err)
+		assert.Equal(t, &domain.InvitationInfo{
+			OrgName: "Maple Street", OrgDescription: "Neighbors sharing tools", Metro: "San Jose", ExpiresOn: future,
+		}, info)
+	})
+
+	t.Run("Unknown code or email", func(t *testing.T) {
+		svc, orgRepo := newSvc(nil, sql.ErrNoRows)
+
+		_, err := svc.GetInvitationInfo(ctx, code, email)
+		assert.ErrorIs(t, err, service.ErrInviteInvalid)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		orgRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	cases := []struct {
+		name string
+		inv  *domain.Invitation
+		want error
+	}{
+		{"Expired", &domain.Invitation{OrgID: 1, ExpiresOn: time.Now().Add(-24 * time.Hour).Format("2006-01-02")}, service.ErrInviteExpired},
+		{"Used", &domain.Invitation{OrgID: 1, ExpiresOn: future, UsedOn: &today}, service.ErrInviteUsed},
+		{"Revoked", &domain.Invitation{OrgID: 1, ExpiresOn: future, RevokedOn: &today}, service.ErrInviteRevoked},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, orgRepo := newSvc(tc.inv, nil)
+
+			info, err := svc.GetInvitationInfo(ctx, code, email)
+			assert.Nil(t, info)
+			assert.ErrorIs(t, err, tc.want)
+			assert.ErrorIs(t, err, domain.ErrConflict)
+			orgRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestAuthService_RequestToJoin(t *testing.T) {
@@ -95,7 +180,7 @@ func TestAuthService_RequestToJoin(t *testing.T) {
 	fcmRepo := new(MockFcmTokenRepo)
 	pendingCredsRepo := new(MockPendingCredentialsRepo)
 
-	svc := service.NewAuthService(userRepo, inviteRepo, reqRepo, orgRepo, noteRepo, emailSvc, "secret", fcmRepo, pendingCredsRepo)
+	svc := service.NewAuthService(userRepo, inviteRepo, reqRepo, orgRepo, noteRepo, emailSvc, security.NewTokenManager("secret"), fcmRepo, pendingCredsRepo, new(MockRevokedTokenRepo), service.AuthPolicy{})
 
 	ctx := context.Background()
 
@@ -121,3 +206,239 @@ func TestAuthService_RequestToJoin(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestAuthService_Login_TwoFAExemption(t *testing.T) {
+	ctx := context.Background()
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	newSvc := func(userRepo *MockUserRepo, emailSvc *MockEmailService) service.AuthService {
+		return service.NewAuthService(userRepo, new(MockInviteRepo), new(MockJoinRequestRepo), new(MockOrganizationRepo),
+			new(MockNotificationRepo), emailSvc, security.NewTokenManager("secret"), new(MockFcmTokenRepo), new(MockPendingCredentialsRepo), new(MockRevokedTokenRepo),
+			service.AuthPolicy{TwoFAExempt: service.TwoFAExemptions{
+				Roles:  []domain.UserOrgRole{domain.UserOrgRoleSuperAdmin},
+				Emails: []string{"Service@Example.com"},
+			}})
+	}
+
+	t.Run("Exempt role skips 2FA", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		emailSvc := new(MockEmailService)
+		svc := newSvc(userRepo, emailSvc)
+
+		user := &domain.User{ID: 1, Email: "admin@example.com", PasswordHash: string(hash)}
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		userRepo.On("ListUserOrgs", ctx, user.ID).Return([]domain.UserOrg{
+			{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleSuperAdmin, Status: domain.UserOrgStatusActive},
+		}, nil)
+		userRepo.On("GetTokenVersion", ctx, user.ID).Return(int32(0), nil)
+
+		session, access, refresh, requires2FA, tempPwd, err := svc.Login(ctx, user.Email, "password")
+		assert.NoError(t, err)
+		assert.False(t, requires2FA)
+		assert.False(t, tempPwd)
+		assert.Empty(t, session)
+		assert.NotEmpty(t, access)
+		assert.NotEmpty(t, refresh)
+		emailSvc.AssertNotCalled(t, "SendAdminNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Exempt email skips 2FA", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		emailSvc := new(MockEmailService)
+		svc := newSvc(userRepo, emailSvc)
+
+		user := &domain.User{ID: 2, Email: "service@example.com", PasswordHash: string(hash)}
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		userRepo.On("GetTokenVersion", ctx, user.ID).Return(int32(0), nil)
+		userRepo.On("ListUserOrgs", ctx, user.ID).Return([]domain.UserOrg{}, nil)
+
+		_, access, _, requires2FA, _, err := svc.Login(ctx, user.Email, "password")
+		assert.NoError(t, err)
+		assert.False(t, requires2FA)
+		assert.NotEmpty(t, access)
+		// Memberships are only read for the token claims, not for the exemption check
+		userRepo.AssertNumberOfCalls(t, "ListUserOrgs", 1)
+	})
+
+	t.Run("Normal user still requires 2FA", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		emailSvc := new(MockEmailService)
+		svc := newSvc(userRepo, emailSvc)
+
+		user := &domain.User{ID: 3, Email: "member@example.com", PasswordHash: string(hash)}
+		userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+		userRepo.On("ListUserOrgs", ctx, user.ID).Return([]domain.UserOrg{
+			{UserID: 3, OrgID: 1, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive},
+			// Inactive memberships do not grant an exemption
+			{UserID: 3, OrgID: 2, Role: domain.UserOrgRoleSuperAdmin, Status: domain.UserOrgStatusBlock},
+		}, nil)
+		emailSvc.On("SendTwoFactorCode", ctx, user.Email, mock.Anything).Return(nil)
+
+		session, access, refresh, requires2FA, _, err := svc.Login(ctx, user.Email, "password")
+		assert.NoError(t, err)
+		assert.True(t, requires2FA)
+		assert.NotEmpty(t, session)
+		assert.Empty(t, access)
+		assert.Empty(t, refresh)
+		emailSvc.AssertExpectations(t)
+	})
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	tm := security.NewTokenManager("secret")
+
+	newSvc := func(userRepo *MockUserRepo, policy service.AuthPolicy) service.AuthService {
+		return service.NewAuthService(userRepo, new(MockInviteRepo), new(MockJoinRequestRepo), new(MockOrganizationRepo),
+			new(MockNotificationRepo), new(MockEmailService), security.NewTokenManager("secret"), new(MockFcmTokenRepo), new(MockPendingCredentialsRepo), new(MockRevokedTokenRepo), policy)
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		svc := newSvc(userRepo, service.AuthPolicy{})
+
+		token, err := tm.GenerateEmailVerificationToken(5, "new@example.com")
+		assert.NoError(t, err)
+		userRepo.On("GetByID", ctx, int32(5)).Return(&domain.User{ID: 5, Email: "new@example.com"}, nil)
+		userRepo.On("MarkEmailVerified", ctx, int32(5)).Return(nil).Once()
+
+		assert.NoError(t, svc.VerifyEmail(ctx, token))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		svc := newSvc(userRepo, service.AuthPolicy{})
+
+		claims := security.UserClaims{
+			UserID: 5,
+			Email:  "new@example.com",
+			Type:   security.TokenTypeEmailVerify,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "5",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+				IssuedAt:  jwt.NewNumericDate(time.Now().Add(-49 * time.Hour)),
+			},
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		assert.NoError(t, err)
+
+		err = svc.VerifyEmail(ctx, token)
+		assert.ErrorIs(t, err, service.ErrVerificationExpired)
+		userRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Wrong token type", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		svc := newSvc(userRepo, service.AuthPolicy{})
+
+		token, err := tm.GenerateAccessToken(5, "new@example.com", []string{"user"}, nil)
+		assert.NoError(t, err)
+
+		assert.ErrorIs(t, svc.VerifyEmail(ctx, token), service.ErrInvalidToken)
+		userRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Login blocked until verified", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		svc := newSvc(userRepo, service.AuthPolicy{RequireEmailVerification: true})
+
+		hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+		assert.NoError(t, err)
+		userRepo.On("GetByEmail", ctx, "new@example.com").Return(&domain.User{
+			ID: 5, Email: "new@example.com", PasswordHash: string(hash), EmailVerified: false,
+		}, nil)
+
+		_, _, _, _, _, err = svc.Login(ctx, "new@example.com", "password")
+		assert.ErrorIs(t, err, service.ErrEmailNotVerified)
+	})
+}
+
+func TestAuthService_RefreshTokenRevocation(t *testing.T) {
+	ctx := context.Background()
+	tm := security.NewTokenManager("secret")
+
+	newSvc := func(userRepo *MockUserRepo, revokedRepo *MockRevokedTokenRepo) service.AuthService {
+		return service.NewAuthService(userRepo, new(MockInviteRepo), new(MockJoinRequestRepo), new(MockOrganizationRepo),
+			new(MockNotificationRepo), new(MockEmailService), security.NewTokenManager("secret"), new(MockFcmTokenRepo), new(MockPendingCredentialsRepo),
+			revokedRepo, service.AuthPolicy{})
+	}
+
+	t.Run("Valid token refreshes", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		revokedRepo := new(MockRevokedTokenRepo)
+		svc := newSvc(userRepo, revokedRepo)
+
+		refresh, err := tm.GenerateRefreshToken(7, "user@example.com", 2)
+		assert.NoError(t, err)
+		revokedRepo.On("IsRevoked", ctx, mock.AnythingOfType("string")).Return(false, nil)
+		userRepo.On("GetTokenVersion", ctx, int32(7)).Return(int32(2), nil)
+		userRepo.On("ListUserOrgs", ctx, int32(7)).Return([]domain.UserOrg{
+			{UserID: 7, OrgID: 4, Role: domain.UserOrgRoleAdmin, Status: domain.UserOrgStatusActive},
+			{UserID: 7, OrgID: 5, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusBlock},
+		}, nil)
+
+		access, newRefresh, err := svc.RefreshToken(ctx, refresh)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, access)
+
+		claims, err := tm.ValidateToken(newRefresh)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), claims.TokenVersion)
+
+		// The new access token carries a fresh snapshot of the active memberships only
+		claims, err = tm.ValidateToken(access)
+		assert.NoError(t, err)
+		assert.Equal(t, []security.OrgMembership{{OrgID: 4, Role: "ADMIN"}}, claims.Orgs)
+	})
+
+	t.Run("Logged-out token cannot refresh", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		revokedRepo := new(MockRevokedTokenRepo)
+		svc := newSvc(userRepo, revokedRepo)
+
+		refresh, err := tm.GenerateRefreshToken(7, "user@example.com", 0)
+		assert.NoError(t, err)
+		claims, err := tm.ValidateToken(refresh)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, claims.ID)
+
+		revokedRepo.On("Revoke", ctx, claims.ID, int32(7), claims.ExpiresAt.Time).Return(nil).Once()
+		assert.NoError(t, svc.Logout(ctx, 7, refresh, ""))
+
+		revokedRepo.On("IsRevoked", ctx, claims.ID).Return(true, nil)
+		_, _, err = svc.RefreshToken(ctx, refresh)
+		assert.ErrorIs(t, err, service.ErrTokenRevoked)
+		revokedRepo.AssertExpectations(t)
+	})
+
+	t.Run("Logout ignores another user's token", func(t *testing.T) {
+		revokedRepo := new(MockRevokedTokenRepo)
+		svc := newSvc(new(MockUserRepo), revokedRepo)
+
+		refresh, err := tm.GenerateRefreshToken(8, "other@example.com", 0)
+		assert.NoError(t, err)
+
+		assert.NoError(t, svc.Logout(ctx, 7, refresh, ""))
+		revokedRepo.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RevokeAllSessions invalidates older tokens", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		revokedRepo := new(MockRevokedTokenRepo)
+		svc := newSvc(userRepo, revokedRepo)
+
+		refresh, err := tm.GenerateRefreshToken(7, "user@example.com", 0)
+		assert.NoError(t, err)
+
+		userRepo.On("IncrementTokenVersion", ctx, int32(7)).Return(int32(1), nil).Once()
+		assert.NoError(t, svc.RevokeAllSessions(ctx, 7))
+
+		revokedRepo.On("IsRevoked", ctx, mock.AnythingOfType("string")).Return(false, nil)
+		userRepo.On("GetTokenVersion", ctx, int32(7)).Return(int32(1), nil)
+		_, _, err = svc.RefreshToken(ctx, refresh)
+		assert.ErrorIs(t, err, service.ErrTokenRevoked)
+		userRepo.AssertExpectations(t)
+	})
+}
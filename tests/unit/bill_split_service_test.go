@@ -7,10 +7,12 @@ import (
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository"
 	"ubertool-backend-trusted/internal/service"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // TestBillSplitService_GetGlobalBillSplitSummary verification of bill summary aggregation.
@@ -22,7 +24,7 @@ import (
 func TestBillSplitService_GetGlobalBillSplitSummary(t *testing.T) {
 	mockBillRepo := new(MockBillRepo)
 	mockUserRepo := new(MockUserRepo)
-	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -32,19 +34,19 @@ func TestBillSplitService_GetGlobalBillSplitSummary(t *testing.T) {
 
 		// Mock ListByUser for org 1 - returns various bills for counting
 		now := time.Now()
-		mockBillRepo.On("ListByUser", ctx, int32(1), int32(1), []domain.BillStatus(nil)).
+		mockBillRepo.On("ListByUser", ctx, int32(1), int32(1), []domain.BillStatus(nil), int32(0), int32(0)).
 			Return([]domain.Bill{
 				{ID: 1, DebtorUserID: 1, Status: domain.BillStatusPending, DebtorAcknowledgedAt: nil},    // Payment to make
 				{ID: 2, CreditorUserID: 1, Status: domain.BillStatusPending, DebtorAcknowledgedAt: &now}, // Receipt to verify
 				{ID: 3, DebtorUserID: 1, Status: domain.BillStatusDisputed},                              // Payment in dispute
 				{ID: 4, CreditorUserID: 1, Status: domain.BillStatusDisputed},                            // Receipt in dispute
-			}, nil).Once()
+			}, int32(4), nil).Once()
 
 		// Mock ListByUser for org 2 - returns one bill
-		mockBillRepo.On("ListByUser", ctx, int32(1), int32(2), []domain.BillStatus(nil)).
+		mockBillRepo.On("ListByUser", ctx, int32(1), int32(2), []domain.BillStatus(nil), int32(0), int32(0)).
 			Return([]domain.Bill{
 				{ID: 5, DebtorUserID: 1, Status: domain.BillStatusPending, DebtorAcknowledgedAt: nil}, // Payment to make
-			}, nil).Once()
+			}, int32(1), nil).Once()
 
 		paymentsToMake, receiptsToVerify, paymentsInDispute, receiptsInDispute, err := svc.GetGlobalBillSplitSummary(ctx, 1)
 		assert.NoError(t, err)
@@ -74,7 +76,7 @@ func TestBillSplitService_GetGlobalBillSplitSummary(t *testing.T) {
 func TestBillSplitService_ListPayments(t *testing.T) {
 	mockBillRepo := new(MockBillRepo)
 	mockUserRepo := new(MockUserRepo)
-	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("Success_ShowHistory", func(t *testing.T) {
@@ -87,11 +89,13 @@ func TestBillSplitService_ListPayments(t *testing.T) {
 			domain.BillStatusPaid,
 			domain.BillStatusAdminResolved,
 			domain.BillStatusSystemDefaultAction,
-		}).Return([]domain.Bill{{ID: 1}, {ID: 2}}, nil).Once()
+			domain.BillStatusVoided,
+		}, int32(1), int32(50)).Return([]domain.Bill{{ID: 1}, {ID: 2}}, int32(2), nil).Once()
 
-		bills, err := svc.ListPayments(ctx, 1, 1, true)
+		bills, total, err := svc.ListPayments(ctx, 1, 1, true, 1, 50)
 		assert.NoError(t, err)
 		assert.Equal(t, 2, len(bills))
+		assert.Equal(t, int32(2), total)
 		mockBillRepo.AssertExpectations(t)
 		mockUserRepo.AssertExpectations(t)
 	})
@@ -103,11 +107,12 @@ func TestBillSplitService_ListPayments(t *testing.T) {
 		mockBillRepo.On("ListByUser", ctx, int32(1), int32(1), []domain.BillStatus{
 			domain.BillStatusPending,
 			domain.BillStatusDisputed,
-		}).Return([]domain.Bill{{ID: 1}}, nil).Once()
+		}, int32(1), int32(50)).Return([]domain.Bill{{ID: 1}}, int32(1), nil).Once()
 
-		bills, err := svc.ListPayments(ctx, 1, 1, false)
+		bills, total, err := svc.ListPayments(ctx, 1, 1, false, 1, 50)
 		assert.NoError(t, err)
 		assert.Equal(t, 1, len(bills))
+		assert.Equal(t, int32(1), total)
 		mockBillRepo.AssertExpectations(t)
 		mockUserRepo.AssertExpectations(t)
 	})
@@ -116,7 +121,7 @@ func TestBillSplitService_ListPayments(t *testing.T) {
 		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).
 			Return((*domain.UserOrg)(nil), errors.New("not found")).Once()
 
-		_, err := svc.ListPayments(ctx, 1, 1, true)
+		_, _, err := svc.ListPayments(ctx, 1, 1, true, 1, 50)
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "not a member")
 		mockUserRepo.AssertExpectations(t)
@@ -134,7 +139,7 @@ func TestBillSplitService_ListPayments(t *testing.T) {
 func TestBillSplitService_GetPaymentDetail(t *testing.T) {
 	mockBillRepo := new(MockBillRepo)
 	mockUserRepo := new(MockUserRepo)
-	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("Success_AsDebtor", func(t *testing.T) {
@@ -142,12 +147,13 @@ func TestBillSplitService_GetPaymentDetail(t *testing.T) {
 		actions := []domain.BillAction{{ID: 1, BillID: 1, ActionType: domain.BillActionTypeNoticeSent}}
 
 		mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil).Once()
-		mockBillRepo.On("ListActionsByBill", ctx, int32(1)).Return(actions, nil).Once()
+		mockBillRepo.On("ListActionsByBill", ctx, int32(1), domain.BillActionFilter{}).Return(actions, int32(1), nil).Once()
 
-		retBill, retActions, canAcknowledge, err := svc.GetPaymentDetail(ctx, 1, 1)
+		retBill, retActions, total, canAcknowledge, err := svc.GetPaymentDetail(ctx, 1, 1, domain.BillActionFilter{})
 		assert.NoError(t, err)
 		assert.NotNil(t, retBill)
 		assert.Equal(t, 1, len(retActions))
+		assert.Equal(t, int32(1), total)
 		assert.True(t, canAcknowledge) // Debtor can acknowledge when DebtorAcknowledgedAt is nil
 		mockBillRepo.AssertExpectations(t)
 	})
@@ -158,12 +164,13 @@ func TestBillSplitService_GetPaymentDetail(t *testing.T) {
 		actions := []domain.BillAction{{ID: 1, BillID: 1, ActionType: domain.BillActionTypeDebtorAcknowledged}}
 
 		mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil).Once()
-		mockBillRepo.On("ListActionsByBill", ctx, int32(1)).Return(actions, nil).Once()
+		mockBillRepo.On("ListActionsByBill", ctx, int32(1), domain.BillActionFilter{}).Return(actions, int32(1), nil).Once()
 
-		retBill, retActions, canAcknowledge, err := svc.GetPaymentDetail(ctx, 1, 1)
+		retBill, retActions, total, canAcknowledge, err := svc.GetPaymentDetail(ctx, 1, 1, domain.BillActionFilter{})
 		assert.NoError(t, err)
 		assert.NotNil(t, retBill)
 		assert.Equal(t, 1, len(retActions))
+		assert.Equal(t, int32(1), total)
 		assert.True(t, canAcknowledge) // Creditor can acknowledge when debtor has acknowledged
 		mockBillRepo.AssertExpectations(t)
 	})
@@ -174,7 +181,7 @@ func TestBillSplitService_GetPaymentDetail(t *testing.T) {
 		mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil).Once()
 		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return((*domain.UserOrg)(nil), errors.New("not found")).Once()
 
-		_, _, _, err := svc.GetPaymentDetail(ctx, 1, 1)
+		_, _, _, _, err := svc.GetPaymentDetail(ctx, 1, 1, domain.BillActionFilter{})
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unauthorized")
 		mockBillRepo.AssertExpectations(t)
@@ -184,7 +191,7 @@ func TestBillSplitService_GetPaymentDetail(t *testing.T) {
 	t.Run("Error_BillNotFound", func(t *testing.T) {
 		mockBillRepo.On("GetByID", ctx, int32(1)).Return((*domain.Bill)(nil), errors.New("not found")).Once()
 
-		_, _, _, err := svc.GetPaymentDetail(ctx, 1, 1)
+		_, _, _, _, err := svc.GetPaymentDetail(ctx, 1, 1, domain.BillActionFilter{})
 		assert.Error(t, err)
 		mockBillRepo.AssertExpectations(t)
 	})
@@ -201,7 +208,7 @@ func TestBillSplitService_ListDisputedPayments(t *testing.T) {
 	mockBillRepo := new(MockBillRepo)
 	mockUserRepo := new(MockUserRepo)
 	mockOrgRepo := new(MockOrganizationRepo)
-	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockOrgRepo, nil, nil)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -237,7 +244,7 @@ func TestBillSplitService_ListResolvedDisputes(t *testing.T) {
 	mockBillRepo := new(MockBillRepo)
 	mockUserRepo := new(MockUserRepo)
 	mockOrgRepo := new(MockOrganizationRepo)
-	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockOrgRepo, nil, nil)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -258,6 +265,90 @@ func TestBillSplitService_ListResolvedDisputes(t *testing.T) {
 	})
 }
 
+// TestBillSplitService_GetDisputeDetail verifies the admin view of a single dispute.
+// Goal: Verify that an uninvolved admin gets the bill, its whole action history across pages
+// and both parties' profiles, and that members, involved admins and undisputed bills are refused.
+func TestBillSplitService_GetDisputeDetail(t *testing.T) {
+	ctx := context.Background()
+	disputedAt := time.Now().Add(-24 * time.Hour)
+	setup := func(bill *domain.Bill) (service.BillSplitService, *MockBillRepo, *MockUserRepo) {
+		mockBillRepo := new(MockBillRepo)
+		mockUserRepo := new(MockUserRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, new(MockOrganizationRepo), nil, nil, nil)
+		mockBillRepo.On("GetByID", ctx, int32(9)).Return(bill, nil)
+		return svc, mockBillRepo, mockUserRepo
+	}
+	disputedBill := func() *domain.Bill {
+		return &domain.Bill{
+			ID: 9, OrgID: 1, DebtorUserID: 2, CreditorUserID: 3, AmountCents: 1500,
+			Status: domain.BillStatusDisputed, DisputedAt: &disputedAt,
+			DisputeReason: string(domain.DisputeReasonCreditorNoAck),
+		}
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		svc, mockBillRepo, mockUserRepo := setup(disputedBill())
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+		mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Name: "Debtor"}, nil)
+		mockUserRepo.On("GetByID", ctx, int32(3)).Return(&domain.User{ID: 3, Name: "Creditor"}, nil)
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(&domain.UserOrg{UserID: 2, OrgID: 1, BalanceCents: -1500, RentingBlocked: true}, nil)
+		mockUserRepo.On("GetUserOrg", ctx, int32(3), int32(1)).Return(&domain.UserOrg{UserID: 3, OrgID: 1, BalanceCents: 1500}, nil)
+
+		// A history longer than one page is read in full
+		firstPage := make([]domain.BillAction, 100)
+		for i := range firstPage {
+			firstPage[i] = domain.BillAction{ID: int32(i + 1), BillID: 9, ActionType: domain.BillActionTypeAdminComment}
+		}
+		debtorID := int32(2)
+		lastPage := []domain.BillAction{{ID: 101, BillID: 9, ActorUserID: &debtorID, ActionType: domain.BillActionTypeDisputeOpened}}
+		mockBillRepo.On("ListActionsByBill", ctx, int32(9), domain.BillActionFilter{Page: 1, PageSize: 100}).Return(firstPage, int32(101), nil).Once()
+		mockBillRepo.On("ListActionsByBill", ctx, int32(9), domain.BillActionFilter{Page: 2, PageSize: 100}).Return(lastPage, int32(101), nil).Once()
+
+		detail, err := svc.GetDisputeDetail(ctx, 1, 9)
+		require.NoError(t, err)
+		assert.Equal(t, int32(9), detail.Bill.ID)
+		assert.Equal(t, string(domain.DisputeReasonCreditorNoAck), detail.Bill.DisputeReason)
+		require.Len(t, detail.Actions, 101)
+		assert.Equal(t, int32(1), detail.Actions[0].ID)
+		assert.Equal(t, domain.BillActionTypeDisputeOpened, detail.Actions[100].ActionType)
+		assert.Equal(t, "Debtor", detail.Debtor.User.Name)
+		assert.Equal(t, int32(-1500), detail.Debtor.Membership.BalanceCents)
+		assert.True(t, detail.Debtor.Membership.RentingBlocked)
+		assert.Equal(t, "Creditor", detail.Creditor.User.Name)
+		assert.Equal(t, int32(1500), detail.Creditor.Membership.BalanceCents)
+		mockBillRepo.AssertExpectations(t)
+	})
+
+	t.Run("Members are refused", func(t *testing.T) {
+		svc, mockBillRepo, mockUserRepo := setup(disputedBill())
+		mockUserRepo.On("GetUserOrg", ctx, int32(4), int32(1)).Return(&domain.UserOrg{UserID: 4, OrgID: 1, Role: domain.UserOrgRoleMember}, nil)
+
+		_, err := svc.GetDisputeDetail(ctx, 4, 9)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockBillRepo.AssertNotCalled(t, "ListActionsByBill", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admins involved in the bill are refused", func(t *testing.T) {
+		svc, mockBillRepo, mockUserRepo := setup(disputedBill())
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(&domain.UserOrg{UserID: 2, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+
+		_, err := svc.GetDisputeDetail(ctx, 2, 9)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockBillRepo.AssertNotCalled(t, "ListActionsByBill", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Undisputed bills have no dispute detail", func(t *testing.T) {
+		bill := disputedBill()
+		bill.Status = domain.BillStatusPending
+		bill.DisputedAt = nil
+		svc, _, mockUserRepo := setup(bill)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+
+		_, err := svc.GetDisputeDetail(ctx, 1, 9)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+}
+
 // TestBillSplitService_ResolveDispute verifies the complex logic of dispute resolution.
 // Goal: Verify that when an admin resolves a dispute finding the Debtor at fault:
 // 1. Bill status is updated to ADMIN_RESOLVED.
@@ -266,19 +357,20 @@ func TestBillSplitService_ListResolvedDisputes(t *testing.T) {
 // 4. Notifications are sent to both parties.
 func TestBillSplitService_ResolveDispute(t *testing.T) {
 	// Helper to setup fresh mocks for each subtest
-	setup := func() (service.BillSplitService, *MockBillRepo, *MockUserRepo, *MockOrganizationRepo, *MockNotificationRepo, *MockEmailService) {
+	setup := func() (service.BillSplitService, *MockBillRepo, *MockUserRepo, *MockLedgerRepo, *MockOrganizationRepo, *MockNotificationRepo, *MockEmailService) {
 		mockBillRepo := new(MockBillRepo)
 		mockUserRepo := new(MockUserRepo)
+		mockLedgerRepo := new(MockLedgerRepo)
 		mockOrgRepo := new(MockOrganizationRepo)
 		mockNotifRepo := new(MockNotificationRepo)
 		mockEmailSvc := new(MockEmailService)
-		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc)
-		return svc, mockBillRepo, mockUserRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc, nil)
+		return svc, mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc
 	}
 	ctx := context.Background()
 
 	t.Run("Success_DebtorFault", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
+		svc, mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
 
 		bill := &domain.Bill{
 			ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
@@ -300,21 +392,23 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 		
 		// updateBalances expectations (enforce payment)
 		mockUserRepo.On("GetUserOrg", ctx, int32(3), int32(1)).Return(creditorUO, nil).Once() // Creditor
-		mockUserRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
-			return uo.UserID == 3 && uo.BalanceCents == 1500 // 500 + 1000
+		mockLedgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.UserID == 3 && tx.Amount == 1000 && tx.Type == domain.TransactionTypeBillSettlement
 		})).Return(nil).Once()
-		
+		mockLedgerRepo.On("GetBalance", ctx, int32(3), int32(1)).Return(int32(1500), nil).Once() // 500 + 1000
+
 		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(debtorUO, nil).Twice() // Once for balance, once for blocking
 
-		// Update Debtor Balance
-		mockUserRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
-			// First update: Balance changed to -500 (500-1000)
-			return uo.UserID == 2 && uo.BalanceCents == -500 && !uo.RentingBlocked
+		// Debtor is debited through the ledger: 500 - 1000
+		mockLedgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.UserID == 2 && tx.Amount == -1000 && tx.Type == domain.TransactionTypeBillSettlement
 		})).Return(nil).Once()
+		mockLedgerRepo.On("GetBalance", ctx, int32(2), int32(1)).Return(int32(-500), nil).Once()
 		
 		// Update bill
 		mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
-			return b.Status == domain.BillStatusAdminResolved && b.ResolutionOutcome == string(domain.ResolutionOutcomeDebtorFault)
+			return b.Status == domain.BillStatusAdminResolved && b.ResolutionOutcome == string(domain.ResolutionOutcomeDebtorFault) &&
+				b.ResolutionNotes == "Resolved by admin - debtor at fault, payment enforced and debtor blocked from renting. Admin notes: Admin resolved: Debtor blocked from renting due to fault"
 		})).Return(nil).Once()
 
 		// Create action
@@ -330,20 +424,25 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 
 		// Notifications
 		mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil).Times(2)
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", "Debtor", int32(1000), "DEBTOR_FAULT", "Admin resolved: Debtor blocked from renting due to fault", "Test Org").Return(nil).Once()
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", "Creditor", int32(1000), "DEBTOR_FAULT", "Admin resolved: Debtor blocked from renting due to fault", "Test Org").Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Debtor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "DEBTOR_FAULT", Notes: "Admin resolved: Debtor blocked from renting due to fault", OrgName: "Test Org",
+		}).Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Creditor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "DEBTOR_FAULT", Notes: "Admin resolved: Debtor blocked from renting due to fault", OrgName: "Test Org",
+		}).Return(nil).Once()
 
 		err := svc.ResolveDispute(ctx, 1, 1, "DEBTOR_FAULT", "Admin resolved: Debtor blocked from renting due to fault")
 		assert.NoError(t, err)
 		mockBillRepo.AssertExpectations(t)
 		mockUserRepo.AssertExpectations(t)
+		mockLedgerRepo.AssertExpectations(t)
 		mockOrgRepo.AssertExpectations(t)
 		mockNotifRepo.AssertExpectations(t)
 		mockEmailSvc.AssertExpectations(t)
 	})
 
 	t.Run("Success_CreditorFault", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
+		svc, mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
 
 		bill := &domain.Bill{
 			ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
@@ -372,26 +471,35 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 
 		// Creditor at fault: Penalty applied (Balance reduced), and Lending blocked.
 		// Balance check: Creditor started at -500. Penalty -1000. New Balance -1500.
+		mockLedgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.UserID == 3 && tx.Amount == -1000 && tx.Type == domain.TransactionTypeBillPenalty
+		})).Return(nil).Once()
+		mockLedgerRepo.On("GetBalance", ctx, int32(3), int32(1)).Return(int32(-1500), nil).Once()
 		blockDueTo := int32(1)
 		mockUserRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
 			return uo.UserID == 3 && uo.BalanceCents == -1500 && uo.LendingBlocked == true && uo.BlockedDueToBillID != nil && *uo.BlockedDueToBillID == blockDueTo
 		})).Return(nil).Once()
 
 		mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil).Times(2)
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", "Debtor", int32(1000), "CREDITOR_FAULT", "Admin resolved: Creditor at fault, payment marked valid", "Test Org").Return(nil).Once()
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", "Creditor", int32(1000), "CREDITOR_FAULT", "Admin resolved: Creditor at fault, payment marked valid", "Test Org").Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Debtor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "CREDITOR_FAULT", Notes: "Admin resolved: Creditor at fault, payment marked valid", OrgName: "Test Org",
+		}).Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Creditor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "CREDITOR_FAULT", Notes: "Admin resolved: Creditor at fault, payment marked valid", OrgName: "Test Org",
+		}).Return(nil).Once()
 
 		err := svc.ResolveDispute(ctx, 1, 1, "CREDITOR_FAULT", "Admin resolved: Creditor at fault, payment marked valid")
 		assert.NoError(t, err)
 		mockBillRepo.AssertExpectations(t)
 		mockUserRepo.AssertExpectations(t)
+		mockLedgerRepo.AssertExpectations(t)
 		mockOrgRepo.AssertExpectations(t)
 		mockNotifRepo.AssertExpectations(t)
 		mockEmailSvc.AssertExpectations(t)
 	})
 
 	t.Run("Success_BothFault", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
+		svc, mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc := setup()
 
 		bill := &domain.Bill{
 			ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
@@ -423,6 +531,11 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 
 		blockDueTo := int32(1)
 		// Both blocked and penalized
+		mockLedgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Amount == -1000 && tx.Type == domain.TransactionTypeBillPenalty
+		})).Return(nil).Twice()
+		mockLedgerRepo.On("GetBalance", ctx, int32(2), int32(1)).Return(int32(-500), nil).Once()
+		mockLedgerRepo.On("GetBalance", ctx, int32(3), int32(1)).Return(int32(-1500), nil).Once()
 		// Debtor: Balance 500 -> -500. Blocked.
 		mockUserRepo.On("UpdateUserOrg", ctx, mock.MatchedBy(func(uo *domain.UserOrg) bool {
 			return uo.UserID == 2 && uo.BalanceCents == -500 && uo.RentingBlocked == true && uo.BlockedDueToBillID != nil && *uo.BlockedDueToBillID == blockDueTo
@@ -434,20 +547,25 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 		})).Return(nil).Once()
 
 		mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil).Times(2)
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", "Debtor", int32(1000), "BOTH_FAULT", "Admin resolved: Both parties blocked from renting/lending", "Test Org").Return(nil).Once()
-		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", "Creditor", int32(1000), "BOTH_FAULT", "Admin resolved: Both parties blocked from renting/lending", "Test Org").Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "debtor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Debtor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "BOTH_FAULT", Notes: "Admin resolved: Both parties blocked from renting/lending", OrgName: "Test Org",
+		}).Return(nil).Once()
+		mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, "creditor@test.com", service.BillDisputeResolutionEmail{
+			Name: "Creditor", AmountCents: 1000, CurrencyCode: "USD", Resolution: "BOTH_FAULT", Notes: "Admin resolved: Both parties blocked from renting/lending", OrgName: "Test Org",
+		}).Return(nil).Once()
 
 		err := svc.ResolveDispute(ctx, 1, 1, "BOTH_FAULT", "Admin resolved: Both parties blocked from renting/lending")
 		assert.NoError(t, err)
 		mockBillRepo.AssertExpectations(t)
 		mockUserRepo.AssertExpectations(t)
+		mockLedgerRepo.AssertExpectations(t)
 		mockOrgRepo.AssertExpectations(t)
 		mockNotifRepo.AssertExpectations(t)
 		mockEmailSvc.AssertExpectations(t)
 	})
 
 	t.Run("Error_NotDisputed", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, _, _ := setup()
+		svc, mockBillRepo, mockUserRepo, _, mockOrgRepo, _, _ := setup()
 		
 		bill := &domain.Bill{ID: 1, Status: domain.BillStatusPaid, OrgID: 1}
 		org := &domain.Organization{ID: 1}
@@ -465,7 +583,7 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 	})
 
 	t.Run("Error_AdminInvolved", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, _, _ := setup()
+		svc, mockBillRepo, mockUserRepo, _, mockOrgRepo, _, _ := setup()
 
 		bill := &domain.Bill{ID: 1, DebtorUserID: 1, CreditorUserID: 3, OrgID: 1, Status: domain.BillStatusDisputed}
 		org := &domain.Organization{ID: 1}
@@ -483,7 +601,7 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 	})
 
 	t.Run("Error_InvalidResolution", func(t *testing.T) {
-		svc, mockBillRepo, mockUserRepo, mockOrgRepo, _, _ := setup()
+		svc, mockBillRepo, mockUserRepo, _, mockOrgRepo, _, _ := setup()
 
 		bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, Status: domain.BillStatusDisputed}
 		org := &domain.Organization{ID: 1}
@@ -500,3 +618,218 @@ func TestBillSplitService_ResolveDispute(t *testing.T) {
 		mockUserRepo.AssertExpectations(t)
 	})
 }
+
+// TestBillSplitService_ResolveDispute_Notes verifies that the admin's explanation is stored in
+// resolution_notes after the system note and passed unchanged to the bill action and emails.
+func TestBillSplitService_ResolveDispute_Notes(t *testing.T) {
+	ctx := context.Background()
+	const systemNote = "Resolved by admin - settled gracefully, payment enforced"
+
+	tests := []struct {
+		name       string
+		adminNotes string
+		wantStored string
+		wantAction string
+	}{
+		{"Admin notes follow the system note", "  Paid in cash at the meeting \n",
+			systemNote + ". Admin notes: Paid in cash at the meeting", "Paid in cash at the meeting"},
+		{"Blank notes keep only the system note", "   ", systemNote, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockBillRepo := new(MockBillRepo)
+			mockUserRepo := new(MockUserRepo)
+			mockLedgerRepo := new(MockLedgerRepo)
+			mockOrgRepo := new(MockOrganizationRepo)
+			mockNotifRepo := new(MockNotificationRepo)
+			mockEmailSvc := new(MockEmailService)
+			svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc, nil)
+
+			bill := &domain.Bill{ID: 4, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, AmountCents: 800, Status: domain.BillStatusDisputed}
+			mockBillRepo.On("GetByID", ctx, int32(4)).Return(bill, nil)
+			mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+			mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).Return(&domain.UserOrg{UserID: 2, OrgID: 1}, nil)
+			mockUserRepo.On("GetUserOrg", ctx, int32(3), int32(1)).Return(&domain.UserOrg{UserID: 3, OrgID: 1}, nil)
+			mockUserRepo.On("UpdateUserOrg", ctx, mock.Anything).Return(nil)
+			mockLedgerRepo.On("CreateTransaction", ctx, mock.Anything).Return(nil)
+			mockLedgerRepo.On("GetBalance", ctx, mock.Anything, int32(1)).Return(int32(0), nil)
+			mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Org"}, nil)
+			mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Email: "d@test.com"}, nil)
+			mockUserRepo.On("GetByID", ctx, int32(3)).Return(&domain.User{ID: 3, Email: "c@test.com"}, nil)
+			mockNotifRepo.On("Dispatch", ctx, mock.Anything).Return(nil)
+
+			mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
+				return b.ResolutionNotes == tt.wantStored
+			})).Return(nil).Once()
+			mockBillRepo.On("CreateAction", ctx, mock.MatchedBy(func(a *domain.BillAction) bool {
+				return a.ActionType == domain.BillActionTypeAdminResolution && a.Notes == tt.wantAction
+			})).Return(nil).Once()
+			mockEmailSvc.On("SendBillDisputeResolutionNotification", ctx, mock.Anything, mock.MatchedBy(func(e service.BillDisputeResolutionEmail) bool {
+				return e.Notes == tt.wantAction
+			})).Return(nil).Twice()
+
+			err := svc.ResolveDispute(ctx, 1, 4, string(domain.ResolutionOutcomeGraceful), tt.adminNotes)
+			assert.NoError(t, err)
+			mockBillRepo.AssertExpectations(t)
+			mockEmailSvc.AssertExpectations(t)
+		})
+	}
+}
+
+// TestBillSplitService_ResolveDispute_StaleVersion verifies that a resolution racing with
+// another write is rejected before any balances are touched.
+func TestBillSplitService_ResolveDispute_StaleVersion(t *testing.T) {
+	mockBillRepo := new(MockBillRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockOrgRepo := new(MockOrganizationRepo)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, new(MockNotificationRepo), new(MockEmailService), nil)
+	ctx := context.Background()
+
+	bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, AmountCents: 1000, Status: domain.BillStatusDisputed, Version: 2}
+	mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil).Once()
+	mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1}, nil).Once()
+	mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil).Once()
+	mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.Version == 2
+	})).Return(repository.ErrBillVersionConflict).Once()
+
+	err := svc.ResolveDispute(ctx, 1, 1, "DEBTOR_FAULT", "late resolution")
+	assert.ErrorIs(t, err, repository.ErrBillVersionConflict)
+	mockUserRepo.AssertNotCalled(t, "UpdateUserOrg", mock.Anything, mock.Anything)
+	mockBillRepo.AssertNotCalled(t, "CreateAction", mock.Anything, mock.Anything)
+	mockBillRepo.AssertExpectations(t)
+}
+
+// TestBillSplitService_AcknowledgePayment_StaleVersion verifies that an acknowledgment racing
+// with another write (here the job disputing the bill) is reported as a conflict and nothing
+// else is recorded for it.
+func TestBillSplitService_AcknowledgePayment_StaleVersion(t *testing.T) {
+	mockBillRepo := new(MockBillRepo)
+	mockUserRepo := new(MockUserRepo)
+	mockNoteSvc := new(MockNotificationRepo)
+	mockEmailSvc := new(MockEmailService)
+	svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, new(MockOrganizationRepo), mockNoteSvc, mockEmailSvc, nil)
+	ctx := context.Background()
+
+	bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, AmountCents: 1000, Status: domain.BillStatusPending, Version: 4}
+	mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil).Once()
+	mockUserRepo.On("GetByID", ctx, int32(2)).Return(&domain.User{ID: 2, Name: "Debtor"}, nil).Once()
+	mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
+		return b.Version == 4 && b.DebtorAcknowledgedAt != nil
+	})).Return(repository.ErrBillVersionConflict).Once()
+
+	err := svc.AcknowledgePayment(ctx, 2, 1)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.ErrorIs(t, err, repository.ErrBillVersionConflict)
+	mockBillRepo.AssertNotCalled(t, "CreateAction", mock.Anything, mock.Anything)
+	mockNoteSvc.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+	mockEmailSvc.AssertNotCalled(t, "SendBillPaymentAcknowledgment", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockBillRepo.AssertExpectations(t)
+}
+
+func TestBillSplitService_VoidBill(t *testing.T) {
+	ctx := context.Background()
+	debtor := &domain.User{ID: 2, Name: "Debtor", Email: "debtor@test.com"}
+	creditor := &domain.User{ID: 3, Name: "Creditor", Email: "creditor@test.com"}
+	setup := func(bill *domain.Bill) (service.BillSplitService, *MockBillRepo, *MockLedgerRepo, *MockNotificationRepo, *MockEmailService) {
+		mockBillRepo := new(MockBillRepo)
+		mockUserRepo := new(MockUserRepo)
+		mockLedgerRepo := new(MockLedgerRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockNotifRepo := new(MockNotificationRepo)
+		mockEmailSvc := new(MockEmailService)
+		mockBillRepo.On("GetByID", ctx, int32(1)).Return(bill, nil)
+		mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Test Org"}, nil)
+		mockUserRepo.On("GetByID", ctx, int32(2)).Return(debtor, nil)
+		mockUserRepo.On("GetByID", ctx, int32(3)).Return(creditor, nil)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, mockLedgerRepo, mockOrgRepo, mockNotifRepo, mockEmailSvc, nil)
+		return svc, mockBillRepo, mockLedgerRepo, mockNotifRepo, mockEmailSvc
+	}
+
+	t.Run("Both parties void a pending bill", func(t *testing.T) {
+		bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, AmountCents: 1000,
+			Status: domain.BillStatusPending, SettlementMonth: "2026-01"}
+		svc, mockBillRepo, mockLedgerRepo, mockNotifRepo, mockEmailSvc := setup(bill)
+
+		// Debtor asks first: the request is recorded and the creditor is told
+		mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
+			return b.Status == domain.BillStatusPending && b.VoidRequestedBy != nil && *b.VoidRequestedBy == 2
+		})).Return(nil).Once()
+		mockBillRepo.On("CreateAction", ctx, mock.MatchedBy(func(a *domain.BillAction) bool {
+			return a.ActionType == domain.BillActionTypeVoidRequested && *a.ActorUserID == 2 && a.Notes == "duplicate of January"
+		})).Return(nil).Once()
+		mockNotifRepo.On("Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 3 && n.Attributes["topic"] == "bill_void_requested"
+		})).Return(nil).Once()
+		mockEmailSvc.On("SendAdminNotification", ctx, "creditor@test.com", "Void Requested: Test Org", mock.Anything).Return(nil).Once()
+
+		err := svc.VoidBill(ctx, 2, 1, "duplicate of January")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.BillStatusPending, bill.Status)
+
+		// Creditor agrees: the bill is voided and the debtor is told
+		mockBillRepo.On("Update", ctx, mock.MatchedBy(func(b *domain.Bill) bool {
+			return b.Status == domain.BillStatusVoided && b.ResolvedAt != nil
+		})).Return(nil).Once()
+		mockBillRepo.On("CreateAction", ctx, mock.MatchedBy(func(a *domain.BillAction) bool {
+			return a.ActionType == domain.BillActionTypeVoidConfirmed && *a.ActorUserID == 3
+		})).Return(nil).Once()
+		mockNotifRepo.On("Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 2 && n.Attributes["topic"] == "bill_voided"
+		})).Return(nil).Once()
+		mockEmailSvc.On("SendAdminNotification", ctx, "debtor@test.com", "Payment Voided: Test Org", mock.Anything).Return(nil).Once()
+
+		err = svc.VoidBill(ctx, 3, 1, "")
+		assert.NoError(t, err)
+		assert.Equal(t, domain.BillStatusVoided, bill.Status)
+
+		mockLedgerRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+		mockBillRepo.AssertExpectations(t)
+		mockNotifRepo.AssertExpectations(t)
+		mockEmailSvc.AssertExpectations(t)
+	})
+
+	t.Run("Same party cannot void alone", func(t *testing.T) {
+		requestedBy := int32(3)
+		bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, AmountCents: 1000,
+			Status: domain.BillStatusPending, VoidRequestedBy: &requestedBy}
+		svc, mockBillRepo, _, _, _ := setup(bill)
+
+		err := svc.VoidBill(ctx, 3, 1, "")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		assert.Equal(t, domain.BillStatusPending, bill.Status)
+		mockBillRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Only pending bills can be voided", func(t *testing.T) {
+		for _, status := range []domain.BillStatus{domain.BillStatusPaid, domain.BillStatusDisputed, domain.BillStatusVoided} {
+			bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, Status: status}
+			svc, mockBillRepo, _, _, _ := setup(bill)
+
+			err := svc.VoidBill(ctx, 2, 1, "")
+			assert.ErrorIs(t, err, domain.ErrConflict, "status %s", status)
+			mockBillRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("Acknowledged payment cannot be voided", func(t *testing.T) {
+		now := time.Now()
+		bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1,
+			Status: domain.BillStatusPending, DebtorAcknowledgedAt: &now}
+		svc, mockBillRepo, _, _, _ := setup(bill)
+
+		err := svc.VoidBill(ctx, 3, 1, "")
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		mockBillRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Outsider cannot void", func(t *testing.T) {
+		bill := &domain.Bill{ID: 1, DebtorUserID: 2, CreditorUserID: 3, OrgID: 1, Status: domain.BillStatusPending}
+		svc, mockBillRepo, _, _, _ := setup(bill)
+
+		err := svc.VoidBill(ctx, 9, 1, "")
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockBillRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -3,13 +3,13 @@ package unit
 import (
 	"testing"
 
-	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/utils"
 )
 
 func TestCalculateTransactions(t *testing.T) {
 	// Example: Small church community tool sharing
 	// Scenarios from reference implementation
-	accounts := []jobs.Account{
+	accounts := []utils.Account{
 		{UserID: 1, Balance: 4550},   // John
 		{UserID: 2, Balance: -3820},  // Mary
 		{UserID: 3, Balance: 1275},   // Peter
@@ -24,7 +24,7 @@ func TestCalculateTransactions(t *testing.T) {
 
 	threshold := 500 // $5.00
 
-	var creditors, debtors []jobs.Account
+	var creditors, debtors []utils.Account
 	for _, acc := range accounts {
 		if acc.Balance > 0 {
 			creditors = append(creditors, acc)
@@ -33,7 +33,7 @@ func TestCalculateTransactions(t *testing.T) {
 		}
 	}
 
-	transactions := jobs.CalculateTransactions(creditors, debtors, threshold)
+	transactions := utils.CalculateTransactions(creditors, debtors, threshold)
 
 	// Verify results
 	// 1. Calculate total settled amount
@@ -124,14 +124,14 @@ func TestCalculateTransactions_EdgeCase(t *testing.T) {
 	
 	// Loop 2...10: Alice consumes all small debtors.
 	
-	var creditors, debtors []jobs.Account
-	creditors = append(creditors, jobs.Account{UserID: 1, Balance: 5000})
+	var creditors, debtors []utils.Account
+	creditors = append(creditors, utils.Account{UserID: 1, Balance: 5000})
 	
 	for i := 0; i < 10; i++ {
-		debtors = append(debtors, jobs.Account{UserID: 10 + i, Balance: -300})
+		debtors = append(debtors, utils.Account{UserID: 10 + i, Balance: -300})
 	}
 	
-	transactions := jobs.CalculateTransactions(creditors, debtors, 500)
+	transactions := utils.CalculateTransactions(creditors, debtors, 500)
 	
 	totalSettled := 0
 	for _, txn := range transactions {
@@ -0,0 +1,138 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+var escalatedBillColumns = []string{"id", "org_id", "amount_cents", "currency_code", "settlement_month", "dispute_reason",
+	"id", "name", "email", "id", "name", "email"}
+
+const checkOverdueBillsQuery = `UPDATE bills\s+SET status = 'DISPUTED'.*WHERE status = 'PENDING'\s+AND creditor_acknowledged_at IS NULL` +
+	`.*debtor_acknowledged_at IS NULL AND notice_sent_at < \$1 - \$2 \* INTERVAL '1 day'` +
+	`.*OR debtor_acknowledged_at < \$1 - \$3 \* INTERVAL '1 day'.*INSERT INTO bill_actions.*'DISPUTE_OPENED'`
+
+func newCheckOverdueBillsRunner(t *testing.T, billing config.BillingConfig) (*jobs.JobRunner, sqlmock.Sqlmock, *MockEmailService, *MockNotificationRepo) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	emailSvc := new(MockEmailService)
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc, Notification: noteSvc},
+		&config.Config{Billing: billing})
+	return jr, dbMock, emailSvc, noteSvc
+}
+
+func TestCheckOverdueBills_StuckStates(t *testing.T) {
+	now := time.Date(2026, 3, 12, 5, 0, 0, 0, time.UTC)
+	billing := config.BillingConfig{DebtorAckGraceDays: 10, CreditorAckGraceDays: 5}
+
+	t.Run("Debtor never acknowledged", func(t *testing.T) {
+		jr, dbMock, emailSvc, noteSvc := newCheckOverdueBillsRunner(t, billing)
+		dbMock.ExpectQuery(checkOverdueBillsQuery).
+			WithArgs(now, 10, 5).
+			WillReturnRows(sqlmock.NewRows(escalatedBillColumns).
+				AddRow(7, 1, 2500, "USD", "2026-02", "DEBTOR_NO_ACK", 3, "Dana", "dana@example.com", 4, "Cole", "cole@example.com"))
+
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 3 && n.Attributes["type"] == "BILL_DISPUTE_OPENED" && n.Attributes["dispute_reason"] == "DEBTOR_NO_ACK" &&
+				strings.HasPrefix(n.Message, "You did not confirm paying $25.00 to Cole")
+		})).Return(nil).Once()
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 4 && n.Attributes["bill_id"] == "7" && strings.HasPrefix(n.Message, "Dana did not confirm paying you")
+		})).Return(nil).Once()
+		for _, email := range []string{"dana@example.com", "cole@example.com"} {
+			emailSvc.On("SendAdminNotification", mock.Anything, email, "Payment Disputed: 2026-02", mock.Anything).Return(nil).Once()
+		}
+
+		escalated, err := jr.CheckOverdueBillsAt(context.Background(), now)
+		require.NoError(t, err)
+		require.Len(t, escalated, 1)
+		assert.Equal(t, domain.DisputeReasonDebtorNoAck, escalated[0].Reason)
+
+		noteSvc.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Creditor never confirmed receipt", func(t *testing.T) {
+		jr, dbMock, emailSvc, noteSvc := newCheckOverdueBillsRunner(t, billing)
+		dbMock.ExpectQuery(checkOverdueBillsQuery).
+			WithArgs(now, 10, 5).
+			WillReturnRows(sqlmock.NewRows(escalatedBillColumns).
+				AddRow(8, 1, 1200, "USD", "2026-02", "CREDITOR_NO_ACK", 3, "Dana", "dana@example.com", 4, "Cole", "cole@example.com"))
+
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 3 && n.Attributes["dispute_reason"] == "CREDITOR_NO_ACK" &&
+				strings.HasPrefix(n.Message, "Cole did not confirm receiving your $12.00 payment")
+		})).Return(nil).Once()
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 4 && strings.HasPrefix(n.Message, "You did not confirm receiving $12.00 from Dana")
+		})).Return(nil).Once()
+		emailSvc.On("SendAdminNotification", mock.Anything, mock.Anything, "Payment Disputed: 2026-02", mock.Anything).Return(nil).Twice()
+
+		escalated, err := jr.CheckOverdueBillsAt(context.Background(), now)
+		require.NoError(t, err)
+		require.Len(t, escalated, 1)
+		assert.Equal(t, domain.DisputeReasonCreditorNoAck, escalated[0].Reason)
+
+		noteSvc.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing stuck sends nothing", func(t *testing.T) {
+		jr, dbMock, emailSvc, noteSvc := newCheckOverdueBillsRunner(t, billing)
+		jr.SetClock(func() time.Time { return now })
+		dbMock.ExpectQuery(checkOverdueBillsQuery).
+			WithArgs(now, 10, 5).
+			WillReturnRows(sqlmock.NewRows(escalatedBillColumns))
+
+		jr.CheckOverdueBills()
+
+		noteSvc.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+		emailSvc.AssertNotCalled(t, "SendAdminNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Admins are flagged when enabled", func(t *testing.T) {
+		jr, dbMock, emailSvc, noteSvc := newCheckOverdueBillsRunner(t,
+			config.BillingConfig{DebtorAckGraceDays: 14, CreditorAckGraceDays: 3, EscalateOverdueBillsToAdmins: true})
+		dbMock.ExpectQuery(checkOverdueBillsQuery).
+			WithArgs(now, 14, 3).
+			WillReturnRows(sqlmock.NewRows(escalatedBillColumns).
+				AddRow(9, 1, 900, "EUR", "2026-02", "DEBTOR_NO_ACK", 3, "Dana", "dana@example.com", 4, "Cole", "cole@example.com"))
+		dbMock.ExpectQuery(`SELECT user_id FROM users_orgs WHERE org_id = \$1 AND role IN`).
+			WithArgs(int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(int32(50)))
+
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.Attributes["type"] == "BILL_DISPUTE_OPENED"
+		})).Return(nil).Twice()
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == 50 && n.Attributes["type"] == "BILL_DISPUTE_ESCALATED" &&
+				n.Attributes["channel_id"] == string(domain.ChannelAdmin) && n.Attributes["bill_id"] == "9" &&
+				strings.Contains(n.Message, "Dana to Cole (€9.00)")
+		})).Return(nil).Once()
+		emailSvc.On("SendAdminNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+
+		_, err := jr.CheckOverdueBillsAt(context.Background(), now)
+		require.NoError(t, err)
+
+		noteSvc.AssertExpectations(t)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,261 @@
+package unit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+)
+
+// loadTestConfig writes a minimal valid config and loads it. extra is inserted after the
+// database keys, so indented lines extend that section and unindented ones start new sections.
+func loadTestConfig(t *testing.T, extra string) *config.Config {
+	yaml := fmt.Sprintf(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+%s
+smtp:
+  host: mock
+  port: 587
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+storage:
+  upload_dir: /tmp/uploads
+`, extra)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0o600))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestConfig_DatabasePool(t *testing.T) {
+	t.Run("Reads pool settings", func(t *testing.T) {
+		cfg := loadTestConfig(t, `
+  max_open_conns: 40
+  max_idle_conns: 8
+  conn_max_lifetime_minutes: 15
+  tx_max_attempts: 5
+  tx_retry_backoff_ms: 20`)
+
+		assert.Equal(t, 40, cfg.Database.MaxOpenConns)
+		assert.Equal(t, 8, cfg.Database.MaxIdleConns)
+		assert.Equal(t, 15, cfg.Database.ConnMaxLifetimeMinutes)
+		assert.Equal(t, 5, cfg.Database.TxMaxAttempts)
+		assert.Equal(t, 20, cfg.Database.TxRetryBackoffMs)
+	})
+
+	t.Run("Defaults when unset", func(t *testing.T) {
+		cfg := loadTestConfig(t, "")
+
+		assert.Equal(t, 25, cfg.Database.MaxOpenConns)
+		assert.Equal(t, 10, cfg.Database.MaxIdleConns)
+		assert.Equal(t, 30, cfg.Database.ConnMaxLifetimeMinutes)
+		assert.Equal(t, 3, cfg.Database.TxMaxAttempts)
+		assert.Equal(t, 50, cfg.Database.TxRetryBackoffMs)
+	})
+
+	t.Run("Idle connections are capped at the open limit", func(t *testing.T) {
+		cfg := loadTestConfig(t, `
+  max_open_conns: 5
+  max_idle_conns: 20`)
+
+		assert.Equal(t, 5, cfg.Database.MaxIdleConns)
+	})
+
+	t.Run("ApplyPool sets the limits on the handle", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		config.DatabaseConfig{MaxOpenConns: 12, MaxIdleConns: 4, ConnMaxLifetimeMinutes: 1}.ApplyPool(db)
+
+		assert.Equal(t, 12, db.Stats().MaxOpenConnections)
+	})
+}
+
+func TestConfig_JWT(t *testing.T) {
+	t.Run("Lifetimes default when unset", func(t *testing.T) {
+		cfg := loadTestConfig(t, "")
+
+		assert.Equal(t, 15, cfg.JWT.AccessTokenExpiry)
+		assert.Equal(t, 10080, cfg.JWT.RefreshTokenExpiry)
+		assert.Equal(t, 5, cfg.JWT.TempTokenExpiry)
+		assert.Empty(t, cfg.JWT.RefreshSecret)
+	})
+
+	t.Run("Short refresh secret is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+smtp:
+  host: mock
+  port: 587
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+  refresh_secret: "short"
+storage:
+  upload_dir: /tmp/uploads
+`), 0o600))
+
+		_, err := config.Load(path)
+		assert.ErrorContains(t, err, "refresh secret")
+	})
+}
+
+func TestConfig_SMS(t *testing.T) {
+	t.Run("Defaults to the noop provider", func(t *testing.T) {
+		cfg := loadTestConfig(t, "")
+
+		assert.Equal(t, "noop", cfg.SMS.Provider)
+		assert.Equal(t, 10, cfg.SMS.TimeoutSeconds)
+	})
+
+	t.Run("Reads Twilio settings", func(t *testing.T) {
+		cfg := loadTestConfig(t, `sms:
+  provider: twilio
+  account_sid: AC123
+  auth_token: secret
+  from_number: "+14085550199"`)
+
+		assert.Equal(t, "twilio", cfg.SMS.Provider)
+		assert.Equal(t, "+14085550199", cfg.SMS.FromNumber)
+	})
+
+	t.Run("Twilio without credentials is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+smtp:
+  host: mock
+  port: 587
+sms:
+  provider: twilio
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+storage:
+  upload_dir: /tmp/uploads
+`), 0o600))
+
+		_, err := config.Load(path)
+		assert.ErrorContains(t, err, "account_sid")
+	})
+}
+
+func TestConfig_RentalPricingRounding(t *testing.T) {
+	t.Run("Defaults to round_up", func(t *testing.T) {
+		cfg := loadTestConfig(t, "")
+
+		assert.Equal(t, "round_up", cfg.Rental.PricingRounding)
+	})
+
+	t.Run("Reads the policy", func(t *testing.T) {
+		cfg := loadTestConfig(t, `rental:
+  pricing_rounding: prorate`)
+
+		assert.Equal(t, "prorate", cfg.Rental.PricingRounding)
+	})
+
+	t.Run("Unknown policies are rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+smtp:
+  host: mock
+  port: 587
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+storage:
+  upload_dir: /tmp/uploads
+rental:
+  pricing_rounding: round_down
+`), 0o600))
+
+		_, err := config.Load(path)
+		assert.ErrorContains(t, err, "pricing_rounding")
+	})
+}
+
+func TestConfig_StorageSigningSecret(t *testing.T) {
+	load := func(t *testing.T, storage string) (*config.Config, error) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+smtp:
+  host: mock
+  port: 587
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+storage:
+  upload_dir: /tmp/uploads
+`+storage), 0o600))
+		return config.Load(path)
+	}
+
+	t.Run("Mock storage gets a random key, not the JWT secret", func(t *testing.T) {
+		cfg, err := load(t, "")
+		require.NoError(t, err)
+
+		assert.Len(t, cfg.Storage.SigningSecret, 64)
+		assert.NotEqual(t, cfg.JWT.Secret, cfg.Storage.SigningSecret)
+	})
+
+	t.Run("Other storage requires a key", func(t *testing.T) {
+		_, err := load(t, `  type: s3
+`)
+		assert.ErrorContains(t, err, "signing secret is required")
+	})
+
+	t.Run("The placeholder is rejected", func(t *testing.T) {
+		_, err := load(t, `  type: s3
+  signing_secret: "CHANGE_ME_TO_STRONG_RANDOM_SECRET"
+`)
+		assert.ErrorContains(t, err, "signing secret is required")
+	})
+
+	t.Run("The JWT secret cannot be reused", func(t *testing.T) {
+		_, err := load(t, `  type: s3
+  signing_secret: "0123456789abcdef0123456789abcdef"
+`)
+		assert.ErrorContains(t, err, "differ from the JWT secrets")
+	})
+
+	t.Run("A separate key is accepted", func(t *testing.T) {
+		cfg, err := load(t, `  type: s3
+  signing_secret: "fedcba9876543210fedcba9876543210"
+`)
+		require.NoError(t, err)
+		assert.Equal(t, "fedcba9876543210fedcba9876543210", cfg.Storage.SigningSecret)
+	})
+}
@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFormatCents(t *testing.T) {
+	tests := []struct {
+		cents    int32
+		currency string
+		expected string
+	}{
+		{1250, "USD", "$12.50"},
+		{1250, "", "$12.50"},
+		{5, "USD", "$0.05"},
+		{-1250, "USD", "-$12.50"},
+		{99900, "EUR", "€999.00"},
+		{1250, "GBP", "£12.50"},
+		{1250, "CAD", "CA$12.50"},
+		{1250, "CHF", "CHF 12.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, domain.FormatCents(tt.cents, tt.currency))
+		})
+	}
+}
+
+func TestNormalizeCurrencyCode(t *testing.T) {
+	code, err := domain.NormalizeCurrencyCode(" eur ")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", code)
+
+	code, err = domain.NormalizeCurrencyCode("")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.DefaultCurrencyCode, code)
+
+	for _, bad := range []string{"US", "EURO", "U$D"} {
+		_, err = domain.NormalizeCurrencyCode(bad)
+		assert.Error(t, err, bad)
+		assert.ErrorIs(t, err, domain.ErrValidation, bad)
+	}
+}
+
+func TestOrganization_Currency(t *testing.T) {
+	assert.Equal(t, "USD", (&domain.Organization{}).Currency())
+	assert.Equal(t, "EUR", (&domain.Organization{CurrencyCode: "EUR"}).Currency())
+	var org *domain.Organization
+	assert.Equal(t, "USD", org.Currency())
+}
+
+func TestOrganizationService_UpdateOrganization_Currency(t *testing.T) {
+	ctx := context.Background()
+	const callerID, orgID = int32(1), int32(1)
+
+	newSvc := func() (service.OrganizationService, *MockOrganizationRepo) {
+		orgRepo := new(MockOrganizationRepo)
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetUserOrg", ctx, callerID, orgID).Return(&domain.UserOrg{UserID: callerID, OrgID: orgID, Role: domain.UserOrgRoleSuperAdmin}, nil)
+		orgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Name: "Maple Street", CurrencyCode: "CAD"}, nil)
+		return service.NewOrganizationService(orgRepo, userRepo, new(MockInvitationRepo), nil, new(MockNotificationRepo), nil, nil, nil), orgRepo
+	}
+
+	t.Run("Empty keeps the current currency", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+		orgRepo.On("Update", ctx, mock.MatchedBy(func(o *domain.Organization) bool { return o.CurrencyCode == "CAD" })).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateOrganization(ctx, callerID, &domain.Organization{ID: orgID, Name: "Maple Street"}))
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("New currency is normalized", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+		orgRepo.On("Update", ctx, mock.MatchedBy(func(o *domain.Organization) bool { return o.CurrencyCode == "EUR" })).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateOrganization(ctx, callerID, &domain.Organization{ID: orgID, Name: "Maple Street", CurrencyCode: "eur"}))
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Malformed currency is rejected", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+
+		err := svc.UpdateOrganization(ctx, callerID, &domain.Organization{ID: orgID, Name: "Maple Street", CurrencyCode: "EURO"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		orgRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+// fakeEmailTransport records delivered messages and fails while failures remain
+type fakeEmailTransport struct {
+	mu       sync.Mutex
+	failures int
+	sent     []service.EmailMessage
+}
+
+func (f *fakeEmailTransport) SendMessage(_ context.Context, msg service.EmailMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("451 try again later")
+	}
+	f.sent = append(f.sent, msg)
+	return nil
+}
+
+func (f *fakeEmailTransport) messages() []service.EmailMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]service.EmailMessage{}, f.sent...)
+}
+
+func expectOutboxCreate(repo *MockEmailOutboxRepo, id int64) {
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.EmailOutboxEntry")).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.EmailOutboxEntry).ID = id
+		}).Return(nil).Once()
+}
+
+func TestEmailOutbox_EnqueueAndDrain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Enqueued email is persisted then delivered by a worker", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		transport := &fakeEmailTransport{}
+		outbox := service.NewEmailOutbox(repo, transport, 0)
+		emailSvc := service.NewOutboxEmailService(outbox)
+
+		repo.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.EmailOutboxEntry) bool {
+			return e.Status == domain.EmailOutboxStatusPending &&
+				e.Subject == "Rental Request Approved: Drill" &&
+				assert.ObjectsAreEqual([]string{"renter@example.com"}, e.Recipients) &&
+				assert.ObjectsAreEqual([]string{"cc@example.com"}, e.Cc)
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.EmailOutboxEntry).ID = 11
+		}).Return(nil).Once()
+		repo.On("Claim", mock.Anything, int64(11)).Return(true, nil).Once()
+		repo.On("MarkSent", mock.Anything, int64(11)).Return(nil).Once()
+
+		err := emailSvc.SendRentalApprovalNotification(ctx, "renter@example.com", service.RentalApprovalEmail{
+			RenterName: "Renter", OwnerName: "Owner", ToolName: "Drill", PickupNote: "Porch",
+		}, "cc@example.com")
+		require.NoError(t, err)
+		require.NoError(t, outbox.Shutdown(ctx))
+
+		msgs := transport.messages()
+		require.Len(t, msgs, 1)
+		assert.Equal(t, "Rental Request Approved: Drill", msgs[0].Subject)
+		assert.Equal(t, []string{"cc@example.com"}, msgs[0].Cc)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Delivery failure is recorded with a retry time", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		transport := &fakeEmailTransport{failures: 1}
+		outbox := service.NewEmailOutbox(repo, transport, 0)
+
+		expectOutboxCreate(repo, 12)
+		repo.On("Claim", mock.Anything, int64(12)).Return(true, nil).Once()
+		repo.On("MarkFailed", mock.Anything, int64(12), "451 try again later", mock.MatchedBy(func(next time.Time) bool {
+			return next.After(time.Now().Add(30 * time.Second))
+		})).Return(nil).Once()
+
+		require.NoError(t, outbox.Enqueue(ctx, service.EmailMessage{To: []string{"a@example.com"}, Subject: "Hi"}))
+		require.NoError(t, outbox.Shutdown(ctx))
+
+		assert.Empty(t, transport.messages())
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "MarkSent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Entry claimed elsewhere is not sent twice", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		transport := &fakeEmailTransport{}
+		outbox := service.NewEmailOutbox(repo, transport, 0)
+
+		expectOutboxCreate(repo, 13)
+		repo.On("Claim", mock.Anything, int64(13)).Return(false, nil).Once()
+
+		require.NoError(t, outbox.Enqueue(ctx, service.EmailMessage{To: []string{"a@example.com"}, Subject: "Hi"}))
+		require.NoError(t, outbox.Shutdown(ctx))
+
+		assert.Empty(t, transport.messages())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Persistence failure is returned to the caller", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		outbox := service.NewEmailOutbox(repo, &fakeEmailTransport{}, 0)
+		defer outbox.Shutdown(ctx)
+
+		repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+
+		err := outbox.Enqueue(ctx, service.EmailMessage{To: []string{"a@example.com"}, Subject: "Hi"})
+		assert.ErrorContains(t, err, "failed to persist email")
+	})
+
+	t.Run("Enqueue after shutdown leaves the entry for the retry job", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		transport := &fakeEmailTransport{}
+		outbox := service.NewEmailOutbox(repo, transport, 0)
+		require.NoError(t, outbox.Shutdown(ctx))
+
+		expectOutboxCreate(repo, 14)
+
+		require.NoError(t, outbox.Enqueue(ctx, service.EmailMessage{To: []string{"a@example.com"}, Subject: "Hi"}))
+		assert.Empty(t, transport.messages())
+		repo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEmailOutbox_RetryPending(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockEmailOutboxRepo)
+	transport := &fakeEmailTransport{failures: 1}
+	outbox := service.NewEmailOutbox(repo, transport, 4)
+	defer outbox.Shutdown(ctx)
+
+	repo.On("ClaimRetryable", mock.Anything, int32(4), mock.AnythingOfType("time.Duration"), int32(50)).
+		Return([]domain.EmailOutboxEntry{
+			{ID: 21, Recipients: []string{"a@example.com"}, Subject: "First", Attempts: 2},
+			{ID: 22, Recipients: []string{"b@example.com"}, Subject: "Second", Attempts: 1},
+		}, nil).Once()
+	// Attempts comes back already counting this try; a failed second attempt waits 1m doubled once
+	repo.On("MarkFailed", mock.Anything, int64(21), mock.Anything, mock.MatchedBy(func(next time.Time) bool {
+		return next.After(time.Now().Add(90*time.Second)) && next.Before(time.Now().Add(150*time.Second))
+	})).Return(nil).Once()
+	repo.On("MarkSent", mock.Anything, int64(22)).Return(nil).Once()
+
+	sent, failed, err := outbox.RetryPending(ctx, 50)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, failed)
+	require.Len(t, transport.messages(), 1)
+	assert.Equal(t, "Second", transport.messages()[0].Subject)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,314 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/service"
+)
+
+// fakeSMTPServer is a minimal in-process SMTP server that records what clients negotiate
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	implicit  bool // wrap every connection in TLS before the greeting
+	starttls  bool // advertise STARTTLS in the EHLO reply
+
+	mu           sync.Mutex
+	mailReplies  []string // consumed one per MAIL command; empty means "250 OK"
+	connections  int
+	mailCommands int
+	messages     []fakeSMTPMessage
+}
+
+type fakeSMTPMessage struct {
+	Data string
+	TLS  bool
+}
+
+func newTestTLSConfigs(t *testing.T) (server *tls.Config, client *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{RootCAs: pool}
+	return server, client
+}
+
+func startFakeSMTPServer(t *testing.T, serverTLS *tls.Config, implicit, starttls bool) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &fakeSMTPServer{listener: ln, tlsConfig: serverTLS, implicit: implicit, starttls: starttls}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handle(conn)
+		}
+	}()
+	return srv
+}
+
+func (f *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(f.listener.Addr().String())
+	return port
+}
+
+func (f *fakeSMTPServer) handle(conn net.Conn) {
+	defer func() { conn.Close() }()
+	f.mu.Lock()
+	f.connections++
+	f.mu.Unlock()
+
+	secure := false
+	if f.implicit {
+		tlsConn := tls.Server(conn, f.tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn, secure = tlsConn, true
+	}
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	write("220 fake ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			if f.starttls && !secure {
+				write("250-fake\r\n250-STARTTLS\r\n250 AUTH PLAIN")
+			} else {
+				write("250-fake\r\n250 AUTH PLAIN")
+			}
+		case cmd == "STARTTLS":
+			write("220 ready")
+			tlsConn := tls.Server(conn, f.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			r = bufio.NewReader(conn)
+		case strings.HasPrefix(cmd, "AUTH"):
+			write("235 authenticated")
+		case strings.HasPrefix(cmd, "MAIL"):
+			f.mu.Lock()
+			f.mailCommands++
+			reply := "250 OK"
+			if len(f.mailReplies) > 0 {
+				reply, f.mailReplies = f.mailReplies[0], f.mailReplies[1:]
+			}
+			f.mu.Unlock()
+			write(reply)
+		case strings.HasPrefix(cmd, "RCPT"), cmd == "RSET", cmd == "NOOP":
+			write("250 OK")
+		case cmd == "DATA":
+			write("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			f.mu.Lock()
+			f.messages = append(f.messages, fakeSMTPMessage{Data: data.String(), TLS: secure})
+			f.mu.Unlock()
+			write("250 queued")
+		case cmd == "QUIT":
+			write("221 bye")
+			return
+		default:
+			write("502 not implemented")
+		}
+	}
+}
+
+// replyToMail queues replies for the next MAIL commands
+func (f *fakeSMTPServer) replyToMail(replies ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mailReplies = append(f.mailReplies, replies...)
+}
+
+func (f *fakeSMTPServer) stats() (connections, mailCommands int, messages []fakeSMTPMessage) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.connections, f.mailCommands, append([]fakeSMTPMessage{}, f.messages...)
+}
+
+func newFakeSMTPEmailService(srv *fakeSMTPServer, clientTLS *tls.Config, mode service.TLSMode) service.EmailService {
+	return service.NewEmailServiceWithOptions("127.0.0.1", srv.port(), "noreply@example.com", "secret", "Ubertool",
+		service.EmailOptions{
+			TLSMode:      mode,
+			Timeout:      2 * time.Second,
+			MaxAttempts:  3,
+			RetryBackoff: time.Millisecond,
+			TLSConfig:    clientTLS,
+		})
+}
+
+func TestEmailService_TLSNegotiation(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+	ctx := context.Background()
+
+	t.Run("STARTTLS upgrades before sending", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		require.NoError(t, svc.SendAdminNotification(ctx, "admin@example.com", "Hello", "Body"))
+
+		_, _, msgs := srv.stats()
+		require.Len(t, msgs, 1)
+		assert.True(t, msgs[0].TLS)
+		assert.Contains(t, msgs[0].Data, "Subject: Hello")
+	})
+
+	t.Run("Templated email is sent as multipart alternative", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		require.NoError(t, svc.SendTwoFactorCode(ctx, "user@example.com", service.TwoFactorCodeEmail{Name: "Ravi", Code: "04217"}))
+
+		_, _, msgs := srv.stats()
+		require.Len(t, msgs, 1)
+		assert.Contains(t, msgs[0].Data, "Subject: Your 2FA Code - 04217")
+		assert.Contains(t, msgs[0].Data, "Content-Type: multipart/alternative; boundary=")
+		assert.Contains(t, msgs[0].Data, "Content-Type: text/plain; charset=UTF-8")
+		assert.Contains(t, msgs[0].Data, "Content-Type: text/html; charset=UTF-8")
+		assert.Less(t, strings.Index(msgs[0].Data, "text/plain"), strings.Index(msgs[0].Data, "text/html"))
+	})
+
+	t.Run("STARTTLS mode refuses servers that do not offer it", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, false)
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		err := svc.SendAdminNotification(ctx, "admin@example.com", "Hello", "Body")
+		assert.ErrorContains(t, err, "does not support STARTTLS")
+
+		conns, _, msgs := srv.stats()
+		assert.Empty(t, msgs)
+		assert.Equal(t, 1, conns, "missing STARTTLS is permanent and must not be retried")
+	})
+
+	t.Run("Implicit TLS from the first byte", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, true, false)
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeImplicit)
+
+		require.NoError(t, svc.SendAdminNotification(ctx, "admin@example.com", "Hello", "Body"))
+
+		_, _, msgs := srv.stats()
+		require.Len(t, msgs, 1)
+		assert.True(t, msgs[0].TLS)
+	})
+
+	t.Run("Untrusted certificate is rejected", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		svc := newFakeSMTPEmailService(srv, &tls.Config{RootCAs: x509.NewCertPool()}, service.TLSModeStartTLS)
+
+		assert.Error(t, svc.SendAdminNotification(ctx, "admin@example.com", "Hello", "Body"))
+		_, _, msgs := srv.stats()
+		assert.Empty(t, msgs)
+	})
+}
+
+func TestEmailService_RetryAndReuse(t *testing.T) {
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+	ctx := context.Background()
+
+	t.Run("Transient 4xx is retried until success", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		srv.replyToMail("451 try again later", "421 busy")
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		require.NoError(t, svc.SendAdminNotification(ctx, "admin@example.com", "Retry", "Body"))
+
+		_, mails, msgs := srv.stats()
+		assert.Equal(t, 3, mails)
+		assert.Len(t, msgs, 1)
+	})
+
+	t.Run("Gives up after max attempts", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		srv.replyToMail("451 a", "451 b", "451 c", "451 d")
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		assert.Error(t, svc.SendAdminNotification(ctx, "admin@example.com", "Retry", "Body"))
+
+		_, mails, msgs := srv.stats()
+		assert.Equal(t, 3, mails)
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("Permanent 5xx is not retried", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		srv.replyToMail("550 mailbox unavailable")
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		assert.Error(t, svc.SendAdminNotification(ctx, "admin@example.com", "Rejected", "Body"))
+
+		_, mails, msgs := srv.stats()
+		assert.Equal(t, 1, mails)
+		assert.Empty(t, msgs)
+	})
+
+	t.Run("Connection is reused across sends", func(t *testing.T) {
+		srv := startFakeSMTPServer(t, serverTLS, false, true)
+		svc := newFakeSMTPEmailService(srv, clientTLS, service.TLSModeStartTLS)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, svc.SendAdminNotification(ctx, "admin@example.com", "Reuse", "Body"))
+		}
+
+		conns, _, msgs := srv.stats()
+		assert.Equal(t, 1, conns)
+		assert.Len(t, msgs, 3)
+	})
+
+	t.Run("Mock host only logs", func(t *testing.T) {
+		svc := service.NewEmailService("mock", "587", "noreply@example.com", "", "Ubertool")
+		assert.NoError(t, svc.SendAdminNotification(ctx, "admin@example.com", "Mock", "Body"))
+	})
+}
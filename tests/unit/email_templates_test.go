@@ -0,0 +1,325 @@
+package unit
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/service"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files under testdata")
+
+// assertGolden compares got with testdata/<name>, rewriting the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run go test ./tests/unit -run TestEmailRenderer -update")
+	assert.Equal(t, string(want), got)
+}
+
+func TestEmailRenderer_Golden(t *testing.T) {
+	renderer, err := service.NewEmailRenderer()
+	require.NoError(t, err)
+
+	tests := []struct {
+		template string
+		data     interface{}
+		subject  string
+	}{
+		{
+			template: service.EmailTemplateRentalRequest,
+			data:     service.RentalRequestEmail{OwnerName: "Olivia", RenterName: "Ravi", ToolName: "Cordless Drill"},
+			subject:  "New Rental Request for Cordless Drill",
+		},
+		{
+			template: service.EmailTemplateRentalApproval,
+			data: service.RentalApprovalEmail{
+				RenterName: "Ravi", OwnerName: "Olivia", ToolName: "Cordless Drill",
+				PickupNote: "Side gate, after 6pm.\nRing twice.",
+			},
+			subject: "Rental Request Approved: Cordless Drill",
+		},
+		{
+			template: service.EmailTemplateBillDisputeResolution,
+			data: service.BillDisputeResolutionEmail{
+				Name: "Dana", AmountCents: 12345, Resolution: "DEBTOR_FAULT",
+				Notes: "Debtor blocked from renting <until paid>", OrgName: "Maple Street",
+			},
+			subject: "Dispute Resolved: $123.45 Payment (Maple Street)",
+		},
+		{
+			template: service.EmailTemplateReturnDateRejection,
+			data: service.ReturnDateRejectionEmail{
+				ToolName: "Cordless Drill", NewEndDate: "2026-03-14", Reason: "Needed for a job",
+				TotalCostCents: 4500,
+			},
+			subject: "Return Date Extension Rejected: Cordless Drill",
+		},
+		{
+			template: service.EmailTemplateAdminNotification,
+			data:     service.AdminNotificationEmail{Subject: "Balance Alert: Maple Street", Message: "You owe $62.50.\nPlease settle up."},
+			subject:  "Balance Alert: Maple Street",
+		},
+		{
+			template: service.EmailTemplateBillPaymentNotice,
+			data: service.BillPaymentEmail{
+				DebtorName: "Dana", CreditorName: "Olivia", AmountCents: 12345, SettlementMonth: "2026-02", OrgName: "Maple Street",
+			},
+			subject: "Payment Notice: $123.45 Due to Olivia (Maple Street)",
+		},
+		{
+			template: service.EmailTemplateBillDispute,
+			data: service.BillDisputeEmail{
+				Name: "Dana", OtherPartyName: "Olivia", AmountCents: 12345, Reason: "Never received", OrgName: "Maple Street",
+			},
+			subject: "Payment Dispute Opened: $123.45 with Olivia (Maple Street)",
+		},
+		{
+			template: service.EmailTemplateTwoFactorCode,
+			data:     service.TwoFactorCodeEmail{Name: "Ravi", Code: "04217"},
+			subject:  "Your 2FA Code - 04217",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			rendered, err := renderer.Render(tt.template, tt.data)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.subject, rendered.Subject)
+			assertGolden(t, filepath.Join("email", tt.template+".txt.golden"), rendered.Text)
+			assertGolden(t, filepath.Join("email", tt.template+".html.golden"), rendered.HTML)
+		})
+	}
+
+	t.Run("HTML escapes user input", func(t *testing.T) {
+		rendered, err := renderer.Render(service.EmailTemplateRentalRequest,
+			service.RentalRequestEmail{OwnerName: "O", RenterName: "<script>x</script>", ToolName: "Saw"})
+		require.NoError(t, err)
+		assert.NotContains(t, rendered.HTML, "<script>")
+		assert.Contains(t, rendered.Text, "<script>x</script>")
+	})
+
+	t.Run("Amounts use the org currency", func(t *testing.T) {
+		rendered, err := renderer.Render(service.EmailTemplateBillDisputeResolution, service.BillDisputeResolutionEmail{
+			Name: "Dana", AmountCents: 12345, CurrencyCode: "EUR", Resolution: "GRACEFUL", OrgName: "Maple Street",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Dispute Resolved: €123.45 Payment (Maple Street)", rendered.Subject)
+		assert.Contains(t, rendered.Text, "The dispute for a €123.45 payment")
+		assert.Contains(t, rendered.HTML, "<strong>€123.45</strong>")
+	})
+
+	t.Run("Unknown template", func(t *testing.T) {
+		_, err := renderer.Render("no_such_template", nil)
+		assert.ErrorContains(t, err, "unknown email template")
+	})
+}
+
+// TestEmailService_WrappersRouteToTemplates checks that each specialized method sends exactly
+// what Send would for its template, so the wrappers and the registry cannot drift apart
+func TestEmailService_WrappersRouteToTemplates(t *testing.T) {
+	ctx := context.Background()
+	renderer, err := service.NewEmailRenderer()
+	require.NoError(t, err)
+
+	payment := service.BillPaymentEmail{
+		DebtorName: "Dana", CreditorName: "Olivia", AmountCents: 12345, CurrencyCode: "EUR",
+		SettlementMonth: "2026-02", OrgName: "Maple Street",
+	}
+	tests := []struct {
+		name     string
+		send     func(svc service.EmailService) error
+		template string
+		data     interface{}
+		cc       []string
+	}{
+		{
+			name: "SendInvitation",
+			send: func(svc service.EmailService) error {
+				return svc.SendInvitation(ctx, "to@example.com", "Ravi", "ABC123", "Maple Street", "cc@example.com")
+			},
+			template: service.EmailTemplateInvitation,
+			data:     service.InvitationEmail{Name: "Ravi", OrgName: "Maple Street", Token: "ABC123"},
+			cc:       []string{"cc@example.com"},
+		},
+		{
+			name: "SendAccountStatusNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendAccountStatusNotification(ctx, "to@example.com", "Ravi", "Maple Street", "BLOCKED", "Unpaid bill")
+			},
+			template: service.EmailTemplateAccountStatus,
+			data:     service.AccountStatusEmail{Name: "Ravi", OrgName: "Maple Street", Status: "BLOCKED", Reason: "Unpaid bill"},
+		},
+		{
+			name: "SendRentalRequestNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalRequestNotification(ctx, "to@example.com",
+					service.RentalRequestEmail{OwnerName: "Olivia", RenterName: "Ravi", ToolName: "Drill"}, "")
+			},
+			template: service.EmailTemplateRentalRequest,
+			data:     service.RentalRequestEmail{OwnerName: "Olivia", RenterName: "Ravi", ToolName: "Drill"},
+		},
+		{
+			name: "SendRentalRejectionNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalRejectionNotification(ctx, "to@example.com", "Drill", "Olivia", "cc@example.com")
+			},
+			template: service.EmailTemplateRentalRejection,
+			data:     service.RentalRejectionEmail{ToolName: "Drill", OwnerName: "Olivia"},
+			cc:       []string{"cc@example.com"},
+		},
+		{
+			name: "SendRentalConfirmationNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalConfirmationNotification(ctx, "to@example.com", "Ravi", "Drill", "")
+			},
+			template: service.EmailTemplateRentalConfirmation,
+			data:     service.RentalConfirmationEmail{RenterName: "Ravi", ToolName: "Drill"},
+		},
+		{
+			name: "SendRentalCancellationNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalCancellationNotification(ctx, "to@example.com", "Ravi", "Drill", "Plans changed", "")
+			},
+			template: service.EmailTemplateRentalCancellation,
+			data:     service.RentalCancellationEmail{CancellerName: "Ravi", ToolName: "Drill", Reason: "Plans changed"},
+		},
+		{
+			name: "SendRentalCompletionNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalCompletionNotification(ctx, "to@example.com", "owner", "Drill", 1500)
+			},
+			template: service.EmailTemplateRentalCompletion,
+			data:     service.RentalCompletionEmail{Role: "owner", ToolName: "Drill", AmountCents: 1500},
+		},
+		{
+			name: "SendRentalPickupNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendRentalPickupNotification(ctx, "to@example.com", "Ravi", "Drill", "2026-03-01", "2026-03-05")
+			},
+			template: service.EmailTemplateRentalPickup,
+			data:     service.RentalPickupEmail{Name: "Ravi", ToolName: "Drill", StartDate: "2026-03-01", EndDate: "2026-03-05"},
+		},
+		{
+			name: "SendReturnDateRejectionNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendReturnDateRejectionNotification(ctx, "to@example.com", "Drill", "2026-03-05", "Needed", 4500, "EUR")
+			},
+			template: service.EmailTemplateReturnDateRejection,
+			data: service.ReturnDateRejectionEmail{
+				ToolName: "Drill", NewEndDate: "2026-03-05", Reason: "Needed", TotalCostCents: 4500, CurrencyCode: "EUR",
+			},
+		},
+		{
+			name: "SendTwoFactorCode",
+			send: func(svc service.EmailService) error {
+				return svc.SendTwoFactorCode(ctx, "to@example.com", service.TwoFactorCodeEmail{Name: "Ravi", Code: "04217"})
+			},
+			template: service.EmailTemplateTwoFactorCode,
+			data:     service.TwoFactorCodeEmail{Name: "Ravi", Code: "04217"},
+		},
+		{
+			name: "SendAdminNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendAdminNotification(ctx, "to@example.com", "Heads up", "Line one\nLine two")
+			},
+			template: service.EmailTemplateAdminNotification,
+			data:     service.AdminNotificationEmail{Subject: "Heads up", Message: "Line one\nLine two"},
+		},
+		{
+			name: "SendBillPaymentNotice",
+			send: func(svc service.EmailService) error {
+				return svc.SendBillPaymentNotice(ctx, "to@example.com", "Dana", "Olivia", 12345, "2026-02", "Maple Street", "EUR")
+			},
+			template: service.EmailTemplateBillPaymentNotice,
+			data:     payment,
+		},
+		{
+			name: "SendBillPaymentAcknowledgment",
+			send: func(svc service.EmailService) error {
+				return svc.SendBillPaymentAcknowledgment(ctx, "to@example.com", "Olivia", "Dana", 12345, "2026-02", "Maple Street", "EUR")
+			},
+			template: service.EmailTemplateBillPaymentAcknowledgment,
+			data:     payment,
+		},
+		{
+			name: "SendBillReceiptConfirmation",
+			send: func(svc service.EmailService) error {
+				return svc.SendBillReceiptConfirmation(ctx, "to@example.com", "Dana", "Olivia", 12345, "2026-02", "Maple Street", "EUR")
+			},
+			template: service.EmailTemplateBillReceiptConfirmation,
+			data:     payment,
+		},
+		{
+			name: "SendBillDisputeNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendBillDisputeNotification(ctx, "to@example.com", "Dana", "Olivia", 12345, "Never received", "Maple Street", "EUR")
+			},
+			template: service.EmailTemplateBillDispute,
+			data: service.BillDisputeEmail{
+				Name: "Dana", OtherPartyName: "Olivia", AmountCents: 12345, CurrencyCode: "EUR",
+				Reason: "Never received", OrgName: "Maple Street",
+			},
+		},
+		{
+			name: "SendBillDisputeResolutionNotification",
+			send: func(svc service.EmailService) error {
+				return svc.SendBillDisputeResolutionNotification(ctx, "to@example.com", service.BillDisputeResolutionEmail{
+					Name: "Dana", AmountCents: 12345, Resolution: "GRACEFUL", OrgName: "Maple Street",
+				})
+			},
+			template: service.EmailTemplateBillDisputeResolution,
+			data: service.BillDisputeResolutionEmail{
+				Name: "Dana", AmountCents: 12345, Resolution: "GRACEFUL", OrgName: "Maple Street",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockEmailOutboxRepo)
+			transport := &fakeEmailTransport{}
+			outbox := service.NewEmailOutbox(repo, transport, 0)
+			repo.On("Create", mock.Anything, mock.Anything).Return(nil).Once()
+			repo.On("Claim", mock.Anything, mock.Anything).Return(true, nil).Once()
+			repo.On("MarkSent", mock.Anything, mock.Anything).Return(nil).Once()
+
+			require.NoError(t, tt.send(service.NewOutboxEmailService(outbox)))
+			require.NoError(t, outbox.Shutdown(ctx))
+
+			want, err := renderer.Render(tt.template, tt.data)
+			require.NoError(t, err)
+			msgs := transport.messages()
+			require.Len(t, msgs, 1)
+			assert.Equal(t, []string{"to@example.com"}, msgs[0].To)
+			assert.Equal(t, tt.cc, msgs[0].Cc)
+			assert.Equal(t, want.Subject, msgs[0].Subject)
+			assert.Equal(t, want.Text, msgs[0].Body)
+			assert.Equal(t, want.HTML, msgs[0].HTMLBody)
+		})
+	}
+
+	t.Run("Send with an unknown template fails before anything is queued", func(t *testing.T) {
+		repo := new(MockEmailOutboxRepo)
+		outbox := service.NewEmailOutbox(repo, &fakeEmailTransport{}, 0)
+		svc := service.NewOutboxEmailService(outbox)
+
+		err := svc.Send(ctx, "to@example.com", "no_such_template", nil)
+		assert.ErrorContains(t, err, "unknown email template")
+		require.NoError(t, outbox.Shutdown(ctx))
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
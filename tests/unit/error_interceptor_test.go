@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"ubertool-backend-trusted/internal/api/grpc/interceptor"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{"Unauthorized", domain.Unauthorizedf("unauthorized: admin privileges required"), codes.PermissionDenied, "unauthorized: admin privileges required"},
+		{"Bare unauthorized", domain.ErrUnauthorized, codes.PermissionDenied, "unauthorized"},
+		{"Not found", domain.NotFoundf("rental %d not found", 7), codes.NotFound, "rental 7 not found"},
+		{"Missing row", fmt.Errorf("failed to get rental: %w", sql.ErrNoRows), codes.NotFound, "failed to get rental: sql: no rows in result set"},
+		{"Conflict", domain.Conflictf("rental is not pending"), codes.FailedPrecondition, "rental is not pending"},
+		{"Validation", domain.Invalidf("invalid start date: %w", errors.New("bad")), codes.InvalidArgument, "invalid start date: bad"},
+		{"Wrapped sentinel", fmt.Errorf("%w: late returns", service.ErrRentingBlocked), codes.PermissionDenied, "renting privileges are blocked in this organization: late returns"},
+		{"Deadline", context.DeadlineExceeded, codes.DeadlineExceeded, "context deadline exceeded"},
+		{"Unknown kind", errors.New("boom"), codes.Unknown, "boom"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := status.Convert(interceptor.StatusFromError(tc.err))
+			assert.Equal(t, tc.code, st.Code())
+			assert.Equal(t, tc.msg, st.Message())
+		})
+	}
+
+	t.Run("Status errors pass through", func(t *testing.T) {
+		err := status.Error(codes.Unauthenticated, "missing or invalid access token")
+		assert.Equal(t, err, interceptor.StatusFromError(err))
+	})
+
+	t.Run("Nil stays nil", func(t *testing.T) {
+		assert.NoError(t, interceptor.StatusFromError(nil))
+	})
+}
+
+func TestErrorInterceptor_Unary(t *testing.T) {
+	ctx := context.Background()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ubertool.trusted.api.v1.RentalService/ApproveRentalRequest"}
+	unary := interceptor.NewErrorInterceptor().Unary()
+
+	t.Run("Rental service errors carry their code", func(t *testing.T) {
+		rentalRepo := new(MockRentalRepo)
+		svc := service.NewRentalService(rentalRepo, nil, nil, nil, nil, nil, nil)
+		rentalRepo.On("GetByID", mock.Anything, int32(1)).Return(&domain.Rental{ID: 1, OwnerID: 2, Status: domain.RentalStatusPending}, nil)
+		rentalRepo.On("GetByID", mock.Anything, int32(2)).Return(&domain.Rental{ID: 2, OwnerID: 2, Status: domain.RentalStatusScheduled}, nil)
+		rentalRepo.On("GetByID", mock.Anything, int32(3)).Return(nil, sql.ErrNoRows)
+
+		approve := func(ownerID, rentalID int32) codes.Code {
+			_, err := unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return svc.ApproveRentalRequest(ctx, ownerID, rentalID, "")
+			})
+			return status.Code(err)
+		}
+		assert.Equal(t, codes.PermissionDenied, approve(9, 1))
+		assert.Equal(t, codes.FailedPrecondition, approve(2, 2))
+		assert.Equal(t, codes.NotFound, approve(2, 3))
+	})
+
+	t.Run("Bill split service errors carry their code", func(t *testing.T) {
+		billRepo := new(MockBillRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewBillSplitService(billRepo, userRepo, nil, nil, nil, nil, nil)
+		billRepo.On("GetByID", mock.Anything, int32(5)).Return(&domain.Bill{ID: 5, OrgID: 1, DebtorUserID: 2, CreditorUserID: 3, Status: domain.BillStatusDisputed}, nil)
+		userRepo.On("GetUserOrg", mock.Anything, int32(1), int32(1)).Return(&domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil)
+		userRepo.On("GetUserOrg", mock.Anything, int32(4), int32(1)).Return(&domain.UserOrg{UserID: 4, OrgID: 1, Role: domain.UserOrgRoleMember}, nil)
+
+		resolve := func(adminID int32, resolution string) codes.Code {
+			_, err := unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, svc.ResolveDispute(ctx, adminID, 5, resolution, "")
+			})
+			return status.Code(err)
+		}
+		assert.Equal(t, codes.PermissionDenied, resolve(4, string(domain.ResolutionOutcomeGraceful)))
+		assert.Equal(t, codes.InvalidArgument, resolve(1, "NOBODY"))
+	})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/api/grpc"
+	"ubertool-backend-trusted/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestBillSplitHandler_ListPayments(t *testing.T) {
+	billSvc := new(MockBillSplitService)
+	userSvc := new(MockUserService)
+	handler := grpc.NewBillSplitHandler(billSvc, userSvc)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "1"))
+
+	t.Run("Names are fetched in one batch", func(t *testing.T) {
+		bills := []domain.Bill{
+			{ID: 10, DebtorUserID: 1, CreditorUserID: 2, AmountCents: 500, Status: domain.BillStatusPending},
+			{ID: 11, DebtorUserID: 3, CreditorUserID: 1, AmountCents: 700, Status: domain.BillStatusPending},
+			{ID: 12, DebtorUserID: 1, CreditorUserID: 2, AmountCents: 900, Status: domain.BillStatusPending},
+		}
+		billSvc.On("ListPayments", ctx, int32(1), int32(5), false, int32(1), int32(50)).Return(bills, int32(3), nil)
+		userSvc.On("GetUsersByIDs", ctx, []int32{1, 2, 3, 1, 1, 2}).Return(map[int32]*domain.User{
+			1: {ID: 1, Name: "Alice"},
+			2: {ID: 2, Name: "Bob"},
+			3: {ID: 3, Name: "Carol"},
+		}, nil).Once()
+
+		res, err := handler.ListPayments(ctx, &pb.ListPaymentsRequest{OrganizationId: 5})
+		assert.NoError(t, err)
+		assert.Len(t, res.Payments, 3)
+		assert.Equal(t, "Alice", res.Payments[0].DebtorName)
+		assert.Equal(t, "Bob", res.Payments[0].CreditorName)
+		assert.Equal(t, "Carol", res.Payments[1].DebtorName)
+		assert.Equal(t, "Alice", res.Payments[1].CreditorName)
+		userSvc.AssertNumberOfCalls(t, "GetUsersByIDs", 1)
+		userSvc.AssertNotCalled(t, "GetUserProfile", mock.Anything, mock.Anything)
+	})
+}
+
+func TestBillSplitHandler_GetPaymentDetail(t *testing.T) {
+	billSvc := new(MockBillSplitService)
+	userSvc := new(MockUserService)
+	handler := grpc.NewBillSplitHandler(billSvc, userSvc)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "1"))
+
+	t.Run("Bill parties and actors share one lookup", func(t *testing.T) {
+		creditor := int32(2)
+		bill := &domain.Bill{ID: 10, DebtorUserID: 1, CreditorUserID: 2, AmountCents: 500, Status: domain.BillStatusPending}
+		actions := []domain.BillAction{
+			{BillID: 10, ActionType: domain.BillActionTypeNoticeSent},
+			{BillID: 10, ActorUserID: &creditor, ActionType: domain.BillActionTypeCreditorAcknowledged},
+		}
+		billSvc.On("GetPaymentDetail", ctx, int32(1), int32(10), domain.BillActionFilter{Page: 1, PageSize: 50}).
+			Return(bill, actions, int32(2), true, nil).Once()
+		userSvc.On("GetUsersByIDs", ctx, []int32{1, 2, 2}).Return(map[int32]*domain.User{
+			1: {ID: 1, Name: "Alice"},
+			2: {ID: 2, Name: "Bob"},
+		}, nil).Once()
+
+		res, err := handler.GetPaymentDetail(ctx, &pb.GetPaymentDetailRequest{PaymentId: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, "Alice", res.Payment.DebtorName)
+		assert.Len(t, res.History, 2)
+		assert.Equal(t, "System", res.History[0].ActorName)
+		assert.Equal(t, "Bob", res.History[1].ActorName)
+		userSvc.AssertNumberOfCalls(t, "GetUsersByIDs", 1)
+		userSvc.AssertNotCalled(t, "GetUserProfile", mock.Anything, mock.Anything)
+	})
+
+	t.Run("History page and action types are passed through", func(t *testing.T) {
+		bill := &domain.Bill{ID: 11, DebtorUserID: 1, CreditorUserID: 2, AmountCents: 500, Status: domain.BillStatusDisputed}
+		actions := []domain.BillAction{{BillID: 11, ActionType: domain.BillActionTypeAdminComment}}
+		filter := domain.BillActionFilter{ActionTypes: []domain.BillActionType{domain.BillActionTypeAdminComment}, Page: 3, PageSize: 100}
+		billSvc.On("GetPaymentDetail", ctx, int32(1), int32(11), filter).Return(bill, actions, int32(201), false, nil).Once()
+		userSvc.On("GetUsersByIDs", ctx, mock.Anything).Return(map[int32]*domain.User{}, nil).Once()
+
+		res, err := handler.GetPaymentDetail(ctx, &pb.GetPaymentDetailRequest{
+			PaymentId:   11,
+			Pagination:  &pb.PaginationRequest{Page: 3, PageSize: 500},
+			ActionTypes: []string{"ADMIN_COMMENT"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, res.History, 1)
+		assert.Equal(t, int32(201), res.HistoryPagination.TotalCount)
+		assert.Equal(t, int32(3), res.HistoryPagination.Page)
+		assert.Equal(t, int32(100), res.HistoryPagination.PageSize)
+	})
+}
+
+func TestBillSplitHandler_ResolveDispute(t *testing.T) {
+	billSvc := new(MockBillSplitService)
+	handler := grpc.NewBillSplitHandler(billSvc, new(MockUserService))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "1"))
+
+	billSvc.On("ResolveDispute", ctx, int32(1), int32(9), string(domain.ResolutionOutcomeCreditorFault), "Receipt photo was forged").
+		Return(nil).Once()
+
+	res, err := handler.ResolveDispute(ctx, &pb.ResolveDisputeRequest{
+		PaymentId:  9,
+		Resolution: pb.DisputeResolution_CREDITOR_AT_FAULT,
+		Notes:      "Receipt photo was forged",
+	})
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+	billSvc.AssertExpectations(t)
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"context"
+	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -29,12 +31,12 @@ func (m *MockToolService) DeleteTool(ctx context.Context, id int32) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *MockToolService) ListTools(ctx context.Context, orgID, requestingUserID int32, page, pageSize int32) ([]domain.Tool, int32, error) {
-	args := m.Called(ctx, orgID, requestingUserID, page, pageSize)
+func (m *MockToolService) ListTools(ctx context.Context, orgID, requestingUserID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error) {
+	args := m.Called(ctx, orgID, requestingUserID, includeAdjacent, page, pageSize)
 	return args.Get(0).([]domain.Tool), args.Get(1).(int32), args.Error(2)
 }
-func (m *MockToolService) SearchTools(ctx context.Context, userID, orgID int32, metro, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
-	args := m.Called(ctx, userID, orgID, metro, query, categories, maxPrice, condition, page, pageSize)
+func (m *MockToolService) SearchTools(ctx context.Context, userID, orgID int32, metro string, includeAdjacent bool, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
+	args := m.Called(ctx, userID, orgID, metro, includeAdjacent, query, categories, maxPrice, condition, page, pageSize)
 	return args.Get(0).([]domain.Tool), args.Get(1).(int32), args.Error(2)
 }
 func (m *MockToolService) ListMyTools(ctx context.Context, userID, page, pageSize int32) ([]domain.Tool, int32, error) {
@@ -94,6 +96,17 @@ func (m *MockRentalService) GetRental(ctx context.Context, userID, rentalID int3
 	args := m.Called(ctx, userID, rentalID)
 	return args.Get(0).(*domain.Rental), args.Error(1)
 }
+func (m *MockRentalService) GetRentalHistory(ctx context.Context, userID, rentalID int32) ([]domain.RentalEvent, error) {
+	args := m.Called(ctx, userID, rentalID)
+	return args.Get(0).([]domain.RentalEvent), args.Error(1)
+}
+func (m *MockRentalService) GetRentalReceipt(ctx context.Context, userID, rentalID int32) (*domain.RentalReceipt, error) {
+	args := m.Called(ctx, userID, rentalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RentalReceipt), args.Error(1)
+}
 func (m *MockRentalService) ListRentals(ctx context.Context, userID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
 	args := m.Called(ctx, userID, orgID, statuses, page, pageSize)
 	return args.Get(0).([]domain.Rental), args.Get(1).(int32), args.Error(2)
@@ -102,6 +115,10 @@ func (m *MockRentalService) ListLendings(ctx context.Context, userID, orgID int3
 	args := m.Called(ctx, userID, orgID, statuses, page, pageSize)
 	return args.Get(0).([]domain.Rental), args.Get(1).(int32), args.Error(2)
 }
+func (m *MockRentalService) ListActionableRentals(ctx context.Context, userID, orgID int32) ([]domain.Rental, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).([]domain.Rental), args.Error(1)
+}
 func (m *MockRentalService) ActivateRental(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error) {
 	args := m.Called(ctx, ownerID, rentalID)
 	if args.Get(0) == nil {
@@ -144,14 +161,46 @@ func (m *MockRentalService) CancelReturnDateChange(ctx context.Context, renterID
 	}
 	return args.Get(0).(*domain.Rental), args.Error(1)
 }
-func (m *MockRentalService) ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
-	args := m.Called(ctx, ownerID, toolID, orgID, statuses, page, pageSize)
+func (m *MockRentalService) AcceptEarlyReturn(ctx context.Context, renterID, rentalID int32) (*domain.Rental, error) {
+	args := m.Called(ctx, renterID, rentalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Rental), args.Error(1)
+}
+func (m *MockRentalService) DeclineEarlyReturn(ctx context.Context, renterID, rentalID int32, reason string) (*domain.Rental, error) {
+	args := m.Called(ctx, renterID, rentalID, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Rental), args.Error(1)
+}
+func (m *MockRentalService) CancelEarlyReturn(ctx context.Context, ownerID, rentalID int32) (*domain.Rental, error) {
+	args := m.Called(ctx, ownerID, rentalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Rental), args.Error(1)
+}
+func (m *MockRentalService) ListToolRentals(ctx context.Context, ownerID, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error) {
+	args := m.Called(ctx, ownerID, toolID, orgID, statuses, fromDate, toDate, page, pageSize)
 	return args.Get(0).([]domain.Rental), args.Get(1).(int32), args.Error(2)
 }
+func (m *MockRentalService) GetBatchAvailability(ctx context.Context, userID int32, toolIDs []int32, fromDate, toDate string) (map[int32][]domain.ToolBusyRange, error) {
+	args := m.Called(ctx, userID, toolIDs, fromDate, toDate)
+	return args.Get(0).(map[int32][]domain.ToolBusyRange), args.Error(1)
+}
 func (m *MockRentalService) Update(ctx context.Context, rental *domain.Rental) error {
 	args := m.Called(ctx, rental)
 	return args.Error(0)
 }
+func (m *MockRentalService) GetActivityCounts(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RentalActivityCounts), args.Error(1)
+}
 
 // MockOrganizationService
 type MockOrganizationService struct {
@@ -182,6 +231,17 @@ func (m *MockOrganizationService) SearchOrganizations(ctx context.Context, name,
 	args := m.Called(ctx, name, metro)
 	return args.Get(0).([]domain.Organization), args.Error(1)
 }
+func (m *MockOrganizationService) ListMetros(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *MockOrganizationService) SetAdjacentMetros(ctx context.Context, callerID, orgID int32, metros []string) (*domain.Organization, error) {
+	args := m.Called(ctx, callerID, orgID, metros)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
 func (m *MockOrganizationService) UpdateOrganization(ctx context.Context, callerID int32, org *domain.Organization) error {
 	args := m.Called(ctx, callerID, org)
 	return args.Error(0)
@@ -197,6 +257,10 @@ func (m *MockOrganizationService) JoinOrganizationWithInvite(ctx context.Context
 	}
 	return args.Get(0).(*domain.Organization), args.Get(1).(*domain.User), args.Error(2)
 }
+func (m *MockOrganizationService) ListMembers(ctx context.Context, adminID, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error) {
+	args := m.Called(ctx, adminID, orgID, filter)
+	return args.Get(0).([]domain.User), args.Get(1).([]domain.UserOrg), args.Get(2).(int32), args.Error(3)
+}
 
 // MockUserService
 type MockUserService struct {
@@ -211,7 +275,176 @@ func (m *MockUserService) GetUserProfile(ctx context.Context, userID int32) (*do
 	return args.Get(0).(*domain.User), args.Get(1).([]domain.Organization), args.Get(2).([]domain.UserOrg), args.Error(3)
 }
 
+func (m *MockUserService) GetUsersByIDs(ctx context.Context, ids []int32) (map[int32]*domain.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[int32]*domain.User), args.Error(1)
+}
+
 func (m *MockUserService) UpdateProfile(ctx context.Context, userID int32, name, email, phone, avatarURL string) error {
 	args := m.Called(ctx, userID, name, email, phone, avatarURL)
 	return args.Error(0)
 }
+func (m *MockUserService) UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error {
+	args := m.Called(ctx, userID, pref)
+	return args.Error(0)
+}
+
+func (m *MockUserService) ExportMyData(ctx context.Context, userID int32) ([]byte, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+// MockBillSplitService
+type MockBillSplitService struct {
+	mock.Mock
+}
+
+func (m *MockBillSplitService) GetGlobalBillSplitSummary(ctx context.Context, userID int32) (int32, int32, int32, int32, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int32), args.Get(1).(int32), args.Get(2).(int32), args.Get(3).(int32), args.Error(4)
+}
+
+func (m *MockBillSplitService) GetOrganizationBillSplitSummary(ctx context.Context, userID int32) ([]domain.Organization, []int32, []int32, []int32, []int32, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]domain.Organization), args.Get(1).([]int32), args.Get(2).([]int32), args.Get(3).([]int32), args.Get(4).([]int32), args.Error(5)
+}
+
+func (m *MockBillSplitService) ListPayments(ctx context.Context, userID, orgID int32, showHistory bool, page, pageSize int32) ([]domain.Bill, int32, error) {
+	args := m.Called(ctx, userID, orgID, showHistory, page, pageSize)
+	return args.Get(0).([]domain.Bill), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockBillSplitService) GetPaymentDetail(ctx context.Context, userID, paymentID int32, filter domain.BillActionFilter) (*domain.Bill, []domain.BillAction, int32, bool, error) {
+	args := m.Called(ctx, userID, paymentID, filter)
+	if args.Get(0) == nil {
+		return nil, nil, 0, false, args.Error(4)
+	}
+	return args.Get(0).(*domain.Bill), args.Get(1).([]domain.BillAction), args.Get(2).(int32), args.Bool(3), args.Error(4)
+}
+
+func (m *MockBillSplitService) AcknowledgePayment(ctx context.Context, userID, paymentID int32) error {
+	args := m.Called(ctx, userID, paymentID)
+	return args.Error(0)
+}
+
+func (m *MockBillSplitService) VoidBill(ctx context.Context, userID, paymentID int32, notes string) error {
+	args := m.Called(ctx, userID, paymentID, notes)
+	return args.Error(0)
+}
+
+func (m *MockBillSplitService) ListDisputedPayments(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error) {
+	args := m.Called(ctx, adminID, orgID)
+	return args.Get(0).([]domain.Bill), args.Error(1)
+}
+
+func (m *MockBillSplitService) GetDisputeDetail(ctx context.Context, adminID, paymentID int32) (*domain.DisputeDetail, error) {
+	args := m.Called(ctx, adminID, paymentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DisputeDetail), args.Error(1)
+}
+
+func (m *MockBillSplitService) ListResolvedDisputes(ctx context.Context, adminID, orgID int32) ([]domain.Bill, error) {
+	args := m.Called(ctx, adminID, orgID)
+	return args.Get(0).([]domain.Bill), args.Error(1)
+}
+
+func (m *MockBillSplitService) ResolveDispute(ctx context.Context, adminID, paymentID int32, resolution, notes string) error {
+	args := m.Called(ctx, adminID, paymentID, resolution, notes)
+	return args.Error(0)
+}
+
+func (m *MockBillSplitService) PreviewSettlement(ctx context.Context, adminID, orgID int32) (*domain.SettlementPreview, error) {
+	args := m.Called(ctx, adminID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SettlementPreview), args.Error(1)
+}
+
+func (m *MockBillSplitService) RunSettlement(ctx context.Context, adminID, orgID int32, month string) ([]domain.Bill, error) {
+	args := m.Called(ctx, adminID, orgID, month)
+	return args.Get(0).([]domain.Bill), args.Error(1)
+}
+
+func (m *MockBillSplitService) GetSettlementThreshold(ctx context.Context, adminID, orgID int32) (*domain.SettlementThreshold, error) {
+	args := m.Called(ctx, adminID, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SettlementThreshold), args.Error(1)
+}
+
+func (m *MockBillSplitService) SetSettlementThreshold(ctx context.Context, adminID, orgID int32, thresholdCents *int32) (*domain.SettlementThreshold, error) {
+	args := m.Called(ctx, adminID, orgID, thresholdCents)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.SettlementThreshold), args.Error(1)
+}
+
+// MockNotificationService
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationService) GetNotifications(ctx context.Context, userID int32, notificationType string, page, pageSize int32) ([]domain.Notification, int32, error) {
+	args := m.Called(ctx, userID, notificationType, page, pageSize)
+	return args.Get(0).([]domain.Notification), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockNotificationService) UnreadCount(ctx context.Context, userID, orgID int32) (int32, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkAsRead(ctx context.Context, userID int32, notificationID int64) error {
+	args := m.Called(ctx, userID, notificationID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *MockNotificationService) SyncDeviceToken(ctx context.Context, userID int32, fcmToken, androidDeviceID, deviceName string) error {
+	args := m.Called(ctx, userID, fcmToken, androidDeviceID, deviceName)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) ReportMessageEvent(ctx context.Context, userID int32, notificationID int64, eventType string, eventTime time.Time) error {
+	args := m.Called(ctx, userID, notificationID, eventType, eventTime)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) Dispatch(ctx context.Context, n *domain.Notification) error {
+	args := m.Called(ctx, n)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) DispatchSilent(ctx context.Context, n *domain.Notification) error {
+	args := m.Called(ctx, n)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) RetryFailed(ctx context.Context, limit int32) (int, int, error) {
+	args := m.Called(ctx, limit)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockNotificationService) Subscribe(userID int32) (<-chan domain.Notification, func()) {
+	args := m.Called(userID)
+	return args.Get(0).(<-chan domain.Notification), args.Get(1).(func())
+}
+
+func (m *MockNotificationService) SetPushService(pushSvc service.PushNotificationService) {
+	m.Called(pushSvc)
+}
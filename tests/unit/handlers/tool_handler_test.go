@@ -58,7 +58,7 @@ func TestToolHandler_SearchTools(t *testing.T) {
 
 		tools := []domain.Tool{{ID: 1, Name: "Drill", PricePerDayCents: 500, Status: domain.ToolStatusAvailable}}
 		// Note: userID is now passed, assuming context has userID 1 (default in helper/mock)
-		svc.On("SearchTools", ctx, int32(1), int32(1), "", "Drill", mock.Anything, int32(0), "", int32(1), int32(10)).
+		svc.On("SearchTools", ctx, int32(1), int32(1), "", false, "Drill", mock.Anything, int32(0), "", int32(1), int32(10)).
 			Return(tools, int32(1), nil)
 
 		res, err := handler.SearchTools(ctx, req)
@@ -68,3 +68,36 @@ func TestToolHandler_SearchTools(t *testing.T) {
 		assert.Equal(t, "Drill", res.Tools[0].Name)
 	})
 }
+
+func TestToolHandler_GetToolDetail(t *testing.T) {
+	svc := new(MockToolService)
+	handler := grpc.NewToolHandler(svc)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "9"))
+
+	t.Run("Success", func(t *testing.T) {
+		owner := &domain.User{ID: 5, Name: "Owner", Email: "owner@example.com", PhoneNumber: "555-0100",
+			Orgs: []domain.Organization{{ID: 1, Name: "Shared Org"}}}
+		tool := &domain.Tool{ID: 7, OwnerID: 5, Name: "Drill", Owner: owner}
+		images := []domain.ToolImage{
+			{ID: 11, ToolID: 7, IsPrimary: true, DisplayOrder: 2},
+			{ID: 12, ToolID: 7, DisplayOrder: 0},
+		}
+		svc.On("GetTool", mock.Anything, int32(7), int32(9)).Return(tool, images, nil)
+
+		res, err := handler.GetToolDetail(ctx, &pb.GetToolDetailRequest{ToolId: 7})
+		assert.NoError(t, err)
+		assert.Equal(t, "Drill", res.Tool.Name)
+		if assert.Len(t, res.Images, 2) {
+			assert.Equal(t, int32(11), res.Images[0].Id)
+			assert.True(t, res.Images[0].IsPrimary)
+			assert.Equal(t, int32(12), res.Images[1].Id)
+		}
+		if assert.NotNil(t, res.Owner) {
+			assert.Equal(t, "Owner", res.Owner.Name)
+			assert.Empty(t, res.Owner.Email)
+			assert.Empty(t, res.Owner.Phone)
+			assert.Len(t, res.Owner.Orgs, 1)
+		}
+		assert.Empty(t, res.Tool.Owner.Email)
+	})
+}
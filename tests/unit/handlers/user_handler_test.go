@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/api/grpc"
+	"ubertool-backend-trusted/internal/domain"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUserHandler_GetDashboard(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "4"))
+
+	t.Run("Aggregates the underlying services", func(t *testing.T) {
+		rentalSvc := new(MockRentalService)
+		noteSvc := new(MockNotificationService)
+		billSvc := new(MockBillSplitService)
+		handler := grpc.NewUserHandler(new(MockUserService), rentalSvc, noteSvc, billSvc)
+
+		rentalSvc.On("GetActivityCounts", ctx, int32(4)).Return(&domain.RentalActivityCounts{ActiveRentals: 2, ActiveLendings: 1, PendingActions: 3}, nil)
+		noteSvc.On("UnreadCount", ctx, int32(4), int32(0)).Return(int32(5), nil)
+		billSvc.On("GetGlobalBillSplitSummary", ctx, int32(4)).Return(int32(1), int32(2), int32(0), int32(1), nil)
+
+		res, err := handler.GetDashboard(ctx, &pb.GetDashboardRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), res.ActiveRentalsCount)
+		assert.Equal(t, int32(1), res.ActiveLendingsCount)
+		assert.Equal(t, int32(3), res.PendingRentalActionsCount)
+		assert.Equal(t, int32(5), res.UnreadNotificationsCount)
+		assert.Equal(t, int32(1), res.BillSplitSummary.PaymentsToMake)
+		assert.Equal(t, int32(2), res.BillSplitSummary.ReceiptsToVerify)
+		assert.Equal(t, int32(0), res.BillSplitSummary.PaymentsInDispute)
+		assert.Equal(t, int32(1), res.BillSplitSummary.ReceiptsInDispute)
+	})
+
+	t.Run("Service error is returned", func(t *testing.T) {
+		rentalSvc := new(MockRentalService)
+		noteSvc := new(MockNotificationService)
+		billSvc := new(MockBillSplitService)
+		handler := grpc.NewUserHandler(new(MockUserService), rentalSvc, noteSvc, billSvc)
+
+		rentalSvc.On("GetActivityCounts", ctx, int32(4)).Return(nil, errors.New("db down"))
+
+		_, err := handler.GetDashboard(ctx, &pb.GetDashboardRequest{})
+		assert.EqualError(t, err, "db down")
+		noteSvc.AssertNotCalled(t, "UnreadCount", mock.Anything, mock.Anything, mock.Anything)
+		billSvc.AssertNotCalled(t, "GetGlobalBillSplitSummary", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,60 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpapi "ubertool-backend-trusted/internal/api/http"
+)
+
+func newHealthRouter(t *testing.T) (*mux.Router, sqlmock.Sqlmock) {
+	db, dbMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	router := mux.NewRouter()
+	httpapi.RegisterHealthRoutes(router, db)
+	return router, dbMock
+}
+
+func TestHealthRoutes(t *testing.T) {
+	t.Run("Healthz does not touch the database", func(t *testing.T) {
+		router, dbMock := newHealthRouter(t)
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Readyz is OK when the database answers", func(t *testing.T) {
+		router, dbMock := newHealthRouter(t)
+		dbMock.ExpectPing()
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Readyz is unavailable when the database is down", func(t *testing.T) {
+		router, dbMock := newHealthRouter(t)
+		dbMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "database unavailable")
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,226 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	httpapi "ubertool-backend-trusted/internal/api/http"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+	"ubertool-backend-trusted/internal/storage"
+)
+
+func newSignedDownloadServer(t *testing.T) (*storage.MockStorageService, *httptest.Server) {
+	mockStorage, err := storage.NewMockStorageService("", t.TempDir(), "test-signing-secret")
+	require.NoError(t, err)
+	require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", strings.NewReader("png-bytes")))
+	require.NoError(t, mockStorage.SaveFile("tools/1/2/saw.webp", strings.NewReader("webp-bytes")))
+	require.NoError(t, mockStorage.SaveFile("tools/1/2/clamp.gif", strings.NewReader("gif-bytes")))
+
+	router := mux.NewRouter()
+	httpapi.RegisterMockStorageRoutes(router, mockStorage, []string{"image/jpeg", "image/png", "image/webp"})
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return mockStorage, server
+}
+
+func TestMockDownload_SignedURL(t *testing.T) {
+	mockStorage, server := newSignedDownloadServer(t)
+
+	t.Run("Valid signature serves file", func(t *testing.T) {
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(context.Background(), "tools/1/2/drill.png", time.Minute)
+		require.NoError(t, err)
+
+		resp, err := http.Get(server.URL + downloadURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "png-bytes", string(body))
+		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	})
+
+	t.Run("Content type follows the allowlist", func(t *testing.T) {
+		for key, want := range map[string]string{
+			"tools/1/2/saw.webp":  "image/webp",
+			"tools/1/2/clamp.gif": "application/octet-stream",
+		} {
+			downloadURL, err := mockStorage.GeneratePresignedDownloadURL(context.Background(), key, time.Minute)
+			require.NoError(t, err)
+
+			resp, err := http.Get(server.URL + downloadURL)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode, key)
+			assert.Equal(t, want, resp.Header.Get("Content-Type"), key)
+		}
+	})
+
+	t.Run("Expired URL is rejected", func(t *testing.T) {
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(context.Background(), "tools/1/2/drill.png", -time.Minute)
+		require.NoError(t, err)
+
+		resp, err := http.Get(server.URL + downloadURL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Tampered key is rejected", func(t *testing.T) {
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(context.Background(), "tools/1/2/drill.png", time.Minute)
+		require.NoError(t, err)
+
+		u, err := url.Parse(downloadURL)
+		require.NoError(t, err)
+		q := u.Query()
+		q.Set("key", "tools/1/3/other.png")
+		u.RawQuery = q.Encode()
+
+		resp, err := http.Get(server.URL + u.String())
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Missing signature is rejected", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/api/v1/download/abc?key=tools/1/2/drill.png")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestMockUpload_AllowedTypes(t *testing.T) {
+	mockStorage, server := newSignedDownloadServer(t)
+
+	tests := []struct {
+		contentType string
+		key         string
+		wantStatus  int
+	}{
+		{"image/webp", "tools/1/5/upload.webp", http.StatusOK},
+		{"image/png", "tools/1/5/upload.png", http.StatusOK},
+		{"image/gif", "tools/1/5/upload.gif", http.StatusBadRequest},
+		{"text/plain", "tools/1/5/upload.txt", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, server.URL+"/api/v1/upload/abc?key="+url.QueryEscape(tt.key), strings.NewReader("image-bytes"))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			exists, _, err := mockStorage.FileExists(context.Background(), tt.key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, exists)
+		})
+	}
+}
+
+func TestMockDelete_SignedURL(t *testing.T) {
+	mockStorage, server := newSignedDownloadServer(t)
+	ctx := context.Background()
+
+	doDelete := func(t *testing.T, path string) *http.Response {
+		req, err := http.NewRequest(http.MethodDelete, server.URL+path, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("Download URL cannot delete", func(t *testing.T) {
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(ctx, "tools/1/2/drill.png", time.Minute)
+		require.NoError(t, err)
+		u, err := url.Parse(downloadURL)
+		require.NoError(t, err)
+
+		resp := doDelete(t, "/api/v1/files/abc?"+u.RawQuery)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		exists, _, err := mockStorage.FileExists(ctx, "tools/1/2/drill.png")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Delete removes file and GET returns 404", func(t *testing.T) {
+		deleteURL, err := mockStorage.GeneratePresignedDeleteURL(ctx, "tools/1/2/drill.png", time.Minute)
+		require.NoError(t, err)
+
+		resp := doDelete(t, deleteURL)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		exists, _, err := mockStorage.FileExists(ctx, "tools/1/2/drill.png")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(ctx, "tools/1/2/drill.png", time.Minute)
+		require.NoError(t, err)
+		getResp, err := http.Get(server.URL + downloadURL)
+		require.NoError(t, err)
+		defer getResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, getResp.StatusCode)
+	})
+
+	t.Run("Path traversal is rejected", func(t *testing.T) {
+		deleteURL, err := mockStorage.GeneratePresignedDeleteURL(ctx, "../../outside.png", time.Minute)
+		require.NoError(t, err)
+
+		resp := doDelete(t, deleteURL)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.ErrorIs(t, mockStorage.DeleteFile(ctx, "../../outside.png"), storage.ErrInvalidKey)
+	})
+}
+
+func TestImageStorageService_DeleteImage_RemovesMockFile(t *testing.T) {
+	mockStorage, server := newSignedDownloadServer(t)
+	ctx := context.Background()
+	require.NoError(t, mockStorage.SaveFile("tools/1/2/drill_thumb.png", strings.NewReader("thumb-bytes")))
+
+	toolRepo := new(MockToolRepo)
+	svc := service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), mockStorage, service.ImageStorageOptions{DownloadURLExpiry: time.Minute})
+
+	toolRepo.On("GetImageByID", ctx, int32(2)).Return(&domain.ToolImage{
+		ID: 2, ToolID: 1, FilePath: "tools/1/2/drill.png", ThumbnailPath: "tools/1/2/drill_thumb.png",
+	}, nil)
+	toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+	toolRepo.On("DeleteImage", ctx, int32(2)).Return(nil)
+
+	require.NoError(t, svc.DeleteImage(ctx, 7, 2, 1))
+
+	for _, key := range []string{"tools/1/2/drill.png", "tools/1/2/drill_thumb.png"} {
+		exists, _, err := mockStorage.FileExists(ctx, key)
+		require.NoError(t, err)
+		assert.False(t, exists, key)
+
+		downloadURL, err := mockStorage.GeneratePresignedDownloadURL(ctx, key, time.Minute)
+		require.NoError(t, err)
+		resp, err := http.Get(server.URL + downloadURL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	toolRepo.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestImageStorageService_SetPrimaryImage(t *testing.T) {
+	ctx := context.Background()
+	newSvc := func(toolRepo *MockToolRepo) service.ImageStorageService {
+		return service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), nil, service.ImageStorageOptions{})
+	}
+
+	t.Run("Delegates the swap to the repository", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("GetImageByID", ctx, int32(3)).Return(&domain.ToolImage{ID: 3, ToolID: 1, Status: "CONFIRMED"}, nil)
+		toolRepo.On("SetPrimaryImage", ctx, int32(1), int32(3)).Return(nil)
+
+		require.NoError(t, newSvc(toolRepo).SetPrimaryImage(ctx, 7, 1, 3))
+		toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("Image of another tool is rejected", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("GetImageByID", ctx, int32(3)).Return(&domain.ToolImage{ID: 3, ToolID: 2, Status: "CONFIRMED"}, nil)
+
+		assert.ErrorContains(t, newSvc(toolRepo).SetPrimaryImage(ctx, 7, 1, 3), "does not belong")
+		toolRepo.AssertNotCalled(t, "SetPrimaryImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Tool without images", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("GetImageByID", ctx, int32(3)).Return(nil, sql.ErrNoRows)
+
+		assert.ErrorContains(t, newSvc(toolRepo).SetPrimaryImage(ctx, 7, 1, 3), "image not found")
+		toolRepo.AssertNotCalled(t, "SetPrimaryImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestImageStorageService_DeleteImage_Primary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Promotion is left to the repository transaction", func(t *testing.T) {
+		mockStorage, _ := newSignedDownloadServer(t)
+		toolRepo := new(MockToolRepo)
+		svc := service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), mockStorage, service.ImageStorageOptions{})
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(&domain.ToolImage{
+			ID: 2, ToolID: 1, FilePath: "tools/1/2/drill.png", IsPrimary: true, Status: "CONFIRMED",
+		}, nil)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("DeleteImage", ctx, int32(2)).Return(nil)
+
+		require.NoError(t, svc.DeleteImage(ctx, 7, 2, 1))
+		toolRepo.AssertExpectations(t)
+		toolRepo.AssertNotCalled(t, "SetPrimaryImage", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Image of another tool is rejected", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc := service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), nil, service.ImageStorageOptions{})
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(&domain.ToolImage{ID: 2, ToolID: 9, IsPrimary: true}, nil)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+
+		assert.ErrorContains(t, svc.DeleteImage(ctx, 7, 2, 1), "does not belong")
+		toolRepo.AssertNotCalled(t, "DeleteImage", mock.Anything, mock.Anything)
+	})
+}
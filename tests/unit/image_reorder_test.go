@@ -0,0 +1,72 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestImageStorageService_ReorderToolImages(t *testing.T) {
+	ctx := context.Background()
+	current := []domain.ToolImage{
+		{ID: 1, ToolID: 5, IsPrimary: true, DisplayOrder: 0},
+		{ID: 2, ToolID: 5, DisplayOrder: 1},
+		{ID: 3, ToolID: 5, DisplayOrder: 2},
+	}
+	setup := func() (service.ImageStorageService, *MockToolRepo) {
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetByID", ctx, int32(5)).Return(&domain.Tool{ID: 5, OwnerID: 7}, nil)
+		return service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), nil, service.ImageStorageOptions{}), toolRepo
+	}
+
+	t.Run("Valid reorder", func(t *testing.T) {
+		svc, toolRepo := setup()
+		reordered := []domain.ToolImage{
+			{ID: 1, ToolID: 5, IsPrimary: true, DisplayOrder: 2},
+			{ID: 3, ToolID: 5, DisplayOrder: 0},
+			{ID: 2, ToolID: 5, DisplayOrder: 1},
+		}
+		toolRepo.On("GetImages", ctx, int32(5)).Return(current, nil).Once()
+		toolRepo.On("ReorderImages", ctx, int32(5), []int32{3, 2, 1}).Return(nil)
+		toolRepo.On("GetImages", ctx, int32(5)).Return(reordered, nil).Once()
+
+		images, err := svc.ReorderToolImages(ctx, 7, 5, []int32{3, 2, 1})
+		require.NoError(t, err)
+		assert.Equal(t, reordered, images)
+		toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("Caller does not own the tool", func(t *testing.T) {
+		svc, toolRepo := setup()
+
+		_, err := svc.ReorderToolImages(ctx, 8, 5, []int32{3, 2, 1})
+		assert.ErrorContains(t, err, "unauthorized")
+		toolRepo.AssertNotCalled(t, "ReorderImages", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	rejected := []struct {
+		name string
+		ids  []int32
+		want string
+	}{
+		{"Partial list", []int32{2, 1}, "must list all 3 images"},
+		{"Image of another tool", []int32{3, 2, 1, 9}, "image 9 does not belong"},
+		{"Duplicate image", []int32{3, 3, 2, 1}, "image 3 does not belong to this tool or is listed twice"},
+	}
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, toolRepo := setup()
+			toolRepo.On("GetImages", ctx, int32(5)).Return(current, nil)
+
+			_, err := svc.ReorderToolImages(ctx, 7, 5, tc.ids)
+			assert.ErrorContains(t, err, tc.want)
+			toolRepo.AssertNotCalled(t, "ReorderImages", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+	"ubertool-backend-trusted/internal/storage"
+)
+
+// encodeTestPNG returns a w×h PNG filled with a single color
+func encodeTestPNG(t *testing.T, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 80, B: 20, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestImageStorageService_ConfirmImageUpload_Thumbnail(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, upload []byte) (service.ImageStorageService, *MockToolRepo, *storage.MockStorageService) {
+		mockStorage, err := storage.NewMockStorageService("", t.TempDir(), "test-signing-secret")
+		require.NoError(t, err)
+		require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", bytes.NewReader(upload)))
+
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(&domain.ToolImage{
+			ID: 2, ToolID: 1, UserID: 7, FilePath: "tools/1/2/drill.png", MimeType: "image/png", Status: "PENDING",
+		}, nil)
+		svc := service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), mockStorage, service.ImageStorageOptions{DownloadURLExpiry: time.Minute, ThumbnailMaxDim: 100})
+		return svc, toolRepo, mockStorage
+	}
+
+	decodeThumb := func(t *testing.T, s *storage.MockStorageService, key string) image.Config {
+		r, err := s.ReadFile(key)
+		require.NoError(t, err)
+		defer r.Close()
+		cfg, format, err := image.DecodeConfig(r)
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		return cfg
+	}
+
+	t.Run("Large image is scaled to the configured bound", func(t *testing.T) {
+		svc, toolRepo, mockStorage := setup(t, encodeTestPNG(t, 400, 200))
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{}, nil)
+		toolRepo.On("UpdateImage", ctx, mock.MatchedBy(func(img *domain.ToolImage) bool {
+			return img.Status == "CONFIRMED" && img.ThumbnailPath == "tools/1/2/thumb_drill.jpg"
+		})).Return(nil)
+
+		img, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "tools/1/2/thumb_drill.jpg", img.ThumbnailPath)
+
+		cfg := decodeThumb(t, mockStorage, img.ThumbnailPath)
+		assert.Equal(t, 100, cfg.Width)
+		assert.Equal(t, 50, cfg.Height)
+		toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("Small image keeps its size", func(t *testing.T) {
+		svc, toolRepo, mockStorage := setup(t, encodeTestPNG(t, 40, 60))
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{}, nil)
+		toolRepo.On("UpdateImage", ctx, mock.Anything).Return(nil)
+
+		img, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		require.NoError(t, err)
+
+		cfg := decodeThumb(t, mockStorage, img.ThumbnailPath)
+		assert.Equal(t, 40, cfg.Width)
+		assert.Equal(t, 60, cfg.Height)
+	})
+
+	t.Run("Corrupt upload is rejected and stays pending", func(t *testing.T) {
+		svc, toolRepo, mockStorage := setup(t, []byte(strings.Repeat("not an image", 10)))
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{}, nil)
+
+		img, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		assert.ErrorContains(t, err, "not a valid image")
+		assert.Nil(t, img)
+		toolRepo.AssertNotCalled(t, "UpdateImage", mock.Anything, mock.Anything)
+
+		exists, _, err := mockStorage.FileExists(ctx, "tools/1/2/thumb_drill.jpg")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
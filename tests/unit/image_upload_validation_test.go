@@ -0,0 +1,133 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+	"ubertool-backend-trusted/internal/storage"
+)
+
+func newValidatingImageService(t *testing.T, toolRepo *MockToolRepo) (service.ImageStorageService, *storage.MockStorageService) {
+	mockStorage, err := storage.NewMockStorageService("http://localhost", t.TempDir(), "test-signing-secret")
+	require.NoError(t, err)
+	svc := service.NewImageStorageService(toolRepo, new(MockUserRepo), new(MockOrganizationRepo), mockStorage, service.ImageStorageOptions{
+		DownloadURLExpiry: time.Minute,
+		AllowedTypes:      []string{"image/jpeg", "image/png", "image/webp"},
+		MaxImageBytes:     4096,
+		MaxImagesPerTool:  2,
+	})
+	return svc, mockStorage
+}
+
+func TestImageStorageService_GetUploadUrl_Validation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Allowed type under the cap", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, _ := newValidatingImageService(t, toolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{{ID: 1}}, nil)
+		toolRepo.On("CreateImage", ctx, mock.Anything).Return(nil)
+		toolRepo.On("UpdateImage", ctx, mock.Anything).Return(nil)
+
+		img, uploadURL, _, _, err := svc.GetUploadUrl(ctx, 7, "drill.webp", "image/webp", 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, "image/webp", img.MimeType)
+		assert.NotEmpty(t, uploadURL)
+	})
+
+	t.Run("Disallowed type", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, _ := newValidatingImageService(t, toolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+
+		_, _, _, _, err := svc.GetUploadUrl(ctx, 7, "manual.pdf", "application/pdf", 1, false)
+		assert.ErrorIs(t, err, service.ErrImageTypeNotAllowed)
+		toolRepo.AssertNotCalled(t, "CreateImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Tool already at the cap", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, _ := newValidatingImageService(t, toolRepo)
+		toolRepo.On("GetByID", ctx, int32(1)).Return(&domain.Tool{ID: 1, OwnerID: 7}, nil)
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{{ID: 1}, {ID: 2}}, nil)
+
+		_, _, _, _, err := svc.GetUploadUrl(ctx, 7, "drill.png", "image/png", 1, false)
+		assert.ErrorIs(t, err, service.ErrTooManyImages)
+		toolRepo.AssertNotCalled(t, "CreateImage", mock.Anything, mock.Anything)
+	})
+}
+
+func TestImageStorageService_ConfirmImageUpload_Validation(t *testing.T) {
+	ctx := context.Background()
+	pending := func(mime string) *domain.ToolImage {
+		return &domain.ToolImage{ID: 2, ToolID: 1, UserID: 7, FilePath: "tools/1/2/drill.png", MimeType: mime, Status: "PENDING"}
+	}
+
+	t.Run("Oversized file is rejected and removed", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, mockStorage := newValidatingImageService(t, toolRepo)
+		require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", bytes.NewReader(make([]byte, 5000))))
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(pending("image/png"), nil)
+
+		_, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		assert.ErrorIs(t, err, service.ErrImageTooLarge)
+		exists, _, err := mockStorage.FileExists(ctx, "tools/1/2/drill.png")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		toolRepo.AssertNotCalled(t, "UpdateImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Tool filled up while the upload was pending", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, mockStorage := newValidatingImageService(t, toolRepo)
+		require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", bytes.NewReader(encodeTestPNG(t, 10, 10))))
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(pending("image/png"), nil)
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{{ID: 3}, {ID: 4}}, nil)
+
+		_, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		assert.ErrorIs(t, err, service.ErrTooManyImages)
+		toolRepo.AssertNotCalled(t, "UpdateImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Decoded format must be allowed whatever the declared type", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, mockStorage := newValidatingImageService(t, toolRepo)
+		var buf bytes.Buffer
+		require.NoError(t, gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 4, 4), color.Palette{color.Black}), nil))
+		require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", &buf))
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(pending("image/png"), nil)
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{}, nil)
+
+		_, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		assert.ErrorIs(t, err, service.ErrImageTypeNotAllowed)
+		toolRepo.AssertNotCalled(t, "UpdateImage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Valid upload is confirmed", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		svc, mockStorage := newValidatingImageService(t, toolRepo)
+		data := encodeTestPNG(t, 10, 10)
+		require.NoError(t, mockStorage.SaveFile("tools/1/2/drill.png", bytes.NewReader(data)))
+		toolRepo.On("GetImageByID", ctx, int32(2)).Return(pending("image/png"), nil)
+		toolRepo.On("GetImages", ctx, int32(1)).Return([]domain.ToolImage{{ID: 3}}, nil)
+		toolRepo.On("UpdateImage", ctx, mock.Anything).Return(nil)
+
+		img, err := svc.ConfirmImageUpload(ctx, 7, 2, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "CONFIRMED", img.Status)
+		assert.Equal(t, int64(len(data)), img.FileSize)
+		assert.False(t, img.IsPrimary)
+	})
+}
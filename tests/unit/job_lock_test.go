@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+func TestAdvisoryLocker_TwoRunnersContend(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Replica A takes the ReconcileBalances lock first
+	dbMock.ExpectQuery(`SELECT pg_try_advisory_lock\(hashtext\('ubertool.job.' \|\| \$1\)\)`).
+		WithArgs("ReconcileBalances").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	// Replica B is refused and must not touch any data
+	dbMock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WithArgs("ReconcileBalances").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+	// Replica A releases when its run finishes
+	dbMock.ExpectQuery(`SELECT pg_advisory_unlock\(hashtext\('ubertool.job.' \|\| \$1\)\)`).
+		WithArgs("ReconcileBalances").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+
+	unlockA, acquired, err := jobs.NewAdvisoryLocker(db).TryLock(context.Background(), "ReconcileBalances")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	runnerB := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{}, &config.Config{})
+	runnerB.SetLocker(jobs.NewAdvisoryLocker(db))
+	runnerB.ReconcileBalances()
+
+	unlockA()
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+// stubLocker grants or refuses every lock and records releases
+type stubLocker struct {
+	grant    bool
+	locked   []string
+	released []string
+}
+
+func (l *stubLocker) TryLock(ctx context.Context, jobName string) (func(), bool, error) {
+	l.locked = append(l.locked, jobName)
+	if !l.grant {
+		return nil, false, nil
+	}
+	return func() { l.released = append(l.released, jobName) }, true, nil
+}
+
+func TestJobRunner_ReleasesLockAfterPanic(t *testing.T) {
+	locker := &stubLocker{grant: true}
+	// The store has no organization repository, so the job panics on its first call
+	jr := jobs.NewJobRunner(nil, &postgres.Store{}, &jobs.Services{}, &config.Config{})
+	jr.SetLocker(locker)
+
+	assert.NotPanics(t, jr.AutoActivateScheduledRentals)
+	assert.Equal(t, []string{"AutoActivateScheduledRentals"}, locker.locked)
+	assert.Equal(t, []string{"AutoActivateScheduledRentals"}, locker.released)
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestJobRunner_RecordsJobRuns(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Successful job is recorded as SUCCEEDED", func(t *testing.T) {
+		runRepo := new(MockJobRunRepo)
+		orgRepo := new(MockOrganizationRepo)
+		jr := jobs.NewJobRunner(nil, &postgres.Store{OrganizationRepository: orgRepo, JobRunRepository: runRepo}, &jobs.Services{}, &config.Config{})
+
+		runRepo.On("Start", ctx, "ReconcileBalances").Return(&domain.JobRun{ID: 11, JobName: "ReconcileBalances"}, nil).Once()
+		orgRepo.On("List", mock.Anything).Return([]domain.Organization{}, nil).Once()
+		runRepo.On("Finish", ctx, int64(11), domain.JobRunStatusSucceeded, "").Return(nil).Once()
+
+		jr.ReconcileBalances()
+
+		runRepo.AssertExpectations(t)
+	})
+
+	t.Run("Failing job is recorded as FAILED with its error", func(t *testing.T) {
+		runRepo := new(MockJobRunRepo)
+		orgRepo := new(MockOrganizationRepo)
+		jr := jobs.NewJobRunner(nil, &postgres.Store{OrganizationRepository: orgRepo, JobRunRepository: runRepo}, &jobs.Services{}, &config.Config{})
+
+		runRepo.On("Start", ctx, "PerformBillSplitting").Return(&domain.JobRun{ID: 12, JobName: "PerformBillSplitting"}, nil).Once()
+		orgRepo.On("List", mock.Anything).Return([]domain.Organization(nil), errors.New("connection reset")).Once()
+		runRepo.On("Finish", ctx, int64(12), domain.JobRunStatusFailed, "failed to get organizations: connection reset").Return(nil).Once()
+
+		jr.PerformBillSplitting()
+
+		runRepo.AssertExpectations(t)
+	})
+
+	t.Run("Panicking job is recorded as FAILED", func(t *testing.T) {
+		runRepo := new(MockJobRunRepo)
+		// No organization repository, so the job panics on its first call
+		jr := jobs.NewJobRunner(nil, &postgres.Store{JobRunRepository: runRepo}, &jobs.Services{}, &config.Config{})
+
+		runRepo.On("Start", ctx, "AutoActivateScheduledRentals").Return(&domain.JobRun{ID: 13, JobName: "AutoActivateScheduledRentals"}, nil).Once()
+		runRepo.On("Finish", ctx, int64(13), domain.JobRunStatusFailed, mock.MatchedBy(func(msg string) bool {
+			return strings.HasPrefix(msg, "panic:")
+		})).Return(nil).Once()
+
+		assert.NotPanics(t, jr.AutoActivateScheduledRentals)
+		runRepo.AssertExpectations(t)
+	})
+
+	t.Run("History failure does not stop the job", func(t *testing.T) {
+		runRepo := new(MockJobRunRepo)
+		orgRepo := new(MockOrganizationRepo)
+		jr := jobs.NewJobRunner(nil, &postgres.Store{OrganizationRepository: orgRepo, JobRunRepository: runRepo}, &jobs.Services{}, &config.Config{})
+
+		runRepo.On("Start", ctx, "ReconcileBalances").Return(nil, errors.New("table missing")).Once()
+		orgRepo.On("List", mock.Anything).Return([]domain.Organization{}, nil).Once()
+
+		jr.ReconcileBalances()
+
+		orgRepo.AssertExpectations(t)
+		runRepo.AssertNotCalled(t, "Finish", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAdminService_GetJobRuns(t *testing.T) {
+	ctx := context.Background()
+	mockUserRepo := new(MockUserRepo)
+	runRepo := new(MockJobRunRepo)
+	svc := service.NewAdminServiceWithOptions(nil, mockUserRepo, nil, nil, nil, nil, nil, nil, service.AdminOptions{JobRuns: runRepo})
+
+	t.Run("Super admin lists runs with the default limit", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(7)).Return(&domain.UserOrg{UserID: 1, OrgID: 7, Role: domain.UserOrgRoleSuperAdmin}, nil).Once()
+		runRepo.On("List", ctx, domain.JobRunFilter{JobName: "PerformBillSplitting", Limit: 50}).Return([]domain.JobRun{
+			{ID: 5, JobName: "PerformBillSplitting", Status: domain.JobRunStatusSucceeded},
+		}, nil).Once()
+
+		runs, err := svc.GetJobRuns(ctx, 1, 7, domain.JobRunFilter{JobName: "PerformBillSplitting"})
+		assert.NoError(t, err)
+		assert.Len(t, runs, 1)
+	})
+
+	t.Run("Org admin is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(7)).Return(&domain.UserOrg{UserID: 2, OrgID: 7, Role: domain.UserOrgRoleAdmin}, nil).Once()
+
+		_, err := svc.GetJobRuns(ctx, 2, 7, domain.JobRunFilter{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "super admin")
+	})
+
+	t.Run("Unknown status is rejected", func(t *testing.T) {
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(7)).Return(&domain.UserOrg{UserID: 1, OrgID: 7, Role: domain.UserOrgRoleSuperAdmin}, nil).Once()
+
+		_, err := svc.GetJobRuns(ctx, 1, 7, domain.JobRunFilter{Status: "DONE"})
+		assert.Error(t, err)
+	})
+
+	runRepo.AssertExpectations(t)
+}
@@ -1,17 +1,22 @@
 package unit
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"testing"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/security"
 	"ubertool-backend-trusted/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestLedgerService_GetBalance(t *testing.T) {
 	repo := new(MockLedgerRepo)
-	svc := service.NewLedgerService(repo)
+	svc := service.NewLedgerService(repo, new(MockUserRepo))
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -25,7 +30,7 @@ func TestLedgerService_GetBalance(t *testing.T) {
 
 func TestLedgerService_GetTransactions(t *testing.T) {
 	repo := new(MockLedgerRepo)
-	svc := service.NewLedgerService(repo)
+	svc := service.NewLedgerService(repo, new(MockUserRepo))
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
@@ -38,3 +43,104 @@ func TestLedgerService_GetTransactions(t *testing.T) {
 		assert.Equal(t, int32(100), res[0].Amount)
 	})
 }
+
+func TestLedgerService_ExportLedger(t *testing.T) {
+	ctx := context.Background()
+	rentalID := int32(42)
+	txs := []domain.LedgerTransaction{
+		{Amount: -1500, Type: domain.TransactionTypeRentalDebit, RelatedRentalID: &rentalID, Description: "Rental of Drill, 2 days", ChargedOn: "2026-03-02"},
+		{Amount: 200, Type: domain.TransactionTypeAdjustment, Description: "Settlement", ChargedOn: "2026-03-31"},
+	}
+
+	t.Run("Member exports own ledger as CSV", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		userRepo.On("GetUserOrg", ctx, int32(1), int32(2)).Return(&domain.UserOrg{UserID: 1, OrgID: 2, Role: domain.UserOrgRoleMember}, nil)
+		repo.On("StreamTransactions", ctx, int32(1), int32(2), "2026-03-01", "2026-03-31").Return(txs, nil)
+
+		var buf bytes.Buffer
+		err := svc.ExportLedger(ctx, 1, 0, 2, "2026-03-01", "2026-03-31", &buf)
+		assert.NoError(t, err)
+		assert.Equal(t, "date,type,amount_cents,description,related_rental_id\n"+
+			"2026-03-02,RENTAL_DEBIT,-1500,\"Rental of Drill, 2 days\",42\n"+
+			"2026-03-31,ADJUSTMENT,200,Settlement,\n", buf.String())
+	})
+
+	t.Run("Member cannot export another member", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		userRepo.On("GetUserOrg", ctx, int32(1), int32(2)).Return(&domain.UserOrg{UserID: 1, OrgID: 2, Role: domain.UserOrgRoleMember}, nil)
+
+		var buf bytes.Buffer
+		err := svc.ExportLedger(ctx, 1, 3, 2, "", "", &buf)
+		assert.ErrorContains(t, err, "only admins")
+		assert.Empty(t, buf.String())
+		repo.AssertNotCalled(t, "StreamTransactions", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin exports any member", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		userRepo.On("GetUserOrg", ctx, int32(9), int32(2)).Return(&domain.UserOrg{UserID: 9, OrgID: 2, Role: domain.UserOrgRoleAdmin}, nil)
+		repo.On("StreamTransactions", ctx, int32(3), int32(2), "", "").Return(txs[1:], nil)
+
+		var buf bytes.Buffer
+		err := svc.ExportLedger(ctx, 9, 3, 2, "", "", &buf)
+		assert.NoError(t, err)
+		assert.Contains(t, buf.String(), "2026-03-31,ADJUSTMENT,200,Settlement,")
+	})
+
+	t.Run("Non-member is rejected", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		userRepo.On("GetUserOrg", ctx, int32(1), int32(2)).Return(nil, sql.ErrNoRows)
+
+		err := svc.ExportLedger(ctx, 1, 0, 2, "", "", &bytes.Buffer{})
+		assert.ErrorContains(t, err, "not a member")
+	})
+
+	t.Run("Role in the token claims skips the membership lookup", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		repo.On("StreamTransactions", mock.Anything, int32(3), int32(2), "", "").Return(txs[1:], nil)
+
+		err := svc.ExportLedger(claimsContext(t, 9, security.OrgMembership{OrgID: 2, Role: "ADMIN"}), 9, 3, 2, "", "", &bytes.Buffer{})
+		assert.NoError(t, err)
+		userRepo.AssertNotCalled(t, "GetUserOrg", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Claims for another org or user fall back to the database", func(t *testing.T) {
+		repo := new(MockLedgerRepo)
+		userRepo := new(MockUserRepo)
+		svc := service.NewLedgerService(repo, userRepo)
+		otherOrg := claimsContext(t, 1, security.OrgMembership{OrgID: 7, Role: "ADMIN"})
+		otherUser := claimsContext(t, 9, security.OrgMembership{OrgID: 2, Role: "ADMIN"})
+		userRepo.On("GetUserOrg", mock.Anything, int32(1), int32(2)).Return(nil, sql.ErrNoRows).Twice()
+
+		assert.ErrorContains(t, svc.ExportLedger(otherOrg, 1, 0, 2, "", "", &bytes.Buffer{}), "not a member")
+		assert.ErrorContains(t, svc.ExportLedger(otherUser, 1, 0, 2, "", "", &bytes.Buffer{}), "not a member")
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid date", func(t *testing.T) {
+		svc := service.NewLedgerService(new(MockLedgerRepo), new(MockUserRepo))
+		err := svc.ExportLedger(ctx, 1, 0, 2, "03/01/2026", "", &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid date")
+	})
+}
+
+// claimsContext returns a context carrying the verified claims of a fresh access token for
+// userID with the given membership snapshot, as the auth interceptor would store them
+func claimsContext(t *testing.T, userID int32, orgs ...security.OrgMembership) context.Context {
+	tm := security.NewTokenManager("claims-test-secret-at-least-32-chars")
+	token, err := tm.GenerateAccessToken(userID, "", nil, orgs)
+	require.NoError(t, err)
+	claims, err := tm.ValidateToken(token)
+	require.NoError(t, err)
+	return security.ContextWithClaims(context.Background(), claims)
+}
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+var lowBalanceColumns = []string{"user_id", "org_id", "balance_cents", "currency_code", "name", "email", "name"}
+
+const (
+	resetLowBalanceQuery = `UPDATE users_orgs SET low_balance_alerted_at = NULL\s+WHERE low_balance_alerted_at IS NOT NULL AND COALESCE\(balance_cents, 0\) > -\$1`
+	claimLowBalanceQuery = `UPDATE users_orgs uo\s+SET low_balance_alerted_at = \$2.*AND uo.balance_cents <= -\$1\s+AND uo.low_balance_alerted_at IS NULL`
+)
+
+// TestSendLowBalanceAlerts_OncePerCrossing simulates the database over four runs: the member
+// crosses the threshold, stays below it, recovers, and crosses it again
+func TestSendLowBalanceAlerts_OncePerCrossing(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emailSvc := new(MockEmailService)
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc, Notification: noteSvc},
+		&config.Config{Billing: config.BillingConfig{LowBalanceAlertCents: 5000}})
+	ctx := context.Background()
+	day := time.Date(2026, 3, 10, 4, 30, 0, 0, time.UTC)
+
+	expectRun := func(now time.Time, rearmed int64, alerted *sqlmock.Rows) {
+		dbMock.ExpectExec(resetLowBalanceQuery).WithArgs(int32(5000)).WillReturnResult(sqlmock.NewResult(0, rearmed))
+		dbMock.ExpectQuery(claimLowBalanceQuery).WithArgs(int32(5000), now).WillReturnRows(alerted)
+	}
+	crossed := func() *sqlmock.Rows {
+		return sqlmock.NewRows(lowBalanceColumns).AddRow(3, 1, -6250, "USD", "Maple Street", "dana@example.com", "Dana")
+	}
+
+	// Day 1: the balance has crossed the threshold
+	expectRun(day, 0, crossed())
+	// Day 2: still below it but already alerted, so the claim matches nothing
+	expectRun(day.AddDate(0, 0, 1), 0, sqlmock.NewRows(lowBalanceColumns))
+	// Day 3: the member settled up and is re-armed
+	expectRun(day.AddDate(0, 0, 2), 1, sqlmock.NewRows(lowBalanceColumns))
+	// Day 4: below the threshold again
+	expectRun(day.AddDate(0, 0, 3), 0, crossed())
+
+	noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 3 && n.OrgID == 1 && n.Attributes["type"] == "LOW_BALANCE" &&
+			strings.HasPrefix(n.Message, "You owe $62.50 in Maple Street")
+	})).Return(nil).Twice()
+	emailSvc.On("SendAdminNotification", mock.Anything, "dana@example.com", "Balance Alert: Maple Street", mock.Anything).Return(nil).Twice()
+
+	var counts []int
+	for i := 0; i < 4; i++ {
+		alerted, err := jr.SendLowBalanceAlertsAt(ctx, day.AddDate(0, 0, i))
+		require.NoError(t, err)
+		counts = append(counts, len(alerted))
+	}
+
+	assert.Equal(t, []int{1, 0, 0, 1}, counts)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+	noteSvc.AssertExpectations(t)
+	emailSvc.AssertExpectations(t)
+}
+
+func TestSendLowBalanceAlerts_ResetFailureSendsNothing(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	emailSvc := new(MockEmailService)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc},
+		&config.Config{Billing: config.BillingConfig{LowBalanceAlertCents: 5000}})
+
+	dbMock.ExpectExec(resetLowBalanceQuery).WillReturnError(assert.AnError)
+
+	_, err = jr.SendLowBalanceAlertsAt(context.Background(), time.Now())
+	assert.ErrorIs(t, err, assert.AnError)
+	emailSvc.AssertNotCalled(t, "SendAdminNotification")
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
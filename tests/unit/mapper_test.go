@@ -103,7 +103,8 @@ func TestMapDomainToolToProto(t *testing.T) {
 		ReplacementCostCents: 5000,
 		Condition:            domain.ToolConditionExcellent,
 		Status:               domain.ToolStatusAvailable,
-		CreatedOn:            now.Format("2006-01-02"),
+		CreatedOn:            now.AddDate(0, 0, -3).Format("2006-01-02"),
+		UpdatedOn:            now.Format("2006-01-02"),
 	}
 
 	proto := grpc.MapDomainToolToProto(tool)
@@ -114,6 +115,8 @@ func TestMapDomainToolToProto(t *testing.T) {
 	assert.Equal(t, tool.Name, proto.Name)
 	assert.Equal(t, pb.ToolCondition_TOOL_CONDITION_EXCELLENT, proto.Condition)
 	assert.Equal(t, pb.ToolStatus_TOOL_STATUS_AVAILABLE, proto.Status)
+	assert.Equal(t, tool.CreatedOn, proto.CreatedOn)
+	assert.Equal(t, tool.UpdatedOn, proto.UpdatedOn)
 
 	assert.Nil(t, grpc.MapDomainToolToProto(nil))
 }
@@ -166,7 +169,10 @@ func TestMapDomainTransactionToProto(t *testing.T) {
 	assert.Equal(t, tx.ID, proto.Id)
 	assert.Equal(t, pb.TransactionType_TRANSACTION_TYPE_RENTAL_DEBIT, proto.Type)
 	assert.Equal(t, pb.TransactionType_TRANSACTION_TYPE_RENTAL_DEBIT, proto.Type)
-	// assert.Equal(t, rentalID, proto.RelatedRentalId) // Proto has RelatedRental object, not ID
+	assert.Equal(t, rentalID, proto.RelatedRentalId)
+
+	tx.RelatedRentalID = nil
+	assert.Equal(t, int32(0), grpc.MapDomainTransactionToProto(tx).RelatedRentalId)
 
 	assert.Nil(t, grpc.MapDomainTransactionToProto(nil))
 }
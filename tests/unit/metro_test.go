@@ -0,0 +1,42 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestNormalizeMetro(t *testing.T) {
+	cases := map[string]string{
+		"San Jose":       "San Jose",
+		"san jose":       "San Jose",
+		"  SAN   JOSE  ": "San Jose",
+		"san\tjose\n":    "San Jose",
+		"winston-salem":  "Winston-Salem",
+		"st. louis":      "St. Louis",
+		"coeur d'alene":  "Coeur D'Alene",
+		"":               "",
+		"   ":            "",
+	}
+	for in, want := range cases {
+		assert.Equal(t, want, domain.NormalizeMetro(in), "NormalizeMetro(%q)", in)
+	}
+	assert.Equal(t, domain.NormalizeMetro("San Jose"), domain.NormalizeMetro("san jose"))
+}
+
+func TestOrganizationService_ListMetros(t *testing.T) {
+	ctx := context.Background()
+	mockOrgRepo := new(MockOrganizationRepo)
+	svc := service.NewOrganizationService(mockOrgRepo, nil, nil, nil, nil, nil, nil, nil)
+	mockOrgRepo.On("ListMetros", ctx).Return([]string{"Austin", "San Jose"}, nil).Once()
+
+	metros, err := svc.ListMetros(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Austin", "San Jose"}, metros)
+	mockOrgRepo.AssertExpectations(t)
+}
@@ -28,6 +28,14 @@ func (m *MockUserRepo) GetByID(ctx context.Context, id int32) (*domain.User, err
 	}
 	return args.Get(0).(*domain.User), args.Error(1)
 }
+func (m *MockUserRepo) GetByIDs(ctx context.Context, ids []int32) ([]domain.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
 func (m *MockUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -43,6 +51,22 @@ func (m *MockUserRepo) UpdatePassword(ctx context.Context, userID int32, passwor
 	args := m.Called(ctx, userID, passwordHash)
 	return args.Error(0)
 }
+func (m *MockUserRepo) MarkEmailVerified(ctx context.Context, userID int32) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+func (m *MockUserRepo) UpdateNotificationPreference(ctx context.Context, userID int32, pref domain.NotificationPreference) error {
+	args := m.Called(ctx, userID, pref)
+	return args.Error(0)
+}
+func (m *MockUserRepo) GetTokenVersion(ctx context.Context, userID int32) (int32, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int32), args.Error(1)
+}
+func (m *MockUserRepo) IncrementTokenVersion(ctx context.Context, userID int32) (int32, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(int32), args.Error(1)
+}
 func (m *MockUserRepo) AddUserToOrg(ctx context.Context, userOrg *domain.UserOrg) error {
 	args := m.Called(ctx, userOrg)
 	return args.Error(0)
@@ -62,6 +86,10 @@ func (m *MockUserRepo) UpdateUserOrg(ctx context.Context, userOrg *domain.UserOr
 	args := m.Called(ctx, userOrg)
 	return args.Error(0)
 }
+func (m *MockUserRepo) AdjustBalance(ctx context.Context, userID, orgID, delta int32) (int32, error) {
+	args := m.Called(ctx, userID, orgID, delta)
+	return args.Get(0).(int32), args.Error(1)
+}
 func (m *MockUserRepo) ListMembersByOrg(ctx context.Context, orgID int32) ([]domain.User, []domain.UserOrg, error) {
 	args := m.Called(ctx, orgID)
 	return args.Get(0).([]domain.User), args.Get(1).([]domain.UserOrg), args.Error(2)
@@ -74,6 +102,10 @@ func (m *MockUserRepo) SearchMembersByOrg(ctx context.Context, orgID int32, quer
 	args := m.Called(ctx, orgID, query)
 	return args.Get(0).([]domain.User), args.Get(1).([]domain.UserOrg), args.Error(2)
 }
+func (m *MockUserRepo) ListMembersByOrgFiltered(ctx context.Context, orgID int32, filter domain.MemberFilter) ([]domain.User, []domain.UserOrg, int32, error) {
+	args := m.Called(ctx, orgID, filter)
+	return args.Get(0).([]domain.User), args.Get(1).([]domain.UserOrg), args.Get(2).(int32), args.Error(3)
+}
 
 // MockOrganizationRepo
 type MockOrganizationRepo struct {
@@ -91,6 +123,10 @@ func (m *MockOrganizationRepo) GetByID(ctx context.Context, id int32) (*domain.O
 	}
 	return args.Get(0).(*domain.Organization), args.Error(1)
 }
+func (m *MockOrganizationRepo) GetByIDs(ctx context.Context, ids []int32) ([]domain.Organization, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]domain.Organization), args.Error(1)
+}
 func (m *MockOrganizationRepo) List(ctx context.Context) ([]domain.Organization, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]domain.Organization), args.Error(1)
@@ -103,6 +139,18 @@ func (m *MockOrganizationRepo) Update(ctx context.Context, org *domain.Organizat
 	args := m.Called(ctx, org)
 	return args.Error(0)
 }
+func (m *MockOrganizationRepo) SetAdjacentMetros(ctx context.Context, orgID int32, metros []string) error {
+	args := m.Called(ctx, orgID, metros)
+	return args.Error(0)
+}
+func (m *MockOrganizationRepo) ListMetros(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *MockOrganizationRepo) SetSettlementThreshold(ctx context.Context, orgID int32, thresholdCents *int32) error {
+	args := m.Called(ctx, orgID, thresholdCents)
+	return args.Error(0)
+}
 
 // MockInviteRepo
 type MockInviteRepo struct {
@@ -146,6 +194,17 @@ func (m *MockInviteRepo) ExpireInvitation(ctx context.Context, id int32, expires
 	args := m.Called(ctx, id, expiresOn)
 	return args.Error(0)
 }
+func (m *MockInviteRepo) Revoke(ctx context.Context, id int32) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockInviteRepo) ListOutstandingEmails(ctx context.Context, orgID int32, emails []string) ([]string, error) {
+	args := m.Called(ctx, orgID, emails)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
 
 // Type alias for compatibility
 type MockInvitationRepo = MockInviteRepo
@@ -203,12 +262,16 @@ func (m *MockToolRepo) Delete(ctx context.Context, id int32) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
-func (m *MockToolRepo) ListByOrg(ctx context.Context, orgID int32, page, pageSize int32) ([]domain.Tool, int32, error) {
-	args := m.Called(ctx, orgID, page, pageSize)
+func (m *MockToolRepo) ListByOrg(ctx context.Context, orgID int32, includeAdjacent bool, page, pageSize int32) ([]domain.Tool, int32, error) {
+	args := m.Called(ctx, orgID, includeAdjacent, page, pageSize)
 	return args.Get(0).([]domain.Tool), args.Get(1).(int32), args.Error(2)
 }
-func (m *MockToolRepo) Search(ctx context.Context, userID int32, metro, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
-	args := m.Called(ctx, userID, metro, query, categories, maxPrice, condition, page, pageSize)
+func (m *MockToolRepo) CountAvailableByMetro(ctx context.Context, metro string) (int32, error) {
+	args := m.Called(ctx, metro)
+	return args.Get(0).(int32), args.Error(1)
+}
+func (m *MockToolRepo) Search(ctx context.Context, userID int32, metros []string, query string, categories []string, maxPrice int32, condition string, page, pageSize int32) ([]domain.Tool, int32, error) {
+	args := m.Called(ctx, userID, metros, query, categories, maxPrice, condition, page, pageSize)
 	return args.Get(0).([]domain.Tool), args.Get(1).(int32), args.Error(2)
 }
 func (m *MockToolRepo) CreateImage(ctx context.Context, image *domain.ToolImage) error {
@@ -246,6 +309,14 @@ func (m *MockToolRepo) SetPrimaryImage(ctx context.Context, toolID, imageID int3
 	args := m.Called(ctx, toolID, imageID)
 	return args.Error(0)
 }
+func (m *MockToolRepo) ReorderImages(ctx context.Context, toolID int32, imageIDs []int32) error {
+	args := m.Called(ctx, toolID, imageIDs)
+	return args.Error(0)
+}
+func (m *MockToolRepo) ListCategories(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
 func (m *MockToolRepo) DeleteExpiredPendingImages(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
@@ -279,11 +350,43 @@ func (m *MockRentalRepo) ListByOwner(ctx context.Context, ownerID, orgID int32,
 	args := m.Called(ctx, ownerID, orgID, statuses, page, pageSize)
 	return args.Get(0).([]domain.Rental), args.Get(1).(int32), args.Error(2)
 }
-func (m *MockRentalRepo) ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, page, pageSize int32) ([]domain.Rental, int32, error) {
-	args := m.Called(ctx, toolID, orgID, statuses, page, pageSize)
+func (m *MockRentalRepo) ListByTool(ctx context.Context, toolID, orgID int32, statuses []string, fromDate, toDate string, page, pageSize int32) ([]domain.Rental, int32, error) {
+	args := m.Called(ctx, toolID, orgID, statuses, fromDate, toDate, page, pageSize)
 	return args.Get(0).([]domain.Rental), args.Get(1).(int32), args.Error(2)
 }
 
+func (m *MockRentalRepo) ListBusyRanges(ctx context.Context, toolIDs []int32, fromDate, toDate string) ([]domain.ToolBusyRange, error) {
+	args := m.Called(ctx, toolIDs, fromDate, toDate)
+	return args.Get(0).([]domain.ToolBusyRange), args.Error(1)
+}
+func (m *MockRentalRepo) CountActivity(ctx context.Context, userID int32) (*domain.RentalActivityCounts, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RentalActivityCounts), args.Error(1)
+}
+
+func (m *MockRentalRepo) ListActionable(ctx context.Context, userID, orgID int32) ([]domain.Rental, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).([]domain.Rental), args.Error(1)
+}
+
+// MockRentalEventRepo mocks repository.RentalEventRepository.
+type MockRentalEventRepo struct {
+	mock.Mock
+}
+
+func (m *MockRentalEventRepo) Create(ctx context.Context, event *domain.RentalEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRentalEventRepo) ListByRental(ctx context.Context, rentalID int32) ([]domain.RentalEvent, error) {
+	args := m.Called(ctx, rentalID)
+	return args.Get(0).([]domain.RentalEvent), args.Error(1)
+}
+
 // MockLedgerRepo
 type MockLedgerRepo struct {
 	mock.Mock
@@ -297,10 +400,27 @@ func (m *MockLedgerRepo) GetBalance(ctx context.Context, userID, orgID int32) (i
 	args := m.Called(ctx, userID, orgID)
 	return args.Get(0).(int32), args.Error(1)
 }
+func (m *MockLedgerRepo) GetRentalHold(ctx context.Context, rentalID int32) (int32, error) {
+	args := m.Called(ctx, rentalID)
+	return args.Get(0).(int32), args.Error(1)
+}
 func (m *MockLedgerRepo) ListTransactions(ctx context.Context, userID, orgID int32, page, pageSize int32) ([]domain.LedgerTransaction, int32, error) {
 	args := m.Called(ctx, userID, orgID, page, pageSize)
 	return args.Get(0).([]domain.LedgerTransaction), args.Get(1).(int32), args.Error(2)
 }
+func (m *MockLedgerRepo) GetBalanceHistory(ctx context.Context, userID, orgID int32, from, to string) ([]domain.BalanceSnapshot, error) {
+	args := m.Called(ctx, userID, orgID, from, to)
+	return args.Get(0).([]domain.BalanceSnapshot), args.Error(1)
+}
+func (m *MockLedgerRepo) StreamTransactions(ctx context.Context, userID, orgID int32, from, to string, fn func(domain.LedgerTransaction) error) error {
+	args := m.Called(ctx, userID, orgID, from, to)
+	for _, tx := range args.Get(0).([]domain.LedgerTransaction) {
+		if err := fn(tx); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
 func (m *MockLedgerRepo) GetSummary(ctx context.Context, userID, orgID int32) (*domain.LedgerSummary, error) {
 	args := m.Called(ctx, userID, orgID)
 	if args.Get(0) == nil {
@@ -314,6 +434,11 @@ type MockEmailService struct {
 	mock.Mock
 }
 
+func (m *MockEmailService) Send(ctx context.Context, to, templateName string, data interface{}) error {
+	args := m.Called(ctx, to, templateName, data)
+	return args.Error(0)
+}
+
 func (m *MockEmailService) SendInvitation(ctx context.Context, email, name, token string, orgName string, ccEmail string) error {
 	args := m.Called(ctx, email, name, token, orgName, ccEmail)
 	return args.Error(0)
@@ -324,13 +449,13 @@ func (m *MockEmailService) SendAccountStatusNotification(ctx context.Context, em
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendRentalRequestNotification(ctx context.Context, ownerEmail, renterName, toolName string, ccEmail string) error {
-	args := m.Called(ctx, ownerEmail, renterName, toolName, ccEmail)
+func (m *MockEmailService) SendRentalRequestNotification(ctx context.Context, ownerEmail string, data service.RentalRequestEmail, ccEmail string) error {
+	args := m.Called(ctx, ownerEmail, data, ccEmail)
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendRentalApprovalNotification(ctx context.Context, renterEmail, toolName, ownerName, pickupNote string, ccEmail string) error {
-	args := m.Called(ctx, renterEmail, toolName, ownerName, pickupNote, ccEmail)
+func (m *MockEmailService) SendRentalApprovalNotification(ctx context.Context, renterEmail string, data service.RentalApprovalEmail, ccEmail string) error {
+	args := m.Called(ctx, renterEmail, data, ccEmail)
 	return args.Error(0)
 }
 
@@ -344,8 +469,8 @@ func (m *MockEmailService) SendRentalConfirmationNotification(ctx context.Contex
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendRentalCancellationNotification(ctx context.Context, ownerEmail, renterName, toolName, reason string, ccEmail string) error {
-	args := m.Called(ctx, ownerEmail, renterName, toolName, reason, ccEmail)
+func (m *MockEmailService) SendRentalCancellationNotification(ctx context.Context, recipientEmail, cancellerName, toolName, reason string, ccEmail string) error {
+	args := m.Called(ctx, recipientEmail, cancellerName, toolName, reason, ccEmail)
 	return args.Error(0)
 }
 
@@ -359,8 +484,8 @@ func (m *MockEmailService) SendRentalPickupNotification(ctx context.Context, ema
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32) error {
-	args := m.Called(ctx, renterEmail, toolName, newEndDate, reason, totalCostCents)
+func (m *MockEmailService) SendReturnDateRejectionNotification(ctx context.Context, renterEmail, toolName, newEndDate, reason string, totalCostCents int32, currencyCode string) error {
+	args := m.Called(ctx, renterEmail, toolName, newEndDate, reason, totalCostCents, currencyCode)
 	return args.Error(0)
 }
 
@@ -369,28 +494,33 @@ func (m *MockEmailService) SendAdminNotification(ctx context.Context, adminEmail
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
-	args := m.Called(ctx, debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName)
+func (m *MockEmailService) SendBillPaymentNotice(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	args := m.Called(ctx, debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName, currencyCode)
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string) error {
-	args := m.Called(ctx, creditorEmail, creditorName, debtorName, amountCents, settlementMonth, orgName)
+func (m *MockEmailService) SendBillPaymentAcknowledgment(ctx context.Context, creditorEmail, creditorName, debtorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	args := m.Called(ctx, creditorEmail, creditorName, debtorName, amountCents, settlementMonth, orgName, currencyCode)
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string) error {
-	args := m.Called(ctx, debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName)
+func (m *MockEmailService) SendBillReceiptConfirmation(ctx context.Context, debtorEmail, debtorName, creditorName string, amountCents int32, settlementMonth string, orgName string, currencyCode string) error {
+	args := m.Called(ctx, debtorEmail, debtorName, creditorName, amountCents, settlementMonth, orgName, currencyCode)
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string) error {
-	args := m.Called(ctx, email, name, otherPartyName, amountCents, reason, orgName)
+func (m *MockEmailService) SendBillDisputeNotification(ctx context.Context, email, name, otherPartyName string, amountCents int32, reason string, orgName string, currencyCode string) error {
+	args := m.Called(ctx, email, name, otherPartyName, amountCents, reason, orgName, currencyCode)
 	return args.Error(0)
 }
 
-func (m *MockEmailService) SendBillDisputeResolutionNotification(ctx context.Context, email, name string, amountCents int32, resolution, notes string, orgName string) error {
-	args := m.Called(ctx, email, name, amountCents, resolution, notes, orgName)
+func (m *MockEmailService) SendBillDisputeResolutionNotification(ctx context.Context, email string, data service.BillDisputeResolutionEmail) error {
+	args := m.Called(ctx, email, data)
+	return args.Error(0)
+}
+
+func (m *MockEmailService) SendTwoFactorCode(ctx context.Context, email string, data service.TwoFactorCodeEmail) error {
+	args := m.Called(ctx, email, data)
 	return args.Error(0)
 }
 
@@ -427,9 +557,9 @@ func (m *MockBillRepo) ListByCreditor(ctx context.Context, creditorID int32, org
 	return args.Get(0).([]domain.Bill), args.Error(1)
 }
 
-func (m *MockBillRepo) ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus) ([]domain.Bill, error) {
-	args := m.Called(ctx, userID, orgID, statuses)
-	return args.Get(0).([]domain.Bill), args.Error(1)
+func (m *MockBillRepo) ListByUser(ctx context.Context, userID int32, orgID int32, statuses []domain.BillStatus, page, pageSize int32) ([]domain.Bill, int32, error) {
+	args := m.Called(ctx, userID, orgID, statuses, page, pageSize)
+	return args.Get(0).([]domain.Bill), args.Get(1).(int32), args.Error(2)
 }
 
 func (m *MockBillRepo) ListDisputedByOrg(ctx context.Context, orgID int32, excludeUserID *int32) ([]domain.Bill, error) {
@@ -442,14 +572,35 @@ func (m *MockBillRepo) ListResolvedDisputesByOrg(ctx context.Context, orgID int3
 	return args.Get(0).([]domain.Bill), args.Error(1)
 }
 
+func (m *MockBillRepo) GetBalanceSheet(ctx context.Context, orgID int32) (*domain.OrgBalanceSheet, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OrgBalanceSheet), args.Error(1)
+}
+
+func (m *MockBillRepo) GetDisputeStatistics(ctx context.Context, orgID int32, fromMonth, toMonth string) (*domain.DisputeStatistics, error) {
+	args := m.Called(ctx, orgID, fromMonth, toMonth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.DisputeStatistics), args.Error(1)
+}
+
 func (m *MockBillRepo) CreateAction(ctx context.Context, action *domain.BillAction) error {
 	args := m.Called(ctx, action)
 	return args.Error(0)
 }
 
-func (m *MockBillRepo) ListActionsByBill(ctx context.Context, billID int32) ([]domain.BillAction, error) {
-	args := m.Called(ctx, billID)
-	return args.Get(0).([]domain.BillAction), args.Error(1)
+func (m *MockBillRepo) ListActionsByBill(ctx context.Context, billID int32, filter domain.BillActionFilter) ([]domain.BillAction, int32, error) {
+	args := m.Called(ctx, billID, filter)
+	return args.Get(0).([]domain.BillAction), args.Get(1).(int32), args.Error(2)
+}
+
+func (m *MockBillRepo) CreateSettlementBills(ctx context.Context, orgID int32, settlementMonth string, thresholdCents int32, force bool) ([]domain.Bill, error) {
+	args := m.Called(ctx, orgID, settlementMonth, thresholdCents, force)
+	return args.Get(0).([]domain.Bill), args.Error(1)
 }
 
 // MockNotificationRepo implements service.NotificationService (no-op for tests)
@@ -457,12 +608,22 @@ type MockNotificationRepo struct {
 	mock.Mock
 }
 
-func (m *MockNotificationRepo) GetNotifications(ctx context.Context, userID int32, page, pageSize int32) ([]domain.Notification, int32, error) {
+func (m *MockNotificationRepo) GetNotifications(ctx context.Context, userID int32, notificationType string, page, pageSize int32) ([]domain.Notification, int32, error) {
 	return nil, 0, nil
 }
+func (m *MockNotificationRepo) UnreadCount(ctx context.Context, userID, orgID int32) (int32, error) {
+	return 0, nil
+}
+func (m *MockNotificationRepo) Subscribe(userID int32) (<-chan domain.Notification, func()) {
+	ch := make(chan domain.Notification)
+	return ch, func() {}
+}
 func (m *MockNotificationRepo) MarkAsRead(ctx context.Context, userID int32, notificationID int64) error {
 	return nil
 }
+func (m *MockNotificationRepo) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	return 0, nil
+}
 func (m *MockNotificationRepo) Dispatch(ctx context.Context, n *domain.Notification) error {
 	for _, call := range m.ExpectedCalls {
 		if call.Method == "Dispatch" {
@@ -481,6 +642,9 @@ func (m *MockNotificationRepo) DispatchSilent(ctx context.Context, n *domain.Not
 	}
 	return nil
 }
+func (m *MockNotificationRepo) RetryFailed(ctx context.Context, limit int32) (int, int, error) {
+	return 0, 0, nil
+}
 func (m *MockNotificationRepo) SyncDeviceToken(ctx context.Context, userID int32, fcmToken, androidDeviceID, deviceName string) error {
 	return nil
 }
@@ -535,6 +699,59 @@ func (m *MockFcmTokenRepo) GetActiveByUserIDs(ctx context.Context, userIDs []int
 	return args.Get(0).([]domain.FcmToken), args.Error(1)
 }
 
+// MockAdminAuditRepo mocks repository.AdminAuditRepository.
+type MockAdminAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAdminAuditRepo) Create(ctx context.Context, entry *domain.AdminAuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAdminAuditRepo) List(ctx context.Context, orgID int32, filter domain.AdminAuditFilter) ([]domain.AdminAuditEntry, int32, error) {
+	args := m.Called(ctx, orgID, filter)
+	return args.Get(0).([]domain.AdminAuditEntry), args.Get(1).(int32), args.Error(2)
+}
+
+// MockJobRunRepo mocks repository.JobRunRepository.
+type MockJobRunRepo struct {
+	mock.Mock
+}
+
+func (m *MockJobRunRepo) Start(ctx context.Context, jobName string) (*domain.JobRun, error) {
+	args := m.Called(ctx, jobName)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.JobRun), args.Error(1)
+}
+
+func (m *MockJobRunRepo) Finish(ctx context.Context, id int64, status domain.JobRunStatus, errMsg string) error {
+	args := m.Called(ctx, id, status, errMsg)
+	return args.Error(0)
+}
+
+func (m *MockJobRunRepo) List(ctx context.Context, filter domain.JobRunFilter) ([]domain.JobRun, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]domain.JobRun), args.Error(1)
+}
+
+// MockRevokedTokenRepo mocks repository.RevokedTokenRepository.
+type MockRevokedTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockRevokedTokenRepo) Revoke(ctx context.Context, jti string, userID int32, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, userID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockRevokedTokenRepo) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
 // MockPendingCredentialsRepo mocks repository.PendingCredentialsRepository.
 type MockPendingCredentialsRepo struct {
 	mock.Mock
@@ -557,3 +774,86 @@ func (m *MockPendingCredentialsRepo) StampUsedAt(ctx context.Context, userID int
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+
+// MockNotificationRepository mocks repository.NotificationRepository
+// (MockNotificationRepo stands in for the NotificationService)
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, note *domain.Notification) error {
+	args := m.Called(ctx, note)
+	return args.Error(0)
+}
+func (m *MockNotificationRepository) List(ctx context.Context, userID int32, notificationType string, limit, offset int32) ([]domain.Notification, int32, error) {
+	args := m.Called(ctx, userID, notificationType, limit, offset)
+	return args.Get(0).([]domain.Notification), args.Get(1).(int32), args.Error(2)
+}
+func (m *MockNotificationRepository) CountUnread(ctx context.Context, userID, orgID int32) (int32, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).(int32), args.Error(1)
+}
+func (m *MockNotificationRepository) MarkAsRead(ctx context.Context, id int64, userID int32) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+func (m *MockNotificationRepository) MarkAllRead(ctx context.Context, userID, orgID int32) (int32, error) {
+	args := m.Called(ctx, userID, orgID)
+	return args.Get(0).(int32), args.Error(1)
+}
+func (m *MockNotificationRepository) MarkDelivered(ctx context.Context, id int64, userID int32, t time.Time) error {
+	args := m.Called(ctx, id, userID, t)
+	return args.Error(0)
+}
+func (m *MockNotificationRepository) MarkClicked(ctx context.Context, id int64, userID int32, t time.Time) error {
+	args := m.Called(ctx, id, userID, t)
+	return args.Error(0)
+}
+
+// MockNotificationDeadLetterRepo mocks repository.NotificationDeadLetterRepository
+type MockNotificationDeadLetterRepo struct {
+	mock.Mock
+}
+
+func (m *MockNotificationDeadLetterRepo) Create(ctx context.Context, entry *domain.NotificationDeadLetter) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+func (m *MockNotificationDeadLetterRepo) ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.NotificationDeadLetter, error) {
+	args := m.Called(ctx, maxAttempts, staleAfter, limit)
+	return args.Get(0).([]domain.NotificationDeadLetter), args.Error(1)
+}
+func (m *MockNotificationDeadLetterRepo) MarkDelivered(ctx context.Context, id, notificationID int64) error {
+	args := m.Called(ctx, id, notificationID)
+	return args.Error(0)
+}
+func (m *MockNotificationDeadLetterRepo) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, lastError, nextAttemptAt)
+	return args.Error(0)
+}
+
+// MockEmailOutboxRepo mocks repository.EmailOutboxRepository
+type MockEmailOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockEmailOutboxRepo) Create(ctx context.Context, entry *domain.EmailOutboxEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+func (m *MockEmailOutboxRepo) Claim(ctx context.Context, id int64) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+func (m *MockEmailOutboxRepo) ClaimRetryable(ctx context.Context, maxAttempts int32, staleAfter time.Duration, limit int32) ([]domain.EmailOutboxEntry, error) {
+	args := m.Called(ctx, maxAttempts, staleAfter, limit)
+	return args.Get(0).([]domain.EmailOutboxEntry), args.Error(1)
+}
+func (m *MockEmailOutboxRepo) MarkSent(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *MockEmailOutboxRepo) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, lastError, nextAttemptAt)
+	return args.Error(0)
+}
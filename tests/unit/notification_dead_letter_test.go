@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestNotificationService_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	insertErr := errors.New("connection reset by peer")
+
+	newService := func() (service.NotificationService, *MockNotificationRepository, *MockNotificationDeadLetterRepo) {
+		noteRepo := new(MockNotificationRepository)
+		deadLetters := new(MockNotificationDeadLetterRepo)
+		svc := service.NewNotificationServiceWithOptions(noteRepo, nil, service.NotificationOptions{DeadLetters: deadLetters, MaxAttempts: 4})
+		return svc, noteRepo, deadLetters
+	}
+
+	t.Run("A failing create is recorded for retry", func(t *testing.T) {
+		svc, noteRepo, deadLetters := newService()
+		noteRepo.On("Create", ctx, mock.Anything).Return(insertErr).Once()
+		deadLetters.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.NotificationDeadLetter) bool {
+			return e.Notification.UserID == 7 && e.Notification.OrgID == 3 &&
+				e.Notification.Title == "Rental Approved" &&
+				e.Notification.Attributes["rental_id"] == "12" &&
+				!e.Silent && e.LastError == insertErr.Error() &&
+				e.NextAttemptAt.After(time.Now())
+		})).Return(nil).Once()
+
+		err := svc.Dispatch(ctx, &domain.Notification{
+			UserID: 7, OrgID: 3, Title: "Rental Approved", Message: "Olivia approved Drill",
+			Attributes: map[string]string{"rental_id": "12"},
+		})
+		assert.ErrorIs(t, err, insertErr)
+		noteRepo.AssertExpectations(t)
+		deadLetters.AssertExpectations(t)
+	})
+
+	t.Run("Silent dispatches stay silent on retry", func(t *testing.T) {
+		svc, noteRepo, deadLetters := newService()
+		noteRepo.On("Create", ctx, mock.Anything).Return(insertErr).Once()
+		deadLetters.On("Create", mock.Anything, mock.MatchedBy(func(e *domain.NotificationDeadLetter) bool {
+			return e.Silent
+		})).Return(nil).Once()
+
+		assert.Error(t, svc.DispatchSilent(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: "Threshold Updated"}))
+		deadLetters.AssertExpectations(t)
+	})
+
+	t.Run("Successful dispatches are not recorded", func(t *testing.T) {
+		svc, noteRepo, deadLetters := newService()
+		noteRepo.On("Create", ctx, mock.Anything).Return(nil).Once()
+
+		require.NoError(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: "Rental Approved"}))
+		deadLetters.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Without a dead letter repository the failure is only returned", func(t *testing.T) {
+		noteRepo := new(MockNotificationRepository)
+		svc := service.NewNotificationService(noteRepo, nil)
+		noteRepo.On("Create", ctx, mock.Anything).Return(insertErr).Once()
+
+		assert.ErrorIs(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, OrgID: 3}), insertErr)
+		delivered, failed, err := svc.RetryFailed(ctx, 10)
+		require.NoError(t, err)
+		assert.Zero(t, delivered+failed)
+	})
+
+	t.Run("RetryFailed re-creates claimed notifications", func(t *testing.T) {
+		svc, noteRepo, deadLetters := newService()
+		deadLetters.On("ClaimRetryable", ctx, int32(4), mock.Anything, int32(10)).Return([]domain.NotificationDeadLetter{
+			{ID: 1, Attempts: 2, Notification: domain.Notification{UserID: 7, OrgID: 3, Title: "Rental Approved"}},
+			{ID: 2, Attempts: 3, Notification: domain.Notification{UserID: 8, OrgID: 3, Title: "Rental Rejected"}},
+		}, nil).Once()
+		noteRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool { return n.UserID == 7 })).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*domain.Notification).ID = 55
+			}).Return(nil).Once()
+		noteRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool { return n.UserID == 8 })).
+			Return(insertErr).Once()
+		deadLetters.On("MarkDelivered", ctx, int64(1), int64(55)).Return(nil).Once()
+		deadLetters.On("MarkFailed", ctx, int64(2), insertErr.Error(), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+		delivered, failed, err := svc.RetryFailed(ctx, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, delivered)
+		assert.Equal(t, 1, failed)
+		noteRepo.AssertExpectations(t)
+		deadLetters.AssertExpectations(t)
+		deadLetters.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
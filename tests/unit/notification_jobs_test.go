@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+func TestSendOverdueReminders_EscalatesOncePerLevel(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	emailSvc := new(MockEmailService)
+	noteSvc := new(MockNotificationRepo)
+	cfg := &config.Config{Rental: config.RentalConfig{
+		OverdueReminderDays:     1,
+		OverdueSecondNoticeDays: 3,
+		OverdueFinalNoticeDays:  7,
+	}}
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc, Notification: noteSvc}, cfg)
+
+	endDate := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	now := endDate
+	jr.SetClock(func() time.Time { return now })
+
+	columns := []string{"id", "org_id", "renter_id", "end_date", "days_overdue", "last_reminder_level", "email", "name", "name"}
+	// expectDay simulates the database on the given day: the row carries the level recorded so far
+	expectDay := func(day int, lastLevel int) {
+		dbMock.ExpectQuery(`SELECT r.id, r.org_id, r.renter_id.*WHERE r.status = 'OVERDUE'\s+AND r.last_reminder_level < \$2`).
+			WithArgs(endDate.AddDate(0, 0, day).Format("2006-01-02"), 3).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(80, 1, 3, "2026-03-01", day, lastLevel, "renter@example.com", "Renter", "Ladder"))
+	}
+	expectLevel := func(level int) {
+		dbMock.ExpectExec(`UPDATE rentals SET last_reminder_level = \$2`).
+			WithArgs(int32(80), level).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	// Day 1: first reminder
+	expectDay(1, 0)
+	expectLevel(1)
+	// Day 2: still level 1, nothing sent
+	expectDay(2, 1)
+	// Day 3: second notice
+	expectDay(3, 1)
+	expectLevel(2)
+	// Day 7: final notice, admins alerted
+	expectDay(7, 2)
+	expectLevel(3)
+	dbMock.ExpectQuery(`SELECT user_id FROM users_orgs`).
+		WithArgs(int32(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(9))
+	// Day 8: rentals at the final level are no longer selected
+	dbMock.ExpectQuery(`SELECT r.id, r.org_id, r.renter_id`).
+		WithArgs("2026-03-09", 3).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	emailSvc.On("SendAdminNotification", mock.Anything, "renter@example.com", "Reminder: Overdue Tool Return", mock.Anything).Return(nil).Once()
+	emailSvc.On("SendAdminNotification", mock.Anything, "renter@example.com", "Second Notice: Overdue Tool Return", mock.Anything).Return(nil).Once()
+	emailSvc.On("SendAdminNotification", mock.Anything, "renter@example.com", "Final Notice: Overdue Tool Return", mock.Anything).Return(nil).Once()
+	noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 9 && n.Attributes["type"] == "RENTAL_OVERDUE_ESCALATED" && n.Attributes["rental_id"] == "80"
+	})).Return(nil).Once()
+
+	for _, day := range []int{1, 2, 3, 7, 8} {
+		now = endDate.AddDate(0, 0, day).Add(3 * time.Hour)
+		jr.SendOverdueReminders()
+	}
+
+	emailSvc.AssertExpectations(t)
+	emailSvc.AssertNumberOfCalls(t, "SendAdminNotification", 3)
+	noteSvc.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestSendOverdueReminders_SkipsToHighestLevelReached(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	emailSvc := new(MockEmailService)
+	cfg := &config.Config{Rental: config.RentalConfig{
+		OverdueReminderDays:     1,
+		OverdueSecondNoticeDays: 3,
+		OverdueFinalNoticeDays:  7,
+	}}
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc}, cfg)
+	jr.SetClock(func() time.Time { return time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC) })
+
+	dbMock.ExpectQuery(`SELECT r.id, r.org_id, r.renter_id`).
+		WithArgs("2026-03-05", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "renter_id", "end_date", "days_overdue", "last_reminder_level", "email", "name", "name"}).
+			AddRow(81, 1, 3, "2026-03-01", 4, 0, "renter@example.com", "Renter", "Saw"))
+	dbMock.ExpectExec(`UPDATE rentals SET last_reminder_level = \$2`).
+		WithArgs(int32(81), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	emailSvc.On("SendAdminNotification", mock.Anything, "renter@example.com", "Second Notice: Overdue Tool Return", mock.Anything).Return(nil).Once()
+
+	jr.SendOverdueReminders()
+
+	emailSvc.AssertExpectations(t)
+	emailSvc.AssertNumberOfCalls(t, "SendAdminNotification", 1)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"ubertool-backend-trusted/internal/api/grpc/interceptor"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/security"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestNotificationService_SubscriberReceivesDispatch(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := new(MockNotificationRepository)
+	svc := service.NewNotificationService(noteRepo, nil)
+
+	nextID := int64(40)
+	noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Run(func(args mock.Arguments) {
+		nextID++
+		args.Get(1).(*domain.Notification).ID = nextID
+	}).Return(nil)
+
+	updates, unsubscribe := svc.Subscribe(7)
+	defer unsubscribe()
+	otherUpdates, unsubscribeOther := svc.Subscribe(8)
+	defer unsubscribeOther()
+
+	require.NoError(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: "New Rental Request", Message: "Bob requested Drill"}))
+	require.NoError(t, svc.DispatchSilent(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: "Threshold Updated"}))
+
+	for _, want := range []struct {
+		id    int64
+		title string
+	}{{41, "New Rental Request"}, {42, "Threshold Updated"}} {
+		select {
+		case n := <-updates:
+			assert.Equal(t, want.id, n.ID)
+			assert.Equal(t, want.title, n.Title)
+		case <-time.After(time.Second):
+			t.Fatalf("subscriber did not receive notification %d", want.id)
+		}
+	}
+
+	select {
+	case n := <-otherUpdates:
+		t.Fatalf("user 8 received user 7's notification %d", n.ID)
+	default:
+	}
+}
+
+func TestNotificationService_DispatchWithoutSubscribers(t *testing.T) {
+	ctx := context.Background()
+	noteRepo := new(MockNotificationRepository)
+	svc := service.NewNotificationService(noteRepo, nil)
+	noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Return(nil)
+
+	assert.NoError(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, Title: "Nobody listening"}))
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNotificationBroker_Unsubscribe(t *testing.T) {
+	broker := service.NewNotificationBroker(1)
+
+	updates, unsubscribe := broker.Subscribe(7)
+	_, unsubscribeSecond := broker.Subscribe(7)
+	assert.Equal(t, 2, broker.SubscriberCount(7))
+
+	unsubscribe()
+	unsubscribe() // idempotent
+	assert.Equal(t, 1, broker.SubscriberCount(7))
+	_, open := <-updates
+	assert.False(t, open, "channel must be closed after unsubscribe")
+
+	unsubscribeSecond()
+	assert.Equal(t, 0, broker.SubscriberCount(7))
+
+	// Publishing with no subscribers left is a no-op
+	broker.Publish(domain.Notification{ID: 1, UserID: 7})
+}
+
+func TestNotificationBroker_SlowSubscriberDoesNotBlock(t *testing.T) {
+	broker := service.NewNotificationBroker(1)
+	updates, unsubscribe := broker.Subscribe(7)
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		broker.Publish(domain.Notification{ID: 1, UserID: 7})
+		broker.Publish(domain.Notification{ID: 2, UserID: 7}) // buffer full, dropped
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscriber")
+	}
+	n := <-updates
+	assert.Equal(t, int64(1), n.ID)
+}
+
+// fakeServerStream is the minimum grpc.ServerStream needed to drive a stream interceptor
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestAuthInterceptor_StreamOverridesClientUserID(t *testing.T) {
+	tm := security.NewTokenManager("stream-test-secret-at-least-32-chars!!")
+	token, err := tm.GenerateAccessToken(7, "u7@test.com", nil, nil)
+	require.NoError(t, err)
+
+	streamAuth := interceptor.NewAuthInterceptor(tm).Stream()
+	info := &grpc.StreamServerInfo{FullMethod: "/ubertool.trusted.api.v1.NotificationService/StreamNotifications", IsServerStream: true}
+
+	t.Run("Verified user ID replaces spoofed header", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token, "user-id", "99"))
+		var seen []string
+		err := streamAuth(nil, &fakeServerStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+			md, _ := metadata.FromIncomingContext(ss.Context())
+			seen = md.Get("user-id")
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"7"}, seen)
+	})
+
+	t.Run("Missing token is rejected", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("user-id", "99"))
+		called := false
+		err := streamAuth(nil, &fakeServerStream{ctx: ctx}, info, func(srv interface{}, ss grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+		assert.Error(t, err)
+		assert.False(t, called)
+	})
+}
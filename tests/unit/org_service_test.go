@@ -8,6 +8,7 @@ import (
 	"ubertool-backend-trusted/internal/service"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestOrganizationService_UpdateOrganization(t *testing.T) {
@@ -15,7 +16,7 @@ func TestOrganizationService_UpdateOrganization(t *testing.T) {
 	mockUserRepo := new(MockUserRepo)
 	mockInviteRepo := new(MockInvitationRepo)
 	mockNoteRepo := new(MockNotificationRepo)
-	svc := service.NewOrganizationService(mockRepo, mockUserRepo, mockInviteRepo, mockNoteRepo, nil, nil)
+	svc := service.NewOrganizationService(mockRepo, mockUserRepo, mockInviteRepo, nil, mockNoteRepo, nil, nil, nil)
 	ctx := context.Background()
 
 	const callerID = int32(1)
@@ -37,3 +38,123 @@ func TestOrganizationService_UpdateOrganization(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 	mockUserRepo.AssertExpectations(t)
 }
+
+func TestOrganizationService_SetAdjacentMetros(t *testing.T) {
+	ctx := context.Background()
+	const callerID, orgID = int32(1), int32(1)
+
+	t.Run("Stores canonical, distinct metros other than the org's own", func(t *testing.T) {
+		mockRepo, mockUserRepo := new(MockOrganizationRepo), new(MockUserRepo)
+		svc := service.NewOrganizationService(mockRepo, mockUserRepo, nil, nil, nil, nil, nil, nil)
+		mockUserRepo.On("GetUserOrg", ctx, callerID, orgID).Return(&domain.UserOrg{UserID: callerID, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+		mockRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, Metro: "San Jose"}, nil)
+		mockRepo.On("SetAdjacentMetros", ctx, orgID, []string{"Santa Clara", "Milpitas"}).Return(nil).Once()
+
+		org, err := svc.SetAdjacentMetros(ctx, callerID, orgID, []string{" santa clara", "san jose", "MILPITAS", "Santa Clara", ""})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Santa Clara", "Milpitas"}, org.AdjacentMetros)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Members cannot change them", func(t *testing.T) {
+		mockRepo, mockUserRepo := new(MockOrganizationRepo), new(MockUserRepo)
+		svc := service.NewOrganizationService(mockRepo, mockUserRepo, nil, nil, nil, nil, nil, nil)
+		mockUserRepo.On("GetUserOrg", ctx, callerID, orgID).Return(&domain.UserOrg{UserID: callerID, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil)
+
+		_, err := svc.SetAdjacentMetros(ctx, callerID, orgID, []string{"Santa Clara"})
+		assert.ErrorContains(t, err, "permission denied")
+		mockRepo.AssertNotCalled(t, "SetAdjacentMetros", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrganizationService_ListMembers(t *testing.T) {
+	ctx := context.Background()
+	const orgID = int32(1)
+
+	newSvc := func() (service.OrganizationService, *MockUserRepo) {
+		mockUserRepo := new(MockUserRepo)
+		svc := service.NewOrganizationService(new(MockOrganizationRepo), mockUserRepo, new(MockInvitationRepo), nil, new(MockNotificationRepo), nil, nil, nil)
+		return svc, mockUserRepo
+	}
+
+	t.Run("Admin receives filtered page", func(t *testing.T) {
+		svc, mockUserRepo := newSvc()
+		filter := domain.MemberFilter{BlockedOnly: true, NegativeBalanceOnly: true, Role: domain.UserOrgRoleMember, Page: 2, PageSize: 10}
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(&domain.UserOrg{UserID: 1, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+		mockUserRepo.On("ListMembersByOrgFiltered", ctx, orgID, filter).Return(
+			[]domain.User{{ID: 5, Name: "Debtor"}},
+			[]domain.UserOrg{{UserID: 5, OrgID: orgID, BalanceCents: -500, RentingBlocked: true, Role: domain.UserOrgRoleMember}},
+			int32(11), nil)
+
+		users, uos, total, err := svc.ListMembers(ctx, 1, orgID, filter)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int32(-500), uos[0].BalanceCents)
+		assert.Equal(t, int32(11), total)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Member is denied", func(t *testing.T) {
+		svc, mockUserRepo := newSvc()
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), orgID).Return(&domain.UserOrg{UserID: 2, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil)
+
+		_, _, _, err := svc.ListMembers(ctx, 2, orgID, domain.MemberFilter{})
+		assert.ErrorContains(t, err, "permission denied")
+		mockUserRepo.AssertNotCalled(t, "ListMembersByOrgFiltered", ctx, orgID, domain.MemberFilter{})
+	})
+
+	t.Run("Non-member is denied", func(t *testing.T) {
+		svc, mockUserRepo := newSvc()
+		mockUserRepo.On("GetUserOrg", ctx, int32(3), orgID).Return(nil, assert.AnError)
+
+		_, _, _, err := svc.ListMembers(ctx, 3, orgID, domain.MemberFilter{})
+		assert.ErrorContains(t, err, "permission denied")
+	})
+}
+
+func TestOrganizationService_ToolCount(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("GetOrganization counts tools in the org's metro", func(t *testing.T) {
+		mockRepo, mockUserRepo, mockToolRepo := new(MockOrganizationRepo), new(MockUserRepo), new(MockToolRepo)
+		svc := service.NewOrganizationService(mockRepo, mockUserRepo, nil, mockToolRepo, nil, nil, nil, nil)
+		mockRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Metro: "San Jose", AdjacentMetros: []string{"Santa Clara"}}, nil)
+		mockUserRepo.On("CountMembersByOrg", ctx, int32(1)).Return(int32(4), nil)
+		mockToolRepo.On("CountAvailableByMetro", ctx, "San Jose").Return(int32(12), nil).Once()
+
+		org, _, err := svc.GetOrganization(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), org.MemberCount)
+		assert.Equal(t, int32(12), org.ToolCount)
+		mockToolRepo.AssertExpectations(t)
+	})
+
+	t.Run("SearchOrganizations counts each metro once", func(t *testing.T) {
+		mockRepo, mockUserRepo, mockToolRepo := new(MockOrganizationRepo), new(MockUserRepo), new(MockToolRepo)
+		svc := service.NewOrganizationService(mockRepo, mockUserRepo, nil, mockToolRepo, nil, nil, nil, nil)
+		mockRepo.On("Search", ctx, "", "").Return([]domain.Organization{
+			{ID: 1, Metro: "San Jose"}, {ID: 2, Metro: "san jose"}, {ID: 3, Metro: "Milpitas"},
+		}, nil)
+		mockUserRepo.On("ListMembersByOrg", ctx, mock.Anything).Return([]domain.User{}, []domain.UserOrg{}, nil)
+		mockUserRepo.On("CountMembersByOrg", ctx, mock.Anything).Return(int32(1), nil)
+		mockToolRepo.On("CountAvailableByMetro", ctx, "San Jose").Return(int32(12), nil).Once()
+		mockToolRepo.On("CountAvailableByMetro", ctx, "Milpitas").Return(int32(0), nil).Once()
+
+		orgs, err := svc.SearchOrganizations(ctx, "", "")
+		assert.NoError(t, err)
+		assert.Equal(t, []int32{12, 12, 0}, []int32{orgs[0].ToolCount, orgs[1].ToolCount, orgs[2].ToolCount})
+		mockToolRepo.AssertExpectations(t)
+	})
+
+	t.Run("A failed count leaves zero", func(t *testing.T) {
+		mockRepo, mockUserRepo, mockToolRepo := new(MockOrganizationRepo), new(MockUserRepo), new(MockToolRepo)
+		svc := service.NewOrganizationService(mockRepo, mockUserRepo, nil, mockToolRepo, nil, nil, nil, nil)
+		mockRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Metro: "San Jose"}, nil)
+		mockUserRepo.On("CountMembersByOrg", ctx, int32(1)).Return(int32(4), nil)
+		mockToolRepo.On("CountAvailableByMetro", ctx, "San Jose").Return(int32(0), assert.AnError)
+
+		org, _, err := svc.GetOrganization(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Zero(t, org.ToolCount)
+	})
+}
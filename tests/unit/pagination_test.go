@@ -0,0 +1,38 @@
+package unit
+
+import (
+	"math"
+	"testing"
+
+	"ubertool-backend-trusted/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       int32
+		pageSize   int32
+		wantLimit  int32
+		wantOffset int32
+	}{
+		{"First page", 1, 20, 20, 0},
+		{"Third page", 3, 20, 20, 40},
+		{"Page zero is the first page", 0, 20, 20, 0},
+		{"Negative page is the first page", -5, 20, 20, 0},
+		{"Zero page size uses the default", 2, 0, utils.DefaultPageSize, utils.DefaultPageSize},
+		{"Negative page size uses the default", 1, -1, utils.DefaultPageSize, 0},
+		{"Oversized page size is capped", 2, 10000, utils.MaxPageSize, utils.MaxPageSize},
+		{"Max page size is allowed", 1, utils.MaxPageSize, utils.MaxPageSize, 0},
+		{"Huge page saturates the offset", math.MaxInt32, utils.MaxPageSize, utils.MaxPageSize, math.MaxInt32},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, offset := utils.Paginate(tt.page, tt.pageSize)
+			assert.Equal(t, tt.wantLimit, limit)
+			assert.Equal(t, tt.wantOffset, offset)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/api/grpc"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBill_GetPaymentCategory enumerates every status, role and debtor acknowledgment and
+// checks that the summary counts, the active/history listing and the API category agree
+// with the domain category for each.
+func TestBill_GetPaymentCategory(t *testing.T) {
+	const debtorID, creditorID, otherID = int32(1), int32(2), int32(3)
+	now := time.Now()
+
+	expected := func(status domain.BillStatus, userID int32, debtorAcked bool) domain.PaymentCategory {
+		switch {
+		case status.IsClosed():
+			return domain.PaymentCategoryCompleted
+		case status == domain.BillStatusDisputed && userID == debtorID:
+			return domain.PaymentCategoryPaymentInDispute
+		case status == domain.BillStatusDisputed && userID == creditorID:
+			return domain.PaymentCategoryReceiptInDispute
+		case userID == debtorID && !debtorAcked:
+			return domain.PaymentCategoryPaymentToMake
+		case userID == creditorID && debtorAcked:
+			return domain.PaymentCategoryReceiptToVerify
+		}
+		return domain.PaymentCategoryNone
+	}
+	protoCategory := map[domain.PaymentCategory]pb.PaymentCategory{
+		domain.PaymentCategoryNone:             pb.PaymentCategory_PAYMENT_CATEGORY_UNSPECIFIED,
+		domain.PaymentCategoryPaymentToMake:    pb.PaymentCategory_PAYMENT_TO_MAKE,
+		domain.PaymentCategoryReceiptToVerify:  pb.PaymentCategory_RECEIPT_TO_VERIFY,
+		domain.PaymentCategoryPaymentInDispute: pb.PaymentCategory_PAYMENT_IN_DISPUTE,
+		domain.PaymentCategoryReceiptInDispute: pb.PaymentCategory_RECEIPT_IN_DISPUTE,
+		domain.PaymentCategoryCompleted:        pb.PaymentCategory_COMPLETED,
+	}
+
+	statuses := append(domain.OpenBillStatuses(), domain.ClosedBillStatuses()...)
+	for _, status := range statuses {
+		for _, userID := range []int32{debtorID, creditorID, otherID} {
+			for _, debtorAcked := range []bool{false, true} {
+				bill := domain.Bill{ID: 10, OrgID: 1, DebtorUserID: debtorID, CreditorUserID: creditorID, Status: status}
+				if debtorAcked {
+					bill.DebtorAcknowledgedAt = &now
+				}
+				want := expected(status, userID, debtorAcked)
+
+				t.Run(fmt.Sprintf("%s/user%d/acked=%t", status, userID, debtorAcked), func(t *testing.T) {
+					got := bill.GetPaymentCategory(userID)
+					assert.Equal(t, want, got)
+
+					// The API category is the domain category
+					item := grpc.MapDomainBillToPaymentItem(&bill, userID, nil)
+					assert.Equal(t, protoCategory[got], item.Category)
+
+					// Only COMPLETED bills are listed as history
+					if got == domain.PaymentCategoryCompleted {
+						assert.Contains(t, domain.ClosedBillStatuses(), status)
+					} else {
+						assert.Contains(t, domain.OpenBillStatuses(), status)
+					}
+
+					// The summary counts the bill under its category and nowhere else
+					billRepo := new(MockBillRepo)
+					userRepo := new(MockUserRepo)
+					svc := service.NewBillSplitService(billRepo, userRepo, nil, nil, nil, nil, nil)
+					ctx := context.Background()
+					userRepo.On("ListUserOrgs", ctx, userID).Return([]domain.UserOrg{{UserID: userID, OrgID: 1}}, nil)
+					billRepo.On("ListByUser", ctx, userID, int32(1), []domain.BillStatus(nil), int32(0), int32(0)).
+						Return([]domain.Bill{bill}, int32(1), nil)
+
+					p, r, pd, rd, err := svc.GetGlobalBillSplitSummary(ctx, userID)
+					assert.NoError(t, err)
+					counts := map[domain.PaymentCategory]int32{
+						domain.PaymentCategoryPaymentToMake:    p,
+						domain.PaymentCategoryReceiptToVerify:  r,
+						domain.PaymentCategoryPaymentInDispute: pd,
+						domain.PaymentCategoryReceiptInDispute: rd,
+					}
+					for category, count := range counts {
+						if category == got {
+							assert.Equal(t, int32(1), count, "count for %s", category)
+						} else {
+							assert.Zero(t, count, "count for %s", category)
+						}
+					}
+				})
+			}
+		}
+	}
+}
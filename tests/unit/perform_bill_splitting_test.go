@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+func expectSettlementBalances(dbMock sqlmock.Sqlmock, orgID int32) {
+	dbMock.ExpectQuery(`SELECT user_id, balance_cents FROM users_orgs WHERE org_id = \$1`).
+		WithArgs(orgID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance_cents"}).
+			AddRow(1, -1000).
+			AddRow(2, 1000))
+}
+
+// insertedBillRows is the RETURNING row of a bill the settlement inserted
+func insertedBillRows(id int32) *sqlmock.Rows {
+	now := time.Now()
+	return sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(id, 1, now, now)
+}
+
+func TestPerformBillSplittingForOrg_RunTwiceCreatesBillsOnce(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
+	ctx := context.Background()
+	orgID, month := int32(7), "2026-02"
+
+	// First run claims the month and creates the bill
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO settlement_runs .* ON CONFLICT \(org_id, settlement_month\) DO NOTHING`).
+		WithArgs(orgID, month).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(orgID, month).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	expectSettlementBalances(dbMock, orgID)
+	dbMock.ExpectQuery(`INSERT INTO bills`).
+		WithArgs(orgID, 1, 2, 1000, month).
+		WillReturnRows(insertedBillRows(1))
+	dbMock.ExpectExec(`UPDATE settlement_runs`).
+		WithArgs(orgID, month, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	// Second run hits the marker and stops before reading balances
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO settlement_runs`).
+		WithArgs(orgID, month).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectRollback()
+
+	count, err := jr.PerformBillSplittingForOrg(ctx, orgID, "Org", month, 500, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	count, err = jr.PerformBillSplittingForOrg(ctx, orgID, "Org", month, 500, false)
+	assert.ErrorIs(t, err, jobs.ErrSettlementAlreadyRun)
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestPerformBillSplittingForOrg_SkipsMonthWithLegacyBills(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
+	orgID, month := int32(7), "2026-02"
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO settlement_runs`).
+		WithArgs(orgID, month).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(`SELECT EXISTS`).
+		WithArgs(orgID, month).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	dbMock.ExpectRollback()
+
+	count, err := jr.PerformBillSplittingForOrg(context.Background(), orgID, "Org", month, 500, false)
+	assert.ErrorIs(t, err, jobs.ErrSettlementAlreadyRun)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestPerformBillSplittingForOrg_ForceKeepsExistingBills(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
+	orgID, month := int32(7), "2026-02"
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO settlement_runs .* DO UPDATE SET forced_at = NOW\(\)`).
+		WithArgs(orgID, month).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSettlementBalances(dbMock, orgID)
+	// The bill from the first run already exists, so the insert is a no-op
+	dbMock.ExpectQuery(`INSERT INTO bills`).
+		WithArgs(orgID, 1, 2, 1000, month).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}))
+	dbMock.ExpectExec(`UPDATE settlement_runs`).
+		WithArgs(orgID, month, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	count, err := jr.PerformBillSplittingForOrg(context.Background(), orgID, "Org", month, 500, true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
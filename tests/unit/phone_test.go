@@ -0,0 +1,124 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	cases := map[string]string{
+		"(415) 555-0123":    "+14155550123",
+		"415-555-0123":      "+14155550123",
+		"415.555.0123":      "+14155550123",
+		"4155550123":        "+14155550123",
+		"1-415-555-0123":    "+14155550123",
+		"+1 415 555 0123":   "+14155550123",
+		"  +14155550123  ":  "+14155550123",
+		"+44 20 7183 8750":  "+442071838750",
+		"0044 20 7183 8750": "+442071838750",
+		"+61 (2) 9374 4000": "+61293744000",
+		"":                  "",
+		"   ":               "",
+	}
+	for in, want := range cases {
+		got, err := domain.NormalizePhoneNumber(in)
+		if assert.NoError(t, err, "NormalizePhoneNumber(%q)", in) {
+			assert.Equal(t, want, got, "NormalizePhoneNumber(%q)", in)
+		}
+	}
+
+	invalid := []string{
+		"555-0000",          // No area code
+		"call me",           // Not a number
+		"415-555-0123 x12",  // Extensions are not dialable
+		"415+555+0123",      // + only leads
+		"+0 415 555 0123",   // Country codes never start with 0
+		"+1 015 555 0123",   // Area codes start with 2-9
+		"+1 415 155 0123",   // Exchanges start with 2-9
+		"+1 415 555 012",    // Too short for North America
+		"+1234567890123456", // More than 15 digits
+		"+12345",            // Too short for any country
+		"2-415-555-0123",    // 11 digits without a + are only read with a leading 1
+	}
+	for _, in := range invalid {
+		_, err := domain.NormalizePhoneNumber(in)
+		assert.ErrorIs(t, err, domain.ErrValidation, "NormalizePhoneNumber(%q)", in)
+	}
+}
+
+func TestFormatPhoneNumber(t *testing.T) {
+	assert.Equal(t, "+1 (415) 555-0123", domain.FormatPhoneNumber("+14155550123"))
+	assert.Equal(t, "+442071838750", domain.FormatPhoneNumber("+442071838750"))
+	// Numbers saved before normalization are shown as they were entered
+	assert.Equal(t, "555-0000", domain.FormatPhoneNumber("555-0000"))
+	assert.Equal(t, "", domain.FormatPhoneNumber(""))
+}
+
+func TestUserService_UpdateProfile_Phone(t *testing.T) {
+	ctx := context.Background()
+	newSvc := func() (service.UserService, *MockUserRepo) {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetByID", ctx, int32(1)).Return(&domain.User{ID: 1, Name: "Me", PhoneNumber: "555-0000"}, nil)
+		return service.NewUserService(userRepo, nil, nil, nil, nil, nil), userRepo
+	}
+
+	t.Run("Phone is stored in E.164", func(t *testing.T) {
+		svc, userRepo := newSvc()
+		userRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.User) bool { return u.PhoneNumber == "+14155550123" })).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateProfile(ctx, 1, "Me", "me@example.com", "(415) 555-0123", ""))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid phone is rejected", func(t *testing.T) {
+		svc, userRepo := newSvc()
+
+		err := svc.UpdateProfile(ctx, 1, "Me", "me@example.com", "555-0000", "")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
+
+func TestOrganizationService_AdminPhone(t *testing.T) {
+	ctx := context.Background()
+	const callerID, orgID = int32(1), int32(1)
+
+	newSvc := func() (service.OrganizationService, *MockOrganizationRepo) {
+		orgRepo := new(MockOrganizationRepo)
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetUserOrg", ctx, callerID, orgID).Return(&domain.UserOrg{UserID: callerID, OrgID: orgID, Role: domain.UserOrgRoleSuperAdmin}, nil)
+		userRepo.On("AddUserToOrg", ctx, mock.Anything).Return(nil)
+		orgRepo.On("GetByID", ctx, orgID).Maybe().Return(&domain.Organization{ID: orgID, Name: "Maple Street", CurrencyCode: "USD"}, nil)
+		return service.NewOrganizationService(orgRepo, userRepo, new(MockInvitationRepo), nil, new(MockNotificationRepo), nil, nil, nil), orgRepo
+	}
+
+	t.Run("Create stores the admin phone in E.164", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+		orgRepo.On("Create", ctx, mock.MatchedBy(func(o *domain.Organization) bool { return o.AdminPhoneNumber == "+14085550199" })).Return(nil).Once()
+
+		assert.NoError(t, svc.CreateOrganization(ctx, callerID, &domain.Organization{Name: "Maple Street", AdminPhoneNumber: "408 555 0199"}))
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Update stores the admin phone in E.164", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+		orgRepo.On("Update", ctx, mock.MatchedBy(func(o *domain.Organization) bool { return o.AdminPhoneNumber == "+442071838750" })).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateOrganization(ctx, callerID, &domain.Organization{ID: orgID, Name: "Maple Street", AdminPhoneNumber: "+44 20 7183 8750"}))
+		orgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Invalid admin phone is rejected", func(t *testing.T) {
+		svc, orgRepo := newSvc()
+
+		err := svc.UpdateOrganization(ctx, callerID, &domain.Organization{ID: orgID, Name: "Maple Street", AdminPhoneNumber: "555-9999"})
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		orgRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/repository/postgres"
+	"ubertool-backend-trusted/internal/service"
+)
+
+// previewMembers mirrors the reference scenario in TestCalculateTransactions, plus a
+// suspended member and a settled member that bill splitting must ignore
+func previewMembers(orgID int32) ([]domain.User, []domain.UserOrg) {
+	balances := []int32{4550, -3820, 1275, -1560, 320, -280, 2500, -3015, 450, -420}
+	var users []domain.User
+	var uos []domain.UserOrg
+	for i, b := range balances {
+		id := int32(i + 1)
+		users = append(users, domain.User{ID: id, Name: fmt.Sprintf("User %d", id)})
+		uos = append(uos, domain.UserOrg{UserID: id, OrgID: orgID, BalanceCents: b, Status: domain.UserOrgStatusActive})
+	}
+	users = append(users, domain.User{ID: 11, Name: "Suspended"}, domain.User{ID: 12, Name: "Settled"})
+	uos = append(uos,
+		domain.UserOrg{UserID: 11, OrgID: orgID, BalanceCents: -9000, Status: domain.UserOrgStatusSuspend},
+		domain.UserOrg{UserID: 12, OrgID: orgID, BalanceCents: 0, Status: domain.UserOrgStatusActive})
+	return users, uos
+}
+
+func TestBillSplitService_PreviewSettlement(t *testing.T) {
+	ctx := context.Background()
+	orgID := int32(1)
+
+	t.Run("Success", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockBillRepo := new(MockBillRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
+
+		users, uos := previewMembers(orgID)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(&domain.UserOrg{UserID: 1, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, SettlementThresholdCents: 500}, nil).Once()
+		mockUserRepo.On("ListMembersByOrg", ctx, orgID).Return(users, uos, nil).Once()
+
+		preview, err := svc.PreviewSettlement(ctx, 1, orgID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(500), preview.ThresholdCents)
+		require.Len(t, preview.Transfers, 4)
+		// Largest debtor pays largest creditor first
+		assert.Equal(t, domain.SettlementTransfer{
+			DebtorUserID: 2, DebtorName: "User 2", CreditorUserID: 1, CreditorName: "User 1", AmountCents: 3820,
+		}, preview.Transfers[0])
+		for _, tr := range preview.Transfers {
+			assert.NotEqual(t, int32(11), tr.DebtorUserID, "suspended members are not settled")
+		}
+		// Nothing is written
+		mockBillRepo.AssertNotCalled(t, "Create")
+		mockUserRepo.AssertExpectations(t)
+		mockOrgRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error_NotAdmin", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepo)
+		svc := service.NewBillSplitService(nil, mockUserRepo, nil, nil, nil, nil, nil)
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), orgID).Return(&domain.UserOrg{UserID: 2, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil).Once()
+
+		_, err := svc.PreviewSettlement(ctx, 2, orgID)
+		assert.ErrorContains(t, err, "unauthorized")
+	})
+}
+
+// TestPreviewSettlement_MatchesBillSplittingJob feeds the same balances to the preview and
+// to the job, and expects the job to insert exactly the previewed transfers, in order
+func TestPreviewSettlement_MatchesBillSplittingJob(t *testing.T) {
+	ctx := context.Background()
+	orgID, month := int32(1), "2026-02"
+	users, uos := previewMembers(orgID)
+
+	mockUserRepo := new(MockUserRepo)
+	mockOrgRepo := new(MockOrganizationRepo)
+	svc := service.NewBillSplitService(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
+	mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(&domain.UserOrg{UserID: 1, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil).Once()
+	mockOrgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, SettlementThresholdCents: 500}, nil).Once()
+	mockUserRepo.On("ListMembersByOrg", ctx, orgID).Return(users, uos, nil).Once()
+
+	preview, err := svc.PreviewSettlement(ctx, 1, orgID)
+	require.NoError(t, err)
+	require.NotEmpty(t, preview.Transfers)
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	jr := jobs.NewJobRunner(db, &postgres.Store{BillRepository: postgres.NewBillRepository(db)}, nil, &config.Config{})
+
+	// The job's query returns only active, non-zero balances
+	rows := sqlmock.NewRows([]string{"user_id", "balance_cents"})
+	for _, uo := range uos {
+		if uo.Status == domain.UserOrgStatusActive && uo.BalanceCents != 0 {
+			rows.AddRow(uo.UserID, uo.BalanceCents)
+		}
+	}
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`INSERT INTO settlement_runs`).WithArgs(orgID, month).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(`SELECT EXISTS`).WithArgs(orgID, month).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	dbMock.ExpectQuery(`SELECT user_id, balance_cents FROM users_orgs`).WithArgs(orgID).WillReturnRows(rows)
+	for i, tr := range preview.Transfers {
+		dbMock.ExpectQuery(`INSERT INTO bills`).
+			WithArgs(orgID, int(tr.DebtorUserID), int(tr.CreditorUserID), int(tr.AmountCents), month).
+			WillReturnRows(insertedBillRows(int32(i + 1)))
+	}
+	dbMock.ExpectExec(`UPDATE settlement_runs`).WithArgs(orgID, month, len(preview.Transfers)).WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	count, err := jr.PerformBillSplittingForOrg(ctx, orgID, "Org", month, int(preview.ThresholdCents), false)
+	require.NoError(t, err)
+	assert.Equal(t, len(preview.Transfers), count)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestBillSplitService_RunSettlement(t *testing.T) {
+	ctx := context.Background()
+	orgID, month := int32(1), "2026-02"
+	admin := &domain.UserOrg{UserID: 1, OrgID: orgID, Role: domain.UserOrgRoleAdmin}
+
+	t.Run("Success_SpecifiedMonth", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockBillRepo := new(MockBillRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
+
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(admin, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID, SettlementThresholdCents: 750}, nil).Once()
+		created := []domain.Bill{{ID: 9, OrgID: orgID, DebtorUserID: 2, CreditorUserID: 3, AmountCents: 1200, SettlementMonth: month, Status: domain.BillStatusPending}}
+		// The org's own threshold is used, and an already settled month is not forced
+		mockBillRepo.On("CreateSettlementBills", ctx, orgID, month, int32(750), false).Return(created, nil).Once()
+
+		bills, err := svc.RunSettlement(ctx, 1, orgID, month)
+		require.NoError(t, err)
+		assert.Equal(t, created, bills)
+		mockBillRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error_AlreadyRun", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepo)
+		mockOrgRepo := new(MockOrganizationRepo)
+		mockBillRepo := new(MockBillRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
+
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(admin, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, orgID).Return(&domain.Organization{ID: orgID}, nil).Once()
+		mockBillRepo.On("CreateSettlementBills", ctx, orgID, month, service.DefaultSettlementThresholdCents, false).
+			Return([]domain.Bill(nil), repository.ErrSettlementAlreadyRun).Once()
+
+		_, err := svc.RunSettlement(ctx, 1, orgID, month)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+	})
+
+	t.Run("Error_NotAdmin", func(t *testing.T) {
+		mockUserRepo := new(MockUserRepo)
+		mockBillRepo := new(MockBillRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), orgID).Return(&domain.UserOrg{UserID: 2, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil).Once()
+
+		_, err := svc.RunSettlement(ctx, 2, orgID, month)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		mockBillRepo.AssertNotCalled(t, "CreateSettlementBills")
+	})
+
+	for _, bad := range []string{"", "2026-2", "2026-13", "02-2026", time.Now().AddDate(0, 1, 0).Format("2006-01")} {
+		t.Run("Error_InvalidMonth_"+bad, func(t *testing.T) {
+			mockUserRepo := new(MockUserRepo)
+			mockBillRepo := new(MockBillRepo)
+			svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
+			mockUserRepo.On("GetUserOrg", ctx, int32(1), orgID).Return(admin, nil).Once()
+
+			_, err := svc.RunSettlement(ctx, 1, orgID, bad)
+			assert.ErrorIs(t, err, domain.ErrValidation)
+			mockBillRepo.AssertNotCalled(t, "CreateSettlementBills")
+		})
+	}
+}
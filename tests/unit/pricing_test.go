@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -457,3 +458,144 @@ func TestCalculateRentalCost_DefaultUnit(t *testing.T) {
 		assert.Equal(t, int32(4500), cost) // 1 week * $45
 	})
 }
+
+func TestCalculateRentalCost_RoundingPolicy(t *testing.T) {
+	weekPrices := func(policy utils.RoundingPolicy) utils.RentalPriceSnapshot {
+		return utils.RentalPriceSnapshot{
+			PricePerDayCents:   1000,  // $10.00
+			PricePerWeekCents:  4500,  // $45.00
+			PricePerMonthCents: 13500, // $135.00
+			DurationUnit:       domain.ToolDurationUnitWeek,
+			Rounding:           policy,
+		}
+	}
+
+	t.Run("Nearest: 10 days rounds down to 1 week", func(t *testing.T) {
+		// Jan 15 to Jan 25 = 10 days = 1 week + 3 days (3 < 3.5 → down)
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-01-25")
+		breakdown, err := utils.CalculateRentalCostWithBreakdown(start, end, weekPrices(utils.RoundingPolicyNearest))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, breakdown.Weeks)
+		assert.Equal(t, int32(4500), breakdown.TotalCost)
+
+		cost, err := utils.CalculateRentalCost(start, end, weekPrices(utils.RoundingPolicyNearest))
+		assert.NoError(t, err)
+		assert.Equal(t, breakdown.TotalCost, cost)
+	})
+
+	t.Run("Nearest: 11 days rounds up to 2 weeks", func(t *testing.T) {
+		// Jan 15 to Jan 26 = 11 days = 1 week + 4 days (4 >= 3.5 → up)
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-01-26")
+		cost, err := utils.CalculateRentalCost(start, end, weekPrices(utils.RoundingPolicyNearest))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(9000), cost) // 2 weeks * $45
+	})
+
+	t.Run("Nearest: short rental charges at least 1 week", func(t *testing.T) {
+		// Jan 15 to Jan 17 = 2 days
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-01-17")
+		cost, err := utils.CalculateRentalCost(start, end, weekPrices(utils.RoundingPolicyNearest))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4500), cost)
+	})
+
+	t.Run("Prorate: 10 days charges 1 week + 3/7 week", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-01-25")
+		breakdown, err := utils.CalculateRentalCostWithBreakdown(start, end, weekPrices(utils.RoundingPolicyProrate))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, breakdown.Weeks)
+		assert.Equal(t, 3, breakdown.Days)
+		assert.Equal(t, int32(4500), breakdown.WeeksCost)
+		assert.Equal(t, int32(1929), breakdown.DaysCost) // 3 * 4500 / 7 = 1928.57 → 1929
+		assert.Equal(t, int32(6429), breakdown.TotalCost)
+
+		cost, err := utils.CalculateRentalCost(start, end, weekPrices(utils.RoundingPolicyProrate))
+		assert.NoError(t, err)
+		assert.Equal(t, breakdown.TotalCost, cost)
+	})
+
+	t.Run("Round-up is the default", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-01-25")
+		def, err := utils.CalculateRentalCost(start, end, weekPrices(""))
+		assert.NoError(t, err)
+		up, err := utils.CalculateRentalCost(start, end, weekPrices(utils.RoundingPolicyRoundUp))
+		assert.NoError(t, err)
+		assert.Equal(t, int32(9000), def)
+		assert.Equal(t, def, up)
+	})
+
+	t.Run("Month unit nearest and prorate", func(t *testing.T) {
+		prices := utils.RentalPriceSnapshot{
+			PricePerMonthCents: 13500,
+			DurationUnit:       domain.ToolDurationUnitMonth,
+		}
+		// Jan 15 to Mar 20 = 2 months + 5 days
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-03-20")
+
+		prices.Rounding = utils.RoundingPolicyNearest
+		cost, err := utils.CalculateRentalCost(start, end, prices)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(27000), cost) // 5 days < half a month → 2 months
+
+		prices.Rounding = utils.RoundingPolicyProrate
+		cost, err = utils.CalculateRentalCost(start, end, prices)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(29250), cost) // 2 * $135 + 5 * $135 / 30
+	})
+}
+
+func TestCalculateRentalCost_BreakdownMatchesCost(t *testing.T) {
+	ranges := []struct{ start, end string }{
+		{"2024-01-15", "2024-01-17"}, // 2 days
+		{"2024-01-15", "2024-01-25"}, // 1 week + 3 days
+		{"2024-01-15", "2024-02-05"}, // 3 weeks
+		{"2024-01-15", "2024-02-12"}, // 4 weeks: week cost passes the month price
+		{"2024-01-15", "2024-03-11"}, // 1 month + 25 days
+		{"2024-01-15", "2024-03-15"}, // 2 months
+	}
+	for _, unit := range []domain.ToolDurationUnit{domain.ToolDurationUnitWeek, domain.ToolDurationUnitMonth} {
+		for _, policy := range []utils.RoundingPolicy{utils.RoundingPolicyRoundUp, utils.RoundingPolicyNearest, utils.RoundingPolicyProrate} {
+			prices := utils.RentalPriceSnapshot{
+				PricePerDayCents:   1000,
+				PricePerWeekCents:  4500,
+				PricePerMonthCents: 13500,
+				DurationUnit:       unit,
+				Rounding:           policy,
+			}
+			for _, r := range ranges {
+				t.Run(fmt.Sprintf("%s/%s/%s_%s", unit, policy, r.start, r.end), func(t *testing.T) {
+					start, _ := time.Parse("2006-01-02", r.start)
+					end, _ := time.Parse("2006-01-02", r.end)
+
+					breakdown, err := utils.CalculateRentalCostWithBreakdown(start, end, prices)
+					assert.NoError(t, err)
+					cost, err := utils.CalculateRentalCost(start, end, prices)
+					assert.NoError(t, err)
+					assert.Equal(t, cost, breakdown.TotalCost)
+					assert.Equal(t, breakdown.TotalCost, breakdown.MonthsCost+breakdown.WeeksCost+breakdown.DaysCost)
+				})
+			}
+		}
+	}
+
+	t.Run("Capped weeks are shown as a month", func(t *testing.T) {
+		start, _ := time.Parse("2006-01-02", "2024-01-15")
+		end, _ := time.Parse("2006-01-02", "2024-03-11")
+		breakdown, err := utils.CalculateRentalCostWithBreakdown(start, end, utils.RentalPriceSnapshot{
+			PricePerWeekCents:  4500,
+			PricePerMonthCents: 13500,
+			DurationUnit:       domain.ToolDurationUnitWeek,
+		})
+		assert.NoError(t, err)
+		// 1 month + 25 days rounds up to 4 weeks ($180), more than a month ($135)
+		assert.Equal(t, 2, breakdown.Months)
+		assert.Equal(t, 0, breakdown.Weeks)
+		assert.Equal(t, int32(27000), breakdown.TotalCost)
+	})
+}
@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	pb "ubertool-backend-trusted/api/gen/v1"
+	"ubertool-backend-trusted/internal/api/grpc/interceptor"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	// 3 attempts at once, then one attempt every 10 seconds
+	limiter := interceptor.NewInMemoryRateLimiterWithClock(6, 3, clock)
+	unary := interceptor.NewRateLimitInterceptor(limiter, interceptor.RateLimitedMethods).Unary()
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	login := &grpc.UnaryServerInfo{FullMethod: "/ubertool.trusted.api.v1.AuthService/Login"}
+	ctxFrom := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+	}
+	call := func(ctx context.Context, info *grpc.UnaryServerInfo, req interface{}) error {
+		_, err := unary(ctx, req, info, handler)
+		return err
+	}
+
+	t.Run("Exhausts bucket then recovers after refill", func(t *testing.T) {
+		ctx := ctxFrom("10.0.0.1")
+		req := &pb.LoginRequest{Email: "a@example.com"}
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, call(ctx, login, req))
+		}
+
+		err := call(ctx, login, req)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		now = now.Add(5 * time.Second)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(ctx, login, req)))
+
+		now = now.Add(10 * time.Second)
+		assert.NoError(t, call(ctx, login, req))
+	})
+
+	t.Run("Same email from another IP is still limited", func(t *testing.T) {
+		req := &pb.LoginRequest{Email: "B@example.com"}
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, call(ctxFrom("10.0.1.1"), login, req))
+		}
+		err := call(ctxFrom("10.0.1.2"), login, &pb.LoginRequest{Email: "b@example.com"})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("Verify2FA limited by IP", func(t *testing.T) {
+		verify := &grpc.UnaryServerInfo{FullMethod: "/ubertool.trusted.api.v1.AuthService/Verify2FA"}
+		ctx := ctxFrom("10.0.2.1")
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, call(ctx, verify, &pb.Verify2FARequest{TwoFaCode: "00000"}))
+		}
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(ctx, verify, &pb.Verify2FARequest{TwoFaCode: "00000"})))
+		// Login from the same IP has its own bucket
+		assert.NoError(t, call(ctx, login, &pb.LoginRequest{Email: "c@example.com"}))
+	})
+
+	t.Run("Other methods are not limited", func(t *testing.T) {
+		other := &grpc.UnaryServerInfo{FullMethod: "/ubertool.trusted.api.v1.ToolService/ListTools"}
+		ctx := ctxFrom("10.0.3.1")
+		for i := 0; i < 10; i++ {
+			assert.NoError(t, call(ctx, other, &pb.LoginRequest{}))
+		}
+	})
+}
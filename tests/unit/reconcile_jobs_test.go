@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+func TestReconcileBalancesForOrg_DetectsDrift(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Notification: noteSvc}, &config.Config{})
+
+	orgID := int32(7)
+
+	// Seeded drift: user 1 stored 1500 but ledger sums to 1000; user 2 stored -950 vs ledger -1000.
+	dbMock.ExpectQuery(`SELECT uo.user_id, COALESCE\(uo.balance_cents, 0\), COALESCE\(SUM\(lt.amount\), 0\)`).
+		WithArgs(orgID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance_cents", "ledger_sum"}).
+			AddRow(1, 1500, 1000).
+			AddRow(2, -950, -1000))
+
+	dbMock.ExpectQuery(`SELECT user_id FROM users_orgs WHERE org_id = \$1 AND role IN`).
+		WithArgs(orgID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(9))
+
+	noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 9 && n.OrgID == orgID && n.Attributes["type"] == "BALANCE_DRIFT"
+	})).Return(nil).Once()
+
+	drifts, err := jr.ReconcileBalancesForOrg(context.Background(), orgID, "Drift Org", false, 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, drifts, 2)
+	assert.Equal(t, int32(500), drifts[0].DriftCents)
+	assert.Equal(t, int32(50), drifts[1].DriftCents)
+	assert.False(t, drifts[0].AutoCorrected)
+	noteSvc.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestReconcileBalancesForOrg_AutoCorrectBelowThreshold(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Notification: noteSvc}, &config.Config{})
+
+	orgID := int32(8)
+
+	dbMock.ExpectQuery(`SELECT uo.user_id`).
+		WithArgs(orgID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance_cents", "ledger_sum"}).
+			AddRow(3, 210, 200))
+
+	dbMock.ExpectExec(`UPDATE users_orgs SET balance_cents = \$1`).
+		WithArgs(int32(200), int32(3), orgID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Drift of 10 cents is below the alert threshold; no admin lookup or notification expected.
+	drifts, err := jr.ReconcileBalancesForOrg(context.Background(), orgID, "Small Drift Org", true, 100)
+
+	assert.NoError(t, err)
+	assert.Len(t, drifts, 1)
+	assert.True(t, drifts[0].AutoCorrected)
+	noteSvc.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
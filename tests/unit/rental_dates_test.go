@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestRentalService_CreateRentalRequest_DateValidation(t *testing.T) {
+	ctx := context.Background()
+	day := func(offset int) string { return time.Now().UTC().AddDate(0, 0, offset).Format("2006-01-02") }
+
+	tests := []struct {
+		name  string
+		start string
+		end   string
+		want  error
+	}{
+		{"Empty start", "", day(3), service.ErrRentalDateRequired},
+		{"Empty end", day(1), "", service.ErrRentalDateRequired},
+		{"Slashes", "2099/01/05", "2099-01-08", service.ErrRentalDateMalformed},
+		{"Unpadded month", "2099-1-05", "2099-01-08", service.ErrRentalDateMalformed},
+		{"Non-numeric", "2099-01-05", "abcd-01-08", service.ErrRentalDateMalformed},
+		{"Month out of range", "2099-13-01", "2099-12-08", service.ErrRentalDateMalformed},
+		{"Day past month end", "2099-02-30", "2099-03-08", service.ErrRentalDateMalformed},
+		{"Trailing time", "2099-01-05T10:00:00Z", "2099-01-08", service.ErrRentalDateMalformed},
+		{"Surrounding spaces", " 2099-01-05", "2099-01-08", service.ErrRentalDateMalformed},
+		{"Start in the past", day(-2), day(3), service.ErrRentalStartInPast},
+		{"End equals start", day(2), day(2), service.ErrRentalEndNotAfterStart},
+		{"End before start", day(5), day(2), service.ErrRentalEndNotAfterStart},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// No repository expectations: validation must fail before any lookup or cost computation
+			toolRepo := new(MockToolRepo)
+			rentalRepo := new(MockRentalRepo)
+			svc := service.NewRentalService(rentalRepo, toolRepo, nil, nil, nil, nil, nil)
+
+			rt, err := svc.CreateRentalRequest(ctx, 1, 2, 3, tc.start, tc.end)
+			assert.Nil(t, rt)
+			assert.ErrorIs(t, err, tc.want)
+			assert.ErrorIs(t, err, domain.ErrValidation)
+			toolRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRentalService_ChangeRentalDates_DateValidation(t *testing.T) {
+	ctx := context.Background()
+	rental := domain.Rental{
+		ID: 5, RenterID: 1, OwnerID: 2, ToolID: 3,
+		Status:          domain.RentalStatusScheduled,
+		StartDate:       time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
+		EndDate:         time.Now().AddDate(0, 0, 3).Format("2006-01-02"),
+		DurationUnit:    string(domain.ToolDurationUnitDay),
+		DailyPriceCents: 1000,
+	}
+
+	tests := []struct {
+		name     string
+		newStart string
+		newEnd   string
+		want     error
+	}{
+		{"Malformed end", "", "2099-02-29", service.ErrRentalDateMalformed},
+		{"Malformed start", "05-01-2099", "", service.ErrRentalDateMalformed},
+		{"End not after start", rental.EndDate, "", service.ErrRentalEndNotAfterStart},
+		{"End equals new start", time.Now().AddDate(0, 0, 2).Format("2006-01-02"), time.Now().AddDate(0, 0, 2).Format("2006-01-02"), service.ErrRentalEndNotAfterStart},
+		{"Start moved into the past", time.Now().UTC().AddDate(0, 0, -2).Format("2006-01-02"), "", service.ErrRentalStartInPast},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rentalRepo := new(MockRentalRepo)
+			toolRepo := new(MockToolRepo)
+			svc := service.NewRentalService(rentalRepo, toolRepo, nil, nil, nil, nil, nil)
+			r := rental
+			rentalRepo.On("GetByID", ctx, int32(5)).Return(&r, nil)
+			toolRepo.On("GetByID", ctx, int32(3)).Return(&domain.Tool{ID: 3, Name: "Drill"}, nil)
+
+			_, err := svc.ChangeRentalDates(ctx, 1, 5, tc.newStart, tc.newEnd, "", "")
+			assert.ErrorIs(t, err, tc.want)
+			rentalRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRentalService_StartDateGrace(t *testing.T) {
+	ctx := context.Background()
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	end := time.Now().UTC().AddDate(0, 0, 3).Format("2006-01-02")
+
+	t.Run("Yesterday is accepted within the grace", func(t *testing.T) {
+		// A 36h grace always covers yesterday, so validation passes and the tool is looked up
+		toolRepo := new(MockToolRepo)
+		toolRepo.On("GetByID", ctx, int32(2)).Return(nil, domain.NotFoundf("tool not found")).Once()
+		svc := service.NewRentalServiceWithOptions(new(MockRentalRepo), toolRepo, nil, nil, nil, nil, nil,
+			service.RentalOptions{StartDateGrace: 36 * time.Hour})
+
+		_, err := svc.CreateRentalRequest(ctx, 1, 2, 3, yesterday, end)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+		toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("Two days ago is past any default grace", func(t *testing.T) {
+		svc := service.NewRentalServiceWithOptions(new(MockRentalRepo), new(MockToolRepo), nil, nil, nil, nil, nil,
+			service.RentalOptions{})
+
+		_, err := svc.CreateRentalRequest(ctx, 1, 2, 3, time.Now().UTC().AddDate(0, 0, -2).Format("2006-01-02"), end)
+		assert.ErrorIs(t, err, service.ErrRentalStartInPast)
+	})
+
+	t.Run("Unchanged start of a scheduled rental is not rechecked", func(t *testing.T) {
+		// The start has passed but the rental is still SCHEDULED; extending only the end is allowed
+		rentalRepo := new(MockRentalRepo)
+		toolRepo := new(MockToolRepo)
+		noteRepo := new(MockNotificationRepo)
+		userRepo := new(MockUserRepo)
+		rt := &domain.Rental{
+			ID: 5, RenterID: 1, OwnerID: 2, ToolID: 3, Status: domain.RentalStatusScheduled,
+			StartDate: time.Now().UTC().AddDate(0, 0, -3).Format("2006-01-02"), EndDate: end,
+			DurationUnit: string(domain.ToolDurationUnitDay), DailyPriceCents: 1000,
+		}
+		rentalRepo.On("GetByID", ctx, int32(5)).Return(rt, nil)
+		rentalRepo.On("Update", ctx, rt).Return(nil)
+		toolRepo.On("GetByID", ctx, int32(3)).Return(&domain.Tool{ID: 3, Name: "Drill"}, nil)
+		userRepo.On("GetByID", ctx, mock.Anything).Return(&domain.User{ID: 2}, nil).Maybe()
+		noteRepo.On("Create", ctx, mock.Anything).Return(nil).Maybe()
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, nil, noteRepo, nil)
+
+		_, err := svc.ChangeRentalDates(ctx, 2, 5, "", time.Now().UTC().AddDate(0, 0, 5).Format("2006-01-02"), "", "")
+		assert.NoError(t, err)
+	})
+}
+
+func TestRentalService_ListToolRentals_DateWindow(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"Malformed from", "2026/03/01", ""},
+		{"Malformed to", "", "2026-02-30"},
+		{"To before from", "2026-03-10", "2026-03-01"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			toolRepo := new(MockToolRepo)
+			rentalRepo := new(MockRentalRepo)
+			svc := service.NewRentalService(rentalRepo, toolRepo, nil, nil, nil, nil, nil)
+
+			_, _, err := svc.ListToolRentals(ctx, 1, 2, 3, nil, tc.from, tc.to, 1, 10)
+			assert.ErrorIs(t, err, domain.ErrValidation)
+			toolRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("Valid window is passed through", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		rentalRepo := new(MockRentalRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, nil, nil, nil, nil)
+		statuses := []string{string(domain.RentalStatusScheduled)}
+		toolRepo.On("GetByID", ctx, int32(2)).Return(&domain.Tool{ID: 2, OwnerID: 1}, nil)
+		rentalRepo.On("ListByTool", ctx, int32(2), int32(3), statuses, "2026-03-01", "2026-03-01", int32(1), int32(10)).
+			Return([]domain.Rental{{ID: 7}}, int32(1), nil)
+
+		rentals, total, err := svc.ListToolRentals(ctx, 1, 2, 3, statuses, "2026-03-01", "2026-03-01", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, rentals, 1)
+		rentalRepo.AssertExpectations(t)
+	})
+}
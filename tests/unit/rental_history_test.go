@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRentalService_History_FullLifecycle(t *testing.T) {
+	ctx := context.Background()
+	rentalRepo := new(MockRentalRepo)
+	toolRepo := new(MockToolRepo)
+	ledgerRepo := new(MockLedgerRepo)
+	userRepo := new(MockUserRepo)
+	emailSvc := new(MockEmailService)
+	noteRepo := new(MockNotificationRepo)
+	eventRepo := new(MockRentalEventRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, eventRepo)
+
+	renterID, ownerID, orgID, toolID := int32(1), int32(10), int32(3), int32(2)
+	tool := &domain.Tool{ID: toolID, Name: "Drill", OwnerID: ownerID, PricePerDayCents: 1000, DurationUnit: domain.ToolDurationUnitDay}
+
+	var stored *domain.Rental
+	rentalRepo.On("Create", ctx, mock.AnythingOfType("*domain.Rental")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*domain.Rental)
+		stored.ID = 100
+	}).Return(nil)
+	rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+	rentalRepo.On("ListByTool", ctx, toolID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]domain.Rental{}, int32(0), nil)
+	toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
+	toolRepo.On("Update", ctx, mock.AnythingOfType("*domain.Tool")).Return(nil)
+	userRepo.On("GetUserOrg", ctx, mock.Anything, orgID).Return(&domain.UserOrg{OrgID: orgID}, nil)
+	// Without users no notifications or emails are sent, keeping the test on the transitions.
+	userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+	ledgerRepo.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Return(nil)
+	ledgerRepo.On("GetRentalHold", ctx, int32(100)).Return(int32(0), nil)
+
+	var events []domain.RentalEvent
+	eventRepo.On("Create", ctx, mock.AnythingOfType("*domain.RentalEvent")).Run(func(args mock.Arguments) {
+		events = append(events, *args.Get(1).(*domain.RentalEvent))
+	}).Return(nil)
+
+	start := time.Now().Format("2006-01-02")
+	end := time.Now().Add(48 * time.Hour).Format("2006-01-02")
+	_, err := svc.CreateRentalRequest(ctx, renterID, toolID, orgID, start, end)
+	require.NoError(t, err)
+	rentalRepo.On("GetByID", ctx, int32(100)).Return(stored, nil)
+
+	_, err = svc.ApproveRentalRequest(ctx, ownerID, 100, "Side door")
+	require.NoError(t, err)
+	_, _, _, err = svc.FinalizeRentalRequest(ctx, renterID, 100)
+	require.NoError(t, err)
+	_, err = svc.ActivateRental(ctx, renterID, 100)
+	require.NoError(t, err)
+	_, err = svc.CompleteRental(ctx, ownerID, 100, "Good", 0, "Returned clean", true)
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+
+	type step struct {
+		from, to domain.RentalStatus
+		actor    int32
+		note     string
+	}
+	want := []step{
+		{"", domain.RentalStatusPending, renterID, ""},
+		{domain.RentalStatusPending, domain.RentalStatusApproved, ownerID, "Side door"},
+		{domain.RentalStatusApproved, domain.RentalStatusScheduled, renterID, ""},
+		{domain.RentalStatusScheduled, domain.RentalStatusActive, renterID, ""},
+		{domain.RentalStatusActive, domain.RentalStatusCompleted, ownerID, "Returned clean"},
+	}
+	require.Len(t, events, len(want))
+	for i, w := range want {
+		e := events[i]
+		assert.Equal(t, int32(100), e.RentalID, "event %d", i)
+		assert.Equal(t, w.from, e.FromStatus, "event %d", i)
+		assert.Equal(t, w.to, e.ToStatus, "event %d", i)
+		require.NotNil(t, e.ActorUserID, "event %d", i)
+		assert.Equal(t, w.actor, *e.ActorUserID, "event %d", i)
+		assert.Equal(t, w.note, e.Note, "event %d", i)
+	}
+}
+
+func TestRentalService_History_EventFailureDoesNotFailTransition(t *testing.T) {
+	ctx := context.Background()
+	rentalRepo := new(MockRentalRepo)
+	toolRepo := new(MockToolRepo)
+	userRepo := new(MockUserRepo)
+	eventRepo := new(MockRentalEventRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, new(MockEmailService), new(MockNotificationRepo), eventRepo)
+
+	rt := &domain.Rental{ID: 7, RenterID: 1, OwnerID: 10, ToolID: 2, OrgID: 3, Status: domain.RentalStatusPending}
+	rentalRepo.On("GetByID", ctx, int32(7)).Return(rt, nil)
+	rentalRepo.On("Update", ctx, rt).Return(nil)
+	toolRepo.On("GetByID", ctx, int32(2)).Return(nil, errors.New("not found"))
+	userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+	eventRepo.On("Create", ctx, mock.AnythingOfType("*domain.RentalEvent")).Return(errors.New("db down"))
+
+	res, err := svc.RejectRentalRequest(ctx, 10, 7)
+	require.NoError(t, err)
+	assert.Equal(t, domain.RentalStatusRejected, res.Status)
+	eventRepo.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestRentalService_GetRentalHistory(t *testing.T) {
+	ctx := context.Background()
+	rentalRepo := new(MockRentalRepo)
+	eventRepo := new(MockRentalEventRepo)
+	svc := service.NewRentalService(rentalRepo, nil, nil, nil, nil, nil, eventRepo)
+
+	rt := &domain.Rental{ID: 7, RenterID: 1, OwnerID: 10}
+	rentalRepo.On("GetByID", ctx, int32(7)).Return(rt, nil)
+	history := []domain.RentalEvent{
+		{ID: 1, RentalID: 7, ToStatus: domain.RentalStatusPending},
+		{ID: 2, RentalID: 7, FromStatus: domain.RentalStatusPending, ToStatus: domain.RentalStatusApproved},
+	}
+	eventRepo.On("ListByRental", ctx, int32(7)).Return(history, nil)
+
+	t.Run("Participant sees ordered events", func(t *testing.T) {
+		events, err := svc.GetRentalHistory(ctx, 10, 7)
+		require.NoError(t, err)
+		assert.Equal(t, history, events)
+	})
+
+	t.Run("Outsider is rejected", func(t *testing.T) {
+		_, err := svc.GetRentalHistory(ctx, 99, 7)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,181 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+)
+
+func TestAutoActivateScheduledRentals_PolicyOn(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	orgRepo := new(MockOrganizationRepo)
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{OrganizationRepository: orgRepo}, &jobs.Services{Notification: noteSvc}, &config.Config{})
+
+	orgRepo.On("List", mock.Anything).Return([]domain.Organization{
+		{ID: 1, Name: "Auto Org", AutoActivateRentals: true},
+	}, nil)
+
+	dbMock.ExpectQuery(`UPDATE rentals r\s+SET status = 'ACTIVE'`).
+		WithArgs(int32(1), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "renter_id", "owner_id", "name"}).
+			AddRow(50, 3, 4, "Drill"))
+
+	for _, userID := range []int32{3, 4} {
+		uid := userID
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == uid && n.Attributes["type"] == "RENTAL_AUTO_ACTIVATED" && n.Attributes["rental_id"] == "50"
+		})).Return(nil).Once()
+	}
+
+	jr.AutoActivateScheduledRentals()
+
+	noteSvc.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAutoActivateScheduledRentals_PolicyOff(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	orgRepo := new(MockOrganizationRepo)
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{OrganizationRepository: orgRepo}, &jobs.Services{Notification: noteSvc}, &config.Config{})
+
+	orgRepo.On("List", mock.Anything).Return([]domain.Organization{
+		{ID: 2, Name: "Manual Org", AutoActivateRentals: false},
+	}, nil)
+
+	// No UPDATE is expected: rentals in orgs without the policy stay SCHEDULED.
+	jr.AutoActivateScheduledRentals()
+
+	noteSvc.AssertNotCalled(t, "Dispatch", mock.Anything, mock.Anything)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAutoActivateScheduledRentals_StartDateBoundary(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	orgRepo := new(MockOrganizationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{OrganizationRepository: orgRepo}, &jobs.Services{Notification: new(MockNotificationRepo)}, &config.Config{})
+	orgRepo.On("List", mock.Anything).Return([]domain.Organization{
+		{ID: 1, Name: "Auto Org", AutoActivateRentals: true},
+	}, nil)
+
+	// One second before midnight rentals starting tomorrow stay SCHEDULED; at midnight they start
+	for _, tc := range []struct {
+		now   time.Time
+		today string
+	}{
+		{time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC), "2026-03-31"},
+		{time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), "2026-04-01"},
+	} {
+		jr.SetClock(func() time.Time { return tc.now })
+		dbMock.ExpectQuery(`UPDATE rentals r\s+SET status = 'ACTIVE'`).
+			WithArgs(int32(1), tc.today).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "renter_id", "owner_id", "name"}))
+
+		jr.AutoActivateScheduledRentals()
+	}
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestExpireStalePendingRentals(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	noteSvc := new(MockNotificationRepo)
+	cfg := &config.Config{Rental: config.RentalConfig{RequestExpiryHours: 24}}
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Notification: noteSvc}, cfg)
+
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	jr.SetClock(func() time.Time { return now })
+	cutoff := now.Add(-24 * time.Hour)
+
+	// Only requests created before the cutoff match; the row returned is the stale one.
+	dbMock.ExpectQuery(`UPDATE rentals r\s+SET status = 'REJECTED'.*r.status = 'PENDING'\s+AND r.created_at < \$1`).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "renter_id", "name"}).
+			AddRow(70, 1, 3, "Ladder"))
+	// A second run finds nothing left to expire.
+	dbMock.ExpectQuery(`UPDATE rentals r\s+SET status = 'REJECTED'`).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "renter_id", "name"}))
+
+	noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+		return n.UserID == 3 && n.OrgID == 1 && n.Attributes["type"] == "RENTAL_REQUEST_EXPIRED" && n.Attributes["rental_id"] == "70"
+	})).Return(nil).Once()
+
+	jr.ExpireStalePendingRentals()
+	jr.ExpireStalePendingRentals()
+
+	noteSvc.AssertExpectations(t)
+	noteSvc.AssertNumberOfCalls(t, "Dispatch", 1)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestMarkOverdueRentals_NotifiesBothPartiesOnce(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	emailSvc := new(MockEmailService)
+	noteSvc := new(MockNotificationRepo)
+	jr := jobs.NewJobRunner(db, &postgres.Store{}, &jobs.Services{Email: emailSvc, Notification: noteSvc}, &config.Config{})
+	jr.SetClock(func() time.Time { return time.Date(2026, 4, 2, 2, 0, 0, 0, time.UTC) })
+
+	columns := []string{"id", "org_id", "renter_id", "owner_id", "end_date", "name", "email", "name", "email", "name"}
+	dbMock.ExpectQuery(`UPDATE rentals\s+SET status = 'OVERDUE'.*WHERE status = 'ACTIVE'\s+AND end_date < \$1.*INSERT INTO rental_events`).
+		WithArgs("2026-04-02").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(90, 1, 3, 4, "2026-04-01", "Drill", "renter@example.com", "Renter", "owner@example.com", "Owner"))
+	// The next run finds no ACTIVE rentals left past their end date
+	dbMock.ExpectQuery(`UPDATE rentals\s+SET status = 'OVERDUE'`).
+		WithArgs("2026-04-02").
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	for _, userID := range []int32{3, 4} {
+		uid := userID
+		noteSvc.On("Dispatch", mock.Anything, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == uid && n.OrgID == 1 && n.Attributes["type"] == "RENTAL_OVERDUE" && n.Attributes["rental_id"] == "90"
+		})).Return(nil).Once()
+	}
+	for _, email := range []string{"renter@example.com", "owner@example.com"} {
+		emailSvc.On("SendAdminNotification", mock.Anything, email, "Rental Overdue: Drill", mock.Anything).Return(nil).Once()
+	}
+
+	jr.MarkOverdueRentals()
+	jr.MarkOverdueRentals()
+
+	noteSvc.AssertExpectations(t)
+	noteSvc.AssertNumberOfCalls(t, "Dispatch", 2)
+	emailSvc.AssertExpectations(t)
+	emailSvc.AssertNumberOfCalls(t, "SendAdminNotification", 2)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -2,13 +2,17 @@ package unit
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository"
 	"ubertool-backend-trusted/internal/service"
+	"ubertool-backend-trusted/internal/utils"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -23,7 +27,7 @@ func TestRentalService_CreateRentalRequest(t *testing.T) {
 	emailSvc := new(MockEmailService)
 	noteRepo := new(MockNotificationRepo)
 
-	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 
 	ctx := context.Background()
 	renterID := int32(1)
@@ -44,13 +48,17 @@ func TestRentalService_CreateRentalRequest(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
+		userRepo.On("GetUserOrg", ctx, renterID, orgID).Return(&domain.UserOrg{UserID: renterID, OrgID: orgID}, nil)
+		userRepo.On("GetUserOrg", ctx, int32(10), orgID).Return(&domain.UserOrg{UserID: 10, OrgID: orgID}, nil)
 		ledgerRepo.On("GetBalance", ctx, renterID, orgID).Return(int32(5000), nil)
 		rentalRepo.On("Create", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
 
 		// Setup expectations for email notification
 		userRepo.On("GetByID", ctx, int32(10)).Return(&domain.User{ID: 10, Email: "owner@test.com", Name: "Owner"}, nil)
 		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{ID: renterID, Email: "renter@test.com", Name: "Renter"}, nil)
-		emailSvc.On("SendRentalRequestNotification", ctx, "owner@test.com", "Renter", "Tool", "renter@test.com").Return(nil)
+		emailSvc.On("SendRentalRequestNotification", ctx, "owner@test.com", mock.MatchedBy(func(d service.RentalRequestEmail) bool {
+			return d.RenterName == "Renter" && d.ToolName == "Tool"
+		}), "renter@test.com").Return(nil)
 		noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Return(nil)
 
 		res, err := svc.CreateRentalRequest(ctx, renterID, toolID, orgID, startDate, endDate)
@@ -59,6 +67,51 @@ func TestRentalService_CreateRentalRequest(t *testing.T) {
 		assert.Equal(t, toolID, res.ToolID)
 		assert.Equal(t, renterID, res.RenterID)
 		assert.Equal(t, int32(2000), res.TotalCostCents) // 2 days (end-exclusive: +24h to +72h) * 1000
+		assert.Equal(t, "round_up", res.PricingRounding)
+	})
+
+	t.Run("Configured rounding is recorded and priced", func(t *testing.T) {
+		weekTool := &domain.Tool{ID: 5, Name: "Tool", OwnerID: 10, PricePerWeekCents: 4500, PricePerMonthCents: 13500, DurationUnit: domain.ToolDurationUnitWeek}
+		toolRepo.On("GetByID", ctx, int32(5)).Return(weekTool, nil)
+		svc := service.NewRentalServiceWithOptions(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil,
+			service.RentalOptions{PricingRounding: utils.RoundingPolicyProrate})
+
+		end := time.Now().Add(24*time.Hour).AddDate(0, 0, 10).Format("2006-01-02")
+		res, err := svc.CreateRentalRequest(ctx, renterID, 5, orgID, startDate, end)
+		assert.NoError(t, err)
+		assert.Equal(t, "prorate", res.PricingRounding)
+		assert.Equal(t, int32(6429), res.TotalCostCents) // 1 week + 3/7 week
+	})
+
+	t.Run("Renter Renting Blocked", func(t *testing.T) {
+		blockedRepo := new(MockUserRepo)
+		blockedRentalRepo := new(MockRentalRepo)
+		svc := service.NewRentalService(blockedRentalRepo, toolRepo, ledgerRepo, blockedRepo, emailSvc, noteRepo, nil)
+		blockedRepo.On("GetUserOrg", ctx, renterID, orgID).Return(&domain.UserOrg{
+			UserID: renterID, OrgID: orgID, RentingBlocked: true, BlockedReason: "Blocked due to unresolved payment dispute (debtor at fault)",
+		}, nil)
+
+		res, err := svc.CreateRentalRequest(ctx, renterID, toolID, orgID, startDate, endDate)
+		assert.ErrorIs(t, err, service.ErrRentingBlocked)
+		assert.Contains(t, err.Error(), "debtor at fault")
+		assert.Nil(t, res)
+		blockedRentalRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Owner Lending Blocked", func(t *testing.T) {
+		blockedRepo := new(MockUserRepo)
+		blockedRentalRepo := new(MockRentalRepo)
+		svc := service.NewRentalService(blockedRentalRepo, toolRepo, ledgerRepo, blockedRepo, emailSvc, noteRepo, nil)
+		blockedRepo.On("GetUserOrg", ctx, renterID, orgID).Return(&domain.UserOrg{UserID: renterID, OrgID: orgID, LendingBlocked: true}, nil)
+		blockedRepo.On("GetUserOrg", ctx, int32(10), orgID).Return(&domain.UserOrg{
+			UserID: 10, OrgID: orgID, LendingBlocked: true, BlockedReason: "Blocked due to dispute resolution (creditor at fault)",
+		}, nil)
+
+		res, err := svc.CreateRentalRequest(ctx, renterID, toolID, orgID, startDate, endDate)
+		assert.ErrorIs(t, err, service.ErrLendingBlocked)
+		assert.Contains(t, err.Error(), "creditor at fault")
+		assert.Nil(t, res)
+		blockedRentalRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 	})
 
 	// Balance check is disabled for now
@@ -75,6 +128,24 @@ func TestRentalService_CreateRentalRequest(t *testing.T) {
 	// })
 }
 
+func TestRentalService_ApproveRentalRequest_LendingBlocked(t *testing.T) {
+	rentalRepo := new(MockRentalRepo)
+	userRepo := new(MockUserRepo)
+	svc := service.NewRentalService(rentalRepo, new(MockToolRepo), new(MockLedgerRepo), userRepo, new(MockEmailService), new(MockNotificationRepo), nil)
+	ctx := context.Background()
+
+	rentalRepo.On("GetByID", ctx, int32(1)).Return(&domain.Rental{ID: 1, OrgID: 3, OwnerID: 10, RenterID: 2, Status: domain.RentalStatusPending}, nil)
+	userRepo.On("GetUserOrg", ctx, int32(10), int32(3)).Return(&domain.UserOrg{
+		UserID: 10, OrgID: 3, LendingBlocked: true, BlockedReason: "Blocked due to unresolved payment dispute (both at fault)",
+	}, nil)
+
+	res, err := svc.ApproveRentalRequest(ctx, 10, 1, "garage")
+	assert.ErrorIs(t, err, service.ErrLendingBlocked)
+	assert.Contains(t, err.Error(), "both at fault")
+	assert.Nil(t, res)
+	rentalRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
 func TestRentalService_CompleteRental(t *testing.T) {
 	ctx := context.Background()
 	ownerID := int32(10)
@@ -110,12 +181,13 @@ func TestRentalService_CompleteRental(t *testing.T) {
 
 	t.Run("Success with charge_billsplit=true", func(t *testing.T) {
 		rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo := newMocks()
-		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 
 		rt := *baseRental
 		rentalRepo.On("GetByID", ctx, rentalID).Return(&rt, nil)
 		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
-		rentalRepo.On("ListByTool", ctx, toolID, orgID, mock.Anything, int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
 
 		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{Email: "renter@test.com"}, nil)
 		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{Email: "owner@test.com"}, nil)
@@ -143,12 +215,13 @@ func TestRentalService_CompleteRental(t *testing.T) {
 
 	t.Run("Success with charge_billsplit=false", func(t *testing.T) {
 		rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo := newMocks()
-		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 
 		rt := *baseRental
 		rentalRepo.On("GetByID", ctx, rentalID).Return(&rt, nil)
 		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
-		rentalRepo.On("ListByTool", ctx, toolID, orgID, mock.Anything, int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
 
 		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{Email: "renter@test.com"}, nil)
 		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{Email: "owner@test.com"}, nil)
@@ -173,14 +246,66 @@ func TestRentalService_CompleteRental(t *testing.T) {
 		userRepo.AssertNotCalled(t, "UpdateUserOrg")
 	})
 
+	t.Run("Hold from finalize is released before the settlement debit", func(t *testing.T) {
+		rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo := newMocks()
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
+
+		rt := *baseRental
+		rentalRepo.On("GetByID", ctx, rentalID).Return(&rt, nil)
+		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(2000), nil)
+
+		var entries []domain.LedgerTransaction
+		ledgerRepo.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Run(func(args mock.Arguments) {
+			entries = append(entries, *args.Get(1).(*domain.LedgerTransaction))
+		}).Return(nil)
+		userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{Name: "Tool"}, nil)
+		toolRepo.On("Update", ctx, mock.AnythingOfType("*domain.Tool")).Return(nil)
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good condition", 0, "", true)
+		require.NoError(t, err)
+
+		require.Len(t, entries, 3)
+		assert.Equal(t, domain.TransactionTypeHoldRelease, entries[0].Type)
+		assert.Equal(t, renterID, entries[0].UserID)
+		assert.Equal(t, int32(2000), entries[0].Amount)
+		assert.Equal(t, domain.TransactionTypeLendingCredit, entries[1].Type)
+		assert.Equal(t, domain.TransactionTypeLendingDebit, entries[2].Type)
+		assert.Equal(t, int32(-2000), entries[2].Amount)
+	})
+
+	t.Run("Hold is released when the rental is not charged", func(t *testing.T) {
+		rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo := newMocks()
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
+
+		rt := *baseRental
+		rentalRepo.On("GetByID", ctx, rentalID).Return(&rt, nil)
+		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(2000), nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHoldRelease && tx.Amount == 2000
+		})).Return(nil).Once()
+		userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{Name: "Tool"}, nil)
+		toolRepo.On("Update", ctx, mock.AnythingOfType("*domain.Tool")).Return(nil)
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good condition", 0, "", false)
+		require.NoError(t, err)
+		ledgerRepo.AssertNumberOfCalls(t, "CreateTransaction", 1)
+	})
+
 	t.Run("Settlement notification reminder text when charge_billsplit=false", func(t *testing.T) {
 		rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo := newMocks()
-		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 
 		rt := *baseRental
 		rentalRepo.On("GetByID", ctx, rentalID).Return(&rt, nil)
 		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
-		rentalRepo.On("ListByTool", ctx, toolID, orgID, mock.Anything, int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
 
 		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{ID: renterID, Email: "renter@test.com", Name: "Renter"}, nil)
 		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{ID: ownerID, Email: "owner@test.com", Name: "Owner"}, nil)
@@ -220,6 +345,158 @@ func TestRentalService_CompleteRental(t *testing.T) {
 	})
 }
 
+// fakeTransactor hands fn a fixed set of repositories and records whether the transaction
+// would have committed, standing in for postgres.Store.WithTx
+type fakeTransactor struct {
+	repos     repository.TxRepositories
+	committed bool
+}
+
+func (f *fakeTransactor) WithTx(ctx context.Context, fn func(tx repository.TxRepositories) error) error {
+	err := fn(f.repos)
+	f.committed = err == nil
+	return err
+}
+
+func TestRentalService_CompleteRental_Transaction(t *testing.T) {
+	ctx := context.Background()
+	rental := domain.Rental{
+		ID: 1, RenterID: 1, OwnerID: 10, OrgID: 3, ToolID: 4,
+		StartDate:    time.Now().Add(-48 * time.Hour).Format("2006-01-02"),
+		EndDate:      time.Now().Format("2006-01-02"),
+		DurationUnit: string(domain.ToolDurationUnitDay), DailyPriceCents: 1000,
+		Status: domain.RentalStatusActive,
+	}
+
+	t.Run("Every write goes through the transaction", func(t *testing.T) {
+		rentalRepo, userRepo := new(MockRentalRepo), new(MockUserRepo)
+		txRentals, txTools, txLedger := new(MockRentalRepo), new(MockToolRepo), new(MockLedgerRepo)
+		tx := &fakeTransactor{repos: repository.TxRepositories{Rentals: txRentals, Tools: txTools, Ledger: txLedger, Users: new(MockUserRepo)}}
+		noteSvc := new(MockNotificationRepo)
+		svc := service.NewRentalServiceWithOptions(rentalRepo, new(MockToolRepo), new(MockLedgerRepo), userRepo, nil, noteSvc, nil,
+			service.RentalOptions{Transactor: tx})
+
+		rt := rental
+		rentalRepo.On("GetByID", ctx, int32(1)).Return(&rt, nil)
+		txRentals.On("Update", ctx, &rt).Return(nil)
+		txRentals.On("ListByTool", ctx, int32(4), int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		txLedger.On("GetRentalHold", ctx, int32(1)).Return(int32(2000), nil)
+		txLedger.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Return(nil).Times(3)
+		txTools.On("GetByID", ctx, int32(4)).Return(&domain.Tool{ID: 4, Name: "Drill"}, nil)
+		txTools.On("Update", ctx, mock.AnythingOfType("*domain.Tool")).Return(nil)
+		// Users are looked up after the commit, for notifications only
+		userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+		noteSvc.On("Dispatch", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+		res, err := svc.CompleteRental(ctx, 10, 1, "Good", 0, "", true)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusCompleted, res.Status)
+		assert.True(t, tx.committed)
+		txRentals.AssertExpectations(t)
+		txLedger.AssertExpectations(t)
+		rentalRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("A failed settlement rolls the completion back", func(t *testing.T) {
+		rentalRepo := new(MockRentalRepo)
+		txRentals, txLedger := new(MockRentalRepo), new(MockLedgerRepo)
+		tx := &fakeTransactor{repos: repository.TxRepositories{Rentals: txRentals, Tools: new(MockToolRepo), Ledger: txLedger, Users: new(MockUserRepo)}}
+		svc := service.NewRentalServiceWithOptions(rentalRepo, new(MockToolRepo), new(MockLedgerRepo), new(MockUserRepo), nil, nil, nil,
+			service.RentalOptions{Transactor: tx})
+
+		rt := rental
+		rentalRepo.On("GetByID", ctx, int32(1)).Return(&rt, nil)
+		txRentals.On("Update", ctx, &rt).Return(nil)
+		txLedger.On("GetRentalHold", ctx, int32(1)).Return(int32(0), nil)
+		txLedger.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Return(nil).Once()
+		txLedger.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Return(errors.New("insert failed")).Once()
+
+		_, err := svc.CompleteRental(ctx, 10, 1, "Good", 0, "", true)
+		assert.EqualError(t, err, "insert failed")
+		assert.False(t, tx.committed)
+	})
+}
+
+func TestResolveToolStatus(t *testing.T) {
+	cases := []struct {
+		current domain.ToolStatus
+		busy    int32
+		want    domain.ToolStatus
+	}{
+		{domain.ToolStatusAvailable, 0, domain.ToolStatusAvailable},
+		{domain.ToolStatusAvailable, 1, domain.ToolStatusRented},
+		{domain.ToolStatusRented, 2, domain.ToolStatusRented},
+		{domain.ToolStatusRented, 0, domain.ToolStatusAvailable},
+		{domain.ToolStatusUnavailable, 0, domain.ToolStatusUnavailable},
+		{domain.ToolStatusUnavailable, 1, domain.ToolStatusUnavailable},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, domain.ResolveToolStatus(tc.current, tc.busy), "%s with %d busy rentals", tc.current, tc.busy)
+	}
+}
+
+func TestRentalService_ToolStatusWithSeveralRentals(t *testing.T) {
+	ctx := context.Background()
+	ownerID, renterID, rentalID, toolID := int32(10), int32(20), int32(1), int32(4)
+	busy := []string{"SCHEDULED", "ACTIVE", "OVERDUE", "RETURN_DATE_CHANGED", "RETURN_DATE_CHANGE_REJECTED", "EARLY_RETURN_REQUESTED"}
+
+	newSvc := func(toolStatus domain.ToolStatus, busyLeft int32) (service.RentalService, *MockRentalRepo, *MockToolRepo) {
+		rentalRepo, toolRepo, ledgerRepo, userRepo := new(MockRentalRepo), new(MockToolRepo), new(MockLedgerRepo), new(MockUserRepo)
+		rentalRepo.On("GetByID", ctx, rentalID).Return(&domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, OrgID: 3, ToolID: toolID,
+			StartDate:    time.Now().Add(-48 * time.Hour).Format("2006-01-02"),
+			EndDate:      time.Now().Format("2006-01-02"),
+			DurationUnit: string(domain.ToolDurationUnitDay), DailyPriceCents: 1000,
+			Status: domain.RentalStatusActive,
+		}, nil)
+		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+		// Rentals of every org count, in every status that keeps the tool out
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), busy, "", "", int32(1), int32(1)).Return([]domain.Rental{}, busyLeft, nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Return(nil)
+		userRepo.On("GetByID", mock.Anything, mock.Anything).Return(&domain.User{Email: "u@test.com"}, nil)
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Drill", Status: toolStatus}, nil)
+		emailSvc := new(MockEmailService)
+		emailSvc.On("SendRentalCompletionNotification", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return(nil)
+		return service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, new(MockNotificationRepo), nil), rentalRepo, toolRepo
+	}
+
+	t.Run("Completing one of two rentals keeps the tool rented", func(t *testing.T) {
+		svc, _, toolRepo := newSvc(domain.ToolStatusRented, 1) // the other rental is overdue
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good", 0, "", true)
+		require.NoError(t, err)
+		toolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Completing the last rental frees the tool", func(t *testing.T) {
+		svc, _, toolRepo := newSvc(domain.ToolStatusRented, 0)
+		toolRepo.On("Update", ctx, mock.MatchedBy(func(tl *domain.Tool) bool {
+			return tl.Status == domain.ToolStatusAvailable
+		})).Return(nil).Once()
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good", 0, "", true)
+		require.NoError(t, err)
+		toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("A tool the owner made unavailable stays unavailable", func(t *testing.T) {
+		svc, _, toolRepo := newSvc(domain.ToolStatusUnavailable, 0)
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good", 0, "", true)
+		require.NoError(t, err)
+		toolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("A failed tool update fails the completion", func(t *testing.T) {
+		svc, _, toolRepo := newSvc(domain.ToolStatusRented, 0)
+		toolRepo.On("Update", ctx, mock.AnythingOfType("*domain.Tool")).Return(errors.New("tool update failed"))
+
+		_, err := svc.CompleteRental(ctx, ownerID, rentalID, "Good", 0, "", true)
+		assert.EqualError(t, err, "tool update failed")
+	})
+}
+
 func TestRentalService_FinalizeRentalRequest(t *testing.T) {
 	rentalRepo := new(MockRentalRepo)
 	toolRepo := new(MockToolRepo)
@@ -228,7 +505,7 @@ func TestRentalService_FinalizeRentalRequest(t *testing.T) {
 	emailSvc := new(MockEmailService)
 	noteRepo := new(MockNotificationRepo)
 
-	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
 	ctx := context.Background()
 
 	renterID := int32(1)
@@ -240,7 +517,8 @@ func TestRentalService_FinalizeRentalRequest(t *testing.T) {
 	requestRental := &domain.Rental{
 		ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID,
 		Status: domain.RentalStatusApproved, TotalCostCents: 5000,
-		OrgID: 99,
+		OrgID: 99, StartDate: "2026-03-02", EndDate: "2026-03-07",
+		DurationUnit: string(domain.ToolDurationUnitDay), DailyPriceCents: 1000, WeeklyPriceCents: 6000, MonthlyPriceCents: 20000,
 	}
 	approvedRental := domain.Rental{ID: 101, ToolID: toolID, Status: domain.RentalStatusApproved}
 	pendingRental := domain.Rental{ID: 102, ToolID: toolID, Status: domain.RentalStatusPending}
@@ -253,27 +531,36 @@ func TestRentalService_FinalizeRentalRequest(t *testing.T) {
 		// 1. Get Rental
 		rentalRepo.On("GetByID", ctx, rentalID).Return(requestRental, nil)
 
-		// 2. Update Rental Status (no ledger transaction at finalize)
+		// 2. Update Rental Status
 		rentalRepo.On("Update", ctx, mock.MatchedBy(func(r *domain.Rental) bool {
 			return r.Status == domain.RentalStatusScheduled
 		})).Return(nil)
 
-		// 3. Update Tool Status
+		// 3. Hold the estimated cost (5 days * 1000 cents); the renter is charged at completion
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHold && tx.UserID == renterID && tx.Amount == -5000 &&
+				tx.RelatedRentalID != nil && *tx.RelatedRentalID == rentalID
+		})).Return(nil).Once()
+
+		// 4. Update Tool Status: the finalized rental now occupies the tool
 		toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.MatchedBy(func(statuses []string) bool { return len(statuses) > 1 }), "", "", int32(1), int32(1)).
+			Return([]domain.Rental{*requestRental}, int32(1), nil)
 		toolRepo.On("Update", ctx, mock.MatchedBy(func(tl *domain.Tool) bool {
 			return tl.Status == domain.ToolStatusRented
-		})).Return(nil)
+		})).Return(nil).Once()
 
-		// 4. Notifications
+		// 5. Notifications
 		userRepo.On("GetByID", ctx, renterID).Return(renter, nil)
 		userRepo.On("GetByID", ctx, ownerID).Return(owner, nil)
 		emailSvc.On("SendRentalConfirmationNotification", ctx, owner.Email, renter.Name, tool.Name, renter.Email).Return(nil)
 		noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Return(nil)
 
-		// 5. List Related Rentals
-		rentalRepo.On("ListByTool", ctx, mock.Anything, mock.Anything, []string{string(domain.RentalStatusApproved)}, mock.Anything, mock.Anything).
+		// 6. List Related Rentals
+		rentalRepo.On("ListByTool", ctx, mock.Anything, mock.Anything, []string{string(domain.RentalStatusApproved)}, "", "", mock.Anything, mock.Anything).
 			Return([]domain.Rental{approvedRental}, int32(1), nil)
-		rentalRepo.On("ListByTool", ctx, mock.Anything, mock.Anything, []string{string(domain.RentalStatusPending)}, mock.Anything, mock.Anything).
+		rentalRepo.On("ListByTool", ctx, mock.Anything, mock.Anything, []string{string(domain.RentalStatusPending)}, "", "", mock.Anything, mock.Anything).
 			Return([]domain.Rental{pendingRental}, int32(1), nil)
 
 		res, approved, pending, err := svc.FinalizeRentalRequest(ctx, renterID, rentalID)
@@ -286,9 +573,168 @@ func TestRentalService_FinalizeRentalRequest(t *testing.T) {
 		assert.Len(t, pending, 1)
 		assert.Equal(t, approvedRental.ID, approved[0].ID)
 		assert.Equal(t, pendingRental.ID, pending[0].ID)
+		ledgerRepo.AssertExpectations(t)
+		toolRepo.AssertExpectations(t)
 	})
 }
 
+func TestRentalService_CancelRental(t *testing.T) {
+	ctx := context.Background()
+	renterID, ownerID, rentalID, toolID, orgID := int32(1), int32(10), int32(100), int32(200), int32(99)
+
+	type mocks struct {
+		rentalRepo *MockRentalRepo
+		toolRepo   *MockToolRepo
+		ledgerRepo *MockLedgerRepo
+	}
+	newService := func(status domain.RentalStatus) (service.RentalService, mocks) {
+		m := mocks{new(MockRentalRepo), new(MockToolRepo), new(MockLedgerRepo)}
+		userRepo := new(MockUserRepo)
+		m.rentalRepo.On("GetByID", ctx, rentalID).Return(&domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID, Status: status,
+		}, nil)
+		m.rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+		userRepo.On("GetByID", ctx, mock.Anything).Return(nil, errors.New("not found"))
+		return service.NewRentalService(m.rentalRepo, m.toolRepo, m.ledgerRepo, userRepo, new(MockEmailService), new(MockNotificationRepo), nil), m
+	}
+
+	t.Run("Cancel after finalize refunds the hold and frees the tool", func(t *testing.T) {
+		svc, m := newService(domain.RentalStatusScheduled)
+		m.ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(3000), nil)
+		m.ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHoldRelease && tx.UserID == renterID && tx.Amount == 3000 &&
+				tx.RelatedRentalID != nil && *tx.RelatedRentalID == rentalID
+		})).Return(nil).Once()
+		m.toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Hammer", Status: domain.ToolStatusRented}, nil)
+		m.rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		m.toolRepo.On("Update", ctx, mock.MatchedBy(func(tl *domain.Tool) bool {
+			return tl.Status == domain.ToolStatusAvailable
+		})).Return(nil).Once()
+
+		res, err := svc.CancelRental(ctx, renterID, rentalID, "Plans changed")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusCancelled, res.Status)
+		m.ledgerRepo.AssertExpectations(t)
+		m.toolRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tool stays rented while another rental is scheduled", func(t *testing.T) {
+		svc, m := newService(domain.RentalStatusScheduled)
+		m.ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(0), nil)
+		m.toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Hammer", Status: domain.ToolStatusRented}, nil)
+		m.rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{{ID: 101}}, int32(1), nil)
+
+		_, err := svc.CancelRental(ctx, renterID, rentalID, "Plans changed")
+		require.NoError(t, err)
+		m.toolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		m.ledgerRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Pending rental never held funds", func(t *testing.T) {
+		svc, m := newService(domain.RentalStatusPending)
+		m.toolRepo.On("GetByID", ctx, toolID).Return(nil, errors.New("not found"))
+
+		_, err := svc.CancelRental(ctx, renterID, rentalID, "Plans changed")
+		require.NoError(t, err)
+		m.ledgerRepo.AssertNotCalled(t, "GetRentalHold", mock.Anything, mock.Anything)
+		m.ledgerRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+		m.toolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Active rental cannot be cancelled", func(t *testing.T) {
+		for _, status := range []domain.RentalStatus{domain.RentalStatusActive, domain.RentalStatusOverdue, domain.RentalStatusCompleted} {
+			svc, m := newService(status)
+
+			_, err := svc.CancelRental(ctx, renterID, rentalID, "Plans changed")
+			assert.ErrorIs(t, err, service.ErrRentalNotCancellable, "status %s", status)
+			m.rentalRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestRentalService_CancelRental_ByOwner(t *testing.T) {
+	ctx := context.Background()
+	renterID, ownerID, rentalID, toolID, orgID := int32(1), int32(10), int32(100), int32(200), int32(99)
+	renter := &domain.User{ID: renterID, Name: "Renter", Email: "r@test.com"}
+	owner := &domain.User{ID: ownerID, Name: "Owner", Email: "o@test.com"}
+
+	setup := func(status domain.RentalStatus) (service.RentalService, *MockLedgerRepo, *MockToolRepo, *MockEmailService, *MockNotificationRepo) {
+		rentalRepo, toolRepo, ledgerRepo, userRepo := new(MockRentalRepo), new(MockToolRepo), new(MockLedgerRepo), new(MockUserRepo)
+		emailSvc, noteRepo := new(MockEmailService), new(MockNotificationRepo)
+		rentalRepo.On("GetByID", ctx, rentalID).Return(&domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID, Status: status,
+		}, nil)
+		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
+		rentalRepo.On("ListByTool", ctx, toolID, int32(0), mock.Anything, "", "", int32(1), int32(1)).Return([]domain.Rental{}, int32(0), nil)
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Hammer", Status: domain.ToolStatusRented}, nil)
+		userRepo.On("GetByID", ctx, renterID).Return(renter, nil)
+		userRepo.On("GetByID", ctx, ownerID).Return(owner, nil)
+		emailSvc.On("SendRentalCancellationNotification", ctx, renter.Email, owner.Name, "Hammer", "Tool broke", owner.Email).Return(nil)
+		noteRepo.On("Dispatch", ctx, mock.AnythingOfType("*domain.Notification")).Return(nil)
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil)
+		return svc, ledgerRepo, toolRepo, emailSvc, noteRepo
+	}
+
+	// sentNotice returns the notification dispatched by the cancellation
+	sentNotice := func(t *testing.T, noteRepo *MockNotificationRepo) *domain.Notification {
+		for _, call := range noteRepo.Calls {
+			if n, ok := call.Arguments.Get(1).(*domain.Notification); ok && call.Method == "Dispatch" {
+				return n
+			}
+		}
+		t.Fatal("no notification dispatched")
+		return nil
+	}
+
+	t.Run("Pending rental", func(t *testing.T) {
+		svc, ledgerRepo, toolRepo, emailSvc, noteRepo := setup(domain.RentalStatusPending)
+
+		res, err := svc.CancelRental(ctx, ownerID, rentalID, "Tool broke")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusCancelled, res.Status)
+		ledgerRepo.AssertNotCalled(t, "GetRentalHold", mock.Anything, mock.Anything)
+		toolRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		emailSvc.AssertExpectations(t)
+
+		n := sentNotice(t, noteRepo)
+		assert.Equal(t, renterID, n.UserID)
+		assert.Equal(t, "Rental Cancelled by Owner", n.Title)
+		assert.Equal(t, "OWNER", n.Attributes["cancelled_by"])
+		assert.Contains(t, n.Message, "Tool broke")
+	})
+
+	t.Run("Scheduled rental refunds the renter and frees the tool", func(t *testing.T) {
+		svc, ledgerRepo, toolRepo, emailSvc, noteRepo := setup(domain.RentalStatusScheduled)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Return(int32(2000), nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHoldRelease && tx.UserID == renterID && tx.Amount == 2000
+		})).Return(nil).Once()
+		toolRepo.On("Update", ctx, mock.MatchedBy(func(tl *domain.Tool) bool {
+			return tl.Status == domain.ToolStatusAvailable
+		})).Return(nil).Once()
+
+		_, err := svc.CancelRental(ctx, ownerID, rentalID, "Tool broke")
+		require.NoError(t, err)
+		ledgerRepo.AssertExpectations(t)
+		toolRepo.AssertExpectations(t)
+		emailSvc.AssertExpectations(t)
+		assert.Equal(t, renterID, sentNotice(t, noteRepo).UserID)
+	})
+
+	t.Run("Reason is required", func(t *testing.T) {
+		svc, _, _, _, _ := setup(domain.RentalStatusScheduled)
+
+		_, err := svc.CancelRental(ctx, ownerID, rentalID, "  ")
+		assert.ErrorIs(t, err, service.ErrCancelReasonRequired)
+	})
+
+	t.Run("Outsider is rejected", func(t *testing.T) {
+		svc, _, _, _, _ := setup(domain.RentalStatusScheduled)
+
+		_, err := svc.CancelRental(ctx, 42, rentalID, "Tool broke")
+		assert.EqualError(t, err, "unauthorized")
+	})
+}
 func TestRentalService_ActivateRental(t *testing.T) {
 	rentalRepo := new(MockRentalRepo)
 	toolRepo := new(MockToolRepo)
@@ -296,7 +742,7 @@ func TestRentalService_ActivateRental(t *testing.T) {
 	emailSvc := new(MockEmailService)
 	noteRepo := new(MockNotificationRepo)
 
-	svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 	ctx := context.Background()
 
 	ownerID := int32(10)
@@ -343,7 +789,7 @@ func TestRentalService_ChangeRentalDates(t *testing.T) {
 	userRepo := new(MockUserRepo)
 	noteRepo := new(MockNotificationRepo)
 
-	svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 	ctx := context.Background()
 
 	renterID := int32(20)
@@ -377,17 +823,20 @@ func TestRentalService_ChangeRentalDates(t *testing.T) {
 		rentalRepo.On("GetByID", ctx, rentalID).Return(&r, nil)
 		toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
 
-		// Expect update with temp status and new cost
+		// Expect the request to be held in RequestedEndDate; the agreed end date and cost are unchanged
 		rentalRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.Rental) bool {
 			return u.Status == domain.RentalStatusReturnDateChanged &&
-				u.TotalCostCents == 2000 // 2 days end-exclusive (today to +48h) * 1000
+				u.RequestedEndDate != nil && *u.RequestedEndDate == newEnd &&
+				u.EndDate == baseRental.EndDate &&
+				u.TotalCostCents == 1000
 		})).Return(nil)
 
 		// Notifications
 		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{ID: ownerID, Email: "owner@a.com"}, nil)
 		// Expect notification via NotificationRepo
 		noteRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
-			return n.UserID == ownerID && n.Title == "Return Date Extension Request"
+			return n.UserID == ownerID && n.Title == "Return Date Extension Request" &&
+				n.Attributes["requested_cost_cents"] == "2000" // 2 days end-exclusive (today to +48h) * 1000
 		})).Return(nil)
 
 		_, err := svc.ChangeRentalDates(ctx, renterID, rentalID, "", newEnd, "", "")
@@ -400,7 +849,7 @@ func TestRentalService_ChangeRentalDates(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		requestedEndDate := time.Now().Add(48 * time.Hour).Format("2006-01-02")
 		lastAgreedEndDate := time.Now().Add(24 * time.Hour).Format("2006-01-02")
@@ -409,8 +858,9 @@ func TestRentalService_ChangeRentalDates(t *testing.T) {
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         time.Now().Format("2006-01-02"),
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate, // Already has a pending request
-			TotalCostCents:    2000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate, // Already has a pending request
+			TotalCostCents:    1000,
 			DurationUnit:      string(domain.ToolDurationUnitDay),
 			DailyPriceCents:   1000,
 			WeeklyPriceCents:  6000,
@@ -423,25 +873,55 @@ func TestRentalService_ChangeRentalDates(t *testing.T) {
 		rentalRepo.On("GetByID", ctx, rentalID).Return(r, nil)
 		toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
 
-		// Expect update with new end date and cost
+		// Expect update with the new requested date; cost stays priced on the agreed date
 		rentalRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.Rental) bool {
 			return u.Status == domain.RentalStatusReturnDateChanged &&
-				u.TotalCostCents == 3000 // 3 days end-exclusive (today to +72h) * 1000
+				*u.RequestedEndDate == updatedEndDate &&
+				u.TotalCostCents == 1000
 		})).Return(nil)
 
 		// Notifications
 		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{ID: ownerID, Email: "owner@a.com"}, nil)
 		noteRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
-			return n.UserID == ownerID && n.Title == "Extension Request Updated"
+			return n.UserID == ownerID && n.Title == "Extension Request Updated" &&
+				n.Attributes["requested_cost_cents"] == "3000" // 3 days end-exclusive (today to +72h) * 1000
 		})).Return(nil)
 
 		result, err := svc.ChangeRentalDates(ctx, renterID, rentalID, "", updatedEndDate, "", "")
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Equal(t, domain.RentalStatusReturnDateChanged, result.Status)
-		assert.Equal(t, int32(3000), result.TotalCostCents)
-		assert.NotNil(t, result.EndDate)
+		assert.Equal(t, int32(1000), result.TotalCostCents)
+		assert.Equal(t, lastAgreedEndDate, result.EndDate)
+		assert.Equal(t, updatedEndDate, *result.RequestedEndDate)
 	})
+
+	// A second negotiation cannot start while the first is still open
+	pendingCases := []struct {
+		name   string
+		status domain.RentalStatus
+		userID int32
+	}{
+		{"Owner cannot change dates while extension pending", domain.RentalStatusReturnDateChanged, ownerID},
+		{"Renter cannot re-request before acknowledging counter-proposal", domain.RentalStatusReturnDateChangeRejected, renterID},
+	}
+	for _, tc := range pendingCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rentalRepo := new(MockRentalRepo)
+			toolRepo := new(MockToolRepo)
+			svc := service.NewRentalService(rentalRepo, toolRepo, nil, new(MockUserRepo), new(MockEmailService), new(MockNotificationRepo), nil)
+
+			r := *baseRental
+			r.Status = tc.status
+			rentalRepo.On("GetByID", ctx, rentalID).Return(&r, nil)
+			toolRepo.On("GetByID", ctx, toolID).Return(tool, nil)
+
+			newEnd := time.Now().Add(96 * time.Hour).Format("2006-01-02")
+			_, err := svc.ChangeRentalDates(ctx, tc.userID, rentalID, "", newEnd, "", "")
+			assert.ErrorIs(t, err, service.ErrExtensionPending)
+			rentalRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
 }
 
 func TestRentalService_RejectReturnDateChange(t *testing.T) {
@@ -473,15 +953,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 			DurationUnit:      string(domain.ToolDurationUnitDay),
 			DailyPriceCents:   1000,
 			WeeklyPriceCents:  6000,
@@ -497,28 +978,32 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		rentalRepo.On("Update", ctx, mock.MatchedBy(func(u *domain.Rental) bool {
 			return u.Status == domain.RentalStatusReturnDateChangeRejected &&
 				u.RejectionReason == reason &&
-				u.EndDate == counterProposalDate &&
-				u.TotalCostCents == 2000 // 2 days end-exclusive (today to +48h) * 1000
+				*u.RequestedEndDate == counterProposalDate &&
+				u.EndDate == lastAgreedEndDate &&
+				u.TotalCostCents == 1000
 		})).Return(nil)
 
 		// Expect notification to renter
 		userRepo.On("GetByID", ctx, renterID).Return(renter, nil)
+		userRepo.On("GetUserOrg", ctx, renterID, baseRental.OrgID).Return(&domain.UserOrg{UserID: renterID, OrgID: baseRental.OrgID, CurrencyCode: "CAD"}, nil)
 		noteRepo.On("Create", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
 			return n.UserID == renterID &&
 				n.Title == "Extension Rejected - Counter-Proposal" &&
 				n.Attributes["type"] == "RETURN_DATE_CHANGE_REJECTED" &&
-				n.Attributes["new_end_date"] == counterProposalDate
+				n.Attributes["new_end_date"] == counterProposalDate &&
+				strings.HasSuffix(n.Message, "Updated cost: CA$20.00")
 		})).Return(nil)
 
-		// Expect email notification
-		emailSvc.On("SendReturnDateRejectionNotification", ctx, renter.Email, tool.Name, counterProposalDate, reason, int32(2000)).Return(nil)
+		// Expect email notification quoting the counter-proposal's cost in the org's currency:
+		// 2 days end-exclusive (today to +48h) * 1000
+		emailSvc.On("SendReturnDateRejectionNotification", ctx, renter.Email, tool.Name, counterProposalDate, reason, int32(2000), "CAD").Return(nil)
 
 		result, err := svc.RejectReturnDateChange(ctx, ownerID, rentalID, reason, counterProposalDate)
 		assert.NoError(t, err)
 		assert.NotNil(t, result)
 		assert.Equal(t, domain.RentalStatusReturnDateChangeRejected, result.Status)
 		assert.Equal(t, reason, result.RejectionReason)
-		assert.Equal(t, int32(2000), result.TotalCostCents)
+		assert.Equal(t, int32(1000), result.TotalCostCents)
 	})
 
 	t.Run("Error - Unauthorized (not owner)", func(t *testing.T) {
@@ -527,15 +1012,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 		}
 		unauthorizedUserID := int32(999)
 		counterProposalDate := time.Now().Add(48 * time.Hour).Format("2006-01-02")
@@ -554,7 +1040,7 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
@@ -580,15 +1066,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 		}
 
 		rentalRepo.On("GetByID", ctx, rentalID).Return(baseRental, nil)
@@ -605,15 +1092,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 		}
 		invalidDate := "2024/01/01" // Wrong format
 
@@ -631,15 +1119,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 		}
 		sameAsRequested := requestedEndDate
 
@@ -657,15 +1146,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 		}
 		pastDate := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
 
@@ -684,15 +1174,16 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		emailSvc := new(MockEmailService)
 		userRepo := new(MockUserRepo)
 		noteRepo := new(MockNotificationRepo)
-		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, emailSvc, noteRepo, nil)
 
 		baseRental := &domain.Rental{
 			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
 			Status:            domain.RentalStatusReturnDateChanged,
 			StartDate:         startDate,
 			LastAgreedEndDate: &lastAgreedEndDate,
-			EndDate:           requestedEndDate,
-			TotalCostCents:    3000,
+			EndDate:           lastAgreedEndDate,
+			RequestedEndDate:  &requestedEndDate,
+			TotalCostCents:    1000,
 			DurationUnit:      string(domain.ToolDurationUnitDay),
 			DailyPriceCents:   1000,
 			WeeklyPriceCents:  6000,
@@ -706,10 +1197,11 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		rentalRepo.On("Update", ctx, mock.AnythingOfType("*domain.Rental")).Return(nil)
 
 		userRepo.On("GetByID", ctx, renterID).Return(renter, nil)
+		userRepo.On("GetUserOrg", ctx, renterID, baseRental.OrgID).Return(nil, fmt.Errorf("membership lookup failed"))
 		noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Return(nil)
 
-		// Email fails but operation should still succeed
-		emailSvc.On("SendReturnDateRejectionNotification", ctx, renter.Email, tool.Name, counterProposalDate, reason, int32(2000)).Return(fmt.Errorf("email error"))
+		// Email fails but operation should still succeed; an unreadable membership falls back to USD
+		emailSvc.On("SendReturnDateRejectionNotification", ctx, renter.Email, tool.Name, counterProposalDate, reason, int32(2000), "USD").Return(fmt.Errorf("email error"))
 
 		result, err := svc.RejectReturnDateChange(ctx, ownerID, rentalID, reason, counterProposalDate)
 		assert.NoError(t, err) // Email error is ignored
@@ -717,3 +1209,553 @@ func TestRentalService_RejectReturnDateChange(t *testing.T) {
 		assert.Equal(t, domain.RentalStatusReturnDateChangeRejected, result.Status)
 	})
 }
+
+func TestRentalService_ApproveReturnDateChange(t *testing.T) {
+	ctx := context.Background()
+	ownerID, renterID, rentalID, toolID, orgID := int32(10), int32(20), int32(100), int32(200), int32(3)
+	startDate := time.Now().Add(-48 * time.Hour).Format("2006-01-02")
+	agreedEnd := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+	requestedEnd := time.Now().Add(72 * time.Hour).Format("2006-01-02")
+
+	newSvc := func(rt *domain.Rental) (service.RentalService, *MockRentalRepo, *MockLedgerRepo) {
+		rentalRepo := new(MockRentalRepo)
+		ledgerRepo := new(MockLedgerRepo)
+		toolRepo := new(MockToolRepo)
+		userRepo := new(MockUserRepo)
+		noteRepo := new(MockNotificationRepo)
+		rentalRepo.On("GetByID", ctx, rentalID).Return(rt, nil)
+		rentalRepo.On("Update", ctx, rt).Return(nil)
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Tool"}, nil)
+		userRepo.On("GetByID", ctx, mock.Anything).Return(&domain.User{ID: renterID, Email: "renter@test.com"}, nil)
+		userRepo.On("GetUserOrg", ctx, mock.Anything, mock.Anything).Maybe().Return(&domain.UserOrg{CurrencyCode: "USD"}, nil)
+		noteRepo.On("Create", ctx, mock.AnythingOfType("*domain.Notification")).Maybe().Return(nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Maybe().Return(rt.TotalCostCents, nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Maybe().Return(nil)
+		emailSvc := new(MockEmailService)
+		emailSvc.On("SendReturnDateRejectionNotification", ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe().Return(nil)
+		return service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, emailSvc, noteRepo, nil), rentalRepo, ledgerRepo
+	}
+	pendingExtension := func() *domain.Rental {
+		agreed, requested := agreedEnd, requestedEnd
+		return &domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
+			Status:            domain.RentalStatusReturnDateChanged,
+			StartDate:         startDate,
+			LastAgreedEndDate: &agreed,
+			EndDate:           agreedEnd,
+			RequestedEndDate:  &requested,
+			DurationUnit:      string(domain.ToolDurationUnitDay),
+			DailyPriceCents:   1000,
+			WeeklyPriceCents:  6000,
+			MonthlyPriceCents: 20000,
+			TotalCostCents:    3000,
+		}
+	}
+
+	t.Run("Sets last agreed end date to the approved date", func(t *testing.T) {
+		rt := pendingExtension()
+		svc, rentalRepo, _ := newSvc(rt)
+
+		res, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+		require.NotNil(t, res.LastAgreedEndDate)
+		assert.Equal(t, requestedEnd, *res.LastAgreedEndDate)
+		assert.Equal(t, requestedEnd, res.EndDate)
+		assert.Nil(t, res.RequestedEndDate)
+		assert.Equal(t, int32(5000), res.TotalCostCents) // repriced: 5 days end-exclusive (-48h to +72h) * 1000
+		rentalRepo.AssertCalled(t, "Update", ctx, rt)
+	})
+
+	t.Run("Extension increases the hold by the extra cost", func(t *testing.T) {
+		rt := pendingExtension()
+		svc, _, ledgerRepo := newSvc(rt)
+
+		_, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		ledgerRepo.AssertCalled(t, "CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHold && tx.Amount == -2000 && tx.UserID == renterID &&
+				tx.OrgID == orgID && *tx.RelatedRentalID == rentalID
+		}))
+	})
+
+	t.Run("Shortening releases the difference", func(t *testing.T) {
+		rt := pendingExtension()
+		earlier := time.Now().Format("2006-01-02")
+		rt.RequestedEndDate = &earlier
+		svc, _, ledgerRepo := newSvc(rt)
+
+		res, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2000), res.TotalCostCents)
+		ledgerRepo.AssertCalled(t, "CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHoldRelease && tx.Amount == 1000 && tx.UserID == renterID
+		}))
+	})
+
+	t.Run("Unchanged cost leaves the hold alone", func(t *testing.T) {
+		rt := pendingExtension()
+		agreed := agreedEnd
+		rt.RequestedEndDate = &agreed
+		svc, _, ledgerRepo := newSvc(rt)
+
+		_, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		ledgerRepo.AssertNotCalled(t, "CreateTransaction", ctx, mock.Anything)
+	})
+
+	t.Run("Cancelling the request keeps the agreed date", func(t *testing.T) {
+		rt := pendingExtension()
+		rt.TotalCostCents = 5000 // priced on the requested date, as rows written before requested_end_date were
+		svc, _, _ := newSvc(rt)
+
+		res, err := svc.CancelReturnDateChange(ctx, renterID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+		assert.Equal(t, agreedEnd, res.EndDate)
+		assert.Nil(t, res.RequestedEndDate)
+		assert.Equal(t, int32(3000), res.TotalCostCents) // 3 days end-exclusive (-48h to +24h) * 1000
+	})
+
+	t.Run("Approve without a requested date is rejected", func(t *testing.T) {
+		rt := pendingExtension()
+		rt.RequestedEndDate = nil
+		svc, rentalRepo, _ := newSvc(rt)
+
+		_, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		assert.ErrorContains(t, err, "no extension request is pending")
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+
+	t.Run("Approved date survives a later rejected extension", func(t *testing.T) {
+		rt := pendingExtension()
+		svc, _, _ := newSvc(rt)
+
+		_, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+
+		// Renter asks for another week; owner counter-proposes; renter acknowledges.
+		further := time.Now().Add(7 * 24 * time.Hour).Format("2006-01-02")
+		_, err = svc.ChangeRentalDates(ctx, renterID, rentalID, "", further, "", "")
+		require.NoError(t, err)
+		require.Equal(t, requestedEnd, *rt.LastAgreedEndDate, "a pending request must not move the agreed date")
+
+		counter := time.Now().Add(5 * 24 * time.Hour).Format("2006-01-02")
+		_, err = svc.RejectReturnDateChange(ctx, ownerID, rentalID, "Need it back", counter)
+		require.NoError(t, err)
+		require.Equal(t, requestedEnd, *rt.LastAgreedEndDate, "a counter-proposal must not move the agreed date")
+
+		res, err := svc.AcknowledgeReturnDateRejection(ctx, renterID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, requestedEnd, res.EndDate)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+	})
+
+	t.Run("Approving a date already past marks the rental overdue", func(t *testing.T) {
+		rt := pendingExtension()
+		past := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+		rt.RequestedEndDate = &past
+		svc, _, _ := newSvc(rt)
+
+		res, err := svc.ApproveReturnDateChange(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusOverdue, res.Status)
+		assert.Equal(t, past, *res.LastAgreedEndDate)
+	})
+
+	t.Run("Error - Unauthorized (not owner)", func(t *testing.T) {
+		rt := pendingExtension()
+		svc, rentalRepo, _ := newSvc(rt)
+
+		_, err := svc.ApproveReturnDateChange(ctx, renterID, rentalID)
+		assert.ErrorContains(t, err, "unauthorized")
+		assert.Equal(t, agreedEnd, *rt.LastAgreedEndDate)
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+}
+
+func TestRentalService_EarlyReturn(t *testing.T) {
+	ctx := context.Background()
+	ownerID, renterID, rentalID, toolID, orgID := int32(10), int32(20), int32(100), int32(200), int32(3)
+	startDate := time.Now().Add(-48 * time.Hour).Format("2006-01-02")
+	agreedEnd := time.Now().Add(72 * time.Hour).Format("2006-01-02")
+	earlyEnd := time.Now().Add(24 * time.Hour).Format("2006-01-02")
+
+	newSvc := func(rt *domain.Rental) (service.RentalService, *MockRentalRepo, *MockLedgerRepo, *MockNotificationRepo) {
+		rentalRepo := new(MockRentalRepo)
+		ledgerRepo := new(MockLedgerRepo)
+		toolRepo := new(MockToolRepo)
+		userRepo := new(MockUserRepo)
+		noteRepo := new(MockNotificationRepo)
+		rentalRepo.On("GetByID", ctx, rentalID).Return(rt, nil)
+		rentalRepo.On("Update", ctx, rt).Return(nil)
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Drill"}, nil)
+		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{ID: ownerID, Email: "owner@test.com"}, nil)
+		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{ID: renterID, Email: "renter@test.com"}, nil)
+		noteRepo.On("Dispatch", ctx, mock.AnythingOfType("*domain.Notification")).Maybe().Return(nil)
+		ledgerRepo.On("GetRentalHold", ctx, rentalID).Maybe().Return(rt.TotalCostCents, nil)
+		ledgerRepo.On("CreateTransaction", ctx, mock.AnythingOfType("*domain.LedgerTransaction")).Maybe().Return(nil)
+		svc := service.NewRentalService(rentalRepo, toolRepo, ledgerRepo, userRepo, new(MockEmailService), noteRepo, nil)
+		return svc, rentalRepo, ledgerRepo, noteRepo
+	}
+	activeRental := func() *domain.Rental {
+		agreed := agreedEnd
+		return &domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
+			Status:            domain.RentalStatusActive,
+			StartDate:         startDate,
+			LastAgreedEndDate: &agreed,
+			EndDate:           agreedEnd,
+			DurationUnit:      string(domain.ToolDurationUnitDay),
+			DailyPriceCents:   1000,
+			WeeklyPriceCents:  6000,
+			MonthlyPriceCents: 20000,
+			TotalCostCents:    5000, // 5 days end-exclusive (-48h to +72h) * 1000
+		}
+	}
+	pendingEarlyReturn := func() *domain.Rental {
+		rt := activeRental()
+		requested := earlyEnd
+		rt.Status = domain.RentalStatusEarlyReturnRequested
+		rt.RequestedEndDate = &requested
+		return rt
+	}
+
+	t.Run("Owner asks for an earlier return date", func(t *testing.T) {
+		rt := activeRental()
+		svc, _, _, noteRepo := newSvc(rt)
+
+		res, err := svc.ChangeRentalDates(ctx, ownerID, rentalID, "", earlyEnd, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusEarlyReturnRequested, res.Status)
+		require.NotNil(t, res.RequestedEndDate)
+		assert.Equal(t, earlyEnd, *res.RequestedEndDate)
+		assert.Equal(t, agreedEnd, res.EndDate, "the agreed date stays in force until the renter accepts")
+		assert.Equal(t, int32(5000), res.TotalCostCents)
+		noteRepo.AssertCalled(t, "Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == renterID && n.Attributes["type"] == "EARLY_RETURN_REQUEST" &&
+				n.Attributes["requested_end_date"] == earlyEnd &&
+				n.Attributes["requested_cost_cents"] == "3000"
+		}))
+	})
+
+	t.Run("Owner cannot push the return date later", func(t *testing.T) {
+		rt := activeRental()
+		svc, rentalRepo, _, _ := newSvc(rt)
+
+		later := time.Now().Add(96 * time.Hour).Format("2006-01-02")
+		_, err := svc.ChangeRentalDates(ctx, ownerID, rentalID, "", later, "", "")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+
+	t.Run("Owner cannot ask for a return date in the past", func(t *testing.T) {
+		rt := activeRental()
+		svc, rentalRepo, _, _ := newSvc(rt)
+
+		yesterday := time.Now().Add(-24 * time.Hour).Format("2006-01-02")
+		_, err := svc.ChangeRentalDates(ctx, ownerID, rentalID, "", yesterday, "", "")
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+
+	t.Run("A pending early return blocks further date changes", func(t *testing.T) {
+		rt := pendingEarlyReturn()
+		svc, _, _, _ := newSvc(rt)
+
+		later := time.Now().Add(96 * time.Hour).Format("2006-01-02")
+		_, err := svc.ChangeRentalDates(ctx, renterID, rentalID, "", later, "", "")
+		assert.ErrorIs(t, err, service.ErrExtensionPending)
+	})
+
+	t.Run("Renter accepts: the rental is repriced and the hold reduced", func(t *testing.T) {
+		rt := pendingEarlyReturn()
+		svc, _, ledgerRepo, noteRepo := newSvc(rt)
+
+		res, err := svc.AcceptEarlyReturn(ctx, renterID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+		assert.Equal(t, earlyEnd, res.EndDate)
+		require.NotNil(t, res.LastAgreedEndDate)
+		assert.Equal(t, earlyEnd, *res.LastAgreedEndDate)
+		assert.Nil(t, res.RequestedEndDate)
+		assert.Equal(t, int32(3000), res.TotalCostCents)
+		ledgerRepo.AssertCalled(t, "CreateTransaction", ctx, mock.MatchedBy(func(tx *domain.LedgerTransaction) bool {
+			return tx.Type == domain.TransactionTypeHoldRelease && tx.Amount == 2000 && tx.UserID == renterID
+		}))
+		noteRepo.AssertCalled(t, "Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == ownerID && n.Attributes["type"] == "EARLY_RETURN_ACCEPTED"
+		}))
+	})
+
+	t.Run("Renter declines: the agreed date and cost stay", func(t *testing.T) {
+		rt := pendingEarlyReturn()
+		svc, _, ledgerRepo, noteRepo := newSvc(rt)
+
+		res, err := svc.DeclineEarlyReturn(ctx, renterID, rentalID, "Still need it")
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+		assert.Equal(t, agreedEnd, res.EndDate)
+		assert.Nil(t, res.RequestedEndDate)
+		assert.Equal(t, int32(5000), res.TotalCostCents)
+		ledgerRepo.AssertNotCalled(t, "CreateTransaction", ctx, mock.Anything)
+		noteRepo.AssertCalled(t, "Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == ownerID && n.Attributes["type"] == "EARLY_RETURN_DECLINED"
+		}))
+	})
+
+	t.Run("Owner withdraws the request", func(t *testing.T) {
+		rt := pendingEarlyReturn()
+		svc, _, _, noteRepo := newSvc(rt)
+
+		res, err := svc.CancelEarlyReturn(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.RentalStatusActive, res.Status)
+		assert.Equal(t, agreedEnd, res.EndDate)
+		assert.Nil(t, res.RequestedEndDate)
+		noteRepo.AssertCalled(t, "Dispatch", ctx, mock.MatchedBy(func(n *domain.Notification) bool {
+			return n.UserID == renterID && n.Attributes["type"] == "EARLY_RETURN_CANCELLED"
+		}))
+	})
+
+	t.Run("Only the renter answers and only the owner withdraws", func(t *testing.T) {
+		rt := pendingEarlyReturn()
+		svc, rentalRepo, _, _ := newSvc(rt)
+
+		_, err := svc.AcceptEarlyReturn(ctx, ownerID, rentalID)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		_, err = svc.DeclineEarlyReturn(ctx, ownerID, rentalID, "")
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		_, err = svc.CancelEarlyReturn(ctx, renterID, rentalID)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+
+	t.Run("Answering without a pending request is rejected", func(t *testing.T) {
+		rt := activeRental()
+		svc, rentalRepo, _, _ := newSvc(rt)
+
+		_, err := svc.AcceptEarlyReturn(ctx, renterID, rentalID)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		_, err = svc.CancelEarlyReturn(ctx, ownerID, rentalID)
+		assert.ErrorIs(t, err, domain.ErrConflict)
+		rentalRepo.AssertNotCalled(t, "Update", ctx, rt)
+	})
+}
+
+func TestRentalService_GetBatchAvailability(t *testing.T) {
+	rentalRepo := new(MockRentalRepo)
+	toolRepo := new(MockToolRepo)
+	userRepo := new(MockUserRepo)
+	svc := service.NewRentalService(rentalRepo, toolRepo, new(MockLedgerRepo), userRepo, new(MockEmailService), new(MockNotificationRepo), nil)
+	ctx := context.Background()
+
+	// Caller 20 and owner 10 share org 1; owner 11 is only in org 2
+	userRepo.On("ListUserOrgs", ctx, int32(20)).Return([]domain.UserOrg{{UserID: 20, OrgID: 1, Status: domain.UserOrgStatusActive}}, nil)
+	userRepo.On("ListUserOrgs", ctx, int32(10)).Return([]domain.UserOrg{{UserID: 10, OrgID: 1, Status: domain.UserOrgStatusActive}}, nil)
+	userRepo.On("ListUserOrgs", ctx, int32(11)).Return([]domain.UserOrg{{UserID: 11, OrgID: 2, Status: domain.UserOrgStatusActive}}, nil)
+	for _, id := range []int32{1, 2, 3} {
+		toolRepo.On("GetByID", ctx, id).Return(&domain.Tool{ID: id, OwnerID: 10}, nil)
+	}
+	toolRepo.On("GetByID", ctx, int32(4)).Return(&domain.Tool{ID: 4, OwnerID: 11}, nil)
+
+	t.Run("Busy ranges grouped per tool", func(t *testing.T) {
+		toolIDs := []int32{1, 2, 3}
+		rentalRepo.On("ListBusyRanges", ctx, toolIDs, "2026-03-01", "2026-03-31").Return([]domain.ToolBusyRange{
+			{ToolID: 1, RentalID: 10, StartDate: "2026-03-02", EndDate: "2026-03-05", Status: domain.RentalStatusScheduled},
+			{ToolID: 1, RentalID: 11, StartDate: "2026-03-20", EndDate: "2026-04-02", Status: domain.RentalStatusScheduled},
+			{ToolID: 2, RentalID: 12, StartDate: "2026-02-25", EndDate: "2026-03-03", Status: domain.RentalStatusActive},
+		}, nil).Once()
+
+		availability, err := svc.GetBatchAvailability(ctx, 20, toolIDs, "2026-03-01", "2026-03-31")
+		require.NoError(t, err)
+		require.Len(t, availability, 3)
+
+		require.Len(t, availability[1], 2)
+		assert.Equal(t, "2026-03-02", availability[1][0].StartDate)
+		assert.Equal(t, "2026-03-05", availability[1][0].EndDate)
+		assert.Equal(t, int32(11), availability[1][1].RentalID)
+
+		require.Len(t, availability[2], 1)
+		assert.Equal(t, "2026-02-25", availability[2][0].StartDate)
+		assert.Equal(t, domain.RentalStatusActive, availability[2][0].Status)
+
+		// Tool 3 has no rentals in the window
+		assert.NotNil(t, availability[3])
+		assert.Empty(t, availability[3])
+		rentalRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tools from other orgs are left out", func(t *testing.T) {
+		rentalRepo.On("ListBusyRanges", ctx, []int32{1}, "2026-03-01", "2026-03-31").Return([]domain.ToolBusyRange{}, nil).Once()
+		// Tool 5 does not exist; it is dropped like a tool from another org
+		toolRepo.On("GetByID", ctx, int32(5)).Return(nil, sql.ErrNoRows).Once()
+
+		availability, err := svc.GetBatchAvailability(ctx, 20, []int32{1, 4, 5}, "2026-03-01", "2026-03-31")
+		require.NoError(t, err)
+		assert.Len(t, availability, 1)
+		assert.Contains(t, availability, int32(1))
+		assert.NotContains(t, availability, int32(4))
+		assert.NotContains(t, availability, int32(5))
+		rentalRepo.AssertExpectations(t)
+	})
+
+	t.Run("Nothing visible skips the lookup", func(t *testing.T) {
+		availability, err := svc.GetBatchAvailability(ctx, 20, []int32{4}, "2026-03-01", "2026-03-31")
+		require.NoError(t, err)
+		assert.Empty(t, availability)
+		rentalRepo.AssertNotCalled(t, "ListBusyRanges", ctx, []int32{4}, "2026-03-01", "2026-03-31")
+	})
+
+	t.Run("Invalid window", func(t *testing.T) {
+		_, err := svc.GetBatchAvailability(ctx, 20, []int32{1}, "2026-03-31", "2026-03-01")
+		assert.Error(t, err)
+
+		_, err = svc.GetBatchAvailability(ctx, 20, []int32{1}, "03/01/2026", "2026-03-31")
+		assert.Error(t, err)
+
+		_, err = svc.GetBatchAvailability(ctx, 20, nil, "2026-03-01", "2026-03-31")
+		assert.Error(t, err)
+	})
+}
+
+func TestRental_AwaitsActionFrom(t *testing.T) {
+	ownerID, renterID, otherID := int32(10), int32(20), int32(30)
+
+	// Every status, with who is expected to act next
+	cases := []struct {
+		status        domain.RentalStatus
+		owner, renter bool
+	}{
+		{domain.RentalStatusPending, true, false},  // owner approves or rejects
+		{domain.RentalStatusApproved, false, true}, // renter finalizes
+		{domain.RentalStatusRejected, false, false},
+		{domain.RentalStatusScheduled, false, false},
+		{domain.RentalStatusActive, false, false},
+		{domain.RentalStatusOverdue, true, true},                   // renter brings it back, owner confirms the return
+		{domain.RentalStatusReturnDateChanged, true, false},        // owner answers the extension
+		{domain.RentalStatusReturnDateChangeRejected, false, true}, // renter acknowledges the counter-proposal
+		{domain.RentalStatusEarlyReturnRequested, false, true},     // renter answers the early return
+		{domain.RentalStatusCompleted, false, false},
+		{domain.RentalStatusCancelled, false, false},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.status), func(t *testing.T) {
+			rt := &domain.Rental{OwnerID: ownerID, RenterID: renterID, Status: tc.status}
+			assert.Equal(t, tc.owner, rt.AwaitsActionFrom(ownerID), "owner")
+			assert.Equal(t, tc.renter, rt.AwaitsActionFrom(renterID), "renter")
+			assert.False(t, rt.AwaitsActionFrom(otherID), "non-participant")
+		})
+	}
+}
+
+func TestRentalService_ListActionableRentals(t *testing.T) {
+	ctx := context.Background()
+	rentalRepo := new(MockRentalRepo)
+	svc := service.NewRentalService(rentalRepo, nil, nil, nil, nil, nil, nil)
+
+	rentals := []domain.Rental{{ID: 1, Status: domain.RentalStatusPending}, {ID: 2, Status: domain.RentalStatusApproved}}
+	rentalRepo.On("ListActionable", ctx, int32(7), int32(3)).Return(rentals, nil)
+
+	res, err := svc.ListActionableRentals(ctx, 7, 3)
+	require.NoError(t, err)
+	assert.Equal(t, rentals, res)
+}
+
+func TestRentalService_GetRentalReceipt(t *testing.T) {
+	ctx := context.Background()
+	ownerID, renterID, adminID, strangerID := int32(10), int32(20), int32(30), int32(40)
+	rentalID, toolID, orgID := int32(100), int32(200), int32(3)
+
+	completedRental := func() *domain.Rental {
+		return &domain.Rental{
+			ID: rentalID, RenterID: renterID, OwnerID: ownerID, ToolID: toolID, OrgID: orgID,
+			Status:                 domain.RentalStatusCompleted,
+			StartDate:              "2026-03-02",
+			EndDate:                "2026-03-12",
+			DurationUnit:           string(domain.ToolDurationUnitDay),
+			DailyPriceCents:        1000,
+			WeeklyPriceCents:       6000,
+			MonthlyPriceCents:      20000,
+			TotalCostCents:         9000, // 1 week + 3 days
+			SurchargeOrCreditCents: 500,
+			ChargeBillsplit:        true,
+			ReturnCondition:        "Blade dull",
+			UpdatedOn:              "2026-03-12",
+		}
+	}
+	newSvc := func(rt *domain.Rental) (service.RentalService, *MockUserRepo) {
+		rentalRepo := new(MockRentalRepo)
+		toolRepo := new(MockToolRepo)
+		userRepo := new(MockUserRepo)
+		rentalRepo.On("GetByID", ctx, rentalID).Return(rt, nil)
+		toolRepo.On("GetByID", ctx, toolID).Return(&domain.Tool{ID: toolID, Name: "Table Saw"}, nil)
+		userRepo.On("GetByID", ctx, ownerID).Return(&domain.User{ID: ownerID, Name: "Olivia"}, nil)
+		userRepo.On("GetByID", ctx, renterID).Return(&domain.User{ID: renterID, Name: "Ravi"}, nil)
+		userRepo.On("GetUserOrg", ctx, renterID, orgID).Return(&domain.UserOrg{UserID: renterID, OrgID: orgID, CurrencyCode: "CAD"}, nil)
+		svc := service.NewRentalService(rentalRepo, toolRepo, nil, userRepo, nil, nil, nil)
+		return svc, userRepo
+	}
+
+	t.Run("Receipt matches the completed rental", func(t *testing.T) {
+		svc, _ := newSvc(completedRental())
+
+		receipt, err := svc.GetRentalReceipt(ctx, renterID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, rentalID, receipt.RentalID)
+		assert.Equal(t, orgID, receipt.OrgID)
+		assert.Equal(t, "Table Saw", receipt.ToolName)
+		assert.Equal(t, "Olivia", receipt.OwnerName)
+		assert.Equal(t, "Ravi", receipt.RenterName)
+		assert.Equal(t, "2026-03-02", receipt.StartDate)
+		assert.Equal(t, "2026-03-12", receipt.EndDate)
+		assert.Equal(t, "2026-03-12", receipt.CompletedOn)
+		assert.Equal(t, []domain.RentalReceiptLine{
+			{Unit: domain.ToolDurationUnitWeek, Quantity: 1, UnitPriceCents: 6000, AmountCents: 6000},
+			{Unit: domain.ToolDurationUnitDay, Quantity: 3, UnitPriceCents: 1000, AmountCents: 3000},
+		}, receipt.Lines)
+		assert.Zero(t, receipt.AdjustmentCents)
+		assert.Equal(t, int32(9000), receipt.RentalCostCents)
+		assert.Equal(t, int32(500), receipt.SurchargeOrCreditCents)
+		assert.Equal(t, int32(9500), receipt.TotalCents)
+		assert.True(t, receipt.ChargeBillsplit)
+		assert.Equal(t, "Blade dull", receipt.ReturnCondition)
+		assert.Equal(t, "CAD", receipt.CurrencyCode)
+	})
+
+	t.Run("Lines reconcile with a capped cost", func(t *testing.T) {
+		rt := completedRental()
+		rt.TotalCostCents = 8500
+		svc, _ := newSvc(rt)
+
+		receipt, err := svc.GetRentalReceipt(ctx, ownerID, rentalID)
+		require.NoError(t, err)
+		assert.Equal(t, int32(-500), receipt.AdjustmentCents)
+		assert.Equal(t, int32(9000), receipt.TotalCents)
+	})
+
+	t.Run("Org admins may read it", func(t *testing.T) {
+		svc, userRepo := newSvc(completedRental())
+		userRepo.On("GetUserOrg", ctx, adminID, orgID).Return(&domain.UserOrg{UserID: adminID, OrgID: orgID, Role: domain.UserOrgRoleAdmin}, nil)
+
+		_, err := svc.GetRentalReceipt(ctx, adminID, rentalID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Other members may not", func(t *testing.T) {
+		svc, userRepo := newSvc(completedRental())
+		userRepo.On("GetUserOrg", ctx, strangerID, orgID).Return(&domain.UserOrg{UserID: strangerID, OrgID: orgID, Role: domain.UserOrgRoleMember}, nil)
+
+		_, err := svc.GetRentalReceipt(ctx, strangerID, rentalID)
+		assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	})
+
+	t.Run("Only completed rentals have a receipt", func(t *testing.T) {
+		rt := completedRental()
+		rt.Status = domain.RentalStatusActive
+		svc, _ := newSvc(rt)
+
+		_, err := svc.GetRentalReceipt(ctx, renterID, rentalID)
+		assert.ErrorIs(t, err, service.ErrReceiptNotAvailable)
+	})
+}
@@ -0,0 +1,79 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuditRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewAdminAuditRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Create stores snapshots", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO admin_audit \(org_id, admin_id, action, target_type, target_id, details, before, after\)`).
+			WithArgs(int32(1), int32(2), domain.AdminAuditActionBlockMember, domain.AdminAuditTargetUser, int32(5),
+				[]byte(`{"reason":"late"}`), []byte(`{"status":"ACTIVE"}`), []byte(`{"status":"BLOCK"}`)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(11, now))
+
+		entry := &domain.AdminAuditEntry{
+			OrgID: 1, AdminID: 2, Action: domain.AdminAuditActionBlockMember,
+			TargetType: domain.AdminAuditTargetUser, TargetID: 5,
+			Details: map[string]string{"reason": "late"},
+			Before:  map[string]string{"status": "ACTIVE"},
+			After:   map[string]string{"status": "BLOCK"},
+		}
+		assert.NoError(t, repo.Create(ctx, entry))
+		assert.Equal(t, int32(11), entry.ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Create without snapshots writes NULL", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO admin_audit`).
+			WithArgs(int32(1), int32(2), domain.AdminAuditActionSendInvitation, domain.AdminAuditTargetInvitation, int32(6),
+				[]byte(`{"email":"a@test.com"}`), []byte(nil), []byte(nil)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(12, now))
+
+		entry := &domain.AdminAuditEntry{
+			OrgID: 1, AdminID: 2, Action: domain.AdminAuditActionSendInvitation,
+			TargetType: domain.AdminAuditTargetInvitation, TargetID: 6,
+			Details: map[string]string{"email": "a@test.com"},
+		}
+		assert.NoError(t, repo.Create(ctx, entry))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List decodes snapshots", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(SELECT .* FROM admin_audit WHERE org_id = \$1 AND action = \$2\) as sub`).
+			WithArgs(int32(1), "RESOLVE_DISPUTE").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT id, org_id, admin_id, action, target_type, target_id, details, before, after, created_at\s+FROM admin_audit WHERE org_id = \$1 AND action = \$2 ORDER BY created_at DESC, id DESC LIMIT \$3 OFFSET \$4`).
+			WithArgs(int32(1), "RESOLVE_DISPUTE", int32(20), int32(0)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "org_id", "admin_id", "action", "target_type", "target_id", "details", "before", "after", "created_at"}).
+				AddRow(3, 1, 2, "RESOLVE_DISPUTE", "BILL", 9, []byte(`{"resolution":"GRACEFUL"}`),
+					[]byte(`{"status":"DISPUTED"}`), []byte(`{"status":"ADMIN_RESOLVED"}`), now).
+				AddRow(4, 1, 2, "RESOLVE_DISPUTE", "BILL", 8, []byte(`{}`), nil, nil, now))
+
+		entries, total, err := repo.List(ctx, 1, domain.AdminAuditFilter{Action: domain.AdminAuditActionResolveDispute, Page: 1, PageSize: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, "DISPUTED", entries[0].Before["status"])
+		assert.Equal(t, "ADMIN_RESOLVED", entries[0].After["status"])
+		assert.Nil(t, entries[1].Before)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,221 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"ubertool-backend-trusted/internal/repository"
+)
+
+var billColumns = []string{"id", "org_id", "debtor_user_id", "creditor_user_id", "amount_cents", "settlement_month",
+	"status", "notice_sent_at", "debtor_acknowledged_at", "creditor_acknowledged_at",
+	"disputed_at", "resolved_at", "dispute_reason", "resolution_outcome", "resolution_notes",
+	"void_requested_by", "void_requested_at", "version", "created_at", "updated_at"}
+
+func TestBillRepository_ListByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+	statuses := []domain.BillStatus{domain.BillStatusPending, domain.BillStatusDisputed}
+	statusArg := pq.Array([]string{"PENDING", "DISPUTED"})
+
+	t.Run("Second page applies limit and offset", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(`).
+			WithArgs(int32(1), int32(2), statusArg).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectQuery(`ORDER BY notice_sent_at DESC, created_at DESC, id DESC LIMIT \$4 OFFSET \$5`).
+			WithArgs(int32(1), int32(2), statusArg, int32(2), int32(2)).
+			WillReturnRows(sqlmock.NewRows(billColumns).
+				AddRow(3, 2, 1, 4, 1000, "2026-01", "PENDING", now, nil, nil, nil, nil, "", "", "", nil, nil, 1, now, now).
+				AddRow(2, 2, 4, 1, 500, "2026-01", "DISPUTED", now, nil, nil, now, nil, "", "", "", nil, nil, 1, now, now))
+
+		bills, total, err := repo.ListByUser(ctx, 1, 2, statuses, 2, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		assert.Len(t, bills, 2)
+		assert.Equal(t, int32(3), bills[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Page past the end returns total with no rows", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(`).
+			WithArgs(int32(1), int32(2), statusArg).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectQuery(`LIMIT \$4 OFFSET \$5`).
+			WithArgs(int32(1), int32(2), statusArg, int32(2), int32(6)).
+			WillReturnRows(sqlmock.NewRows(billColumns))
+
+		bills, total, err := repo.ListByUser(ctx, 1, 2, statuses, 4, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		assert.Empty(t, bills)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Zero page size returns every bill", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM \(`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`ORDER BY notice_sent_at DESC, created_at DESC, id DESC$`).
+			WithArgs(int32(1), int32(2)).
+			WillReturnRows(sqlmock.NewRows(billColumns).
+				AddRow(1, 2, 1, 4, 1000, "2026-01", "PAID", now, now, now, nil, nil, "", "", "", nil, nil, 1, now, now))
+
+		bills, total, err := repo.ListByUser(ctx, 1, 2, nil, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, bills, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBillRepository_Update_Version(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+
+	t.Run("Matching version increments", func(t *testing.T) {
+		bill := &domain.Bill{ID: 7, Status: domain.BillStatusPaid, Version: 3}
+		mock.ExpectQuery(`UPDATE bills SET (.+) version = version \+ 1 WHERE id = \$13 AND version = \$14`).
+			WithArgs(bill.Status, nil, nil, nil, nil, nil, "", "", "", nil, nil, sqlmock.AnyArg(), int32(7), int32(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}).AddRow(4, time.Now()))
+
+		err := repo.Update(ctx, bill)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), bill.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stale version is rejected", func(t *testing.T) {
+		bill := &domain.Bill{ID: 7, Status: domain.BillStatusAdminResolved, Version: 3}
+		mock.ExpectQuery(`UPDATE bills SET`).
+			WithArgs(bill.Status, nil, nil, nil, nil, nil, "", "", "", nil, nil, sqlmock.AnyArg(), int32(7), int32(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "updated_at"}))
+
+		err := repo.Update(ctx, bill)
+		assert.ErrorIs(t, err, repository.ErrBillVersionConflict)
+		assert.Equal(t, int32(3), bill.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBillRepository_ListActionsByBill(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+	actionColumns := []string{"id", "bill_id", "actor_user_id", "action_type", "action_details", "notes", "created_at"}
+
+	t.Run("Pages oldest first", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM bill_actions WHERE bill_id = \$1$`).
+			WithArgs(int32(7)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectQuery(`FROM bill_actions WHERE bill_id = \$1\s+ORDER BY created_at ASC, id ASC\s+LIMIT \$2 OFFSET \$3`).
+			WithArgs(int32(7), int32(2), int32(2)).
+			WillReturnRows(sqlmock.NewRows(actionColumns).
+				AddRow(3, 7, nil, "ADMIN_COMMENT", "", "first", now).
+				AddRow(4, 7, nil, "ADMIN_COMMENT", "", "second", now))
+
+		actions, total, err := repo.ListActionsByBill(ctx, 7, domain.BillActionFilter{Page: 2, PageSize: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), total)
+		assert.Equal(t, []int32{3, 4}, []int32{actions[0].ID, actions[1].ID})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filters by action type", func(t *testing.T) {
+		types := pq.Array([]string{"DISPUTE_OPENED", "ADMIN_RESOLUTION"})
+		mock.ExpectQuery(`SELECT count\(\*\) FROM bill_actions WHERE bill_id = \$1 AND action_type = ANY\(\$2\)`).
+			WithArgs(int32(7), types).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(`AND action_type = ANY\(\$2\)\s+ORDER BY created_at ASC, id ASC\s+LIMIT \$3 OFFSET \$4`).
+			WithArgs(int32(7), types, int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(actionColumns))
+
+		actions, total, err := repo.ListActionsByBill(ctx, 7, domain.BillActionFilter{
+			ActionTypes: []domain.BillActionType{domain.BillActionTypeDisputeOpened, domain.BillActionTypeAdminResolution},
+		})
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, actions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBillRepository_CreateAction_Details(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewBillRepository(db)
+	ctx := context.Background()
+	insert := `INSERT INTO bill_actions`
+
+	t.Run("Valid JSON is stored compacted", func(t *testing.T) {
+		mock.ExpectQuery(insert).
+			WithArgs(int32(7), nil, domain.BillActionTypeDisputeOpened, `{"reason":"DEBTOR_NO_ACK","grace_days":7}`, nil, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
+
+		action := &domain.BillAction{BillID: 7, ActionType: domain.BillActionTypeDisputeOpened,
+			ActionDetails: "{\n  \"reason\": \"DEBTOR_NO_ACK\",\n  \"grace_days\": 7\n}"}
+		assert.NoError(t, repo.CreateAction(ctx, action))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Empty details are stored as NULL", func(t *testing.T) {
+		mock.ExpectQuery(insert).
+			WithArgs(int32(7), nil, domain.BillActionTypeAdminComment, nil, "Looking into it", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, time.Now()))
+
+		action := &domain.BillAction{BillID: 7, ActionType: domain.BillActionTypeAdminComment, ActionDetails: "  ", Notes: "Looking into it"}
+		assert.NoError(t, repo.CreateAction(ctx, action))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Invalid JSON is rejected before the insert", func(t *testing.T) {
+		action := &domain.BillAction{BillID: 7, ActionType: domain.BillActionTypeAdminComment, ActionDetails: `{"reason": DEBTOR_NO_ACK}`}
+		err := repo.CreateAction(ctx, action)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing details read back as an empty object", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM bill_actions`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`COALESCE\(action_details::text, '\{\}'\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "bill_id", "actor_user_id", "action_type", "action_details", "notes", "created_at"}).
+				AddRow(2, 7, nil, "ADMIN_COMMENT", "{}", "Looking into it", time.Now()))
+
+		actions, _, err := repo.ListActionsByBill(ctx, 7, domain.BillActionFilter{})
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{}`, actions[0].ActionDetails)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,86 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailOutboxRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewEmailOutboxRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Create", func(t *testing.T) {
+		entry := &domain.EmailOutboxEntry{Recipients: []string{"a@example.com"}, Subject: "Hi", Body: "Body"}
+		mock.ExpectQuery(`INSERT INTO email_outbox`).
+			WithArgs(pq.Array([]string{"a@example.com"}), pq.Array([]string(nil)), "Hi", "Body", false, "", domain.EmailOutboxStatusPending).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "next_attempt_at", "created_at"}).AddRow(5, now, now))
+
+		assert.NoError(t, repo.Create(ctx, entry))
+		assert.Equal(t, int64(5), entry.ID)
+		assert.Equal(t, domain.EmailOutboxStatusPending, entry.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Claim only takes pending entries", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE email_outbox SET status = 'SENDING', attempts = attempts \+ 1, updated_at = NOW\(\)\s+WHERE id = \$1 AND status = 'PENDING'`).
+			WithArgs(int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE email_outbox SET status = 'SENDING'`).
+			WithArgs(int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		claimed, err := repo.Claim(ctx, 5)
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = repo.Claim(ctx, 5)
+		assert.NoError(t, err)
+		assert.False(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimRetryable", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE email_outbox SET status = 'SENDING'.*WHERE attempts < \$1.*FOR UPDATE SKIP LOCKED`).
+			WithArgs(int32(6), int64(600), int32(100)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "recipients", "cc", "subject", "body", "is_html", "html_body", "status", "attempts", "last_error", "next_attempt_at", "created_at"}).
+				AddRow(7, "{a@example.com,b@example.com}", "{}", "Hi", "Body", false, "<p>Body</p>", "SENDING", 3, "451 busy", now, now))
+
+		entries, err := repo.ClaimRetryable(ctx, 6, 10*time.Minute, 100)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, []string{"a@example.com", "b@example.com"}, entries[0].Recipients)
+		assert.Equal(t, int32(3), entries[0].Attempts)
+		assert.Equal(t, "451 busy", entries[0].LastError)
+		assert.Equal(t, "<p>Body</p>", entries[0].HTMLBody)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkSent and MarkFailed", func(t *testing.T) {
+		next := now.Add(time.Minute)
+		mock.ExpectExec(`UPDATE email_outbox SET status = 'SENT'`).
+			WithArgs(int64(7)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE email_outbox SET status = 'FAILED', last_error = \$2, next_attempt_at = \$3`).
+			WithArgs(int64(8), "421 busy", next).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.MarkSent(ctx, 7))
+		assert.NoError(t, repo.MarkFailed(ctx, 8, "421 busy", next))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
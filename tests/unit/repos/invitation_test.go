@@ -0,0 +1,44 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvitationRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewInvitationRepository(db)
+	ctx := context.Background()
+
+	t.Run("ListOutstandingEmails matches case-insensitively", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT DISTINCT LOWER\(email\) FROM invitations\s+WHERE org_id = \$1 AND LOWER\(email\) = ANY\(\$2\)\s+AND used_on IS NULL AND revoked_on IS NULL AND expires_on >= CURRENT_DATE`).
+			WithArgs(int32(10), pq.Array([]string{"a@test.com", "b@test.com"})).
+			WillReturnRows(sqlmock.NewRows([]string{"email"}).AddRow("b@test.com"))
+
+		emails, err := repo.ListOutstandingEmails(ctx, 10, []string{"A@test.com", "b@test.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b@test.com"}, emails)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoke of a used or revoked invitation is ErrNoRows", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE invitations SET revoked_on = CURRENT_DATE WHERE id = \$1 AND used_on IS NULL AND revoked_on IS NULL`).
+			WithArgs(int32(5)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.ErrorIs(t, repo.Revoke(ctx, 5), sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,60 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobRunRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewJobRunRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Start", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO job_runs \(job_name, status\) VALUES \(\$1, \$2\) RETURNING id, started_at`).
+			WithArgs("ReconcileBalances", domain.JobRunStatusRunning).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "started_at"}).AddRow(4, now))
+
+		run, err := repo.Start(ctx, "ReconcileBalances")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), run.ID)
+		assert.Equal(t, domain.JobRunStatusRunning, run.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Finish", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE job_runs SET status = \$2, error = NULLIF\(\$3, ''\), finished_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(int64(4), domain.JobRunStatusFailed, "boom").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.Finish(ctx, 4, domain.JobRunStatusFailed, "boom"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("List applies filters newest first", func(t *testing.T) {
+		mock.ExpectQuery(`FROM job_runs WHERE 1=1 AND job_name = \$1 AND status = \$2 ORDER BY started_at DESC, id DESC LIMIT \$3`).
+			WithArgs("PerformBillSplitting", "FAILED", int32(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "job_name", "status", "error", "started_at", "finished_at"}).
+				AddRow(9, "PerformBillSplitting", "FAILED", "failed to get organizations", now, now))
+
+		runs, err := repo.List(ctx, domain.JobRunFilter{JobName: "PerformBillSplitting", Status: domain.JobRunStatusFailed, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, runs, 1)
+		assert.Equal(t, "failed to get organizations", runs[0].Error)
+		assert.NotNil(t, runs[0].FinishedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
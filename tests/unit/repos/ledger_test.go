@@ -3,6 +3,7 @@ package repos
 import (
 	"context"
 	"testing"
+	"time"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository/postgres"
@@ -59,3 +60,182 @@ func TestLedgerRepository_GetBalance(t *testing.T) {
 		assert.Equal(t, int32(1000), balance)
 	})
 }
+
+func TestLedgerRepository_GetSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewLedgerRepository(db)
+	ctx := context.Background()
+
+	t.Run("Balance comes from the ledger and drift is flagged", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COALESCE\\(uo.balance_cents, 0\\),\\s+COALESCE\\(\\(SELECT SUM\\(lt.amount\\) FROM ledger_transactions lt\\s+WHERE .* AND lt.type NOT IN \\('HOLD', 'HOLD_RELEASE'\\)").
+			WithArgs(int32(1), int32(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance_cents", "ledger_cents", "held_cents"}).AddRow(1500, 1200, 700))
+		mock.ExpectQuery("status = 'ACTIVE'").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("status = 'ACTIVE'").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("status = 'PENDING'").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("GROUP BY status").
+			WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow("ACTIVE", 1).AddRow("PENDING", 2))
+
+		summary, err := repo.GetSummary(ctx, 1, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1200), summary.Balance)
+		assert.Equal(t, int32(1500), summary.StoredBalance)
+		assert.True(t, summary.BalanceMismatch)
+		assert.Equal(t, int32(700), summary.HeldBalance)
+		assert.Equal(t, int32(500), summary.AvailableBalance)
+		assert.Equal(t, int32(2), summary.StatusCount["PENDING"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestLedgerRepository_GetRentalHold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewLedgerRepository(db)
+
+	mock.ExpectQuery("SELECT COALESCE\\(-SUM\\(amount\\), 0\\) FROM ledger_transactions\\s+WHERE related_rental_id = \\$1 AND type IN \\('HOLD', 'HOLD_RELEASE'\\)").
+		WithArgs(int32(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"held"}).AddRow(2000))
+
+	held, err := repo.GetRentalHold(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2000), held)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepository_GetBalanceHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewLedgerRepository(db)
+	ctx := context.Background()
+
+	t.Run("Date range", func(t *testing.T) {
+		d1, _ := time.Parse("2006-01-02", "2026-01-31")
+		d2, _ := time.Parse("2006-01-02", "2026-02-28")
+		mock.ExpectQuery("SELECT user_id, org_id, balance_cents, settlement_month, snapshot_date FROM balance_snapshots WHERE user_id = \\$1 AND org_id = \\$2 AND snapshot_date >= \\$3 AND snapshot_date <= \\$4 ORDER BY snapshot_date ASC").
+			WithArgs(int32(1), int32(2), "2026-01-01", "2026-03-01").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "org_id", "balance_cents", "settlement_month", "snapshot_date"}).
+				AddRow(1, 2, 500, "2026-01", d1).
+				AddRow(1, 2, -250, "2026-02", d2))
+
+		history, err := repo.GetBalanceHistory(ctx, 1, 2, "2026-01-01", "2026-03-01")
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.Equal(t, "2026-01-31", history[0].SnapshotDate)
+		assert.Equal(t, int32(500), history[0].BalanceCents)
+		assert.Equal(t, "2026-02-28", history[1].SnapshotDate)
+		assert.Equal(t, int32(-250), history[1].BalanceCents)
+	})
+
+	t.Run("Unbounded", func(t *testing.T) {
+		mock.ExpectQuery("SELECT user_id, org_id, balance_cents, settlement_month, snapshot_date FROM balance_snapshots WHERE user_id = \\$1 AND org_id = \\$2 ORDER BY snapshot_date ASC").
+			WithArgs(int32(1), int32(2)).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "org_id", "balance_cents", "settlement_month", "snapshot_date"}))
+
+		history, err := repo.GetBalanceHistory(ctx, 1, 2, "", "")
+		assert.NoError(t, err)
+		assert.Empty(t, history)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepository_StreamTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewLedgerRepository(db)
+	ctx := context.Background()
+	cols := []string{"id", "org_id", "user_id", "amount", "type", "related_rental_id", "description", "charged_on", "created_on"}
+	d1, _ := time.Parse("2006-01-02", "2026-03-02")
+	d2, _ := time.Parse("2006-01-02", "2026-03-31")
+
+	t.Run("Date range in charge order", func(t *testing.T) {
+		mock.ExpectQuery("FROM ledger_transactions WHERE user_id = \\$1 AND org_id = \\$2 AND charged_on >= \\$3 AND charged_on <= \\$4 ORDER BY charged_on, id").
+			WithArgs(int32(1), int32(2), "2026-03-01", "2026-03-31").
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow(5, 2, 1, -1500, "RENTAL_DEBIT", 42, "Rental", d1, d1).
+				AddRow(6, 2, 1, 200, "ADJUSTMENT", nil, "", d2, d2))
+
+		var got []domain.LedgerTransaction
+		err := repo.StreamTransactions(ctx, 1, 2, "2026-03-01", "2026-03-31", func(tx domain.LedgerTransaction) error {
+			got = append(got, tx)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got, 2)
+		assert.Equal(t, "2026-03-02", got[0].ChargedOn)
+		assert.Equal(t, int32(42), *got[0].RelatedRentalID)
+		assert.Nil(t, got[1].RelatedRentalID)
+	})
+
+	t.Run("Callback error stops the scan", func(t *testing.T) {
+		mock.ExpectQuery("FROM ledger_transactions WHERE user_id = \\$1 AND org_id = \\$2 ORDER BY charged_on, id").
+			WithArgs(int32(1), int32(2)).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow(5, 2, 1, -1500, "RENTAL_DEBIT", 42, "Rental", d1, d1).
+				AddRow(6, 2, 1, 200, "ADJUSTMENT", nil, "", d2, d2))
+
+		calls := 0
+		err := repo.StreamTransactions(ctx, 1, 2, "", "", func(tx domain.LedgerTransaction) error {
+			calls++
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, calls)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepository_ListTransactions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewLedgerRepository(db)
+	ctx := context.Background()
+
+	t.Run("Scans related rental", func(t *testing.T) {
+		day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		rows := sqlmock.NewRows([]string{"id", "org_id", "user_id", "amount", "type", "related_rental_id", "description", "charged_on", "created_on"}).
+			AddRow(1, 1, 2, -500, "RENTAL_DEBIT", 10, "Rental", day, day).
+			AddRow(2, 1, 2, 300, "SETTLEMENT", nil, "Settlement", day, day)
+
+		mock.ExpectQuery("SELECT id, org_id, user_id, amount, type, related_rental_id").
+			WithArgs(int32(2), int32(1), int32(10), int32(0)).
+			WillReturnRows(rows)
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM ledger_transactions").
+			WithArgs(int32(2), int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		txs, count, err := repo.ListTransactions(ctx, 2, 1, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), count)
+		assert.Len(t, txs, 2)
+		if assert.NotNil(t, txs[0].RelatedRentalID) {
+			assert.Equal(t, int32(10), *txs[0].RelatedRentalID)
+		}
+		assert.Nil(t, txs[1].RelatedRentalID)
+		assert.Equal(t, "2026-03-01", txs[0].ChargedOn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,68 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationDeadLetterRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewNotificationDeadLetterRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("Create counts the original dispatch as the first attempt", func(t *testing.T) {
+		entry := &domain.NotificationDeadLetter{
+			Notification:  domain.Notification{UserID: 7, OrgID: 3, Title: "Rental Approved", Message: "Drill", Attributes: map[string]string{"type": "RENTAL"}},
+			LastError:     "connection reset",
+			NextAttemptAt: now,
+		}
+		mock.ExpectQuery(`INSERT INTO notification_dead_letters`).
+			WithArgs(int32(7), int32(3), "Rental Approved", "Drill", []byte(`{"type":"RENTAL"}`), false,
+				domain.NotificationDeadLetterStatusFailed, int32(1), "connection reset", now).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, now))
+
+		assert.NoError(t, repo.Create(ctx, entry))
+		assert.Equal(t, int64(9), entry.ID)
+		assert.Equal(t, int32(1), entry.Attempts)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ClaimRetryable decodes the notification", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE notification_dead_letters SET status = 'RETRYING'.*WHERE attempts < \$1.*FOR UPDATE SKIP LOCKED`).
+			WithArgs(int32(6), int64(600), int32(200)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "org_id", "title", "message", "attributes", "silent", "status", "attempts", "last_error", "next_attempt_at", "created_at"}).
+				AddRow(9, 7, 3, "Rental Approved", "Drill", []byte(`{"type":"RENTAL"}`), true, "RETRYING", 2, "connection reset", now, now))
+
+		entries, err := repo.ClaimRetryable(ctx, 6, 10*time.Minute, 200)
+		assert.NoError(t, err)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, int32(7), entries[0].Notification.UserID)
+			assert.Equal(t, "RENTAL", entries[0].Notification.Attributes["type"])
+			assert.True(t, entries[0].Silent)
+			assert.Equal(t, int32(2), entries[0].Attempts)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MarkDelivered links the re-created notification", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE notification_dead_letters SET status = 'DELIVERED', notification_id = \$2`).
+			WithArgs(int64(9), int64(55)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.MarkDelivered(ctx, 9, 55))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,153 @@
+package repos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRepository_MarkAllRead(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewNotificationRepository(db)
+	ctx := context.Background()
+
+	t.Run("All orgs", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE notifications SET read_at = NOW\(\) WHERE user_id = \$1 AND read_at IS NULL$`).
+			WithArgs(int32(1)).
+			WillReturnResult(sqlmock.NewResult(0, 12))
+
+		count, err := repo.MarkAllRead(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(12), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Scoped to org", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE notifications SET read_at = NOW\(\) WHERE user_id = \$1 AND read_at IS NULL AND org_id = \$2`).
+			WithArgs(int32(1), int32(3)).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+
+		count, err := repo.MarkAllRead(ctx, 1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(4), count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nothing unread", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE notifications SET read_at`).
+			WithArgs(int32(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		count, err := repo.MarkAllRead(ctx, 2, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), count)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE notifications SET read_at`).
+			WithArgs(int32(1)).
+			WillReturnError(assert.AnError)
+
+		_, err := repo.MarkAllRead(ctx, 1, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestNotificationRepository_List(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewNotificationRepository(db)
+	ctx := context.Background()
+	columns := []string{"id", "user_id", "org_id", "title", "message", "delivered_at", "clicked_at", "read_at", "attributes", "created_at"}
+
+	t.Run("Unfiltered", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM notifications WHERE user_id = \$1 ORDER BY created_at DESC LIMIT \$2 OFFSET \$3`).
+			WithArgs(int32(1), int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, 1, 3, "New Rental Request", "msg", nil, nil, nil, []byte(`{"type":"RENTAL_REQUEST"}`), time.Now()).
+				AddRow(2, 1, 3, "Payment Acknowledged", "msg", nil, nil, time.Now(), []byte(`{"topic":"bill_payment_acknowledged"}`), time.Now()))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM notifications WHERE user_id = \$1$`).
+			WithArgs(int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+		notes, count, err := repo.List(ctx, 1, "", 10, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), count)
+		assert.Len(t, notes, 2)
+		assert.Nil(t, notes[0].ReadAt)
+		assert.NotNil(t, notes[1].ReadAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filtered by type or topic", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT (.+) FROM notifications WHERE user_id = \$1 AND \(attributes @> jsonb_build_object\('type', \$2::text\) OR attributes @> jsonb_build_object\('topic', \$2::text\)\) ORDER BY created_at DESC LIMIT \$3 OFFSET \$4`).
+			WithArgs(int32(1), "bill_payment_acknowledged", int32(5), int32(5)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(2, 1, 3, "Payment Acknowledged", "msg", nil, nil, nil, []byte(`{"topic":"bill_payment_acknowledged"}`), time.Now()))
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM notifications WHERE user_id = \$1 AND \(attributes @> (.+)\)`).
+			WithArgs(int32(1), "bill_payment_acknowledged").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+		notes, count, err := repo.List(ctx, 1, "bill_payment_acknowledged", 5, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(6), count)
+		assert.Len(t, notes, 1)
+		assert.Equal(t, "bill_payment_acknowledged", notes[0].Attributes["topic"])
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNotificationRepository_CountUnread(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewNotificationRepository(db)
+	ctx := context.Background()
+
+	t.Run("All orgs", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM notifications WHERE user_id = \$1 AND read_at IS NULL$`).
+			WithArgs(int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(17))
+
+		count, err := repo.CountUnread(ctx, 1, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(17), count)
+	})
+
+	t.Run("Scoped to org", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM notifications WHERE user_id = \$1 AND read_at IS NULL AND org_id = \$2`).
+			WithArgs(int32(1), int32(3)).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+
+		count, err := repo.CountUnread(ctx, 1, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(5), count)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT COUNT`).
+			WithArgs(int32(1)).
+			WillReturnError(assert.AnError)
+
+		_, err := repo.CountUnread(ctx, 1, 0)
+		assert.Error(t, err)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,10 +3,12 @@ package repos
 import (
 	"context"
 	"testing"
+	"time"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository/postgres"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,13 +31,130 @@ func TestOrganizationRepository_Update(t *testing.T) {
 			AdminPhoneNumber:            "123",
 			SettlementThresholdCents:    500,
 			MaxBillsplitRentalCostCents: 1000,
+			AutoActivateRentals:         true,
 		}
 
 		mock.ExpectExec("UPDATE orgs SET").
-			WithArgs(org.Name, org.Description, org.Address, org.Metro, org.AdminPhoneNumber, org.AdminEmail, org.SettlementThresholdCents, org.MaxBillsplitRentalCostCents, org.ID).
+			WithArgs(org.Name, org.Description, org.Address, org.Metro, org.AdminPhoneNumber, org.AdminEmail, org.SettlementThresholdCents, org.MaxBillsplitRentalCostCents, org.AutoActivateRentals, "USD", org.ID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		err := repo.Update(ctx, org)
 		assert.NoError(t, err)
 	})
 }
+
+func TestOrganizationRepository_SetSettlementThreshold(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	t.Run("Override", func(t *testing.T) {
+		threshold := int32(2000)
+		mock.ExpectExec("UPDATE orgs SET billsplit_settlement_threshold_cents = \\$1 WHERE id = \\$2").
+			WithArgs(int64(2000), int32(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.SetSettlementThreshold(ctx, 1, &threshold))
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		mock.ExpectExec("UPDATE orgs SET billsplit_settlement_threshold_cents").
+			WithArgs(nil, int32(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.SetSettlementThreshold(ctx, 1, nil))
+	})
+
+	t.Run("Unknown org", func(t *testing.T) {
+		mock.ExpectExec("UPDATE orgs SET billsplit_settlement_threshold_cents").
+			WithArgs(nil, int32(99)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.Error(t, repo.SetSettlementThreshold(ctx, 99, nil))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOrganizationRepository_Create(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	t.Run("Normalizes metro", func(t *testing.T) {
+		org := &domain.Organization{Name: "Church A", Address: "1 Main St", Metro: "san jose ", AdminEmail: "admin@test.com", AdminPhoneNumber: "123"}
+
+		mock.ExpectQuery("INSERT INTO orgs").
+			WithArgs(org.Name, org.Description, org.Address, "San Jose", org.AdminPhoneNumber, org.AdminEmail, "USD", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		assert.NoError(t, repo.Create(ctx, org))
+		assert.Equal(t, "San Jose", org.Metro)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOrganizationRepository_GetByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	t.Run("Batch", func(t *testing.T) {
+		cols := []string{"id", "name", "description", "address", "metro", "admin_phone_number", "admin_email", "created_on",
+			"billsplit_settlement_threshold_cents", "max_billsplit_rental_cost_cents", "auto_activate_rentals", "adjacent_metros", "currency_code"}
+		mock.ExpectQuery("FROM orgs WHERE id = ANY\\(\\$1\\) ORDER BY id").
+			WithArgs(pq.Array([]int32{1, 3})).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow(1, "Org 1", "", "", "Austin", "", "", time.Now(), 0, 0, false, "{}", "USD").
+				AddRow(3, "Org 3", "", "", "Dallas", "", "", time.Now(), 0, 0, false, "{Fort Worth}", "EUR"))
+
+		orgs, err := repo.GetByIDs(ctx, []int32{1, 3})
+		assert.NoError(t, err)
+		assert.Len(t, orgs, 2)
+		assert.Equal(t, "Org 3", orgs[1].Name)
+		assert.Equal(t, []string{"Fort Worth"}, orgs[1].AdjacentMetros)
+		assert.Equal(t, "EUR", orgs[1].CurrencyCode)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("EmptySkipsQuery", func(t *testing.T) {
+		orgs, err := repo.GetByIDs(ctx, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, orgs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOrganizationRepository_ListMetros(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewOrganizationRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("UNION\\s+SELECT metro FROM tools WHERE metro <> '' AND deleted_on IS NULL\\s+ORDER BY metro").
+		WillReturnRows(sqlmock.NewRows([]string{"metro"}).AddRow("Austin").AddRow("San Jose"))
+
+	metros, err := repo.ListMetros(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Austin", "San Jose"}, metros)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -9,6 +9,7 @@ import (
 	"ubertool-backend-trusted/internal/repository/postgres"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -35,12 +36,13 @@ func TestRentalRepository_Create(t *testing.T) {
 			WeeklyPriceCents:    6000,
 			MonthlyPriceCents:   20000,
 			ReplacementCostCents: 50000,
+			PricingRounding:     "nearest",
 			TotalCostCents:      1000,
 			Status:              domain.RentalStatusPending,
 		}
 
 		mock.ExpectQuery("INSERT INTO rentals").
-			WithArgs(rental.OrgID, rental.ToolID, rental.RenterID, rental.OwnerID, rental.StartDate, rental.EndDate, rental.DurationUnit, rental.DailyPriceCents, rental.WeeklyPriceCents, rental.MonthlyPriceCents, rental.ReplacementCostCents, rental.TotalCostCents, rental.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(rental.OrgID, rental.ToolID, rental.RenterID, rental.OwnerID, rental.StartDate, rental.EndDate, rental.DurationUnit, rental.DailyPriceCents, rental.WeeklyPriceCents, rental.MonthlyPriceCents, rental.ReplacementCostCents, rental.PricingRounding, rental.TotalCostCents, rental.Status, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 		err := repo.Create(ctx, rental)
@@ -60,8 +62,8 @@ func TestRentalRepository_GetByID(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "org_id", "tool_id", "renter_id", "owner_id", "start_date", "last_agreed_end_date", "end_date", "duration_unit", "daily_price_cents", "weekly_price_cents", "monthly_price_cents", "replacement_cost_cents", "total_cost_cents", "status", "pickup_note", "rejection_reason", "completed_by", "return_condition", "surcharge_or_credit_cents", "return_note", "charge_billsplit", "created_on", "updated_on"}).
-			AddRow(1, 1, 2, 3, 4, time.Now(), time.Now(), time.Now(), "day", 1000, 6000, 20000, 50000, 1000, "PENDING", "Note", "", nil, "", 0, "Return Note", false, time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "org_id", "tool_id", "renter_id", "owner_id", "start_date", "last_agreed_end_date", "end_date", "requested_end_date", "duration_unit", "daily_price_cents", "weekly_price_cents", "monthly_price_cents", "replacement_cost_cents", "pricing_rounding", "total_cost_cents", "status", "pickup_note", "rejection_reason", "completed_by", "return_condition", "surcharge_or_credit_cents", "return_note", "charge_billsplit", "created_on", "updated_on"}).
+			AddRow(1, 1, 2, 3, 4, time.Now(), time.Now(), time.Now(), time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), "day", 1000, 6000, 20000, 50000, "prorate", 1000, "PENDING", "Note", "", nil, "", 0, "Return Note", false, time.Now(), time.Now())
 
 		mock.ExpectQuery("SELECT (.+) FROM rentals WHERE id = \\$1").
 			WithArgs(int32(1)).
@@ -71,5 +73,189 @@ func TestRentalRepository_GetByID(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, rental)
 		assert.Equal(t, int32(1), rental.ID)
+		assert.Equal(t, "prorate", rental.PricingRounding)
+		if assert.NotNil(t, rental.RequestedEndDate) {
+			assert.Equal(t, "2026-03-05", *rental.RequestedEndDate)
+		}
+	})
+}
+
+func TestRentalRepository_ListBusyRanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewRentalRepository(db)
+	ctx := context.Background()
+
+	t.Run("Single query for all tools", func(t *testing.T) {
+		start1, _ := time.Parse("2006-01-02", "2026-03-02")
+		end1, _ := time.Parse("2006-01-02", "2026-03-05")
+		start2, _ := time.Parse("2006-01-02", "2026-02-25")
+		end2, _ := time.Parse("2006-01-02", "2026-03-03")
+
+		mock.ExpectQuery("SELECT tool_id, id, start_date, GREATEST\\(end_date, requested_end_date\\), status FROM rentals WHERE tool_id = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]int32{1, 2}), sqlmock.AnyArg(), "2026-03-01", "2026-03-31").
+			WillReturnRows(sqlmock.NewRows([]string{"tool_id", "id", "start_date", "end_date", "status"}).
+				AddRow(1, 10, start1, end1, "SCHEDULED").
+				AddRow(2, 12, start2, end2, "ACTIVE"))
+
+		ranges, err := repo.ListBusyRanges(ctx, []int32{1, 2}, "2026-03-01", "2026-03-31")
+		assert.NoError(t, err)
+		assert.Len(t, ranges, 2)
+		assert.Equal(t, domain.ToolBusyRange{ToolID: 1, RentalID: 10, StartDate: "2026-03-02", EndDate: "2026-03-05", Status: domain.RentalStatusScheduled}, ranges[0])
+		assert.Equal(t, int32(2), ranges[1].ToolID)
+		assert.Equal(t, "2026-02-25", ranges[1].StartDate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRentalRepository_ListByTool(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewRentalRepository(db)
+	ctx := context.Background()
+	columns := []string{"id", "org_id", "tool_id", "renter_id", "owner_id", "start_date", "last_agreed_end_date", "end_date", "requested_end_date", "duration_unit", "daily_price_cents", "weekly_price_cents", "monthly_price_cents", "replacement_cost_cents", "pricing_rounding", "total_cost_cents", "status", "pickup_note", "rejection_reason", "completed_by", "return_condition", "surcharge_or_credit_cents", "return_note", "charge_billsplit", "created_on", "updated_on"}
+
+	t.Run("Date window filters on overlap after status", func(t *testing.T) {
+		start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+		where := "WHERE tool_id = \\$1 AND org_id = \\$2 AND status IN \\(\\$3\\) AND end_date >= \\$4 AND start_date <= \\$5"
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\(SELECT .* FROM rentals " + where + "\\) as sub").
+			WithArgs(int32(2), int32(1), "SCHEDULED", "2026-03-03", "2026-03-10").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("SELECT .* FROM rentals " + where + " ORDER BY created_on DESC, id DESC LIMIT \\$6 OFFSET \\$7").
+			WithArgs(int32(2), int32(1), "SCHEDULED", "2026-03-03", "2026-03-10", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(9, 1, 2, 3, 4, start, end, end, nil, "day", 1000, 0, 0, 0, "round_up", 3000, "SCHEDULED", "", "", nil, "", 0, "", true, time.Now(), time.Now()))
+
+		rentals, total, err := repo.ListByTool(ctx, 2, 1, []string{"SCHEDULED"}, "2026-03-03", "2026-03-10", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		if assert.Len(t, rentals, 1) {
+			assert.Equal(t, "2026-03-01", rentals[0].StartDate)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Open-ended window only binds the given bound", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\(SELECT .* FROM rentals WHERE tool_id = \\$1 AND start_date <= \\$2\\) as sub").
+			WithArgs(int32(2), "2026-03-10").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("SELECT .* FROM rentals WHERE tool_id = \\$1 AND start_date <= \\$2 ORDER BY").
+			WithArgs(int32(2), "2026-03-10", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		rentals, total, err := repo.ListByTool(ctx, 2, 0, nil, "", "2026-03-10", 1, 10)
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, rentals)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRentalRepository_CountActivity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewRentalRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FILTER .* FROM rentals WHERE renter_id = \\$1 OR owner_id = \\$1").
+		WithArgs(int32(7), pq.Array([]string{"ACTIVE", "OVERDUE", "RETURN_DATE_CHANGED", "RETURN_DATE_CHANGE_REJECTED", "EARLY_RETURN_REQUESTED"}),
+			pq.Array([]string{"PENDING", "RETURN_DATE_CHANGED"}),
+			pq.Array([]string{"APPROVED", "RETURN_DATE_CHANGE_REJECTED", "EARLY_RETURN_REQUESTED"})).
+		WillReturnRows(sqlmock.NewRows([]string{"active_rentals", "active_lendings", "pending_actions"}).AddRow(2, 1, 3))
+
+	counts, err := repo.CountActivity(ctx, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.RentalActivityCounts{ActiveRentals: 2, ActiveLendings: 1, PendingActions: 3}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRentalRepository_ListActionable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewRentalRepository(db)
+	ctx := context.Background()
+
+	columns := []string{"id", "org_id", "tool_id", "renter_id", "owner_id", "start_date", "last_agreed_end_date", "end_date", "requested_end_date", "duration_unit", "daily_price_cents", "weekly_price_cents", "monthly_price_cents", "replacement_cost_cents", "pricing_rounding", "total_cost_cents", "status", "pickup_note", "rejection_reason", "completed_by", "return_condition", "surcharge_or_credit_cents", "return_note", "charge_billsplit", "created_on", "updated_on"}
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM rentals\s+WHERE org_id = \$2 AND \(\(owner_id = \$1 AND status = ANY\(\$3\)\) OR \(renter_id = \$1 AND status = ANY\(\$4\)\)\)\s+ORDER BY created_on DESC, id DESC`).
+		WithArgs(int32(7), int32(1),
+			pq.Array([]string{"PENDING", "RETURN_DATE_CHANGED", "OVERDUE"}),
+			pq.Array([]string{"APPROVED", "RETURN_DATE_CHANGE_REJECTED", "EARLY_RETURN_REQUESTED", "OVERDUE"})).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(11, 1, 2, 8, 7, start, nil, end, nil, "day", 1000, 6000, 20000, 0, "round_up", 4000, "PENDING", "", "", nil, "", 0, "", true, start, start).
+			AddRow(12, 1, 3, 7, 9, start, end, end, end.AddDate(0, 0, -2), "day", 1000, 6000, 20000, 0, "round_up", 4000, "EARLY_RETURN_REQUESTED", "", "", nil, "", 0, "", true, start, start))
+
+	rentals, err := repo.ListActionable(ctx, 7, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, rentals, 2) {
+		assert.Equal(t, domain.RentalStatusPending, rentals[0].Status)
+		assert.Equal(t, "2026-03-05", rentals[0].EndDate)
+		if assert.NotNil(t, rentals[1].RequestedEndDate) {
+			assert.Equal(t, "2026-03-03", *rentals[1].RequestedEndDate)
+		}
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRentalEventRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %v", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewRentalEventRepository(db)
+	ctx := context.Background()
+
+	t.Run("Create stores empty from_status and note as NULL", func(t *testing.T) {
+		now := time.Now()
+		actor := int32(4)
+		event := &domain.RentalEvent{RentalID: 1, ActorUserID: &actor, ToStatus: domain.RentalStatusPending}
+		mock.ExpectQuery(`INSERT INTO rental_events .* VALUES \(\$1, \$2, NULLIF\(\$3, ''\), \$4, NULLIF\(\$5, ''\)\)`).
+			WithArgs(int32(1), &actor, "", "PENDING", "").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(9, now))
+
+		err := repo.Create(ctx, event)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(9), event.ID)
+		assert.Equal(t, now, event.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ListByRental orders by time and maps job events", func(t *testing.T) {
+		now := time.Now()
+		mock.ExpectQuery(`FROM rental_events WHERE rental_id = \$1 ORDER BY created_at, id`).
+			WithArgs(int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "rental_id", "actor_user_id", "from_status", "to_status", "note", "created_at"}).
+				AddRow(1, 1, 4, "", "PENDING", "", now).
+				AddRow(2, 1, nil, "ACTIVE", "OVERDUE", "end date passed", now))
+
+		events, err := repo.ListByRental(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int32(4), *events[0].ActorUserID)
+		assert.Equal(t, domain.RentalStatus(""), events[0].FromStatus)
+		assert.Nil(t, events[1].ActorUserID)
+		assert.Equal(t, domain.RentalStatusOverdue, events[1].ToStatus)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
@@ -0,0 +1,175 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_WithTx(t *testing.T) {
+	ctx := context.Background()
+	entry := func() *domain.LedgerTransaction {
+		return &domain.LedgerTransaction{OrgID: 1, UserID: 2, Amount: -500, Type: domain.TransactionTypeLendingDebit, Description: "Settlement"}
+	}
+
+	t.Run("Commits when fn succeeds", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStore(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO ledger_transactions").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec("UPDATE rentals SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			if err := tx.LedgerRepository.CreateTransaction(ctx, entry()); err != nil {
+				return err
+			}
+			return tx.RentalRepository.Update(ctx, &domain.Rental{ID: 3, Status: domain.RentalStatusCompleted})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when fn fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStore(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO ledger_transactions").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec("UPDATE rentals SET status").WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			if err := tx.LedgerRepository.CreateTransaction(ctx, entry()); err != nil {
+				return err
+			}
+			return tx.RentalRepository.Update(ctx, &domain.Rental{ID: 3, Status: domain.RentalStatusCompleted})
+		})
+		assert.EqualError(t, err, "connection reset")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rolls back when fn panics", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStore(db)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		assert.Panics(t, func() {
+			_ = store.WithTx(ctx, func(tx *postgres.Store) error { panic("boom") })
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Nested calls and repository transactions join the outer one", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStore(db)
+
+		// SetPrimaryImage opens its own transaction when called outside WithTx
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images SET is_primary = false").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("UPDATE tool_images SET is_primary = true").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			return tx.WithTx(ctx, func(inner *postgres.Store) error {
+				return inner.ToolRepository.SetPrimaryImage(ctx, 4, 9)
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestStore_WithTx_Retry(t *testing.T) {
+	ctx := context.Background()
+	opts := postgres.StoreOptions{TxMaxAttempts: 3, TxRetryBackoff: time.Millisecond}
+	complete := func(tx *postgres.Store) error {
+		return tx.RentalRepository.Update(ctx, &domain.Rental{ID: 3, Status: domain.RentalStatusCompleted})
+	}
+
+	t.Run("Retries a serialization failure and succeeds", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStoreWithOptions(db, opts)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE rentals SET status").WillReturnError(&pq.Error{Code: "40001"})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE rentals SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		calls := 0
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			calls++
+			return complete(tx)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Gives up after the last attempt", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStoreWithOptions(db, opts)
+
+		for i := 0; i < 3; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE rentals SET status").WillReturnError(&pq.Error{Code: "40P01"})
+			mock.ExpectRollback()
+		}
+
+		err = store.WithTx(ctx, complete)
+		assert.True(t, postgres.IsRetryable(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Does not retry other errors", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		store := postgres.NewStoreWithOptions(db, opts)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE rentals SET status").WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		err = store.WithTx(ctx, complete)
+		assert.Error(t, err)
+		assert.False(t, postgres.IsRetryable(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, postgres.IsRetryable(&pq.Error{Code: "40001"}))
+	assert.True(t, postgres.IsRetryable(&pq.Error{Code: "40P01"}))
+	assert.True(t, postgres.IsRetryable(fmt.Errorf("complete rental: %w", &pq.Error{Code: "40001"})))
+	assert.False(t, postgres.IsRetryable(&pq.Error{Code: "23505"}))
+	assert.False(t, postgres.IsRetryable(errors.New("connection reset")))
+	assert.False(t, postgres.IsRetryable(nil))
+}
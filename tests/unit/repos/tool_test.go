@@ -23,8 +23,8 @@ func TestToolRepository_GetByID(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "owner_id", "name", "description", "categories", "price_per_day_cents", "price_per_week_cents", "price_per_month_cents", "replacement_cost_cents", "duration_unit", "condition", "metro", "status", "created_on", "deleted_on"}).
-			AddRow(1, 2, "Hammer", "A tool", pq.Array([]string{"Hand Tools"}), 100, 500, 1500, 2000, "day", "EXCELLENT", "San Jose", "AVAILABLE", time.Now(), nil)
+		rows := sqlmock.NewRows([]string{"id", "owner_id", "name", "description", "categories", "price_per_day_cents", "price_per_week_cents", "price_per_month_cents", "replacement_cost_cents", "duration_unit", "condition", "metro", "status", "created_on", "updated_on", "deleted_on"}).
+			AddRow(1, 2, "Hammer", "A tool", pq.Array([]string{"Hand Tools"}), 100, 500, 1500, 2000, "day", "EXCELLENT", "San Jose", "AVAILABLE", time.Now(), time.Now(), nil)
 
 		mock.ExpectQuery("SELECT (.+) FROM tools WHERE id = \\$1").
 			WithArgs(int32(1)).
@@ -69,4 +69,372 @@ func TestToolRepository_Create(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, int32(1), tool.ID)
 	})
+
+	t.Run("Normalizes metro", func(t *testing.T) {
+		tool := &domain.Tool{OwnerID: 1, Name: "Saw", Metro: "  san   JOSE ", Status: domain.ToolStatusAvailable}
+
+		mock.ExpectQuery("INSERT INTO tools").
+			WithArgs(tool.OwnerID, tool.Name, tool.Description, pq.Array(tool.Categories), tool.PricePerDayCents, tool.PricePerWeekCents, tool.PricePerMonthCents, tool.ReplacementCostCents, tool.DurationUnit, tool.Condition, "San Jose", tool.Status, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+
+		assert.NoError(t, repo.Create(ctx, tool))
+		assert.Equal(t, "San Jose", tool.Metro)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_Search(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+	columns := []string{"id", "owner_id", "name", "description", "categories", "price_per_day_cents", "price_per_week_cents", "price_per_month_cents", "replacement_cost_cents", "duration_unit", "condition", "metro", "status", "created_on", "updated_on", "deleted_on"}
+
+	t.Run("Ranked full-text query", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\((.+)search_vector @@ to_tsquery\\('english', \\$4\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "cordless | drill", "%cordless drill!%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("ORDER BY ts_rank\\(search_vector, to_tsquery\\('english', \\$4\\)\\) DESC, id LIMIT \\$6 OFFSET \\$7").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "cordless | drill", "%cordless drill!%", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(5, 2, "Cordless Drill", "18V drill", pq.Array([]string{"Power Tools"}), 500, 0, 0, 0, "day", "GOOD", "San Jose", "AVAILABLE", time.Now(), time.Now(), nil).
+				AddRow(6, 2, "Drill Bits", "Assorted bits", pq.Array([]string{"Power Tools"}), 100, 0, 0, 0, "day", "GOOD", "San Jose", "AVAILABLE", time.Now(), time.Now(), nil))
+
+		tools, total, err := repo.Search(ctx, 1, []string{"San Jose"}, "cordless drill!", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), total)
+		assert.Equal(t, "Cordless Drill", tools[0].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Falls back to ILIKE without word characters", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\((.+)AND \\(name ILIKE \\$4 OR description ILIKE \\$4\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "%--%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery("AND \\(name ILIKE \\$4 OR description ILIKE \\$4\\) LIMIT \\$5 OFFSET \\$6").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "%--%", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		tools, total, err := repo.Search(ctx, 1, []string{"San Jose"}, "--", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), total)
+		assert.Empty(t, tools)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Metro matches case-insensitively", func(t *testing.T) {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\((.+)WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "%--%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery("WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(1), domain.ToolStatusUnavailable, "%--%", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(5, 2, "Drill", "", pq.Array([]string{"Power Tools"}), 500, 0, 0, 0, "day", "GOOD", "San Jose", "AVAILABLE", time.Now(), time.Now(), nil))
+
+		tools, total, err := repo.Search(ctx, 1, []string{" san jose "}, "--", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, tools, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Spans several metros in one parameter", func(t *testing.T) {
+		metros := pq.Array([]string{"san jose", "santa clara"})
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM \\((.+)WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(metros, int32(1), domain.ToolStatusUnavailable, "%--%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery("WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(metros, int32(1), domain.ToolStatusUnavailable, "%--%", int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(5, 2, "Drill", "", pq.Array([]string{"Power Tools"}), 500, 0, 0, 0, "day", "GOOD", "San Jose", "AVAILABLE", time.Now(), time.Now(), nil).
+				AddRow(6, 3, "Saw", "", pq.Array([]string{"Power Tools"}), 500, 0, 0, 0, "day", "GOOD", "Santa Clara", "AVAILABLE", time.Now(), time.Now(), nil))
+
+		// Duplicates and blanks are dropped before querying
+		tools, total, err := repo.Search(ctx, 1, []string{"San Jose", "santa clara", "SAN JOSE", " "}, "--", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), total)
+		assert.Len(t, tools, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_ListByOrg(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+	columns := []string{"id", "owner_id", "name", "description", "categories", "price_per_day_cents", "price_per_week_cents", "price_per_month_cents", "replacement_cost_cents", "duration_unit", "condition", "metro", "status", "created_on", "updated_on", "deleted_on"}
+	expectOrg := func() {
+		mock.ExpectQuery("SELECT metro, adjacent_metros FROM orgs WHERE id = \\$1").
+			WithArgs(int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"metro", "adjacent_metros"}).AddRow("San Jose", pq.Array([]string{"Santa Clara"})))
+	}
+
+	t.Run("Defaults to the org's own metro, matched case-insensitively", func(t *testing.T) {
+		expectOrg()
+		mock.ExpectQuery("FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(5, 2, "Drill", "", pq.Array([]string{"Power Tools"}), 500, 0, 0, 0, "day", "GOOD", "san jose", "AVAILABLE", time.Now(), time.Now(), nil))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"})).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		tools, total, err := repo.ListByOrg(ctx, 1, false, 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		assert.Len(t, tools, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Includes adjacent metros on request", func(t *testing.T) {
+		expectOrg()
+		mock.ExpectQuery("FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose", "santa clara"}), int32(10), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose", "santa clara"})).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		_, _, err := repo.ListByOrg(ctx, 1, true, 1, 10)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Out of range paging is clamped", func(t *testing.T) {
+		expectOrg()
+		mock.ExpectQuery("FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"}), int32(100), int32(0)).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM tools WHERE lower\\(metro\\) = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]string{"san jose"})).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		_, _, err := repo.ListByOrg(ctx, 1, false, -3, 5000)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_Update(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	t.Run("Sets updated_on", func(t *testing.T) {
+		tool := &domain.Tool{ID: 4, Name: "Drill", CreatedOn: "2025-01-10", UpdatedOn: "2025-01-10", Status: domain.ToolStatusAvailable}
+		today := time.Now().Format("2006-01-02")
+
+		mock.ExpectExec("UPDATE tools SET (.+) updated_on=\\$12 WHERE id=\\$13").
+			WithArgs(tool.Name, tool.Description, sqlmock.AnyArg(), tool.PricePerDayCents, tool.PricePerWeekCents, tool.PricePerMonthCents, tool.ReplacementCostCents, tool.Condition, tool.Metro, tool.Status, tool.DurationUnit, today, tool.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := repo.Update(ctx, tool)
+		assert.NoError(t, err)
+		assert.Equal(t, today, tool.UpdatedOn)
+		assert.Equal(t, "2025-01-10", tool.CreatedOn)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_UpdateImage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	t.Run("Confirmed primary unsets the previous primary", func(t *testing.T) {
+		img := &domain.ToolImage{ID: 8, ToolID: 3, IsPrimary: true, Status: "CONFIRMED"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images SET is_primary = false WHERE tool_id = \\$1 AND id <> \\$2 AND is_primary = true").
+			WithArgs(int32(3), int32(8)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE tool_images SET tool_id = \\$2").
+			WithArgs(int32(8), int32(3), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), true, sqlmock.AnyArg(), "CONFIRMED", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.UpdateImage(ctx, img))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Pending primary leaves other images alone", func(t *testing.T) {
+		img := &domain.ToolImage{ID: 9, ToolID: 3, IsPrimary: true, Status: "PENDING"}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images SET tool_id = \\$2").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.UpdateImage(ctx, img))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_SetPrimaryImage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	t.Run("Swaps primary in one transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images SET is_primary = false WHERE tool_id = \\$1").
+			WithArgs(int32(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE tool_images SET is_primary = true WHERE id = \\$1 AND tool_id = \\$2").
+			WithArgs(int32(8), int32(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.SetPrimaryImage(ctx, 3, 8))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Tool without that image rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images SET is_primary = false WHERE tool_id = \\$1").
+			WithArgs(int32(4)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("UPDATE tool_images SET is_primary = true WHERE id = \\$1 AND tool_id = \\$2").
+			WithArgs(int32(8), int32(4)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.ErrorContains(t, repo.SetPrimaryImage(ctx, 4, 8), "not found")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_DeleteImage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	t.Run("Deleting the primary promotes the next by display order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT tool_id, is_primary FROM tool_images WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int32(8)).
+			WillReturnRows(sqlmock.NewRows([]string{"tool_id", "is_primary"}).AddRow(3, true))
+		mock.ExpectExec("UPDATE tool_images SET status = 'DELETED', is_primary = false").
+			WithArgs(sqlmock.AnyArg(), int32(8)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE tool_images SET is_primary = true (.+) ORDER BY display_order ASC, created_at ASC, id ASC LIMIT 1").
+			WithArgs(int32(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteImage(ctx, 8))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Deleting a secondary image keeps the primary", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT tool_id, is_primary FROM tool_images WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int32(9)).
+			WillReturnRows(sqlmock.NewRows([]string{"tool_id", "is_primary"}).AddRow(3, false))
+		mock.ExpectExec("UPDATE tool_images SET status = 'DELETED', is_primary = false").
+			WithArgs(sqlmock.AnyArg(), int32(9)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteImage(ctx, 9))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Deleting the last image leaves no primary", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT tool_id, is_primary FROM tool_images WHERE id = \\$1 FOR UPDATE").
+			WithArgs(int32(10)).
+			WillReturnRows(sqlmock.NewRows([]string{"tool_id", "is_primary"}).AddRow(5, true))
+		mock.ExpectExec("UPDATE tool_images SET status = 'DELETED', is_primary = false").
+			WithArgs(sqlmock.AnyArg(), int32(10)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE tool_images SET is_primary = true").
+			WithArgs(int32(5)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.DeleteImage(ctx, 10))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_ReorderImages(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	t.Run("Sets display_order from list position", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images t SET display_order = o.ord - 1 FROM unnest\\(\\$2::int\\[\\]\\) WITH ORDINALITY").
+			WithArgs(int32(5), pq.Array([]int32{3, 2, 1})).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectCommit()
+
+		assert.NoError(t, repo.ReorderImages(ctx, 5, []int32{3, 2, 1}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unmatched image rolls back", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE tool_images t SET display_order").
+			WithArgs(int32(5), pq.Array([]int32{3, 2})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		assert.ErrorContains(t, repo.ReorderImages(ctx, 5, []int32{3, 2}), "reordered 1 of 2")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestToolRepository_ListCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewToolRepository(db)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT name FROM tool_categories ORDER BY sort_order, name").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Hand Tools").AddRow("Power Tools"))
+
+	categories, err := repo.ListCategories(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Hand Tools", "Power Tools"}, categories)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
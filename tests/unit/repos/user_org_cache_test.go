@@ -0,0 +1,163 @@
+package repos
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/repository"
+	"ubertool-backend-trusted/internal/repository/postgres"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getUserOrgQuery = `SELECT (.+) FROM users_orgs uo JOIN orgs o ON o.id = uo.org_id WHERE uo.user_id = \$1 AND uo.org_id = \$2`
+
+func expectGetUserOrg(mock sqlmock.Sqlmock, rentingBlocked bool) {
+	mock.ExpectQuery(getUserOrgQuery).WithArgs(int32(2), int32(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "org_id", "joined_on", "balance_cents", "last_balance_updated_on",
+			"status", "role", "blocked_on", "blocked_reason", "renting_blocked", "lending_blocked", "blocked_due_to_bill_id", "currency_code"}).
+			AddRow(2, 1, time.Now(), -500, nil, "ACTIVE", "MEMBER", nil, "", rentingBlocked, false, nil, "USD"))
+}
+
+func TestStore_UserOrgCache(t *testing.T) {
+	newStore := func(t *testing.T) (*postgres.Store, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return postgres.NewStore(db), mock
+	}
+
+	t.Run("Repeated reads in a request hit the database once", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		expectGetUserOrg(mock, false)
+
+		first, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		first.BalanceCents = 0 // callers get copies; the cached membership is untouched
+
+		second, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int32(-500), second.BalanceCents)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Misses and errors are not cached", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		mock.ExpectQuery(getUserOrgQuery).WithArgs(int32(2), int32(1)).WillReturnError(sql.ErrNoRows)
+		expectGetUserOrg(mock, false)
+
+		_, err := store.GetUserOrg(ctx, 2, 1)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = store.GetUserOrg(ctx, 2, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Without a request cache every read queries", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := context.Background()
+		expectGetUserOrg(mock, false)
+		expectGetUserOrg(mock, false)
+
+		_, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		_, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateUserOrg invalidates so the block flag is reread", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		expectGetUserOrg(mock, false)
+		mock.ExpectExec("UPDATE users_orgs SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		expectGetUserOrg(mock, true)
+
+		userOrg, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		userOrg.RentingBlocked = true
+		require.NoError(t, store.UpdateUserOrg(ctx, userOrg))
+
+		userOrg, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.True(t, userOrg.RentingBlocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Balance writes invalidate", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		expectGetUserOrg(mock, false)
+		mock.ExpectQuery("UPDATE users_orgs SET balance_cents").WillReturnRows(sqlmock.NewRows([]string{"balance_cents"}).AddRow(500))
+		expectGetUserOrg(mock, false)
+		mock.ExpectQuery("INSERT INTO ledger_transactions").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		expectGetUserOrg(mock, false)
+
+		_, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		_, err = store.AdjustBalance(ctx, 2, 1, 1000)
+		require.NoError(t, err)
+		_, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		require.NoError(t, store.LedgerRepository.CreateTransaction(ctx, &domain.LedgerTransaction{
+			OrgID: 1, UserID: 2, Amount: -500, Type: domain.TransactionTypeLendingDebit, Description: "Settlement"}))
+		_, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("A committed transaction invalidates", func(t *testing.T) {
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		expectGetUserOrg(mock, false)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users_orgs SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectGetUserOrg(mock, true)
+
+		userOrg, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			userOrg.RentingBlocked = true
+			return tx.UpdateUserOrg(ctx, userOrg)
+		})
+		require.NoError(t, err)
+
+		userOrg, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.True(t, userOrg.RentingBlocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("A failed commit invalidates", func(t *testing.T) {
+		// The commit may have reached the database before the error, so the write can't be ruled out
+		store, mock := newStore(t)
+		ctx := repository.WithUserOrgCache(context.Background())
+		expectGetUserOrg(mock, false)
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users_orgs SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+		expectGetUserOrg(mock, true)
+
+		userOrg, err := store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		err = store.WithTx(ctx, func(tx *postgres.Store) error {
+			userOrg.RentingBlocked = true
+			return tx.UpdateUserOrg(ctx, userOrg)
+		})
+		require.Error(t, err)
+
+		userOrg, err = store.GetUserOrg(ctx, 2, 1)
+		require.NoError(t, err)
+		assert.True(t, userOrg.RentingBlocked)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -2,13 +2,17 @@ package repos
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"testing"
 	"time"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/repository/postgres"
+	"ubertool-backend-trusted/internal/utils"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,8 +27,8 @@ func TestUserRepository_GetByID(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Success", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "created_on", "updated_on"}).
-			AddRow(1, "test@test.com", "123", "hash", "Name", "url", time.Now(), time.Now())
+		rows := sqlmock.NewRows([]string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "email_verified", "notification_preference", "created_on", "updated_on"}).
+			AddRow(1, "test@test.com", "123", "hash", "Name", "url", true, "EMAIL_AND_SMS", time.Now(), time.Now())
 
 		mock.ExpectQuery("SELECT (.+) FROM users WHERE id = \\$1").
 			WithArgs(int32(1)).
@@ -34,6 +38,8 @@ func TestUserRepository_GetByID(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
 		assert.Equal(t, int32(1), user.ID)
+		assert.True(t, user.EmailVerified)
+		assert.Equal(t, domain.NotificationPreferenceEmailAndSMS, user.NotificationPreference)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
@@ -47,6 +53,40 @@ func TestUserRepository_GetByID(t *testing.T) {
 	})
 }
 
+func TestUserRepository_GetByIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewUserRepository(db)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "email_verified", "notification_preference", "created_on", "updated_on"}).
+			AddRow(1, "a@test.com", "111", "hash", "Alice", "", true, "EMAIL", time.Now(), time.Now()).
+			AddRow(2, "b@test.com", "222", "hash", "Bob", "", false, "EMAIL", time.Now(), time.Now())
+
+		mock.ExpectQuery("SELECT (.+) FROM users WHERE id = ANY\\(\\$1\\)").
+			WithArgs(pq.Array([]int32{1, 2})).
+			WillReturnRows(rows)
+
+		users, err := repo.GetByIDs(ctx, []int32{1, 2})
+		assert.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.Equal(t, "Bob", users[1].Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Empty IDs skip the query", func(t *testing.T) {
+		users, err := repo.GetByIDs(ctx, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestUserRepository_Create(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -66,7 +106,7 @@ func TestUserRepository_Create(t *testing.T) {
 		}
 
 		mock.ExpectQuery("INSERT INTO users").
-			WithArgs(u.Email, u.PhoneNumber, u.PasswordHash, u.Name, u.AvatarURL, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(u.Email, u.PhoneNumber, u.PasswordHash, u.Name, u.AvatarURL, false, domain.NotificationPreferenceEmail, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 
 		err := repo.Create(ctx, u)
@@ -74,6 +114,23 @@ func TestUserRepository_Create(t *testing.T) {
 		assert.Equal(t, int32(1), u.ID)
 	})
 }
+
+func TestUserRepository_UpdateNotificationPreference(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewUserRepository(db)
+
+	mock.ExpectExec("UPDATE users SET notification_preference=\\$1").
+		WithArgs(domain.NotificationPreferenceEmailAndSMS, sqlmock.AnyArg(), int32(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.UpdateNotificationPreference(context.Background(), 1, domain.NotificationPreferenceEmailAndSMS))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 func TestUserRepository_ListMembersByOrg(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -87,8 +144,8 @@ func TestUserRepository_ListMembersByOrg(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "created_on", "updated_on",
 			"user_id", "org_id", "joined_on", "balance_cents", "last_balance_updated_on", "status", "role", "blocked_on", "blocked_reason",
-			"renting_blocked", "lending_blocked", "blocked_due_to_bill_id"}).
-			AddRow(1, "u1@test.com", "111", "hash", "User 1", "url", time.Now(), time.Now(), 1, 1, time.Now(), 100, nil, "ACTIVE", "MEMBER", nil, "", false, false, nil)
+			"renting_blocked", "lending_blocked", "blocked_due_to_bill_id", "currency_code"}).
+			AddRow(1, "u1@test.com", "111", "hash", "User 1", "url", time.Now(), time.Now(), 1, 1, time.Now(), 100, nil, "ACTIVE", "MEMBER", nil, "", false, false, nil, "USD")
 
 		mock.ExpectQuery("SELECT (.+) FROM users u JOIN users_orgs uo").
 			WithArgs(int32(1)).
@@ -115,8 +172,8 @@ func TestUserRepository_SearchMembersByOrg(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "created_on", "updated_on",
 			"user_id", "org_id", "joined_on", "balance_cents", "last_balance_updated_on", "status", "role", "blocked_on", "blocked_reason",
-			"renting_blocked", "lending_blocked", "blocked_due_to_bill_id"}).
-			AddRow(1, "u1@test.com", "111", "hash", "User 1", "url", time.Now(), time.Now(), 1, 1, time.Now(), 100, nil, "ACTIVE", "MEMBER", nil, "", false, false, nil)
+			"renting_blocked", "lending_blocked", "blocked_due_to_bill_id", "currency_code"}).
+			AddRow(1, "u1@test.com", "111", "hash", "User 1", "url", time.Now(), time.Now(), 1, 1, time.Now(), 100, nil, "ACTIVE", "MEMBER", nil, "", false, false, nil, "USD")
 
 		mock.ExpectQuery("SELECT (.+) FROM users u JOIN users_orgs uo").
 			WithArgs(int32(1), "%search%").
@@ -127,3 +184,102 @@ func TestUserRepository_SearchMembersByOrg(t *testing.T) {
 		assert.Equal(t, 1, len(users))
 	})
 }
+
+func TestUserRepository_ListMembersByOrgFiltered(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewUserRepository(db)
+	ctx := context.Background()
+
+	memberColumns := []string{"id", "email", "phone_number", "password_hash", "name", "avatar_url", "created_on", "updated_on",
+		"user_id", "org_id", "joined_on", "balance_cents", "last_balance_updated_on", "status", "role", "blocked_on", "blocked_reason",
+		"renting_blocked", "lending_blocked", "blocked_due_to_bill_id", "currency_code"}
+
+	const blockedClause = `AND \(uo.status = 'BLOCK' OR uo.renting_blocked OR uo.lending_blocked\)`
+	const negativeClause = `AND uo.balance_cents < 0`
+	const roleClause = `AND uo.role = \$2`
+
+	tests := []struct {
+		name     string
+		filter   domain.MemberFilter
+		pattern  string
+		args     []driver.Value
+		pageArgs []driver.Value
+	}{
+		{"NoFilter", domain.MemberFilter{}, `WHERE uo.org_id = \$1 ORDER BY`, []driver.Value{int32(1)}, nil},
+		{"BlockedOnly", domain.MemberFilter{BlockedOnly: true}, `WHERE uo.org_id = \$1 ` + blockedClause + ` ORDER BY`, []driver.Value{int32(1)}, nil},
+		{"NegativeBalanceOnly", domain.MemberFilter{NegativeBalanceOnly: true}, `WHERE uo.org_id = \$1 ` + negativeClause + ` ORDER BY`, []driver.Value{int32(1)}, nil},
+		{"RoleOnly", domain.MemberFilter{Role: domain.UserOrgRoleAdmin}, `WHERE uo.org_id = \$1 ` + roleClause + ` ORDER BY`, []driver.Value{int32(1), "ADMIN"}, nil},
+		{"BlockedAndNegative", domain.MemberFilter{BlockedOnly: true, NegativeBalanceOnly: true},
+			blockedClause + ` ` + negativeClause + ` ORDER BY`, []driver.Value{int32(1)}, nil},
+		{"BlockedAndRole", domain.MemberFilter{BlockedOnly: true, Role: domain.UserOrgRoleMember},
+			blockedClause + ` ` + roleClause + ` ORDER BY`, []driver.Value{int32(1), "MEMBER"}, nil},
+		{"NegativeAndRole", domain.MemberFilter{NegativeBalanceOnly: true, Role: domain.UserOrgRoleMember},
+			negativeClause + ` ` + roleClause + ` ORDER BY`, []driver.Value{int32(1), "MEMBER"}, nil},
+		{"AllFiltersPaged", domain.MemberFilter{BlockedOnly: true, NegativeBalanceOnly: true, Role: domain.UserOrgRoleMember, Page: 2, PageSize: 5},
+			blockedClause + ` ` + negativeClause + ` AND uo.role = \$2 ORDER BY u.name, u.id LIMIT \$3 OFFSET \$4`,
+			[]driver.Value{int32(1), "MEMBER"}, []driver.Value{int32(5), int32(5)}},
+		{"OversizedPageIsCapped", domain.MemberFilter{Page: 3, PageSize: 100000},
+			`WHERE uo.org_id = \$1 ORDER BY u.name, u.id LIMIT \$2 OFFSET \$3`,
+			[]driver.Value{int32(1)}, []driver.Value{utils.MaxPageSize, 2 * utils.MaxPageSize}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mock.ExpectQuery(`SELECT count\(\*\) FROM \(SELECT (.+) FROM users u JOIN users_orgs uo`).
+				WithArgs(tc.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+
+			rows := sqlmock.NewRows(memberColumns).
+				AddRow(4, "u4@test.com", "444", "hash", "User 4", "", time.Now(), time.Now(), 4, 1, time.Now(), -250, nil, "ACTIVE", "MEMBER", time.Now(), "overdue", true, false, nil, "CAD")
+			mock.ExpectQuery(`SELECT (.+) FROM users u JOIN users_orgs uo (.+)` + tc.pattern).
+				WithArgs(append(append([]driver.Value{}, tc.args...), tc.pageArgs...)...).
+				WillReturnRows(rows)
+
+			users, uos, total, err := repo.ListMembersByOrgFiltered(ctx, 1, tc.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, int32(7), total)
+			assert.Len(t, users, 1)
+			assert.Equal(t, int32(-250), uos[0].BalanceCents)
+			assert.Equal(t, "CAD", uos[0].CurrencyCode)
+			assert.NotNil(t, uos[0].BlockedOn)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUserRepository_AdjustBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := postgres.NewUserRepository(db)
+	ctx := context.Background()
+
+	t.Run("Increments in SQL", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE users_orgs SET balance_cents = balance_cents \+ \$1.*RETURNING balance_cents`).
+			WithArgs(int32(-250), int32(2), int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance_cents"}).AddRow(750))
+
+		balance, err := repo.AdjustBalance(ctx, 2, 1, -250)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(750), balance)
+	})
+
+	t.Run("Not a member", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE users_orgs SET balance_cents`).
+			WithArgs(int32(100), int32(9), int32(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"balance_cents"}))
+
+		_, err := repo.AdjustBalance(ctx, 9, 1, 100)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"ubertool-backend-trusted/internal/api/grpc/interceptor"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/logger"
+	"ubertool-backend-trusted/internal/service"
+)
+
+// captureLogs routes the global logger to a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger.InitializeWithWriter(&buf, "debug", "json")
+	t.Cleanup(func() { logger.Initialize("info", "text") })
+	return &buf
+}
+
+// logLinesFor returns the decoded log records whose method attribute matches
+func logLinesFor(t *testing.T, buf *bytes.Buffer, method string) []map[string]any {
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &rec))
+		if rec["method"] == method {
+			lines = append(lines, rec)
+		}
+	}
+	return lines
+}
+
+func TestRequestIDInterceptor(t *testing.T) {
+	unary := interceptor.NewRequestIDInterceptor().Unary()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ubertool.trusted.api.v1.BillSplitService/GetGlobalBillSplitSummary"}
+
+	callSummary := func(ctx context.Context) {
+		mockBillRepo := new(MockBillRepo)
+		mockUserRepo := new(MockUserRepo)
+		svc := service.NewBillSplitService(mockBillRepo, mockUserRepo, nil, nil, nil, nil, nil)
+		mockUserRepo.On("ListUserOrgs", mock.Anything, int32(1)).Return([]domain.UserOrg{}, nil)
+
+		_, err := unary(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			_, _, _, _, err := svc.GetGlobalBillSplitSummary(ctx, 1)
+			return nil, err
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("Generated ID is attached to nested service logs", func(t *testing.T) {
+		buf := captureLogs(t)
+		callSummary(context.Background())
+
+		lines := logLinesFor(t, buf, "billSplitService.GetGlobalBillSplitSummary")
+		require.Len(t, lines, 2) // enter and exit
+		id, _ := lines[0]["request_id"].(string)
+		assert.Len(t, id, 32)
+		assert.Equal(t, id, lines[1]["request_id"])
+	})
+
+	t.Run("Caller-supplied ID is reused", func(t *testing.T) {
+		buf := captureLogs(t)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptor.RequestIDHeader, "client-req-42"))
+		callSummary(ctx)
+
+		lines := logLinesFor(t, buf, "billSplitService.GetGlobalBillSplitSummary")
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			assert.Equal(t, "client-req-42", line["request_id"])
+		}
+	})
+
+	t.Run("Malformed caller ID is replaced", func(t *testing.T) {
+		buf := captureLogs(t)
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(interceptor.RequestIDHeader, "bad id\nwith newline"))
+		callSummary(ctx)
+
+		lines := logLinesFor(t, buf, "billSplitService.GetGlobalBillSplitSummary")
+		require.NotEmpty(t, lines)
+		assert.Len(t, lines[0]["request_id"], 32)
+	})
+
+	t.Run("WithContext attaches the ID", func(t *testing.T) {
+		buf := captureLogs(t)
+		ctx := logger.ContextWithRequestID(context.Background(), "abc")
+		logger.WithContext(ctx).Info("hello", "method", "test.WithContext")
+
+		lines := logLinesFor(t, buf, "test.WithContext")
+		require.Len(t, lines, 1)
+		assert.Equal(t, "abc", lines[0]["request_id"])
+	})
+}
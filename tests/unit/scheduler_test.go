@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/scheduler"
+)
+
+func TestScheduler_Config(t *testing.T) {
+	t.Run("Defaults register every job in UTC", func(t *testing.T) {
+		cfg := loadTestConfig(t, "")
+		assert.Equal(t, "UTC", cfg.Scheduler.Timezone)
+		assert.Equal(t, "0 0 2 * * *", cfg.Scheduler.MarkOverdueRentals)
+
+		s, err := scheduler.NewScheduler(jobs.NewJobRunner(nil, nil, nil, cfg))
+		require.NoError(t, err)
+		assert.True(t, s.IsRunning())
+	})
+
+	t.Run("Reads expressions and timezone", func(t *testing.T) {
+		cfg := loadTestConfig(t, `
+scheduler:
+  timezone: "America/New_York"
+  mark_overdue_rentals: "@daily"
+  perform_bill_splitting: "0 0 6 1 * *"`)
+
+		assert.Equal(t, "America/New_York", cfg.Scheduler.Timezone)
+		assert.Equal(t, "@daily", cfg.Scheduler.MarkOverdueRentals)
+		assert.Equal(t, "0 0 6 1 * *", cfg.Scheduler.PerformBillSplitting)
+
+		_, err := scheduler.NewScheduler(jobs.NewJobRunner(nil, nil, nil, cfg))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid expression fails and names the job", func(t *testing.T) {
+		cfg := loadTestConfig(t, `
+scheduler:
+  resolve_disputed_bills: "0 0 25 L * *"`)
+
+		_, err := scheduler.NewScheduler(jobs.NewJobRunner(nil, nil, nil, cfg))
+		assert.ErrorContains(t, err, "ResolveDisputedBills")
+	})
+
+	t.Run("Five-field expression is rejected", func(t *testing.T) {
+		cfg := loadTestConfig(t, `
+scheduler:
+  send_bill_notices: "0 9 * * *"`)
+
+		_, err := scheduler.NewScheduler(jobs.NewJobRunner(nil, nil, nil, cfg))
+		assert.ErrorContains(t, err, "SendBillSplittingNotices")
+	})
+
+	t.Run("Unknown timezone fails to load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  port: 50051
+database:
+  host: localhost
+  user: ubertool
+  database: ubertool_db
+smtp:
+  host: mock
+  port: 587
+jwt:
+  secret: "0123456789abcdef0123456789abcdef"
+storage:
+  upload_dir: /tmp/uploads
+scheduler:
+  timezone: "Mars/Olympus_Mons"
+`), 0o600))
+
+		_, err := config.Load(path)
+		assert.ErrorContains(t, err, "invalid scheduler timezone")
+	})
+}
+
+func TestParseSchedule_LastDayOfMonth(t *testing.T) {
+	schedule, err := scheduler.ParseSchedule("0 0 23 L * *")
+	require.NoError(t, err)
+
+	next := schedule.Next(time.Date(2026, time.February, 10, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2026, time.February, 28, 23, 0, 0, 0, time.UTC), next)
+
+	next = schedule.Next(next)
+	assert.Equal(t, time.Date(2026, time.March, 31, 23, 0, 0, 0, time.UTC), next)
+
+	next = schedule.Next(time.Date(2028, time.February, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2028, time.February, 29, 23, 0, 0, 0, time.UTC), next, "leap year")
+
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	next = schedule.Next(time.Date(2026, time.April, 1, 0, 0, 0, 0, ny))
+	assert.Equal(t, time.Date(2026, time.April, 30, 23, 0, 0, 0, ny), next, "evaluated in the caller's timezone")
+}
@@ -0,0 +1,103 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"ubertool-backend-trusted/internal/server"
+)
+
+// recordingDrainer appends its name to order when drained, optionally blocking until ctx expires
+type recordingDrainer struct {
+	name  string
+	order *[]string
+	block bool
+}
+
+func (d *recordingDrainer) Shutdown(ctx context.Context) error {
+	*d.order = append(*d.order, d.name)
+	if d.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return nil
+}
+
+// startBufconnServer serves the health service on an in-memory listener
+func startBufconnServer(t *testing.T) (*grpc.Server, *health.Server, *grpc.ClientConn, <-chan error) {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	healthSrv := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthSrv)
+
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(lis) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return s, healthSrv, conn, served
+}
+
+func TestServerShutdown(t *testing.T) {
+	t.Run("Stops servers, drains in order and closes the database", func(t *testing.T) {
+		s, healthSrv, conn, served := startBufconnServer(t)
+		_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		db, dbMock, err := sqlmock.New()
+		require.NoError(t, err)
+		dbMock.ExpectClose()
+
+		var order []string
+		err = server.Shutdown(server.Components{
+			GRPC:   s,
+			Health: healthSrv,
+			Drainers: []server.NamedDrainer{
+				{Name: "fcm", Drainer: &recordingDrainer{name: "fcm", order: &order}},
+				{Name: "email", Drainer: &recordingDrainer{name: "email", order: &order}},
+			},
+			DB: db,
+		}, time.Second)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fcm", "email"}, order)
+		assert.NoError(t, <-served)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("Open streams are cancelled at the deadline", func(t *testing.T) {
+		s, _, conn, served := startBufconnServer(t)
+		// A Watch stream stays open until the client cancels, so GracefulStop alone would block
+		stream, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		var order []string
+		start := time.Now()
+		err = server.Shutdown(server.Components{
+			GRPC:     s,
+			Drainers: []server.NamedDrainer{{Name: "email", Drainer: &recordingDrainer{name: "email", order: &order, block: true}}},
+		}, 100*time.Millisecond)
+
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, time.Since(start), 2*time.Second)
+		assert.Equal(t, []string{"email"}, order, "drainers still run after the gRPC deadline")
+		<-served
+	})
+}
@@ -0,0 +1,153 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/config"
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/jobs"
+	"ubertool-backend-trusted/internal/repository/postgres"
+	"ubertool-backend-trusted/internal/service"
+)
+
+func TestBillSplitService_GetSettlementThreshold(t *testing.T) {
+	ctx := context.Background()
+	admin := &domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleAdmin}
+	opts := service.BillSplitOptions{DefaultSettlementThresholdCents: 800}
+
+	t.Run("Override", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil, opts)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(admin, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, SettlementThresholdCents: 2500}, nil).Once()
+
+		got, err := svc.GetSettlementThreshold(ctx, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, &domain.SettlementThreshold{OrgID: 1, ThresholdCents: 2500, UsesDefault: false, DefaultCents: 800}, got)
+	})
+
+	t.Run("Fallback to configured default", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil, opts)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(admin, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1}, nil).Once()
+
+		got, err := svc.GetSettlementThreshold(ctx, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int32(800), got.ThresholdCents)
+		assert.True(t, got.UsesDefault)
+	})
+
+	t.Run("Built-in default without options", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitService(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(admin, nil).Once()
+		mockOrgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1}, nil).Once()
+
+		got, err := svc.GetSettlementThreshold(ctx, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, service.DefaultSettlementThresholdCents, got.ThresholdCents)
+	})
+}
+
+func TestBillSplitService_SetSettlementThreshold(t *testing.T) {
+	ctx := context.Background()
+	superAdmin := &domain.UserOrg{UserID: 1, OrgID: 1, Role: domain.UserOrgRoleSuperAdmin}
+	opts := service.BillSplitOptions{DefaultSettlementThresholdCents: 800}
+
+	t.Run("Set override", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo, auditRepo := new(MockUserRepo), new(MockOrganizationRepo), new(MockAdminAuditRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, service.NewAdminAudit(auditRepo), opts)
+		threshold := int32(1500)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(superAdmin, nil).Once()
+		mockOrgRepo.On("SetSettlementThreshold", ctx, int32(1), &threshold).Return(nil).Once()
+		auditRepo.On("Create", ctx, mock.MatchedBy(func(e *domain.AdminAuditEntry) bool {
+			return e.Action == domain.AdminAuditActionSetThreshold && e.Details["settlement_threshold_cents"] == "1500"
+		})).Return(nil).Once()
+
+		got, err := svc.SetSettlementThreshold(ctx, 1, 1, &threshold)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1500), got.ThresholdCents)
+		assert.False(t, got.UsesDefault)
+		mockOrgRepo.AssertExpectations(t)
+		auditRepo.AssertExpectations(t)
+	})
+
+	t.Run("Clear reverts to default", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil, opts)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(superAdmin, nil).Once()
+		mockOrgRepo.On("SetSettlementThreshold", ctx, int32(1), (*int32)(nil)).Return(nil).Once()
+
+		got, err := svc.SetSettlementThreshold(ctx, 1, 1, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int32(800), got.ThresholdCents)
+		assert.True(t, got.UsesDefault)
+	})
+
+	t.Run("Rejects non-positive threshold", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil, opts)
+		zero := int32(0)
+		mockUserRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(superAdmin, nil).Once()
+
+		_, err := svc.SetSettlementThreshold(ctx, 1, 1, &zero)
+		assert.ErrorContains(t, err, "must be positive")
+		mockOrgRepo.AssertNotCalled(t, "SetSettlementThreshold", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Admin is not allowed", func(t *testing.T) {
+		mockUserRepo, mockOrgRepo := new(MockUserRepo), new(MockOrganizationRepo)
+		svc := service.NewBillSplitServiceWithOptions(nil, mockUserRepo, nil, mockOrgRepo, nil, nil, nil, opts)
+		threshold := int32(1500)
+		mockUserRepo.On("GetUserOrg", ctx, int32(2), int32(1)).
+			Return(&domain.UserOrg{UserID: 2, OrgID: 1, Role: domain.UserOrgRoleAdmin}, nil).Once()
+
+		_, err := svc.SetSettlementThreshold(ctx, 2, 1, &threshold)
+		assert.ErrorContains(t, err, "only SUPER_ADMIN")
+		mockOrgRepo.AssertNotCalled(t, "SetSettlementThreshold", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// TestPerformBillSplitting_UsesPerOrgThreshold runs the monthly job over two orgs with the same
+// balances: one overrides the threshold, the other falls back to billing.default_settlement_threshold_cents
+func TestPerformBillSplitting_UsesPerOrgThreshold(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockOrgRepo := new(MockOrganizationRepo)
+	mockOrgRepo.On("List", mock.Anything).Return([]domain.Organization{
+		{ID: 1, Name: "Override", SettlementThresholdCents: 500},
+		{ID: 2, Name: "Fallback"},
+	}, nil)
+	cfg := &config.Config{Billing: config.BillingConfig{DefaultSettlementThresholdCents: 1000}}
+	jr := jobs.NewJobRunner(db, &postgres.Store{OrganizationRepository: mockOrgRepo, BillRepository: postgres.NewBillRepository(db)}, nil, cfg)
+
+	for _, orgID := range []int32{1, 2} {
+		dbMock.ExpectBegin()
+		dbMock.ExpectExec(`INSERT INTO settlement_runs`).WithArgs(orgID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		dbMock.ExpectQuery(`SELECT EXISTS`).WithArgs(orgID, sqlmock.AnyArg()).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		dbMock.ExpectQuery(`SELECT user_id, balance_cents FROM users_orgs`).WithArgs(orgID).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "balance_cents"}).AddRow(1, -800).AddRow(2, 800))
+		if orgID == 1 {
+			// 800 is above the org's own 500 threshold, so it is billed
+			dbMock.ExpectQuery(`INSERT INTO bills`).WithArgs(orgID, 1, 2, 800, sqlmock.AnyArg()).WillReturnRows(insertedBillRows(1))
+			dbMock.ExpectExec(`UPDATE settlement_runs`).WithArgs(orgID, sqlmock.AnyArg(), 1).WillReturnResult(sqlmock.NewResult(0, 1))
+		} else {
+			// 800 is below the 1000 default, so it carries over
+			dbMock.ExpectExec(`UPDATE settlement_runs`).WithArgs(orgID, sqlmock.AnyArg(), 0).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		dbMock.ExpectCommit()
+	}
+
+	jr.PerformBillSplitting()
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
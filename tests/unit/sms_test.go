@@ -0,0 +1,220 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+)
+
+// fakeSMS records texts instead of sending them
+type fakeSMS struct {
+	mu   sync.Mutex
+	sent []sentSMS
+	err  error
+}
+
+type sentSMS struct {
+	to, body string
+}
+
+func (f *fakeSMS) Send(ctx context.Context, to, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, sentSMS{to: to, body: body})
+	return f.err
+}
+
+func (f *fakeSMS) messages() []sentSMS {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]sentSMS(nil), f.sent...)
+}
+
+func TestNotificationService_SMS(t *testing.T) {
+	ctx := context.Background()
+	optedIn := &domain.User{ID: 7, PhoneNumber: "+14155550123", NotificationPreference: domain.NotificationPreferenceEmailAndSMS}
+	emailOnly := &domain.User{ID: 8, PhoneNumber: "+14155550124", NotificationPreference: domain.NotificationPreferenceEmail}
+
+	newService := func() (service.NotificationService, *MockNotificationRepository, *MockUserRepo, *fakeSMS) {
+		noteRepo := new(MockNotificationRepository)
+		noteRepo.On("Create", ctx, mock.Anything).Return(nil)
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetByID", ctx, int32(7)).Return(optedIn, nil)
+		userRepo.On("GetByID", ctx, int32(8)).Return(emailOnly, nil)
+		sms := &fakeSMS{}
+		svc := service.NewNotificationServiceWithOptions(noteRepo, nil, service.NotificationOptions{SMS: sms, Users: userRepo})
+		return svc, noteRepo, userRepo, sms
+	}
+	approved := func(userID int32) *domain.Notification {
+		return &domain.Notification{
+			UserID: userID, OrgID: 3, Title: "Rental Approved", Message: "Your rental request for Drill by Olivia was approved",
+			Attributes: map[string]string{"type": "RENTAL_APPROVED", "rental_id": "12"},
+		}
+	}
+
+	t.Run("Opted-in users are texted high-priority notifications", func(t *testing.T) {
+		svc, _, _, sms := newService()
+
+		require.NoError(t, svc.Dispatch(ctx, approved(7)))
+		assert.Equal(t, []sentSMS{{to: "+14155550123", body: "Rental Approved: Your rental request for Drill by Olivia was approved"}}, sms.messages())
+	})
+
+	t.Run("Overdue and dispute notifications are texted too", func(t *testing.T) {
+		svc, _, _, sms := newService()
+
+		for _, typ := range []string{"RENTAL_PICKUP", "RENTAL_AUTO_ACTIVATED", "RENTAL_OVERDUE", "BILL_DISPUTE_OPENED"} {
+			require.NoError(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: typ, Attributes: map[string]string{"type": typ}}))
+		}
+		assert.Len(t, sms.messages(), 4)
+	})
+
+	t.Run("Email-only users are not texted", func(t *testing.T) {
+		svc, _, userRepo, sms := newService()
+
+		require.NoError(t, svc.Dispatch(ctx, approved(8)))
+		assert.Empty(t, sms.messages())
+		userRepo.AssertCalled(t, "GetByID", ctx, int32(8))
+	})
+
+	t.Run("Routine notifications are not texted and skip the lookup", func(t *testing.T) {
+		svc, _, userRepo, sms := newService()
+
+		require.NoError(t, svc.Dispatch(ctx, &domain.Notification{UserID: 7, OrgID: 3, Title: "Rental Request",
+			Attributes: map[string]string{"type": "RENTAL_REQUEST"}}))
+		assert.Empty(t, sms.messages())
+		userRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Silent dispatches are not texted", func(t *testing.T) {
+		svc, _, _, sms := newService()
+
+		require.NoError(t, svc.DispatchSilent(ctx, approved(7)))
+		assert.Empty(t, sms.messages())
+	})
+
+	t.Run("A failed text or lookup does not fail the dispatch", func(t *testing.T) {
+		svc, _, userRepo, sms := newService()
+		sms.err = errors.New("provider unavailable")
+		userRepo.On("GetByID", ctx, int32(9)).Return(nil, errors.New("connection reset"))
+
+		assert.NoError(t, svc.Dispatch(ctx, approved(7)))
+		assert.NoError(t, svc.Dispatch(ctx, approved(9)))
+		assert.Len(t, sms.messages(), 1)
+	})
+}
+
+func TestTwilioSMSService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Posts the message with account credentials", func(t *testing.T) {
+		var gotPath, gotUser, gotPass string
+		var gotForm map[string][]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotUser, gotPass, _ = r.BasicAuth()
+			require.NoError(t, r.ParseForm())
+			gotForm = r.PostForm
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+		}))
+		defer srv.Close()
+
+		sms := service.NewTwilioSMSService("AC123", "secret", "+14085550199", service.TwilioOptions{BaseURL: srv.URL})
+		require.NoError(t, sms.Send(ctx, "+14155550123", "Rental Approved: Drill"))
+
+		assert.Equal(t, "/Accounts/AC123/Messages.json", gotPath)
+		assert.Equal(t, "AC123", gotUser)
+		assert.Equal(t, "secret", gotPass)
+		assert.Equal(t, []string{"+14155550123"}, gotForm["To"])
+		assert.Equal(t, []string{"+14085550199"}, gotForm["From"])
+		assert.Equal(t, []string{"Rental Approved: Drill"}, gotForm["Body"])
+	})
+
+	t.Run("Rejections carry Twilio's explanation", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number."}`))
+		}))
+		defer srv.Close()
+
+		sms := service.NewTwilioSMSService("AC123", "secret", "+14085550199", service.TwilioOptions{BaseURL: srv.URL})
+		err := sms.Send(ctx, "+10000000000", "Hello")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "21211")
+		assert.Contains(t, err.Error(), "not a valid phone number")
+	})
+}
+
+func TestNewSMSProvider(t *testing.T) {
+	noop, err := service.NewSMSProvider("", "", "", "", service.TwilioOptions{})
+	require.NoError(t, err)
+	assert.NoError(t, noop.Send(context.Background(), "+14155550123", "Hello"))
+
+	_, err = service.NewSMSProvider("carrier-pigeon", "", "", "", service.TwilioOptions{})
+	assert.Error(t, err)
+}
+
+func TestAsyncSMSService(t *testing.T) {
+	provider := &fakeSMS{}
+	sms := service.NewAsyncSMSService(provider)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, sms.Send(ctx, "+14155550123", "Rental Overdue: Drill"))
+	// Sends outlive the request that started them
+	cancel()
+
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), time.Second)
+	defer drainCancel()
+	require.NoError(t, sms.Shutdown(drainCtx))
+	assert.Equal(t, []sentSMS{{to: "+14155550123", body: "Rental Overdue: Drill"}}, provider.messages())
+}
+
+func TestUserService_UpdateNotificationPreference(t *testing.T) {
+	ctx := context.Background()
+	newSvc := func(phone string) (service.UserService, *MockUserRepo) {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetByID", ctx, int32(1)).Return(&domain.User{ID: 1, PhoneNumber: phone}, nil)
+		return service.NewUserService(userRepo, nil, nil, nil, nil, nil), userRepo
+	}
+
+	t.Run("Opting in to SMS", func(t *testing.T) {
+		svc, userRepo := newSvc("+14155550123")
+		userRepo.On("UpdateNotificationPreference", ctx, int32(1), domain.NotificationPreferenceEmailAndSMS).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateNotificationPreference(ctx, 1, domain.NotificationPreferenceEmailAndSMS))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Opting in needs a phone number", func(t *testing.T) {
+		svc, userRepo := newSvc("")
+
+		err := svc.UpdateNotificationPreference(ctx, 1, domain.NotificationPreferenceEmailAndSMS)
+		assert.ErrorIs(t, err, domain.ErrValidation)
+		userRepo.AssertNotCalled(t, "UpdateNotificationPreference", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Opting out works without a phone number", func(t *testing.T) {
+		svc, userRepo := newSvc("")
+		userRepo.On("UpdateNotificationPreference", ctx, int32(1), domain.NotificationPreferenceEmail).Return(nil).Once()
+
+		assert.NoError(t, svc.UpdateNotificationPreference(ctx, 1, domain.NotificationPreferenceEmail))
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown preferences are rejected", func(t *testing.T) {
+		svc, _ := newSvc("+14155550123")
+
+		assert.ErrorIs(t, svc.UpdateNotificationPreference(ctx, 1, "PIGEON"), domain.ErrValidation)
+	})
+}
@@ -0,0 +1,149 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"ubertool-backend-trusted/internal/security"
+)
+
+const (
+	accessSecret  = "access-secret-0123456789abcdef0123"
+	refreshSecret = "refresh-secret-0123456789abcdef012"
+)
+
+func TestTokenManager_Expiry(t *testing.T) {
+	t.Run("Configured lifetimes are applied", func(t *testing.T) {
+		tm := security.NewTokenManagerWithConfig(security.TokenConfig{
+			AccessSecret: accessSecret, AccessTTL: 15 * time.Minute, RefreshTTL: 48 * time.Hour, TwoFATTL: 3 * time.Minute,
+		})
+		check := func(token string, err error, ttl time.Duration) {
+			require.NoError(t, err)
+			claims, err := tm.ValidateToken(token)
+			require.NoError(t, err)
+			assert.Equal(t, ttl, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+		}
+
+		token, err := tm.GenerateAccessToken(1, "a@example.com", nil, nil)
+		check(token, err, 15*time.Minute)
+		token, err = tm.GenerateRefreshToken(1, "a@example.com", 0)
+		check(token, err, 48*time.Hour)
+		token, err = tm.Generate2FAToken(1, "email", false)
+		check(token, err, 3*time.Minute)
+	})
+
+	t.Run("Unset lifetimes fall back to the defaults", func(t *testing.T) {
+		tm := security.NewTokenManager(accessSecret)
+		token, err := tm.GenerateAccessToken(1, "a@example.com", nil, nil)
+		require.NoError(t, err)
+		claims, err := tm.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, security.DefaultAccessTokenTTL, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+	})
+
+	t.Run("Expired token is rejected", func(t *testing.T) {
+		tm := security.NewTokenManager(accessSecret)
+		// Sign with the manager's own key so only the expiry can fail
+		expired := jwt.NewWithClaims(jwt.SigningMethodHS256, security.UserClaims{
+			UserID: 1,
+			Type:   security.TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				IssuedAt:  jwt.NewNumericDate(time.Now().Add(-2 * time.Hour)),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour)),
+			},
+		})
+		token, err := expired.SignedString([]byte(accessSecret))
+		require.NoError(t, err)
+
+		_, err = tm.ValidateToken(token)
+		assert.ErrorIs(t, err, security.ErrExpiredToken)
+	})
+}
+
+func TestTokenManager_KeySeparation(t *testing.T) {
+	tm := security.NewTokenManagerWithConfig(security.TokenConfig{AccessSecret: accessSecret, RefreshSecret: refreshSecret})
+
+	t.Run("Each type validates with its own key", func(t *testing.T) {
+		access, err := tm.GenerateAccessToken(1, "a@example.com", nil, nil)
+		require.NoError(t, err)
+		refresh, err := tm.GenerateRefreshToken(1, "a@example.com", 0)
+		require.NoError(t, err)
+
+		claims, err := tm.ValidateToken(access)
+		require.NoError(t, err)
+		assert.Equal(t, security.TokenTypeAccess, claims.Type)
+		claims, err = tm.ValidateToken(refresh)
+		require.NoError(t, err)
+		assert.Equal(t, security.TokenTypeRefresh, claims.Type)
+	})
+
+	t.Run("Refresh token signed with the access key is rejected", func(t *testing.T) {
+		// What a holder of only the access key could forge
+		forged, err := security.NewTokenManager(accessSecret).GenerateRefreshToken(1, "a@example.com", 0)
+		require.NoError(t, err)
+
+		_, err = tm.ValidateToken(forged)
+		assert.ErrorIs(t, err, security.ErrInvalidToken)
+	})
+
+	t.Run("Access token signed with the refresh key is rejected", func(t *testing.T) {
+		forged, err := security.NewTokenManager(refreshSecret).GenerateAccessToken(1, "a@example.com", nil, nil)
+		require.NoError(t, err)
+
+		_, err = tm.ValidateToken(forged)
+		assert.ErrorIs(t, err, security.ErrInvalidToken)
+	})
+
+	t.Run("A single secret signs both types", func(t *testing.T) {
+		single := security.NewTokenManager(accessSecret)
+		refresh, err := single.GenerateRefreshToken(1, "a@example.com", 0)
+		require.NoError(t, err)
+
+		_, err = single.ValidateToken(refresh)
+		assert.NoError(t, err)
+		// Refresh tokens issued before the split keep working if refresh_secret is set to the old key
+		_, err = security.NewTokenManagerWithConfig(security.TokenConfig{AccessSecret: refreshSecret, RefreshSecret: accessSecret}).ValidateToken(refresh)
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserClaims_OrgMembership(t *testing.T) {
+	tm := security.NewTokenManagerWithConfig(security.TokenConfig{AccessSecret: accessSecret, OrgClaimsTTL: time.Minute})
+	token, err := tm.GenerateAccessToken(1, "a@example.com", nil, []security.OrgMembership{
+		{OrgID: 4, Role: "ADMIN"}, {OrgID: 6, Role: "MEMBER"},
+	})
+	require.NoError(t, err)
+	claims, err := tm.ValidateToken(token)
+	require.NoError(t, err)
+
+	t.Run("Listed orgs are answered from the snapshot", func(t *testing.T) {
+		require.NotNil(t, claims.OrgsExpiresAt)
+		assert.Equal(t, time.Minute, claims.OrgsExpiresAt.Sub(claims.IssuedAt.Time))
+		assert.True(t, claims.IsMemberOf(4))
+		role, ok := claims.RoleIn(6)
+		assert.True(t, ok)
+		assert.Equal(t, "MEMBER", role)
+		assert.False(t, claims.IsMemberOf(5))
+	})
+
+	t.Run("Snapshot past its TTL vouches for nothing", func(t *testing.T) {
+		stale := *claims
+		stale.OrgsExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Second))
+		assert.False(t, stale.IsMemberOf(4))
+		_, ok := stale.RoleIn(4)
+		assert.False(t, ok)
+	})
+
+	t.Run("Tokens without a snapshot vouch for nothing", func(t *testing.T) {
+		old, err := tm.GenerateAccessToken(1, "a@example.com", nil, nil)
+		require.NoError(t, err)
+		claims, err := tm.ValidateToken(old)
+		require.NoError(t, err)
+		assert.Nil(t, claims.OrgsExpiresAt)
+		assert.False(t, claims.IsMemberOf(4))
+	})
+}
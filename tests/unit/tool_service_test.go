@@ -2,12 +2,14 @@ package unit
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"ubertool-backend-trusted/internal/domain"
 	"ubertool-backend-trusted/internal/service"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestToolService_AddTool(t *testing.T) {
@@ -26,6 +28,54 @@ func TestToolService_AddTool(t *testing.T) {
 	})
 }
 
+func TestToolService_Categories(t *testing.T) {
+	ctx := context.Background()
+	curated := []string{"Hand Tools", "Power Tools", "Gardening"}
+
+	t.Run("Variants are stored with the curated spelling", func(t *testing.T) {
+		repo := new(MockToolRepo)
+		svc := service.NewToolService(repo, nil, nil)
+		repo.On("ListCategories", ctx).Return(curated, nil).Once()
+		tool := &domain.Tool{Name: "Drill", Categories: []string{"power-tools", "POWER TOOLS", "gardening"}}
+		repo.On("Create", ctx, tool).Return(nil).Once()
+
+		assert.NoError(t, svc.AddTool(ctx, tool, nil))
+		assert.Equal(t, []string{"Power Tools", "Gardening"}, tool.Categories)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Unknown category is rejected on create", func(t *testing.T) {
+		repo := new(MockToolRepo)
+		svc := service.NewToolService(repo, nil, nil)
+		repo.On("ListCategories", ctx).Return(curated, nil).Once()
+
+		err := svc.AddTool(ctx, &domain.Tool{Name: "Drill", Categories: []string{"Power Tools", "powertool"}}, nil)
+		assert.ErrorIs(t, err, service.ErrUnknownCategory)
+		assert.ErrorContains(t, err, `"powertool"`)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Unknown category is rejected on update", func(t *testing.T) {
+		repo := new(MockToolRepo)
+		svc := service.NewToolService(repo, nil, nil)
+		repo.On("ListCategories", ctx).Return(curated, nil).Once()
+
+		err := svc.UpdateTool(ctx, &domain.Tool{ID: 1, Categories: []string{"Woodworking"}})
+		assert.ErrorIs(t, err, service.ErrUnknownCategory)
+		repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ListCategories returns the curated list", func(t *testing.T) {
+		repo := new(MockToolRepo)
+		svc := service.NewToolService(repo, nil, nil)
+		repo.On("ListCategories", ctx).Return(curated, nil).Once()
+
+		got, err := svc.ListCategories(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, curated, got)
+	})
+}
+
 func TestToolService_SearchTools(t *testing.T) {
 	repo := new(MockToolRepo)
 	userRepo := new(MockUserRepo) // Need user repo now
@@ -35,7 +85,7 @@ func TestToolService_SearchTools(t *testing.T) {
 
 	t.Run("Success", func(t *testing.T) {
 		tools := []domain.Tool{{ID: 1, OwnerID: 5, Name: "Hammer"}}
-		repo.On("Search", ctx, int32(1), "San Jose", "query", []string{"cat"}, int32(100), "NOT_DAMAGED", int32(1), int32(10)).
+		repo.On("Search", ctx, int32(1), []string{"San Jose"}, "query", []string{"cat"}, int32(100), "NOT_DAMAGED", int32(1), int32(10)).
 			Return(tools, int32(1), nil)
 
 		// Mock GetUserOrg logic if orgID != 0
@@ -49,9 +99,9 @@ func TestToolService_SearchTools(t *testing.T) {
 		userRepo.On("GetByID", ctx, int32(5)).Return(owner, nil)
 		userRepo.On("ListUserOrgs", ctx, int32(5)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
 		userRepo.On("ListUserOrgs", ctx, int32(1)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
-		orgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Test Org"}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{1}).Return([]domain.Organization{{ID: 1, Name: "Test Org"}}, nil)
 
-		res, total, err := svc.SearchTools(ctx, 1, 1, "", "query", []string{"cat"}, 100, "", 1, 10)
+		res, total, err := svc.SearchTools(ctx, 1, 1, "", false, "query", []string{"cat"}, 100, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(1), total)
 		assert.Equal(t, "Hammer", res[0].Name)
@@ -62,7 +112,7 @@ func TestToolService_SearchTools(t *testing.T) {
 	t.Run("SharedOrgFiltering_ReturnsToolWhenUsersShareOrg", func(t *testing.T) {
 		// Scenario: Tool 141 owned by user 1, requesting user 301, both in org 1
 		tools := []domain.Tool{{ID: 141, OwnerID: 1, Name: "Shared Tool"}}
-		repo.On("Search", ctx, int32(301), "San Francisco", "st", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+		repo.On("Search", ctx, int32(301), []string{"San Francisco"}, "st", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
 			Return(tools, int32(1), nil)
 
 		// Mock owner population - both users in org 1
@@ -70,9 +120,9 @@ func TestToolService_SearchTools(t *testing.T) {
 		userRepo.On("GetByID", ctx, int32(1)).Return(owner, nil)
 		userRepo.On("ListUserOrgs", ctx, int32(1)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
 		userRepo.On("ListUserOrgs", ctx, int32(301)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
-		orgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Shared Org", Metro: "San Francisco"}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{1}).Return([]domain.Organization{{ID: 1, Name: "Shared Org", Metro: "San Francisco"}}, nil)
 
-		res, total, err := svc.SearchTools(ctx, 301, 0, "San Francisco", "st", nil, 0, "", 1, 10)
+		res, total, err := svc.SearchTools(ctx, 301, 0, "San Francisco", false, "st", nil, 0, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(1), total, "Should return 1 tool since users share org")
 		assert.Len(t, res, 1)
@@ -92,7 +142,7 @@ func TestToolService_SearchTools(t *testing.T) {
 
 		// Scenario: Tool owned by user 5 in org 2, requesting user 301 in org 3 - no shared org
 		tools := []domain.Tool{{ID: 200, OwnerID: 5, Name: "Different Org Tool"}}
-		repo2.On("Search", ctx, int32(301), "San Francisco", "tool", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+		repo2.On("Search", ctx, int32(301), []string{"San Francisco"}, "tool", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
 			Return(tools, int32(1), nil)
 
 		// Mock owner population - different orgs (no overlap)
@@ -101,13 +151,55 @@ func TestToolService_SearchTools(t *testing.T) {
 		userRepo2.On("ListUserOrgs", ctx, int32(5)).Return([]domain.UserOrg{{OrgID: 2}}, nil)   // Owner in org 2
 		userRepo2.On("ListUserOrgs", ctx, int32(301)).Return([]domain.UserOrg{{OrgID: 3}}, nil) // Requesting user in org 3 (different!)
 
-		res, total, err := svc2.SearchTools(ctx, 301, 0, "San Francisco", "tool", nil, 0, "", 1, 10)
+		res, total, err := svc2.SearchTools(ctx, 301, 0, "San Francisco", false, "tool", nil, 0, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(0), total, "Should return 0 tools since users don't share org")
 		assert.Len(t, res, 0, "Tool should be filtered out")
 	})
 }
 
+func TestToolService_SearchTools_AdjacentMetros(t *testing.T) {
+	ctx := context.Background()
+	org := &domain.Organization{ID: 1, Name: "Test Org", Metro: "San Jose", AdjacentMetros: []string{"Santa Clara", "Milpitas"}}
+
+	newSvc := func() (service.ToolService, *MockToolRepo) {
+		repo, userRepo, orgRepo := new(MockToolRepo), new(MockUserRepo), new(MockOrganizationRepo)
+		userRepo.On("GetUserOrg", ctx, int32(1), int32(1)).Return(&domain.UserOrg{}, nil)
+		orgRepo.On("GetByID", ctx, int32(1)).Return(org, nil)
+		return service.NewToolService(repo, userRepo, orgRepo), repo
+	}
+
+	t.Run("Defaults to the org's metro", func(t *testing.T) {
+		svc, repo := newSvc()
+		repo.On("Search", ctx, int32(1), []string{"San Jose"}, "drill", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+			Return([]domain.Tool{}, int32(0), nil).Once()
+
+		_, _, err := svc.SearchTools(ctx, 1, 1, "", false, "drill", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Opt-in adds the org's adjacent metros", func(t *testing.T) {
+		svc, repo := newSvc()
+		repo.On("Search", ctx, int32(1), []string{"San Jose", "Santa Clara", "Milpitas"}, "drill", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+			Return([]domain.Tool{}, int32(0), nil).Once()
+
+		_, _, err := svc.SearchTools(ctx, 1, 1, "", true, "drill", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("Ignored without an org", func(t *testing.T) {
+		svc, repo := newSvc()
+		repo.On("Search", ctx, int32(1), []string{"Austin"}, "drill", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+			Return([]domain.Tool{}, int32(0), nil).Once()
+
+		_, _, err := svc.SearchTools(ctx, 1, 0, "Austin", true, "drill", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
+
 func TestToolService_GetSharedOrganizations(t *testing.T) {
 	ctx := context.Background()
 
@@ -120,7 +212,7 @@ func TestToolService_GetSharedOrganizations(t *testing.T) {
 		// Setup: User 1 and User 301 both in org 1
 		userRepo.On("ListUserOrgs", ctx, int32(1)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
 		userRepo.On("ListUserOrgs", ctx, int32(301)).Return([]domain.UserOrg{{OrgID: 1}}, nil)
-		orgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Shared Org"}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{1}).Return([]domain.Organization{{ID: 1, Name: "Shared Org"}}, nil)
 
 		// This is a workaround to test the private method getSharedOrganizations
 		// We test it indirectly through populateToolOwner
@@ -130,10 +222,10 @@ func TestToolService_GetSharedOrganizations(t *testing.T) {
 
 		// Access the unexported method via reflection or test through SearchTools
 		// For now, we'll create a minimal SearchTools scenario
-		toolRepo.On("Search", ctx, int32(301), "San Diego, CA", "test", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+		toolRepo.On("Search", ctx, int32(301), []string{"San Diego, CA"}, "test", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
 			Return([]domain.Tool{*tool}, int32(1), nil)
 
-		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", "test", nil, 0, "", 1, 10)
+		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", false, "test", nil, 0, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(1), total)
 		assert.NotNil(t, res[0].Owner)
@@ -156,10 +248,10 @@ func TestToolService_GetSharedOrganizations(t *testing.T) {
 		owner := &domain.User{ID: 5, Name: "Owner 5"}
 		userRepo.On("GetByID", ctx, int32(5)).Return(owner, nil)
 
-		toolRepo.On("Search", ctx, int32(301), "San Diego, CA", "test", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+		toolRepo.On("Search", ctx, int32(301), []string{"San Diego, CA"}, "test", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
 			Return([]domain.Tool{*tool}, int32(1), nil)
 
-		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", "test", nil, 0, "", 1, 10)
+		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", false, "test", nil, 0, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(0), total, "Tool should be filtered out")
 		assert.Len(t, res, 0, "No tools should be returned")
@@ -182,20 +274,80 @@ func TestToolService_GetSharedOrganizations(t *testing.T) {
 			{OrgID: 2},
 			{OrgID: 4},
 		}, nil)
-		orgRepo.On("GetByID", ctx, int32(2)).Return(&domain.Organization{ID: 2, Name: "Shared Org 2"}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{2}).Return([]domain.Organization{{ID: 2, Name: "Shared Org 2"}}, nil)
 
 		tool := &domain.Tool{ID: 300, OwnerID: 10, Name: "Multi Org Tool"}
 		owner := &domain.User{ID: 10, Name: "Owner 10"}
 		userRepo.On("GetByID", ctx, int32(10)).Return(owner, nil)
 
-		toolRepo.On("Search", ctx, int32(301), "San Diego, CA", "multi", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+		toolRepo.On("Search", ctx, int32(301), []string{"San Diego, CA"}, "multi", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
 			Return([]domain.Tool{*tool}, int32(1), nil)
 
-		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", "multi", nil, 0, "", 1, 10)
+		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", false, "multi", nil, 0, "", 1, 10)
 		assert.NoError(t, err)
 		assert.Equal(t, int32(1), total)
 		assert.NotNil(t, res[0].Owner)
 		assert.Len(t, res[0].Owner.Orgs, 1, "Should have exactly 1 shared org")
 		assert.Equal(t, int32(2), res[0].Owner.Orgs[0].ID, "Should return org 2 only")
 	})
+
+	t.Run("SkipsOrgsThatFailToLoad", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		toolRepo := new(MockToolRepo)
+		svc := service.NewToolService(toolRepo, userRepo, orgRepo)
+
+		// Owner and requester share orgs 1 and 2; the batch lookup fails and org 2 cannot be loaded
+		userRepo.On("ListUserOrgs", ctx, int32(10)).Return([]domain.UserOrg{{OrgID: 1}, {OrgID: 2}}, nil)
+		userRepo.On("ListUserOrgs", ctx, int32(301)).Return([]domain.UserOrg{{OrgID: 1}, {OrgID: 2}}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{1, 2}).Return([]domain.Organization(nil), errors.New("scan error"))
+		orgRepo.On("GetByID", ctx, int32(1)).Return(&domain.Organization{ID: 1, Name: "Shared Org 1"}, nil)
+		orgRepo.On("GetByID", ctx, int32(2)).Return(nil, errors.New("scan error"))
+
+		tool := &domain.Tool{ID: 300, OwnerID: 10, Name: "Multi Org Tool"}
+		userRepo.On("GetByID", ctx, int32(10)).Return(&domain.User{ID: 10, Name: "Owner 10"}, nil)
+		toolRepo.On("Search", ctx, int32(301), []string{"San Diego, CA"}, "multi", []string(nil), int32(0), "NOT_DAMAGED", int32(1), int32(10)).
+			Return([]domain.Tool{*tool}, int32(1), nil)
+
+		res, total, err := svc.SearchTools(ctx, 301, 0, "San Diego, CA", false, "multi", nil, 0, "", 1, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), total)
+		if assert.Len(t, res[0].Owner.Orgs, 1) {
+			assert.Equal(t, int32(1), res[0].Owner.Orgs[0].ID)
+		}
+	})
+}
+
+func TestToolService_GetTool(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ReturnsImagesAndOwnerWithSharedOrgs", func(t *testing.T) {
+		toolRepo := new(MockToolRepo)
+		userRepo := new(MockUserRepo)
+		orgRepo := new(MockOrganizationRepo)
+		svc := service.NewToolService(toolRepo, userRepo, orgRepo)
+
+		images := []domain.ToolImage{
+			{ID: 11, ToolID: 7, IsPrimary: true, DisplayOrder: 2},
+			{ID: 12, ToolID: 7, DisplayOrder: 0},
+			{ID: 13, ToolID: 7, DisplayOrder: 1},
+		}
+		toolRepo.On("GetByID", ctx, int32(7)).Return(&domain.Tool{ID: 7, OwnerID: 5, Name: "Drill"}, nil)
+		toolRepo.On("GetImages", ctx, int32(7)).Return(images, nil)
+		userRepo.On("GetByID", ctx, int32(5)).Return(&domain.User{ID: 5, Name: "Owner"}, nil)
+		userRepo.On("ListUserOrgs", ctx, int32(5)).Return([]domain.UserOrg{{OrgID: 1}, {OrgID: 2}, {OrgID: 3}}, nil)
+		userRepo.On("ListUserOrgs", ctx, int32(9)).Return([]domain.UserOrg{{OrgID: 1}, {OrgID: 3}}, nil)
+		orgRepo.On("GetByIDs", ctx, []int32{1, 3}).
+			Return([]domain.Organization{{ID: 1, Name: "Org 1"}, {ID: 3, Name: "Org 3"}}, nil).Once()
+
+		tool, gotImages, err := svc.GetTool(ctx, 7, 9)
+		assert.NoError(t, err)
+		assert.Equal(t, images, gotImages)
+		if assert.NotNil(t, tool.Owner) {
+			assert.Equal(t, "Owner", tool.Owner.Name)
+			assert.Len(t, tool.Owner.Orgs, 2)
+		}
+		orgRepo.AssertNumberOfCalls(t, "GetByIDs", 1)
+		orgRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+	})
 }
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"ubertool-backend-trusted/internal/domain"
+	"ubertool-backend-trusted/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserService_ExportMyData(t *testing.T) {
+	userRepo := new(MockUserRepo)
+	orgRepo := new(MockOrganizationRepo)
+	rentalRepo := new(MockRentalRepo)
+	billRepo := new(MockBillRepo)
+	ledgerRepo := new(MockLedgerRepo)
+	noteRepo := new(MockNotificationRepository)
+	svc := service.NewUserService(userRepo, orgRepo, rentalRepo, billRepo, ledgerRepo, noteRepo)
+
+	ctx := context.Background()
+	userID, otherID, orgID := int32(1), int32(2), int32(10)
+	rentalID := int32(100)
+
+	userRepo.On("GetByID", ctx, userID).Return(&domain.User{
+		ID: userID, Email: "me@example.com", PhoneNumber: "555-0001", Name: "Me", PasswordHash: "hash",
+	}, nil)
+	userRepo.On("GetByID", ctx, otherID).Return(&domain.User{
+		ID: otherID, Email: "owner@example.com", PhoneNumber: "555-0002", Name: "Owner", PasswordHash: "other-hash",
+	}, nil)
+	userRepo.On("ListUserOrgs", ctx, userID).Return([]domain.UserOrg{
+		{UserID: userID, OrgID: orgID, Role: domain.UserOrgRoleMember, Status: domain.UserOrgStatusActive, BalanceCents: -500},
+	}, nil)
+
+	rental := domain.Rental{ID: rentalID, OrgID: orgID, ToolID: 5, RenterID: userID, OwnerID: otherID, Status: domain.RentalStatusCompleted}
+	rentalRepo.On("ListByRenter", ctx, userID, orgID, []string(nil), int32(1), mock.Anything).Return([]domain.Rental{rental}, int32(1), nil)
+	rentalRepo.On("ListByOwner", ctx, userID, orgID, []string(nil), int32(1), mock.Anything).Return([]domain.Rental{}, int32(0), nil)
+
+	bill := domain.Bill{ID: 7, OrgID: orgID, DebtorUserID: userID, CreditorUserID: otherID, AmountCents: 500, Status: domain.BillStatusPending}
+	billRepo.On("ListByUser", ctx, userID, orgID, []domain.BillStatus(nil), int32(0), int32(0)).Return([]domain.Bill{bill}, int32(1), nil)
+
+	ledgerRepo.On("ListTransactions", ctx, userID, orgID, int32(1), mock.Anything).Return([]domain.LedgerTransaction{
+		{ID: 3, OrgID: orgID, UserID: userID, Amount: -500, Type: domain.TransactionTypeRentalDebit, RelatedRentalID: &rentalID},
+	}, int32(1), nil)
+	noteRepo.On("List", ctx, userID, "", mock.Anything, int32(0)).Return([]domain.Notification{
+		{ID: 9, UserID: userID, OrgID: orgID, Title: "Rental completed"},
+	}, int32(1), nil)
+
+	data, err := svc.ExportMyData(ctx, userID)
+	require.NoError(t, err)
+
+	var export domain.UserDataExport
+	require.NoError(t, json.Unmarshal(data, &export))
+	assert.Equal(t, "me@example.com", export.Profile.Email)
+	assert.Len(t, export.Memberships, 1)
+	if assert.Len(t, export.Rentals, 1) {
+		assert.Equal(t, rentalID, export.Rentals[0].ID)
+	}
+	if assert.Len(t, export.Bills, 1) {
+		assert.Equal(t, int32(7), export.Bills[0].ID)
+	}
+	assert.Len(t, export.Transactions, 1)
+	assert.Len(t, export.Notifications, 1)
+	assert.Equal(t, []domain.ExportCounterparty{{ID: otherID, Name: "Owner"}}, export.Counterparties)
+
+	// No password hashes and nothing private about the counterparty
+	raw := string(data)
+	assert.NotContains(t, raw, "hash")
+	assert.NotContains(t, raw, "owner@example.com")
+	assert.NotContains(t, raw, "555-0002")
+}
+
+func TestUserService_GetUsersByIDs(t *testing.T) {
+	userRepo := new(MockUserRepo)
+	svc := service.NewUserService(userRepo, new(MockOrganizationRepo), new(MockRentalRepo), new(MockBillRepo), new(MockLedgerRepo), new(MockNotificationRepository))
+	ctx := context.Background()
+
+	t.Run("Duplicate IDs are queried once", func(t *testing.T) {
+		userRepo.On("GetByIDs", ctx, []int32{1, 2, 3}).Return([]domain.User{
+			{ID: 1, Name: "Alice"},
+			{ID: 3, Name: "Carol"},
+		}, nil).Once()
+
+		users, err := svc.GetUsersByIDs(ctx, []int32{1, 2, 1, 3, 2})
+		require.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.Equal(t, "Alice", users[1].Name)
+		assert.Equal(t, "Carol", users[3].Name)
+		assert.NotContains(t, users, int32(2))
+		userRepo.AssertExpectations(t)
+	})
+}